@@ -0,0 +1,722 @@
+package fluentforward
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/insertutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/writeconcurrencylimiter"
+)
+
+var (
+	listenAddr = flagutil.NewArrayString("fluentforward.listenAddr", "Comma-separated list of TCP addresses to listen to for logs sent via Fluent Forward protocol "+
+		"by Fluent Bit and Fluentd. See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/")
+
+	streamFields = flagutil.NewArrayString("fluentforward.streamFields", "Fields to use as log stream labels for logs ingested via the corresponding -fluentforward.listenAddr. "+
+		`By default the "tag" field is used as log stream label. See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#stream-fields`)
+	msgFields = flagutil.NewArrayString("fluentforward.msgFields", "Fields to use as log message for logs ingested via the corresponding -fluentforward.listenAddr. "+
+		`By default the "message" or "log" field is used as log message. See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#message-field`)
+	ignoreFields = flagutil.NewArrayString("fluentforward.ignoreFields", "Fields to ignore at logs ingested via the corresponding -fluentforward.listenAddr. "+
+		`See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#dropping-fields`)
+	extraFields = flagutil.NewArrayString("fluentforward.extraFields", "Fields to add to logs ingested via the corresponding -fluentforward.listenAddr. "+
+		`See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#adding-extra-fields`)
+	tenantID = flagutil.NewArrayString("fluentforward.tenantID", "TenantID for logs ingested via the corresponding -fluentforward.listenAddr. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#multitenancy")
+
+	maxMessageSize = flagutil.NewBytes("fluentforward.maxMessageSize", 64*1024*1024, "The maximum size of a single message received via -fluentforward.listenAddr. "+
+		"Fluent Bit and Fluentd send logs in chunks, so this limit must exceed the maximum chunk size")
+
+	tlsEnable = flagutil.NewArrayBool("fluentforward.tls", "Whether to enable TLS for receiving logs at the corresponding -fluentforward.listenAddr. "+
+		"The corresponding -fluentforward.tlsCertFile and -fluentforward.tlsKeyFile must be set if -fluentforward.tls is set. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#security")
+	tlsCertFile = flagutil.NewArrayString("fluentforward.tlsCertFile", "Path to file with TLS certificate for the corresponding -fluentforward.listenAddr if the corresponding -fluentforward.tls is set. "+
+		"Prefer ECDSA certs instead of RSA certs as RSA certs are slower. The provided certificate file is automatically re-read every second, so it can be dynamically updated. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#security")
+	tlsKeyFile = flagutil.NewArrayString("fluentforward.tlsKeyFile", "Path to file with TLS key for the corresponding -fluentforward.listenAddr if the corresponding -fluentforward.tls is set. "+
+		"The provided key file is automatically re-read every second, so it can be dynamically updated. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#security")
+	tlsCipherSuites = flagutil.NewArrayString("fluentforward.tlsCipherSuites", "Optional list of TLS cipher suites for -fluentforward.listenAddr if -fluentforward.tls is set. "+
+		"See the list of supported cipher suites at https://pkg.go.dev/crypto/tls#pkg-constants . "+
+		"See also https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#security")
+	tlsMinVersion = flag.String("fluentforward.tlsMinVersion", "TLS13", "The minimum TLS version to use for -fluentforward.listenAddr if -fluentforward.tls is set. "+
+		"Supported values: TLS10, TLS11, TLS12, TLS13. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/fluentforward/#security")
+)
+
+// MustInit initializes Fluent Forward protocol receiver at the given -fluentforward.listenAddr ports.
+//
+// This function must be called after flag.Parse().
+//
+// MustStop() must be called in order to free up resources occupied by the initialized receiver.
+func MustInit() {
+	if workersStopCh != nil {
+		logger.Panicf("BUG: MustInit() called twice without MustStop() call")
+	}
+	workersStopCh = make(chan struct{})
+
+	for argIdx, addr := range *listenAddr {
+		workersWG.Add(1)
+		go func(addr string, argIdx int) {
+			runTCPListener(addr, argIdx)
+			workersWG.Done()
+		}(addr, argIdx)
+	}
+}
+
+var (
+	workersWG     sync.WaitGroup
+	workersStopCh chan struct{}
+)
+
+// MustStop stops Fluent Forward protocol receiver initialized via MustInit()
+func MustStop() {
+	close(workersStopCh)
+	workersWG.Wait()
+	workersStopCh = nil
+}
+
+func runTCPListener(addr string, argIdx int) {
+	var tlsConfig *tls.Config
+	if tlsEnable.GetOptionalArg(argIdx) {
+		certFile := tlsCertFile.GetOptionalArg(argIdx)
+		keyFile := tlsKeyFile.GetOptionalArg(argIdx)
+		tc, err := netutil.GetServerTLSConfig(certFile, keyFile, *tlsMinVersion, *tlsCipherSuites)
+		if err != nil {
+			logger.Fatalf("cannot load TLS cert from -fluentforward.tlsCertFile=%q, -fluentforward.tlsKeyFile=%q, -fluentforward.tlsMinVersion=%q, -fluentforward.tlsCipherSuites=%q: %s",
+				certFile, keyFile, *tlsMinVersion, *tlsCipherSuites, err)
+		}
+		tlsConfig = tc
+	}
+	ln, err := netutil.NewTCPListener("fluentforward", addr, false, tlsConfig)
+	if err != nil {
+		logger.Fatalf("fluentforward: cannot start TCP listener at %s: %s", addr, err)
+	}
+
+	tenantIDStr := tenantID.GetOptionalArg(argIdx)
+	tid, err := logstorage.ParseTenantID(tenantIDStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -fluentforward.tenantID=%q for -fluentforward.listenAddr=%q: %s", tenantIDStr, addr, err)
+	}
+
+	streamFieldsStr := streamFields.GetOptionalArg(argIdx)
+	sfs, err := parseFieldsList(streamFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -fluentforward.streamFields=%q for -fluentforward.listenAddr=%q: %s", streamFieldsStr, addr, err)
+	}
+
+	msgFieldsStr := msgFields.GetOptionalArg(argIdx)
+	mfs, err := parseFieldsList(msgFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -fluentforward.msgFields=%q for -fluentforward.listenAddr=%q: %s", msgFieldsStr, addr, err)
+	}
+
+	ignoreFieldsStr := ignoreFields.GetOptionalArg(argIdx)
+	ifs, err := parseFieldsList(ignoreFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -fluentforward.ignoreFields=%q for -fluentforward.listenAddr=%q: %s", ignoreFieldsStr, addr, err)
+	}
+
+	extraFieldsStr := extraFields.GetOptionalArg(argIdx)
+	efs, err := parseExtraFields(extraFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -fluentforward.extraFields=%q for -fluentforward.listenAddr=%q: %s", extraFieldsStr, addr, err)
+	}
+
+	cp := getCommonParams(tid, sfs, mfs, ifs, efs)
+
+	doneCh := make(chan struct{})
+	go func() {
+		serveTCP(ln, cp)
+		close(doneCh)
+	}()
+
+	logger.Infof("started accepting Fluent Forward messages at -fluentforward.listenAddr=%q", addr)
+	<-workersStopCh
+	if err := ln.Close(); err != nil {
+		logger.Fatalf("fluentforward: cannot close TCP listener at %s: %s", addr, err)
+	}
+	<-doneCh
+	logger.Infof("finished accepting Fluent Forward messages at -fluentforward.listenAddr=%q", addr)
+}
+
+func getCommonParams(tenantID logstorage.TenantID, streamFields, msgFields, ignoreFields []string, extraFields []logstorage.Field) *insertutils.CommonParams {
+	if streamFields == nil {
+		streamFields = []string{"tag"}
+	}
+	if msgFields == nil {
+		// Fluent Bit stores log messages read by tail input in the "log" field by default.
+		msgFields = []string{"message", "log"}
+	}
+	return &insertutils.CommonParams{
+		TenantID:     tenantID,
+		MsgFields:    msgFields,
+		StreamFields: streamFields,
+		IgnoreFields: ignoreFields,
+		ExtraFields:  extraFields,
+	}
+}
+
+func serveTCP(ln net.Listener, cp *insertutils.CommonParams) {
+	var cm ingestserver.ConnsMap
+	cm.Init("fluentforward")
+
+	var wg sync.WaitGroup
+	addr := ln.Addr()
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) {
+				if ne.Temporary() {
+					logger.Errorf("fluentforward: temporary error when listening for TCP addr %q: %s", addr, err)
+					time.Sleep(time.Second)
+					continue
+				}
+				if strings.Contains(err.Error(), "use of closed network connection") {
+					break
+				}
+				logger.Fatalf("fluentforward: unrecoverable error when accepting TCP connections at %q: %s", addr, err)
+			}
+			logger.Fatalf("fluentforward: unexpected error when accepting TCP connections at %q: %s", addr, err)
+		}
+		if !cm.Add(c) {
+			_ = c.Close()
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			if err := processConn(c, cp); err != nil {
+				logger.Errorf("fluentforward: cannot process TCP data from %q at %q: %s", c.RemoteAddr(), addr, err)
+			}
+
+			cm.Delete(c)
+			_ = c.Close()
+			wg.Done()
+		}()
+	}
+
+	cm.CloseAll(0)
+	wg.Wait()
+}
+
+// processConn reads Fluent Forward messages from c, ingests them into vlstorage and sends acknowledgements to c.
+func processConn(c net.Conn, cp *insertutils.CommonParams) error {
+	if err := vlstorage.CanWriteData(); err != nil {
+		return err
+	}
+
+	lmp := cp.NewLogMessageProcessor("fluentforward")
+	err := processStream(c, c, cp.MsgFields, lmp)
+	lmp.MustClose()
+
+	return err
+}
+
+// processStream reads Fluent Forward messages from r, sends them to lmp and writes acknowledgements to w.
+func processStream(r io.Reader, w io.Writer, msgFields []string, lmp insertutils.LogMessageProcessor) error {
+	wcr := writeconcurrencylimiter.GetReader(r)
+	defer writeconcurrencylimiter.PutReader(wcr)
+
+	mp := getMessageProcessor(wcr, msgFields, lmp)
+	defer putMessageProcessor(mp)
+
+	maxSize := maxMessageSize.IntN()
+	n := 0
+	for {
+		var err error
+		mp.msg, err = readMsgpackValue(mp.msg[:0], mp.br, maxSize)
+		wcr.DecConcurrency()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			errorsTotal.Inc()
+			return fmt.Errorf("cannot read message #%d: %w", n, err)
+		}
+
+		chunk, err := mp.processMessage(mp.msg)
+		if err != nil {
+			errorsTotal.Inc()
+			return fmt.Errorf("cannot process message #%d: %w", n, err)
+		}
+		if len(chunk) > 0 {
+			// The client requested the acknowledgement for the received message.
+			// See https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1#response
+			mp.ack = appendAck(mp.ack[:0], chunk)
+			if _, err := w.Write(mp.ack); err != nil {
+				return fmt.Errorf("cannot send acknowledgement for message #%d: %w", n, err)
+			}
+		}
+		n++
+	}
+}
+
+// appendAck appends msgpack-encoded {"ack": chunk} response to dst.
+func appendAck(dst, chunk []byte) []byte {
+	dst = append(dst, 0x81, 0xa3, 'a', 'c', 'k')
+	n := len(chunk)
+	switch {
+	case n <= 31:
+		dst = append(dst, 0xa0|byte(n))
+	case n <= 0xff:
+		dst = append(dst, 0xd9, byte(n))
+	case n <= 0xffff:
+		dst = append(dst, 0xda, byte(n>>8), byte(n))
+	default:
+		dst = append(dst, 0xdb, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(dst, chunk...)
+}
+
+type messageProcessor struct {
+	br *bufio.Reader
+
+	msgFields []string
+	lmp       insertutils.LogMessageProcessor
+
+	// msg is the buffer for the currently processed message
+	msg []byte
+
+	// ack is the buffer for the acknowledgement response
+	ack []byte
+
+	// tag is the tag for the currently processed message
+	tag []byte
+
+	// decompressed is the buffer for decompressed entries in CompressedPackedForward mode
+	decompressed bytesutil.ByteBuffer
+
+	fields    []logstorage.Field
+	buf       []byte
+	prefixBuf []byte
+}
+
+func (mp *messageProcessor) reset() {
+	mp.br.Reset(nil)
+
+	mp.msgFields = nil
+	mp.lmp = nil
+
+	mp.msg = mp.msg[:0]
+	mp.ack = mp.ack[:0]
+	mp.tag = mp.tag[:0]
+	mp.decompressed.Reset()
+
+	mp.resetFields()
+}
+
+func (mp *messageProcessor) resetFields() {
+	clear(mp.fields)
+	mp.fields = mp.fields[:0]
+	mp.buf = mp.buf[:0]
+	mp.prefixBuf = mp.prefixBuf[:0]
+}
+
+// processMessage processes a single Fluent Forward message from src.
+//
+// It returns the chunk id, which must be acknowledged.
+//
+// See https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1#event-modes
+func (mp *messageProcessor) processMessage(src []byte) ([]byte, error) {
+	h, tail, err := parseMsgpackHeader(src)
+	if err != nil {
+		return nil, err
+	}
+	if h.typ != msgpackArray || h.n < 2 || h.n > 4 {
+		return nil, fmt.Errorf("unexpected message; want array with 2-4 items")
+	}
+	itemsCount := h.n
+
+	tag, tail, err := parseMsgpackString(tail)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse tag: %w", err)
+	}
+	mp.tag = append(mp.tag[:0], tag...)
+
+	h, entriesTail, err := parseMsgpackHeader(tail)
+	if err != nil {
+		return nil, err
+	}
+	switch h.typ {
+	case msgpackArray:
+		// Forward mode: [tag, [[time, record], ...], option]
+		entriesCount := h.n
+		optionsSrc, err := skipMsgpackValue(tail)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse entries: %w", err)
+		}
+		chunk, _, err := parseOptions(optionsSrc, itemsCount-2)
+		if err != nil {
+			return nil, err
+		}
+		tail = entriesTail
+		for i := uint64(0); i < entriesCount; i++ {
+			tail, err = mp.processEntry(tail)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse entry #%d: %w", i, err)
+			}
+		}
+		return chunk, nil
+	case msgpackStr, msgpackBin:
+		// PackedForward and CompressedPackedForward modes: [tag, msgpack stream of [time, record] entries, option]
+		entries, optionsSrc, err := parseMsgpackPayload(&h, entriesTail)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse entries: %w", err)
+		}
+		chunk, compressed, err := parseOptions(optionsSrc, itemsCount-2)
+		if err != nil {
+			return nil, err
+		}
+		switch string(compressed) {
+		case "":
+		case "gzip":
+			entries, err = mp.decompress(entries)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported compression %q; supported values: gzip", compressed)
+		}
+		for i := 0; len(entries) > 0; i++ {
+			entries, err = mp.processEntry(entries)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse entry #%d: %w", i, err)
+			}
+		}
+		return chunk, nil
+	default:
+		// Message mode: [tag, time, record, option]
+		if itemsCount < 3 {
+			return nil, fmt.Errorf("missing record in message")
+		}
+		ts, tail, err := parseEventTime(tail)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse time: %w", err)
+		}
+		tail, err = mp.processRecord(ts, tail)
+		if err != nil {
+			return nil, err
+		}
+		chunk, _, err := parseOptions(tail, itemsCount-3)
+		return chunk, err
+	}
+}
+
+func (mp *messageProcessor) decompress(src []byte) ([]byte, error) {
+	zr, err := common.GetGzipReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read gzipped entries: %w", err)
+	}
+	defer common.PutGzipReader(zr)
+
+	maxSize := maxMessageSize.IntN()
+	mp.decompressed.Reset()
+	if _, err := mp.decompressed.ReadFrom(io.LimitReader(zr, int64(maxSize)+1)); err != nil {
+		return nil, fmt.Errorf("cannot decompress gzipped entries: %w", err)
+	}
+	if len(mp.decompressed.B) > maxSize {
+		return nil, fmt.Errorf("too big decompressed entries; they mustn't exceed -fluentforward.maxMessageSize=%d bytes", maxSize)
+	}
+	return mp.decompressed.B, nil
+}
+
+// parseOptions parses the optional options map from src if optionsCount > 0.
+//
+// It returns the chunk id and the compression method from the options.
+func parseOptions(src []byte, optionsCount uint64) ([]byte, []byte, error) {
+	if optionsCount == 0 {
+		return nil, nil, nil
+	}
+	h, tail, err := parseMsgpackHeader(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse options: %w", err)
+	}
+	if h.typ == msgpackNil {
+		return nil, nil, nil
+	}
+	if h.typ != msgpackMap {
+		return nil, nil, fmt.Errorf("unexpected options; want map")
+	}
+
+	var chunk, compressed []byte
+	for i := uint64(0); i < h.n; i++ {
+		var key []byte
+		key, tail, err = parseMsgpackString(tail)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse options key: %w", err)
+		}
+		switch string(key) {
+		case "chunk":
+			chunk, tail, err = parseMsgpackString(tail)
+		case "compressed":
+			compressed, tail, err = parseMsgpackString(tail)
+		default:
+			tail, err = skipMsgpackValue(tail)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse options value for %q: %w", key, err)
+		}
+	}
+	return chunk, compressed, nil
+}
+
+// processEntry processes [time, record] entry from src and returns the tail.
+func (mp *messageProcessor) processEntry(src []byte) ([]byte, error) {
+	h, tail, err := parseMsgpackHeader(src)
+	if err != nil {
+		return src, err
+	}
+	if h.typ != msgpackArray || h.n != 2 {
+		return src, fmt.Errorf("unexpected entry; want [time, record] array")
+	}
+	ts, tail, err := parseEventTime(tail)
+	if err != nil {
+		return src, fmt.Errorf("cannot parse time: %w", err)
+	}
+	return mp.processRecord(ts, tail)
+}
+
+// processRecord processes the record from src with the given timestamp ts and returns the tail.
+func (mp *messageProcessor) processRecord(ts int64, src []byte) ([]byte, error) {
+	mp.resetFields()
+	tail, err := mp.appendRecordFields(src, 0)
+	if err != nil {
+		return src, fmt.Errorf("cannot parse record: %w", err)
+	}
+
+	hasTag := false
+	for _, f := range mp.fields {
+		if f.Name == "tag" {
+			hasTag = true
+			break
+		}
+	}
+	if !hasTag {
+		mp.fields = append(mp.fields, logstorage.Field{
+			Name:  "tag",
+			Value: bytesutil.ToUnsafeString(mp.tag),
+		})
+	}
+
+	if ts == 0 {
+		ts = time.Now().UnixNano()
+	}
+	logstorage.RenameField(mp.fields, mp.msgFields, "_msg")
+	mp.lmp.AddRow(ts, mp.fields, nil)
+
+	return tail, nil
+}
+
+// appendRecordFields appends fields from the record map at src to mp.fields.
+//
+// Nested maps are flattened in the same way as nested JSON objects are flattened at JSON stream API.
+// depth is the nesting depth of the map at src. An error is returned if nested arrays and maps exceed maxMsgpackDepth.
+func (mp *messageProcessor) appendRecordFields(src []byte, depth int) ([]byte, error) {
+	h, tail, err := parseMsgpackHeader(src)
+	if err != nil {
+		return src, err
+	}
+	if h.typ != msgpackMap {
+		return src, fmt.Errorf("unexpected record; want map")
+	}
+	if depth >= maxMsgpackDepth {
+		return src, fmt.Errorf("too deep nesting for record; it mustn't exceed %d levels", maxMsgpackDepth)
+	}
+
+	for i := uint64(0); i < h.n; i++ {
+		var key []byte
+		key, tail, err = parseMsgpackString(tail)
+		if err != nil {
+			return src, fmt.Errorf("cannot parse key: %w", err)
+		}
+
+		hv, valueTail, err := parseMsgpackHeader(tail)
+		if err != nil {
+			return src, fmt.Errorf("cannot parse value for %q: %w", key, err)
+		}
+		switch hv.typ {
+		case msgpackNil:
+			// Skip nulls
+			tail = valueTail
+		case msgpackMap:
+			// Flatten nested maps.
+			// For example, {"foo":{"bar":"baz"}} is converted to {"foo.bar":"baz"}
+			prefixLen := len(mp.prefixBuf)
+			mp.prefixBuf = append(mp.prefixBuf, key...)
+			mp.prefixBuf = append(mp.prefixBuf, '.')
+			tail, err = mp.appendRecordFields(tail, depth+1)
+			mp.prefixBuf = mp.prefixBuf[:prefixLen]
+			if err != nil {
+				return src, err
+			}
+		case msgpackArray:
+			// Convert arrays to JSON
+			bufLen := len(mp.buf)
+			mp.buf, tail, err = appendMsgpackJSON(mp.buf, tail, depth+1)
+			if err != nil {
+				return src, fmt.Errorf("cannot parse value for %q: %w", key, err)
+			}
+			mp.appendField(key, mp.buf[bufLen:])
+		case msgpackStr, msgpackBin:
+			var value []byte
+			value, tail, err = parseMsgpackPayload(&hv, valueTail)
+			if err != nil {
+				return src, fmt.Errorf("cannot parse value for %q: %w", key, err)
+			}
+			mp.appendField(key, value)
+		case msgpackExt:
+			// Skip extension values, since their format is application-specific
+			_, tail, err = parseMsgpackPayload(&hv, valueTail)
+			if err != nil {
+				return src, fmt.Errorf("cannot parse value for %q: %w", key, err)
+			}
+		default:
+			bufLen := len(mp.buf)
+			mp.buf = appendMsgpackScalar(mp.buf, &hv)
+			mp.appendField(key, mp.buf[bufLen:])
+			tail = valueTail
+		}
+	}
+	return tail, nil
+}
+
+func (mp *messageProcessor) appendField(key, value []byte) {
+	bufLen := len(mp.buf)
+	mp.buf = append(mp.buf, mp.prefixBuf...)
+	mp.buf = append(mp.buf, key...)
+	name := mp.buf[bufLen:]
+
+	mp.fields = append(mp.fields, logstorage.Field{
+		Name:  bytesutil.ToUnsafeString(name),
+		Value: bytesutil.ToUnsafeString(value),
+	})
+}
+
+// parseEventTime parses event time from src.
+//
+// The time can be either unix timestamp in seconds or EventTime extension.
+// See https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1#eventtime-ext-format
+//
+// Fluent Bit may send [time, metadata] array instead of time. The metadata is ignored in this case.
+// See https://docs.fluentbit.io/manual/concepts/key-concepts#event-format
+func parseEventTime(src []byte) (int64, []byte, error) {
+	h, tail, err := parseMsgpackHeader(src)
+	if err != nil {
+		return 0, src, err
+	}
+	switch h.typ {
+	case msgpackUint:
+		return int64(h.u) * 1e9, tail, nil
+	case msgpackInt:
+		return h.i * 1e9, tail, nil
+	case msgpackFloat:
+		return int64(h.f * 1e9), tail, nil
+	case msgpackNil:
+		return 0, tail, nil
+	case msgpackExt:
+		payload, tail, err := parseMsgpackPayload(&h, tail)
+		if err != nil {
+			return 0, src, err
+		}
+		if h.extType != 0 || len(payload) != 8 {
+			return 0, src, fmt.Errorf("unexpected extension type %d with length %d; want EventTime extension type 0 with length 8", h.extType, len(payload))
+		}
+		secs := int64(unmarshalMsgpackUint(payload[:4]))
+		nsecs := int64(unmarshalMsgpackUint(payload[4:]))
+		return secs*1e9 + nsecs, tail, nil
+	case msgpackArray:
+		if h.n == 0 {
+			return 0, src, fmt.Errorf("unexpected empty array for time")
+		}
+		ts, tail, err := parseEventTime(tail)
+		if err != nil {
+			return 0, src, err
+		}
+		for i := uint64(1); i < h.n; i++ {
+			tail, err = skipMsgpackValue(tail)
+			if err != nil {
+				return 0, src, err
+			}
+		}
+		return ts, tail, nil
+	default:
+		return 0, src, fmt.Errorf("unexpected time type; want integer, float or EventTime extension")
+	}
+}
+
+func getMessageProcessor(r io.Reader, msgFields []string, lmp insertutils.LogMessageProcessor) *messageProcessor {
+	v := messageProcessorPool.Get()
+	if v == nil {
+		v = &messageProcessor{
+			br: bufio.NewReaderSize(r, 64*1024),
+		}
+	}
+	mp := v.(*messageProcessor)
+	mp.br.Reset(r)
+	mp.msgFields = msgFields
+	mp.lmp = lmp
+	return mp
+}
+
+func putMessageProcessor(mp *messageProcessor) {
+	mp.reset()
+	messageProcessorPool.Put(mp)
+}
+
+var messageProcessorPool sync.Pool
+
+var errorsTotal = metrics.NewCounter(`vl_errors_total{type="fluentforward"}`)
+
+func parseFieldsList(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var a []string
+	err := json.Unmarshal([]byte(s), &a)
+	return a, err
+}
+
+func parseExtraFields(s string) ([]logstorage.Field, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var m map[string]string
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil, err
+	}
+	fields := make([]logstorage.Field, 0, len(m))
+	for k, v := range m {
+		fields = append(fields, logstorage.Field{
+			Name:  k,
+			Value: v,
+		})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+	return fields, nil
+}
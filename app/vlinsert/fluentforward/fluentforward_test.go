@@ -0,0 +1,168 @@
+package fluentforward
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/insertutils"
+)
+
+func TestProcessStream_Success(t *testing.T) {
+	f := func(messages []any, timestampsExpected []int64, resultExpected string, acksExpected []string) {
+		t.Helper()
+
+		var data []byte
+		for _, m := range messages {
+			data = appendTestMsgpack(data, m)
+		}
+
+		msgFields := []string{"message", "log"}
+		tlp := &insertutils.TestLogMessageProcessor{}
+		var w bytes.Buffer
+		if err := processStream(bytes.NewReader(data), &w, msgFields, tlp); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := tlp.Verify(timestampsExpected, resultExpected); err != nil {
+			t.Fatal(err)
+		}
+
+		var acks []byte
+		for _, chunk := range acksExpected {
+			// {"ack": chunk} map
+			acks = append(acks, 0x81)
+			acks = appendTestMsgpack(acks, "ack")
+			acks = appendTestMsgpack(acks, chunk)
+		}
+		if !bytes.Equal(w.Bytes(), acks) {
+			t.Fatalf("unexpected acks;\ngot\n%X\nwant\n%X", w.Bytes(), acks)
+		}
+	}
+
+	// empty stream
+	f(nil, nil, "", nil)
+
+	// Message mode
+	f([]any{
+		[]any{"app.foo", 1700000000, testMap{"log", "hello", "level", "info"}},
+	}, []int64{1700000000000000000}, `{"_msg":"hello","level":"info","tag":"app.foo"}`, nil)
+
+	// Message mode with EventTime and chunk option
+	f([]any{
+		[]any{"app.foo", testEventTime{1700000000, 123}, testMap{"message", "hello"}, testMap{"chunk", "abc"}},
+	}, []int64{1700000000000000123}, `{"_msg":"hello","tag":"app.foo"}`, []string{"abc"})
+
+	// Forward mode
+	f([]any{
+		[]any{"app.bar", []any{
+			[]any{1700000000, testMap{"log", "foo"}},
+			[]any{1.5, testMap{"log", "bar", "tag", "custom"}},
+		}, testMap{"size", 2, "chunk", "c1"}},
+	}, []int64{1700000000000000000, 1500000000}, `{"_msg":"foo","tag":"app.bar"}
+{"_msg":"bar","tag":"custom"}`, []string{"c1"})
+
+	// Forward mode with Fluent Bit v2 [time, metadata] format
+	f([]any{
+		[]any{"app.bar", []any{
+			[]any{[]any{testEventTime{1700000000, 5}, testMap{}}, testMap{"log", "foo"}},
+		}},
+	}, []int64{1700000000000000005}, `{"_msg":"foo","tag":"app.bar"}`, nil)
+
+	// PackedForward mode
+	var entries []byte
+	entries = appendTestMsgpack(entries, []any{uint32(1700000000), testMap{"log", "foo"}})
+	entries = appendTestMsgpack(entries, []any{uint32(1700000001), testMap{"log", "bar"}})
+	f([]any{
+		[]any{"app.packed", entries, testMap{"chunk", "p1"}},
+		[]any{"app.packed", string(entries)},
+	}, []int64{1700000000000000000, 1700000001000000000, 1700000000000000000, 1700000001000000000}, `{"_msg":"foo","tag":"app.packed"}
+{"_msg":"bar","tag":"app.packed"}
+{"_msg":"foo","tag":"app.packed"}
+{"_msg":"bar","tag":"app.packed"}`, []string{"p1"})
+
+	// CompressedPackedForward mode
+	var bb bytes.Buffer
+	zw := gzip.NewWriter(&bb)
+	if _, err := zw.Write(entries); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f([]any{
+		[]any{"app.gzip", bb.Bytes(), testMap{"compressed", "gzip", "chunk", "z1"}},
+	}, []int64{1700000000000000000, 1700000001000000000}, `{"_msg":"foo","tag":"app.gzip"}
+{"_msg":"bar","tag":"app.gzip"}`, []string{"z1"})
+
+	// nested records, arrays and various value types
+	f([]any{
+		[]any{"k8s", 1700000000, testMap{
+			"log", "foo",
+			"kubernetes", testMap{"pod_name", "bar", "labels", testMap{"app", "baz"}},
+			"ids", []any{1, "x"},
+			"ok", true,
+			"n", -12,
+			"f", 0.5,
+			"null", nil,
+			"ext", testEventTime{1, 2},
+		}},
+	}, []int64{1700000000000000000}, `{"_msg":"foo","kubernetes.pod_name":"bar","kubernetes.labels.app":"baz","ids":"[1,\"x\"]","ok":"true","n":"-12","f":"0.5","tag":"k8s"}`, nil)
+}
+
+func TestProcessStream_Failure(t *testing.T) {
+	f := func(message any) {
+		t.Helper()
+
+		data := appendTestMsgpack(nil, message)
+		tlp := &insertutils.TestLogMessageProcessor{}
+		var w bytes.Buffer
+		if err := processStream(bytes.NewReader(data), &w, nil, tlp); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// not an array
+	f("foo")
+
+	// too short array
+	f([]any{"tag"})
+
+	// too long array
+	f([]any{"tag", 1, testMap{}, testMap{}, 1})
+
+	// non-string tag
+	f([]any{1, 1, testMap{}})
+
+	// missing record in Message mode
+	f([]any{"tag", 1})
+
+	// invalid record
+	f([]any{"tag", 1, "foo"})
+
+	// invalid time
+	f([]any{"tag", "foo", testMap{}})
+	f([]any{"tag", []any{[]any{"foo", testMap{}}}})
+
+	// invalid entry
+	f([]any{"tag", []any{1}})
+
+	// invalid options
+	f([]any{"tag", 1, testMap{}, "foo"})
+
+	// unsupported compression
+	f([]any{"tag", []byte{}, testMap{"compressed", "zstd"}})
+
+	// invalid gzip data
+	f([]any{"tag", []byte("foo"), testMap{"compressed", "gzip"}})
+
+	// too deep nesting
+	var nestedMap any = "foo"
+	var nestedArray any = "foo"
+	for i := 0; i <= maxMsgpackDepth; i++ {
+		nestedMap = testMap{"a", nestedMap}
+		nestedArray = []any{nestedArray}
+	}
+	f([]any{"tag", 1, nestedMap})
+	f([]any{"tag", 1, testMap{"a", nestedArray}})
+	f([]any{"tag", appendTestMsgpack(nil, []any{1, nestedMap})})
+}
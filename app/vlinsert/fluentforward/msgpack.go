@@ -0,0 +1,403 @@
+package fluentforward
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/valyala/quicktemplate"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/slicesutil"
+)
+
+// maxMsgpackDepth is the maximum nesting depth for msgpack arrays and maps.
+//
+// Deeper values are rejected in order to prevent from stack overflow on recursive parsing of specially crafted messages.
+const maxMsgpackDepth = 100
+
+// msgpackType is the type of msgpack value.
+//
+// See https://github.com/msgpack/msgpack/blob/master/spec.md
+type msgpackType int
+
+const (
+	msgpackNil msgpackType = iota
+	msgpackBool
+	msgpackInt
+	msgpackUint
+	msgpackFloat
+	msgpackStr
+	msgpackBin
+	msgpackArray
+	msgpackMap
+	msgpackExt
+)
+
+// msgpackHeader contains the parsed header of msgpack value.
+type msgpackHeader struct {
+	typ msgpackType
+
+	// n is the length of the payload for msgpackStr, msgpackBin and msgpackExt,
+	// the number of items for msgpackArray and the number of key-value pairs for msgpackMap.
+	n uint64
+
+	// b is the value for msgpackBool
+	b bool
+
+	// i is the value for msgpackInt
+	i int64
+
+	// u is the value for msgpackUint
+	u uint64
+
+	// f is the value for msgpackFloat
+	f float64
+
+	// extType is the extension type for msgpackExt
+	extType int8
+}
+
+// getMsgpackHeaderSize returns the size of the header for msgpack value starting with the given byte c.
+//
+// The header contains the type byte and the length of the value. Fixed-size scalar values are included in the header.
+// -1 is returned if c is invalid.
+func getMsgpackHeaderSize(c byte) int {
+	if c <= 0xbf || c >= 0xe0 {
+		// positive fixint, fixmap, fixarray, fixstr and negative fixint
+		return 1
+	}
+	switch c {
+	case 0xc0, 0xc2, 0xc3:
+		// nil, false, true
+		return 1
+	case 0xc4, 0xd9, 0xcc, 0xd0:
+		// bin8, str8, uint8, int8
+		return 2
+	case 0xc5, 0xda, 0xdc, 0xde, 0xcd, 0xd1:
+		// bin16, str16, array16, map16, uint16, int16
+		return 3
+	case 0xc6, 0xdb, 0xdd, 0xdf, 0xce, 0xd2, 0xca:
+		// bin32, str32, array32, map32, uint32, int32, float32
+		return 5
+	case 0xcf, 0xd3, 0xcb:
+		// uint64, int64, float64
+		return 9
+	case 0xc7:
+		// ext8
+		return 3
+	case 0xc8:
+		// ext16
+		return 4
+	case 0xc9:
+		// ext32
+		return 6
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		// fixext1, fixext2, fixext4, fixext8, fixext16
+		return 2
+	default:
+		// 0xc1 is never used
+		return -1
+	}
+}
+
+// parseMsgpackHeader parses msgpack value header from src.
+//
+// It returns the parsed header and the tail left after the header.
+func parseMsgpackHeader(src []byte) (msgpackHeader, []byte, error) {
+	var h msgpackHeader
+	if len(src) == 0 {
+		return h, src, fmt.Errorf("missing msgpack value")
+	}
+	c := src[0]
+	hdrSize := getMsgpackHeaderSize(c)
+	if hdrSize < 0 {
+		return h, src, fmt.Errorf("unexpected msgpack type 0x%02x", c)
+	}
+	if len(src) < hdrSize {
+		return h, src, fmt.Errorf("too short msgpack value header for type 0x%02x; got %d bytes; want %d bytes", c, len(src), hdrSize)
+	}
+	b := src[1:hdrSize]
+	tail := src[hdrSize:]
+
+	switch {
+	case c <= 0x7f:
+		h.typ = msgpackUint
+		h.u = uint64(c)
+	case c <= 0x8f:
+		h.typ = msgpackMap
+		h.n = uint64(c & 0x0f)
+	case c <= 0x9f:
+		h.typ = msgpackArray
+		h.n = uint64(c & 0x0f)
+	case c <= 0xbf:
+		h.typ = msgpackStr
+		h.n = uint64(c & 0x1f)
+	case c >= 0xe0:
+		h.typ = msgpackInt
+		h.i = int64(int8(c))
+	default:
+		switch c {
+		case 0xc0:
+			h.typ = msgpackNil
+		case 0xc2, 0xc3:
+			h.typ = msgpackBool
+			h.b = c == 0xc3
+		case 0xc4, 0xc5, 0xc6:
+			h.typ = msgpackBin
+			h.n = unmarshalMsgpackUint(b)
+		case 0xd9, 0xda, 0xdb:
+			h.typ = msgpackStr
+			h.n = unmarshalMsgpackUint(b)
+		case 0xdc, 0xdd:
+			h.typ = msgpackArray
+			h.n = unmarshalMsgpackUint(b)
+		case 0xde, 0xdf:
+			h.typ = msgpackMap
+			h.n = unmarshalMsgpackUint(b)
+		case 0xcc, 0xcd, 0xce, 0xcf:
+			h.typ = msgpackUint
+			h.u = unmarshalMsgpackUint(b)
+		case 0xd0:
+			h.typ = msgpackInt
+			h.i = int64(int8(b[0]))
+		case 0xd1:
+			h.typ = msgpackInt
+			h.i = int64(int16(binary.BigEndian.Uint16(b)))
+		case 0xd2:
+			h.typ = msgpackInt
+			h.i = int64(int32(binary.BigEndian.Uint32(b)))
+		case 0xd3:
+			h.typ = msgpackInt
+			h.i = int64(binary.BigEndian.Uint64(b))
+		case 0xca:
+			h.typ = msgpackFloat
+			h.f = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+		case 0xcb:
+			h.typ = msgpackFloat
+			h.f = math.Float64frombits(binary.BigEndian.Uint64(b))
+		case 0xc7, 0xc8, 0xc9:
+			h.typ = msgpackExt
+			h.n = unmarshalMsgpackUint(b[:len(b)-1])
+			h.extType = int8(b[len(b)-1])
+		default:
+			// fixext1, fixext2, fixext4, fixext8, fixext16
+			h.typ = msgpackExt
+			h.n = 1 << (c - 0xd4)
+			h.extType = int8(b[0])
+		}
+	}
+	return h, tail, nil
+}
+
+func unmarshalMsgpackUint(b []byte) uint64 {
+	switch len(b) {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b))
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b))
+	default:
+		return binary.BigEndian.Uint64(b)
+	}
+}
+
+// parseMsgpackPayload returns the payload for msgpackStr, msgpackBin and msgpackExt values with the given header h from src.
+func parseMsgpackPayload(h *msgpackHeader, src []byte) ([]byte, []byte, error) {
+	if uint64(len(src)) < h.n {
+		return nil, src, fmt.Errorf("too short msgpack value payload; got %d bytes; want %d bytes", len(src), h.n)
+	}
+	return src[:h.n], src[h.n:], nil
+}
+
+// parseMsgpackString parses msgpack string or binary value from src.
+func parseMsgpackString(src []byte) ([]byte, []byte, error) {
+	h, tail, err := parseMsgpackHeader(src)
+	if err != nil {
+		return nil, src, err
+	}
+	if h.typ != msgpackStr && h.typ != msgpackBin {
+		return nil, src, fmt.Errorf("unexpected msgpack value type; want string")
+	}
+	return parseMsgpackPayload(&h, tail)
+}
+
+// skipMsgpackValue skips a single msgpack value at src and returns the tail.
+func skipMsgpackValue(src []byte) ([]byte, error) {
+	pending := uint64(1)
+	for pending > 0 {
+		pending--
+		h, tail, err := parseMsgpackHeader(src)
+		if err != nil {
+			return src, err
+		}
+		switch h.typ {
+		case msgpackStr, msgpackBin, msgpackExt:
+			_, tail, err = parseMsgpackPayload(&h, tail)
+			if err != nil {
+				return src, err
+			}
+		case msgpackArray:
+			pending += h.n
+		case msgpackMap:
+			pending += 2 * h.n
+		}
+		src = tail
+	}
+	return src, nil
+}
+
+// readMsgpackValue reads a single msgpack value from br, appends it to dst and returns the result.
+//
+// An error is returned if the value size exceeds maxSize.
+// io.EOF is returned if br has no more data.
+func readMsgpackValue(dst []byte, br *bufio.Reader, maxSize int) ([]byte, error) {
+	dstLen := len(dst)
+	pending := uint64(1)
+	for pending > 0 {
+		pending--
+
+		c, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(dst) > dstLen {
+				err = io.ErrUnexpectedEOF
+			}
+			return dst, err
+		}
+		hdrSize := getMsgpackHeaderSize(c)
+		if hdrSize < 0 {
+			return dst, fmt.Errorf("unexpected msgpack type 0x%02x", c)
+		}
+		hdrStart := len(dst)
+		dst = append(dst, c)
+		dst = slicesutil.SetLength(dst, hdrStart+hdrSize)
+		if _, err := io.ReadFull(br, dst[hdrStart+1:]); err != nil {
+			return dst, fmt.Errorf("cannot read msgpack value header: %w", unexpectedEOF(err))
+		}
+		h, _, err := parseMsgpackHeader(dst[hdrStart:])
+		if err != nil {
+			return dst, err
+		}
+
+		switch h.typ {
+		case msgpackStr, msgpackBin, msgpackExt:
+			if uint64(len(dst)-dstLen)+h.n > uint64(maxSize) {
+				return dst, fmt.Errorf("too big msgpack message; it mustn't exceed %d bytes", maxSize)
+			}
+			payloadStart := len(dst)
+			dst = slicesutil.SetLength(dst, payloadStart+int(h.n))
+			if _, err := io.ReadFull(br, dst[payloadStart:]); err != nil {
+				return dst, fmt.Errorf("cannot read msgpack value with size %d bytes: %w", h.n, unexpectedEOF(err))
+			}
+		case msgpackArray:
+			pending += h.n
+		case msgpackMap:
+			pending += 2 * h.n
+		}
+		if len(dst)-dstLen > maxSize {
+			return dst, fmt.Errorf("too big msgpack message; it mustn't exceed %d bytes", maxSize)
+		}
+	}
+	return dst, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// appendMsgpackScalar appends string representation of the scalar msgpack value with the given header h to dst.
+func appendMsgpackScalar(dst []byte, h *msgpackHeader) []byte {
+	switch h.typ {
+	case msgpackBool:
+		return strconv.AppendBool(dst, h.b)
+	case msgpackInt:
+		return strconv.AppendInt(dst, h.i, 10)
+	case msgpackUint:
+		return strconv.AppendUint(dst, h.u, 10)
+	case msgpackFloat:
+		return strconv.AppendFloat(dst, h.f, 'g', -1, 64)
+	default:
+		return dst
+	}
+}
+
+// appendMsgpackJSON appends JSON representation of msgpack value from src to dst.
+//
+// depth is the nesting depth of the value. An error is returned if nested arrays and maps exceed maxMsgpackDepth.
+//
+// It returns the result and the tail left after the value at src.
+func appendMsgpackJSON(dst, src []byte, depth int) ([]byte, []byte, error) {
+	h, tail, err := parseMsgpackHeader(src)
+	if err != nil {
+		return dst, src, err
+	}
+	switch h.typ {
+	case msgpackNil:
+		return append(dst, "null"...), tail, nil
+	case msgpackFloat:
+		if math.IsNaN(h.f) || math.IsInf(h.f, 0) {
+			return append(dst, "null"...), tail, nil
+		}
+		return appendMsgpackScalar(dst, &h), tail, nil
+	case msgpackBool, msgpackInt, msgpackUint:
+		return appendMsgpackScalar(dst, &h), tail, nil
+	case msgpackStr, msgpackBin:
+		s, tail, err := parseMsgpackPayload(&h, tail)
+		if err != nil {
+			return dst, src, err
+		}
+		return quicktemplate.AppendJSONString(dst, bytesutil.ToUnsafeString(s), true), tail, nil
+	case msgpackExt:
+		_, tail, err := parseMsgpackPayload(&h, tail)
+		if err != nil {
+			return dst, src, err
+		}
+		return append(dst, "null"...), tail, nil
+	case msgpackArray:
+		if depth >= maxMsgpackDepth {
+			return dst, src, fmt.Errorf("too deep nesting for msgpack value; it mustn't exceed %d levels", maxMsgpackDepth)
+		}
+		dst = append(dst, '[')
+		for i := uint64(0); i < h.n; i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst, tail, err = appendMsgpackJSON(dst, tail, depth+1)
+			if err != nil {
+				return dst, src, err
+			}
+		}
+		return append(dst, ']'), tail, nil
+	case msgpackMap:
+		if depth >= maxMsgpackDepth {
+			return dst, src, fmt.Errorf("too deep nesting for msgpack value; it mustn't exceed %d levels", maxMsgpackDepth)
+		}
+		dst = append(dst, '{')
+		for i := uint64(0); i < h.n; i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			var key []byte
+			key, tail, err = parseMsgpackString(tail)
+			if err != nil {
+				return dst, src, fmt.Errorf("cannot parse map key: %w", err)
+			}
+			dst = quicktemplate.AppendJSONString(dst, bytesutil.ToUnsafeString(key), true)
+			dst = append(dst, ':')
+			dst, tail, err = appendMsgpackJSON(dst, tail, depth+1)
+			if err != nil {
+				return dst, src, err
+			}
+		}
+		return append(dst, '}'), tail, nil
+	default:
+		return dst, src, fmt.Errorf("BUG: unexpected msgpack type %d", h.typ)
+	}
+}
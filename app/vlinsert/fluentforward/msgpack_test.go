@@ -0,0 +1,191 @@
+package fluentforward
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testMap is a msgpack map with ordered keys for tests.
+//
+// It contains key, value pairs.
+type testMap []any
+
+// testEventTime is the EventTime msgpack extension for tests.
+type testEventTime struct {
+	secs  uint32
+	nsecs uint32
+}
+
+// appendTestMsgpack appends msgpack-encoded v to dst.
+func appendTestMsgpack(dst []byte, v any) []byte {
+	switch t := v.(type) {
+	case nil:
+		return append(dst, 0xc0)
+	case bool:
+		if t {
+			return append(dst, 0xc3)
+		}
+		return append(dst, 0xc2)
+	case int:
+		if t >= 0 && t <= 0x7f {
+			return append(dst, byte(t))
+		}
+		if t < 0 && t >= -32 {
+			return append(dst, byte(int8(t)))
+		}
+		dst = append(dst, 0xd3)
+		return binary.BigEndian.AppendUint64(dst, uint64(t))
+	case uint32:
+		dst = append(dst, 0xce)
+		return binary.BigEndian.AppendUint32(dst, t)
+	case float64:
+		dst = append(dst, 0xcb)
+		return binary.BigEndian.AppendUint64(dst, math.Float64bits(t))
+	case string:
+		n := len(t)
+		switch {
+		case n <= 31:
+			dst = append(dst, 0xa0|byte(n))
+		case n <= 0xff:
+			dst = append(dst, 0xd9, byte(n))
+		default:
+			dst = append(dst, 0xdb)
+			dst = binary.BigEndian.AppendUint32(dst, uint32(n))
+		}
+		return append(dst, t...)
+	case []byte:
+		dst = append(dst, 0xc6)
+		dst = binary.BigEndian.AppendUint32(dst, uint32(len(t)))
+		return append(dst, t...)
+	case []any:
+		dst = append(dst, 0xdd)
+		dst = binary.BigEndian.AppendUint32(dst, uint32(len(t)))
+		for _, item := range t {
+			dst = appendTestMsgpack(dst, item)
+		}
+		return dst
+	case testMap:
+		dst = append(dst, 0xdf)
+		dst = binary.BigEndian.AppendUint32(dst, uint32(len(t)/2))
+		for _, item := range t {
+			dst = appendTestMsgpack(dst, item)
+		}
+		return dst
+	case testEventTime:
+		dst = append(dst, 0xd7, 0x00)
+		dst = binary.BigEndian.AppendUint32(dst, t.secs)
+		return binary.BigEndian.AppendUint32(dst, t.nsecs)
+	default:
+		panic(fmt.Errorf("BUG: unexpected type %T", v))
+	}
+}
+
+func TestReadMsgpackValue_Success(t *testing.T) {
+	f := func(values []any) {
+		t.Helper()
+
+		var data []byte
+		for _, v := range values {
+			data = appendTestMsgpack(data, v)
+		}
+
+		br := bufio.NewReader(bytes.NewReader(data))
+		var result []byte
+		for i := range values {
+			var err error
+			bufLen := len(result)
+			result, err = readMsgpackValue(result, br, 1024)
+			if err != nil {
+				t.Fatalf("unexpected error when reading value #%d: %s", i, err)
+			}
+			valueExpected := appendTestMsgpack(nil, values[i])
+			if !bytes.Equal(result[bufLen:], valueExpected) {
+				t.Fatalf("unexpected value #%d;\ngot\n%X\nwant\n%X", i, result[bufLen:], valueExpected)
+			}
+		}
+		if _, err := readMsgpackValue(result, br, 1024); err != io.EOF {
+			t.Fatalf("unexpected error at the end of data; got %v; want %v", err, io.EOF)
+		}
+	}
+
+	f(nil)
+	f([]any{nil})
+	f([]any{1, -5, 12345, -12345, 1.5, true, false, "foo", []byte("bar")})
+	f([]any{[]any{}, testMap{}})
+	f([]any{[]any{"tag", []any{[]any{testEventTime{1, 2}, testMap{"a", "b", "c", []any{1, 2}}}}}})
+}
+
+func TestReadMsgpackValue_Failure(t *testing.T) {
+	f := func(data []byte, maxSize int) {
+		t.Helper()
+
+		br := bufio.NewReader(bytes.NewReader(data))
+		_, err := readMsgpackValue(nil, br, maxSize)
+		if err == nil || err == io.EOF {
+			t.Fatalf("expecting non-nil error; got %v", err)
+		}
+	}
+
+	// invalid type
+	f([]byte{0xc1}, 1024)
+
+	// truncated header
+	f([]byte{0xcd, 0x01}, 1024)
+
+	// truncated payload
+	f([]byte{0xa3, 'f', 'o'}, 1024)
+
+	// missing array items
+	f([]byte{0x92, 0x01}, 1024)
+
+	// too big value
+	f(appendTestMsgpack(nil, "foobar"), 4)
+	f(appendTestMsgpack(nil, []any{1, 2, 3, 4, 5}), 4)
+}
+
+func TestAppendMsgpackJSON(t *testing.T) {
+	f := func(v any, resultExpected string) {
+		t.Helper()
+
+		src := appendTestMsgpack(nil, v)
+		result, tail, err := appendMsgpackJSON(nil, src, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(tail) > 0 {
+			t.Fatalf("unexpected non-empty tail: %X", tail)
+		}
+		if string(result) != resultExpected {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+
+	f(nil, `null`)
+	f(true, `true`)
+	f(-123, `-123`)
+	f(1.25, `1.25`)
+	f(math.NaN(), `null`)
+	f(`foo"bar`, `"foo\"bar"`)
+	f([]any{}, `[]`)
+	f([]any{1, "a", nil, testEventTime{1, 2}}, `[1,"a",null,null]`)
+	f(testMap{"a", []any{testMap{"b", 1}}, "c", "d"}, `{"a":[{"b":1}],"c":"d"}`)
+
+	// the maximum nesting depth
+	var v any = 1
+	for i := 0; i < maxMsgpackDepth; i++ {
+		v = []any{v}
+	}
+	f(v, strings.Repeat("[", maxMsgpackDepth)+"1"+strings.Repeat("]", maxMsgpackDepth))
+
+	// too deep nesting
+	src := appendTestMsgpack(nil, []any{v})
+	if _, _, err := appendMsgpackJSON(nil, src, 0); err == nil {
+		t.Fatalf("expecting non-nil error for too deep nesting")
+	}
+}
@@ -0,0 +1,184 @@
+package gelf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+)
+
+// chunkTimeoutSeconds is the maximum duration in seconds for receiving all the chunks of a single message.
+//
+// See https://go2docs.graylog.org/current/getting_in_log_data/gelf.html#GELFviaUDP
+const chunkTimeoutSeconds = 5
+
+// maxChunksPerMessage is the maximum number of chunks per message according to GELF specification.
+const maxChunksPerMessage = 128
+
+// maxPendingChunkedMessages is the maximum number of incomplete chunked messages.
+//
+// The oldest incomplete messages are dropped when this limit is exceeded.
+const maxPendingChunkedMessages = 10_000
+
+// maxPendingChunkedMessagesSize is the maximum total size of incomplete chunked messages.
+//
+// The oldest incomplete messages are dropped when this limit is exceeded.
+const maxPendingChunkedMessagesSize = 64 * 1024 * 1024
+
+// chunkHeaderSize is the size of the GELF chunk header: magic bytes, message id, sequence number and sequence count.
+const chunkHeaderSize = 2 + 8 + 1 + 1
+
+// isChunk returns true if data contains GELF chunk.
+func isChunk(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1e && data[1] == 0x0f
+}
+
+// chunkAssembler assembles messages from chunks received via UDP.
+type chunkAssembler struct {
+	// maxMessageSize is the maximum size of the assembled message.
+	maxMessageSize int
+
+	// maxPendingMessages is the maximum number of incomplete messages.
+	maxPendingMessages int
+
+	// maxPendingSize is the maximum total size of incomplete messages.
+	maxPendingSize int
+
+	mu sync.Mutex
+
+	// m contains incomplete messages keyed by message id.
+	m map[uint64]*chunkedMessage
+
+	// queue contains incomplete messages in the order of their creation, e.g. the oldest message is at the head of the queue.
+	//
+	// It may contain already completed messages, which are missing in m. They are skipped when reaching the head of the queue.
+	queue []*chunkedMessage
+
+	// pendingSize is the total size of incomplete messages in m.
+	pendingSize int
+}
+
+type chunkedMessage struct {
+	id             uint64
+	chunks         [][]byte
+	chunksReceived int
+	size           int
+	deadline       uint64
+}
+
+func newChunkAssembler(maxMessageSize, maxPendingMessages, maxPendingSize int) *chunkAssembler {
+	return &chunkAssembler{
+		maxMessageSize:     maxMessageSize,
+		maxPendingMessages: maxPendingMessages,
+		maxPendingSize:     maxPendingSize,
+		m:                  make(map[uint64]*chunkedMessage),
+	}
+}
+
+// addChunk adds the chunk from data to ca.
+//
+// It returns the assembled message if all its chunks are received. Otherwise nil is returned.
+// The returned message doesn't refer to data.
+func (ca *chunkAssembler) addChunk(data []byte) ([]byte, error) {
+	if len(data) < chunkHeaderSize {
+		return nil, fmt.Errorf("too short chunk; got %d bytes; want at least %d bytes", len(data), chunkHeaderSize)
+	}
+	id := binary.BigEndian.Uint64(data[2:10])
+	seqNum := int(data[10])
+	seqCount := int(data[11])
+	payload := data[chunkHeaderSize:]
+	if seqCount == 0 || seqCount > maxChunksPerMessage {
+		return nil, fmt.Errorf("unexpected number of chunks for message %X: %d; must be in the range [1..%d]", id, seqCount, maxChunksPerMessage)
+	}
+	if seqNum >= seqCount {
+		return nil, fmt.Errorf("unexpected chunk number for message %X: %d; must be smaller than %d", id, seqNum, seqCount)
+	}
+
+	ct := fasttime.UnixTimestamp()
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	ca.removeExpiredLocked(ct)
+
+	cm := ca.m[id]
+	if cm == nil {
+		cm = &chunkedMessage{
+			id:       id,
+			chunks:   make([][]byte, seqCount),
+			deadline: ct + chunkTimeoutSeconds,
+		}
+		ca.m[id] = cm
+		ca.queue = append(ca.queue, cm)
+	}
+	if len(cm.chunks) != seqCount {
+		ca.deleteLocked(cm)
+		return nil, fmt.Errorf("unexpected number of chunks for message %X: %d; previous chunks had %d", id, seqCount, len(cm.chunks))
+	}
+	if cm.chunks[seqNum] != nil {
+		// Ignore duplicate chunk
+		return nil, nil
+	}
+	if cm.size+len(payload) > ca.maxMessageSize {
+		ca.deleteLocked(cm)
+		return nil, fmt.Errorf("too big chunked message %X; it mustn't exceed %d bytes", id, ca.maxMessageSize)
+	}
+	cm.size += len(payload)
+	ca.pendingSize += len(payload)
+	cm.chunks[seqNum] = append([]byte{}, payload...)
+	cm.chunksReceived++
+	if cm.chunksReceived < seqCount {
+		ca.evictOldestLocked()
+		return nil, nil
+	}
+
+	ca.deleteLocked(cm)
+	msg := make([]byte, 0, cm.size)
+	for _, chunk := range cm.chunks {
+		msg = append(msg, chunk...)
+	}
+	return msg, nil
+}
+
+// removeExpiredLocked removes incomplete messages, which weren't assembled until their deadline.
+//
+// It inspects only the head of ca.queue, since messages are added to the queue in the order of their deadlines.
+func (ca *chunkAssembler) removeExpiredLocked(ct uint64) {
+	for len(ca.queue) > 0 {
+		cm := ca.queue[0]
+		if ca.m[cm.id] == cm && ct <= cm.deadline {
+			return
+		}
+		if ca.m[cm.id] == cm {
+			chunkedMessagesExpired.Inc()
+			ca.deleteLocked(cm)
+		}
+		ca.popQueueLocked()
+	}
+}
+
+// evictOldestLocked removes the oldest incomplete messages until their number and size fit the configured limits.
+func (ca *chunkAssembler) evictOldestLocked() {
+	for len(ca.queue) > 0 && (len(ca.m) > ca.maxPendingMessages || ca.pendingSize > ca.maxPendingSize) {
+		cm := ca.queue[0]
+		if ca.m[cm.id] == cm {
+			chunkedMessagesEvicted.Inc()
+			ca.deleteLocked(cm)
+		}
+		ca.popQueueLocked()
+	}
+}
+
+func (ca *chunkAssembler) popQueueLocked() {
+	ca.queue[0] = nil
+	ca.queue = ca.queue[1:]
+}
+
+// deleteLocked removes cm from incomplete messages.
+//
+// cm remains in ca.queue until it reaches the head of the queue.
+func (ca *chunkAssembler) deleteLocked(cm *chunkedMessage) {
+	delete(ca.m, cm.id)
+	ca.pendingSize -= cm.size
+}
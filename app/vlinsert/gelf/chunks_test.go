@@ -0,0 +1,148 @@
+package gelf
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func newTestChunk(id uint64, seqNum, seqCount byte, payload string) []byte {
+	b := []byte{0x1e, 0x0f}
+	b = binary.BigEndian.AppendUint64(b, id)
+	b = append(b, seqNum, seqCount)
+	return append(b, payload...)
+}
+
+func TestChunkAssembler_Success(t *testing.T) {
+	ca := newChunkAssembler(100, 10, 1000)
+
+	f := func(chunk []byte, resultExpected string) {
+		t.Helper()
+
+		if !isChunk(chunk) {
+			t.Fatalf("expecting chunk")
+		}
+		result, err := ca.addChunk(chunk)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(result) != resultExpected {
+			t.Fatalf("unexpected result; got %q; want %q", result, resultExpected)
+		}
+	}
+
+	// single chunk
+	f(newTestChunk(1, 0, 1, "foo"), "foo")
+
+	// chunks in order
+	f(newTestChunk(2, 0, 3, "foo"), "")
+	f(newTestChunk(2, 1, 3, "bar"), "")
+	f(newTestChunk(2, 2, 3, "baz"), "foobarbaz")
+
+	// interleaved chunks from distinct messages out of order with duplicates
+	f(newTestChunk(3, 1, 2, "bar"), "")
+	f(newTestChunk(4, 1, 2, "def"), "")
+	f(newTestChunk(3, 1, 2, "bar"), "")
+	f(newTestChunk(4, 0, 2, "abc"), "abcdef")
+	f(newTestChunk(3, 0, 2, "foo"), "foobar")
+
+	if len(ca.m) != 0 {
+		t.Fatalf("unexpected incomplete messages left: %d", len(ca.m))
+	}
+}
+
+func TestChunkAssembler_Failure(t *testing.T) {
+	f := func(chunks ...[]byte) {
+		t.Helper()
+
+		ca := newChunkAssembler(10, 10, 1000)
+		for i, chunk := range chunks {
+			_, err := ca.addChunk(chunk)
+			if i < len(chunks)-1 {
+				if err != nil {
+					t.Fatalf("unexpected error for chunk #%d: %s", i, err)
+				}
+				continue
+			}
+			if err == nil {
+				t.Fatalf("expecting non-nil error")
+			}
+		}
+		if len(ca.m) != 0 {
+			t.Fatalf("unexpected incomplete messages left: %d", len(ca.m))
+		}
+	}
+
+	// too short chunk
+	f([]byte{0x1e, 0x0f, 1, 2, 3})
+
+	// zero chunks
+	f(newTestChunk(1, 0, 0, "foo"))
+
+	// too many chunks
+	f(newTestChunk(1, 0, 129, "foo"))
+
+	// invalid chunk number
+	f(newTestChunk(1, 2, 2, "foo"))
+
+	// mismatched number of chunks
+	f(newTestChunk(1, 0, 2, "foo"), newTestChunk(1, 1, 3, "bar"))
+
+	// too big message
+	f(newTestChunk(1, 0, 2, "foobar"), newTestChunk(1, 1, 2, "bazqux"))
+}
+
+func TestChunkAssembler_EvictOldest(t *testing.T) {
+	f := func(maxPendingMessages, maxPendingSize int, chunks [][]byte, pendingIDsExpected []uint64) {
+		t.Helper()
+
+		ca := newChunkAssembler(100, maxPendingMessages, maxPendingSize)
+		for i, chunk := range chunks {
+			if _, err := ca.addChunk(chunk); err != nil {
+				t.Fatalf("unexpected error for chunk #%d: %s", i, err)
+			}
+		}
+		if len(ca.m) != len(pendingIDsExpected) {
+			t.Fatalf("unexpected number of incomplete messages; got %d; want %d", len(ca.m), len(pendingIDsExpected))
+		}
+		pendingSize := 0
+		for _, id := range pendingIDsExpected {
+			cm := ca.m[id]
+			if cm == nil {
+				t.Fatalf("missing incomplete message %d", id)
+			}
+			pendingSize += cm.size
+		}
+		if ca.pendingSize != pendingSize {
+			t.Fatalf("unexpected pending size; got %d; want %d", ca.pendingSize, pendingSize)
+		}
+	}
+
+	// limit on the number of incomplete messages
+	f(2, 1000, [][]byte{
+		newTestChunk(1, 0, 2, "foo"),
+		newTestChunk(2, 0, 2, "bar"),
+		newTestChunk(3, 0, 2, "baz"),
+	}, []uint64{2, 3})
+
+	// limit on the size of incomplete messages
+	f(10, 8, [][]byte{
+		newTestChunk(1, 0, 2, "foo"),
+		newTestChunk(2, 0, 2, "bar"),
+		newTestChunk(3, 0, 2, "baz"),
+	}, []uint64{2, 3})
+
+	// completed messages do not count towards limits
+	f(2, 1000, [][]byte{
+		newTestChunk(1, 0, 2, "foo"),
+		newTestChunk(2, 0, 2, "bar"),
+		newTestChunk(1, 1, 2, "foo"),
+		newTestChunk(3, 0, 2, "baz"),
+	}, []uint64{2, 3})
+
+	// the evicted message is assembled from scratch if its chunks continue arriving
+	f(1, 1000, [][]byte{
+		newTestChunk(1, 0, 2, "foo"),
+		newTestChunk(2, 0, 2, "bar"),
+		newTestChunk(1, 1, 2, "foo"),
+	}, []uint64{1})
+}
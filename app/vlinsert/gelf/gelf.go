@@ -0,0 +1,663 @@
+package gelf
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/insertutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/cgroup"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/writeconcurrencylimiter"
+)
+
+var (
+	streamFieldsTCP = flagutil.NewArrayString("gelf.streamFields.tcp", "Fields to use as log stream labels for logs ingested via the corresponding -gelf.listenAddr.tcp. "+
+		`See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#stream-fields`)
+	streamFieldsUDP = flagutil.NewArrayString("gelf.streamFields.udp", "Fields to use as log stream labels for logs ingested via the corresponding -gelf.listenAddr.udp. "+
+		`See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#stream-fields`)
+
+	ignoreFieldsTCP = flagutil.NewArrayString("gelf.ignoreFields.tcp", "Fields to ignore at logs ingested via the corresponding -gelf.listenAddr.tcp. "+
+		`See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#dropping-fields`)
+	ignoreFieldsUDP = flagutil.NewArrayString("gelf.ignoreFields.udp", "Fields to ignore at logs ingested via the corresponding -gelf.listenAddr.udp. "+
+		`See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#dropping-fields`)
+
+	extraFieldsTCP = flagutil.NewArrayString("gelf.extraFields.tcp", "Fields to add to logs ingested via the corresponding -gelf.listenAddr.tcp. "+
+		`See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#adding-extra-fields`)
+	extraFieldsUDP = flagutil.NewArrayString("gelf.extraFields.udp", "Fields to add to logs ingested via the corresponding -gelf.listenAddr.udp. "+
+		`See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#adding-extra-fields`)
+
+	tenantIDTCP = flagutil.NewArrayString("gelf.tenantID.tcp", "TenantID for logs ingested via the corresponding -gelf.listenAddr.tcp. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#multitenancy")
+	tenantIDUDP = flagutil.NewArrayString("gelf.tenantID.udp", "TenantID for logs ingested via the corresponding -gelf.listenAddr.udp. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#multitenancy")
+
+	listenAddrTCP = flagutil.NewArrayString("gelf.listenAddr.tcp", "Comma-separated list of TCP addresses to listen to for GELF messages. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/")
+	listenAddrUDP = flagutil.NewArrayString("gelf.listenAddr.udp", "Comma-separated list of UDP addresses to listen to for GELF messages. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/")
+
+	tlsEnable = flagutil.NewArrayBool("gelf.tls", "Whether to enable TLS for receiving GELF messages at the corresponding -gelf.listenAddr.tcp. "+
+		"The corresponding -gelf.tlsCertFile and -gelf.tlsKeyFile must be set if -gelf.tls is set. See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#security")
+	tlsCertFile = flagutil.NewArrayString("gelf.tlsCertFile", "Path to file with TLS certificate for the corresponding -gelf.listenAddr.tcp if the corresponding -gelf.tls is set. "+
+		"Prefer ECDSA certs instead of RSA certs as RSA certs are slower. The provided certificate file is automatically re-read every second, so it can be dynamically updated. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#security")
+	tlsKeyFile = flagutil.NewArrayString("gelf.tlsKeyFile", "Path to file with TLS key for the corresponding -gelf.listenAddr.tcp if the corresponding -gelf.tls is set. "+
+		"The provided key file is automatically re-read every second, so it can be dynamically updated. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#security")
+	tlsCipherSuites = flagutil.NewArrayString("gelf.tlsCipherSuites", "Optional list of TLS cipher suites for -gelf.listenAddr.tcp if -gelf.tls is set. "+
+		"See the list of supported cipher suites at https://pkg.go.dev/crypto/tls#pkg-constants . "+
+		"See also https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#security")
+	tlsMinVersion = flag.String("gelf.tlsMinVersion", "TLS13", "The minimum TLS version to use for -gelf.listenAddr.tcp if -gelf.tls is set. "+
+		"Supported values: TLS10, TLS11, TLS12, TLS13. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/gelf/#security")
+)
+
+// defaultStreamFields contains the default stream fields for the logs ingested via GELF.
+var defaultStreamFields = []string{"host"}
+
+// defaultMsgFields contains the default message fields for the logs ingested via GELF.
+var defaultMsgFields = []string{"short_message"}
+
+// MustInit initializes GELF receivers at the given -gelf.listenAddr.tcp and -gelf.listenAddr.udp ports.
+//
+// This function must be called after flag.Parse().
+//
+// MustStop() must be called in order to free up resources occupied by the initialized receivers.
+func MustInit() {
+	if workersStopCh != nil {
+		logger.Panicf("BUG: MustInit() called twice without MustStop() call")
+	}
+	workersStopCh = make(chan struct{})
+
+	for argIdx, addr := range *listenAddrTCP {
+		workersWG.Add(1)
+		go func(addr string, argIdx int) {
+			runTCPListener(addr, argIdx)
+			workersWG.Done()
+		}(addr, argIdx)
+	}
+
+	for argIdx, addr := range *listenAddrUDP {
+		workersWG.Add(1)
+		go func(addr string, argIdx int) {
+			runUDPListener(addr, argIdx)
+			workersWG.Done()
+		}(addr, argIdx)
+	}
+}
+
+var (
+	workersWG     sync.WaitGroup
+	workersStopCh chan struct{}
+)
+
+// MustStop stops GELF receivers initialized via MustInit()
+func MustStop() {
+	close(workersStopCh)
+	workersWG.Wait()
+	workersStopCh = nil
+}
+
+// RequestHandler processes GELF messages sent via HTTP.
+//
+// See https://go2docs.graylog.org/current/getting_in_log_data/gelf.html#GELFviaHTTP
+func RequestHandler(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestsTotal.Inc()
+
+	cp, err := insertutils.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "%s", err)
+		return
+	}
+	if len(cp.StreamFields) == 0 {
+		cp.StreamFields = defaultStreamFields
+	}
+	if len(cp.MsgFields) == 0 {
+		cp.MsgFields = defaultMsgFields
+	}
+	// GELF messages contain the timestamp in the "timestamp" field. Use it unless the time field is explicitly set in the request.
+	timeField := ""
+	if cp.TimeField != "_time" {
+		timeField = cp.TimeField
+	}
+
+	if err := vlstorage.CanWriteData(); err != nil {
+		httpserver.Errorf(w, r, "%s", err)
+		return
+	}
+
+	reader := r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "gzip":
+		zr, err := common.GetGzipReader(reader)
+		if err != nil {
+			httpserver.Errorf(w, r, "cannot read gzipped GELF request: %s", err)
+			return
+		}
+		defer common.PutGzipReader(zr)
+		reader = zr
+	case "deflate":
+		zr, err := common.GetZlibReader(reader)
+		if err != nil {
+			httpserver.Errorf(w, r, "cannot read deflated GELF request: %s", err)
+			return
+		}
+		defer common.PutZlibReader(zr)
+		reader = zr
+	}
+
+	wcr := writeconcurrencylimiter.GetReader(reader)
+	data, err := io.ReadAll(wcr)
+	writeconcurrencylimiter.PutReader(wcr)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot read request body: %s", err)
+		return
+	}
+
+	lmp := cp.NewLogMessageProcessor("gelf_http")
+	err = processHTTPRequest(data, startTime.UnixNano(), timeField, cp.MsgFields, lmp)
+	lmp.MustClose()
+	if err != nil {
+		httpErrorsTotal.Inc()
+		httpserver.Errorf(w, r, "cannot process GELF request: %s", err)
+		return
+	}
+
+	// update requestDuration only for successfully parsed requests.
+	// There is no need in updating requestDuration for request errors,
+	// since their timings are usually much smaller than the timing for successful request parsing.
+	requestDuration.UpdateDuration(startTime)
+
+	// Graylog responds with 202 Accepted to GELF HTTP requests.
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// processHTTPRequest processes GELF messages from data sent via HTTP.
+//
+// Multiple messages can be sent in a single request, optionally delimited by newlines.
+func processHTTPRequest(data []byte, currentTimestamp int64, timeField string, msgFields []string, lmp insertutils.LogMessageProcessor) error {
+	p := logstorage.GetJSONParser()
+	defer logstorage.PutJSONParser(p)
+
+	n := 0
+	for {
+		msg, tail, err := insertutils.NextJSONObject(data)
+		if err != nil {
+			return fmt.Errorf("cannot read message #%d: %w", n, err)
+		}
+		if len(msg) == 0 {
+			return nil
+		}
+		data = tail
+
+		if err := processMessage(p, msg, currentTimestamp, timeField, msgFields, lmp); err != nil {
+			return fmt.Errorf("cannot process message #%d: %w", n, err)
+		}
+		n++
+	}
+}
+
+var (
+	requestsTotal   = metrics.NewCounter(`vl_http_requests_total{path="/insert/gelf"}`)
+	httpErrorsTotal = metrics.NewCounter(`vl_http_errors_total{path="/insert/gelf"}`)
+
+	requestDuration = metrics.NewHistogram(`vl_http_request_duration_seconds{path="/insert/gelf"}`)
+)
+
+func runUDPListener(addr string, argIdx int) {
+	ln, err := net.ListenPacket(netutil.GetUDPNetwork(), addr)
+	if err != nil {
+		logger.Fatalf("cannot start UDP GELF server at %q: %s", addr, err)
+	}
+
+	tenantIDStr := tenantIDUDP.GetOptionalArg(argIdx)
+	tenantID, err := logstorage.ParseTenantID(tenantIDStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -gelf.tenantID.udp=%q for -gelf.listenAddr.udp=%q: %s", tenantIDStr, addr, err)
+	}
+
+	streamFieldsStr := streamFieldsUDP.GetOptionalArg(argIdx)
+	streamFields, err := parseFieldsList(streamFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -gelf.streamFields.udp=%q for -gelf.listenAddr.udp=%q: %s", streamFieldsStr, addr, err)
+	}
+
+	ignoreFieldsStr := ignoreFieldsUDP.GetOptionalArg(argIdx)
+	ignoreFields, err := parseFieldsList(ignoreFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -gelf.ignoreFields.udp=%q for -gelf.listenAddr.udp=%q: %s", ignoreFieldsStr, addr, err)
+	}
+
+	extraFieldsStr := extraFieldsUDP.GetOptionalArg(argIdx)
+	extraFields, err := parseExtraFields(extraFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -gelf.extraFields.udp=%q for -gelf.listenAddr.udp=%q: %s", extraFieldsStr, addr, err)
+	}
+
+	cp := getCommonParams(tenantID, streamFields, ignoreFields, extraFields)
+
+	doneCh := make(chan struct{})
+	go func() {
+		serveUDP(ln, cp)
+		close(doneCh)
+	}()
+
+	logger.Infof("started accepting GELF messages at -gelf.listenAddr.udp=%q", addr)
+	<-workersStopCh
+	if err := ln.Close(); err != nil {
+		logger.Fatalf("gelf: cannot close UDP listener at %s: %s", addr, err)
+	}
+	<-doneCh
+	logger.Infof("finished accepting GELF messages at -gelf.listenAddr.udp=%q", addr)
+}
+
+func runTCPListener(addr string, argIdx int) {
+	var tlsConfig *tls.Config
+	if tlsEnable.GetOptionalArg(argIdx) {
+		certFile := tlsCertFile.GetOptionalArg(argIdx)
+		keyFile := tlsKeyFile.GetOptionalArg(argIdx)
+		tc, err := netutil.GetServerTLSConfig(certFile, keyFile, *tlsMinVersion, *tlsCipherSuites)
+		if err != nil {
+			logger.Fatalf("cannot load TLS cert from -gelf.tlsCertFile=%q, -gelf.tlsKeyFile=%q, -gelf.tlsMinVersion=%q, -gelf.tlsCipherSuites=%q: %s",
+				certFile, keyFile, *tlsMinVersion, *tlsCipherSuites, err)
+		}
+		tlsConfig = tc
+	}
+	ln, err := netutil.NewTCPListener("gelf", addr, false, tlsConfig)
+	if err != nil {
+		logger.Fatalf("gelf: cannot start TCP listener at %s: %s", addr, err)
+	}
+
+	tenantIDStr := tenantIDTCP.GetOptionalArg(argIdx)
+	tenantID, err := logstorage.ParseTenantID(tenantIDStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -gelf.tenantID.tcp=%q for -gelf.listenAddr.tcp=%q: %s", tenantIDStr, addr, err)
+	}
+
+	streamFieldsStr := streamFieldsTCP.GetOptionalArg(argIdx)
+	streamFields, err := parseFieldsList(streamFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -gelf.streamFields.tcp=%q for -gelf.listenAddr.tcp=%q: %s", streamFieldsStr, addr, err)
+	}
+
+	ignoreFieldsStr := ignoreFieldsTCP.GetOptionalArg(argIdx)
+	ignoreFields, err := parseFieldsList(ignoreFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -gelf.ignoreFields.tcp=%q for -gelf.listenAddr.tcp=%q: %s", ignoreFieldsStr, addr, err)
+	}
+
+	extraFieldsStr := extraFieldsTCP.GetOptionalArg(argIdx)
+	extraFields, err := parseExtraFields(extraFieldsStr)
+	if err != nil {
+		logger.Fatalf("cannot parse -gelf.extraFields.tcp=%q for -gelf.listenAddr.tcp=%q: %s", extraFieldsStr, addr, err)
+	}
+
+	cp := getCommonParams(tenantID, streamFields, ignoreFields, extraFields)
+
+	doneCh := make(chan struct{})
+	go func() {
+		serveTCP(ln, cp)
+		close(doneCh)
+	}()
+
+	logger.Infof("started accepting GELF messages at -gelf.listenAddr.tcp=%q", addr)
+	<-workersStopCh
+	if err := ln.Close(); err != nil {
+		logger.Fatalf("gelf: cannot close TCP listener at %s: %s", addr, err)
+	}
+	<-doneCh
+	logger.Infof("finished accepting GELF messages at -gelf.listenAddr.tcp=%q", addr)
+}
+
+func getCommonParams(tenantID logstorage.TenantID, streamFields, ignoreFields []string, extraFields []logstorage.Field) *insertutils.CommonParams {
+	if streamFields == nil {
+		streamFields = defaultStreamFields
+	}
+	return &insertutils.CommonParams{
+		TenantID:     tenantID,
+		MsgFields:    defaultMsgFields,
+		StreamFields: streamFields,
+		IgnoreFields: ignoreFields,
+		ExtraFields:  extraFields,
+	}
+}
+
+func serveUDP(ln net.PacketConn, cp *insertutils.CommonParams) {
+	ca := newChunkAssembler(insertutils.MaxLineSizeBytes.IntN(), maxPendingChunkedMessages, maxPendingChunkedMessagesSize)
+
+	gomaxprocs := cgroup.AvailableCPUs()
+	var wg sync.WaitGroup
+	localAddr := ln.LocalAddr()
+	for i := 0; i < gomaxprocs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			lmp := cp.NewLogMessageProcessor("gelf_udp")
+			defer lmp.MustClose()
+
+			p := logstorage.GetJSONParser()
+			defer logstorage.PutJSONParser(p)
+
+			var bb bytesutil.ByteBuffer
+			bb.B = bytesutil.ResizeNoCopyNoOverallocate(bb.B, 64*1024)
+			var msgBuf []byte
+			for {
+				bb.Reset()
+				bb.B = bb.B[:cap(bb.B)]
+				n, remoteAddr, err := ln.ReadFrom(bb.B)
+				if err != nil {
+					udpErrorsTotal.Inc()
+					var ne net.Error
+					if errors.As(err, &ne) {
+						if ne.Temporary() {
+							logger.Errorf("gelf: temporary error when listening for UDP at %q: %s", localAddr, err)
+							time.Sleep(time.Second)
+							continue
+						}
+						if strings.Contains(err.Error(), "use of closed network connection") {
+							break
+						}
+					}
+					logger.Errorf("gelf: cannot read UDP data from %s at %s: %s", remoteAddr, localAddr, err)
+					continue
+				}
+				bb.B = bb.B[:n]
+				udpRequestsTotal.Inc()
+
+				msg := bb.B
+				if isChunk(msg) {
+					msg, err = ca.addChunk(msg)
+					if err != nil {
+						udpErrorsTotal.Inc()
+						logger.Errorf("gelf: cannot process UDP chunk from %s at %s: %s", remoteAddr, localAddr, err)
+						continue
+					}
+					if msg == nil {
+						// Wait for the remaining chunks
+						continue
+					}
+				}
+				msgBuf, err = decompressMessage(msgBuf[:0], msg)
+				if err != nil {
+					udpErrorsTotal.Inc()
+					logger.Errorf("gelf: cannot decompress UDP message from %s at %s: %s", remoteAddr, localAddr, err)
+					continue
+				}
+				if err := vlstorage.CanWriteData(); err != nil {
+					logger.Errorf("gelf: cannot store UDP message from %s at %s: %s", remoteAddr, localAddr, err)
+					continue
+				}
+				if err := processMessage(p, msgBuf, time.Now().UnixNano(), "", cp.MsgFields, lmp); err != nil {
+					errorsTotal.Inc()
+					logger.Errorf("gelf: cannot process UDP message from %s at %s: %s", remoteAddr, localAddr, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// decompressMessage appends decompressed msg to dst and returns the result.
+//
+// GELF messages sent via UDP may be compressed with gzip or zlib. The compression is detected by magic bytes.
+// See https://go2docs.graylog.org/current/getting_in_log_data/gelf.html#Compression
+func decompressMessage(dst, msg []byte) ([]byte, error) {
+	var r io.Reader
+	switch {
+	case len(msg) >= 2 && msg[0] == 0x1f && msg[1] == 0x8b:
+		zr, err := common.GetGzipReader(bytes.NewReader(msg))
+		if err != nil {
+			return dst, fmt.Errorf("cannot read gzipped message: %w", err)
+		}
+		defer common.PutGzipReader(zr)
+		r = zr
+	case len(msg) >= 2 && msg[0] == 0x78 && (uint16(msg[0])<<8|uint16(msg[1]))%31 == 0:
+		zr, err := common.GetZlibReader(bytes.NewReader(msg))
+		if err != nil {
+			return dst, fmt.Errorf("cannot read zlib-compressed message: %w", err)
+		}
+		defer common.PutZlibReader(zr)
+		r = zr
+	default:
+		return append(dst, msg...), nil
+	}
+
+	maxSize := insertutils.MaxLineSizeBytes.IntN()
+	bb := bytesutil.ByteBuffer{
+		B: dst,
+	}
+	if _, err := bb.ReadFrom(io.LimitReader(r, int64(maxSize)+1)); err != nil {
+		return bb.B, fmt.Errorf("cannot decompress message: %w", err)
+	}
+	if len(bb.B)-len(dst) > maxSize {
+		return bb.B, fmt.Errorf("too big decompressed message; it mustn't exceed -insert.maxLineSizeBytes=%d bytes", maxSize)
+	}
+	return bb.B, nil
+}
+
+func serveTCP(ln net.Listener, cp *insertutils.CommonParams) {
+	var cm ingestserver.ConnsMap
+	cm.Init("gelf")
+
+	var wg sync.WaitGroup
+	addr := ln.Addr()
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) {
+				if ne.Temporary() {
+					logger.Errorf("gelf: temporary error when listening for TCP addr %q: %s", addr, err)
+					time.Sleep(time.Second)
+					continue
+				}
+				if strings.Contains(err.Error(), "use of closed network connection") {
+					break
+				}
+				logger.Fatalf("gelf: unrecoverable error when accepting TCP connections at %q: %s", addr, err)
+			}
+			logger.Fatalf("gelf: unexpected error when accepting TCP connections at %q: %s", addr, err)
+		}
+		if !cm.Add(c) {
+			_ = c.Close()
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			if err := processConn(c, cp); err != nil {
+				logger.Errorf("gelf: cannot process TCP data from %q at %q: %s", c.RemoteAddr(), addr, err)
+			}
+
+			cm.Delete(c)
+			_ = c.Close()
+			wg.Done()
+		}()
+	}
+
+	cm.CloseAll(0)
+	wg.Wait()
+}
+
+func processConn(c net.Conn, cp *insertutils.CommonParams) error {
+	if err := vlstorage.CanWriteData(); err != nil {
+		return err
+	}
+
+	lmp := cp.NewLogMessageProcessor("gelf_tcp")
+	err := processStream(c, cp.MsgFields, lmp)
+	lmp.MustClose()
+
+	return err
+}
+
+// processStream processes GELF messages from r sent via TCP.
+//
+// Messages are delimited by null bytes. Newline delimiters are supported too, since JSON messages cannot contain raw newlines.
+// See https://go2docs.graylog.org/current/getting_in_log_data/gelf.html#GELFviaTCP
+func processStream(r io.Reader, msgFields []string, lmp insertutils.LogMessageProcessor) error {
+	wcr := writeconcurrencylimiter.GetReader(r)
+	defer writeconcurrencylimiter.PutReader(wcr)
+
+	sc := bufio.NewScanner(wcr)
+	sc.Buffer(make([]byte, 0, 64*1024), insertutils.MaxLineSizeBytes.IntN())
+	sc.Split(splitMessages)
+
+	p := logstorage.GetJSONParser()
+	defer logstorage.PutJSONParser(p)
+
+	n := 0
+	for {
+		ok := sc.Scan()
+		wcr.DecConcurrency()
+		if !ok {
+			break
+		}
+		msg := bytes.TrimSpace(sc.Bytes())
+		if len(msg) == 0 {
+			continue
+		}
+		if err := processMessage(p, msg, time.Now().UnixNano(), "", msgFields, lmp); err != nil {
+			errorsTotal.Inc()
+			return fmt.Errorf("cannot process message #%d: %w", n, err)
+		}
+		n++
+	}
+	if err := sc.Err(); err != nil {
+		errorsTotal.Inc()
+		return fmt.Errorf("cannot read message #%d: %w", n, err)
+	}
+	return nil
+}
+
+func splitMessages(data []byte, atEOF bool) (int, []byte, error) {
+	if n := bytes.IndexAny(data, "\x00\n"); n >= 0 {
+		return n + 1, data[:n], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// processMessage parses GELF message from data and sends it to lmp.
+//
+// The timestamp is obtained from timeField if it isn't empty. Otherwise the timestamp is obtained from the "timestamp" field of the message.
+// The currentTimestamp is used for messages without timestamps.
+//
+// See https://go2docs.graylog.org/current/getting_in_log_data/gelf.html#GELFPayloadSpecification
+func processMessage(p *logstorage.JSONParser, data []byte, currentTimestamp int64, timeField string, msgFields []string, lmp insertutils.LogMessageProcessor) error {
+	if err := p.ParseLogMessage(data); err != nil {
+		return err
+	}
+
+	var ts int64
+	fields := p.Fields
+	for i := range fields {
+		f := &fields[i]
+		switch {
+		case f.Name == "version":
+			// Drop the GELF version, since it doesn't contain useful information.
+			f.Value = ""
+		case f.Name == "timestamp":
+			if timeField == "" {
+				nsecs, err := insertutils.ParseUnixTimestampSeconds(f.Value)
+				if err != nil {
+					return fmt.Errorf("cannot parse timestamp: %w", err)
+				}
+				ts = nsecs
+				f.Value = ""
+			}
+		case strings.HasPrefix(f.Name, "_"):
+			// Additional fields are prefixed with underscore. Strip it in the same way as Graylog does.
+			f.Name = f.Name[1:]
+		}
+	}
+
+	if timeField != "" {
+		nsecs, err := insertutils.ExtractTimestampRFC3339NanoFromFields(timeField, fields)
+		if err != nil {
+			return err
+		}
+		ts = nsecs
+	}
+	if ts == 0 {
+		ts = currentTimestamp
+	}
+
+	logstorage.RenameField(fields, msgFields, "_msg")
+	lmp.AddRow(ts, fields, nil)
+	return nil
+}
+
+var (
+	errorsTotal = metrics.NewCounter(`vl_errors_total{type="gelf"}`)
+
+	udpRequestsTotal = metrics.NewCounter(`vl_udp_reqests_total{type="gelf"}`)
+	udpErrorsTotal   = metrics.NewCounter(`vl_udp_errors_total{type="gelf"}`)
+
+	chunkedMessagesExpired = metrics.NewCounter(`vl_gelf_chunked_messages_expired_total`)
+	chunkedMessagesEvicted = metrics.NewCounter(`vl_gelf_chunked_messages_evicted_total`)
+)
+
+func parseFieldsList(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var a []string
+	err := json.Unmarshal([]byte(s), &a)
+	return a, err
+}
+
+func parseExtraFields(s string) ([]logstorage.Field, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var m map[string]string
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil, err
+	}
+	fields := make([]logstorage.Field, 0, len(m))
+	for k, v := range m {
+		fields = append(fields, logstorage.Field{
+			Name:  k,
+			Value: v,
+		})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+	return fields, nil
+}
@@ -0,0 +1,159 @@
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/insertutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+func TestProcessMessage_Success(t *testing.T) {
+	currentTimestamp := time.Now().UnixNano()
+
+	f := func(data, timeField string, timestampExpected int64, resultExpected string) {
+		t.Helper()
+
+		p := logstorage.GetJSONParser()
+		defer logstorage.PutJSONParser(p)
+
+		tlp := &insertutils.TestLogMessageProcessor{}
+		if err := processMessage(p, []byte(data), currentTimestamp, timeField, defaultMsgFields, tlp); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := tlp.Verify([]int64{timestampExpected}, resultExpected); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// minimal message
+	f(`{"version":"1.1","host":"h1","short_message":"foo"}`, "", currentTimestamp, `{"host":"h1","_msg":"foo"}`)
+
+	// message with timestamp, level and additional fields
+	f(`{"version":"1.1","host":"h1","short_message":"foo","full_message":"foo\nbar","timestamp":1718773640.123,"level":3,"_user_id":42,"_app":{"name":"x"}}`,
+		"", 1718773640123000000, `{"host":"h1","_msg":"foo","full_message":"foo\nbar","level":"3","user_id":"42","app.name":"x"}`)
+
+	// custom time field
+	f(`{"host":"h1","short_message":"foo","timestamp":1718773640,"_ts":"2024-06-18T23:37:20.123Z"}`, "ts", 1718753840123000000,
+		`{"host":"h1","_msg":"foo","timestamp":"1718773640"}`)
+}
+
+func TestProcessMessage_Failure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+
+		p := logstorage.GetJSONParser()
+		defer logstorage.PutJSONParser(p)
+
+		tlp := &insertutils.TestLogMessageProcessor{}
+		if err := processMessage(p, []byte(data), 0, "", defaultMsgFields, tlp); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	f(``)
+	f(`foo`)
+	f(`[1,2]`)
+	f(`{"short_message":"foo","timestamp":"bar"}`)
+}
+
+func TestProcessStream_Success(t *testing.T) {
+	f := func(data string, resultExpected string) {
+		t.Helper()
+
+		tlp := &insertutils.TestLogMessageProcessor{}
+		if err := processStream(bytes.NewBufferString(data), defaultMsgFields, tlp); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := tlp.Verify([]int64{1718773640000000000, 1718773641000000000}, resultExpected); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// null-delimited messages
+	f("{\"host\":\"h1\",\"short_message\":\"foo\",\"timestamp\":1718773640}\x00{\"host\":\"h2\",\"short_message\":\"bar\",\"timestamp\":1718773641}\x00",
+		`{"host":"h1","_msg":"foo"}
+{"host":"h2","_msg":"bar"}`)
+
+	// newline-delimited messages without the trailing delimiter
+	f("{\"host\":\"h1\",\"short_message\":\"foo\",\"timestamp\":1718773640}\n\n{\"host\":\"h2\",\"short_message\":\"bar\",\"timestamp\":1718773641}",
+		`{"host":"h1","_msg":"foo"}
+{"host":"h2","_msg":"bar"}`)
+}
+
+func TestProcessStream_Failure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+
+		tlp := &insertutils.TestLogMessageProcessor{}
+		if err := processStream(bytes.NewBufferString(data), defaultMsgFields, tlp); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	f("{\"short_message\":\"foo\"}\x00foobar\x00")
+	f("{\"short_message\":\"foo\"")
+}
+
+func TestProcessHTTPRequest(t *testing.T) {
+	f := func(data string, resultExpected string) {
+		t.Helper()
+
+		tlp := &insertutils.TestLogMessageProcessor{}
+		if err := processHTTPRequest([]byte(data), 0, "", defaultMsgFields, tlp); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := tlp.Verify([]int64{1718773640000000000, 1718773641000000000}, resultExpected); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f(`{"host":"h1","short_message":"foo","timestamp":1718773640}{"host":"h2","short_message":"bar","timestamp":1718773641}`,
+		`{"host":"h1","_msg":"foo"}
+{"host":"h2","_msg":"bar"}`)
+	f(`{"host":"h1","short_message":"foo","timestamp":1718773640}
+{"host":"h2","short_message":"bar","timestamp":1718773641}
+`, `{"host":"h1","_msg":"foo"}
+{"host":"h2","_msg":"bar"}`)
+}
+
+func TestDecompressMessage(t *testing.T) {
+	msg := `{"short_message":"foo"}`
+
+	f := func(data []byte) {
+		t.Helper()
+
+		result, err := decompressMessage(nil, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(result) != msg {
+			t.Fatalf("unexpected result; got %q; want %q", result, msg)
+		}
+	}
+
+	// plain message
+	f([]byte(msg))
+
+	// gzip
+	var bb bytes.Buffer
+	gw := gzip.NewWriter(&bb)
+	_, _ = gw.Write([]byte(msg))
+	_ = gw.Close()
+	f(bb.Bytes())
+
+	// zlib
+	bb.Reset()
+	zw := zlib.NewWriter(&bb)
+	_, _ = zw.Write([]byte(msg))
+	_ = zw.Close()
+	f(bb.Bytes())
+
+	// invalid gzip
+	if _, err := decompressMessage(nil, []byte{0x1f, 0x8b, 1, 2, 3}); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
@@ -0,0 +1,61 @@
+package insertutils
+
+import (
+	"fmt"
+)
+
+// NextJSONObject returns the next JSON object from data, which contains a stream of JSON objects delimited by optional whitespace.
+//
+// It returns the object and the tail left after the object. Empty object and tail are returned if data contains only whitespace.
+//
+// The returned object isn't validated - it must be parsed with JSON parser afterwards.
+// This function is useful for protocols, which send concatenated JSON objects without newline delimiters such as Splunk HEC.
+func NextJSONObject(data []byte) ([]byte, []byte, error) {
+	data = skipJSONWhitespace(data)
+	if len(data) == 0 {
+		return nil, nil, nil
+	}
+	if data[0] != '{' {
+		return nil, data, fmt.Errorf("unexpected char at the start of JSON object: %q; want '{'", data[0])
+	}
+
+	depth := 0
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			switch c {
+			case '\\':
+				// Skip the escaped char
+				i++
+			case '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return data[:i+1], data[i+1:], nil
+			}
+		}
+	}
+	return nil, data, fmt.Errorf("unexpected end of JSON object")
+}
+
+func skipJSONWhitespace(data []byte) []byte {
+	for len(data) > 0 {
+		switch data[0] {
+		case ' ', '\t', '\n', '\r':
+			data = data[1:]
+		default:
+			return data
+		}
+	}
+	return data
+}
@@ -0,0 +1,55 @@
+package insertutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNextJSONObject_Success(t *testing.T) {
+	f := func(data string, objectsExpected []string) {
+		t.Helper()
+
+		var objects []string
+		tail := []byte(data)
+		for {
+			obj, tailLocal, err := NextJSONObject(tail)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(obj) == 0 {
+				break
+			}
+			objects = append(objects, string(obj))
+			tail = tailLocal
+		}
+		if !reflect.DeepEqual(objects, objectsExpected) {
+			t.Fatalf("unexpected objects;\ngot\n%q\nwant\n%q", objects, objectsExpected)
+		}
+	}
+
+	f("", nil)
+	f(" \n\t\r ", nil)
+	f(`{}`, []string{`{}`})
+	f(`{"a":"b"}{"c":1}`, []string{`{"a":"b"}`, `{"c":1}`})
+	f(` {"a":{"b":[1,{"c":2}]}}
+{"d":"}{\"]"}  `, []string{`{"a":{"b":[1,{"c":2}]}}`, `{"d":"}{\"]"}`})
+}
+
+func TestNextJSONObject_Failure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+
+		obj, _, err := NextJSONObject([]byte(data))
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if len(obj) > 0 {
+			t.Fatalf("unexpected non-empty object: %q", obj)
+		}
+	}
+
+	f("foo")
+	f(`[1,2]`)
+	f(`{"a":"b"`)
+	f(`{"a":"}`)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
@@ -67,3 +68,51 @@ func ParseUnixTimestamp(s string) (int64, error) {
 	n *= 1e6
 	return n, nil
 }
+
+// ParseUnixTimestampSeconds parses s as unix timestamp in seconds with optional fractional part and returns the parsed timestamp in nanoseconds.
+//
+// For example, 1718773640.123456 is parsed into 1718773640123456000.
+func ParseUnixTimestampSeconds(s string) (int64, error) {
+	n := strings.IndexByte(s, '.')
+	if n < 0 {
+		n = len(s)
+	}
+	secsStr, fracStr := s[:n], s[min(n+1, len(s)):]
+	if strings.ContainsAny(s, "eE") || len(secsStr) == 0 || secsStr[0] == '-' {
+		// Slow path - parse the timestamp as float.
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse unix timestamp in seconds from %q: %w", s, err)
+		}
+		if f >= math.MaxInt64/1e9 || f <= math.MinInt64/1e9 {
+			return 0, fmt.Errorf("too big unix timestamp in seconds: %s", s)
+		}
+		return int64(math.Round(f * 1e9)), nil
+	}
+
+	secs, err := strconv.ParseUint(secsStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse unix timestamp in seconds from %q: %w", s, err)
+	}
+	const maxSecs = math.MaxInt64 / 1_000_000_000
+	if secs >= maxSecs {
+		return 0, fmt.Errorf("too big unix timestamp in seconds: %d; must be smaller than %d", secs, maxSecs)
+	}
+	nsecs := int64(secs) * 1e9
+	if fracStr == "" {
+		return nsecs, nil
+	}
+
+	// Take into account up to 9 digits of the fractional part, since they fit nanosecond precision.
+	if len(fracStr) > 9 {
+		fracStr = fracStr[:9]
+	}
+	frac, err := strconv.ParseUint(fracStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse fractional part of unix timestamp in seconds from %q: %w", s, err)
+	}
+	for i := len(fracStr); i < 9; i++ {
+		frac *= 10
+	}
+	return nsecs + int64(frac), nil
+}
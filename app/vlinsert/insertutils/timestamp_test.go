@@ -86,3 +86,48 @@ func TestExtractTimestampRFC3339NanoFromFields_Error(t *testing.T) {
 	f("2024-06-18")
 	f("2024-06-18T23:37")
 }
+
+func TestParseUnixTimestampSeconds_Success(t *testing.T) {
+	f := func(s string, nsecsExpected int64) {
+		t.Helper()
+
+		nsecs, err := ParseUnixTimestampSeconds(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if nsecs != nsecsExpected {
+			t.Fatalf("unexpected nsecs for %q; got %d; want %d", s, nsecs, nsecsExpected)
+		}
+	}
+
+	f("0", 0)
+	f("1718773640", 1718773640000000000)
+	f("1718773640.", 1718773640000000000)
+	f("1718773640.1", 1718773640100000000)
+	f("1718773640.123456", 1718773640123456000)
+	f("1718773640.123456789", 1718773640123456789)
+	f("1718773640.1234567891234", 1718773640123456789)
+	f("1.5e9", 1500000000000000000)
+	f("-1.5", -1500000000)
+}
+
+func TestParseUnixTimestampSeconds_Error(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+
+		nsecs, err := ParseUnixTimestampSeconds(s)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if nsecs != 0 {
+			t.Fatalf("unexpected nsecs; got %d; want %d", nsecs, 0)
+		}
+	}
+
+	f("")
+	f("foobar")
+	f("123.foo")
+	f("123foo")
+	f("99999999999999999999")
+	f("1e20")
+}
@@ -7,21 +7,29 @@ import (
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/datadog"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/elasticsearch"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/fluentforward"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/gelf"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/journald"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/jsonline"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/loki"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/opentelemetry"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/splunk"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/syslog"
 )
 
 // Init initializes vlinsert
 func Init() {
 	syslog.MustInit()
+	fluentforward.MustInit()
+	gelf.MustInit()
+	splunk.MustInit()
 }
 
 // Stop stops vlinsert
 func Stop() {
 	syslog.MustStop()
+	fluentforward.MustStop()
+	gelf.MustStop()
 }
 
 // RequestHandler handles insert requests for VictoriaLogs
@@ -39,6 +47,9 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 	case "/jsonline":
 		jsonline.RequestHandler(w, r)
 		return true
+	case "/gelf":
+		gelf.RequestHandler(w, r)
+		return true
 	case "/ready":
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(200)
@@ -61,6 +72,9 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 	case strings.HasPrefix(path, "/datadog/"):
 		path = strings.TrimPrefix(path, "/datadog")
 		return datadog.RequestHandler(path, w, r)
+	case strings.HasPrefix(path, "/splunk/"):
+		path = strings.TrimPrefix(path, "/splunk")
+		return splunk.RequestHandler(path, w, r)
 	default:
 		return false
 	}
@@ -0,0 +1,514 @@
+package splunk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/insertutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/writeconcurrencylimiter"
+)
+
+var (
+	splunkStreamFields = flagutil.NewArrayString("splunk.streamFields", "Fields to use as log stream labels for logs ingested via Splunk HEC API "+
+		"if they aren't set via _stream_fields query arg or via VL-Stream-Fields request header. By default host, source, sourcetype and index fields are used. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/splunk/#stream-fields")
+	splunkIgnoreFields = flagutil.NewArrayString("splunk.ignoreFields", "Fields to ignore at logs ingested via Splunk HEC API "+
+		"if they aren't set via ignore_fields query arg or via VL-Ignore-Fields request header. "+
+		"See https://docs.victoriametrics.com/victorialogs/data-ingestion/splunk/#dropping-fields")
+	splunkTokens = flagutil.NewArrayString("splunk.tokens", "Optional list of Splunk HEC tokens, which are accepted by Splunk HEC API. "+
+		"Every token may have optional =accountID:projectID suffix with the tenant to store the logs ingested with this token to. "+
+		"If the list is empty, then tokens aren't verified. See https://docs.victoriametrics.com/victorialogs/data-ingestion/splunk/#authorization")
+)
+
+// defaultStreamFields contains the default stream fields for the logs ingested via Splunk HEC API.
+var defaultStreamFields = []string{"host", "source", "sourcetype", "index"}
+
+// defaultMsgFields contains the default message fields for events with JSON objects.
+var defaultMsgFields = []string{"message", "msg", "log", "line"}
+
+// MustInit initializes Splunk HEC API handlers.
+//
+// This function must be called after flag.Parse().
+func MustInit() {
+	tokens, err := parseTokens(*splunkTokens)
+	if err != nil {
+		logger.Fatalf("cannot parse -splunk.tokens: %s", err)
+	}
+	tokensByValue = tokens
+}
+
+// tokensByValue contains tokens parsed from -splunk.tokens.
+//
+// nil tokensByValue means that tokens aren't verified.
+var tokensByValue map[string]*tokenInfo
+
+type tokenInfo struct {
+	// tenantID is the tenant to store the logs ingested with the token to.
+	tenantID logstorage.TenantID
+
+	// hasTenantID is set to true if tenantID is configured for the token.
+	hasTenantID bool
+}
+
+func parseTokens(a []string) (map[string]*tokenInfo, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+	m := make(map[string]*tokenInfo, len(a))
+	for _, s := range a {
+		token := s
+		var ti tokenInfo
+		if n := strings.IndexByte(s, '='); n >= 0 {
+			token = s[:n]
+			tenantID, err := logstorage.ParseTenantID(s[n+1:])
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse tenant for token %q: %w", token, err)
+			}
+			ti.tenantID = tenantID
+			ti.hasTenantID = true
+		}
+		if token == "" {
+			return nil, fmt.Errorf("token cannot be empty in %q", s)
+		}
+		if _, ok := m[token]; ok {
+			return nil, fmt.Errorf("duplicate token %q", token)
+		}
+		m[token] = &ti
+	}
+	return m, nil
+}
+
+// RequestHandler processes Splunk HEC API requests.
+//
+// See https://docs.splunk.com/Documentation/Splunk/latest/Data/HECRESTendpoints
+func RequestHandler(path string, w http.ResponseWriter, r *http.Request) bool {
+	switch path {
+	case "/services/collector", "/services/collector/event", "/services/collector/event/1.0":
+		handleEvent(w, r)
+		return true
+	case "/services/collector/raw", "/services/collector/raw/1.0":
+		handleRaw(w, r)
+		return true
+	case "/services/collector/health", "/services/collector/health/1.0":
+		handleHealth(w)
+		return true
+	default:
+		return false
+	}
+}
+
+func handleEvent(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	requestsEventTotal.Inc()
+
+	cp, err := getCommonParams(r)
+	if err != nil {
+		errorsEventTotal.Inc()
+		writeErrorResponse(w, r, err)
+		return
+	}
+
+	reader := r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := common.GetGzipReader(reader)
+		if err != nil {
+			errorsEventTotal.Inc()
+			writeErrorResponse(w, r, newInvalidDataError(fmt.Errorf("cannot read gzipped request: %w", err)))
+			return
+		}
+		defer common.PutGzipReader(zr)
+		reader = zr
+	}
+
+	wcr := writeconcurrencylimiter.GetReader(reader)
+	data, err := io.ReadAll(wcr)
+	writeconcurrencylimiter.PutReader(wcr)
+	if err != nil {
+		errorsEventTotal.Inc()
+		writeErrorResponse(w, r, newInvalidDataError(fmt.Errorf("cannot read request body: %w", err)))
+		return
+	}
+	if len(data) == 0 {
+		errorsEventTotal.Inc()
+		writeErrorResponse(w, r, errNoData)
+		return
+	}
+
+	// Splunk HEC sends the event timestamp in the "time" field. Use it unless the time field is explicitly set in the request.
+	timeField := ""
+	if cp.TimeField != "_time" {
+		timeField = cp.TimeField
+	}
+	defaultFields := getDefaultFields(r)
+
+	lmp := cp.NewLogMessageProcessor("splunk_event")
+	err = processEventsRequest(data, startTime.UnixNano(), timeField, cp.MsgFields, defaultFields, lmp)
+	lmp.MustClose()
+	if err != nil {
+		errorsEventTotal.Inc()
+		writeErrorResponse(w, r, err)
+		return
+	}
+
+	// update requestEventDuration only for successfully parsed requests.
+	// There is no need in updating requestEventDuration for request errors,
+	// since their timings are usually much smaller than the timing for successful request parsing.
+	requestEventDuration.UpdateDuration(startTime)
+	writeSuccessResponse(w)
+}
+
+func handleRaw(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	requestsRawTotal.Inc()
+
+	cp, err := getCommonParams(r)
+	if err != nil {
+		errorsRawTotal.Inc()
+		writeErrorResponse(w, r, err)
+		return
+	}
+
+	reader := r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := common.GetGzipReader(reader)
+		if err != nil {
+			errorsRawTotal.Inc()
+			writeErrorResponse(w, r, newInvalidDataError(fmt.Errorf("cannot read gzipped request: %w", err)))
+			return
+		}
+		defer common.PutGzipReader(zr)
+		reader = zr
+	}
+
+	defaultFields := getDefaultFields(r)
+
+	lmp := cp.NewLogMessageProcessor("splunk_raw")
+	streamName := fmt.Sprintf("remoteAddr=%s, requestURI=%q", httpserver.GetQuotedRemoteAddr(r), r.RequestURI)
+	err = processRawRequest(streamName, reader, startTime.UnixNano(), defaultFields, lmp)
+	lmp.MustClose()
+	if err != nil {
+		errorsRawTotal.Inc()
+		writeErrorResponse(w, r, newInvalidDataError(err))
+		return
+	}
+
+	// update requestRawDuration only for successfully parsed requests.
+	// There is no need in updating requestRawDuration for request errors,
+	// since their timings are usually much smaller than the timing for successful request parsing.
+	requestRawDuration.UpdateDuration(startTime)
+	writeSuccessResponse(w)
+}
+
+func handleHealth(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := vlstorage.CanWriteData(); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, `{"text":"HEC is unhealthy","code":18}`)
+		return
+	}
+	fmt.Fprintf(w, `{"text":"HEC is healthy","code":17}`)
+}
+
+var (
+	requestsEventTotal   = metrics.NewCounter(`vl_http_requests_total{path="/insert/splunk/services/collector/event"}`)
+	errorsEventTotal     = metrics.NewCounter(`vl_http_errors_total{path="/insert/splunk/services/collector/event"}`)
+	requestEventDuration = metrics.NewHistogram(`vl_http_request_duration_seconds{path="/insert/splunk/services/collector/event"}`)
+
+	requestsRawTotal   = metrics.NewCounter(`vl_http_requests_total{path="/insert/splunk/services/collector/raw"}`)
+	errorsRawTotal     = metrics.NewCounter(`vl_http_errors_total{path="/insert/splunk/services/collector/raw"}`)
+	requestRawDuration = metrics.NewHistogram(`vl_http_request_duration_seconds{path="/insert/splunk/services/collector/raw"}`)
+)
+
+// getCommonParams returns common params for Splunk HEC request r.
+//
+// It verifies the token from r and sets the tenant configured for the token.
+func getCommonParams(r *http.Request) (*insertutils.CommonParams, error) {
+	cp, err := insertutils.GetCommonParams(r)
+	if err != nil {
+		return nil, newInvalidDataError(err)
+	}
+
+	if tokensByValue != nil {
+		token, err := getToken(r)
+		if err != nil {
+			return nil, err
+		}
+		ti := tokensByValue[token]
+		if ti == nil {
+			return nil, errInvalidToken
+		}
+		if ti.hasTenantID {
+			cp.TenantID = ti.tenantID
+		}
+	}
+
+	if len(cp.StreamFields) == 0 {
+		cp.StreamFields = *splunkStreamFields
+		if len(cp.StreamFields) == 0 {
+			cp.StreamFields = defaultStreamFields
+		}
+	}
+	if len(cp.IgnoreFields) == 0 {
+		cp.IgnoreFields = *splunkIgnoreFields
+	}
+	if len(cp.MsgFields) == 0 {
+		cp.MsgFields = defaultMsgFields
+	}
+
+	if err := vlstorage.CanWriteData(); err != nil {
+		return nil, &hecError{
+			statusCode: http.StatusServiceUnavailable,
+			code:       9,
+			text:       "Server is busy",
+			err:        err,
+		}
+	}
+
+	return cp, nil
+}
+
+// getToken returns Splunk HEC token from r.
+//
+// The token is passed in `Authorization: Splunk <token>` request header.
+func getToken(r *http.Request) (string, error) {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return "", errTokenRequired
+	}
+	n := strings.IndexByte(auth, ' ')
+	if n < 0 || !strings.EqualFold(auth[:n], "Splunk") {
+		return "", errInvalidAuthorization
+	}
+	return strings.TrimSpace(auth[n+1:]), nil
+}
+
+// getDefaultFields returns default fields for the ingested events from query args at r.
+//
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTinput#services.2Fcollector.2Fevent
+func getDefaultFields(r *http.Request) []logstorage.Field {
+	var fields []logstorage.Field
+	q := r.URL.Query()
+	for _, name := range []string{"host", "source", "sourcetype", "index"} {
+		if v := q.Get(name); v != "" {
+			fields = append(fields, logstorage.Field{
+				Name:  name,
+				Value: v,
+			})
+		}
+	}
+	return fields
+}
+
+// processEventsRequest processes a stream of Splunk HEC events from data and sends them to lmp.
+//
+// The timestamp is obtained from timeField if it isn't empty. Otherwise the timestamp is obtained from the "time" field of the event.
+// The currentTimestamp is used for events without timestamps. defaultFields are added to events, which do not contain them.
+//
+// See https://docs.splunk.com/Documentation/Splunk/latest/Data/FormateventsforHTTPEventCollector
+func processEventsRequest(data []byte, currentTimestamp int64, timeField string, msgFields []string, defaultFields []logstorage.Field, lmp insertutils.LogMessageProcessor) error {
+	p := logstorage.GetJSONParser()
+	defer logstorage.PutJSONParser(p)
+
+	n := 0
+	for {
+		obj, tail, err := insertutils.NextJSONObject(data)
+		if err != nil {
+			return newInvalidDataError(fmt.Errorf("cannot read event #%d: %w", n, err))
+		}
+		if len(obj) == 0 {
+			if n == 0 {
+				return errNoData
+			}
+			return nil
+		}
+		data = tail
+
+		if err := p.ParseLogMessage(obj); err != nil {
+			return newInvalidDataError(fmt.Errorf("cannot parse event #%d: %w", n, err))
+		}
+		if err := processEvent(p.Fields, currentTimestamp, timeField, msgFields, defaultFields, lmp); err != nil {
+			var he *hecError
+			if errors.As(err, &he) {
+				return err
+			}
+			return newInvalidDataError(fmt.Errorf("cannot process event #%d: %w", n, err))
+		}
+		n++
+	}
+}
+
+func processEvent(fields []logstorage.Field, currentTimestamp int64, timeField string, msgFields []string, defaultFields []logstorage.Field, lmp insertutils.LogMessageProcessor) error {
+	var ts int64
+	hasEvent := false
+	hasMsg := false
+	for i := range fields {
+		f := &fields[i]
+		switch {
+		case f.Name == "time":
+			if timeField == "" {
+				nsecs, err := insertutils.ParseUnixTimestampSeconds(f.Value)
+				if err != nil {
+					return fmt.Errorf("cannot parse time: %w", err)
+				}
+				ts = nsecs
+				f.Value = ""
+			}
+		case f.Name == "event":
+			// The event is a string. Use it as log message.
+			if f.Value == "" {
+				return errEventBlank
+			}
+			f.Name = "_msg"
+			hasEvent = true
+			hasMsg = true
+		case strings.HasPrefix(f.Name, "event."):
+			// The event is a JSON object. Store its fields as is.
+			f.Name = f.Name[len("event."):]
+			hasEvent = true
+		case strings.HasPrefix(f.Name, "fields."):
+			// Indexed fields.
+			// See https://docs.splunk.com/Documentation/Splunk/latest/Data/IFXandHEC
+			f.Name = f.Name[len("fields."):]
+		}
+	}
+	if !hasEvent {
+		return errEventRequired
+	}
+
+	if timeField != "" {
+		nsecs, err := insertutils.ExtractTimestampRFC3339NanoFromFields(timeField, fields)
+		if err != nil {
+			return err
+		}
+		ts = nsecs
+	}
+	if ts == 0 {
+		ts = currentTimestamp
+	}
+
+	if !hasMsg {
+		logstorage.RenameField(fields, msgFields, "_msg")
+	}
+
+	for _, df := range defaultFields {
+		if !hasField(fields, df.Name) {
+			fields = append(fields, df)
+		}
+	}
+
+	lmp.AddRow(ts, fields, nil)
+	return nil
+}
+
+func hasField(fields []logstorage.Field, name string) bool {
+	for _, f := range fields {
+		if f.Name == name && f.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// processRawRequest processes newline-delimited log messages from r and sends them with the given timestamp ts to lmp.
+//
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTinput#services.2Fcollector.2Fraw
+func processRawRequest(streamName string, r io.Reader, ts int64, defaultFields []logstorage.Field, lmp insertutils.LogMessageProcessor) error {
+	wcr := writeconcurrencylimiter.GetReader(r)
+	defer writeconcurrencylimiter.PutReader(wcr)
+
+	lr := insertutils.NewLineReader(streamName, wcr)
+
+	var fields []logstorage.Field
+	for lr.NextLine() {
+		wcr.DecConcurrency()
+		line := bytes.TrimSpace(lr.Line)
+		if len(line) == 0 {
+			continue
+		}
+		fields = append(fields[:0], logstorage.Field{
+			Name:  "_msg",
+			Value: bytesutil.ToUnsafeString(line),
+		})
+		fields = append(fields, defaultFields...)
+		lmp.AddRow(ts, fields, nil)
+	}
+	if err := lr.Err(); err != nil {
+		return fmt.Errorf("cannot read raw events: %w", err)
+	}
+	return nil
+}
+
+// hecError is an error returned to Splunk HEC clients.
+//
+// See https://docs.splunk.com/Documentation/Splunk/latest/Data/TroubleshootHTTPEventCollector#Possible_error_codes
+type hecError struct {
+	statusCode int
+	code       int
+	text       string
+	err        error
+}
+
+// Error implements error interface.
+func (e *hecError) Error() string {
+	if e.err == nil {
+		return e.text
+	}
+	return fmt.Sprintf("%s: %s", e.text, e.err)
+}
+
+// Unwrap returns e.err.
+func (e *hecError) Unwrap() error {
+	return e.err
+}
+
+var (
+	errTokenRequired        = &hecError{statusCode: http.StatusUnauthorized, code: 2, text: "Token is required"}
+	errInvalidAuthorization = &hecError{statusCode: http.StatusUnauthorized, code: 3, text: "Invalid authorization"}
+	errInvalidToken         = &hecError{statusCode: http.StatusForbidden, code: 4, text: "Invalid token"}
+	errNoData               = &hecError{statusCode: http.StatusBadRequest, code: 5, text: "No data"}
+	errEventRequired        = &hecError{statusCode: http.StatusBadRequest, code: 12, text: "Event field is required"}
+	errEventBlank           = &hecError{statusCode: http.StatusBadRequest, code: 13, text: "Event field cannot be blank"}
+)
+
+func newInvalidDataError(err error) error {
+	return &hecError{
+		statusCode: http.StatusBadRequest,
+		code:       6,
+		text:       "Invalid data format",
+		err:        err,
+	}
+}
+
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	he := &hecError{
+		statusCode: http.StatusBadRequest,
+		code:       6,
+		text:       "Invalid data format",
+	}
+	_ = errors.As(err, &he)
+
+	logger.Warnf("splunk: remoteAddr: %s; requestURI: %s; %s", httpserver.GetQuotedRemoteAddr(r), httpserver.GetRequestURI(r), err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(he.statusCode)
+	fmt.Fprintf(w, `{"text":%q,"code":%d}`, he.text, he.code)
+}
+
+func writeSuccessResponse(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"text":"Success","code":0}`)
+}
@@ -0,0 +1,166 @@
+package splunk
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/insertutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+func TestParseTokens_Success(t *testing.T) {
+	f := func(a []string, resultExpected map[string]*tokenInfo) {
+		t.Helper()
+
+		result, err := parseTokens(a)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result;\ngot\n%v\nwant\n%v", result, resultExpected)
+		}
+	}
+
+	f(nil, nil)
+	f([]string{"foo"}, map[string]*tokenInfo{
+		"foo": {},
+	})
+	f([]string{"foo", "bar=12:34", "baz="}, map[string]*tokenInfo{
+		"foo": {},
+		"bar": {
+			tenantID: logstorage.TenantID{
+				AccountID: 12,
+				ProjectID: 34,
+			},
+			hasTenantID: true,
+		},
+		"baz": {
+			hasTenantID: true,
+		},
+	})
+}
+
+func TestParseTokens_Failure(t *testing.T) {
+	f := func(a []string) {
+		t.Helper()
+
+		_, err := parseTokens(a)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	f([]string{""})
+	f([]string{"=1:2"})
+	f([]string{"foo=bar"})
+	f([]string{"foo", "foo=1:2"})
+}
+
+func TestProcessEventsRequest_Success(t *testing.T) {
+	currentTimestamp := time.Now().UnixNano()
+
+	f := func(data, timeField string, defaultFields []logstorage.Field, timestampsExpected []int64, resultExpected string) {
+		t.Helper()
+
+		tlp := &insertutils.TestLogMessageProcessor{}
+		if err := processEventsRequest([]byte(data), currentTimestamp, timeField, defaultMsgFields, defaultFields, tlp); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := tlp.Verify(timestampsExpected, resultExpected); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// string events
+	f(`{"time":1718773640,"host":"h1","source":"s1","sourcetype":"st1","index":"main","event":"foo"}
+{"time":1718773640.123,"event":"bar","fields":{"region":"us","tags":["a","b"]}}`, "", nil, []int64{1718773640000000000, 1718773640123000000},
+		`{"host":"h1","source":"s1","sourcetype":"st1","index":"main","_msg":"foo"}
+{"_msg":"bar","region":"us","tags":"[\"a\",\"b\"]"}`)
+
+	// missing time
+	f(`{"event":"foo"}`, "", nil, []int64{currentTimestamp}, `{"_msg":"foo"}`)
+
+	// concatenated events without delimiters
+	f(`{"time":"1718773640","event":"foo"}{"time":1718773641,"event":123}`, "", nil, []int64{1718773640000000000, 1718773641000000000},
+		`{"_msg":"foo"}
+{"_msg":"123"}`)
+
+	// object events
+	f(`{"time":1718773640,"event":{"message":"foo","level":"info","kubernetes":{"pod":"p1"}}}
+{"time":1718773640,"event":{"line":"bar","source":"stdout"}}`, "", nil, []int64{1718773640000000000, 1718773640000000000},
+		`{"_msg":"foo","level":"info","kubernetes.pod":"p1"}
+{"_msg":"bar","source":"stdout"}`)
+
+	// default fields
+	f(`{"time":1718773640,"host":"h1","event":"foo"}`, "", []logstorage.Field{
+		{
+			Name:  "host",
+			Value: "default",
+		},
+		{
+			Name:  "index",
+			Value: "main",
+		},
+	}, []int64{1718773640000000000}, `{"host":"h1","_msg":"foo","index":"main"}`)
+
+	// custom time field
+	f(`{"time":1718773640,"event":{"message":"foo","ts":"2024-06-18T23:37:20.123Z"}}`, "ts", nil, []int64{1718753840123000000},
+		`{"time":"1718773640","_msg":"foo"}`)
+}
+
+func TestProcessEventsRequest_Failure(t *testing.T) {
+	f := func(data string, errExpected error) {
+		t.Helper()
+
+		tlp := &insertutils.TestLogMessageProcessor{}
+		err := processEventsRequest([]byte(data), 0, "", defaultMsgFields, nil, tlp)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if errExpected != nil && !errors.Is(err, errExpected) {
+			t.Fatalf("unexpected error; got %q; want %q", err, errExpected)
+		}
+	}
+
+	f("", errNoData)
+	f(" \n ", errNoData)
+	f(`{"time":123}`, errEventRequired)
+	f(`{"event":""}`, errEventBlank)
+	f(`{"event":"foo"}{"foo":"bar"}`, errEventRequired)
+	f(`foobar`, nil)
+	f(`{"event":"foo"`, nil)
+	f(`{"event":"foo","time":"bar"}`, nil)
+	f(`{"event":"foo"}[1,2]`, nil)
+}
+
+func TestProcessRawRequest(t *testing.T) {
+	f := func(data string, defaultFields []logstorage.Field, rowsExpected int, resultExpected string) {
+		t.Helper()
+
+		ts := time.Now().UnixNano()
+		var timestampsExpected []int64
+		for i := 0; i < rowsExpected; i++ {
+			timestampsExpected = append(timestampsExpected, ts)
+		}
+		tlp := &insertutils.TestLogMessageProcessor{}
+		if err := processRawRequest("test", bytes.NewBufferString(data), ts, defaultFields, tlp); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := tlp.Verify(timestampsExpected, resultExpected); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f("", nil, 0, "")
+	f("foo bar\n\n  baz \n", nil, 2, `{"_msg":"foo bar"}
+{"_msg":"baz"}`)
+	f("foo", []logstorage.Field{
+		{
+			Name:  "host",
+			Value: "h1",
+		},
+	}, 1, `{"_msg":"foo","host":"h1"}`)
+}
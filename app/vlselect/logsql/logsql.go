@@ -193,7 +193,8 @@ func ProcessHitsRequest(ctx context.Context, w http.ResponseWriter, r *http.Requ
 	}
 
 	// Execute the query
-	if err := vlstorage.RunQuery(ctx, tenantIDs, q, writeBlock); err != nil {
+	cq := newCachedQuery(r, "hits", tenantIDs, int64(step), int64(offset))
+	if err := runQueryWithResultsCache(ctx, tenantIDs, q, cq, writeBlock); err != nil {
 		httpserver.Errorf(w, r, "cannot execute query [%s]: %s", q, err)
 		return
 	}
@@ -452,6 +453,37 @@ func ProcessStreamsRequest(ctx context.Context, w http.ResponseWriter, r *http.R
 	WriteValuesWithHitsJSON(w, streams)
 }
 
+// ProcessTenantStatsRequest processes /select/logsql/tenant_stats request.
+//
+// See https://docs.victoriametrics.com/victorialogs/#per-tenant-limits
+func ProcessTenantStatsRequest(w http.ResponseWriter, r *http.Request) {
+	tss, err := vlstorage.GetTenantStats()
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot obtain tenant stats: %s", err)
+		return
+	}
+
+	// Parse optional tenant query arg
+	if tenant := r.FormValue("tenant"); tenant != "" {
+		tenantID, err := logstorage.ParseTenantID(tenant)
+		if err != nil {
+			httpserver.Errorf(w, r, "cannot parse tenant=%q: %s", tenant, err)
+			return
+		}
+		tssFiltered := tss[:0]
+		for _, ts := range tss {
+			if ts.TenantID == tenantID {
+				tssFiltered = append(tssFiltered, ts)
+			}
+		}
+		tss = tssFiltered
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteTenantStatsResponse(w, tss)
+}
+
 // ProcessLiveTailRequest processes live tailing request to /select/logsq/tail
 //
 // See https://docs.victoriametrics.com/victorialogs/querying/#live-tailing
@@ -742,7 +774,8 @@ func ProcessStatsQueryRangeRequest(ctx context.Context, w http.ResponseWriter, r
 		}
 	}
 
-	if err := vlstorage.RunQuery(ctx, tenantIDs, q, writeBlock); err != nil {
+	cq := newCachedQuery(r, "stats_query_range", tenantIDs, int64(step), 0)
+	if err := runQueryWithResultsCache(ctx, tenantIDs, q, cq, writeBlock); err != nil {
 		err = fmt.Errorf("cannot execute query [%s]: %s", q, err)
 		httpserver.SendPrometheusError(w, r, err)
 		return
@@ -0,0 +1,371 @@
+package logsql
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httputils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/lrucache"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/memory"
+)
+
+var (
+	disableCache = flag.Bool("search.disableCache", false, "Whether to disable the cache for /select/logsql/stats_query_range and /select/logsql/hits results. "+
+		"See https://docs.victoriametrics.com/victorialogs/querying/#results-cache")
+	cacheTimestampOffset = flag.Duration("search.cacheTimestampOffset", 5*time.Minute, "The duration before the current time, which isn't cached in the cache "+
+		"for /select/logsql/stats_query_range and /select/logsql/hits results. It should cover the maximum delay for log entries ingestion. "+
+		"See https://docs.victoriametrics.com/victorialogs/querying/#results-cache")
+)
+
+var resultsCache = lrucache.NewCache(getMaxResultsCacheSize)
+
+func getMaxResultsCacheSize() int {
+	maxResultsCacheSizeOnce.Do(func() {
+		maxResultsCacheSize = memory.Allowed() / 32
+	})
+	return maxResultsCacheSize
+}
+
+var (
+	maxResultsCacheSize     int
+	maxResultsCacheSizeOnce sync.Once
+)
+
+var (
+	_ = metrics.NewGauge(`vl_cache_entries{type="logsql/results"}`, func() float64 {
+		return float64(resultsCache.Len())
+	})
+	_ = metrics.NewGauge(`vl_cache_size_bytes{type="logsql/results"}`, func() float64 {
+		return float64(resultsCache.SizeBytes())
+	})
+	_ = metrics.NewGauge(`vl_cache_size_max_bytes{type="logsql/results"}`, func() float64 {
+		return float64(resultsCache.SizeMaxBytes())
+	})
+	_ = metrics.NewGauge(`vl_cache_requests_total{type="logsql/results"}`, func() float64 {
+		return float64(resultsCache.Requests())
+	})
+	_ = metrics.NewGauge(`vl_cache_misses_total{type="logsql/results"}`, func() float64 {
+		return float64(resultsCache.Misses())
+	})
+)
+
+// cachedQuery contains a query, which results are cached in resultsCache per `_time` buckets.
+type cachedQuery struct {
+	// key is the key for the query results in resultsCache.
+	key string
+
+	// step and offset define `_time` buckets for the query results.
+	step   int64
+	offset int64
+
+	// isStats must be set to true if the query is prepared via Query.GetStatsByFieldsAddGroupingByTime.
+	isStats bool
+}
+
+// newCachedQuery returns cachedQuery for the query from r with the given kind, step and offset.
+//
+// nil is returned if the query results cannot be cached.
+func newCachedQuery(r *http.Request, kind string, tenantIDs []logstorage.TenantID, step, offset int64) *cachedQuery {
+	if *disableCache || httputils.GetBool(r, "nocache") {
+		return nil
+	}
+
+	// Verify the original query without the global time filter.
+	qStr := r.FormValue("query")
+	q, err := logstorage.ParseQuery(qStr)
+	if err != nil {
+		return nil
+	}
+	isStats := kind == "stats_query_range"
+	if !isStats {
+		q.DropAllPipes()
+	}
+	if !q.CanSplitByTime() {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s step=%d offset=%d", kind, step, offset)
+	for i := range tenantIDs {
+		fmt.Fprintf(&b, " tenant=%s", &tenantIDs[i])
+	}
+	fmt.Fprintf(&b, " query=%q extra_filters=%q extra_stream_filters=%q", qStr, r.FormValue("extra_filters"), r.FormValue("extra_stream_filters"))
+	for _, f := range r.Form["field"] {
+		fmt.Fprintf(&b, " field=%q", f)
+	}
+
+	return &cachedQuery{
+		key:     b.String(),
+		step:    step,
+		offset:  offset,
+		isStats: isStats,
+	}
+}
+
+// alignDown returns the start of the `_time` bucket for the given timestamp.
+func (cq *cachedQuery) alignDown(timestamp int64) int64 {
+	timestamp -= cq.offset
+	timestamp -= timestamp % cq.step
+	return timestamp + cq.offset
+}
+
+// runQueryWithResultsCache runs q for the given tenantIDs and calls writeBlock for the returned data blocks.
+//
+// If cq isn't nil, then the results for full `_time` buckets are served from resultsCache,
+// while q is executed only on the remaining time ranges. The cache is updated with the newly obtained results.
+func runQueryWithResultsCache(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, cq *cachedQuery, writeBlock logstorage.WriteBlockFunc) error {
+	if cq == nil {
+		return vlstorage.RunQuery(ctx, tenantIDs, q, writeBlock)
+	}
+
+	start, end := q.GetFilterTimeRange()
+	if start < max(0, cq.offset) || end == math.MaxInt64 {
+		// Buckets for negative timestamps and open time ranges aren't cached.
+		return vlstorage.RunQuery(ctx, tenantIDs, q, writeBlock)
+	}
+
+	// Only full buckets ending before the current time minus -search.cacheTimestampOffset are cached,
+	// since the recently ingested log entries may be still missing in the results.
+	currentTime := time.Now().UnixNano()
+	cacheStart := cq.alignDown(start)
+	if cacheStart < start {
+		cacheStart += cq.step
+	}
+	cacheEnd := cq.alignDown(min(end+1, currentTime-cacheTimestampOffset.Nanoseconds()))
+	if cacheStart >= cacheEnd {
+		return vlstorage.RunQuery(ctx, tenantIDs, q, writeBlock)
+	}
+
+	// Obtain the cached time range [cachedStart ... cachedEnd)
+	var cachedBlocks []resultsCacheBlock
+	cachedStart, cachedEnd := cacheEnd, cacheEnd
+	if e, ok := resultsCache.GetEntry(cq.key).(*resultsCacheEntry); ok {
+		eEnd := e.end
+		minTimestamp := vlstorage.GetMinIngestedTimestamp(tenantIDs, e.validatedAt)
+		if minTimestamp < eEnd {
+			// Some log entries were ingested or deleted for the cached time range after the entry validation.
+			// Drop the cached buckets starting from the bucket for the minimum changed timestamp.
+			eEnd = max(e.start, cq.alignDown(max(minTimestamp, e.start)))
+		}
+		cs := max(e.start, cacheStart)
+		ce := min(eEnd, cacheEnd)
+		if cs < ce {
+			cachedBlocks = e.blocks
+			cachedStart, cachedEnd = cs, ce
+		}
+	}
+
+	rc := &resultsCollector{
+		start: cacheStart,
+		end:   cacheEnd,
+	}
+	writeBlockCollect := func(workerID uint, timestamps []int64, columns []logstorage.BlockColumn) {
+		writeBlock(workerID, timestamps, columns)
+		rc.collect(timestamps, columns)
+	}
+
+	if cachedStart >= cachedEnd {
+		// Nothing is cached - execute the whole query and cache its results.
+		if err := vlstorage.RunQuery(ctx, tenantIDs, q, writeBlockCollect); err != nil {
+			return err
+		}
+		rc.putEntry(cq.key, currentTime)
+		return nil
+	}
+
+	runSubquery := func(subStart, subEnd int64) error {
+		qSub := q.CloneWithTimeFilter(q.GetTimestamp(), subStart, subEnd)
+		if cq.isStats {
+			// Propagate the step into the cloned query again, since the cloned query is parsed with the new time range.
+			if _, err := qSub.GetStatsByFieldsAddGroupingByTime(cq.step); err != nil {
+				return err
+			}
+		}
+		return vlstorage.RunQuery(ctx, tenantIDs, qSub, writeBlockCollect)
+	}
+
+	// Execute the query on the time range before the cached buckets.
+	if start < cachedStart {
+		if err := runSubquery(start, cachedStart-1); err != nil {
+			return err
+		}
+	}
+
+	// Return the cached buckets.
+	for i := range cachedBlocks {
+		cb := cachedBlocks[i].filter(cachedStart, cachedEnd)
+		if len(cb.buckets) == 0 {
+			continue
+		}
+		writeBlock(0, cb.buckets, cb.columns)
+
+		rc.mu.Lock()
+		rc.blocks = append(rc.blocks, cb)
+		rc.mu.Unlock()
+	}
+
+	// Execute the query on the time range after the cached buckets.
+	if cachedEnd <= end {
+		if err := runSubquery(cachedEnd, end); err != nil {
+			return err
+		}
+	}
+
+	rc.putEntry(cq.key, currentTime)
+	return nil
+}
+
+// resultsCollector collects query results for full `_time` buckets on the [start ... end) time range.
+type resultsCollector struct {
+	start int64
+	end   int64
+
+	mu       sync.Mutex
+	blocks   []resultsCacheBlock
+	isFailed bool
+}
+
+func (rc *resultsCollector) collect(timestamps []int64, columns []logstorage.BlockColumn) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.isFailed || len(timestamps) == 0 {
+		return
+	}
+
+	timeIdx := -1
+	for i := range columns {
+		if columns[i].Name == "_time" {
+			timeIdx = i
+			break
+		}
+	}
+	if timeIdx < 0 {
+		// Cannot determine buckets for the results.
+		rc.isFailed = true
+		return
+	}
+
+	var cb resultsCacheBlock
+	cb.columns = make([]logstorage.BlockColumn, len(columns))
+	for i := range columns {
+		cb.columns[i].Name = strings.Clone(columns[i].Name)
+	}
+	for rowIdx, v := range columns[timeIdx].Values {
+		bucket, ok := logstorage.TryParseTimestampRFC3339Nano(v)
+		if !ok {
+			rc.isFailed = true
+			return
+		}
+		if bucket < rc.start || bucket >= rc.end {
+			continue
+		}
+		cb.buckets = append(cb.buckets, bucket)
+		for i := range columns {
+			cb.columns[i].Values = append(cb.columns[i].Values, strings.Clone(columns[i].Values[rowIdx]))
+		}
+	}
+	if len(cb.buckets) > 0 {
+		rc.blocks = append(rc.blocks, cb)
+	}
+}
+
+// putEntry stores the collected results at resultsCache under the given key.
+func (rc *resultsCollector) putEntry(key string, validatedAt int64) {
+	if rc.isFailed {
+		return
+	}
+
+	e := &resultsCacheEntry{
+		start:       rc.start,
+		end:         rc.end,
+		validatedAt: validatedAt,
+		blocks:      rc.blocks,
+	}
+	for i := range e.blocks {
+		e.sizeBytes += e.blocks[i].sizeBytes()
+	}
+
+	// lrucache.Cache doesn't replace the existing entries, so remove the previous entry at first.
+	resultsCache.RemoveEntry(key)
+	resultsCache.PutEntry(key, e)
+}
+
+// resultsCacheEntry contains cached query results for full `_time` buckets on the [start ... end) time range.
+type resultsCacheEntry struct {
+	start int64
+	end   int64
+
+	// validatedAt is the time when the entry was validated against the ingested log entries.
+	validatedAt int64
+
+	blocks    []resultsCacheBlock
+	sizeBytes int
+}
+
+// SizeBytes implements lrucache.Entry interface
+func (e *resultsCacheEntry) SizeBytes() int {
+	return e.sizeBytes
+}
+
+// resultsCacheBlock contains a block of cached query results.
+type resultsCacheBlock struct {
+	// buckets contains `_time` bucket per each row in the block.
+	buckets []int64
+
+	columns []logstorage.BlockColumn
+}
+
+// filter returns rows from cb for buckets on the [start ... end) time range.
+func (cb *resultsCacheBlock) filter(start, end int64) resultsCacheBlock {
+	n := 0
+	for _, bucket := range cb.buckets {
+		if bucket >= start && bucket < end {
+			n++
+		}
+	}
+	if n == len(cb.buckets) {
+		return *cb
+	}
+
+	dst := resultsCacheBlock{
+		buckets: make([]int64, 0, n),
+		columns: make([]logstorage.BlockColumn, len(cb.columns)),
+	}
+	for i := range cb.columns {
+		dst.columns[i].Name = cb.columns[i].Name
+		dst.columns[i].Values = make([]string, 0, n)
+	}
+	for rowIdx, bucket := range cb.buckets {
+		if bucket < start || bucket >= end {
+			continue
+		}
+		dst.buckets = append(dst.buckets, bucket)
+		for i := range cb.columns {
+			dst.columns[i].Values = append(dst.columns[i].Values, cb.columns[i].Values[rowIdx])
+		}
+	}
+	return dst
+}
+
+func (cb *resultsCacheBlock) sizeBytes() int {
+	n := 8 * len(cb.buckets)
+	for i := range cb.columns {
+		c := &cb.columns[i]
+		n += len(c.Name)
+		for _, v := range c.Values {
+			n += len(v) + 16
+		}
+	}
+	return n
+}
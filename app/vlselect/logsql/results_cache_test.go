@@ -0,0 +1,175 @@
+package logsql
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+func TestNewCachedQuery(t *testing.T) {
+	f := func(kind, query string, isCacheableExpected bool) {
+		t.Helper()
+
+		r, err := http.NewRequest(http.MethodGet, "http://localhost/?"+url.Values{"query": {query}}.Encode(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		cq := newCachedQuery(r, kind, []logstorage.TenantID{{}}, int64(time.Minute), 0)
+		if isCacheable := cq != nil; isCacheable != isCacheableExpected {
+			t.Fatalf("unexpected isCacheable for %s query [%s]; got %v; want %v", kind, query, isCacheable, isCacheableExpected)
+		}
+	}
+
+	f("stats_query_range", "error | stats by (host) count() hits", true)
+	f("stats_query_range", "_time:5m error | stats by (host) count() hits", false)
+	f("stats_query_range", "error | uniq by (host) | stats count() hosts", false)
+	f("stats_query_range", "error | stats by (host", false)
+
+	// pipes are dropped for hits queries
+	f("hits", "error | uniq by (host)", true)
+	f("hits", "_time:5m error", false)
+}
+
+func TestNewCachedQuery_Key(t *testing.T) {
+	f := func(args1, args2 url.Values, isEqualExpected bool) {
+		t.Helper()
+
+		getKey := func(args url.Values) string {
+			r, err := http.NewRequest(http.MethodGet, "http://localhost/?"+args.Encode(), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			cq := newCachedQuery(r, "hits", []logstorage.TenantID{{}}, int64(time.Minute), 0)
+			if cq == nil {
+				t.Fatalf("expecting non-nil cachedQuery for %s", args.Encode())
+			}
+			return cq.key
+		}
+		key1 := getKey(args1)
+		key2 := getKey(args2)
+		if isEqualExpected != (key1 == key2) {
+			t.Fatalf("unexpected keys equality for %s and %s; key1=%s; key2=%s", args1.Encode(), args2.Encode(), key1, key2)
+		}
+	}
+
+	// The time range doesn't affect the key
+	f(url.Values{"query": {"error"}, "start": {"1h"}}, url.Values{"query": {"error"}, "start": {"2h"}, "end": {"1h"}}, true)
+
+	// Different queries
+	f(url.Values{"query": {"error"}}, url.Values{"query": {"warn"}}, false)
+
+	// Different extra filters
+	f(url.Values{"query": {"error"}}, url.Values{"query": {"error"}, "extra_filters": {`{"host":"foo"}`}}, false)
+	f(url.Values{"query": {"error"}}, url.Values{"query": {"error"}, "extra_stream_filters": {`{"host":"foo"}`}}, false)
+
+	// Different fields
+	f(url.Values{"query": {"error"}, "field": {"host"}}, url.Values{"query": {"error"}, "field": {"app"}}, false)
+}
+
+func TestCachedQueryAlignDown(t *testing.T) {
+	f := func(step, offset, timestamp, resultExpected int64) {
+		t.Helper()
+
+		cq := &cachedQuery{
+			step:   step,
+			offset: offset,
+		}
+		result := cq.alignDown(timestamp)
+		if result != resultExpected {
+			t.Fatalf("unexpected alignDown(%d) for step=%d, offset=%d; got %d; want %d", timestamp, step, offset, result, resultExpected)
+		}
+	}
+
+	f(10, 0, 0, 0)
+	f(10, 0, 9, 0)
+	f(10, 0, 10, 10)
+	f(10, 0, 25, 20)
+	f(10, 3, 25, 23)
+	f(10, 3, 22, 13)
+	f(10, 3, 23, 23)
+}
+
+func TestResultsCollector(t *testing.T) {
+	rc := &resultsCollector{
+		start: 60e9,
+		end:   180e9,
+	}
+	rc.collect([]int64{0, 0, 0, 0}, []logstorage.BlockColumn{
+		{
+			Name:   "_time",
+			Values: []string{"1970-01-01T00:00:00Z", "1970-01-01T00:01:00Z", "1970-01-01T00:02:00Z", "1970-01-01T00:03:00Z"},
+		},
+		{
+			Name:   "hits",
+			Values: []string{"1", "2", "3", "4"},
+		},
+	})
+	if rc.isFailed {
+		t.Fatalf("unexpected failure for collecting results")
+	}
+	blocksExpected := []resultsCacheBlock{
+		{
+			buckets: []int64{60e9, 120e9},
+			columns: []logstorage.BlockColumn{
+				{
+					Name:   "_time",
+					Values: []string{"1970-01-01T00:01:00Z", "1970-01-01T00:02:00Z"},
+				},
+				{
+					Name:   "hits",
+					Values: []string{"2", "3"},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(rc.blocks, blocksExpected) {
+		t.Fatalf("unexpected blocks\ngot\n%v\nwant\n%v", rc.blocks, blocksExpected)
+	}
+
+	// Results without _time field cannot be cached
+	rc.collect([]int64{0}, []logstorage.BlockColumn{
+		{
+			Name:   "hits",
+			Values: []string{"1"},
+		},
+	})
+	if !rc.isFailed {
+		t.Fatalf("expecting failure for collecting results without _time field")
+	}
+}
+
+func TestResultsCacheBlockFilter(t *testing.T) {
+	cb := &resultsCacheBlock{
+		buckets: []int64{10, 20, 30},
+		columns: []logstorage.BlockColumn{
+			{
+				Name:   "hits",
+				Values: []string{"1", "2", "3"},
+			},
+		},
+	}
+
+	f := func(start, end int64, bucketsExpected []int64, valuesExpected []string) {
+		t.Helper()
+
+		result := cb.filter(start, end)
+		if len(result.buckets) == 0 && len(bucketsExpected) == 0 {
+			return
+		}
+		if !reflect.DeepEqual(result.buckets, bucketsExpected) {
+			t.Fatalf("unexpected buckets for [%d, %d); got %v; want %v", start, end, result.buckets, bucketsExpected)
+		}
+		if !reflect.DeepEqual(result.columns[0].Values, valuesExpected) {
+			t.Fatalf("unexpected values for [%d, %d); got %q; want %q", start, end, result.columns[0].Values, valuesExpected)
+		}
+	}
+
+	f(0, 100, []int64{10, 20, 30}, []string{"1", "2", "3"})
+	f(10, 30, []int64{10, 20}, []string{"1", "2"})
+	f(20, 21, []int64{20}, []string{"2"})
+	f(40, 50, nil, nil)
+}
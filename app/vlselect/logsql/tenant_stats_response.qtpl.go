@@ -0,0 +1,154 @@
+// Code generated by qtc from "tenant_stats_response.qtpl". DO NOT EDIT.
+// See https://github.com/valyala/quicktemplate for details.
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:1
+package logsql
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:1
+import (
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+// TenantStatsResponse generates response for /select/logsql/tenant_stats
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:8
+import (
+	qtio422016 "io"
+
+	qt422016 "github.com/valyala/quicktemplate"
+)
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:8
+var (
+	_ = qtio422016.Copy
+	_ = qt422016.AcquireByteBuffer
+)
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:8
+func StreamTenantStatsResponse(qw422016 *qt422016.Writer, tss []logstorage.TenantStats) {
+//line app/vlselect/logsql/tenant_stats_response.qtpl:8
+	qw422016.N().S(`{"tenants":[`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:11
+	if len(tss) > 0 {
+//line app/vlselect/logsql/tenant_stats_response.qtpl:12
+		streamformatTenantStats(qw422016, &tss[0])
+//line app/vlselect/logsql/tenant_stats_response.qtpl:13
+		tss = tss[1:]
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:14
+		for i := range tss {
+//line app/vlselect/logsql/tenant_stats_response.qtpl:14
+			qw422016.N().S(`,`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:15
+			streamformatTenantStats(qw422016, &tss[i])
+//line app/vlselect/logsql/tenant_stats_response.qtpl:16
+		}
+//line app/vlselect/logsql/tenant_stats_response.qtpl:17
+	}
+//line app/vlselect/logsql/tenant_stats_response.qtpl:17
+	qw422016.N().S(`]}`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+}
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+func WriteTenantStatsResponse(qq422016 qtio422016.Writer, tss []logstorage.TenantStats) {
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+	StreamTenantStatsResponse(qw422016, tss)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+	qt422016.ReleaseWriter(qw422016)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+}
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+func TenantStatsResponse(tss []logstorage.TenantStats) string {
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+	WriteTenantStatsResponse(qb422016, tss)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+	qs422016 := string(qb422016.B)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+	return qs422016
+//line app/vlselect/logsql/tenant_stats_response.qtpl:20
+}
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:22
+func streamformatTenantStats(qw422016 *qt422016.Writer, ts *logstorage.TenantStats) {
+//line app/vlselect/logsql/tenant_stats_response.qtpl:22
+	qw422016.N().S(`{"tenant":"`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:24
+	qw422016.N().DUL(uint64(ts.TenantID.AccountID))
+//line app/vlselect/logsql/tenant_stats_response.qtpl:24
+	qw422016.N().S(`:`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:24
+	qw422016.N().DUL(uint64(ts.TenantID.ProjectID))
+//line app/vlselect/logsql/tenant_stats_response.qtpl:24
+	qw422016.N().S(`","limits":{"retention":"`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:26
+	qw422016.N().DL(int64(ts.Retention.Hours()) / 24)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:26
+	qw422016.N().S(`d","max_bytes_per_day":`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:27
+	qw422016.N().DUL(ts.MaxBytesPerDay)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:27
+	qw422016.N().S(`,"max_new_streams_per_hour":`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:28
+	qw422016.N().DUL(ts.MaxNewStreamsPerHour)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:28
+	qw422016.N().S(`},"usage":{"bytes_ingested_today":`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:31
+	qw422016.N().DUL(ts.BytesIngestedToday)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:31
+	qw422016.N().S(`,"rows_ingested_today":`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:32
+	qw422016.N().DUL(ts.RowsIngestedToday)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:32
+	qw422016.N().S(`,"new_streams_this_hour":`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:33
+	qw422016.N().DUL(ts.NewStreamsThisHour)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:33
+	qw422016.N().S(`},"rows_rejected":{"retention":`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:36
+	qw422016.N().DUL(ts.RowsRejectedRetention)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:36
+	qw422016.N().S(`,"max_bytes_per_day":`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:37
+	qw422016.N().DUL(ts.RowsRejectedMaxBytesPerDay)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:37
+	qw422016.N().S(`,"max_new_streams_per_hour":`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:38
+	qw422016.N().DUL(ts.RowsRejectedMaxNewStreamsPerHour)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:38
+	qw422016.N().S(`}}`)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+}
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+func writeformatTenantStats(qq422016 qtio422016.Writer, ts *logstorage.TenantStats) {
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+	streamformatTenantStats(qw422016, ts)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+	qt422016.ReleaseWriter(qw422016)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+}
+
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+func formatTenantStats(ts *logstorage.TenantStats) string {
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+	writeformatTenantStats(qb422016, ts)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+	qs422016 := string(qb422016.B)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+	return qs422016
+//line app/vlselect/logsql/tenant_stats_response.qtpl:41
+}
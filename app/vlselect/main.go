@@ -221,6 +221,10 @@ func processSelectRequest(ctx context.Context, w http.ResponseWriter, r *http.Re
 		logsqlStreamsRequests.Inc()
 		logsql.ProcessStreamsRequest(ctx, w, r)
 		return true
+	case "/select/logsql/tenant_stats":
+		logsqlTenantStatsRequests.Inc()
+		logsql.ProcessTenantStatsRequest(w, r)
+		return true
 	default:
 		return false
 	}
@@ -252,4 +256,5 @@ var (
 	logsqlStreamIDsRequests         = metrics.NewCounter(`vl_http_requests_total{path="/select/logsql/stream_ids"}`)
 	logsqlStreamsRequests           = metrics.NewCounter(`vl_http_requests_total{path="/select/logsql/streams"}`)
 	logsqlTailRequests              = metrics.NewCounter(`vl_http_requests_total{path="/select/logsql/tail"}`)
+	logsqlTenantStatsRequests       = metrics.NewCounter(`vl_http_requests_total{path="/select/logsql/tenant_stats"}`)
 )
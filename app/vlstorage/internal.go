@@ -0,0 +1,146 @@
+package vlstorage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlstorage/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+// maxInsertRequestSize is the maximum size of compressed data in a single /internal/insert request.
+const maxInsertRequestSize = 64 * 1024 * 1024
+
+// processInternalRequest processes /internal/* requests sent by other VictoriaLogs nodes in cluster mode.
+func processInternalRequest(w http.ResponseWriter, r *http.Request, path string) bool {
+	switch path {
+	case "/internal/insert", "/internal/select/query", "/internal/select/min_ingested_timestamp", "/internal/select/tenant_stats":
+	default:
+		return false
+	}
+
+	if version := r.FormValue("version"); version != netstorage.ProtocolVersion {
+		httpserver.Errorf(w, r, "unsupported protocol version=%q; want %q; make sure all the VictoriaLogs nodes in the cluster have the same version", version, netstorage.ProtocolVersion)
+		return true
+	}
+
+	var err error
+	switch path {
+	case "/internal/insert":
+		internalInsertRequests.Inc()
+		err = processInternalInsert(r)
+		if err != nil {
+			internalInsertErrors.Inc()
+		}
+	case "/internal/select/query":
+		internalQueryRequests.Inc()
+		err = processInternalQuery(w, r)
+		if err != nil {
+			internalQueryErrors.Inc()
+		}
+	case "/internal/select/min_ingested_timestamp":
+		err = processInternalMinIngestedTimestamp(w, r)
+	case "/internal/select/tenant_stats":
+		err = writeJSONResponse(w, strg.GetTenantStats())
+	}
+	if err != nil {
+		httpserver.Errorf(w, r, "%s", err)
+	}
+	return true
+}
+
+var (
+	internalInsertRequests = metrics.NewCounter(`vl_http_requests_total{path="/internal/insert"}`)
+	internalInsertErrors   = metrics.NewCounter(`vl_http_errors_total{path="/internal/insert"}`)
+	internalQueryRequests  = metrics.NewCounter(`vl_http_requests_total{path="/internal/select/query"}`)
+	internalQueryErrors    = metrics.NewCounter(`vl_http_errors_total{path="/internal/select/query"}`)
+)
+
+func processInternalInsert(r *http.Request) error {
+	if err := CanWriteData(); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxInsertRequestSize+1))
+	if err != nil {
+		return fmt.Errorf("cannot read request body: %w", err)
+	}
+	if len(data) > maxInsertRequestSize {
+		return fmt.Errorf("too big request body; it mustn't exceed %d bytes", maxInsertRequestSize)
+	}
+
+	lr := logstorage.GetLogRows(nil, nil, nil, "")
+	defer logstorage.PutLogRows(lr)
+
+	err = netstorage.ForEachInsertRow(data, func(row *logstorage.InsertRow) {
+		lr.MustAddInsertRow(row)
+		if lr.NeedFlush() {
+			strg.MustAddRows(lr)
+			lr.ResetKeepSettings()
+		}
+	})
+	strg.MustAddRows(lr)
+	return err
+}
+
+func processInternalQuery(w http.ResponseWriter, r *http.Request) error {
+	tenantIDs, err := netstorage.ParseTenantIDs(r.FormValue("tenant_ids"))
+	if err != nil {
+		return fmt.Errorf("cannot parse tenant_ids: %w", err)
+	}
+	timestamp, err := strconv.ParseInt(r.FormValue("timestamp"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse timestamp: %w", err)
+	}
+	qStr := r.FormValue("query")
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, timestamp)
+	if err != nil {
+		return fmt.Errorf("cannot parse query [%s]: %w", qStr, err)
+	}
+	ss, err := netstorage.ParseStreamSharding(r)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	qw := netstorage.NewQueryResponseWriter(w)
+	writeBlock := func(_ uint, timestamps []int64, columns []logstorage.BlockColumn) {
+		qw.WriteBlock(timestamps, columns)
+	}
+	errQuery := strg.RunRemoteQuery(r.Context(), tenantIDs, q, ss, writeBlock)
+
+	// The query error is sent in the response body, since the response status code has been already sent.
+	// Errors on writing the response are ignored, since they mean the client has closed the connection.
+	_ = qw.Finish(errQuery)
+	return nil
+}
+
+func processInternalMinIngestedTimestamp(w http.ResponseWriter, r *http.Request) error {
+	tenantIDs, err := netstorage.ParseTenantIDs(r.FormValue("tenant_ids"))
+	if err != nil {
+		return fmt.Errorf("cannot parse tenant_ids: %w", err)
+	}
+	since, err := strconv.ParseInt(r.FormValue("since"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse since: %w", err)
+	}
+	ts := strg.GetMinIngestedTimestamp(tenantIDs, since)
+	_, err = fmt.Fprintf(w, "%d", ts)
+	return err
+}
+
+// processClusterRequest processes storage requests in cluster mode when -storageNode is set.
+func processClusterRequest(w http.ResponseWriter, r *http.Request, path string) bool {
+	if path == "/internal/force_merge" || strings.HasPrefix(path, "/delete/") || strings.HasPrefix(path, "/snapshot/") ||
+		strings.HasPrefix(path, "/internal/insert") || strings.HasPrefix(path, "/internal/select/") {
+		httpserver.Errorf(w, r, "%s isn't supported at nodes with -storageNode command-line flag; send the request directly to every storage node from -storageNode=%q", path, *storageNodeAddrs)
+		return true
+	}
+	return false
+}
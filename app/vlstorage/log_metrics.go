@@ -0,0 +1,222 @@
+package vlstorage
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/golang/snappy"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs/fscore"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+var (
+	logMetricsFile = flag.String("logMetricsFile", "", "Optional path to a file with rules for generating metrics from the ingested logs. "+
+		"The path can point either to local file or to http url. The file is reloaded on SIGHUP signal. The generated metrics are exposed at /log_metrics page "+
+		"and can be pushed to -logMetrics.remoteWrite.url; see https://docs.victoriametrics.com/victorialogs/log-metrics/")
+	logMetricsRemoteWriteURL = flag.String("logMetrics.remoteWrite.url", "", "Optional Prometheus remote write url to push metrics generated according to -logMetricsFile to. "+
+		"For example, http://victoriametrics:8428/api/v1/write ; see https://docs.victoriametrics.com/victorialogs/log-metrics/#remote-write")
+	logMetricsRemoteWriteInterval = flag.Duration("logMetrics.remoteWrite.interval", 30*time.Second, "Interval for pushing metrics generated according to -logMetricsFile "+
+		"to -logMetrics.remoteWrite.url")
+	logMetricsRemoteWriteInstance = flag.String("logMetrics.remoteWrite.instance", "", "Value for the instance label added to metrics pushed to -logMetrics.remoteWrite.url. "+
+		"By default the hostname is used. Every VictoriaLogs instance must have a unique value, so the pushed metrics do not collide in cluster mode; "+
+		"see https://docs.victoriametrics.com/victorialogs/log-metrics/#remote-write")
+)
+
+// logMetrics contains log metrics rules loaded from -logMetricsFile.
+var logMetrics atomic.Pointer[logstorage.LogMetrics]
+
+func initLogMetrics(s *logstorage.Storage) {
+	if *logMetricsFile == "" {
+		if *logMetricsRemoteWriteURL != "" {
+			logger.Fatalf("-logMetrics.remoteWrite.url is set, while -logMetricsFile isn't set")
+		}
+		return
+	}
+
+	// Register SIGHUP handler for config re-read just before loadLogMetrics call.
+	// This guarantees that the config will be re-read if the signal arrives during loadLogMetrics call.
+	sighupCh := procutil.NewSighupChan()
+
+	lm, err := loadLogMetrics(nil)
+	if err != nil {
+		logger.Fatalf("cannot load -logMetricsFile: %s", err)
+	}
+	logMetrics.Store(lm)
+	s.SetLogMetrics(lm)
+	logMetricsSuccess.Set(1)
+	logMetricsTimestamp.Set(fasttime.UnixTimestamp())
+
+	logMetricsStopCh = make(chan struct{})
+	logMetricsWG.Add(1)
+	go func() {
+		defer logMetricsWG.Done()
+		runLogMetricsReloader(s, sighupCh)
+	}()
+
+	if *logMetricsRemoteWriteURL != "" {
+		extraLabels := []prompbmarshal.Label{
+			{
+				Name:  "instance",
+				Value: getLogMetricsInstance(),
+			},
+		}
+		logMetricsWG.Add(1)
+		go func() {
+			defer logMetricsWG.Done()
+			runLogMetricsPusher(extraLabels)
+		}()
+	}
+}
+
+func stopLogMetrics(s *logstorage.Storage) {
+	if logMetricsStopCh == nil {
+		return
+	}
+	close(logMetricsStopCh)
+	logMetricsWG.Wait()
+	logMetricsStopCh = nil
+	s.SetLogMetrics(nil)
+	logMetrics.Store(nil)
+}
+
+func getLogMetricsInstance() string {
+	if *logMetricsRemoteWriteInstance != "" {
+		return *logMetricsRemoteWriteInstance
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		logger.Fatalf("cannot obtain hostname for the instance label; set it explicitly via -logMetrics.remoteWrite.instance: %s", err)
+	}
+	return hostname
+}
+
+var (
+	logMetricsStopCh chan struct{}
+	logMetricsWG     sync.WaitGroup
+)
+
+func runLogMetricsReloader(s *logstorage.Storage, sighupCh <-chan os.Signal) {
+	for {
+		select {
+		case <-logMetricsStopCh:
+			return
+		case <-sighupCh:
+		}
+		logMetricsReloads.Inc()
+		logger.Infof("received SIGHUP; reloading -logMetricsFile=%q...", *logMetricsFile)
+		lm, err := loadLogMetrics(logMetrics.Load())
+		if err != nil {
+			logMetricsReloadErrors.Inc()
+			logMetricsSuccess.Set(0)
+			logger.Errorf("cannot load the updated -logMetricsFile: %s; preserving the previous config", err)
+			continue
+		}
+		logMetrics.Store(lm)
+		s.SetLogMetrics(lm)
+		logMetricsSuccess.Set(1)
+		logMetricsTimestamp.Set(fasttime.UnixTimestamp())
+		logger.Infof("successfully reloaded -logMetricsFile=%q", *logMetricsFile)
+	}
+}
+
+func loadLogMetrics(prev *logstorage.LogMetrics) (*logstorage.LogMetrics, error) {
+	data, err := fscore.ReadFileOrHTTP(*logMetricsFile)
+	if err != nil {
+		return nil, err
+	}
+	lm, err := logstorage.ParseLogMetrics(data, prev)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %q: %w", *logMetricsFile, err)
+	}
+	return lm, nil
+}
+
+var (
+	logMetricsReloads      = metrics.NewCounter(`vl_log_metrics_config_reloads_total`)
+	logMetricsReloadErrors = metrics.NewCounter(`vl_log_metrics_config_reloads_errors_total`)
+	logMetricsSuccess      = metrics.NewGauge(`vl_log_metrics_config_last_reload_successful`, nil)
+	logMetricsTimestamp    = metrics.NewCounter(`vl_log_metrics_config_last_reload_success_timestamp_seconds`)
+)
+
+// processLogMetricsRequest writes metrics generated from the ingested logs to w in Prometheus text exposition format.
+func processLogMetricsRequest(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	logMetrics.Load().WritePrometheus(w)
+}
+
+func runLogMetricsPusher(extraLabels []prompbmarshal.Label) {
+	ticker := time.NewTicker(*logMetricsRemoteWriteInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-logMetricsStopCh:
+			// Push the final metrics before exit, so they aren't lost.
+			pushLogMetrics(extraLabels)
+			return
+		case <-ticker.C:
+			pushLogMetrics(extraLabels)
+		}
+	}
+}
+
+func pushLogMetrics(extraLabels []prompbmarshal.Label) {
+	logMetricsPushes.Inc()
+	ctx, cancel := context.WithTimeout(context.Background(), *logMetricsRemoteWriteInterval)
+	defer cancel()
+	if err := pushLogMetricsInternal(ctx, logMetrics.Load(), extraLabels, time.Now().UnixMilli()); err != nil {
+		logMetricsPushErrors.Inc()
+		logger.Errorf("cannot push log metrics to -logMetrics.remoteWrite.url=%q: %s", *logMetricsRemoteWriteURL, err)
+	}
+}
+
+func pushLogMetricsInternal(ctx context.Context, lm *logstorage.LogMetrics, extraLabels []prompbmarshal.Label, timestamp int64) error {
+	var wr prompbmarshal.WriteRequest
+	wr.Timeseries = lm.AppendTimeSeries(wr.Timeseries[:0], extraLabels, timestamp)
+	if len(wr.Timeseries) == 0 {
+		return nil
+	}
+	data := snappy.Encode(nil, wr.MarshalProtobuf(nil))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *logMetricsRemoteWriteURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("cannot create request: %w", err)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := logMetricsClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d; response body: %q", resp.StatusCode, body)
+	}
+	return nil
+}
+
+var logMetricsClient = &http.Client{}
+
+var (
+	logMetricsPushes     = metrics.NewCounter(`vl_log_metrics_remote_write_requests_total`)
+	logMetricsPushErrors = metrics.NewCounter(`vl_log_metrics_remote_write_errors_total`)
+)
@@ -2,19 +2,31 @@ package vlstorage
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/VictoriaMetrics/metrics"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlstorage/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs/fscore"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/stringsutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timeutil"
 )
 
 var (
@@ -39,19 +51,56 @@ var (
 		"the storage stops accepting new data")
 
 	forceMergeAuthKey = flagutil.NewPassword("forceMergeAuthKey", "authKey, which must be passed in query string to /internal/force_merge pages. It overrides -httpAuth.*")
+	deleteAuthKey     = flagutil.NewPassword("deleteAuthKey", "authKey, which must be passed in query string to /delete/* pages. It overrides -httpAuth.*; "+
+		"see https://docs.victoriametrics.com/victorialogs/#deleting-logs")
+	snapshotAuthKey = flagutil.NewPassword("snapshotAuthKey", "authKey, which must be passed in query string to /snapshot* pages. It overrides -httpAuth.*; "+
+		"see https://docs.victoriametrics.com/victorialogs/#backup-and-restore")
+	snapshotsMaxAge = flagutil.NewRetentionDuration("snapshotsMaxAge", "0", "Automatically delete snapshots older than -snapshotsMaxAge if it is set to non-zero duration. "+
+		"Make sure that backup process has enough time to finish the backup before the corresponding snapshot is automatically deleted")
+	tenantLimitsFile = flag.String("tenantLimitsFile", "", "Optional path to a file with per-tenant retention and ingestion limits. "+
+		"The path can point either to local file or to http url. The file is reloaded on SIGHUP signal; "+
+		"see https://docs.victoriametrics.com/victorialogs/#per-tenant-limits")
+
+	storageNodeAddrs = flagutil.NewArrayString("storageNode", "Comma-separated list of addresses of VictoriaLogs storage nodes in cluster mode, e.g. vlstorage-1:9428,vlstorage-2:9428. "+
+		"If this flag is set, then the ingested logs are spread among the given storage nodes and queries are executed over all the storage nodes "+
+		"instead of the local storage at -storageDataPath; see https://docs.victoriametrics.com/victorialogs/cluster/")
+	replicationFactor = flag.Int("replicationFactor", 1, "The number of -storageNode nodes every ingested log stream is replicated to. "+
+		"Queries return full results if less than -replicationFactor storage nodes are unavailable; see https://docs.victoriametrics.com/victorialogs/cluster/#replication")
+	allowPartialResponse = flag.Bool("search.allowPartialResponse", false, "Whether to return partial responses when some of -storageNode nodes are unavailable. "+
+		"By default queries fail if some logs cannot be obtained from the available storage nodes; see https://docs.victoriametrics.com/victorialogs/cluster/#partial-responses")
 )
 
 // Init initializes vlstorage.
 //
 // Stop must be called when vlstorage is no longer needed
 func Init() {
-	if strg != nil {
+	if strg != nil || netstrg != nil {
 		logger.Panicf("BUG: Init() has been already called")
 	}
 
+	if len(*storageNodeAddrs) > 0 {
+		if *logMetricsFile != "" {
+			logger.Fatalf("-logMetricsFile cannot be set together with -storageNode; set it at vlstorage nodes instead; " +
+				"see https://docs.victoriametrics.com/victorialogs/log-metrics/")
+		}
+		initNetworkStorage()
+		return
+	}
+
 	if retentionPeriod.Duration() < 24*time.Hour {
 		logger.Fatalf("-retentionPeriod cannot be smaller than a day; got %s", retentionPeriod)
 	}
+
+	// Register SIGHUP handler for config re-read just before loadTenantLimits call.
+	// This guarantees that the config will be re-read if the signal arrives during loadTenantLimits call.
+	sighupCh := procutil.NewSighupChan()
+
+	tl, err := loadTenantLimits()
+	if err != nil {
+		logger.Fatalf("cannot load -tenantLimitsFile: %s", err)
+	}
+	tenantLimitsSuccess.Set(1)
+	tenantLimitsTimestamp.Set(fasttime.UnixTimestamp())
 	cfg := &logstorage.StorageConfig{
 		Retention:              retentionPeriod.Duration(),
 		MaxDiskSpaceUsageBytes: maxDiskSpaceUsageBytes.N,
@@ -60,6 +109,7 @@ func Init() {
 		LogNewStreams:          *logNewStreams,
 		LogIngestedRows:        *logIngestedRows,
 		MinFreeDiskSpaceBytes:  minFreeDiskSpaceBytes.N,
+		TenantLimits:           tl,
 	}
 	logger.Infof("opening storage at -storageDataPath=%s", *storageDataPath)
 	startTime := time.Now()
@@ -78,10 +128,30 @@ func Init() {
 		writeStorageMetrics(w, strg)
 	})
 	metrics.RegisterSet(storageMetrics)
+
+	initStaleSnapshotsRemover(strg)
+	initTenantLimitsReloader(sighupCh)
+	initLogMetrics(strg)
+}
+
+func initNetworkStorage() {
+	logger.Infof("starting cluster mode with -storageNode=%q, -replicationFactor=%d", *storageNodeAddrs, *replicationFactor)
+	netstrg = netstorage.NewStorage(*storageNodeAddrs, *replicationFactor, *allowPartialResponse)
 }
 
 // Stop stops vlstorage.
 func Stop() {
+	if netstrg != nil {
+		netstrg.MustStop()
+		netstrg = nil
+		return
+	}
+
+	stopLogMetrics(strg)
+
+	stopTenantLimitsReloader()
+	stopStaleSnapshotsRemover()
+
 	metrics.UnregisterSet(storageMetrics, true)
 	storageMetrics = nil
 
@@ -92,6 +162,16 @@ func Stop() {
 // RequestHandler is a storage request handler.
 func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 	path := r.URL.Path
+	if path == "/log_metrics" {
+		processLogMetricsRequest(w)
+		return true
+	}
+	if netstrg != nil {
+		return processClusterRequest(w, r, path)
+	}
+	if strings.HasPrefix(path, "/internal/insert") || strings.HasPrefix(path, "/internal/select/") {
+		return processInternalRequest(w, r, path)
+	}
 	if path == "/internal/force_merge" {
 		if !httpserver.CheckAuthFlag(w, r, forceMergeAuthKey) {
 			return true
@@ -108,14 +188,318 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 		}()
 		return true
 	}
+	switch path {
+	case "/delete/run_task":
+		if !httpserver.CheckAuthFlag(w, r, deleteAuthKey) {
+			return true
+		}
+		if err := processDeleteRunTask(w, r); err != nil {
+			httpserver.Errorf(w, r, "%s", err)
+		}
+		return true
+	case "/delete/task_status":
+		if !httpserver.CheckAuthFlag(w, r, deleteAuthKey) {
+			return true
+		}
+		if err := processDeleteTaskStatus(w, r); err != nil {
+			httpserver.Errorf(w, r, "%s", err)
+		}
+		return true
+	}
+	if strings.HasPrefix(path, "/snapshot/") {
+		if !httpserver.CheckAuthFlag(w, r, snapshotAuthKey) {
+			return true
+		}
+		return processSnapshotRequest(w, r, path[len("/snapshot"):])
+	}
 	return false
 }
 
+// processSnapshotRequest processes /snapshot/* requests.
+//
+// Responses are compatible with /snapshot/* responses at VictoriaMetrics, so vmbackup can create and delete snapshots
+// via -snapshot.createURL and -snapshot.deleteURL command-line flags.
+//
+// See https://docs.victoriametrics.com/victorialogs/#backup-and-restore
+func processSnapshotRequest(w http.ResponseWriter, r *http.Request, path string) bool {
+	switch path {
+	case "/create":
+		snapshotName, err := strg.CreateSnapshot()
+		if err != nil {
+			writeSnapshotError(w, fmt.Errorf("cannot create snapshot: %w", err))
+			return true
+		}
+		writeSnapshotResponse(w, map[string]string{
+			"status":   "ok",
+			"snapshot": snapshotName,
+		})
+		return true
+	case "/list":
+		snapshotNames, err := strg.ListSnapshots()
+		if err != nil {
+			writeSnapshotError(w, fmt.Errorf("cannot list snapshots: %w", err))
+			return true
+		}
+		if snapshotNames == nil {
+			snapshotNames = []string{}
+		}
+		writeSnapshotResponse(w, map[string]any{
+			"status":    "ok",
+			"snapshots": snapshotNames,
+		})
+		return true
+	case "/delete":
+		snapshotName := r.FormValue("snapshot")
+		if err := strg.DeleteSnapshot(snapshotName); err != nil {
+			writeSnapshotError(w, fmt.Errorf("cannot delete snapshot %q: %w", snapshotName, err))
+			return true
+		}
+		writeSnapshotResponse(w, map[string]string{
+			"status": "ok",
+		})
+		return true
+	case "/delete_all":
+		snapshotNames, err := strg.ListSnapshots()
+		if err != nil {
+			writeSnapshotError(w, fmt.Errorf("cannot list snapshots: %w", err))
+			return true
+		}
+		for _, snapshotName := range snapshotNames {
+			if err := strg.DeleteSnapshot(snapshotName); err != nil {
+				writeSnapshotError(w, fmt.Errorf("cannot delete snapshot %q: %w", snapshotName, err))
+				return true
+			}
+		}
+		writeSnapshotResponse(w, map[string]string{
+			"status": "ok",
+		})
+		return true
+	default:
+		return false
+	}
+}
+
+func writeSnapshotResponse(w http.ResponseWriter, v any) {
+	if err := writeJSONResponse(w, v); err != nil {
+		logger.Errorf("cannot write snapshot response: %s", err)
+	}
+}
+
+func writeSnapshotError(w http.ResponseWriter, err error) {
+	logger.Errorf("%s", err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `{"status":"error","msg":%s}`, stringsutil.JSONString(err.Error()))
+}
+
+func initStaleSnapshotsRemover(strg *logstorage.Storage) {
+	staleSnapshotsRemoverCh = make(chan struct{})
+	if snapshotsMaxAge.Duration() <= 0 {
+		return
+	}
+	snapshotsMaxAgeDur := snapshotsMaxAge.Duration()
+	staleSnapshotsRemoverWG.Add(1)
+	go func() {
+		defer staleSnapshotsRemoverWG.Done()
+		d := timeutil.AddJitterToDuration(time.Second * 11)
+		t := time.NewTicker(d)
+		defer t.Stop()
+		for {
+			select {
+			case <-staleSnapshotsRemoverCh:
+				return
+			case <-t.C:
+			}
+			if err := strg.DeleteStaleSnapshots(snapshotsMaxAgeDur); err != nil {
+				// Use logger.Errorf instead of logger.Fatalf in the hope the error is temporary.
+				logger.Errorf("cannot delete stale snapshots: %s", err)
+			}
+		}
+	}()
+}
+
+func stopStaleSnapshotsRemover() {
+	close(staleSnapshotsRemoverCh)
+	staleSnapshotsRemoverWG.Wait()
+}
+
+var (
+	staleSnapshotsRemoverCh chan struct{}
+	staleSnapshotsRemoverWG sync.WaitGroup
+)
+
+// processDeleteRunTask starts a task for deleting logs matching the filter query arg on the [start ... end] time range.
+//
+// See https://docs.victoriametrics.com/victorialogs/#deleting-logs
+func processDeleteRunTask(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &httpserver.ErrorWithStatusCode{
+			Err:        fmt.Errorf("unsupported method %s; use POST for starting delete tasks", r.Method),
+			StatusCode: http.StatusMethodNotAllowed,
+		}
+	}
+
+	tenantID, err := logstorage.GetTenantIDFromRequest(r)
+	if err != nil {
+		return fmt.Errorf("cannot obtain tenantID: %w", err)
+	}
+
+	filterStr := r.FormValue("filter")
+	if filterStr == "" {
+		return fmt.Errorf("missing `filter` query arg")
+	}
+	f, err := logstorage.ParseFilter(filterStr)
+	if err != nil {
+		return fmt.Errorf("cannot parse filter [%s]: %w", filterStr, err)
+	}
+
+	currentTimestamp := time.Now().UnixNano()
+	start := int64(math.MinInt64)
+	if s := r.FormValue("start"); s != "" {
+		start, err = promutils.ParseTimeAt(s, currentTimestamp)
+		if err != nil {
+			return fmt.Errorf("cannot parse start=%s: %w", s, err)
+		}
+	}
+	end := currentTimestamp
+	if s := r.FormValue("end"); s != "" {
+		end, err = promutils.ParseTimeAt(s, currentTimestamp)
+		if err != nil {
+			return fmt.Errorf("cannot parse end=%s: %w", s, err)
+		}
+	}
+
+	taskID, err := strg.DeleteRunTask([]logstorage.TenantID{tenantID}, f, start, end)
+	if err != nil {
+		return err
+	}
+	logger.Infof("started delete task %s for filter [%s] on the time range [%d, %d] at tenant %s", taskID, f, start, end, &tenantID)
+
+	return writeJSONResponse(w, map[string]string{
+		"task_id": taskID,
+	})
+}
+
+// processDeleteTaskStatus returns the status for the delete task with the given task_id query arg at the tenant from the request.
+//
+// Active and recently finished delete tasks for the tenant are returned if task_id query arg is missing.
+func processDeleteTaskStatus(w http.ResponseWriter, r *http.Request) error {
+	tenantID, err := logstorage.GetTenantIDFromRequest(r)
+	if err != nil {
+		return fmt.Errorf("cannot obtain tenantID: %w", err)
+	}
+
+	taskID := r.FormValue("task_id")
+	if taskID == "" {
+		return writeJSONResponse(w, strg.GetDeleteTasks(tenantID))
+	}
+	dt, ok := strg.GetDeleteTask(tenantID, taskID)
+	if !ok {
+		return &httpserver.ErrorWithStatusCode{
+			Err:        fmt.Errorf("cannot find delete task with task_id=%q", taskID),
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	return writeJSONResponse(w, dt)
+}
+
+func writeJSONResponse(w http.ResponseWriter, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cannot marshal response to JSON: %w", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	return err
+}
+
 var strg *logstorage.Storage
 var storageMetrics *metrics.Set
 
+// netstrg is set instead of strg in cluster mode when -storageNode is set
+var netstrg *netstorage.Storage
+
+var (
+	tenantLimitsReloaderStopCh chan struct{}
+	tenantLimitsReloaderWG     sync.WaitGroup
+)
+
+func initTenantLimitsReloader(sighupCh <-chan os.Signal) {
+	if *tenantLimitsFile == "" {
+		return
+	}
+	tenantLimitsReloaderStopCh = make(chan struct{})
+	tenantLimitsReloaderWG.Add(1)
+	go func() {
+		defer tenantLimitsReloaderWG.Done()
+		for {
+			select {
+			case <-tenantLimitsReloaderStopCh:
+				return
+			case <-sighupCh:
+			}
+			tenantLimitsReloads.Inc()
+			logger.Infof("received SIGHUP; reloading -tenantLimitsFile=%q...", *tenantLimitsFile)
+			tl, err := loadTenantLimits()
+			if err != nil {
+				tenantLimitsReloadErrors.Inc()
+				tenantLimitsSuccess.Set(0)
+				logger.Errorf("cannot load the updated -tenantLimitsFile: %s; preserving the previous config", err)
+				continue
+			}
+			strg.SetTenantLimits(tl)
+			tenantLimitsSuccess.Set(1)
+			tenantLimitsTimestamp.Set(fasttime.UnixTimestamp())
+			logger.Infof("successfully reloaded -tenantLimitsFile=%q", *tenantLimitsFile)
+		}
+	}()
+}
+
+func stopTenantLimitsReloader() {
+	if tenantLimitsReloaderStopCh == nil {
+		return
+	}
+	close(tenantLimitsReloaderStopCh)
+	tenantLimitsReloaderWG.Wait()
+	tenantLimitsReloaderStopCh = nil
+}
+
+func loadTenantLimits() (*logstorage.TenantLimits, error) {
+	if *tenantLimitsFile == "" {
+		return nil, nil
+	}
+	data, err := fscore.ReadFileOrHTTP(*tenantLimitsFile)
+	if err != nil {
+		return nil, err
+	}
+	tl, err := logstorage.ParseTenantLimits(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %q: %w", *tenantLimitsFile, err)
+	}
+	return tl, nil
+}
+
+var (
+	tenantLimitsReloads      = metrics.NewCounter(`vl_tenant_limits_config_reloads_total`)
+	tenantLimitsReloadErrors = metrics.NewCounter(`vl_tenant_limits_config_reloads_errors_total`)
+	tenantLimitsSuccess      = metrics.NewGauge(`vl_tenant_limits_config_last_reload_successful`, nil)
+	tenantLimitsTimestamp    = metrics.NewCounter(`vl_tenant_limits_config_last_reload_success_timestamp_seconds`)
+)
+
+// GetTenantStats returns per-tenant usage stats.
+func GetTenantStats() ([]logstorage.TenantStats, error) {
+	if netstrg != nil {
+		return netstrg.GetTenantStats()
+	}
+	return strg.GetTenantStats(), nil
+}
+
 // CanWriteData returns non-nil error if it cannot write data to vlstorage.
 func CanWriteData() error {
+	if netstrg != nil {
+		// Storage nodes apply backpressure if they cannot accept the data.
+		return nil
+	}
 	if strg.IsReadOnly() {
 		return &httpserver.ErrorWithStatusCode{
 			Err: fmt.Errorf("cannot add rows into storage in read-only mode; the storage can be in read-only mode "+
@@ -130,16 +514,36 @@ func CanWriteData() error {
 //
 // It is advised to call CanWriteData() before calling MustAddRows()
 func MustAddRows(lr *logstorage.LogRows) {
+	if netstrg != nil {
+		netstrg.MustAddRows(lr)
+		return
+	}
 	strg.MustAddRows(lr)
 }
 
 // RunQuery runs the given q and calls writeBlock for the returned data blocks
 func RunQuery(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, writeBlock logstorage.WriteBlockFunc) error {
+	if netstrg != nil {
+		return netstrg.RunQuery(ctx, tenantIDs, q, writeBlock)
+	}
 	return strg.RunQuery(ctx, tenantIDs, q, writeBlock)
 }
 
+// GetMinIngestedTimestamp returns the minimum timestamp of log entries ingested or deleted for the given tenantIDs since the given timestamp.
+//
+// See logstorage.Storage.GetMinIngestedTimestamp for details.
+func GetMinIngestedTimestamp(tenantIDs []logstorage.TenantID, since int64) int64 {
+	if netstrg != nil {
+		return netstrg.GetMinIngestedTimestamp(tenantIDs, since)
+	}
+	return strg.GetMinIngestedTimestamp(tenantIDs, since)
+}
+
 // GetFieldNames executes q and returns field names seen in results.
 func GetFieldNames(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query) ([]logstorage.ValueWithHits, error) {
+	if netstrg != nil {
+		return netstrg.GetFieldNames(ctx, tenantIDs, q)
+	}
 	return strg.GetFieldNames(ctx, tenantIDs, q)
 }
 
@@ -147,11 +551,17 @@ func GetFieldNames(ctx context.Context, tenantIDs []logstorage.TenantID, q *logs
 //
 // If limit > 0, then up to limit unique values are returned.
 func GetFieldValues(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, fieldName string, limit uint64) ([]logstorage.ValueWithHits, error) {
+	if netstrg != nil {
+		return netstrg.GetFieldValues(ctx, tenantIDs, q, fieldName, limit)
+	}
 	return strg.GetFieldValues(ctx, tenantIDs, q, fieldName, limit)
 }
 
 // GetStreamFieldNames executes q and returns stream field names seen in results.
 func GetStreamFieldNames(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query) ([]logstorage.ValueWithHits, error) {
+	if netstrg != nil {
+		return netstrg.GetStreamFieldNames(ctx, tenantIDs, q)
+	}
 	return strg.GetStreamFieldNames(ctx, tenantIDs, q)
 }
 
@@ -159,6 +569,9 @@ func GetStreamFieldNames(ctx context.Context, tenantIDs []logstorage.TenantID, q
 //
 // If limit > 0, then up to limit unique stream field values are returned.
 func GetStreamFieldValues(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, fieldName string, limit uint64) ([]logstorage.ValueWithHits, error) {
+	if netstrg != nil {
+		return netstrg.GetStreamFieldValues(ctx, tenantIDs, q, fieldName, limit)
+	}
 	return strg.GetStreamFieldValues(ctx, tenantIDs, q, fieldName, limit)
 }
 
@@ -166,6 +579,9 @@ func GetStreamFieldValues(ctx context.Context, tenantIDs []logstorage.TenantID,
 //
 // If limit > 0, then up to limit unique streams are returned.
 func GetStreams(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, limit uint64) ([]logstorage.ValueWithHits, error) {
+	if netstrg != nil {
+		return netstrg.GetStreams(ctx, tenantIDs, q, limit)
+	}
 	return strg.GetStreams(ctx, tenantIDs, q, limit)
 }
 
@@ -173,6 +589,9 @@ func GetStreams(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstor
 //
 // If limit > 0, then up to limit unique streamIDs are returned.
 func GetStreamIDs(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, limit uint64) ([]logstorage.ValueWithHits, error) {
+	if netstrg != nil {
+		return netstrg.GetStreamIDs(ctx, tenantIDs, q, limit)
+	}
 	return strg.GetStreamIDs(ctx, tenantIDs, q, limit)
 }
 
@@ -228,6 +647,20 @@ func writeStorageMetrics(w io.Writer, strg *logstorage.Storage) {
 
 	metrics.WriteCounterUint64(w, `vl_rows_dropped_total{reason="too_big_timestamp"}`, ss.RowsDroppedTooBigTimestamp)
 	metrics.WriteCounterUint64(w, `vl_rows_dropped_total{reason="too_small_timestamp"}`, ss.RowsDroppedTooSmallTimestamp)
+
+	metrics.WriteCounterUint64(w, `vl_rows_deleted_total`, ss.RowsDeleted)
+	metrics.WriteGaugeUint64(w, `vl_active_delete_tasks`, ss.ActiveDeleteTasks)
+
+	for _, ts := range strg.GetTenantStats() {
+		if !ts.HasLimits {
+			continue
+		}
+		tenant := fmt.Sprintf("%d:%d", ts.TenantID.AccountID, ts.TenantID.ProjectID)
+		metrics.WriteCounterUint64(w, fmt.Sprintf(`vl_tenant_rows_rejected_total{tenant=%q,reason="retention"}`, tenant), ts.RowsRejectedRetention)
+		metrics.WriteCounterUint64(w, fmt.Sprintf(`vl_tenant_rows_rejected_total{tenant=%q,reason="max_bytes_per_day"}`, tenant), ts.RowsRejectedMaxBytesPerDay)
+		metrics.WriteCounterUint64(w, fmt.Sprintf(`vl_tenant_rows_rejected_total{tenant=%q,reason="max_new_streams_per_hour"}`, tenant), ts.RowsRejectedMaxNewStreamsPerHour)
+		metrics.WriteGaugeUint64(w, fmt.Sprintf(`vl_tenant_ingested_bytes_today{tenant=%q}`, tenant), ts.BytesIngestedToday)
+	}
 }
 
 var activeForceMerges = metrics.NewCounter("vl_active_force_merges")
@@ -0,0 +1,290 @@
+package netstorage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding/zstd"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+const (
+	// maxPendingDataSize is the maximum size of the pending data per storage node.
+	//
+	// MustAddRows blocks when the pending data for available storage node exceeds this size. This provides backpressure
+	// when storage nodes cannot keep up with the ingestion rate. The pending data for unavailable storage node is sent
+	// when the node becomes available. New rows for unavailable storage node are skipped when its pending data exceeds this size.
+	maxPendingDataSize = 32 * 1024 * 1024
+
+	// flushPendingDataSize is the size of the pending data, which triggers sending it to the storage node.
+	flushPendingDataSize = 1024 * 1024
+
+	// flushInterval is the maximum interval between sending the pending data to the storage node.
+	flushInterval = time.Second
+
+	// sendRetryInterval is the interval between attempts to send data to the unavailable storage node.
+	sendRetryInterval = time.Second
+
+	// sendTimeout is the timeout for sending a single data block to the storage node.
+	sendTimeout = 30 * time.Second
+)
+
+// MustAddRows sends lr to storage nodes.
+//
+// Every log stream is sent to the replicationFactor storage nodes starting from the node returned by logstorage.GetStreamNodeIdx.
+// The call blocks if available storage nodes cannot keep up with the ingestion rate. Rows for unavailable storage nodes are buffered
+// until the buffer is full, so unavailable storage nodes do not block the ingestion.
+func (s *Storage) MustAddRows(lr *logstorage.LogRows) {
+	lr.ForEachRow(func(streamHash uint64, r *logstorage.InsertRow) {
+		idx := logstorage.GetStreamNodeIdx(streamHash, len(s.sns))
+		s.addRow(idx, r)
+	})
+}
+
+// addRow sends r to the replicationFactor storage nodes starting from the node with the given idx.
+//
+// If all these nodes cannot accept r, then it is rerouted to the next available storage node, which can accept it.
+func (s *Storage) addRow(idx int, r *logstorage.InsertRow) {
+	replicas := 0
+	var skippedReplicas []*storageNode
+	for i := 0; i < s.replicationFactor; i++ {
+		sn := s.sns[(idx+i)%len(s.sns)]
+		if sn.addRowIfAvailable(r) || sn.tryAddRow(r) {
+			replicas++
+			continue
+		}
+		// The replica is unavailable and its buffer is full.
+		sn.rowsSkipped.Inc()
+		skippedReplicas = append(skippedReplicas, sn)
+	}
+	if replicas > 0 {
+		// r is missing at the skipped replicas, while it is stored at other replicas.
+		// Queries mustn't read the log stream for r from the skipped replicas.
+		for _, sn := range skippedReplicas {
+			sn.registerSkippedRow(r.Timestamp)
+		}
+		return
+	}
+
+	// All the replicas are unavailable and cannot buffer r. Reroute r to the next available storage node.
+	// Such rows are stored only at a single storage node, which isn't a replica for the log stream.
+	for i := s.replicationFactor; i < len(s.sns); i++ {
+		sn := s.sns[(idx+i)%len(s.sns)]
+		if sn.isAvailable() && sn.tryAddRow(r) {
+			sn.rowsRerouted.Inc()
+			return
+		}
+	}
+
+	// All the storage nodes cannot accept r. Wait until the first replica accepts it in order to provide backpressure.
+	s.sns[idx].addRow(r)
+}
+
+// addRowIfAvailable adds r to the pending data for sn if sn is available.
+//
+// It blocks until sn has free space in the pending data buffer. It returns false without adding r if sn is unavailable
+// or if it becomes unavailable while waiting for free space.
+func (sn *storageNode) addRowIfAvailable(r *logstorage.InsertRow) bool {
+	if !sn.isAvailable() {
+		return false
+	}
+
+	sn.pendingDataLock.Lock()
+	for len(sn.pendingData) >= maxPendingDataSize {
+		if sn.isStopped() || !sn.isAvailable() {
+			sn.pendingDataLock.Unlock()
+			return false
+		}
+		sn.pendingDataCond.Wait()
+	}
+	sn.addRowLocked(r)
+	return true
+}
+
+// tryAddRow adds r to the pending data for sn.
+//
+// It returns false if the pending data buffer for sn is full.
+func (sn *storageNode) tryAddRow(r *logstorage.InsertRow) bool {
+	sn.pendingDataLock.Lock()
+	if len(sn.pendingData) >= maxPendingDataSize {
+		sn.pendingDataLock.Unlock()
+		return false
+	}
+	sn.addRowLocked(r)
+	return true
+}
+
+// addRow adds r to the pending data for sn.
+//
+// It blocks until sn has free space in the pending data buffer.
+func (sn *storageNode) addRow(r *logstorage.InsertRow) {
+	sn.pendingDataLock.Lock()
+	for len(sn.pendingData) >= maxPendingDataSize && !sn.isStopped() {
+		sn.pendingDataCond.Wait()
+	}
+	sn.addRowLocked(r)
+}
+
+// registerSkippedRow registers the row with the given timestamp, which wasn't sent to sn, while it was sent to other replicas.
+func (sn *storageNode) registerSkippedRow(timestamp int64) {
+	sn.skippedRowsLock.Lock()
+	if sn.skippedRowsMinTimestamp > timestamp {
+		sn.skippedRowsMinTimestamp = timestamp
+	}
+	if sn.skippedRowsMaxTimestamp < timestamp {
+		sn.skippedRowsMaxTimestamp = timestamp
+	}
+	sn.skippedRowsLock.Unlock()
+}
+
+// hasSkippedRows returns true if some rows on the [minTimestamp, maxTimestamp] time range weren't sent to sn.
+func (sn *storageNode) hasSkippedRows(minTimestamp, maxTimestamp int64) bool {
+	sn.skippedRowsLock.Lock()
+	defer sn.skippedRowsLock.Unlock()
+
+	return sn.skippedRowsMinTimestamp <= maxTimestamp && sn.skippedRowsMaxTimestamp >= minTimestamp
+}
+
+// addRowLocked adds r to the pending data for sn and unlocks sn.pendingDataLock.
+//
+// sn.pendingDataLock must be locked when calling this function.
+func (sn *storageNode) addRowLocked(r *logstorage.InsertRow) {
+	sn.pendingData = r.Marshal(sn.pendingData)
+	sn.pendingRows++
+	needFlush := len(sn.pendingData) >= flushPendingDataSize
+	sn.pendingDataLock.Unlock()
+
+	if needFlush {
+		select {
+		case sn.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (sn *storageNode) isStopped() bool {
+	select {
+	case <-sn.s.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func (sn *storageNode) runFlusher() {
+	t := time.NewTicker(flushInterval)
+	defer t.Stop()
+
+	var buf []byte
+	for {
+		select {
+		case <-sn.s.stopCh:
+			sn.flushPendingData(buf)
+			// Wake up goroutines blocked in addRow.
+			sn.pendingDataCond.Broadcast()
+			return
+		case <-t.C:
+		case <-sn.flushCh:
+		}
+		buf = sn.flushPendingData(buf)
+	}
+}
+
+// flushPendingData sends the pending data to sn.
+//
+// buf is used as a replacement for the pending data. The sent data buffer is returned, so it can be reused.
+func (sn *storageNode) flushPendingData(buf []byte) []byte {
+	sn.pendingDataLock.Lock()
+	data := sn.pendingData
+	rowsCount := sn.pendingRows
+	sn.pendingData = buf[:0]
+	sn.pendingRows = 0
+	sn.pendingDataLock.Unlock()
+
+	if len(data) > 0 {
+		compressedData := zstd.CompressLevel(nil, data, 1)
+		sn.mustSendInsertData(compressedData, rowsCount)
+	}
+
+	// Wake up goroutines blocked in addRow.
+	sn.pendingDataCond.Broadcast()
+
+	return data
+}
+
+func (sn *storageNode) mustSendInsertData(data []byte, rowsCount int) {
+	for {
+		err := sn.sendInsertData(data)
+		if err == nil {
+			sn.rowsSent.Add(rowsCount)
+			return
+		}
+		sn.sendErrors.Inc()
+		sn.markUnavailable()
+		if sn.isStopped() {
+			logger.Errorf("dropping %d rows, since they cannot be sent to -storageNode=%q during shutdown: %s", rowsCount, sn.addr, err)
+			return
+		}
+		logger.Warnf("cannot send %d rows to -storageNode=%q: %s; retrying in %s", rowsCount, sn.addr, err, sendRetryInterval)
+
+		t := time.NewTimer(sendRetryInterval)
+		select {
+		case <-sn.s.stopCh:
+			t.Stop()
+		case <-t.C:
+		}
+	}
+}
+
+func (sn *storageNode) sendInsertData(data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	args := url.Values{}
+	args.Set("version", ProtocolVersion)
+	reqURL := sn.baseURL + "/internal/insert?" + args.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("cannot create request to %q: %w", reqURL, err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := sn.s.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("unexpected status code returned from %q: %d; response body: %q", reqURL, resp.StatusCode, body)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// ForEachInsertRow calls callback for every row in the data sent by MustAddRows.
+//
+// callback cannot hold references to r after returning.
+func ForEachInsertRow(compressedData []byte, callback func(r *logstorage.InsertRow)) error {
+	data, err := zstd.Decompress(nil, compressedData)
+	if err != nil {
+		return fmt.Errorf("cannot decompress data: %w", err)
+	}
+
+	var r logstorage.InsertRow
+	for len(data) > 0 {
+		tail, err := r.UnmarshalInplace(data)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal row: %w", err)
+		}
+		data = tail
+		callback(&r)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package netstorage
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+func newTestStorage(replicationFactor, nodesCount int) *Storage {
+	s := &Storage{
+		replicationFactor: replicationFactor,
+		stopCh:            make(chan struct{}),
+	}
+	for i := 0; i < nodesCount; i++ {
+		sn := &storageNode{
+			s:                       s,
+			flushCh:                 make(chan struct{}, 1),
+			skippedRowsMinTimestamp: math.MaxInt64,
+			skippedRowsMaxTimestamp: math.MinInt64,
+			rowsSkipped:             &metrics.Counter{},
+			rowsRerouted:            &metrics.Counter{},
+		}
+		sn.pendingDataCond = sync.NewCond(&sn.pendingDataLock)
+		s.sns = append(s.sns, sn)
+	}
+	return s
+}
+
+func newTestInsertRow(timestamp int64) *logstorage.InsertRow {
+	return &logstorage.InsertRow{
+		Timestamp: timestamp,
+		Fields: []logstorage.Field{
+			{
+				Name:  "_msg",
+				Value: "foo",
+			},
+		},
+	}
+}
+
+func TestStorageAddRow(t *testing.T) {
+	f := func(replicationFactor int, unavailableNodeIdxs, fullNodeIdxs []int, rowsExpected []int, skippedRowsNodeIdxsExpected []int) {
+		t.Helper()
+
+		s := newTestStorage(replicationFactor, len(rowsExpected))
+		for _, idx := range unavailableNodeIdxs {
+			s.sns[idx].markUnavailable()
+		}
+		for _, idx := range fullNodeIdxs {
+			s.sns[idx].pendingData = make([]byte, maxPendingDataSize)
+		}
+
+		s.addRow(1, newTestInsertRow(123))
+
+		var skippedRowsNodeIdxs []int
+		for i, sn := range s.sns {
+			if sn.pendingRows != rowsExpected[i] {
+				t.Fatalf("unexpected number of pending rows at node %d; got %d; want %d", i, sn.pendingRows, rowsExpected[i])
+			}
+			if sn.hasSkippedRows(123, 123) {
+				skippedRowsNodeIdxs = append(skippedRowsNodeIdxs, i)
+			}
+		}
+		if len(skippedRowsNodeIdxs) != len(skippedRowsNodeIdxsExpected) {
+			t.Fatalf("unexpected nodes with skipped rows; got %v; want %v", skippedRowsNodeIdxs, skippedRowsNodeIdxsExpected)
+		}
+		for i := range skippedRowsNodeIdxs {
+			if skippedRowsNodeIdxs[i] != skippedRowsNodeIdxsExpected[i] {
+				t.Fatalf("unexpected nodes with skipped rows; got %v; want %v", skippedRowsNodeIdxs, skippedRowsNodeIdxsExpected)
+			}
+		}
+	}
+
+	// all the nodes are available
+	f(1, nil, nil, []int{0, 1, 0, 0}, nil)
+	f(2, nil, nil, []int{0, 1, 1, 0}, nil)
+
+	// the row is buffered for unavailable replicas
+	f(2, []int{1}, nil, []int{0, 1, 1, 0}, nil)
+	f(1, []int{0, 1, 2, 3}, nil, []int{0, 1, 0, 0}, nil)
+
+	// unavailable replicas with full buffers are skipped
+	f(2, []int{1}, []int{1}, []int{0, 0, 1, 0}, []int{1})
+	f(3, []int{1, 2}, []int{1, 2}, []int{0, 0, 0, 1}, []int{1, 2})
+
+	// the row is rerouted to the next available node if all the replicas cannot accept it
+	f(1, []int{1}, []int{1}, []int{0, 0, 1, 0}, nil)
+	f(2, []int{1, 2}, []int{1, 2}, []int{0, 0, 0, 1}, nil)
+	f(2, []int{1, 2, 3}, []int{1, 2, 3}, []int{1, 0, 0, 0}, nil)
+}
+
+func TestStorageAddRowBlocksOnFullReplica(t *testing.T) {
+	f := func(unblock func(sn *storageNode), pendingRowsExpected int) {
+		t.Helper()
+
+		s := newTestStorage(1, 2)
+		sn := s.sns[0]
+		sn.pendingData = make([]byte, maxPendingDataSize)
+
+		doneCh := make(chan struct{})
+		go func() {
+			s.addRow(0, newTestInsertRow(123))
+			close(doneCh)
+		}()
+
+		select {
+		case <-doneCh:
+			t.Fatalf("addRow mustn't return while the available replica has full buffer")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		unblock(sn)
+		select {
+		case <-doneCh:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for addRow")
+		}
+		if sn.pendingRows != pendingRowsExpected {
+			t.Fatalf("unexpected number of pending rows at the replica; got %d; want %d", sn.pendingRows, pendingRowsExpected)
+		}
+	}
+
+	// the row is added to the replica after its buffer is sent
+	f(func(sn *storageNode) {
+		sn.pendingDataLock.Lock()
+		sn.pendingData = sn.pendingData[:0]
+		sn.pendingDataCond.Broadcast()
+		sn.pendingDataLock.Unlock()
+	}, 1)
+
+	// the row is rerouted to other node when the replica becomes unavailable
+	f(func(sn *storageNode) {
+		sn.markUnavailable()
+	}, 0)
+}
@@ -0,0 +1,412 @@
+package netstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+// ProtocolVersion is the version of the protocol used for communication between VictoriaLogs nodes in cluster mode.
+//
+// It must be changed on every incompatible change in the protocol.
+const ProtocolVersion = "v1"
+
+// unavailableNodeRetryInterval is the interval during which the storage node isn't queried after network errors
+// if the missing data can be obtained from other replicas.
+const unavailableNodeRetryInterval = 10 * time.Second
+
+// healthCheckInterval is the interval between health checks for storage nodes.
+//
+// Health checks allow detecting unavailable storage nodes before sending queries to them.
+const healthCheckInterval = time.Second
+
+// Storage spreads the ingested logs among storage nodes and queries them in cluster mode.
+type Storage struct {
+	sns []*storageNode
+
+	replicationFactor    int
+	allowPartialResponse bool
+
+	ns *logstorage.NetStorage
+
+	c *http.Client
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+type storageNode struct {
+	s *Storage
+
+	// addr is the address of the storage node as passed to NewStorage
+	addr string
+
+	// baseURL is the url prefix for requests to the storage node
+	baseURL string
+
+	// unavailableUntil is the unix timestamp in seconds until the storage node is considered unavailable for queries
+	unavailableUntil atomic.Uint64
+
+	// pendingDataLock protects pendingData and pendingRows
+	pendingDataLock sync.Mutex
+
+	// pendingDataCond is signaled when pendingData is sent to the storage node
+	pendingDataCond *sync.Cond
+
+	// pendingData contains marshaled rows, which must be sent to the storage node
+	pendingData []byte
+
+	// pendingRows is the number of rows in pendingData
+	pendingRows int
+
+	// flushCh is used for notifying the flusher about big amounts of pendingData
+	flushCh chan struct{}
+
+	// skippedRowsLock protects skippedRowsMinTimestamp and skippedRowsMaxTimestamp
+	skippedRowsLock sync.Mutex
+
+	// skippedRowsMinTimestamp and skippedRowsMaxTimestamp contain the time range for rows, which weren't sent to the storage node,
+	// since it was unavailable and its pending data buffer was full.
+	//
+	// Such rows are missing at the storage node, so it mustn't be used as a replica for queries over this time range.
+	skippedRowsMinTimestamp int64
+	skippedRowsMaxTimestamp int64
+
+	rowsSent       *metrics.Counter
+	rowsSkipped    *metrics.Counter
+	rowsRerouted   *metrics.Counter
+	sendErrors     *metrics.Counter
+	queryErrors    *metrics.Counter
+	skippedQueries *metrics.Counter
+}
+
+// NewStorage returns new Storage for the given storage node addresses.
+//
+// Every ingested log stream is replicated to replicationFactor storage nodes.
+// If allowPartialResponse is set, then queries return partial results when some of storage nodes are unavailable.
+//
+// MustStop must be called when the returned Storage is no longer needed.
+func NewStorage(addrs []string, replicationFactor int, allowPartialResponse bool) *Storage {
+	if replicationFactor < 1 {
+		replicationFactor = 1
+	}
+	if replicationFactor > len(addrs) {
+		logger.Warnf("replicationFactor=%d exceeds the number of storage nodes=%d; using replicationFactor=%d", replicationFactor, len(addrs), len(addrs))
+		replicationFactor = len(addrs)
+	}
+
+	s := &Storage{
+		replicationFactor:    replicationFactor,
+		allowPartialResponse: allowPartialResponse,
+		c:                    &http.Client{},
+		stopCh:               make(chan struct{}),
+	}
+	for _, addr := range addrs {
+		baseURL := addr
+		if !strings.Contains(baseURL, "://") {
+			baseURL = "http://" + baseURL
+		}
+		baseURL = strings.TrimSuffix(baseURL, "/")
+
+		sn := &storageNode{
+			s:       s,
+			addr:    addr,
+			baseURL: baseURL,
+			flushCh: make(chan struct{}, 1),
+
+			skippedRowsMinTimestamp: math.MaxInt64,
+			skippedRowsMaxTimestamp: math.MinInt64,
+
+			rowsSent:       metrics.GetOrCreateCounter(fmt.Sprintf(`vl_insert_remote_rows_sent_total{addr=%q}`, addr)),
+			rowsSkipped:    metrics.GetOrCreateCounter(fmt.Sprintf(`vl_insert_remote_rows_skipped_total{addr=%q}`, addr)),
+			rowsRerouted:   metrics.GetOrCreateCounter(fmt.Sprintf(`vl_insert_remote_rows_rerouted_total{addr=%q}`, addr)),
+			sendErrors:     metrics.GetOrCreateCounter(fmt.Sprintf(`vl_insert_remote_send_errors_total{addr=%q}`, addr)),
+			queryErrors:    metrics.GetOrCreateCounter(fmt.Sprintf(`vl_select_remote_query_errors_total{addr=%q}`, addr)),
+			skippedQueries: metrics.GetOrCreateCounter(fmt.Sprintf(`vl_select_remote_skipped_queries_total{addr=%q}`, addr)),
+		}
+		sn.pendingDataCond = sync.NewCond(&sn.pendingDataLock)
+		_ = metrics.GetOrCreateGauge(fmt.Sprintf(`vl_insert_remote_pending_data_bytes{addr=%q}`, addr), func() float64 {
+			sn.pendingDataLock.Lock()
+			n := len(sn.pendingData)
+			sn.pendingDataLock.Unlock()
+			return float64(n)
+		})
+		s.sns = append(s.sns, sn)
+	}
+
+	s.ns = logstorage.NewNetStorage(len(s.sns), s.runNetQuery)
+
+	for _, sn := range s.sns {
+		s.wg.Add(1)
+		go func(sn *storageNode) {
+			defer s.wg.Done()
+			sn.runFlusher()
+		}(sn)
+		s.wg.Add(1)
+		go func(sn *storageNode) {
+			defer s.wg.Done()
+			sn.runHealthChecker()
+		}(sn)
+	}
+
+	return s
+}
+
+// MustStop stops s.
+//
+// It sends the pending data to storage nodes before returning.
+func (s *Storage) MustStop() {
+	close(s.stopCh)
+	s.wg.Wait()
+}
+
+// RunQuery runs the given q at storage nodes and calls writeBlock for the merged results.
+func (s *Storage) RunQuery(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, writeBlock logstorage.WriteBlockFunc) error {
+	return s.ns.RunQuery(ctx, tenantIDs, q, writeBlock)
+}
+
+// GetFieldNames executes q at storage nodes and returns field names seen in results.
+func (s *Storage) GetFieldNames(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query) ([]logstorage.ValueWithHits, error) {
+	return s.ns.GetFieldNames(ctx, tenantIDs, q)
+}
+
+// GetFieldValues executes q at storage nodes and returns unique values for the fieldName seen in results.
+//
+// If limit > 0, then up to limit unique values are returned.
+func (s *Storage) GetFieldValues(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, fieldName string, limit uint64) ([]logstorage.ValueWithHits, error) {
+	return s.ns.GetFieldValues(ctx, tenantIDs, q, fieldName, limit)
+}
+
+// GetStreamFieldNames executes q at storage nodes and returns stream field names seen in results.
+func (s *Storage) GetStreamFieldNames(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query) ([]logstorage.ValueWithHits, error) {
+	return s.ns.GetStreamFieldNames(ctx, tenantIDs, q)
+}
+
+// GetStreamFieldValues executes q at storage nodes and returns stream field values for the given fieldName seen in results.
+//
+// If limit > 0, then up to limit unique stream field values are returned.
+func (s *Storage) GetStreamFieldValues(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, fieldName string, limit uint64) ([]logstorage.ValueWithHits, error) {
+	return s.ns.GetStreamFieldValues(ctx, tenantIDs, q, fieldName, limit)
+}
+
+// GetStreams executes q at storage nodes and returns streams seen in query results.
+//
+// If limit > 0, then up to limit unique streams are returned.
+func (s *Storage) GetStreams(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, limit uint64) ([]logstorage.ValueWithHits, error) {
+	return s.ns.GetStreams(ctx, tenantIDs, q, limit)
+}
+
+// GetStreamIDs executes q at storage nodes and returns streamIDs seen in query results.
+//
+// If limit > 0, then up to limit unique streamIDs are returned.
+func (s *Storage) GetStreamIDs(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, limit uint64) ([]logstorage.ValueWithHits, error) {
+	return s.ns.GetStreamIDs(ctx, tenantIDs, q, limit)
+}
+
+// GetMinIngestedTimestamp returns the minimum timestamp of log entries ingested or deleted at all the storage nodes for the given tenantIDs since the given timestamp.
+//
+// math.MinInt64 is returned if some of storage nodes cannot return the timestamp, so cached results could be invalidated.
+//
+// See logstorage.Storage.GetMinIngestedTimestamp for details.
+func (s *Storage) GetMinIngestedTimestamp(tenantIDs []logstorage.TenantID, since int64) int64 {
+	timestamps := make([]int64, len(s.sns))
+	s.forEachNode(func(idx int, sn *storageNode) {
+		ts, err := sn.getMinIngestedTimestamp(tenantIDs, since)
+		if err != nil {
+			logger.Warnf("cannot obtain the minimum ingested timestamp from -storageNode=%q: %s", sn.addr, err)
+			ts = math.MinInt64
+		}
+		timestamps[idx] = ts
+	})
+	return slices.Min(timestamps)
+}
+
+// GetTenantStats returns per-tenant usage stats summed over all the storage nodes.
+func (s *Storage) GetTenantStats() ([]logstorage.TenantStats, error) {
+	results := make([][]logstorage.TenantStats, len(s.sns))
+	errs := make([]error, len(s.sns))
+	s.forEachNode(func(idx int, sn *storageNode) {
+		results[idx], errs[idx] = sn.getTenantStats()
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return mergeTenantStats(results), nil
+}
+
+func mergeTenantStats(results [][]logstorage.TenantStats) []logstorage.TenantStats {
+	m := make(map[logstorage.TenantID]*logstorage.TenantStats)
+	for _, tss := range results {
+		for i := range tss {
+			ts := &tss[i]
+			dst := m[ts.TenantID]
+			if dst == nil {
+				// Limits are configured per every storage node, so take them from the first node.
+				tsCopy := *ts
+				m[ts.TenantID] = &tsCopy
+				continue
+			}
+			dst.HasLimits = dst.HasLimits || ts.HasLimits
+			dst.BytesIngestedToday += ts.BytesIngestedToday
+			dst.RowsIngestedToday += ts.RowsIngestedToday
+			dst.NewStreamsThisHour += ts.NewStreamsThisHour
+			dst.RowsRejectedRetention += ts.RowsRejectedRetention
+			dst.RowsRejectedMaxBytesPerDay += ts.RowsRejectedMaxBytesPerDay
+			dst.RowsRejectedMaxNewStreamsPerHour += ts.RowsRejectedMaxNewStreamsPerHour
+		}
+	}
+
+	tss := make([]logstorage.TenantStats, 0, len(m))
+	for _, ts := range m {
+		tss = append(tss, *ts)
+	}
+	slices.SortFunc(tss, func(a, b logstorage.TenantStats) int {
+		if a.TenantID.AccountID != b.TenantID.AccountID {
+			return compareUint32(a.TenantID.AccountID, b.TenantID.AccountID)
+		}
+		return compareUint32(a.TenantID.ProjectID, b.TenantID.ProjectID)
+	})
+	return tss
+}
+
+func compareUint32(a, b uint32) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+func (s *Storage) forEachNode(f func(idx int, sn *storageNode)) {
+	var wg sync.WaitGroup
+	for i, sn := range s.sns {
+		wg.Add(1)
+		go func(idx int, sn *storageNode) {
+			defer wg.Done()
+			f(idx, sn)
+		}(i, sn)
+	}
+	wg.Wait()
+}
+
+func (sn *storageNode) runHealthChecker() {
+	t := time.NewTicker(healthCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-sn.s.stopCh:
+			return
+		case <-t.C:
+		}
+		if err := sn.checkHealth(); err != nil {
+			if sn.isAvailable() {
+				logger.Warnf("-storageNode=%q is unavailable: %s", sn.addr, err)
+			}
+			sn.markUnavailable()
+		}
+	}
+}
+
+func (sn *storageNode) checkHealth() error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckInterval)
+	defer cancel()
+
+	reqURL := sn.baseURL + "/health"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("cannot create request to %q: %w", reqURL, err)
+	}
+	resp, err := sn.s.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code returned from %q: %d", reqURL, resp.StatusCode)
+	}
+	return nil
+}
+
+func (sn *storageNode) isAvailable() bool {
+	return fasttime.UnixTimestamp() >= sn.unavailableUntil.Load()
+}
+
+func (sn *storageNode) markUnavailable() {
+	sn.unavailableUntil.Store(fasttime.UnixTimestamp() + uint64(unavailableNodeRetryInterval.Seconds()))
+
+	// Wake up goroutines blocked in addRowIfAvailable, so they do not wait for the unavailable storage node.
+	// The lock is needed for preventing from lost wakeups for goroutines, which are going to wait.
+	sn.pendingDataLock.Lock()
+	sn.pendingDataCond.Broadcast()
+	sn.pendingDataLock.Unlock()
+}
+
+// MarshalTenantIDs returns string representation of tenantIDs, which can be parsed with ParseTenantIDs.
+func MarshalTenantIDs(tenantIDs []logstorage.TenantID) string {
+	a := make([]string, len(tenantIDs))
+	for i, tenantID := range tenantIDs {
+		a[i] = fmt.Sprintf("%d:%d", tenantID.AccountID, tenantID.ProjectID)
+	}
+	return strings.Join(a, ",")
+}
+
+// ParseTenantIDs parses tenantIDs from s obtained via MarshalTenantIDs.
+func ParseTenantIDs(s string) ([]logstorage.TenantID, error) {
+	if s == "" {
+		return nil, nil
+	}
+	a := strings.Split(s, ",")
+	tenantIDs := make([]logstorage.TenantID, len(a))
+	for i, v := range a {
+		tenantID, err := logstorage.ParseTenantID(v)
+		if err != nil {
+			return nil, err
+		}
+		tenantIDs[i] = tenantID
+	}
+	return tenantIDs, nil
+}
+
+func marshalInts(a []int) string {
+	b := make([]string, len(a))
+	for i, n := range a {
+		b[i] = strconv.Itoa(n)
+	}
+	return strings.Join(b, ",")
+}
+
+func parseInts(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	a := strings.Split(s, ",")
+	result := make([]int, len(a))
+	for i, v := range a {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = n
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package netstorage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+func TestMarshalParseTenantIDs(t *testing.T) {
+	f := func(tenantIDs []logstorage.TenantID, sExpected string) {
+		t.Helper()
+
+		s := MarshalTenantIDs(tenantIDs)
+		if s != sExpected {
+			t.Fatalf("unexpected marshaled tenantIDs; got %q; want %q", s, sExpected)
+		}
+		result, err := ParseTenantIDs(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(result, tenantIDs) {
+			t.Fatalf("unexpected tenantIDs parsed from %q; got %v; want %v", s, result, tenantIDs)
+		}
+	}
+
+	f(nil, "")
+	f([]logstorage.TenantID{{}}, "0:0")
+	f([]logstorage.TenantID{{AccountID: 1, ProjectID: 2}, {AccountID: 123, ProjectID: 0}}, "1:2,123:0")
+}
+
+func TestParseTenantIDsFailure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+
+		_, err := ParseTenantIDs(s)
+		if err == nil {
+			t.Fatalf("expecting non-nil error for %q", s)
+		}
+	}
+
+	f("foo")
+	f("1:2,-3:4")
+}
+
+func TestMarshalParseInts(t *testing.T) {
+	f := func(a []int, sExpected string) {
+		t.Helper()
+
+		s := marshalInts(a)
+		if s != sExpected {
+			t.Fatalf("unexpected marshaled ints; got %q; want %q", s, sExpected)
+		}
+		result, err := parseInts(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(result, a) {
+			t.Fatalf("unexpected ints parsed from %q; got %v; want %v", s, result, a)
+		}
+	}
+
+	f(nil, "")
+	f([]int{0}, "0")
+	f([]int{3, 1, 12}, "3,1,12")
+}
+
+func TestMergeTenantStats(t *testing.T) {
+	results := [][]logstorage.TenantStats{
+		{
+			{
+				TenantID:           logstorage.TenantID{AccountID: 1},
+				HasLimits:          true,
+				MaxBytesPerDay:     1000,
+				BytesIngestedToday: 10,
+				RowsIngestedToday:  2,
+			},
+			{
+				TenantID:           logstorage.TenantID{},
+				RowsIngestedToday:  5,
+				NewStreamsThisHour: 1,
+			},
+		},
+		{
+			{
+				TenantID:              logstorage.TenantID{AccountID: 1},
+				HasLimits:             true,
+				MaxBytesPerDay:        1000,
+				BytesIngestedToday:    20,
+				RowsIngestedToday:     3,
+				RowsRejectedRetention: 4,
+			},
+		},
+	}
+	result := mergeTenantStats(results)
+	resultExpected := []logstorage.TenantStats{
+		{
+			TenantID:           logstorage.TenantID{},
+			RowsIngestedToday:  5,
+			NewStreamsThisHour: 1,
+		},
+		{
+			TenantID:              logstorage.TenantID{AccountID: 1},
+			HasLimits:             true,
+			MaxBytesPerDay:        1000,
+			BytesIngestedToday:    30,
+			RowsIngestedToday:     5,
+			RowsRejectedRetention: 4,
+		},
+	}
+	if !reflect.DeepEqual(result, resultExpected) {
+		t.Fatalf("unexpected result\ngot\n%v\nwant\n%v", result, resultExpected)
+	}
+}
@@ -0,0 +1,484 @@
+package netstorage
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding/zstd"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+// maxFrameSize is the maximum size of a single frame in the response for /internal/select/query
+const maxFrameSize = 256 * 1024 * 1024
+
+var partialResponses = metrics.NewCounter(`vl_select_partial_responses_total`)
+
+// queryError is returned when the storage node fails executing the query.
+//
+// Such errors are returned to the client even if partial responses are allowed, since other storage nodes fail on the same query.
+type queryError struct {
+	addr string
+	msg  string
+}
+
+func (qe *queryError) Error() string {
+	return fmt.Sprintf("-storageNode=%q cannot execute the query: %s", qe.addr, qe.msg)
+}
+
+func (s *Storage) runNetQuery(ctx context.Context, tenantIDs []logstorage.TenantID, q *logstorage.Query, writeBlock logstorage.WriteDataBlockFunc) error {
+	var unavailableNodeIdxs []int
+	for i, sn := range s.sns {
+		if !sn.isAvailable() {
+			unavailableNodeIdxs = append(unavailableNodeIdxs, i)
+		}
+	}
+	if len(unavailableNodeIdxs) >= s.replicationFactor && !s.allowPartialResponse {
+		// Some logs can be missing if the unavailable nodes are skipped, so query all the nodes.
+		unavailableNodeIdxs = nil
+	}
+	if len(unavailableNodeIdxs) == len(s.sns) {
+		return fmt.Errorf("all the -storageNode nodes are unavailable")
+	}
+
+	// Storage nodes with skipped rows on the queried time range mustn't return log streams, which are replicated to other nodes,
+	// since some logs for these streams are missing at such nodes. These nodes are still queried for the rest of logs.
+	var skippedRowsNodeIdxs []int
+	minTimestamp, maxTimestamp := q.GetFilterTimeRange()
+	for i, sn := range s.sns {
+		if !slices.Contains(unavailableNodeIdxs, i) && sn.hasSkippedRows(minTimestamp, maxTimestamp) {
+			skippedRowsNodeIdxs = append(skippedRowsNodeIdxs, i)
+		}
+	}
+	if len(skippedRowsNodeIdxs) > 0 && len(unavailableNodeIdxs)+len(skippedRowsNodeIdxs) >= s.replicationFactor {
+		// Some log streams may have no replicas with all the logs.
+		if !s.allowPartialResponse {
+			return fmt.Errorf("some logs on the queried time range are missing at -storageNode=%q, since they were unavailable during data ingestion; "+
+				"pass -search.allowPartialResponse command-line flag for returning partial results", s.getAddrs(skippedRowsNodeIdxs))
+		}
+		partialResponses.Inc()
+		logger.Warnf("returning partial response for the query [%s], since some logs on the queried time range are missing at -storageNode=%q",
+			q, s.getAddrs(skippedRowsNodeIdxs))
+		skippedRowsNodeIdxs = nil
+	}
+
+	ctxQuery, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	args := url.Values{}
+	args.Set("version", ProtocolVersion)
+	args.Set("tenant_ids", MarshalTenantIDs(tenantIDs))
+	args.Set("query", q.String())
+	args.Set("timestamp", strconv.FormatInt(q.GetTimestamp(), 10))
+	args.Set("nodes_count", strconv.Itoa(len(s.sns)))
+	args.Set("replication_factor", strconv.Itoa(s.replicationFactor))
+	args.Set("unavailable_nodes", marshalInts(append(slices.Clone(unavailableNodeIdxs), skippedRowsNodeIdxs...)))
+
+	var nodeIdxs []int
+	for i, sn := range s.sns {
+		if slices.Contains(unavailableNodeIdxs, i) {
+			sn.skippedQueries.Inc()
+			continue
+		}
+		nodeIdxs = append(nodeIdxs, i)
+	}
+	queriedNodes := len(nodeIdxs)
+
+	errs, retryNodeIdxs := s.queryNodes(ctxQuery, cancel, args, nodeIdxs, writeBlock)
+	if len(unavailableNodeIdxs)+len(skippedRowsNodeIdxs)+len(retryNodeIdxs) >= s.replicationFactor && !s.allowPartialResponse {
+		// Some logs cannot be obtained from the remaining replicas, so return the error.
+		retryNodeIdxs = nil
+	}
+	if len(retryNodeIdxs) > 0 && len(retryNodeIdxs) < queriedNodes {
+		// Some storage nodes failed before returning any data. Retry the query for log streams of these nodes at the next available replicas.
+		// The retry is performed only once, since the query at the retried nodes cannot be retried again without returning duplicate logs.
+		var retryNodes []string
+		var retryQueryNodeIdxs []int
+		for _, nodeIdx := range nodeIdxs {
+			if slices.Contains(retryNodeIdxs, nodeIdx) {
+				retryNodes = append(retryNodes, s.sns[nodeIdx].addr)
+				continue
+			}
+			if errs[nodeIdx] == nil {
+				retryQueryNodeIdxs = append(retryQueryNodeIdxs, nodeIdx)
+			}
+		}
+		logger.Warnf("retrying the query [%s] at other replicas, since the following storage nodes are unavailable: %q; the first error: %s",
+			q, retryNodes, errs[retryNodeIdxs[0]])
+
+		args.Set("retry_nodes", marshalInts(retryNodeIdxs))
+		retryErrs, _ := s.queryNodes(ctxQuery, cancel, args, retryQueryNodeIdxs, writeBlock)
+		for _, nodeIdx := range retryNodeIdxs {
+			// Logs for the retried nodes are obtained from the next available replicas.
+			errs[nodeIdx] = nil
+			unavailableNodeIdxs = append(unavailableNodeIdxs, nodeIdx)
+		}
+		for _, nodeIdx := range retryQueryNodeIdxs {
+			if err := retryErrs[nodeIdx]; err != nil {
+				errs[nodeIdx] = err
+			}
+		}
+		queriedNodes -= len(retryNodeIdxs)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var failedNodes []string
+	var firstErr error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		var qe *queryError
+		if errors.As(err, &qe) {
+			return err
+		}
+		if errors.Is(err, context.Canceled) {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		failedNodes = append(failedNodes, s.sns[i].addr)
+	}
+	if firstErr == nil {
+		if len(unavailableNodeIdxs)+len(skippedRowsNodeIdxs) >= s.replicationFactor {
+			// Some logs may be missing, since they are stored only at the skipped storage nodes.
+			partialResponses.Inc()
+		}
+		return nil
+	}
+	if !s.allowPartialResponse || len(failedNodes) == queriedNodes {
+		return firstErr
+	}
+
+	partialResponses.Inc()
+	logger.Warnf("returning partial response for the query [%s], since some of storage nodes are unavailable: %q; the first error: %s", q, failedNodes, firstErr)
+	return nil
+}
+
+func (s *Storage) getAddrs(nodeIdxs []int) []string {
+	addrs := make([]string, len(nodeIdxs))
+	for i, nodeIdx := range nodeIdxs {
+		addrs[i] = s.sns[nodeIdx].addr
+	}
+	return addrs
+}
+
+// queryNodes runs the query with the given args at storage nodes with the given nodeIdxs.
+//
+// It returns per-node errors indexed by node index and the indexes of storage nodes, which failed before returning any data.
+// The query for such nodes can be retried at other replicas without returning duplicate logs.
+func (s *Storage) queryNodes(ctx context.Context, cancel func(), args url.Values, nodeIdxs []int, writeBlock logstorage.WriteDataBlockFunc) ([]error, []int) {
+	// The query can be retried only once and only if every log stream is replicated to multiple nodes.
+	canRetry := s.replicationFactor > 1 && args.Get("retry_nodes") == ""
+
+	errs := make([]error, len(s.sns))
+	canRetryNodes := make([]bool, len(s.sns))
+	var wg sync.WaitGroup
+	for _, nodeIdx := range nodeIdxs {
+		sn := s.sns[nodeIdx]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			hasData := false
+			writeNodeBlock := func(db *logstorage.DataBlock) {
+				hasData = true
+				writeBlock(uint(nodeIdx), db)
+			}
+			err := sn.runQuery(ctx, args, nodeIdx, writeNodeBlock)
+			if err == nil {
+				return
+			}
+			errs[nodeIdx] = err
+			if ctx.Err() != nil {
+				// The query has been canceled.
+				return
+			}
+
+			sn.queryErrors.Inc()
+			var qe *queryError
+			if errors.As(err, &qe) {
+				// There is no sense in waiting for other nodes, since they fail on the same query.
+				cancel()
+				return
+			}
+			sn.markUnavailable()
+			if canRetry && !hasData {
+				canRetryNodes[nodeIdx] = true
+				return
+			}
+			if !s.allowPartialResponse {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var retryNodeIdxs []int
+	if ctx.Err() == nil {
+		for nodeIdx, ok := range canRetryNodes {
+			if ok {
+				retryNodeIdxs = append(retryNodeIdxs, nodeIdx)
+			}
+		}
+	}
+	return errs, retryNodeIdxs
+}
+
+func (sn *storageNode) runQuery(ctx context.Context, args url.Values, nodeIdx int, writeBlock func(db *logstorage.DataBlock)) error {
+	nodeArgs := url.Values{}
+	for k, vs := range args {
+		nodeArgs[k] = vs
+	}
+	nodeArgs.Set("node_idx", strconv.Itoa(nodeIdx))
+
+	reqURL := sn.baseURL + "/internal/select/query"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, strings.NewReader(nodeArgs.Encode()))
+	if err != nil {
+		return fmt.Errorf("cannot create request to %q: %w", reqURL, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := sn.s.c.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot query -storageNode=%q: %w", sn.addr, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("unexpected status code returned from %q: %d; response body: %q", reqURL, resp.StatusCode, body)
+	}
+
+	br := bufio.NewReaderSize(resp.Body, 64*1024)
+	var frame, data []byte
+	var valuesBuf []string
+	var db logstorage.DataBlock
+	for {
+		frame, err = readFrame(br, frame[:0])
+		if err != nil {
+			return fmt.Errorf("cannot read response from -storageNode=%q: %w", sn.addr, err)
+		}
+		if len(frame) == 0 {
+			// The end of the response. It is followed by the error message.
+			frame, err = readFrame(br, frame[:0])
+			if err != nil {
+				return fmt.Errorf("cannot read the final status from -storageNode=%q: %w", sn.addr, err)
+			}
+			if len(frame) > 0 {
+				return &queryError{
+					addr: sn.addr,
+					msg:  string(frame),
+				}
+			}
+			return nil
+		}
+
+		data, err = zstd.Decompress(data[:0], frame)
+		if err != nil {
+			return fmt.Errorf("cannot decompress data block from -storageNode=%q: %w", sn.addr, err)
+		}
+		tail, vb, err := db.UnmarshalInplace(data, valuesBuf[:0])
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal data block from -storageNode=%q: %w", sn.addr, err)
+		}
+		if len(tail) > 0 {
+			return fmt.Errorf("unexpected non-empty tail left after unmarshaling data block from -storageNode=%q; len(tail)=%d", sn.addr, len(tail))
+		}
+		valuesBuf = vb
+		writeBlock(&db)
+	}
+}
+
+func readFrame(br *bufio.Reader, dst []byte) ([]byte, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return dst, err
+	}
+	if n > maxFrameSize {
+		return dst, fmt.Errorf("too big frame size: %d bytes; mustn't exceed %d bytes", n, maxFrameSize)
+	}
+	dstLen := len(dst)
+	dst = bytesutil.ResizeNoCopyMayOverallocate(dst, dstLen+int(n))
+	if _, err := io.ReadFull(br, dst[dstLen:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return dst, err
+	}
+	return dst, nil
+}
+
+func appendFrame(dst, data []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(data)))
+	return append(dst, data...)
+}
+
+// QueryResponseWriter writes query results to the response for /internal/select/query request at storage node.
+type QueryResponseWriter struct {
+	mu sync.Mutex
+	bw *bufio.Writer
+}
+
+// NewQueryResponseWriter returns new QueryResponseWriter, which writes the response to w.
+func NewQueryResponseWriter(w io.Writer) *QueryResponseWriter {
+	return &QueryResponseWriter{
+		bw: bufio.NewWriterSize(w, 64*1024),
+	}
+}
+
+// WriteBlock writes the block with the given timestamps and columns to qw.
+//
+// It is safe calling WriteBlock from concurrently running goroutines.
+func (qw *QueryResponseWriter) WriteBlock(timestamps []int64, columns []logstorage.BlockColumn) {
+	db := logstorage.DataBlock{
+		Timestamps: timestamps,
+		Columns:    columns,
+	}
+
+	bb := bbPool.Get()
+	bb.B = db.Marshal(bb.B[:0])
+	data := zstd.CompressLevel(nil, bb.B, 1)
+	bb.B = appendFrame(bb.B[:0], data)
+
+	qw.mu.Lock()
+	// Ignore write errors, since they are returned when the client closes the connection.
+	// The query is canceled in this case via the request context.
+	_, _ = qw.bw.Write(bb.B)
+	qw.mu.Unlock()
+
+	bbPool.Put(bb)
+}
+
+// Finish writes the final status with the given optional query error to qw and flushes the response.
+func (qw *QueryResponseWriter) Finish(queryErr error) error {
+	var errMsg string
+	if queryErr != nil {
+		errMsg = queryErr.Error()
+	}
+	b := appendFrame(nil, nil)
+	b = appendFrame(b, []byte(errMsg))
+
+	qw.mu.Lock()
+	defer qw.mu.Unlock()
+
+	if _, err := qw.bw.Write(b); err != nil {
+		return err
+	}
+	return qw.bw.Flush()
+}
+
+var bbPool bytesutil.ByteBufferPool
+
+func (sn *storageNode) getMinIngestedTimestamp(tenantIDs []logstorage.TenantID, since int64) (int64, error) {
+	args := url.Values{}
+	args.Set("version", ProtocolVersion)
+	args.Set("tenant_ids", MarshalTenantIDs(tenantIDs))
+	args.Set("since", strconv.FormatInt(since, 10))
+
+	body, err := sn.getResponse("/internal/select/min_ingested_timestamp", args)
+	if err != nil {
+		return 0, err
+	}
+	ts, err := strconv.ParseInt(string(body), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse timestamp %q: %w", body, err)
+	}
+	return ts, nil
+}
+
+func (sn *storageNode) getTenantStats() ([]logstorage.TenantStats, error) {
+	args := url.Values{}
+	args.Set("version", ProtocolVersion)
+
+	body, err := sn.getResponse("/internal/select/tenant_stats", args)
+	if err != nil {
+		return nil, err
+	}
+	var tss []logstorage.TenantStats
+	if err := json.Unmarshal(body, &tss); err != nil {
+		return nil, fmt.Errorf("cannot parse tenant stats from -storageNode=%q: %w", sn.addr, err)
+	}
+	return tss, nil
+}
+
+func (sn *storageNode) getResponse(path string, args url.Values) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	reqURL := sn.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, strings.NewReader(args.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create request to %q: %w", reqURL, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := sn.s.c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read response from %q: %w", reqURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code returned from %q: %d; response body: %q", reqURL, resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// ParseStreamSharding parses logstorage.StreamSharding from /internal/select/query request args.
+func ParseStreamSharding(r *http.Request) (*logstorage.StreamSharding, error) {
+	nodeIdx, err := strconv.Atoi(r.FormValue("node_idx"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse node_idx: %w", err)
+	}
+	nodesCount, err := strconv.Atoi(r.FormValue("nodes_count"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse nodes_count: %w", err)
+	}
+	if nodeIdx < 0 || nodeIdx >= nodesCount {
+		return nil, fmt.Errorf("node_idx=%d must be in the range [0...%d)", nodeIdx, nodesCount)
+	}
+	replicationFactor, err := strconv.Atoi(r.FormValue("replication_factor"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse replication_factor: %w", err)
+	}
+	unavailableNodeIdxs, err := parseInts(r.FormValue("unavailable_nodes"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse unavailable_nodes: %w", err)
+	}
+	retryNodeIdxs, err := parseInts(r.FormValue("retry_nodes"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse retry_nodes: %w", err)
+	}
+	ss := &logstorage.StreamSharding{
+		NodeIdx:             nodeIdx,
+		NodesCount:          nodesCount,
+		ReplicationFactor:   replicationFactor,
+		UnavailableNodeIdxs: unavailableNodeIdxs,
+		RetryNodeIdxs:       retryNodeIdxs,
+	}
+	return ss, nil
+}
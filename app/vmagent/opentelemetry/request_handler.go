@@ -58,8 +58,9 @@ func insertRows(at *auth.Token, tss []prompbmarshal.TimeSeries, extraLabels []pr
 		samplesLen := len(samples)
 		samples = append(samples, ts.Samples...)
 		tssDst = append(tssDst, prompbmarshal.TimeSeries{
-			Labels:  labels[labelsLen:],
-			Samples: samples[samplesLen:],
+			Labels:    labels[labelsLen:],
+			Samples:   samples[samplesLen:],
+			Exemplars: ts.Exemplars,
 		})
 	}
 	ctx.WriteRequest.Timeseries = tssDst
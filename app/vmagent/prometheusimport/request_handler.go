@@ -7,6 +7,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/remotewrite"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/auth"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	parserCommon "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
 	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
@@ -32,20 +33,21 @@ func InsertHandler(at *auth.Token, req *http.Request) error {
 		return err
 	}
 	isGzipped := req.Header.Get("Content-Encoding") == "gzip"
-	return stream.Parse(req.Body, defaultTimestamp, isGzipped, true, func(rows []parser.Row) error {
-		return insertRows(at, rows, extraLabels)
+	return stream.Parse(req.Body, defaultTimestamp, isGzipped, true, func(rows []parser.Row, mms []parser.Metadata) error {
+		return insertRows(at, rows, mms, extraLabels)
 	}, func(s string) {
 		httpserver.LogError(req, s)
 	})
 }
 
-func insertRows(at *auth.Token, rows []parser.Row, extraLabels []prompbmarshal.Label) error {
+func insertRows(at *auth.Token, rows []parser.Row, mms []parser.Metadata, extraLabels []prompbmarshal.Label) error {
 	ctx := common.GetPushCtx()
 	defer common.PutPushCtx(ctx)
 
 	tssDst := ctx.WriteRequest.Timeseries[:0]
 	labels := ctx.Labels[:0]
 	samples := ctx.Samples[:0]
+	exemplars := ctx.Exemplars[:0]
 	for i := range rows {
 		r := &rows[i]
 		labelsLen := len(labels)
@@ -65,14 +67,50 @@ func insertRows(at *auth.Token, rows []parser.Row, extraLabels []prompbmarshal.L
 			Value:     r.Value,
 			Timestamp: r.Timestamp,
 		})
+		seriesLabelsLen := len(labels)
+		exemplarsLen := len(exemplars)
+		if r.HasExemplar {
+			e := &r.Exemplar
+			for j := range e.Tags {
+				tag := &e.Tags[j]
+				labels = append(labels, prompbmarshal.Label{
+					Name:  tag.Key,
+					Value: tag.Value,
+				})
+			}
+			timestamp := e.Timestamp
+			if timestamp == 0 {
+				timestamp = r.Timestamp
+			}
+			exemplars = append(exemplars, prompbmarshal.Exemplar{
+				Labels:    labels[seriesLabelsLen:],
+				Value:     e.Value,
+				Timestamp: timestamp,
+			})
+		}
 		tssDst = append(tssDst, prompbmarshal.TimeSeries{
-			Labels:  labels[labelsLen:],
-			Samples: samples[len(samples)-1:],
+			Labels:    labels[labelsLen:seriesLabelsLen:seriesLabelsLen],
+			Samples:   samples[len(samples)-1:],
+			Exemplars: exemplars[exemplarsLen:],
 		})
 	}
+	if parserCommon.IsMetadataEnabled() {
+		mmsDst := ctx.WriteRequest.Metadata[:0]
+		for i := range mms {
+			mm := &mms[i]
+			mmsDst = append(mmsDst, prompbmarshal.MetricMetadata{
+				Type:             uint32(prompb.GetMetricType(mm.Type)),
+				MetricFamilyName: mm.Metric,
+				Help:             mm.Help,
+				Unit:             mm.Unit,
+			})
+		}
+		ctx.WriteRequest.Metadata = mmsDst
+	}
 	ctx.WriteRequest.Timeseries = tssDst
 	ctx.Labels = labels
 	ctx.Samples = samples
+	ctx.Exemplars = exemplars
 	if !remotewrite.TryPush(at, &ctx.WriteRequest) {
 		return remotewrite.ErrQueueFullHTTPRetry
 	}
@@ -6,6 +6,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/common"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/remotewrite"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/auth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	parserCommon "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
@@ -21,18 +22,37 @@ var (
 )
 
 // InsertHandler processes remote write for prometheus.
-func InsertHandler(at *auth.Token, req *http.Request) error {
+//
+// Both Prometheus remote write 1.0 and 2.0 requests are supported. The protocol is selected via Content-Type header.
+// See https://prometheus.io/docs/specs/prw/remote_write_spec_2_0/#protocol
+//
+// The caller must write the response status code after successful processing.
+func InsertHandler(at *auth.Token, w http.ResponseWriter, req *http.Request) error {
 	extraLabels, err := parserCommon.GetExtraLabels(req)
 	if err != nil {
 		return err
 	}
+	isRemoteWriteV2, err := stream.IsRemoteWriteV2(req.Header.Get("Content-Type"))
+	if err != nil {
+		return &httpserver.ErrorWithStatusCode{
+			Err:        err,
+			StatusCode: http.StatusUnsupportedMediaType,
+		}
+	}
 	isVMRemoteWrite := req.Header.Get("Content-Encoding") == "zstd"
-	return stream.Parse(req.Body, isVMRemoteWrite, func(tss []prompb.TimeSeries) error {
-		return insertRows(at, tss, extraLabels)
+	ws, err := stream.Parse(req.Body, isVMRemoteWrite, isRemoteWriteV2, func(tss []prompb.TimeSeries, mms []prompb.MetricMetadata) error {
+		return insertRows(at, tss, mms, extraLabels)
 	})
+	if err != nil {
+		return err
+	}
+	if isRemoteWriteV2 {
+		ws.SetResponseHeaders(w.Header())
+	}
+	return nil
 }
 
-func insertRows(at *auth.Token, timeseries []prompb.TimeSeries, extraLabels []prompbmarshal.Label) error {
+func insertRows(at *auth.Token, timeseries []prompb.TimeSeries, mms []prompb.MetricMetadata, extraLabels []prompbmarshal.Label) error {
 	ctx := common.GetPushCtx()
 	defer common.PutPushCtx(ctx)
 
@@ -40,6 +60,7 @@ func insertRows(at *auth.Token, timeseries []prompb.TimeSeries, extraLabels []pr
 	tssDst := ctx.WriteRequest.Timeseries[:0]
 	labels := ctx.Labels[:0]
 	samples := ctx.Samples[:0]
+	exemplars := ctx.Exemplars[:0]
 	for i := range timeseries {
 		ts := &timeseries[i]
 		rowsTotal += len(ts.Samples)
@@ -60,14 +81,47 @@ func insertRows(at *auth.Token, timeseries []prompb.TimeSeries, extraLabels []pr
 				Timestamp: sample.Timestamp,
 			})
 		}
+		seriesLabelsLen := len(labels)
+		exemplarsLen := len(exemplars)
+		for i := range ts.Exemplars {
+			e := &ts.Exemplars[i]
+			exemplarLabelsLen := len(labels)
+			for j := range e.Labels {
+				label := &e.Labels[j]
+				labels = append(labels, prompbmarshal.Label{
+					Name:  label.Name,
+					Value: label.Value,
+				})
+			}
+			exemplars = append(exemplars, prompbmarshal.Exemplar{
+				Labels:    labels[exemplarLabelsLen:],
+				Value:     e.Value,
+				Timestamp: e.Timestamp,
+			})
+		}
 		tssDst = append(tssDst, prompbmarshal.TimeSeries{
-			Labels:  labels[labelsLen:],
-			Samples: samples[samplesLen:],
+			Labels:    labels[labelsLen:seriesLabelsLen:seriesLabelsLen],
+			Samples:   samples[samplesLen:],
+			Exemplars: exemplars[exemplarsLen:],
 		})
 	}
+	if parserCommon.IsMetadataEnabled() {
+		mmsDst := ctx.WriteRequest.Metadata[:0]
+		for i := range mms {
+			mm := &mms[i]
+			mmsDst = append(mmsDst, prompbmarshal.MetricMetadata{
+				Type:             uint32(mm.Type),
+				MetricFamilyName: mm.MetricFamilyName,
+				Help:             mm.Help,
+				Unit:             mm.Unit,
+			})
+		}
+		ctx.WriteRequest.Metadata = mmsDst
+	}
 	ctx.WriteRequest.Timeseries = tssDst
 	ctx.Labels = labels
 	ctx.Samples = samples
+	ctx.Exemplars = exemplars
 	if !remotewrite.TryPush(at, &ctx.WriteRequest) {
 		return remotewrite.ErrQueueFullHTTPRetry
 	}
@@ -10,15 +10,21 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/golang/snappy"
+
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/awsapi"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/persistentqueue"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/promremotewrite/stream"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/ratelimiter"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timerpool"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timeutil"
@@ -30,6 +36,12 @@ var (
 		"to the corresponding -remoteWrite.url . See https://docs.victoriametrics.com/vmagent/#victoriametrics-remote-write-protocol")
 	forceVMProto = flagutil.NewArrayBool("remoteWrite.forceVMProto", "Whether to force VictoriaMetrics remote write protocol for sending data "+
 		"to the corresponding -remoteWrite.url . See https://docs.victoriametrics.com/vmagent/#victoriametrics-remote-write-protocol")
+	usePromProtoV2 = flagutil.NewArrayBool("remoteWrite.usePromProtoV2", "Whether to use Prometheus remote write 2.0 protocol for sending data "+
+		"to the corresponding -remoteWrite.url if VictoriaMetrics remote write protocol isn't used. vmagent automatically falls back to Prometheus remote write 1.0 protocol "+
+		"if the remote storage doesn't support Prometheus remote write 2.0. See https://docs.victoriametrics.com/vmagent/#prometheus-remote-write-20")
+	useOTLP = flagutil.NewArrayBool("remoteWrite.otlp", "Whether to send data to the corresponding -remoteWrite.url via OpenTelemetry protocol (OTLP/HTTP with protobuf encoding). "+
+		"In this case -remoteWrite.url must point to OTLP/HTTP metrics endpoint such as http://otel-collector:4318/v1/metrics . "+
+		"See https://docs.victoriametrics.com/vmagent/#sending-data-via-opentelemetry")
 
 	rateLimit = flagutil.NewArrayInt("remoteWrite.rateLimit", 0, "Optional rate limit in bytes per second for data sent to the corresponding -remoteWrite.url. "+
 		"By default, the rate limit is disabled. It can be useful for limiting load on remote storage when big amounts of buffered data "+
@@ -89,6 +101,14 @@ type client struct {
 	// Whether to use VictoriaMetrics remote write protocol for sending the data to remoteWriteURL
 	useVMProto bool
 
+	// Whether to use Prometheus remote write 2.0 protocol for sending the data to remoteWriteURL.
+	//
+	// It is reset to false if remoteWriteURL doesn't support Prometheus remote write 2.0.
+	usePromProtoV2 atomic.Bool
+
+	// Whether to use OpenTelemetry protocol for sending the data to remoteWriteURL
+	useOTLP bool
+
 	fq *persistentqueue.FastQueue
 	hc *http.Client
 
@@ -165,10 +185,21 @@ func newHTTPClient(argIdx int, remoteWriteURL, sanitizedURL string, fq *persiste
 	if useVMProto && usePromProto {
 		logger.Fatalf("-remoteWrite.useVMProto and -remoteWrite.usePromProto cannot be set simultaneously for -remoteWrite.url=%s", sanitizedURL)
 	}
+	usePromV2 := usePromProtoV2.GetOptionalArg(argIdx)
+	if useVMProto && usePromV2 {
+		logger.Fatalf("-remoteWrite.forceVMProto and -remoteWrite.usePromProtoV2 cannot be set simultaneously for -remoteWrite.url=%s", sanitizedURL)
+	}
+	if useOTLP.GetOptionalArg(argIdx) {
+		if useVMProto || usePromProto || usePromV2 {
+			logger.Fatalf("-remoteWrite.otlp cannot be set together with -remoteWrite.forceVMProto, -remoteWrite.forcePromProto or -remoteWrite.usePromProtoV2 for -remoteWrite.url=%s", sanitizedURL)
+		}
+		c.useOTLP = true
+		return c
+	}
 	if !useVMProto && !usePromProto {
 		// Auto-detect whether the remote storage supports VictoriaMetrics remote write protocol.
 		doRequest := func(url string) (*http.Response, error) {
-			return c.doRequest(url, nil)
+			return c.doRequest(url, nil, false)
 		}
 		useVMProto = common.HandleVMProtoClientHandshake(c.remoteWriteURL, doRequest)
 		if !useVMProto {
@@ -177,6 +208,13 @@ func newHTTPClient(argIdx int, remoteWriteURL, sanitizedURL string, fq *persiste
 		}
 	}
 	c.useVMProto = useVMProto
+	if usePromV2 {
+		if useVMProto {
+			logger.Infof("the remote storage at %q supports VictoriaMetrics remote write protocol, so it is used instead of Prometheus remote write 2.0 protocol", sanitizedURL)
+		} else {
+			c.usePromProtoV2.Store(true)
+		}
+	}
 
 	return c
 }
@@ -201,6 +239,12 @@ func (c *client) init(argIdx, concurrency int, sanitizedURL string) {
 	metrics.GetOrCreateGauge(fmt.Sprintf(`vmagent_remotewrite_queues{url=%q}`, c.sanitizedURL), func() float64 {
 		return float64(*queues)
 	})
+	metrics.GetOrCreateGauge(fmt.Sprintf(`vmagent_remotewrite_prom_proto_v2_enabled{url=%q}`, c.sanitizedURL), func() float64 {
+		if c.usePromProtoV2.Load() {
+			return 1
+		}
+		return 0
+	})
 	for i := 0; i < concurrency; i++ {
 		c.wg.Add(1)
 		go func() {
@@ -344,8 +388,8 @@ func (c *client) runWorker() {
 	}
 }
 
-func (c *client) doRequest(url string, body []byte) (*http.Response, error) {
-	req, err := c.newRequest(url, body)
+func (c *client) doRequest(url string, body []byte, usePromProtoV2 bool) (*http.Response, error) {
+	req, err := c.newRequest(url, body, usePromProtoV2)
 	if err != nil {
 		return nil, err
 	}
@@ -360,7 +404,7 @@ func (c *client) doRequest(url string, body []byte) (*http.Response, error) {
 	// Make another attempt in hope request will succeed.
 	// If not, the error should be handled by the caller as usual.
 	// This should help with https://github.com/VictoriaMetrics/VictoriaMetrics/issues/4139
-	req, err = c.newRequest(url, body)
+	req, err = c.newRequest(url, body, usePromProtoV2)
 	if err != nil {
 		return nil, fmt.Errorf("second attempt: %w", err)
 	}
@@ -371,7 +415,7 @@ func (c *client) doRequest(url string, body []byte) (*http.Response, error) {
 	return resp, nil
 }
 
-func (c *client) newRequest(url string, body []byte) (*http.Request, error) {
+func (c *client) newRequest(url string, body []byte, usePromProtoV2 bool) (*http.Request, error) {
 	reqBody := bytes.NewBuffer(body)
 	req, err := http.NewRequest(http.MethodPost, url, reqBody)
 	if err != nil {
@@ -384,9 +428,15 @@ func (c *client) newRequest(url string, body []byte) (*http.Request, error) {
 	h := req.Header
 	h.Set("User-Agent", "vmagent")
 	h.Set("Content-Type", "application/x-protobuf")
-	if c.useVMProto {
+	if c.useOTLP {
+		h.Set("Content-Encoding", "gzip")
+	} else if c.useVMProto {
 		h.Set("Content-Encoding", "zstd")
 		h.Set("X-VictoriaMetrics-Remote-Write-Version", "1")
+	} else if usePromProtoV2 {
+		h.Set("Content-Type", stream.ContentTypeV2)
+		h.Set("Content-Encoding", "snappy")
+		h.Set("X-Prometheus-Remote-Write-Version", "2.0.0")
 	} else {
 		h.Set("Content-Encoding", "snappy")
 		h.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
@@ -405,14 +455,47 @@ func (c *client) newRequest(url string, body []byte) (*http.Request, error) {
 // The function returns false only if c.stopCh is closed.
 // Otherwise, it tries sending the block to remote storage indefinitely.
 func (c *client) sendBlockHTTP(block []byte) bool {
+	if c.useOTLP {
+		// The block is stored in Prometheus remote write 1.0 format, so it must be converted before sending.
+		bb := blockBufPool.Get()
+		defer blockBufPool.Put(bb)
+		if err := marshalBlockOTLP(bb, block); err != nil {
+			remoteWriteRejectedLogger.Errorf("cannot convert a block with size %d bytes to OpenTelemetry format for sending to %q (skipping the block): %s",
+				len(block), c.sanitizedURL, err)
+			c.packetsDropped.Inc()
+			return true
+		}
+		block = bb.B
+	}
 	c.rl.Register(len(block))
 	maxRetryDuration := timeutil.AddJitterToDuration(c.retryMaxTime)
 	retryDuration := timeutil.AddJitterToDuration(c.retryMinInterval)
 	retriesCount := 0
+	var blockV2 *bytesutil.ByteBuffer
+	var blockV2Err error
 
 again:
+	reqBody := block
+	usePromProtoV2 := c.usePromProtoV2.Load()
+	if usePromProtoV2 {
+		// The block is stored in Prometheus remote write 1.0 format, so it must be converted before sending.
+		if blockV2 == nil {
+			blockV2 = blockBufPool.Get()
+			defer blockBufPool.Put(blockV2)
+			blockV2Err = marshalBlockPromProtoV2(blockV2, block)
+			if blockV2Err != nil {
+				logger.Errorf("cannot convert a block with size %d bytes to Prometheus remote write 2.0 format for sending to %q; sending it in Prometheus remote write 1.0 format: %s",
+					len(block), c.sanitizedURL, blockV2Err)
+			}
+		}
+		if blockV2Err == nil {
+			reqBody = blockV2.B
+		} else {
+			usePromProtoV2 = false
+		}
+	}
 	startTime := time.Now()
-	resp, err := c.doRequest(c.remoteWriteURL, block)
+	resp, err := c.doRequest(c.remoteWriteURL, reqBody, usePromProtoV2)
 	c.requestDuration.UpdateDuration(startTime)
 	if err != nil {
 		c.errorsCount.Inc()
@@ -436,12 +519,24 @@ again:
 	statusCode := resp.StatusCode
 	if statusCode/100 == 2 {
 		_ = resp.Body.Close()
+		if usePromProtoV2 && resp.Header.Get("X-Prometheus-Remote-Write-Samples-Written") == "" {
+			// The remote storage ignores unknown fields in Prometheus remote write 2.0 requests
+			// if it supports only Prometheus remote write 1.0, so the sent data is silently lost.
+			// Re-send the block in Prometheus remote write 1.0 format.
+			c.disablePromProtoV2(fmt.Sprintf("the response with status code %d doesn't contain X-Prometheus-Remote-Write-Samples-Written header", statusCode))
+			goto again
+		}
 		c.requestsOKCount.Inc()
-		c.bytesSent.Add(len(block))
+		c.bytesSent.Add(len(reqBody))
 		c.blocksSent.Inc()
 		return true
 	}
 	metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_requests_total{url=%q, status_code="%d"}`, c.sanitizedURL, statusCode)).Inc()
+	if statusCode == http.StatusUnsupportedMediaType && usePromProtoV2 {
+		_ = resp.Body.Close()
+		c.disablePromProtoV2(fmt.Sprintf("unexpected status code %d", statusCode))
+		goto again
+	}
 	if statusCode == 409 || statusCode == 400 {
 		body, err := io.ReadAll(resp.Body)
 		_ = resp.Body.Close()
@@ -489,6 +584,55 @@ again:
 
 var remoteWriteRejectedLogger = logger.WithThrottler("remoteWriteRejected", 5*time.Second)
 
+// disablePromProtoV2 switches c to Prometheus remote write 1.0 protocol because of the given reason.
+func (c *client) disablePromProtoV2(reason string) {
+	if c.usePromProtoV2.CompareAndSwap(true, false) {
+		logger.Warnf("the remote storage at %q doesn't support Prometheus remote write 2.0 protocol: %s; switching to Prometheus remote write 1.0 protocol. "+
+			"See https://docs.victoriametrics.com/vmagent/#prometheus-remote-write-20", c.sanitizedURL, reason)
+	}
+}
+
+// marshalBlockPromProtoV2 converts snappy-encoded block in Prometheus remote write 1.0 format
+// to snappy-encoded Prometheus remote write 2.0 format and stores the result to dst.
+func marshalBlockPromProtoV2(dst *bytesutil.ByteBuffer, block []byte) error {
+	bb := blockBufPool.Get()
+	defer blockBufPool.Put(bb)
+
+	var err error
+	bb.B, err = snappy.Decode(bb.B[:cap(bb.B)], block)
+	if err != nil {
+		return fmt.Errorf("cannot decompress snappy-encoded block: %w", err)
+	}
+	wr := getPromWriteRequest()
+	defer putPromWriteRequest(wr)
+	if err := wr.UnmarshalProtobuf(bb.B); err != nil {
+		return fmt.Errorf("cannot unmarshal block: %w", err)
+	}
+
+	bbV2 := blockBufPool.Get()
+	defer blockBufPool.Put(bbV2)
+	bbV2.B = wr.MarshalProtobufV2(bbV2.B[:0])
+	dst.B = snappy.Encode(dst.B[:cap(dst.B)], bbV2.B)
+	return nil
+}
+
+var blockBufPool bytesutil.ByteBufferPool
+
+func getPromWriteRequest() *prompb.WriteRequest {
+	v := promWriteRequestPool.Get()
+	if v == nil {
+		return &prompb.WriteRequest{}
+	}
+	return v.(*prompb.WriteRequest)
+}
+
+func putPromWriteRequest(wr *prompb.WriteRequest) {
+	wr.Reset()
+	promWriteRequestPool.Put(wr)
+}
+
+var promWriteRequestPool sync.Pool
+
 // getRetryDuration returns retry duration.
 // retryAfterDuration has the highest priority.
 // If retryAfterDuration is not specified, retryDuration gets doubled.
@@ -1,10 +1,21 @@
 package remotewrite
 
 import (
+	"io"
 	"math"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/golang/snappy"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/promremotewrite/stream"
 )
 
 func TestCalculateRetryDuration(t *testing.T) {
@@ -97,3 +108,107 @@ func helper(d time.Duration) time.Duration {
 
 	return d + dv
 }
+
+func TestClientSendBlockPromProtoV2(t *testing.T) {
+	f := func(handler func(w http.ResponseWriter, isPromProtoV2 bool), promProtoV2Expected bool, protocolsExpected []string) {
+		t.Helper()
+
+		var protocols []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			isPromProtoV2, err := stream.IsRemoteWriteV2(r.Header.Get("Content-Type"))
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("cannot read request body: %s", err)
+			}
+			data, err = snappy.Decode(nil, data)
+			if err != nil {
+				t.Errorf("cannot decode request body: %s", err)
+			}
+			var wr prompb.WriteRequest
+			if isPromProtoV2 {
+				protocols = append(protocols, "v2")
+				err = wr.UnmarshalProtobufV2(data)
+			} else {
+				protocols = append(protocols, "v1")
+				err = wr.UnmarshalProtobuf(data)
+			}
+			if err != nil {
+				t.Errorf("cannot unmarshal request body: %s", err)
+			}
+			if len(wr.Timeseries) != 1 || len(wr.Timeseries[0].Samples) != 1 || len(wr.Timeseries[0].Labels) != 1 || wr.Timeseries[0].Labels[0].Value != "foo" {
+				t.Errorf("unexpected time series received: %+v", wr.Timeseries)
+			}
+			handler(w, isPromProtoV2)
+		}))
+		defer ts.Close()
+
+		authCfg, err := (&promauth.Options{}).NewConfig()
+		if err != nil {
+			t.Fatalf("cannot create auth config: %s", err)
+		}
+		ms := metrics.NewSet()
+		c := &client{
+			sanitizedURL:     ts.URL,
+			remoteWriteURL:   ts.URL,
+			authCfg:          authCfg,
+			hc:               &http.Client{},
+			retryMinInterval: time.Millisecond,
+			retryMaxTime:     time.Millisecond,
+			stopCh:           make(chan struct{}),
+			bytesSent:        ms.NewCounter("bytes_sent"),
+			blocksSent:       ms.NewCounter("blocks_sent"),
+			requestDuration:  ms.NewHistogram("request_duration"),
+			requestsOKCount:  ms.NewCounter("requests_ok"),
+			errorsCount:      ms.NewCounter("errors"),
+			packetsDropped:   ms.NewCounter("packets_dropped"),
+			retriesCount:     ms.NewCounter("retries"),
+		}
+		c.usePromProtoV2.Store(true)
+
+		wr := &prompbmarshal.WriteRequest{
+			Timeseries: []prompbmarshal.TimeSeries{{
+				Labels:  []prompbmarshal.Label{{Name: "__name__", Value: "foo"}},
+				Samples: []prompbmarshal.Sample{{Value: 1, Timestamp: 1000}},
+			}},
+		}
+		block := snappy.Encode(nil, wr.MarshalProtobuf(nil))
+		for i := 0; i < 2; i++ {
+			if !c.sendBlockHTTP(block) {
+				t.Fatalf("cannot send the block")
+			}
+		}
+
+		if !reflect.DeepEqual(protocols, protocolsExpected) {
+			t.Fatalf("unexpected protocols used\ngot\n%q\nwant\n%q", protocols, protocolsExpected)
+		}
+		if promProtoV2 := c.usePromProtoV2.Load(); promProtoV2 != promProtoV2Expected {
+			t.Fatalf("unexpected usePromProtoV2; got %v; want %v", promProtoV2, promProtoV2Expected)
+		}
+	}
+
+	// remote storage supports Prometheus remote write 2.0
+	f(func(w http.ResponseWriter, isPromProtoV2 bool) {
+		if isPromProtoV2 {
+			ws := stream.WriteStats{Samples: 1}
+			ws.SetResponseHeaders(w.Header())
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}, true, []string{"v2", "v2"})
+
+	// remote storage silently ignores Prometheus remote write 2.0 requests
+	f(func(w http.ResponseWriter, _ bool) {
+		w.WriteHeader(http.StatusNoContent)
+	}, false, []string{"v2", "v1", "v1"})
+
+	// remote storage rejects Prometheus remote write 2.0 requests
+	f(func(w http.ResponseWriter, isPromProtoV2 bool) {
+		if isPromProtoV2 {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}, false, []string{"v2", "v1", "v1"})
+}
@@ -0,0 +1,485 @@
+package remotewrite
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/opentelemetry/pb"
+)
+
+// marshalBlockOTLP converts snappy-encoded block in Prometheus remote write 1.0 format
+// to gzip-compressed OpenTelemetry ExportMetricsServiceRequest and stores the result to dst.
+func marshalBlockOTLP(dst *bytesutil.ByteBuffer, block []byte) error {
+	bb := blockBufPool.Get()
+	defer blockBufPool.Put(bb)
+
+	var err error
+	bb.B, err = snappy.Decode(bb.B[:cap(bb.B)], block)
+	if err != nil {
+		return fmt.Errorf("cannot decompress snappy-encoded block: %w", err)
+	}
+	wr := getPromWriteRequest()
+	defer putPromWriteRequest(wr)
+	if err := wr.UnmarshalProtobuf(bb.B); err != nil {
+		return fmt.Errorf("cannot unmarshal block: %w", err)
+	}
+
+	req := convertToOTLP(wr.Timeseries, wr.Metadata)
+	bbOTLP := blockBufPool.Get()
+	defer blockBufPool.Put(bbOTLP)
+	bbOTLP.B = req.MarshalProtobuf(bbOTLP.B[:0])
+
+	dst.Reset()
+	zw := getGzipWriter(dst)
+	defer putGzipWriter(zw)
+	if _, err := zw.Write(bbOTLP.B); err != nil {
+		return fmt.Errorf("cannot compress OTLP request: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("cannot finish compression of OTLP request: %w", err)
+	}
+	return nil
+}
+
+func getGzipWriter(bb *bytesutil.ByteBuffer) *gzip.Writer {
+	v := gzipWriterPool.Get()
+	if v == nil {
+		zw, err := gzip.NewWriterLevel(bb, gzip.BestSpeed)
+		if err != nil {
+			panic(fmt.Errorf("BUG: cannot create gzip writer: %w", err))
+		}
+		return zw
+	}
+	zw := v.(*gzip.Writer)
+	zw.Reset(bb)
+	return zw
+}
+
+func putGzipWriter(zw *gzip.Writer) {
+	zw.Reset(nil)
+	gzipWriterPool.Put(zw)
+}
+
+var gzipWriterPool sync.Pool
+
+// convertToOTLP converts tss and mms to OpenTelemetry ExportMetricsServiceRequest.
+//
+// Series with `le` label and `_bucket` suffix are converted together with the corresponding `_sum` and `_count` series
+// to OpenTelemetry histograms if tss contain all the buckets including `le="+Inf"`, `_sum` and `_count` samples for the given timestamp.
+// Otherwise these series are converted to gauges, since the missing samples may be sent in another block. Counters are converted to cumulative monotonic sums, while the rest of series are converted to gauges.
+// Metric types are detected via mms if they contain metadata for the given metric family.
+// Otherwise series with `_total` suffix are treated as counters.
+//
+// The returned request refers to tss and mms, so they mustn't be changed while the request is in use.
+func convertToOTLP(tss []prompb.TimeSeries, mms []prompb.MetricMetadata) *pb.ExportMetricsServiceRequest {
+	var oc otlpConverter
+	oc.init(tss, mms)
+	for i := range tss {
+		oc.addSeries(&tss[i])
+	}
+	return &pb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*pb.ResourceMetrics{{
+			ScopeMetrics: []*pb.ScopeMetrics{{
+				Metrics: oc.finalize(),
+			}},
+		}},
+	}
+}
+
+type otlpConverter struct {
+	// metadata maps metric family names to metadata
+	metadata map[string]*prompb.MetricMetadata
+
+	// histogramFamilies contains metric family names for series with `le` label and `_bucket` suffix
+	histogramFamilies map[string]struct{}
+
+	// metrics contains the converted metrics in the order of their appearance
+	metrics       []*pb.Metric
+	metricsByName map[string]*pb.Metric
+
+	// histogramPoints contains histogram data points in the order of their appearance
+	histogramPoints      []*otlpHistogramPoint
+	histogramPointsByKey map[string]*otlpHistogramPoint
+}
+
+type otlpHistogramPoint struct {
+	family     string
+	attributes []*pb.KeyValue
+	timestamp  int64
+
+	buckets  []otlpBucket
+	sum      *float64
+	count    float64
+	hasCount bool
+	isStale  bool
+
+	// samples contains the original samples for the histogram point.
+	// They are converted to gauges if the histogram point is incomplete.
+	samples []otlpHistogramSample
+}
+
+type otlpHistogramSample struct {
+	metricName string
+	attributes []*pb.KeyValue
+	value      float64
+	exemplars  []*pb.Exemplar
+}
+
+type otlpBucket struct {
+	upperBound float64
+	cumulative float64
+}
+
+func (oc *otlpConverter) init(tss []prompb.TimeSeries, mms []prompb.MetricMetadata) {
+	oc.metadata = make(map[string]*prompb.MetricMetadata, len(mms))
+	for i := range mms {
+		mm := &mms[i]
+		oc.metadata[mm.MetricFamilyName] = mm
+	}
+	oc.histogramFamilies = make(map[string]struct{})
+	for i := range tss {
+		ts := &tss[i]
+		family, ok := strings.CutSuffix(getMetricName(ts.Labels), "_bucket")
+		if !ok || getLabelValue(ts.Labels, "le") == "" {
+			continue
+		}
+		if mm := oc.metadata[family]; mm != nil && mm.Type != prompb.MetricTypeHistogram && mm.Type != prompb.MetricTypeGaugeHistogram && mm.Type != prompb.MetricTypeUnknown {
+			continue
+		}
+		oc.histogramFamilies[family] = struct{}{}
+	}
+	oc.metricsByName = make(map[string]*pb.Metric)
+	oc.histogramPointsByKey = make(map[string]*otlpHistogramPoint)
+}
+
+func (oc *otlpConverter) addSeries(ts *prompb.TimeSeries) {
+	metricName := getMetricName(ts.Labels)
+	if metricName == "" {
+		return
+	}
+	if family, suffix, ok := oc.getHistogramFamily(metricName); ok {
+		oc.addHistogramSeries(ts, family, suffix)
+		return
+	}
+
+	m := oc.getMetric(metricName, func(m *pb.Metric) {
+		if oc.isCounter(metricName) {
+			m.Sum = &pb.Sum{
+				AggregationTemporality: pb.AggregationTemporalityCumulative,
+				IsMonotonic:            true,
+			}
+		} else {
+			m.Gauge = &pb.Gauge{}
+		}
+	})
+	attributes := appendOTLPAttributes(nil, ts.Labels, "")
+	for i, s := range ts.Samples {
+		var exemplars []*pb.Exemplar
+		if i == len(ts.Samples)-1 {
+			exemplars = appendOTLPExemplars(nil, ts.Exemplars)
+		}
+		p := newOTLPNumberDataPoint(attributes, s.Timestamp, s.Value, exemplars)
+		if m.Sum != nil {
+			m.Sum.DataPoints = append(m.Sum.DataPoints, p)
+		} else {
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, p)
+		}
+	}
+}
+
+func newOTLPNumberDataPoint(attributes []*pb.KeyValue, timestamp int64, value float64, exemplars []*pb.Exemplar) *pb.NumberDataPoint {
+	p := &pb.NumberDataPoint{
+		Attributes:   attributes,
+		TimeUnixNano: uint64(timestamp) * 1e6,
+		Exemplars:    exemplars,
+	}
+	if decimal.IsStaleNaN(value) {
+		p.Flags = otlpFlagNoRecordedValue
+	} else {
+		p.DoubleValue = &value
+	}
+	return p
+}
+
+func (oc *otlpConverter) addHistogramSeries(ts *prompb.TimeSeries, family, suffix string) {
+	var upperBound float64
+	skipLabel := ""
+	if suffix == "_bucket" {
+		le := getLabelValue(ts.Labels, "le")
+		v, err := strconv.ParseFloat(le, 64)
+		if err != nil {
+			// Skip buckets with invalid `le` label values.
+			return
+		}
+		upperBound = v
+		skipLabel = "le"
+	}
+
+	var attributes []*pb.KeyValue
+	seriesAttributes := appendOTLPAttributes(nil, ts.Labels, "")
+	keyPrefix := family + "\xff" + getLabelsKey(ts.Labels, skipLabel)
+	for i, s := range ts.Samples {
+		key := keyPrefix + "\xff" + strconv.FormatInt(s.Timestamp, 10)
+		hp := oc.histogramPointsByKey[key]
+		if hp == nil {
+			if attributes == nil {
+				attributes = appendOTLPAttributes(nil, ts.Labels, skipLabel)
+			}
+			hp = &otlpHistogramPoint{
+				family:     family,
+				attributes: attributes,
+				timestamp:  s.Timestamp,
+			}
+			oc.histogramPointsByKey[key] = hp
+			oc.histogramPoints = append(oc.histogramPoints, hp)
+		}
+		if decimal.IsStaleNaN(s.Value) {
+			hp.isStale = true
+		}
+		switch suffix {
+		case "_bucket":
+			hp.buckets = append(hp.buckets, otlpBucket{
+				upperBound: upperBound,
+				cumulative: s.Value,
+			})
+		case "_sum":
+			v := s.Value
+			hp.sum = &v
+		case "_count":
+			hp.count = s.Value
+			hp.hasCount = true
+		}
+		sample := otlpHistogramSample{
+			metricName: family + suffix,
+			attributes: seriesAttributes,
+			value:      s.Value,
+		}
+		if i == len(ts.Samples)-1 {
+			sample.exemplars = appendOTLPExemplars(nil, ts.Exemplars)
+		}
+		hp.samples = append(hp.samples, sample)
+	}
+}
+
+func (oc *otlpConverter) finalize() []*pb.Metric {
+	for _, hp := range oc.histogramPoints {
+		if !hp.isComplete() {
+			// The remaining samples for the histogram point may be sent in another block,
+			// so the histogram cannot be built reliably. Send the original samples as gauges.
+			for _, s := range hp.samples {
+				m := oc.getMetric(s.metricName, func(m *pb.Metric) {
+					m.Gauge = &pb.Gauge{}
+				})
+				m.Gauge.DataPoints = append(m.Gauge.DataPoints, newOTLPNumberDataPoint(s.attributes, hp.timestamp, s.value, s.exemplars))
+			}
+			continue
+		}
+		m := oc.getMetric(hp.family, func(m *pb.Metric) {
+			aggregationTemporality := pb.AggregationTemporalityCumulative
+			if mm := oc.metadata[hp.family]; mm != nil && mm.Type == prompb.MetricTypeGaugeHistogram {
+				aggregationTemporality = pb.AggregationTemporalityUnspecified
+			}
+			m.Histogram = &pb.Histogram{
+				AggregationTemporality: aggregationTemporality,
+			}
+		})
+		m.Histogram.DataPoints = append(m.Histogram.DataPoints, hp.newDataPoint())
+	}
+	return oc.metrics
+}
+
+// isComplete returns true if hp contains `_sum`, `_count` and `le="+Inf"` bucket samples.
+func (hp *otlpHistogramPoint) isComplete() bool {
+	if !hp.hasCount || hp.sum == nil {
+		return false
+	}
+	for _, b := range hp.buckets {
+		if math.IsInf(b.upperBound, 1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (hp *otlpHistogramPoint) newDataPoint() *pb.HistogramDataPoint {
+	p := &pb.HistogramDataPoint{
+		Attributes:   hp.attributes,
+		TimeUnixNano: uint64(hp.timestamp) * 1e6,
+	}
+	for _, s := range hp.samples {
+		p.Exemplars = append(p.Exemplars, s.exemplars...)
+	}
+	if hp.isStale {
+		p.Flags = otlpFlagNoRecordedValue
+		return p
+	}
+
+	buckets := hp.buckets
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].upperBound < buckets[j].upperBound
+	})
+
+	// OpenTelemetry histograms contain non-cumulative bucket counts.
+	prevCumulative := float64(0)
+	for i, b := range buckets {
+		if i < len(buckets)-1 {
+			p.ExplicitBounds = append(p.ExplicitBounds, b.upperBound)
+		}
+		p.BucketCounts = append(p.BucketCounts, otlpCount(b.cumulative-prevCumulative))
+		if b.cumulative > prevCumulative {
+			prevCumulative = b.cumulative
+		}
+	}
+	p.Count = otlpCount(hp.count)
+	p.Sum = hp.sum
+	return p
+}
+
+func otlpCount(v float64) uint64 {
+	if !(v > 0) {
+		return 0
+	}
+	return uint64(math.Round(v))
+}
+
+// otlpFlagNoRecordedValue is the OpenTelemetry data point flag, which is used for Prometheus staleness markers.
+//
+// See https://opentelemetry.io/docs/specs/otel/metrics/data-model/#dropped-data-points
+const otlpFlagNoRecordedValue = 1
+
+func (oc *otlpConverter) getMetric(name string, initMetric func(m *pb.Metric)) *pb.Metric {
+	if m := oc.metricsByName[name]; m != nil {
+		return m
+	}
+	m := &pb.Metric{
+		Name: name,
+	}
+	if mm := oc.getMetadata(name); mm != nil {
+		m.Description = mm.Help
+		m.Unit = mm.Unit
+	}
+	initMetric(m)
+	oc.metricsByName[name] = m
+	oc.metrics = append(oc.metrics, m)
+	return m
+}
+
+func (oc *otlpConverter) getMetadata(metricName string) *prompb.MetricMetadata {
+	if mm := oc.metadata[metricName]; mm != nil {
+		return mm
+	}
+	// OpenMetrics counters have metric family names without `_total` suffix.
+	if family, ok := strings.CutSuffix(metricName, "_total"); ok {
+		if mm := oc.metadata[family]; mm != nil && mm.Type == prompb.MetricTypeCounter {
+			return mm
+		}
+	}
+	return nil
+}
+
+func (oc *otlpConverter) isCounter(metricName string) bool {
+	if mm := oc.getMetadata(metricName); mm != nil && mm.Type != prompb.MetricTypeUnknown {
+		return mm.Type == prompb.MetricTypeCounter
+	}
+	return strings.HasSuffix(metricName, "_total")
+}
+
+func (oc *otlpConverter) getHistogramFamily(metricName string) (string, string, bool) {
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		family, ok := strings.CutSuffix(metricName, suffix)
+		if !ok {
+			continue
+		}
+		if _, ok := oc.histogramFamilies[family]; ok {
+			return family, suffix, true
+		}
+	}
+	return "", "", false
+}
+
+func appendOTLPAttributes(dst []*pb.KeyValue, labels []prompb.Label, skipLabel string) []*pb.KeyValue {
+	for _, label := range labels {
+		if label.Name == "__name__" || label.Name == skipLabel {
+			continue
+		}
+		value := label.Value
+		dst = append(dst, &pb.KeyValue{
+			Key: label.Name,
+			Value: &pb.AnyValue{
+				StringValue: &value,
+			},
+		})
+	}
+	return dst
+}
+
+func appendOTLPExemplars(dst []*pb.Exemplar, exemplars []prompb.Exemplar) []*pb.Exemplar {
+	for _, e := range exemplars {
+		value := e.Value
+		oe := &pb.Exemplar{
+			TimeUnixNano: uint64(e.Timestamp) * 1e6,
+			DoubleValue:  &value,
+		}
+		for _, label := range e.Labels {
+			switch label.Name {
+			case "trace_id":
+				if b, err := hex.DecodeString(label.Value); err == nil && len(b) == 16 {
+					oe.TraceID = b
+					continue
+				}
+			case "span_id":
+				if b, err := hex.DecodeString(label.Value); err == nil && len(b) == 8 {
+					oe.SpanID = b
+					continue
+				}
+			}
+			labelValue := label.Value
+			oe.FilteredAttributes = append(oe.FilteredAttributes, &pb.KeyValue{
+				Key: label.Name,
+				Value: &pb.AnyValue{
+					StringValue: &labelValue,
+				},
+			})
+		}
+		dst = append(dst, oe)
+	}
+	return dst
+}
+
+func getMetricName(labels []prompb.Label) string {
+	return getLabelValue(labels, "__name__")
+}
+
+func getLabelValue(labels []prompb.Label, name string) string {
+	for _, label := range labels {
+		if label.Name == name {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+func getLabelsKey(labels []prompb.Label, skipLabel string) string {
+	var sb strings.Builder
+	for _, label := range labels {
+		if label.Name == "__name__" || label.Name == skipLabel {
+			continue
+		}
+		sb.WriteString(label.Name)
+		sb.WriteByte('=')
+		sb.WriteString(label.Value)
+		sb.WriteByte('\xfe')
+	}
+	return sb.String()
+}
@@ -0,0 +1,257 @@
+package remotewrite
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/opentelemetry/stream"
+)
+
+func TestMarshalBlockOTLP(t *testing.T) {
+	f := func(s string, resultExpected []string) {
+		t.Helper()
+
+		wr := &prompbmarshal.WriteRequest{
+			Timeseries: prompbmarshal.MustParsePromMetrics(s, 0),
+		}
+		block := snappy.Encode(nil, wr.MarshalProtobuf(nil))
+
+		var bb bytesutil.ByteBuffer
+		if err := marshalBlockOTLP(&bb, block); err != nil {
+			t.Fatalf("cannot marshal block: %s", err)
+		}
+
+		// Verify that the marshaled block is properly parsed by OpenTelemetry parser.
+		var result []string
+		err := stream.ParseStream(bytes.NewReader(bb.B), true, nil, func(tss []prompbmarshal.TimeSeries) error {
+			for _, ts := range tss {
+				for _, sample := range ts.Samples {
+					v := fmt.Sprintf("%g", sample.Value)
+					if decimal.IsStaleNaN(sample.Value) {
+						v = "stale"
+					}
+					result = append(result, fmt.Sprintf("%s %s %d", prompbmarshal.LabelsToString(ts.Labels), v, sample.Timestamp))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("cannot parse OTLP request: %s", err)
+		}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", strings.Join(result, "\n"), strings.Join(resultExpected, "\n"))
+		}
+	}
+
+	// gauges and counters
+	f(`
+foo{job="a"} 1.5 1
+requests_total{job="a",path="/"} 123 1
+`, []string{
+		`{__name__="foo",job="a"} 1.5 1000`,
+		`{__name__="requests_total",job="a",path="/"} 123 1000`,
+	})
+
+	// histogram
+	f(`
+duration_seconds_bucket{job="a",le="0.1"} 2 1
+duration_seconds_bucket{job="a",le="1"} 5 1
+duration_seconds_bucket{job="a",le="+Inf"} 6 1
+duration_seconds_sum{job="a"} 3.5 1
+duration_seconds_count{job="a"} 6 1
+`, []string{
+		`{__name__="duration_seconds_count",job="a"} 6 1000`,
+		`{__name__="duration_seconds_sum",job="a"} 3.5 1000`,
+		`{__name__="duration_seconds_bucket",job="a",le="0.1"} 2 1000`,
+		`{__name__="duration_seconds_bucket",job="a",le="1"} 5 1000`,
+		`{__name__="duration_seconds_bucket",job="a",le="+Inf"} 6 1000`,
+	})
+
+	// incomplete histograms are exported as gauges, since the missing series may be sent in another block
+	f(`
+duration_seconds_bucket{le="1"} 1 1
+duration_seconds_bucket{le="0.5"} 1 1
+duration_seconds_sum 0.3 1
+`, []string{
+		`{__name__="duration_seconds_bucket",le="1"} 1 1000`,
+		`{__name__="duration_seconds_bucket",le="0.5"} 1 1000`,
+		`{__name__="duration_seconds_sum"} 0.3 1000`,
+	})
+	f(`
+duration_seconds_sum{job="a"} 3.5 1
+duration_seconds_count{job="a"} 6 1
+`, []string{
+		`{__name__="duration_seconds_sum",job="a"} 3.5 1000`,
+		`{__name__="duration_seconds_count",job="a"} 6 1000`,
+	})
+
+	// complete and incomplete histogram points
+	f(`
+duration_seconds_bucket{job="a",le="+Inf"} 6 1
+duration_seconds_sum{job="a"} 3.5 1
+duration_seconds_count{job="a"} 6 1
+duration_seconds_bucket{job="b",le="+Inf"} 2 1
+`, []string{
+		`{__name__="duration_seconds_count",job="a"} 6 1000`,
+		`{__name__="duration_seconds_sum",job="a"} 3.5 1000`,
+		`{__name__="duration_seconds_bucket",job="a",le="+Inf"} 6 1000`,
+		`{__name__="duration_seconds_bucket",job="b",le="+Inf"} 2 1000`,
+	})
+
+	// _sum and _count series without buckets are exported as gauges
+	f(`
+foo_sum 10 1
+foo_count 2 1
+`, []string{
+		`{__name__="foo_sum"} 10 1000`,
+		`{__name__="foo_count"} 2 1000`,
+	})
+}
+
+func TestConvertToOTLPMetricTypes(t *testing.T) {
+	f := func(tss []prompb.TimeSeries, mms []prompb.MetricMetadata, resultExpected []string) {
+		t.Helper()
+
+		req := convertToOTLP(tss, mms)
+		var result []string
+		for _, m := range req.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+			var typ string
+			switch {
+			case m.Gauge != nil:
+				typ = fmt.Sprintf("gauge points=%d", len(m.Gauge.DataPoints))
+			case m.Sum != nil:
+				typ = fmt.Sprintf("sum monotonic=%v temporality=%d points=%d", m.Sum.IsMonotonic, m.Sum.AggregationTemporality, len(m.Sum.DataPoints))
+			case m.Histogram != nil:
+				typ = fmt.Sprintf("histogram temporality=%d points=%d", m.Histogram.AggregationTemporality, len(m.Histogram.DataPoints))
+			}
+			result = append(result, fmt.Sprintf("%s %s description=%q unit=%q", m.Name, typ, m.Description, m.Unit))
+		}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", strings.Join(result, "\n"), strings.Join(resultExpected, "\n"))
+		}
+	}
+
+	newSeries := func(name string, timestamps ...int64) prompb.TimeSeries {
+		ts := prompb.TimeSeries{
+			Labels: []prompb.Label{{Name: "__name__", Value: name}},
+		}
+		for _, t := range timestamps {
+			ts.Samples = append(ts.Samples, prompb.Sample{Value: 1, Timestamp: t})
+		}
+		return ts
+	}
+	newBucket := func(name, le string) prompb.TimeSeries {
+		ts := newSeries(name, 1000)
+		ts.Labels = append(ts.Labels, prompb.Label{Name: "le", Value: le})
+		return ts
+	}
+
+	// types detected by metric names
+	f([]prompb.TimeSeries{
+		newSeries("foo", 1000, 2000),
+		newSeries("bar_total", 1000),
+		newBucket("baz_bucket", "1"),
+		newBucket("baz_bucket", "+Inf"),
+		newSeries("baz_sum", 1000),
+		newSeries("baz_count", 1000),
+	}, nil, []string{
+		`foo gauge points=2 description="" unit=""`,
+		`bar_total sum monotonic=true temporality=2 points=1 description="" unit=""`,
+		`baz histogram temporality=2 points=1 description="" unit=""`,
+	})
+
+	// types detected by metadata
+	f([]prompb.TimeSeries{
+		newSeries("foo", 1000),
+		newSeries("bar_total", 1000),
+		newSeries("requests_total", 1000),
+		newBucket("baz_bucket", "+Inf"),
+		newSeries("baz_sum", 1000),
+		newSeries("baz_count", 1000),
+		newBucket("qux_bucket", "1"),
+	}, []prompb.MetricMetadata{
+		{Type: prompb.MetricTypeCounter, MetricFamilyName: "foo", Help: "foo help"},
+		{Type: prompb.MetricTypeGauge, MetricFamilyName: "bar_total"},
+		{Type: prompb.MetricTypeCounter, MetricFamilyName: "requests", Help: "requests help", Unit: "requests"},
+		{Type: prompb.MetricTypeGaugeHistogram, MetricFamilyName: "baz"},
+		{Type: prompb.MetricTypeGauge, MetricFamilyName: "qux"},
+	}, []string{
+		`foo sum monotonic=true temporality=2 points=1 description="foo help" unit=""`,
+		`bar_total gauge points=1 description="" unit=""`,
+		`requests_total sum monotonic=true temporality=2 points=1 description="requests help" unit="requests"`,
+		`qux_bucket gauge points=1 description="" unit=""`,
+		`baz histogram temporality=0 points=1 description="" unit=""`,
+	})
+}
+
+func TestConvertToOTLPStaleMarkers(t *testing.T) {
+	tss := []prompb.TimeSeries{
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "foo"}},
+			Samples: []prompb.Sample{{Value: decimal.StaleNaN, Timestamp: 1000}},
+		},
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "bar_bucket"}, {Name: "le", Value: "+Inf"}},
+			Samples: []prompb.Sample{{Value: decimal.StaleNaN, Timestamp: 1000}},
+		},
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "bar_sum"}},
+			Samples: []prompb.Sample{{Value: decimal.StaleNaN, Timestamp: 1000}},
+		},
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "bar_count"}},
+			Samples: []prompb.Sample{{Value: decimal.StaleNaN, Timestamp: 1000}},
+		},
+	}
+	req := convertToOTLP(tss, nil)
+	ms := req.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(ms) != 2 {
+		t.Fatalf("unexpected number of metrics; got %d; want 2", len(ms))
+	}
+	p := ms[0].Gauge.DataPoints[0]
+	if p.Flags != otlpFlagNoRecordedValue || p.DoubleValue != nil {
+		t.Fatalf("unexpected data point for stale marker: flags=%d, value=%v", p.Flags, p.DoubleValue)
+	}
+	hp := ms[1].Histogram.DataPoints[0]
+	if hp.Flags != otlpFlagNoRecordedValue || len(hp.BucketCounts) != 0 {
+		t.Fatalf("unexpected histogram data point for stale marker: flags=%d, buckets=%v", hp.Flags, hp.BucketCounts)
+	}
+}
+
+func TestConvertToOTLPExemplars(t *testing.T) {
+	tss := []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "foo"}},
+		Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+		Exemplars: []prompb.Exemplar{{
+			Labels: []prompb.Label{
+				{Name: "trace_id", Value: "0102030405060708090a0b0c0d0e0f10"},
+				{Name: "span_id", Value: "0102030405060708"},
+				{Name: "user", Value: "x"},
+			},
+			Value:     math.Pi,
+			Timestamp: 900,
+		}},
+	}}
+	req := convertToOTLP(tss, nil)
+	es := req.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Gauge.DataPoints[0].Exemplars
+	if len(es) != 1 {
+		t.Fatalf("unexpected number of exemplars; got %d; want 1", len(es))
+	}
+	e := es[0]
+	if len(e.TraceID) != 16 || len(e.SpanID) != 8 || *e.DoubleValue != math.Pi || e.TimeUnixNano != 900*1e6 {
+		t.Fatalf("unexpected exemplar: %+v", e)
+	}
+	if len(e.FilteredAttributes) != 1 || e.FilteredAttributes[0].Key != "user" || *e.FilteredAttributes[0].Value.StringValue != "x" {
+		t.Fatalf("unexpected exemplar attributes: %+v", e.FilteredAttributes)
+	}
+}
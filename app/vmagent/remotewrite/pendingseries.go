@@ -66,6 +66,13 @@ func (ps *pendingSeries) TryPush(tss []prompbmarshal.TimeSeries) bool {
 	return ok
 }
 
+func (ps *pendingSeries) TryPushMetadata(mms []prompbmarshal.MetricMetadata) bool {
+	ps.mu.Lock()
+	ok := ps.wr.tryPushMetadata(mms)
+	ps.mu.Unlock()
+	return ok
+}
+
 func (ps *pendingSeries) periodicFlusher() {
 	flushSeconds := int64(flushInterval.Seconds())
 	if flushSeconds <= 0 {
@@ -109,11 +116,13 @@ type writeRequest struct {
 
 	wr prompbmarshal.WriteRequest
 
-	tss     []prompbmarshal.TimeSeries
-	labels  []prompbmarshal.Label
-	samples []prompbmarshal.Sample
+	tss       []prompbmarshal.TimeSeries
+	labels    []prompbmarshal.Label
+	samples   []prompbmarshal.Sample
+	exemplars []prompbmarshal.Exemplar
+	metadata  []prompbmarshal.MetricMetadata
 
-	// buf holds labels data
+	// buf holds labels and metadata data
 	buf []byte
 }
 
@@ -121,6 +130,7 @@ func (wr *writeRequest) reset() {
 	// Do not reset lastFlushTime, fq, isVMRemoteWrite, significantFigures and roundDigits, since they are re-used.
 
 	wr.wr.Timeseries = nil
+	wr.wr.Metadata = nil
 
 	clear(wr.tss)
 	wr.tss = wr.tss[:0]
@@ -129,6 +139,13 @@ func (wr *writeRequest) reset() {
 	wr.labels = wr.labels[:0]
 
 	wr.samples = wr.samples[:0]
+
+	clear(wr.exemplars)
+	wr.exemplars = wr.exemplars[:0]
+
+	clear(wr.metadata)
+	wr.metadata = wr.metadata[:0]
+
 	wr.buf = wr.buf[:0]
 }
 
@@ -137,6 +154,7 @@ func (wr *writeRequest) reset() {
 // This is needed in order to properly save in-memory data to persistent queue on graceful shutdown.
 func (wr *writeRequest) mustFlushOnStop() {
 	wr.wr.Timeseries = wr.tss
+	wr.wr.Metadata = wr.metadata
 	if !tryPushWriteRequest(&wr.wr, wr.mustWriteBlock, wr.isVMRemoteWrite) {
 		logger.Panicf("BUG: final flush must always return true")
 	}
@@ -150,6 +168,7 @@ func (wr *writeRequest) mustWriteBlock(block []byte) bool {
 
 func (wr *writeRequest) tryFlush() bool {
 	wr.wr.Timeseries = wr.tss
+	wr.wr.Metadata = wr.metadata
 	wr.lastFlushTime.Store(fasttime.UnixTimestamp())
 	if !tryPushWriteRequest(&wr.wr, wr.fq.TryWriteBlock, wr.isVMRemoteWrite) {
 		return false
@@ -196,36 +215,90 @@ func (wr *writeRequest) tryPush(src []prompbmarshal.TimeSeries) bool {
 	return true
 }
 
+func (wr *writeRequest) tryPushMetadata(src []prompbmarshal.MetricMetadata) bool {
+	maxEntriesPerBlock := *maxRowsPerBlock
+	for i := range src {
+		if len(wr.samples)+len(wr.metadata) >= maxEntriesPerBlock {
+			if !wr.tryFlush() {
+				return false
+			}
+		}
+		wr.copyMetadata(&src[i])
+	}
+	return true
+}
+
+func (wr *writeRequest) copyMetadata(src *prompbmarshal.MetricMetadata) {
+	buf := wr.buf
+	bufLen := len(buf)
+	buf = append(buf, src.MetricFamilyName...)
+	buf = append(buf, src.Help...)
+	buf = append(buf, src.Unit...)
+	s := bytesutil.ToUnsafeString(buf[bufLen:])
+	nameLen := len(src.MetricFamilyName)
+	helpLen := len(src.Help)
+	wr.metadata = append(wr.metadata, prompbmarshal.MetricMetadata{
+		Type:             src.Type,
+		MetricFamilyName: s[:nameLen],
+		Help:             s[nameLen : nameLen+helpLen],
+		Unit:             s[nameLen+helpLen:],
+	})
+	wr.buf = buf
+}
+
 func (wr *writeRequest) copyTimeSeries(dst, src *prompbmarshal.TimeSeries) {
 	labelsDst := wr.labels
 	labelsLen := len(wr.labels)
 	samplesDst := wr.samples
 	buf := wr.buf
-	for i := range src.Labels {
-		labelsDst = append(labelsDst, prompbmarshal.Label{})
-		dstLabel := &labelsDst[len(labelsDst)-1]
-		srcLabel := &src.Labels[i]
-
-		buf = append(buf, srcLabel.Name...)
-		dstLabel.Name = bytesutil.ToUnsafeString(buf[len(buf)-len(srcLabel.Name):])
-		buf = append(buf, srcLabel.Value...)
-		dstLabel.Value = bytesutil.ToUnsafeString(buf[len(buf)-len(srcLabel.Value):])
-	}
+	labelsDst, buf = copyLabels(labelsDst, buf, src.Labels)
 	dst.Labels = labelsDst[labelsLen:]
 
 	samplesDst = append(samplesDst, src.Samples...)
 	dst.Samples = samplesDst[len(samplesDst)-len(src.Samples):]
 
+	if len(src.Exemplars) > 0 {
+		exemplarsDst := wr.exemplars
+		exemplarsLen := len(exemplarsDst)
+		for i := range src.Exemplars {
+			srcExemplar := &src.Exemplars[i]
+			exemplarLabelsLen := len(labelsDst)
+			labelsDst, buf = copyLabels(labelsDst, buf, srcExemplar.Labels)
+			exemplarsDst = append(exemplarsDst, prompbmarshal.Exemplar{
+				Labels:    labelsDst[exemplarLabelsLen:],
+				Value:     srcExemplar.Value,
+				Timestamp: srcExemplar.Timestamp,
+			})
+		}
+		dst.Exemplars = exemplarsDst[exemplarsLen:]
+		wr.exemplars = exemplarsDst
+	}
+
 	wr.samples = samplesDst
 	wr.labels = labelsDst
 	wr.buf = buf
 }
 
+// copyLabels appends copies of src labels to dst, while holding label strings in buf.
+func copyLabels(dst []prompbmarshal.Label, buf []byte, src []prompbmarshal.Label) ([]prompbmarshal.Label, []byte) {
+	for i := range src {
+		dst = append(dst, prompbmarshal.Label{})
+		dstLabel := &dst[len(dst)-1]
+		srcLabel := &src[i]
+
+		buf = append(buf, srcLabel.Name...)
+		dstLabel.Name = bytesutil.ToUnsafeString(buf[len(buf)-len(srcLabel.Name):])
+		buf = append(buf, srcLabel.Value...)
+		dstLabel.Value = bytesutil.ToUnsafeString(buf[len(buf)-len(srcLabel.Value):])
+	}
+	return dst, buf
+}
+
 // marshalConcurrency limits the maximum number of concurrent workers, which marshal and compress WriteRequest.
 var marshalConcurrencyCh = make(chan struct{}, cgroup.AvailableCPUs())
 
 func tryPushWriteRequest(wr *prompbmarshal.WriteRequest, tryPushBlock func(block []byte) bool, isVMRemoteWrite bool) bool {
-	if len(wr.Timeseries) == 0 {
+	if len(wr.Timeseries) == 0 && len(wr.Metadata) == 0 {
 		// Nothing to push
 		return true
 	}
@@ -263,6 +336,9 @@ func tryPushWriteRequest(wr *prompbmarshal.WriteRequest, tryPushBlock func(block
 	}
 
 	// Too big block. Recursively split it into smaller parts if possible.
+	if len(wr.Metadata) > 0 {
+		return tryPushSplitMetadata(wr, tryPushBlock, isVMRemoteWrite)
+	}
 	if len(wr.Timeseries) == 1 {
 		// A single time series left. Recursively split its samples into smaller parts if possible.
 		samples := wr.Timeseries[0].Samples
@@ -300,6 +376,42 @@ func tryPushWriteRequest(wr *prompbmarshal.WriteRequest, tryPushBlock func(block
 	return true
 }
 
+// tryPushSplitMetadata sends metadata from too big wr separately from time series
+// and recursively splits it into smaller parts if needed.
+func tryPushSplitMetadata(wr *prompbmarshal.WriteRequest, tryPushBlock func(block []byte) bool, isVMRemoteWrite bool) bool {
+	mms := wr.Metadata
+	tss := wr.Timeseries
+	if len(tss) > 0 {
+		wr.Timeseries = nil
+		if !tryPushWriteRequest(wr, tryPushBlock, isVMRemoteWrite) {
+			wr.Timeseries = tss
+			return false
+		}
+		wr.Timeseries = tss
+		wr.Metadata = nil
+		ok := tryPushWriteRequest(wr, tryPushBlock, isVMRemoteWrite)
+		wr.Metadata = mms
+		return ok
+	}
+	if len(mms) == 1 {
+		logger.Warnf("dropping metadata for metric %q exceeding -remoteWrite.maxBlockSize=%d bytes", mms[0].MetricFamilyName, maxUnpackedBlockSize.N)
+		return true
+	}
+	n := len(mms) / 2
+	wr.Metadata = mms[:n]
+	if !tryPushWriteRequest(wr, tryPushBlock, isVMRemoteWrite) {
+		wr.Metadata = mms
+		return false
+	}
+	wr.Metadata = mms[n:]
+	if !tryPushWriteRequest(wr, tryPushBlock, isVMRemoteWrite) {
+		wr.Metadata = mms
+		return false
+	}
+	wr.Metadata = mms
+	return true
+}
+
 var (
 	blockSizeBytes = metrics.NewHistogram(`vmagent_remotewrite_block_size_bytes`)
 	blockSizeRows  = metrics.NewHistogram(`vmagent_remotewrite_block_size_rows`)
@@ -498,6 +498,18 @@ func tryPush(at *auth.Token, wr *prompbmarshal.WriteRequest, forceDropSamplesOnF
 			return false
 		}
 	}
+	return tryPushMetadataToRemoteStorages(rwctxs, wr.Metadata, forceDropSamplesOnFailure)
+}
+
+// tryPushMetadataToRemoteStorages sends mms to all the rwctxs.
+//
+// Metadata isn't sharded among rwctxs, since it is small and it must be available at every remote storage.
+func tryPushMetadataToRemoteStorages(rwctxs []*remoteWriteCtx, mms []prompbmarshal.MetricMetadata, forceDropSamplesOnFailure bool) bool {
+	for _, rwctx := range rwctxs {
+		if !rwctx.tryPushMetadata(mms, forceDropSamplesOnFailure) {
+			return false
+		}
+	}
 	return true
 }
 
@@ -980,6 +992,19 @@ func (rwctx *remoteWriteCtx) tryPushInternal(tss []prompbmarshal.TimeSeries) boo
 	return pss[idx].TryPush(tss)
 }
 
+func (rwctx *remoteWriteCtx) tryPushMetadata(mms []prompbmarshal.MetricMetadata, forceDropSamplesOnFailure bool) bool {
+	if len(mms) == 0 {
+		return true
+	}
+	pss := rwctx.pss
+	idx := rwctx.pssNextIdx.Add(1) % uint64(len(pss))
+	if pss[idx].TryPushMetadata(mms) {
+		return true
+	}
+	rwctx.pushFailures.Inc()
+	return forceDropSamplesOnFailure
+}
+
 var tssPool = &sync.Pool{
 	New: func() any {
 		a := []prompbmarshal.TimeSeries{}
@@ -12,8 +12,10 @@ import (
 	"strings"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/config/log"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/utils"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/envtemplate"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
 	"gopkg.in/yaml.v2"
 )
@@ -50,6 +52,8 @@ type Group struct {
 	NotifierHeaders []Header `yaml:"notifier_headers,omitempty"`
 	// EvalAlignment will make the timestamp of group query requests be aligned with interval
 	EvalAlignment *bool `yaml:"eval_alignment,omitempty"`
+	// InhibitRules contains rules for muting alerts of the group while the matching source alerts are firing
+	InhibitRules []InhibitRule `yaml:"inhibit_rules,omitempty"`
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]any `yaml:",inline"`
 }
@@ -95,6 +99,12 @@ func (g *Group) Validate(validateTplFn ValidateTplFn, validateExpressions bool)
 		return fmt.Errorf("invalid concurrency %d, shouldn't be less than 0", g.Concurrency)
 	}
 
+	for i, ir := range g.InhibitRules {
+		if err := ir.Validate(); err != nil {
+			return fmt.Errorf("invalid inhibit rule #%d: %w", i+1, err)
+		}
+	}
+
 	uniqueRules := map[uint64]struct{}{}
 	for _, r := range g.Rules {
 		ruleName := r.Record
@@ -108,6 +118,14 @@ func (g *Group) Validate(validateTplFn ValidateTplFn, validateExpressions bool)
 		if err := r.Validate(); err != nil {
 			return fmt.Errorf("invalid rule %q: %w", ruleName, err)
 		}
+		if r.LogSamples != nil {
+			if r.Alert == "" || g.Type.String() != "vlogs" {
+				return fmt.Errorf("invalid rule %q: `log_samples` is supported only by alerting rules with `vlogs` type", ruleName)
+			}
+			if err := r.LogSamples.Validate(); err != nil {
+				return fmt.Errorf("invalid log_samples for rule %q: %w", ruleName, err)
+			}
+		}
 		if validateExpressions {
 			// its needed only for tests.
 			// because correct types must be inherited after unmarshalling.
@@ -128,6 +146,27 @@ func (g *Group) Validate(validateTplFn ValidateTplFn, validateExpressions bool)
 	return checkOverflow(g.XXX, fmt.Sprintf("group %q", g.Name))
 }
 
+// InhibitRule describes a rule for muting target alerts of the group
+// while there are firing source alerts with equal values for the given labels.
+//
+// See https://prometheus.io/docs/alerting/latest/configuration/#inhibit_rule
+type InhibitRule struct {
+	SourceMatchers []string `yaml:"source_matchers"`
+	TargetMatchers []string `yaml:"target_matchers"`
+	Equal          []string `yaml:"equal,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]any `yaml:",inline"`
+}
+
+// Validate checks InhibitRule configuration errors
+func (ir *InhibitRule) Validate() error {
+	if _, err := notifier.NewInhibitRule(ir.SourceMatchers, ir.TargetMatchers, ir.Equal); err != nil {
+		return err
+	}
+	return checkOverflow(ir.XXX, "inhibit rule")
+}
+
 // Rule describes entity that represent either
 // recording rule or alerting rule.
 type Rule struct {
@@ -144,6 +183,9 @@ type Rule struct {
 	// UpdateEntriesLimit defines max number of rule's state updates stored in memory.
 	// Overrides `-rule.updateEntriesLimit`.
 	UpdateEntriesLimit *int `yaml:"update_entries_limit,omitempty"`
+	// LogSamples configures fetching of sample log lines for firing alerts.
+	// It is supported only by alerting rules with `vlogs` type.
+	LogSamples *LogSamples `yaml:"log_samples,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]any `yaml:",inline"`
@@ -225,6 +267,51 @@ func (r *Rule) Validate() error {
 	return checkOverflow(r.XXX, "rule")
 }
 
+// LogSamplesMaxLimit is the maximum number of sample log lines, which can be fetched per alert
+const LogSamplesMaxLimit = 100
+
+// LogSamples contains settings for fetching sample log lines for firing alerts
+// of alerting rules with `vlogs` type.
+//
+// See https://docs.victoriametrics.com/victorialogs/vmalert/#log-samples
+type LogSamples struct {
+	// Query is an optional LogsQL query for selecting sample log lines.
+	// By default, filters from the rule expression are used.
+	Query string `yaml:"query,omitempty"`
+	// Limit is the maximum number of sample log lines per alert
+	Limit int `yaml:"limit,omitempty"`
+	// Lookback is the time range for selecting sample log lines before the evaluation time.
+	// By default, the group evaluation interval is used.
+	Lookback *promutils.Duration `yaml:"lookback,omitempty"`
+	// FilterBy defines how alert labels are converted into filters for sample log lines.
+	// Supported values are `stream` (default) and `fields`.
+	FilterBy string `yaml:"filter_by,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]any `yaml:",inline"`
+}
+
+// Validate checks LogSamples configuration errors
+func (ls *LogSamples) Validate() error {
+	if ls.Limit < 0 || ls.Limit > LogSamplesMaxLimit {
+		return fmt.Errorf("limit must be in the range [0..%d]; got %d", LogSamplesMaxLimit, ls.Limit)
+	}
+	if ls.Lookback.Duration() < 0 {
+		return fmt.Errorf("lookback cannot be negative")
+	}
+	switch ls.FilterBy {
+	case "", "stream", "fields":
+	default:
+		return fmt.Errorf("unsupported filter_by=%q; supported values: stream, fields", ls.FilterBy)
+	}
+	if ls.Query != "" {
+		if _, err := logstorage.ParseQuery(ls.Query); err != nil {
+			return fmt.Errorf("bad LogsQL query %q: %w", ls.Query, err)
+		}
+	}
+	return checkOverflow(ls.XXX, "log_samples")
+}
+
 // ValidateTplFn must validate the given annotations
 type ValidateTplFn func(annotations map[string]string) error
 
@@ -178,6 +178,21 @@ func TestGroupValidate_Failure(t *testing.T) {
 		Concurrency: -1,
 	}, false, "invalid concurrency")
 
+	f(&Group{
+		Name: "missing source matchers",
+		InhibitRules: []InhibitRule{{
+			TargetMatchers: []string{`severity="warning"`},
+		}},
+	}, false, "source_matchers cannot be empty")
+
+	f(&Group{
+		Name: "invalid target matchers",
+		InhibitRules: []InhibitRule{{
+			SourceMatchers: []string{`alertname="NodeDown"`},
+			TargetMatchers: []string{`severity=~"warning(`},
+		}},
+	}, false, "invalid target_matchers")
+
 	f(&Group{
 		Name: "test",
 		Rules: []Rule{
@@ -322,6 +337,46 @@ func TestGroupValidate_Failure(t *testing.T) {
 			}},
 		},
 	}, true, "bad LogsQL expr")
+
+	// log_samples
+	f(&Group{
+		Name: "log_samples for non-vlogs rule",
+		Rules: []Rule{
+			{Alert: "alert", Expr: "up == 0", LogSamples: &LogSamples{}},
+		},
+	}, false, "`log_samples` is supported only by alerting rules with `vlogs` type")
+
+	f(&Group{
+		Name: "log_samples for recording rule",
+		Type: NewVLogsType(),
+		Rules: []Rule{
+			{Record: "record", Expr: "* | stats count() rows", LogSamples: &LogSamples{}},
+		},
+	}, false, "`log_samples` is supported only by alerting rules with `vlogs` type")
+
+	f(&Group{
+		Name: "log_samples with too big limit",
+		Type: NewVLogsType(),
+		Rules: []Rule{
+			{Alert: "alert", Expr: "* | stats count() rows", LogSamples: &LogSamples{Limit: 101}},
+		},
+	}, false, "limit must be in the range")
+
+	f(&Group{
+		Name: "log_samples with bad filter_by",
+		Type: NewVLogsType(),
+		Rules: []Rule{
+			{Alert: "alert", Expr: "* | stats count() rows", LogSamples: &LogSamples{FilterBy: "labels"}},
+		},
+	}, false, "unsupported filter_by")
+
+	f(&Group{
+		Name: "log_samples with bad query",
+		Type: NewVLogsType(),
+		Rules: []Rule{
+			{Alert: "alert", Expr: "* | stats count() rows", LogSamples: &LogSamples{Query: "foo |"}},
+		},
+	}, false, "bad LogsQL query")
 }
 
 func TestGroupValidate_Success(t *testing.T) {
@@ -338,6 +393,15 @@ func TestGroupValidate_Success(t *testing.T) {
 		}
 	}
 
+	f(&Group{
+		Name: "test",
+		InhibitRules: []InhibitRule{{
+			SourceMatchers: []string{`alertname="NodeDown"`},
+			TargetMatchers: []string{`severity=~"warning|info"`, `alertname!="NodeDown"`},
+			Equal:          []string{"instance"},
+		}},
+	}, false, false)
+
 	f(&Group{
 		Name: "test",
 		Rules: []Rule{
@@ -397,6 +461,27 @@ func TestGroupValidate_Success(t *testing.T) {
 			}},
 		},
 	}, false, true)
+
+	// log_samples
+	f(&Group{
+		Name: "test log_samples",
+		Type: NewVLogsType(),
+		Rules: []Rule{
+			{
+				Alert: "alert",
+				Expr:  "error | stats by (app) count() errors",
+				Annotations: map[string]string{
+					"logs": "{{ range $logSamples }}{{ .Time }} {{ .Msg }}\n{{ end }}",
+				},
+				LogSamples: &LogSamples{
+					Query:    "error -debug",
+					Limit:    10,
+					Lookback: promutils.NewDuration(5 * time.Minute),
+					FilterBy: "fields",
+				},
+			},
+		},
+	}, true, true)
 }
 
 func TestHashRule_NotEqual(t *testing.T) {
@@ -208,6 +208,12 @@ func (c *Client) QueryRange(ctx context.Context, query string, start, end time.T
 	if end.IsZero() {
 		return res, fmt.Errorf("end param is missing")
 	}
+	if c.dataSourceType == datasourceVLogs && c.evaluationInterval > 0 {
+		if from, to := getVLogsRangeBounds(start, end, c.evaluationInterval); to.Before(from) {
+			// the [start, end] time range doesn't contain step-aligned timestamps
+			return res, nil
+		}
+	}
 	req, err := c.newQueryRangeRequest(ctx, query, start, end)
 	if err != nil {
 		return res, err
@@ -242,6 +248,9 @@ func (c *Client) QueryRange(ctx context.Context, query string, start, end time.T
 	}
 	res, err = parseFn(req, resp)
 	_ = resp.Body.Close()
+	if err == nil && c.dataSourceType == datasourceVLogs && c.evaluationInterval > 0 {
+		shiftVLogsRangeResult(res, c.evaluationInterval)
+	}
 	return res, err
 }
 
@@ -475,9 +475,10 @@ func TestVMRangeQuery(t *testing.T) {
 	if len(m) != 1 {
 		t.Fatalf("expected 1 metric  got %d in %+v", len(m), m)
 	}
+	// timestamps are shifted by step, so they point to the end of the buckets
 	expected = Metric{
 		Labels:     []prompbmarshal.Label{{Value: "total", Name: "stats_result"}},
-		Timestamps: []int64{1583786142},
+		Timestamps: []int64{1583786142 + 60},
 		Values:     []float64{10},
 	}
 	if !reflect.DeepEqual(m[0], expected) {
@@ -485,6 +486,50 @@ func TestVMRangeQuery(t *testing.T) {
 	}
 }
 
+func TestVLogsQueryLogs(t *testing.T) {
+	const query = `{app="nginx"} error`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/select/logsql/query", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") != query {
+			t.Errorf("expected %s in query param, got %s", query, r.URL.Query().Get("query"))
+		}
+		if limit := r.URL.Query().Get("limit"); limit != "2" {
+			t.Errorf("expected 'limit' query param to be 2; got %q instead", limit)
+		}
+		for _, name := range []string{"start", "end"} {
+			if _, err := time.Parse(time.RFC3339, r.URL.Query().Get(name)); err != nil {
+				t.Errorf("failed to parse %q query param: %s", name, err)
+			}
+		}
+		w.Write([]byte(`{"_time":"2025-01-01T00:00:00Z","_msg":"error 1","_stream":"{app=\"nginx\"}"}
+{"_time":"2025-01-01T00:00:01Z","_msg":"error 2","_stream":"{app=\"nginx\"}"}
+{"_time":"2025-01-01T00:00:02Z","_msg":"error 3","_stream":"{app=\"nginx\"}"}
+`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := NewPrometheusClient(srv.URL, nil, false, srv.Client())
+	start, end := time.Now().Add(-time.Minute), time.Now()
+
+	pq := s.BuildWithParams(QuerierParams{DataSourceType: string(datasourcePrometheus)})
+	_, err := pq.(LogsQuerier).QueryLogs(ctx, query, start, end, 2)
+	expectError(t, err, "is not supported")
+
+	lq := s.BuildWithParams(QuerierParams{DataSourceType: string(datasourceVLogs)}).(LogsQuerier)
+	rows, err := lq.QueryLogs(ctx, query, start, end, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []map[string]string{
+		{"_time": "2025-01-01T00:00:00Z", "_msg": "error 1", "_stream": `{app="nginx"}`},
+		{"_time": "2025-01-01T00:00:01Z", "_msg": "error 2", "_stream": `{app="nginx"}`},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("unexpected rows\ngot\n%v\nwant\n%v", rows, expected)
+	}
+}
+
 func TestRequestParams(t *testing.T) {
 	query := "up"
 	vlogsQuery := "_time: 5m | stats count() total"
@@ -733,12 +778,46 @@ func TestRequestParams(t *testing.T) {
 		dataSourceType:     datasourceVLogs,
 		evaluationInterval: time.Minute,
 	}, func(t *testing.T, r *http.Request) {
-		ts := timestamp.Format(time.RFC3339)
-		exp := url.Values{"query": {vlogsQuery}, "start": {ts}, "end": {ts}, "step": {"60s"}}
+		// the time range is aligned to step
+		exp := url.Values{"query": {vlogsQuery}, "start": {"2001-02-03T04:05:00Z"}, "end": {"2001-02-03T04:04:59.999999999Z"}, "step": {"60s"}}
 		checkEqualString(t, exp.Encode(), r.URL.RawQuery)
 	})
 }
 
+func TestGetVLogsRangeBounds(t *testing.T) {
+	f := func(start, end string, step time.Duration, fromExpected, toExpected string) {
+		t.Helper()
+
+		s, err := time.Parse(time.RFC3339Nano, start)
+		if err != nil {
+			t.Fatalf("cannot parse start: %s", err)
+		}
+		e, err := time.Parse(time.RFC3339Nano, end)
+		if err != nil {
+			t.Fatalf("cannot parse end: %s", err)
+		}
+		from, to := getVLogsRangeBounds(s, e, step)
+		if got := from.Format(time.RFC3339Nano); got != fromExpected {
+			t.Fatalf("unexpected from; got %s; want %s", got, fromExpected)
+		}
+		if got := to.Format(time.RFC3339Nano); got != toExpected {
+			t.Fatalf("unexpected to; got %s; want %s", got, toExpected)
+		}
+	}
+
+	// aligned time range
+	f("2025-01-01T00:00:00Z", "2025-01-01T01:00:00Z", 5*time.Minute, "2024-12-31T23:55:00Z", "2025-01-01T00:59:59.999999999Z")
+
+	// unaligned time range
+	f("2025-01-01T00:01:00Z", "2025-01-01T00:59:00Z", 5*time.Minute, "2025-01-01T00:00:00Z", "2025-01-01T00:54:59.999999999Z")
+
+	// time range with a single step-aligned timestamp
+	f("2025-01-01T00:04:00Z", "2025-01-01T00:06:00Z", 5*time.Minute, "2025-01-01T00:00:00Z", "2025-01-01T00:04:59.999999999Z")
+
+	// time range without step-aligned timestamps
+	f("2025-01-01T00:01:00Z", "2025-01-01T00:04:00Z", 5*time.Minute, "2025-01-01T00:00:00Z", "2024-12-31T23:59:59.999999999Z")
+}
+
 func TestHeaders(t *testing.T) {
 	f := func(vmFn func() *Client, checkFn func(t *testing.T, r *http.Request)) {
 		t.Helper()
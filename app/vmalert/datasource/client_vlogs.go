@@ -1,8 +1,12 @@
 package datasource
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -30,16 +34,50 @@ func (c *Client) setVLogsRangeReqParams(r *http.Request, query string, start, en
 		r.URL.Path += "/select/logsql/stats_query_range"
 	}
 	q := r.URL.Query()
-	q.Add("start", start.Format(time.RFC3339))
-	q.Add("end", end.Format(time.RFC3339))
 	// set step as evaluationInterval by default
 	if c.evaluationInterval > 0 {
+		start, end = getVLogsRangeBounds(start, end, c.evaluationInterval)
 		q.Set("step", fmt.Sprintf("%ds", int(c.evaluationInterval.Seconds())))
 	}
+	q.Add("start", start.Format(time.RFC3339Nano))
+	q.Add("end", end.Format(time.RFC3339Nano))
 	r.URL.RawQuery = q.Encode()
 	c.setReqParams(r, query)
 }
 
+// getVLogsRangeBounds returns the time range for stats_query_range request,
+// which must be made for obtaining results at step-aligned timestamps on the [start, end] time range.
+//
+// stats_query_range returns results for [t, t+step) buckets at step-aligned timestamps t,
+// while instant rule evaluation at timestamp t covers logs for the preceding step.
+// So the returned time range covers full buckets only, starting one step before start,
+// and the results timestamps must be shifted by step via shiftVLogsRangeResult.
+// This makes the backfilled results consistent with the results of instant rule evaluations.
+func getVLogsRangeBounds(start, end time.Time, step time.Duration) (time.Time, time.Time) {
+	stepNsecs := step.Nanoseconds()
+	startNsecs := start.UnixNano()
+	if n := startNsecs % stepNsecs; n != 0 {
+		startNsecs += stepNsecs - n
+	}
+	endNsecs := end.UnixNano()
+	endNsecs -= endNsecs % stepNsecs
+	return time.Unix(0, startNsecs-stepNsecs).In(start.Location()), time.Unix(0, endNsecs-1).In(end.Location())
+}
+
+// shiftVLogsRangeResult shifts timestamps of stats_query_range results by step,
+// so every data point is placed at the end of the bucket it was calculated for.
+//
+// See getVLogsRangeBounds.
+func shiftVLogsRangeResult(res Result, step time.Duration) {
+	stepSecs := int64(step.Seconds())
+	for i := range res.Data {
+		m := &res.Data[i]
+		for j := range m.Timestamps {
+			m.Timestamps[j] += stepSecs
+		}
+	}
+}
+
 func parseVLogsResponse(req *http.Request, resp *http.Response) (res Result, err error) {
 	res, err = parsePrometheusResponse(req, resp)
 	if err != nil {
@@ -59,3 +97,48 @@ func parseVLogsResponse(req *http.Request, resp *http.Response) (res Result, err
 	}
 	return
 }
+
+// QueryLogs selects up to limit log entries matching the given LogsQL query on the [start, end] time range.
+//
+// See https://docs.victoriametrics.com/victorialogs/querying/#querying-logs
+func (c *Client) QueryLogs(ctx context.Context, query string, start, end time.Time, limit int) ([]map[string]string, error) {
+	if c.dataSourceType != datasourceVLogs {
+		return nil, fmt.Errorf("%q is not supported for QueryLogs", c.dataSourceType)
+	}
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create query request to datasource %q: %w", c.datasourceURL, err)
+	}
+	if !*disablePathAppend {
+		req.URL.Path += "/select/logsql/query"
+	}
+	q := req.URL.Query()
+	q.Set("start", start.Format(time.RFC3339))
+	q.Set("end", end.Format(time.RFC3339))
+	q.Set("limit", strconv.Itoa(limit))
+	req.URL.RawQuery = q.Encode()
+	c.setReqParams(req, query)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return parseVLogsQueryResponse(resp.Body, limit)
+}
+
+func parseVLogsQueryResponse(r io.Reader, limit int) ([]map[string]string, error) {
+	var rows []map[string]string
+	dec := json.NewDecoder(r)
+	for len(rows) < limit {
+		var row map[string]string
+		if err := dec.Decode(&row); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("cannot parse log entry: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -25,6 +25,14 @@ type Querier interface {
 	QueryRange(ctx context.Context, query string, from, to time.Time) (Result, error)
 }
 
+// LogsQuerier is an optional interface implemented by queriers,
+// which can select raw log entries from VictoriaLogs.
+type LogsQuerier interface {
+	// QueryLogs selects up to limit log entries matching the given LogsQL query on the given time range.
+	// Every returned entry contains log fields with their values.
+	QueryLogs(ctx context.Context, query string, start, end time.Time, limit int) ([]map[string]string, error)
+}
+
 // Result represents expected response from the datasource
 type Result struct {
 	// Data contains list of received Metric
@@ -225,6 +225,9 @@ func newManager(ctx context.Context) (*manager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to init notifier: %w", err)
 	}
+	if err := notifier.InitSilences(); err != nil {
+		return nil, fmt.Errorf("failed to init silences: %w", err)
+	}
 	manager := &manager{
 		groups:         make(map[uint64]*rule.Group),
 		querierBuilder: q,
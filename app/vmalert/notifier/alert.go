@@ -49,6 +49,18 @@ type Alert struct {
 	Restored bool
 	// For defines for how long Alert needs to be active to become StateFiring
 	For time.Duration
+	// SilencedBy contains IDs of active silences matching the Alert
+	SilencedBy []string
+	// InhibitedBy contains IDs of firing alerts, which inhibit the Alert
+	InhibitedBy []string
+	// FiringSent is true if the Alert was sent to notifiers in StateFiring since it became active.
+	// Resolved notifications are sent only for such alerts.
+	FiringSent bool
+}
+
+// IsSuppressed returns true if a is muted by silences or inhibition rules.
+func (a *Alert) IsSuppressed() bool {
+	return len(a.SilencedBy) > 0 || len(a.InhibitedBy) > 0
 }
 
 // AlertState type indicates the Alert state
@@ -85,6 +97,29 @@ type AlertTplData struct {
 	GroupID  uint64
 	ActiveAt time.Time
 	For      time.Duration
+	// LogSamples contains sample log lines for alerts of `vlogs` rules with `log_samples` config
+	LogSamples []LogSample
+}
+
+// LogSample is a log line, which is attached to the alert
+// of alerting rule with `vlogs` type.
+type LogSample struct {
+	// Time is the value of `_time` field
+	Time string
+	// Msg is the value of `_msg` field
+	Msg string
+	// Stream is the value of `_stream` field
+	Stream string
+	// Fields contains all the log fields including `_time`, `_msg` and `_stream`
+	Fields map[string]string
+}
+
+// String returns string representation of the log sample suitable for annotations
+func (ls LogSample) String() string {
+	if ls.Time == "" {
+		return ls.Msg
+	}
+	return ls.Time + " " + ls.Msg
 }
 
 var tplHeaders = []string{
@@ -97,6 +132,7 @@ var tplHeaders = []string{
 	"{{ $groupID := .GroupID }}",
 	"{{ $activeAt := .ActiveAt }}",
 	"{{ $for := .For }}",
+	"{{ $logSamples := .LogSamples }}",
 }
 
 // ExecTemplate executes the Alert template for given
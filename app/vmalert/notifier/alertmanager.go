@@ -137,14 +137,38 @@ const alertManagerPath = "/api/v2/alerts"
 func NewAlertManager(alertManagerURL string, fn AlertURLGenerator, authCfg promauth.HTTPClientConfig,
 	relabelCfg *promrelabel.ParsedConfigs, timeout time.Duration,
 ) (*AlertManager, error) {
+	client, aCfg, err := newHTTPClient(alertManagerURL, authCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init client for alertmanager URL=%q: %w", alertManagerURL, err)
+	}
+
+	amURL, err := url.Parse(alertManagerURL)
+	if err != nil {
+		return nil, fmt.Errorf("provided incorrect notifier url: %w", err)
+	}
+	if !*showNotifierURL {
+		alertManagerURL = amURL.Redacted()
+	}
+	return &AlertManager{
+		addr:           amURL,
+		argFunc:        fn,
+		authCfg:        aCfg,
+		relabelConfigs: relabelCfg,
+		client:         client,
+		timeout:        timeout,
+		metrics:        newMetrics(alertManagerURL),
+	}, nil
+}
+
+// newHTTPClient returns http client and auth config for sending requests to addr with the given authCfg.
+func newHTTPClient(addr string, authCfg promauth.HTTPClientConfig) (*http.Client, *promauth.Config, error) {
 	tls := &promauth.TLSConfig{}
 	if authCfg.TLSConfig != nil {
 		tls = authCfg.TLSConfig
 	}
-	tr, err := httputils.Transport(alertManagerURL, tls.CertFile, tls.KeyFile, tls.CAFile, tls.ServerName, tls.InsecureSkipVerify)
+	tr, err := httputils.Transport(addr, tls.CertFile, tls.KeyFile, tls.CAFile, tls.ServerName, tls.InsecureSkipVerify)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create transport for alertmanager URL=%q: %w", alertManagerURL, err)
-
+		return nil, nil, fmt.Errorf("failed to create transport: %w", err)
 	}
 
 	ba := new(promauth.BasicAuthConfig)
@@ -163,23 +187,8 @@ func NewAlertManager(alertManagerURL string, fn AlertURLGenerator, authCfg proma
 		utils.WithHeaders(strings.Join(authCfg.Headers, "^^")),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to configure auth: %w", err)
+		return nil, nil, fmt.Errorf("failed to configure auth: %w", err)
 	}
 
-	amURL, err := url.Parse(alertManagerURL)
-	if err != nil {
-		return nil, fmt.Errorf("provided incorrect notifier url: %w", err)
-	}
-	if !*showNotifierURL {
-		alertManagerURL = amURL.Redacted()
-	}
-	return &AlertManager{
-		addr:           amURL,
-		argFunc:        fn,
-		authCfg:        aCfg,
-		relabelConfigs: relabelCfg,
-		client:         &http.Client{Transport: tr},
-		timeout:        timeout,
-		metrics:        newMetrics(alertManagerURL),
-	}, nil
+	return &http.Client{Transport: tr}, aCfg, nil
 }
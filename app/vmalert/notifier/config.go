@@ -37,6 +37,13 @@ type Config struct {
 	// StaticConfigs contains list of static targets
 	StaticConfigs []StaticConfig `yaml:"static_configs,omitempty"`
 
+	// WebhookConfigs contains list of generic webhook receivers, which are notified directly
+	WebhookConfigs []WebhookConfig `yaml:"webhook_configs,omitempty"`
+	// SlackConfigs contains list of Slack-compatible incoming webhooks, which are notified directly
+	SlackConfigs []SlackConfig `yaml:"slack_configs,omitempty"`
+	// EmailConfigs contains list of email receivers, which are notified directly via SMTP
+	EmailConfigs []EmailConfig `yaml:"email_configs,omitempty"`
+
 	// HTTPClientConfig contains HTTP configuration for Notifier clients
 	HTTPClientConfig promauth.HTTPClientConfig `yaml:",inline"`
 	// RelabelConfigs contains list of relabeling rules for entities discovered via SD
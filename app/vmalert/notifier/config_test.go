@@ -18,6 +18,7 @@ func TestParseConfig_Success(t *testing.T) {
 	f("testdata/consul.good.yaml")
 	f("testdata/dns.good.yaml")
 	f("testdata/static.good.yaml")
+	f("testdata/receivers.good.yaml")
 }
 
 func TestParseConfig_Failure(t *testing.T) {
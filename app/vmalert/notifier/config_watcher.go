@@ -182,6 +182,10 @@ func (cw *configWatcher) start() error {
 		cw.setTargets(TargetStatic, targets)
 	}
 
+	if err := cw.startReceivers(); err != nil {
+		return err
+	}
+
 	if len(cw.cfg.ConsulSDConfigs) > 0 {
 		err := cw.add(TargetConsul, *consul.SDCheckInterval, func() ([]*promutils.Labels, error) {
 			var labels []*promutils.Labels
@@ -220,6 +224,50 @@ func (cw *configWatcher) start() error {
 	return nil
 }
 
+// startReceivers inits receivers, which are notified directly without Alertmanager.
+func (cw *configWatcher) startReceivers() error {
+	timeout := cw.cfg.Timeout.Duration()
+	relabelCfg := cw.cfg.parsedAlertRelabelConfigs
+
+	var targets []Target
+	for i, cfg := range cw.cfg.WebhookConfigs {
+		cfg.HTTPClientConfig = mergeHTTPClientConfigs(cw.cfg.HTTPClientConfig, cfg.HTTPClientConfig)
+		n, err := newWebhookNotifier(cfg, cw.genFn, relabelCfg, timeout)
+		if err != nil {
+			return fmt.Errorf("failed to init webhook_configs #%d: %w", i+1, err)
+		}
+		targets = append(targets, Target{Notifier: n})
+	}
+	if len(targets) > 0 {
+		cw.setTargets(TargetWebhook, targets)
+	}
+
+	targets = nil
+	for i, cfg := range cw.cfg.SlackConfigs {
+		n, err := newSlackNotifier(cfg, cw.genFn, relabelCfg, timeout)
+		if err != nil {
+			return fmt.Errorf("failed to init slack_configs #%d: %w", i+1, err)
+		}
+		targets = append(targets, Target{Notifier: n})
+	}
+	if len(targets) > 0 {
+		cw.setTargets(TargetSlack, targets)
+	}
+
+	targets = nil
+	for i, cfg := range cw.cfg.EmailConfigs {
+		n, err := newEmailNotifier(cfg, cw.genFn, relabelCfg, timeout)
+		if err != nil {
+			return fmt.Errorf("failed to init email_configs #%d: %w", i+1, err)
+		}
+		targets = append(targets, Target{Notifier: n})
+	}
+	if len(targets) > 0 {
+		cw.setTargets(TargetEmail, targets)
+	}
+	return nil
+}
+
 func (cw *configWatcher) mustStop() {
 	close(cw.syncCh)
 	cw.wg.Wait()
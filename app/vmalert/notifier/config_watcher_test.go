@@ -58,6 +58,35 @@ static_configs:
 	}
 }
 
+func TestConfigWatcherReceivers(t *testing.T) {
+	cw, err := newWatcher("testdata/receivers.good.yaml", nil)
+	if err != nil {
+		t.Fatalf("failed to start config watcher: %s", err)
+	}
+	defer cw.mustStop()
+
+	f := func(typeK TargetType, addrExpected string) {
+		t.Helper()
+
+		cw.targetsMu.RLock()
+		targets := cw.targets[typeK]
+		cw.targetsMu.RUnlock()
+		if len(targets) != 1 {
+			t.Fatalf("expected to have 1 target of type %q; got %d", typeK, len(targets))
+		}
+		if addr := targets[0].Addr(); addr != addrExpected {
+			t.Fatalf("unexpected addr for %q; got %q; want %q", typeK, addr, addrExpected)
+		}
+	}
+	f(TargetWebhook, "http://localhost:8080/alerts")
+	f(TargetSlack, "https://hooks.slack.com")
+	f(TargetEmail, "smtp://localhost:25/ops@example.com")
+
+	if n := len(cw.notifiers()); n != 3 {
+		t.Fatalf("expected to have 3 notifiers; got %d", n)
+	}
+}
+
 func TestConfigWatcherStart(t *testing.T) {
 	consulSDServer := newFakeConsulServer()
 	defer consulSDServer.Close()
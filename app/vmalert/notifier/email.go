@@ -0,0 +1,290 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httputils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+)
+
+const (
+	defaultEmailSubject = defaultSlackTitle
+	defaultEmailBody    = `{{ range .Alerts }}[{{ .Status | toUpper }}] {{ .Labels.alertname }}
+Labels:
+{{ range $k, $v := .Labels }}  {{ $k }}: {{ $v }}
+{{ end }}Annotations:
+{{ range $k, $v := .Annotations }}  {{ $k }}: {{ $v }}
+{{ end }}Source: {{ .GeneratorURL }}
+
+{{ end }}`
+)
+
+// EmailConfig contains settings for sending notifications via SMTP.
+type EmailConfig struct {
+	// To contains the list of recipients
+	To []string `yaml:"to"`
+	// From is the sender address
+	From string `yaml:"from"`
+	// Smarthost is the SMTP server address in the form host:port.
+	// Implicit TLS is used for port 465.
+	Smarthost string `yaml:"smarthost"`
+	// Hello is the hostname sent in HELO/EHLO command
+	Hello string `yaml:"hello,omitempty"`
+	// AuthUsername is the username for PLAIN authentication
+	AuthUsername string `yaml:"auth_username,omitempty"`
+	// AuthPassword is the password for PLAIN authentication
+	AuthPassword *promauth.Secret `yaml:"auth_password,omitempty"`
+	// AuthPasswordFile is the path to the file with the password for PLAIN authentication
+	AuthPasswordFile string `yaml:"auth_password_file,omitempty"`
+	// RequireTLS defines whether STARTTLS must be used. It is true by default
+	RequireTLS *bool `yaml:"require_tls,omitempty"`
+	// TLSConfig contains TLS settings for connecting to Smarthost
+	TLSConfig *promauth.TLSConfig `yaml:"tls_config,omitempty"`
+	// Headers contains additional email headers
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Subject is the template for the email subject
+	Subject string `yaml:"subject,omitempty"`
+	// Body is the template for the email body
+	Body string `yaml:"body,omitempty"`
+	// ContentType is the content type of the email body
+	ContentType string `yaml:"content_type,omitempty"`
+	// ReceiverConfig contains grouping and retry settings
+	ReceiverConfig `yaml:",inline"`
+}
+
+type emailNotifier struct {
+	*receiver
+
+	to           []string
+	from         string
+	toAddrs      []string
+	fromAddr     string
+	smarthost    string
+	host         string
+	hello        string
+	authUsername string
+	authPassword string
+	requireTLS   bool
+	implicitTLS  bool
+	tlsConfig    *tls.Config
+	headers      map[string]string
+	subject      string
+	body         string
+	contentType  string
+}
+
+func newEmailNotifier(cfg EmailConfig, gen AlertURLGenerator, relabelCfg *promrelabel.ParsedConfigs, timeout time.Duration) (*emailNotifier, error) {
+	if len(cfg.To) == 0 {
+		return nil, fmt.Errorf("to cannot be empty")
+	}
+	if cfg.From == "" {
+		return nil, fmt.Errorf("from cannot be empty")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse from=%q: %w", cfg.From, err)
+	}
+	toAddrs := make([]string, 0, len(cfg.To))
+	for _, s := range cfg.To {
+		to, err := mail.ParseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse to=%q: %w", s, err)
+		}
+		toAddrs = append(toAddrs, to.Address)
+	}
+	host, port, err := net.SplitHostPort(cfg.Smarthost)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smarthost %q; it must be in the form host:port: %w", cfg.Smarthost, err)
+	}
+	en := &emailNotifier{
+		to:           cfg.To,
+		from:         cfg.From,
+		toAddrs:      toAddrs,
+		fromAddr:     from.Address,
+		smarthost:    cfg.Smarthost,
+		host:         host,
+		hello:        cfg.Hello,
+		authUsername: cfg.AuthUsername,
+		authPassword: cfg.AuthPassword.String(),
+		requireTLS:   true,
+		implicitTLS:  port == "465",
+		headers:      cfg.Headers,
+		subject:      cfg.Subject,
+		body:         cfg.Body,
+		contentType:  cfg.ContentType,
+	}
+	if cfg.RequireTLS != nil {
+		en.requireTLS = *cfg.RequireTLS
+	}
+	if cfg.AuthPasswordFile != "" {
+		if en.authPassword != "" {
+			return nil, fmt.Errorf("auth_password and auth_password_file cannot be set simultaneously")
+		}
+		data, err := os.ReadFile(cfg.AuthPasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read auth_password_file: %w", err)
+		}
+		en.authPassword = strings.TrimSpace(string(data))
+	}
+	if en.subject == "" {
+		en.subject = defaultEmailSubject
+	}
+	if en.body == "" {
+		en.body = defaultEmailBody
+	}
+	if en.contentType == "" {
+		en.contentType = "text/plain; charset=UTF-8"
+	}
+	if err := validateNotificationTemplate(en.subject); err != nil {
+		return nil, fmt.Errorf("invalid subject: %w", err)
+	}
+	if err := validateNotificationTemplate(en.body); err != nil {
+		return nil, fmt.Errorf("invalid body: %w", err)
+	}
+	tlsCfg := &promauth.TLSConfig{}
+	if cfg.TLSConfig != nil {
+		tlsCfg = cfg.TLSConfig
+	}
+	en.tlsConfig, err = httputils.TLSConfig(tlsCfg.CertFile, tlsCfg.KeyFile, tlsCfg.CAFile, tlsCfg.ServerName, tlsCfg.InsecureSkipVerify)
+	if err != nil {
+		return nil, fmt.Errorf("cannot init tls_config: %w", err)
+	}
+	if en.tlsConfig.ServerName == "" {
+		en.tlsConfig.ServerName = host
+	}
+	addr := fmt.Sprintf("smtp://%s/%s", cfg.Smarthost, strings.Join(cfg.To, ","))
+	en.receiver = newReceiver("email", addr, cfg.ReceiverConfig, timeout, gen, relabelCfg, en.notify)
+	return en, nil
+}
+
+func (en *emailNotifier) notify(ctx context.Context, data *NotificationData) error {
+	subject, err := execNotificationTemplate(en.subject, data)
+	if err != nil {
+		return &permanentError{err}
+	}
+	body, err := execNotificationTemplate(en.body, data)
+	if err != nil {
+		return &permanentError{err}
+	}
+	msg, err := en.newMessage(subject, body)
+	if err != nil {
+		return &permanentError{err}
+	}
+	return en.sendMail(ctx, msg)
+}
+
+func (en *emailNotifier) newMessage(subject, body string) ([]byte, error) {
+	hdrs := map[string]string{
+		"From":    en.from,
+		"To":      strings.Join(en.to, ", "),
+		"Subject": mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject)),
+		"Date":    time.Now().Format(time.RFC1123Z),
+	}
+	for k, v := range en.headers {
+		hdrs[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+	hdrs["MIME-Version"] = "1.0"
+	hdrs["Content-Type"] = en.contentType
+	hdrs["Content-Transfer-Encoding"] = "quoted-printable"
+
+	keys := make([]string, 0, len(hdrs))
+	for k := range hdrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	for _, k := range keys {
+		v := hdrs[k]
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("header %q cannot contain line breaks", k)
+		}
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	b.WriteString("\r\n")
+	qw := quotedprintable.NewWriter(&b)
+	if _, err := qw.Write([]byte(body)); err != nil {
+		return nil, fmt.Errorf("cannot encode email body: %w", err)
+	}
+	if err := qw.Close(); err != nil {
+		return nil, fmt.Errorf("cannot encode email body: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func (en *emailNotifier) sendMail(ctx context.Context, msg []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", en.smarthost)
+	if err != nil {
+		return fmt.Errorf("cannot connect to %q: %w", en.smarthost, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if en.implicitTLS {
+		conn = tls.Client(conn, en.tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, en.host)
+	if err != nil {
+		return fmt.Errorf("cannot create SMTP client for %q: %w", en.smarthost, err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if en.hello != "" {
+		if err := c.Hello(en.hello); err != nil {
+			return fmt.Errorf("HELO failed: %w", err)
+		}
+	}
+	if !en.implicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(en.tlsConfig); err != nil {
+				return fmt.Errorf("STARTTLS failed: %w", err)
+			}
+		} else if en.requireTLS {
+			return &permanentError{fmt.Errorf("SMTP server %q doesn't support STARTTLS; set `require_tls: false` for sending emails without TLS", en.smarthost)}
+		}
+	}
+	if en.authUsername != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return &permanentError{fmt.Errorf("SMTP server %q doesn't support AUTH", en.smarthost)}
+		}
+		if err := c.Auth(smtp.PlainAuth("", en.authUsername, en.authPassword, en.host)); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	if err := c.Mail(en.fromAddr); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+	for _, to := range en.toAddrs {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("RCPT TO %q failed: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA failed: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("cannot write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("cannot send email: %w", err)
+	}
+	return c.Quit()
+}
@@ -0,0 +1,222 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpServer is a minimal SMTP server for testing emailNotifier
+type smtpServer struct {
+	ln         net.Listener
+	messagesCh chan string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot start SMTP server: %s", err)
+	}
+	s := &smtpServer{
+		ln:         ln,
+		messagesCh: make(chan string, 10),
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	tc := textproto.NewConn(conn)
+	_ = tc.PrintfLine("220 localhost ESMTP")
+	var envelope []string
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			_ = tc.PrintfLine("250 localhost")
+		case "MAIL", "RCPT":
+			envelope = append(envelope, line)
+			_ = tc.PrintfLine("250 OK")
+		case "DATA":
+			_ = tc.PrintfLine("354 Go ahead")
+			data, err := tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.messagesCh <- strings.Join(envelope, "\n") + "\n" + string(data)
+			envelope = nil
+			_ = tc.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tc.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tc.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) close() {
+	_ = s.ln.Close()
+}
+
+func TestEmailNotifier_Send(t *testing.T) {
+	srv := newSMTPServer(t)
+	defer srv.close()
+
+	requireTLS := false
+	en, err := newEmailNotifier(EmailConfig{
+		To:         []string{"Ops <ops@example.com>", "dev@example.com"},
+		From:       "vmalert@example.com",
+		Smarthost:  srv.ln.Addr().String(),
+		RequireTLS: &requireTLS,
+		Headers:    map[string]string{"x-priority": "1"},
+	}, nil, nil, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer en.Close()
+
+	a := newTestAlert(1, "foo", StateFiring)
+	a.Annotations = map[string]string{"summary": "foo is broken"}
+	if err := en.Send(context.Background(), []Alert{a}, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	msg := <-srv.messagesCh
+	for _, s := range []string{
+		"MAIL FROM:<vmalert@example.com>",
+		"RCPT TO:<ops@example.com>",
+		"RCPT TO:<dev@example.com>",
+		"From: vmalert@example.com\n",
+		"To: Ops <ops@example.com>, dev@example.com\n",
+		"Subject: [FIRING:1] foo\n",
+		"X-Priority: 1\n",
+		"Content-Type: text/plain; charset=UTF-8\n",
+		"[FIRING] foo\n",
+		"  summary: foo is broken\n",
+	} {
+		if !strings.Contains(msg, s) {
+			t.Fatalf("expecting message to contain %q; got\n%s", s, msg)
+		}
+	}
+}
+
+func TestEmailNotifier_RequireTLS(t *testing.T) {
+	srv := newSMTPServer(t)
+	defer srv.close()
+
+	en, err := newEmailNotifier(EmailConfig{
+		To:        []string{"ops@example.com"},
+		From:      "vmalert@example.com",
+		Smarthost: srv.ln.Addr().String(),
+	}, nil, nil, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer en.Close()
+
+	err = en.Send(context.Background(), []Alert{newTestAlert(1, "foo", StateFiring)}, nil)
+	if err == nil || !strings.Contains(err.Error(), "doesn't support STARTTLS") {
+		t.Fatalf("expecting STARTTLS error; got %v", err)
+	}
+}
+
+func TestEmailNotifier_Retry(t *testing.T) {
+	// reserve the address and close the listener, so the first attempts fail
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	requireTLS := false
+	maxRetries := 1
+	en, err := newEmailNotifier(EmailConfig{
+		To:         []string{"ops@example.com"},
+		From:       "vmalert@example.com",
+		Smarthost:  addr,
+		RequireTLS: &requireTLS,
+		ReceiverConfig: ReceiverConfig{
+			MaxRetries: &maxRetries,
+		},
+	}, nil, nil, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer en.Close()
+	en.retryBackoff = time.Millisecond
+
+	err = en.Send(context.Background(), []Alert{newTestAlert(1, "foo", StateFiring)}, nil)
+	if err == nil || !strings.Contains(err.Error(), "cannot connect") {
+		t.Fatalf("expecting connection error; got %v", err)
+	}
+}
+
+func TestNewEmailNotifier_Failure(t *testing.T) {
+	f := func(cfg EmailConfig, errExpected string) {
+		t.Helper()
+
+		_, err := newEmailNotifier(cfg, nil, nil, time.Second)
+		if err == nil || !strings.Contains(err.Error(), errExpected) {
+			t.Fatalf("expecting error containing %q; got %v", errExpected, err)
+		}
+	}
+
+	f(EmailConfig{From: "a@b"}, "to cannot be empty")
+	f(EmailConfig{To: []string{"a@b"}}, "from cannot be empty")
+	f(EmailConfig{To: []string{"a@b"}, From: "a@b", Smarthost: "localhost"}, "invalid smarthost")
+	f(EmailConfig{To: []string{"foo"}, From: "a@b", Smarthost: "localhost:25"}, "cannot parse to")
+	f(EmailConfig{To: []string{"a@b"}, From: "a@b", Smarthost: "localhost:25", Subject: "{{ .Status"}, "invalid subject")
+}
+
+func TestEmailNotifier_NewMessage(t *testing.T) {
+	en := &emailNotifier{
+		from:        "a@b",
+		to:          []string{"c@d"},
+		contentType: "text/plain",
+		headers:     map[string]string{"X-Foo": "bar\r\nBcc: e@f"},
+	}
+	_, err := en.newMessage("subject", "body")
+	if err == nil {
+		t.Fatalf("expecting error for header with line breaks")
+	}
+
+	en.headers = nil
+	msg, err := en.newMessage("Привет", "body")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(string(msg))))
+	hdr, err := r.ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("cannot parse message headers: %s", err)
+	}
+	if subj := hdr.Get("Subject"); !strings.HasPrefix(subj, "=?utf-8?q?") {
+		t.Fatalf("expecting encoded subject; got %q", subj)
+	}
+	if s := fmt.Sprintf("%s %s", hdr.Get("Mime-Version"), hdr.Get("Content-Transfer-Encoding")); s != "1.0 quoted-printable" {
+		t.Fatalf("unexpected headers: %s", s)
+	}
+}
@@ -0,0 +1,154 @@
+package notifier
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// InhibitRule mutes target alerts while there is at least one firing source alert
+// with the same values for labels listed in Equal.
+//
+// See https://prometheus.io/docs/alerting/latest/configuration/#inhibit_rule
+type InhibitRule struct {
+	// SourceMatchers must match firing alerts which inhibit target alerts
+	SourceMatchers Matchers `json:"source_matchers"`
+	// TargetMatchers must match alerts to be muted
+	TargetMatchers Matchers `json:"target_matchers"`
+	// Equal contains label names, which must have equal values
+	// in source and target alerts for inhibition to take effect
+	Equal []string `json:"equal,omitempty"`
+}
+
+// NewInhibitRule returns InhibitRule for the given source and target matchers.
+func NewInhibitRule(sourceMatchers, targetMatchers, equal []string) (*InhibitRule, error) {
+	if len(sourceMatchers) == 0 {
+		return nil, fmt.Errorf("source_matchers cannot be empty")
+	}
+	if len(targetMatchers) == 0 {
+		return nil, fmt.Errorf("target_matchers cannot be empty")
+	}
+	sms, err := ParseMatchers(sourceMatchers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source_matchers: %w", err)
+	}
+	tms, err := ParseMatchers(targetMatchers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target_matchers: %w", err)
+	}
+	return &InhibitRule{
+		SourceMatchers: sms,
+		TargetMatchers: tms,
+		Equal:          equal,
+	}, nil
+}
+
+func (ir *InhibitRule) isEqual(source, target map[string]string) bool {
+	for _, name := range ir.Equal {
+		if source[name] != target[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// firingAlert holds the data needed for checking whether the alert inhibits other alerts
+type firingAlert struct {
+	groupID uint64
+	id      uint64
+	labels  map[string]string
+}
+
+type firingAlertID struct {
+	groupID uint64
+	id      uint64
+}
+
+type firingAlertsKey struct {
+	groupID uint64
+	ruleID  uint64
+}
+
+// firingAlerts holds firing alerts for all the alerting rules,
+// since source alerts may belong to other groups than target alerts.
+var firingAlerts = struct {
+	mu sync.RWMutex
+	m  map[firingAlertsKey][]firingAlert
+}{
+	m: make(map[firingAlertsKey][]firingAlert),
+}
+
+// RegisterFiringAlerts registers firing alerts for the rule with the given groupID and ruleID,
+// so they could inhibit alerts of other rules.
+//
+// It replaces alerts registered for the rule during the previous call.
+func RegisterFiringAlerts(groupID, ruleID uint64, alerts []*Alert) {
+	var fas []firingAlert
+	for _, a := range alerts {
+		if a.State != StateFiring {
+			continue
+		}
+		fas = append(fas, firingAlert{
+			groupID: a.GroupID,
+			id:      a.ID,
+			labels:  a.Labels,
+		})
+	}
+
+	k := firingAlertsKey{
+		groupID: groupID,
+		ruleID:  ruleID,
+	}
+	firingAlerts.mu.Lock()
+	if len(fas) == 0 {
+		delete(firingAlerts.m, k)
+	} else {
+		firingAlerts.m[k] = fas
+	}
+	firingAlerts.mu.Unlock()
+}
+
+// UnregisterFiringAlerts removes firing alerts for the rule with the given groupID and ruleID.
+//
+// It must be called when the rule is removed.
+func UnregisterFiringAlerts(groupID, ruleID uint64) {
+	RegisterFiringAlerts(groupID, ruleID, nil)
+}
+
+// GetInhibitedBy returns IDs of firing alerts, which inhibit a according to irs.
+//
+// An alert cannot inhibit itself.
+func GetInhibitedBy(irs []*InhibitRule, a *Alert) []string {
+	if len(irs) == 0 {
+		return nil
+	}
+
+	firingAlerts.mu.RLock()
+	defer firingAlerts.mu.RUnlock()
+
+	var ids []string
+	seen := make(map[firingAlertID]bool)
+	for _, ir := range irs {
+		if !ir.TargetMatchers.Matches(a.Labels) {
+			continue
+		}
+		for _, fas := range firingAlerts.m {
+			for _, fa := range fas {
+				if fa.groupID == a.GroupID && fa.id == a.ID {
+					continue
+				}
+				k := firingAlertID{groupID: fa.groupID, id: fa.id}
+				if seen[k] {
+					continue
+				}
+				if !ir.SourceMatchers.Matches(fa.labels) || !ir.isEqual(fa.labels, a.Labels) {
+					continue
+				}
+				seen[k] = true
+				ids = append(ids, fmt.Sprintf("%d", fa.id))
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
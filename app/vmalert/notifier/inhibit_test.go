@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetInhibitedBy(t *testing.T) {
+	ir, err := NewInhibitRule([]string{`alertname="NodeDown"`}, []string{`severity="warning"`}, []string{"instance"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	irs := []*InhibitRule{ir}
+
+	source := &Alert{
+		GroupID: 1,
+		ID:      10,
+		State:   StateFiring,
+		Labels: map[string]string{
+			"alertname": "NodeDown",
+			"instance":  "host1",
+			"severity":  "warning",
+		},
+	}
+	RegisterFiringAlerts(1, 100, []*Alert{source})
+	defer UnregisterFiringAlerts(1, 100)
+
+	f := func(a *Alert, resultExpected []string) {
+		t.Helper()
+
+		result := GetInhibitedBy(irs, a)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result; got %q; want %q", result, resultExpected)
+		}
+	}
+
+	// target alert from another group with equal instance
+	f(&Alert{
+		GroupID: 2,
+		ID:      20,
+		Labels: map[string]string{
+			"alertname": "ServiceDown",
+			"instance":  "host1",
+			"severity":  "warning",
+		},
+	}, []string{"10"})
+
+	// different instance
+	f(&Alert{
+		GroupID: 2,
+		ID:      21,
+		Labels: map[string]string{
+			"alertname": "ServiceDown",
+			"instance":  "host2",
+			"severity":  "warning",
+		},
+	}, nil)
+
+	// target matchers do not match
+	f(&Alert{
+		GroupID: 2,
+		ID:      22,
+		Labels: map[string]string{
+			"alertname": "ServiceDown",
+			"instance":  "host1",
+			"severity":  "critical",
+		},
+	}, nil)
+
+	// alert cannot inhibit itself
+	f(source, nil)
+
+	// no inhibit rules
+	if result := GetInhibitedBy(nil, source); result != nil {
+		t.Fatalf("unexpected result; got %q; want nil", result)
+	}
+
+	// source alert is resolved
+	source.State = StateInactive
+	RegisterFiringAlerts(1, 100, []*Alert{source})
+	f(&Alert{
+		GroupID: 2,
+		ID:      20,
+		Labels: map[string]string{
+			"alertname": "ServiceDown",
+			"instance":  "host1",
+			"severity":  "warning",
+		},
+	}, nil)
+}
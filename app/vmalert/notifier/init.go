@@ -201,6 +201,12 @@ const (
 	TargetConsul TargetType = "consulSD"
 	// TargetDNS is for targets discovered via DNS
 	TargetDNS TargetType = "DNSSD"
+	// TargetWebhook is for webhook receivers configured via webhook_configs
+	TargetWebhook TargetType = "webhook"
+	// TargetSlack is for Slack receivers configured via slack_configs
+	TargetSlack TargetType = "slack"
+	// TargetEmail is for email receivers configured via email_configs
+	TargetEmail TargetType = "email"
 )
 
 // GetTargets returns list of static or discovered targets
@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matcher matches alert labels in the same way as Alertmanager matchers do.
+//
+// See https://prometheus.io/docs/alerting/latest/configuration/#matcher
+type Matcher struct {
+	// Name is the label name to match
+	Name string `json:"name"`
+	// Value is the label value or regular expression to match
+	Value string `json:"value"`
+	// IsRegex defines whether Value must be treated as a regular expression
+	IsRegex bool `json:"isRegex"`
+	// IsEqual defines whether the matcher must match labels with equal values.
+	// Otherwise, the matcher matches labels with non-equal values.
+	IsEqual bool `json:"isEqual"`
+
+	re *regexp.Regexp
+}
+
+// ParseMatcher parses Matcher from s in the form `name="value"`.
+//
+// Supported operators are `=`, `!=`, `=~` and `!~`. The value may be unquoted.
+func ParseMatcher(s string) (*Matcher, error) {
+	s = strings.TrimSpace(s)
+	n := strings.IndexAny(s, "=!")
+	if n <= 0 {
+		return nil, fmt.Errorf("missing operator in matcher %q; supported operators: =, !=, =~, !~", s)
+	}
+	m := &Matcher{
+		Name: strings.TrimSpace(s[:n]),
+	}
+	if !isValidLabelName(m.Name) {
+		return nil, fmt.Errorf("invalid label name %q in matcher %q", m.Name, s)
+	}
+	tail := s[n:]
+	switch {
+	case strings.HasPrefix(tail, "=~"):
+		m.IsRegex, m.IsEqual = true, true
+		tail = tail[2:]
+	case strings.HasPrefix(tail, "!~"):
+		m.IsRegex = true
+		tail = tail[2:]
+	case strings.HasPrefix(tail, "!="):
+		tail = tail[2:]
+	case strings.HasPrefix(tail, "="):
+		m.IsEqual = true
+		tail = tail[1:]
+	default:
+		return nil, fmt.Errorf("unsupported operator in matcher %q; supported operators: =, !=, =~, !~", s)
+	}
+	m.Value = strings.TrimSpace(tail)
+	if strings.HasPrefix(m.Value, `"`) {
+		v, err := strconv.Unquote(m.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot unquote value in matcher %q: %w", s, err)
+		}
+		m.Value = v
+	}
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Matcher) init() error {
+	if !m.IsRegex {
+		return nil
+	}
+	re, err := regexp.Compile("^(?:" + m.Value + ")$")
+	if err != nil {
+		return fmt.Errorf("cannot compile regexp %q for label %q: %w", m.Value, m.Name, err)
+	}
+	m.re = re
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+//
+// IsEqual is set to true if it is missing in data.
+func (m *Matcher) UnmarshalJSON(data []byte) error {
+	type matcher Matcher
+	mm := matcher{
+		IsEqual: true,
+	}
+	if err := json.Unmarshal(data, &mm); err != nil {
+		return err
+	}
+	*m = Matcher(mm)
+	if !isValidLabelName(m.Name) {
+		return fmt.Errorf("invalid label name %q in matcher", m.Name)
+	}
+	return m.init()
+}
+
+// Matches returns true if m matches the given labels.
+//
+// Missing labels are treated as labels with empty values.
+func (m *Matcher) Matches(labels map[string]string) bool {
+	v := labels[m.Name]
+	var ok bool
+	if m.IsRegex {
+		ok = m.re.MatchString(v)
+	} else {
+		ok = v == m.Value
+	}
+	return ok == m.IsEqual
+}
+
+// String returns string representation for m.
+func (m *Matcher) String() string {
+	op := "="
+	switch {
+	case m.IsRegex && m.IsEqual:
+		op = "=~"
+	case m.IsRegex:
+		op = "!~"
+	case !m.IsEqual:
+		op = "!="
+	}
+	return fmt.Sprintf("%s%s%q", m.Name, op, m.Value)
+}
+
+// Matchers is a list of matchers, which must match simultaneously.
+type Matchers []*Matcher
+
+// ParseMatchers parses matchers from ss. See ParseMatcher for details.
+func ParseMatchers(ss []string) (Matchers, error) {
+	ms := make(Matchers, 0, len(ss))
+	for _, s := range ss {
+		m, err := ParseMatcher(s)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// Matches returns true if all the ms match the given labels.
+func (ms Matchers) Matches(labels map[string]string) bool {
+	for _, m := range ms {
+		if !m.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func isValidLabelName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package notifier
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMatcher_Success(t *testing.T) {
+	f := func(s, resultExpected string) {
+		t.Helper()
+
+		m, err := ParseMatcher(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		result := m.String()
+		if result != resultExpected {
+			t.Fatalf("unexpected matcher; got %s; want %s", result, resultExpected)
+		}
+	}
+
+	f(`foo="bar"`, `foo="bar"`)
+	f(` foo = bar `, `foo="bar"`)
+	f(`foo!="bar"`, `foo!="bar"`)
+	f(`foo=~"bar|baz"`, `foo=~"bar|baz"`)
+	f(`foo!~".+"`, `foo!~".+"`)
+	f(`foo="a=b"`, `foo="a=b"`)
+	f(`foo=""`, `foo=""`)
+	f(`foo="\"quoted\""`, `foo="\"quoted\""`)
+}
+
+func TestParseMatcher_Failure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+
+		if _, err := ParseMatcher(s); err == nil {
+			t.Fatalf("expecting non-nil error for %q", s)
+		}
+	}
+
+	f(``)
+	f(`foo`)
+	f(`="bar"`)
+	f(`foo-bar="baz"`)
+	f(`foo<"bar"`)
+	f(`foo!bar`)
+	f(`foo="bar`)
+	f(`foo=~"bar(")`)
+}
+
+func TestMatchers_Matches(t *testing.T) {
+	f := func(ss []string, labels map[string]string, resultExpected bool) {
+		t.Helper()
+
+		ms, err := ParseMatchers(ss)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result := ms.Matches(labels); result != resultExpected {
+			t.Fatalf("unexpected result for matchers %q and labels %v; got %v; want %v", ss, labels, result, resultExpected)
+		}
+	}
+
+	labels := map[string]string{
+		"alertname": "NodeDown",
+		"severity":  "critical",
+	}
+
+	f(nil, labels, true)
+	f([]string{`alertname="NodeDown"`}, labels, true)
+	f([]string{`alertname="NodeDown"`, `severity="warning"`}, labels, false)
+	f([]string{`alertname!="NodeDown"`}, labels, false)
+	f([]string{`severity=~"crit.*"`}, labels, true)
+	f([]string{`severity=~"crit"`}, labels, false)
+	f([]string{`severity!~"warning|info"`}, labels, true)
+
+	// missing labels are treated as labels with empty values
+	f([]string{`instance=""`}, labels, true)
+	f([]string{`instance!=""`}, labels, false)
+	f([]string{`instance=~".*"`}, labels, true)
+}
+
+func TestMatcher_UnmarshalJSON(t *testing.T) {
+	f := func(data, resultExpected string) {
+		t.Helper()
+
+		var m Matcher
+		if err := json.Unmarshal([]byte(data), &m); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result := m.String(); result != resultExpected {
+			t.Fatalf("unexpected matcher; got %s; want %s", result, resultExpected)
+		}
+	}
+
+	f(`{"name":"foo","value":"bar"}`, `foo="bar"`)
+	f(`{"name":"foo","value":"bar","isEqual":false}`, `foo!="bar"`)
+	f(`{"name":"foo","value":"bar.+","isRegex":true}`, `foo=~"bar.+"`)
+	f(`{"name":"foo","value":"bar.+","isRegex":true,"isEqual":false}`, `foo!~"bar.+"`)
+
+	for _, data := range []string{
+		`{"name":"","value":"bar"}`,
+		`{"name":"foo","value":"bar(","isRegex":true}`,
+		`{"name":1}`,
+	} {
+		var m Matcher
+		if err := json.Unmarshal([]byte(data), &m); err == nil {
+			t.Fatalf("expecting non-nil error for %s", data)
+		}
+	}
+}
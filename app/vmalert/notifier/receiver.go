@@ -0,0 +1,485 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/templates"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+const (
+	defaultRepeatInterval = 4 * time.Hour
+	defaultMaxRetries     = 3
+	defaultRetryBackoff   = time.Second
+	maxRetryBackoff       = time.Minute
+)
+
+// ReceiverConfig contains common settings for receivers, which are notified directly by vmalert
+// without Alertmanager, such as webhook, Slack or email receivers.
+type ReceiverConfig struct {
+	// GroupBy contains the list of labels for grouping alerts into a single notification.
+	// All the alerts are grouped together if GroupBy is empty.
+	// The special value `...` disables grouping.
+	GroupBy []string `yaml:"group_by,omitempty"`
+	// RepeatInterval is the interval for re-sending notifications for the group of still firing alerts
+	RepeatInterval *promutils.Duration `yaml:"repeat_interval,omitempty"`
+	// SendResolved defines whether to send notifications about resolved alerts
+	SendResolved *bool `yaml:"send_resolved,omitempty"`
+	// MaxRetries is the maximum number of retries for failed notification
+	MaxRetries *int `yaml:"max_retries,omitempty"`
+	// RetryBackoff is the initial delay between retries. It is doubled after every retry
+	RetryBackoff *promutils.Duration `yaml:"retry_backoff,omitempty"`
+	// Timeout is the timeout for a single attempt of sending notification
+	Timeout *promutils.Duration `yaml:"timeout,omitempty"`
+}
+
+// NotificationData is the data passed to receivers' templates.
+//
+// Its JSON representation is compatible with Alertmanager webhook payload.
+// See https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type NotificationData struct {
+	Version           string             `json:"version"`
+	GroupKey          string             `json:"groupKey"`
+	Receiver          string             `json:"receiver"`
+	Status            string             `json:"status"`
+	Alerts            NotificationAlerts `json:"alerts"`
+	GroupLabels       map[string]string  `json:"groupLabels"`
+	CommonLabels      map[string]string  `json:"commonLabels"`
+	CommonAnnotations map[string]string  `json:"commonAnnotations"`
+	ExternalURL       string             `json:"externalURL"`
+	ExternalLabels    map[string]string  `json:"-"`
+}
+
+// NotificationAlert is a single alert in NotificationData
+type NotificationAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+	Value        float64           `json:"-"`
+}
+
+// NotificationAlerts is a list of alerts in NotificationData
+type NotificationAlerts []NotificationAlert
+
+// Firing returns firing alerts from nas.
+func (nas NotificationAlerts) Firing() []NotificationAlert {
+	return nas.withStatus("firing")
+}
+
+// Resolved returns resolved alerts from nas.
+func (nas NotificationAlerts) Resolved() []NotificationAlert {
+	return nas.withStatus("resolved")
+}
+
+func (nas NotificationAlerts) withStatus(status string) []NotificationAlert {
+	var result []NotificationAlert
+	for _, na := range nas {
+		if na.Status == status {
+			result = append(result, na)
+		}
+	}
+	return result
+}
+
+// notifyFunc must send the notification for data.
+//
+// It must return permanentError if the notification mustn't be retried.
+type notifyFunc func(ctx context.Context, data *NotificationData) error
+
+// permanentError is returned by notifyFunc if the notification cannot succeed on retry.
+type permanentError struct {
+	err error
+}
+
+func (pe *permanentError) Error() string {
+	return pe.err.Error()
+}
+
+func (pe *permanentError) Unwrap() error {
+	return pe.err
+}
+
+// receiver groups alerts and delivers notifications for every group of alerts via notify func.
+//
+// A notification for the group is sent when the group gets new firing alerts or resolved alerts,
+// or when repeatInterval passes since the last notification for the group.
+type receiver struct {
+	name    string
+	addr    string
+	notify  notifyFunc
+	argFunc AlertURLGenerator
+	// stores already parsed AlertRelabelConfigs object
+	relabelConfigs *promrelabel.ParsedConfigs
+
+	groupBy        []string
+	repeatInterval time.Duration
+	sendResolved   bool
+	maxRetries     int
+	retryBackoff   time.Duration
+	timeout        time.Duration
+
+	metrics *metrics
+
+	mu     sync.Mutex
+	groups map[string]*alertGroup
+}
+
+type alertKey struct {
+	groupID uint64
+	id      uint64
+}
+
+type alertGroup struct {
+	key    string
+	labels map[string]string
+
+	// sendMu prevents from concurrent notifications for the same group
+	sendMu sync.Mutex
+
+	// the fields below are protected by receiver.mu
+	alerts   map[alertKey]Alert
+	notified map[alertKey]struct{}
+	lastSent time.Time
+}
+
+func newReceiver(name, addr string, cfg ReceiverConfig, defaultTimeout time.Duration, gen AlertURLGenerator,
+	relabelCfg *promrelabel.ParsedConfigs, notify notifyFunc,
+) *receiver {
+	r := &receiver{
+		name:           name,
+		addr:           addr,
+		notify:         notify,
+		argFunc:        gen,
+		relabelConfigs: relabelCfg,
+		groupBy:        cfg.GroupBy,
+		repeatInterval: cfg.RepeatInterval.Duration(),
+		sendResolved:   true,
+		maxRetries:     defaultMaxRetries,
+		retryBackoff:   cfg.RetryBackoff.Duration(),
+		timeout:        cfg.Timeout.Duration(),
+		metrics:        newMetrics(addr),
+		groups:         make(map[string]*alertGroup),
+	}
+	if r.repeatInterval <= 0 {
+		r.repeatInterval = defaultRepeatInterval
+	}
+	if cfg.SendResolved != nil {
+		r.sendResolved = *cfg.SendResolved
+	}
+	if cfg.MaxRetries != nil && *cfg.MaxRetries >= 0 {
+		r.maxRetries = *cfg.MaxRetries
+	}
+	if r.retryBackoff <= 0 {
+		r.retryBackoff = defaultRetryBackoff
+	}
+	if r.timeout <= 0 {
+		r.timeout = defaultTimeout
+	}
+	return r
+}
+
+// Addr returns address where alerts are sent.
+func (r *receiver) Addr() string {
+	return r.addr
+}
+
+// Close is a destructor for the receiver
+func (r *receiver) Close() {
+	r.metrics.alertsSent.Unregister()
+	r.metrics.alertsSendErrors.Unregister()
+}
+
+// Send adds alerts to the corresponding groups and sends notifications
+// for groups with changes or with expired repeat interval.
+func (r *receiver) Send(ctx context.Context, alerts []Alert, _ map[string]string) error {
+	r.metrics.alertsSent.Add(len(alerts))
+	now := time.Now()
+
+	r.mu.Lock()
+	for _, a := range alerts {
+		r.addAlertLocked(a)
+	}
+	groups := make([]*alertGroup, 0, len(r.groups))
+	for _, g := range r.groups {
+		groups = append(groups, g)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].key < groups[j].key
+	})
+	var errs []error
+	for _, g := range groups {
+		if err := r.flushGroup(ctx, g, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *receiver) addAlertLocked(a Alert) {
+	lbls := a.applyRelabelingIfNeeded(r.relabelConfigs)
+	if len(lbls) == 0 {
+		return
+	}
+	a.Labels = make(map[string]string, len(lbls))
+	for _, l := range lbls {
+		a.Labels[l.Name] = l.Value
+	}
+
+	gk, groupLabels := r.groupKey(a)
+	g, ok := r.groups[gk]
+	if !ok {
+		if a.State != StateFiring {
+			// there is nothing to resolve
+			return
+		}
+		g = &alertGroup{
+			key:      gk,
+			labels:   groupLabels,
+			alerts:   make(map[alertKey]Alert),
+			notified: make(map[alertKey]struct{}),
+		}
+		r.groups[gk] = g
+	}
+	k := alertKey{groupID: a.GroupID, id: a.ID}
+	if a.State != StateFiring {
+		if _, ok := g.notified[k]; !ok {
+			// the alert wasn't notified as firing, so there is no need in notifying it as resolved
+			delete(g.alerts, k)
+			return
+		}
+	}
+	g.alerts[k] = a
+}
+
+func (r *receiver) groupKey(a Alert) (string, map[string]string) {
+	if len(r.groupBy) == 1 && r.groupBy[0] == "..." {
+		return fmt.Sprintf("%d/%d", a.GroupID, a.ID), a.Labels
+	}
+	groupLabels := make(map[string]string, len(r.groupBy))
+	var b strings.Builder
+	for _, name := range r.groupBy {
+		v := a.Labels[name]
+		if v != "" {
+			groupLabels[name] = v
+		}
+		fmt.Fprintf(&b, "%s=%q,", name, v)
+	}
+	return b.String(), groupLabels
+}
+
+// flushGroup sends notification for g if needed.
+func (r *receiver) flushGroup(ctx context.Context, g *alertGroup, now time.Time) error {
+	g.sendMu.Lock()
+	defer g.sendMu.Unlock()
+
+	r.mu.Lock()
+	var firing, resolved []Alert
+	changed := false
+	for k, a := range g.alerts {
+		if a.State == StateFiring && !a.End.IsZero() && a.End.Before(now) {
+			// the alert wasn't updated for too long, so it is considered as resolved
+			a.State = StateInactive
+			a.ResolvedAt = a.End
+			g.alerts[k] = a
+		}
+		if a.State == StateFiring {
+			if _, ok := g.notified[k]; !ok {
+				changed = true
+			}
+			firing = append(firing, a)
+			continue
+		}
+		if !r.sendResolved {
+			delete(g.alerts, k)
+			delete(g.notified, k)
+			continue
+		}
+		resolved = append(resolved, a)
+		changed = true
+	}
+	needRepeat := len(firing) > 0 && now.Sub(g.lastSent) >= r.repeatInterval
+	if !changed && !needRepeat {
+		if len(firing) == 0 {
+			r.deleteGroupLocked(g)
+		}
+		r.mu.Unlock()
+		return nil
+	}
+	data := r.newNotificationData(g, append(firing, resolved...))
+	r.mu.Unlock()
+
+	if err := r.notifyWithRetries(ctx, data); err != nil {
+		r.metrics.alertsSendErrors.Add(len(data.Alerts))
+		return fmt.Errorf("cannot send notification for group %s: %w", g.key, err)
+	}
+
+	r.mu.Lock()
+	g.lastSent = now
+	for _, a := range firing {
+		g.notified[alertKey{groupID: a.GroupID, id: a.ID}] = struct{}{}
+	}
+	for _, a := range resolved {
+		k := alertKey{groupID: a.GroupID, id: a.ID}
+		if prev, ok := g.alerts[k]; ok && prev.State != StateFiring {
+			delete(g.alerts, k)
+			delete(g.notified, k)
+		}
+	}
+	if len(g.alerts) == 0 {
+		r.deleteGroupLocked(g)
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *receiver) deleteGroupLocked(g *alertGroup) {
+	if r.groups[g.key] == g {
+		delete(r.groups, g.key)
+	}
+}
+
+func (r *receiver) notifyWithRetries(ctx context.Context, data *NotificationData) error {
+	backoff := r.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := r.notifyOnce(ctx, data)
+		if err == nil {
+			return nil
+		}
+		var pe *permanentError
+		if errors.As(err, &pe) || attempt >= r.maxRetries {
+			return err
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w; interrupted retries: %w", err, ctx.Err())
+		case <-t.C:
+		}
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+func (r *receiver) notifyOnce(ctx context.Context, data *NotificationData) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.notify(ctx, data)
+}
+
+func (r *receiver) newNotificationData(g *alertGroup, alerts []Alert) *NotificationData {
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Start.Equal(alerts[j].Start) {
+			return alerts[i].ID < alerts[j].ID
+		}
+		return alerts[i].Start.Before(alerts[j].Start)
+	})
+	data := &NotificationData{
+		Version:        "4",
+		GroupKey:       g.key,
+		Receiver:       r.name,
+		Status:         "resolved",
+		GroupLabels:    g.labels,
+		ExternalURL:    externalURL,
+		ExternalLabels: externalLabels,
+	}
+	for i, a := range alerts {
+		na := NotificationAlert{
+			Status:      "resolved",
+			Labels:      a.Labels,
+			Annotations: a.Annotations,
+			StartsAt:    a.Start,
+			EndsAt:      a.End,
+			Fingerprint: fmt.Sprintf("%016x", a.ID),
+			Value:       a.Value,
+		}
+		if a.State == StateFiring {
+			na.Status = "firing"
+			na.EndsAt = time.Time{}
+			data.Status = "firing"
+		} else if !a.ResolvedAt.IsZero() {
+			na.EndsAt = a.ResolvedAt
+		}
+		if r.argFunc != nil {
+			na.GeneratorURL = r.argFunc(a)
+		}
+		data.Alerts = append(data.Alerts, na)
+
+		if i == 0 {
+			data.CommonLabels = copyMap(a.Labels)
+			data.CommonAnnotations = copyMap(a.Annotations)
+			continue
+		}
+		intersectMap(data.CommonLabels, a.Labels)
+		intersectMap(data.CommonAnnotations, a.Annotations)
+	}
+	return data
+}
+
+func copyMap(m map[string]string) map[string]string {
+	dst := make(map[string]string, len(m))
+	for k, v := range m {
+		dst[k] = v
+	}
+	return dst
+}
+
+// intersectMap removes entries from dst, which are missing or have different values in src.
+func intersectMap(dst, src map[string]string) {
+	for k, v := range dst {
+		if src[k] != v {
+			delete(dst, k)
+		}
+	}
+}
+
+// validateNotificationTemplate checks whether text is a valid template for NotificationData.
+func validateNotificationTemplate(text string) error {
+	if text == "" {
+		return nil
+	}
+	tmpl, err := templates.GetWithFuncs(nil)
+	if err != nil {
+		return fmt.Errorf("error cloning template: %w", err)
+	}
+	if _, err := tmpl.Parse(text); err != nil {
+		return fmt.Errorf("cannot parse template %q: %w", text, err)
+	}
+	return nil
+}
+
+// execNotificationTemplate executes text template for the given data.
+//
+// Templates are parsed on every execution, so they could use templates
+// reloaded via -rule.templates.
+func execNotificationTemplate(text string, data *NotificationData) (string, error) {
+	tmpl, err := templates.GetWithFuncs(templates.FuncsWithQuery(nil))
+	if err != nil {
+		return "", fmt.Errorf("error cloning template: %w", err)
+	}
+	tmpl, err = tmpl.Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse template %q: %w", text, err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("cannot execute template %q: %w", text, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,243 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+type fakeNotify struct {
+	mu      sync.Mutex
+	calls   int
+	errs    []error
+	results []string
+}
+
+func (fn *fakeNotify) notify(_ context.Context, data *NotificationData) error {
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	fn.calls++
+	if len(fn.errs) > 0 {
+		err := fn.errs[0]
+		fn.errs = fn.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	var ss []string
+	for _, a := range data.Alerts {
+		ss = append(ss, fmt.Sprintf("%s:%s", a.Labels["alertname"], a.Status))
+	}
+	fn.results = append(fn.results, fmt.Sprintf("%s %s [%s]", data.Status, data.GroupKey, strings.Join(ss, ",")))
+	return nil
+}
+
+func (fn *fakeNotify) reset() []string {
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	results := fn.results
+	fn.results = nil
+	return results
+}
+
+func newTestAlert(id uint64, name string, state AlertState) Alert {
+	now := time.Now()
+	return Alert{
+		ID:     id,
+		Name:   name,
+		State:  state,
+		Labels: map[string]string{"alertname": name, "env": "prod"},
+		Start:  now,
+		End:    now.Add(time.Hour),
+	}
+}
+
+func TestReceiverGrouping(t *testing.T) {
+	fn := &fakeNotify{}
+	r := newReceiver("test", "test", ReceiverConfig{
+		GroupBy: []string{"alertname"},
+	}, time.Second, nil, nil, fn.notify)
+	defer r.Close()
+
+	f := func(alerts []Alert, resultExpected []string) {
+		t.Helper()
+
+		if err := r.Send(context.Background(), alerts, nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		result := fn.reset()
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected notifications\ngot\n%s\nwant\n%s", strings.Join(result, "\n"), strings.Join(resultExpected, "\n"))
+		}
+	}
+
+	// new alerts are notified in separate groups
+	f([]Alert{
+		newTestAlert(1, "foo", StateFiring),
+		newTestAlert(2, "bar", StateFiring),
+	}, []string{
+		`firing alertname="bar", [bar:firing]`,
+		`firing alertname="foo", [foo:firing]`,
+	})
+
+	// already notified alerts aren't notified until repeat interval
+	f([]Alert{
+		newTestAlert(1, "foo", StateFiring),
+		newTestAlert(2, "bar", StateFiring),
+	}, nil)
+
+	// new alert in the existing group triggers notification for the whole group
+	a := newTestAlert(3, "foo", StateFiring)
+	a.Start = a.Start.Add(time.Second)
+	f([]Alert{a}, []string{
+		`firing alertname="foo", [foo:firing,foo:firing]`,
+	})
+
+	// resolved alert is notified together with firing alerts
+	f([]Alert{newTestAlert(1, "foo", StateInactive)}, []string{
+		`firing alertname="foo", [foo:resolved,foo:firing]`,
+	})
+
+	// resolved alert is notified only once
+	f(nil, nil)
+
+	// the group without firing alerts is notified as resolved
+	f([]Alert{newTestAlert(2, "bar", StateInactive)}, []string{
+		`resolved alertname="bar", [bar:resolved]`,
+	})
+
+	// resolved alert, which wasn't notified as firing, isn't notified
+	f([]Alert{newTestAlert(4, "baz", StateInactive)}, nil)
+
+	// the alert, which isn't updated for too long, is notified as resolved
+	a = newTestAlert(3, "foo", StateFiring)
+	a.End = time.Now().Add(-time.Second)
+	f([]Alert{a}, []string{
+		`resolved alertname="foo", [foo:resolved]`,
+	})
+	if len(r.groups) != 0 {
+		t.Fatalf("expecting all the groups to be removed; got %d groups", len(r.groups))
+	}
+}
+
+func TestReceiverRepeatInterval(t *testing.T) {
+	fn := &fakeNotify{}
+	sendResolved := false
+	r := newReceiver("test", "test", ReceiverConfig{
+		GroupBy:        []string{"..."},
+		RepeatInterval: promutils.NewDuration(time.Nanosecond),
+		SendResolved:   &sendResolved,
+	}, time.Second, nil, nil, fn.notify)
+	defer r.Close()
+
+	alerts := []Alert{newTestAlert(1, "foo", StateFiring)}
+	for i := 0; i < 3; i++ {
+		if err := r.Send(context.Background(), alerts, nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if n := len(fn.reset()); n != 3 {
+		t.Fatalf("expecting 3 repeated notifications; got %d", n)
+	}
+
+	// resolved alerts aren't notified if send_resolved is false
+	alerts = []Alert{newTestAlert(1, "foo", StateInactive)}
+	if err := r.Send(context.Background(), alerts, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if results := fn.reset(); len(results) != 0 {
+		t.Fatalf("unexpected notifications: %s", results)
+	}
+}
+
+func TestReceiverRetries(t *testing.T) {
+	f := func(errs []error, callsExpected int, errExpected bool) {
+		t.Helper()
+
+		fn := &fakeNotify{errs: errs}
+		maxRetries := 2
+		r := newReceiver("test", "test", ReceiverConfig{
+			MaxRetries:   &maxRetries,
+			RetryBackoff: promutils.NewDuration(time.Millisecond),
+		}, time.Second, nil, nil, fn.notify)
+		defer r.Close()
+
+		err := r.Send(context.Background(), []Alert{newTestAlert(1, "foo", StateFiring)}, nil)
+		if (err != nil) != errExpected {
+			t.Fatalf("unexpected error: %v; want error: %v", err, errExpected)
+		}
+		if fn.calls != callsExpected {
+			t.Fatalf("unexpected number of calls; got %d; want %d", fn.calls, callsExpected)
+		}
+	}
+
+	tmpErr := fmt.Errorf("temporary error")
+
+	// success on the first attempt
+	f(nil, 1, false)
+
+	// success after retries
+	f([]error{tmpErr, tmpErr}, 3, false)
+
+	// retries are exhausted
+	f([]error{tmpErr, tmpErr, tmpErr}, 3, true)
+
+	// permanent errors aren't retried
+	f([]error{&permanentError{tmpErr}}, 1, true)
+}
+
+func TestReceiverRetriesCancel(t *testing.T) {
+	fn := &fakeNotify{errs: []error{fmt.Errorf("temporary error")}}
+	r := newReceiver("test", "test", ReceiverConfig{
+		RetryBackoff: promutils.NewDuration(time.Hour),
+	}, time.Second, nil, nil, fn.notify)
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := r.Send(ctx, []Alert{newTestAlert(1, "foo", StateFiring)}, nil)
+	if err == nil || !strings.Contains(err.Error(), "interrupted retries") {
+		t.Fatalf("expecting interrupted retries error; got %v", err)
+	}
+}
+
+func TestExecNotificationTemplate(t *testing.T) {
+	f := func(text, resultExpected string) {
+		t.Helper()
+
+		data := &NotificationData{
+			Status: "firing",
+			Alerts: NotificationAlerts{
+				{Status: "firing", Labels: map[string]string{"alertname": "foo"}},
+				{Status: "resolved", Labels: map[string]string{"alertname": "bar"}},
+			},
+			CommonLabels: map[string]string{"env": "prod"},
+		}
+		if err := validateNotificationTemplate(text); err != nil {
+			t.Fatalf("unexpected validation error: %s", err)
+		}
+		result, err := execNotificationTemplate(text, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result != resultExpected {
+			t.Fatalf("unexpected result; got %q; want %q", result, resultExpected)
+		}
+	}
+
+	f(`{{ .Status | toUpper }} {{ .CommonLabels.env }}`, "FIRING prod")
+	f(`{{ .Alerts.Firing | len }}/{{ .Alerts.Resolved | len }}`, "1/1")
+	f(`{{ range .Alerts }}{{ .Labels.alertname }};{{ end }}`, "foo;bar;")
+
+	if err := validateNotificationTemplate("{{ .Status "); err == nil {
+		t.Fatalf("expecting non-nil error for invalid template")
+	}
+}
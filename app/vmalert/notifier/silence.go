@@ -0,0 +1,336 @@
+package notifier
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/utils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+var (
+	silencesPath = flag.String("notifier.silencesPath", "", "Path to the file for persisting silences created via /api/v1/silences API. "+
+		"Silences are kept only in memory and are lost on restart if this flag isn't set. "+
+		"See https://docs.victoriametrics.com/vmalert/#silences")
+	silencesRetention = flag.Duration("notifier.silencesRetention", 5*24*time.Hour, "Duration for keeping expired silences before deleting them. "+
+		"See https://docs.victoriametrics.com/vmalert/#silences")
+)
+
+// Silence mutes alerts matching Matchers during the [StartsAt ... EndsAt) time range.
+type Silence struct {
+	// ID is the unique identifier of the Silence
+	ID string `json:"id"`
+	// Matchers must match alert labels for muting the alert
+	Matchers Matchers `json:"matchers"`
+	// StartsAt is the time when the Silence becomes active
+	StartsAt time.Time `json:"startsAt"`
+	// EndsAt is the time when the Silence expires
+	EndsAt time.Time `json:"endsAt"`
+	// UpdatedAt is the time of the last Silence update
+	UpdatedAt time.Time `json:"updatedAt"`
+	// CreatedBy contains the author of the Silence
+	CreatedBy string `json:"createdBy"`
+	// Comment contains the reason for the Silence
+	Comment string `json:"comment"`
+}
+
+// SilenceState is the state of the Silence at the given time
+type SilenceState string
+
+const (
+	// SilenceStatePending is the state of the Silence, which isn't active yet
+	SilenceStatePending SilenceState = "pending"
+	// SilenceStateActive is the state of the Silence, which mutes matching alerts
+	SilenceStateActive SilenceState = "active"
+	// SilenceStateExpired is the state of the Silence, which has been expired
+	SilenceStateExpired SilenceState = "expired"
+)
+
+// State returns the state of s at the given time.
+func (s *Silence) State(now time.Time) SilenceState {
+	if now.Before(s.StartsAt) {
+		return SilenceStatePending
+	}
+	if now.Before(s.EndsAt) {
+		return SilenceStateActive
+	}
+	return SilenceStateExpired
+}
+
+func (s *Silence) validate() error {
+	if len(s.Matchers) == 0 {
+		return fmt.Errorf("matchers cannot be empty")
+	}
+	matchesEmpty := true
+	for _, m := range s.Matchers {
+		if m == nil {
+			return fmt.Errorf("matchers cannot contain null values")
+		}
+		if !m.Matches(nil) {
+			matchesEmpty = false
+		}
+	}
+	if matchesEmpty {
+		// Prevent from accidental muting of all the alerts
+		return fmt.Errorf("at least one matcher must not match empty label values")
+	}
+	if s.EndsAt.IsZero() {
+		return fmt.Errorf("endsAt must be set")
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("endsAt=%s must be bigger than startsAt=%s", s.EndsAt.Format(time.RFC3339), s.StartsAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+type silencesStorage struct {
+	mu       sync.Mutex
+	path     string
+	silences map[string]*Silence
+}
+
+var silences = &silencesStorage{
+	silences: make(map[string]*Silence),
+}
+
+var (
+	_ = utils.GetOrCreateGauge(`vmalert_silences{state="active"}`, func() float64 {
+		return float64(silences.count(SilenceStateActive))
+	})
+	_ = utils.GetOrCreateGauge(`vmalert_silences{state="pending"}`, func() float64 {
+		return float64(silences.count(SilenceStatePending))
+	})
+)
+
+// InitSilences loads silences from -notifier.silencesPath file if it is set.
+func InitSilences() error {
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+
+	silences.path = *silencesPath
+	silences.silences = make(map[string]*Silence)
+	if silences.path == "" || !fs.IsPathExist(silences.path) {
+		return nil
+	}
+	data, err := os.ReadFile(silences.path)
+	if err != nil {
+		return fmt.Errorf("cannot read silences: %w", err)
+	}
+	var ss []*Silence
+	if err := json.Unmarshal(data, &ss); err != nil {
+		return fmt.Errorf("cannot parse silences from %q: %w", silences.path, err)
+	}
+	for _, s := range ss {
+		silences.silences[s.ID] = s
+	}
+	logger.Infof("loaded %d silences from %q", len(ss), silences.path)
+	return nil
+}
+
+// AddSilence adds s to the list of silences and returns its ID.
+//
+// If s.ID is set, then the existing silence with this ID is updated.
+func AddSilence(s *Silence) (string, error) {
+	now := time.Now()
+	if s.StartsAt.IsZero() || s.StartsAt.Before(now) {
+		s.StartsAt = now
+	}
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+	s.UpdatedAt = now
+
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+
+	if s.ID != "" {
+		prev, ok := silences.silences[s.ID]
+		if !ok {
+			return "", fmt.Errorf("cannot find silence with id %q", s.ID)
+		}
+		if prev.State(now) == SilenceStateExpired {
+			return "", fmt.Errorf("cannot update expired silence with id %q", s.ID)
+		}
+		if prev.State(now) == SilenceStateActive {
+			// Active silence keeps its start time.
+			s.StartsAt = prev.StartsAt
+		}
+	} else {
+		s.ID = newSilenceID()
+	}
+	prev := silences.silences[s.ID]
+	silences.silences[s.ID] = s
+	if err := silences.saveLocked(now); err != nil {
+		if prev != nil {
+			silences.silences[s.ID] = prev
+		} else {
+			delete(silences.silences, s.ID)
+		}
+		return "", err
+	}
+	return s.ID, nil
+}
+
+// ExpireSilence expires the silence with the given id.
+func ExpireSilence(id string) error {
+	now := time.Now()
+
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+
+	prev, ok := silences.silences[id]
+	if !ok {
+		return fmt.Errorf("cannot find silence with id %q", id)
+	}
+	if prev.State(now) == SilenceStateExpired {
+		return nil
+	}
+	s := *prev
+	if s.StartsAt.After(now) {
+		s.StartsAt = now
+	}
+	s.EndsAt = now
+	s.UpdatedAt = now
+	silences.silences[id] = &s
+	if err := silences.saveLocked(now); err != nil {
+		silences.silences[id] = prev
+		return err
+	}
+	return nil
+}
+
+// GetSilence returns silence with the given id.
+func GetSilence(id string) (*Silence, bool) {
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+
+	s, ok := silences.silences[id]
+	return s, ok
+}
+
+// ListSilences returns all the silences sorted by their end time.
+func ListSilences() []*Silence {
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+
+	ss := make([]*Silence, 0, len(silences.silences))
+	for _, s := range silences.silences {
+		ss = append(ss, s)
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		if !ss[i].EndsAt.Equal(ss[j].EndsAt) {
+			return ss[i].EndsAt.Before(ss[j].EndsAt)
+		}
+		return ss[i].ID < ss[j].ID
+	})
+	return ss
+}
+
+// GetSilencedBy returns IDs of silences, which are active at the given time and match labels.
+func GetSilencedBy(labels map[string]string, now time.Time) []string {
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+
+	var ids []string
+	for _, s := range silences.silences {
+		if s.State(now) != SilenceStateActive {
+			continue
+		}
+		if s.Matchers.Matches(labels) {
+			ids = append(ids, s.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (ss *silencesStorage) count(state SilenceState) int {
+	now := time.Now()
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	n := 0
+	for _, s := range ss.silences {
+		if s.State(now) == state {
+			n++
+		}
+	}
+	return n
+}
+
+// saveLocked removes silences expired more than -notifier.silencesRetention ago
+// and writes the rest of silences to ss.path if it is set.
+func (ss *silencesStorage) saveLocked(now time.Time) error {
+	list := make([]*Silence, 0, len(ss.silences))
+	for id, s := range ss.silences {
+		if now.Sub(s.EndsAt) > *silencesRetention {
+			delete(ss.silences, id)
+			continue
+		}
+		list = append(list, s)
+	}
+	if ss.path == "" {
+		return nil
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("cannot marshal silences: %w", err)
+	}
+	if err := writeFileAtomic(ss.path, data); err != nil {
+		return fmt.Errorf("%w: %w", ErrSilencesPersist, err)
+	}
+	return nil
+}
+
+// ErrSilencesPersist is returned when silences cannot be written to -notifier.silencesPath.
+var ErrSilencesPersist = errors.New("cannot persist silences")
+
+// writeFileAtomic writes data to a temporary file and then atomically renames it to path,
+// so path contains either the previous or the new contents if vmalert crashes in the middle of the write.
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("cannot create %q: %w", tmpPath, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("cannot write silences to %q: %w", tmpPath, err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("cannot sync %q: %w", tmpPath, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("cannot close %q: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("cannot move %q to %q: %w", tmpPath, path, err)
+	}
+	return nil
+}
+
+func newSilenceID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		logger.Panicf("BUG: cannot generate random silence id: %s", err)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,169 @@
+package notifier
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSilences(t *testing.T) {
+	path := "silences-test.json"
+	defer func() {
+		_ = os.Remove(path)
+		*silencesPath = ""
+		if err := InitSilences(); err != nil {
+			t.Fatalf("cannot reset silences: %s", err)
+		}
+	}()
+	*silencesPath = path
+	if err := InitSilences(); err != nil {
+		t.Fatalf("cannot init silences: %s", err)
+	}
+
+	matchers, err := ParseMatchers([]string{`alertname="NodeDown"`})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	now := time.Now()
+	id, err := AddSilence(&Silence{
+		Matchers:  matchers,
+		EndsAt:    now.Add(time.Hour),
+		CreatedBy: "test",
+		Comment:   "maintenance",
+	})
+	if err != nil {
+		t.Fatalf("cannot add silence: %s", err)
+	}
+	pendingID, err := AddSilence(&Silence{
+		Matchers: matchers,
+		StartsAt: now.Add(time.Hour),
+		EndsAt:   now.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("cannot add silence: %s", err)
+	}
+
+	f := func(labels map[string]string, ts time.Time, resultExpected []string) {
+		t.Helper()
+
+		result := GetSilencedBy(labels, ts)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected silences; got %q; want %q", result, resultExpected)
+		}
+	}
+	f(map[string]string{"alertname": "NodeDown"}, now.Add(time.Minute), []string{id})
+	f(map[string]string{"alertname": "ServiceDown"}, now.Add(time.Minute), nil)
+	f(map[string]string{"alertname": "NodeDown"}, now.Add(90*time.Minute), []string{pendingID})
+	f(map[string]string{"alertname": "NodeDown"}, now.Add(3*time.Hour), nil)
+
+	// verify silences are loaded from disk
+	if err := InitSilences(); err != nil {
+		t.Fatalf("cannot load silences: %s", err)
+	}
+	if n := len(ListSilences()); n != 2 {
+		t.Fatalf("unexpected number of loaded silences; got %d; want 2", n)
+	}
+	f(map[string]string{"alertname": "NodeDown"}, now.Add(time.Minute), []string{id})
+
+	// expire the silence
+	if err := ExpireSilence(id); err != nil {
+		t.Fatalf("cannot expire silence: %s", err)
+	}
+	s, ok := GetSilence(id)
+	if !ok {
+		t.Fatalf("cannot find expired silence")
+	}
+	if state := s.State(time.Now()); state != SilenceStateExpired {
+		t.Fatalf("unexpected silence state; got %q; want %q", state, SilenceStateExpired)
+	}
+	if err := ExpireSilence("missing"); err == nil {
+		t.Fatalf("expecting non-nil error when expiring missing silence")
+	}
+
+	// expired silence cannot be updated
+	if _, err := AddSilence(&Silence{ID: id, Matchers: matchers, EndsAt: now.Add(time.Hour)}); err == nil {
+		t.Fatalf("expecting non-nil error when updating expired silence")
+	}
+}
+
+func TestAddSilence_Failure(t *testing.T) {
+	f := func(s *Silence) {
+		t.Helper()
+
+		if _, err := AddSilence(s); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	mustParseMatchers := func(ss ...string) Matchers {
+		t.Helper()
+		ms, err := ParseMatchers(ss)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return ms
+	}
+
+	now := time.Now()
+
+	// missing matchers
+	f(&Silence{EndsAt: now.Add(time.Hour)})
+
+	// matchers match all the alerts
+	f(&Silence{Matchers: mustParseMatchers(`foo=~".*"`), EndsAt: now.Add(time.Hour)})
+
+	// missing endsAt
+	f(&Silence{Matchers: mustParseMatchers(`foo="bar"`)})
+
+	// endsAt in the past
+	f(&Silence{Matchers: mustParseMatchers(`foo="bar"`), EndsAt: now.Add(-time.Hour)})
+
+	// missing silence
+	f(&Silence{ID: "missing", Matchers: mustParseMatchers(`foo="bar"`), EndsAt: now.Add(time.Hour)})
+}
+
+func TestSilences_PersistError(t *testing.T) {
+	defer func() {
+		*silencesPath = ""
+		if err := InitSilences(); err != nil {
+			t.Fatalf("cannot reset silences: %s", err)
+		}
+	}()
+	if err := InitSilences(); err != nil {
+		t.Fatalf("cannot init silences: %s", err)
+	}
+
+	matchers, err := ParseMatchers([]string{`alertname="NodeDown"`})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	now := time.Now()
+	id, err := AddSilence(&Silence{
+		Matchers: matchers,
+		EndsAt:   now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("cannot add silence: %s", err)
+	}
+
+	// Silences cannot be written to a file in missing directory, so modifications must be rolled back.
+	silences.mu.Lock()
+	silences.path = "missing-dir/silences.json"
+	silences.mu.Unlock()
+
+	if _, err := AddSilence(&Silence{Matchers: matchers, EndsAt: now.Add(time.Hour)}); !errors.Is(err, ErrSilencesPersist) {
+		t.Fatalf("unexpected error when adding silence; got %v; want %v", err, ErrSilencesPersist)
+	}
+	if err := ExpireSilence(id); !errors.Is(err, ErrSilencesPersist) {
+		t.Fatalf("unexpected error when expiring silence; got %v; want %v", err, ErrSilencesPersist)
+	}
+	ss := ListSilences()
+	if len(ss) != 1 {
+		t.Fatalf("unexpected number of silences; got %d; want 1", len(ss))
+	}
+	if state := ss[0].State(time.Now()); state != SilenceStateActive {
+		t.Fatalf("unexpected silence state; got %q; want %q", state, SilenceStateActive)
+	}
+}
@@ -0,0 +1,163 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+)
+
+const (
+	defaultSlackTitle = `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ .Alerts.Firing | len }}{{ end }}] {{ .CommonLabels.alertname }}`
+	defaultSlackText  = `{{ range .Alerts }}*{{ .Status | toUpper }}* {{ .Labels.alertname }}{{ if .Annotations.summary }}: {{ .Annotations.summary }}{{ end }}
+{{ if .Annotations.description }}{{ .Annotations.description }}
+{{ end }}{{ end }}`
+)
+
+// SlackConfig contains settings for sending notifications to Slack-compatible incoming webhooks.
+//
+// See https://api.slack.com/messaging/webhooks
+type SlackConfig struct {
+	// WebhookURL is the incoming webhook URL
+	WebhookURL *promauth.Secret `yaml:"webhook_url"`
+	// Channel overrides the default channel of the webhook
+	Channel string `yaml:"channel,omitempty"`
+	// Username overrides the default username of the webhook
+	Username string `yaml:"username,omitempty"`
+	// IconEmoji overrides the default icon of the webhook
+	IconEmoji string `yaml:"icon_emoji,omitempty"`
+	// IconURL overrides the default icon of the webhook
+	IconURL string `yaml:"icon_url,omitempty"`
+	// Title is the template for the message title
+	Title string `yaml:"title,omitempty"`
+	// TitleLink is the template for the link in the message title
+	TitleLink string `yaml:"title_link,omitempty"`
+	// Text is the template for the message text
+	Text string `yaml:"text,omitempty"`
+	// HTTPClientConfig contains HTTP configuration for the Slack client
+	HTTPClientConfig promauth.HTTPClientConfig `yaml:",inline"`
+	// ReceiverConfig contains grouping and retry settings
+	ReceiverConfig `yaml:",inline"`
+}
+
+type slackNotifier struct {
+	*receiver
+
+	url     *url.URL
+	client  *http.Client
+	authCfg *promauth.Config
+
+	channel   string
+	username  string
+	iconEmoji string
+	iconURL   string
+	title     string
+	titleLink string
+	text      string
+}
+
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	IconEmoji   string            `json:"icon_emoji,omitempty"`
+	IconURL     string            `json:"icon_url,omitempty"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color     string   `json:"color,omitempty"`
+	Fallback  string   `json:"fallback"`
+	Title     string   `json:"title,omitempty"`
+	TitleLink string   `json:"title_link,omitempty"`
+	Text      string   `json:"text"`
+	MrkdwnIn  []string `json:"mrkdwn_in,omitempty"`
+}
+
+func newSlackNotifier(cfg SlackConfig, gen AlertURLGenerator, relabelCfg *promrelabel.ParsedConfigs, timeout time.Duration) (*slackNotifier, error) {
+	webhookURL := cfg.WebhookURL.String()
+	if webhookURL == "" {
+		return nil, fmt.Errorf("webhook_url cannot be empty")
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse webhook_url: %w", err)
+	}
+	sn := &slackNotifier{
+		url:       u,
+		channel:   cfg.Channel,
+		username:  cfg.Username,
+		iconEmoji: cfg.IconEmoji,
+		iconURL:   cfg.IconURL,
+		title:     cfg.Title,
+		titleLink: cfg.TitleLink,
+		text:      cfg.Text,
+	}
+	if sn.title == "" {
+		sn.title = defaultSlackTitle
+	}
+	if sn.text == "" {
+		sn.text = defaultSlackText
+	}
+	for name, text := range map[string]string{"title": sn.title, "title_link": sn.titleLink, "text": sn.text} {
+		if err := validateNotificationTemplate(text); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	sn.client, sn.authCfg, err = newHTTPClient(webhookURL, cfg.HTTPClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init client for slack: %w", err)
+	}
+	// webhook URL path contains the secret token, so it is shown only if -notifier.showURL is set
+	addr := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	if *showNotifierURL {
+		addr = u.String()
+	}
+	sn.receiver = newReceiver("slack", addr, cfg.ReceiverConfig, timeout, gen, relabelCfg, sn.notify)
+	return sn, nil
+}
+
+func (sn *slackNotifier) notify(ctx context.Context, data *NotificationData) error {
+	title, err := execNotificationTemplate(sn.title, data)
+	if err != nil {
+		return &permanentError{err}
+	}
+	text, err := execNotificationTemplate(sn.text, data)
+	if err != nil {
+		return &permanentError{err}
+	}
+	var titleLink string
+	if sn.titleLink != "" {
+		titleLink, err = execNotificationTemplate(sn.titleLink, data)
+		if err != nil {
+			return &permanentError{err}
+		}
+	}
+	color := "danger"
+	if data.Status == "resolved" {
+		color = "good"
+	}
+	msg := &slackMessage{
+		Channel:   sn.channel,
+		Username:  sn.username,
+		IconEmoji: sn.iconEmoji,
+		IconURL:   sn.iconURL,
+		Attachments: []slackAttachment{{
+			Color:     color,
+			Fallback:  title,
+			Title:     title,
+			TitleLink: titleLink,
+			Text:      text,
+			MrkdwnIn:  []string{"text"},
+		}},
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return &permanentError{fmt.Errorf("cannot marshal slack message: %w", err)}
+	}
+	return postNotification(ctx, sn.client, sn.authCfg, sn.url.String(), sn.Addr(), "application/json", body)
+}
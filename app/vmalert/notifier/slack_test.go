@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+)
+
+func TestSlackNotifier_Send(t *testing.T) {
+	msgCh := make(chan slackMessage, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/services/T000/B000/XXX" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		var msg slackMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("cannot decode slack message: %s", err)
+		}
+		msgCh <- msg
+	}))
+	defer srv.Close()
+
+	sn, err := newSlackNotifier(SlackConfig{
+		WebhookURL: promauth.NewSecret(srv.URL + "/services/T000/B000/XXX"),
+		Channel:    "#alerts",
+	}, nil, nil, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sn.Close()
+	if strings.Contains(sn.Addr(), "XXX") {
+		t.Fatalf("address mustn't contain webhook token; got %q", sn.Addr())
+	}
+
+	a := newTestAlert(1, "foo", StateFiring)
+	a.Annotations = map[string]string{"summary": "foo is broken"}
+	if err := sn.Send(context.Background(), []Alert{a}, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	msg := <-msgCh
+	if msg.Channel != "#alerts" || len(msg.Attachments) != 1 {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	att := msg.Attachments[0]
+	if att.Color != "danger" || att.Title != "[FIRING:1] foo" || !strings.Contains(att.Text, "*FIRING* foo: foo is broken") {
+		t.Fatalf("unexpected attachment: %+v", att)
+	}
+
+	a.State = StateInactive
+	if err := sn.Send(context.Background(), []Alert{a}, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	msg = <-msgCh
+	att = msg.Attachments[0]
+	if att.Color != "good" || att.Title != "[RESOLVED] foo" {
+		t.Fatalf("unexpected attachment: %+v", att)
+	}
+}
+
+func TestNewSlackNotifier_Failure(t *testing.T) {
+	f := func(cfg SlackConfig, errExpected string) {
+		t.Helper()
+
+		_, err := newSlackNotifier(cfg, nil, nil, time.Second)
+		if err == nil || !strings.Contains(err.Error(), errExpected) {
+			t.Fatalf("expecting error containing %q; got %v", errExpected, err)
+		}
+	}
+
+	f(SlackConfig{}, "webhook_url cannot be empty")
+	f(SlackConfig{WebhookURL: promauth.NewSecret("http://localhost"), Text: "{{ .Status"}, "invalid text")
+}
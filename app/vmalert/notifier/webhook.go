@@ -0,0 +1,127 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+)
+
+// WebhookConfig contains settings for sending notifications to generic webhook receivers.
+//
+// By default, the notification is sent as JSON in Alertmanager webhook format.
+// See https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type WebhookConfig struct {
+	// URL is the address for sending notifications to
+	URL string `yaml:"url"`
+	// BodyTemplate is an optional template for the request body.
+	// NotificationData is marshaled to JSON if BodyTemplate is empty.
+	BodyTemplate string `yaml:"body_template,omitempty"`
+	// ContentType is the Content-Type header for the request body
+	ContentType string `yaml:"content_type,omitempty"`
+	// HTTPClientConfig contains HTTP configuration for the webhook client
+	HTTPClientConfig promauth.HTTPClientConfig `yaml:",inline"`
+	// ReceiverConfig contains grouping and retry settings
+	ReceiverConfig `yaml:",inline"`
+}
+
+type webhookNotifier struct {
+	*receiver
+
+	url     *url.URL
+	client  *http.Client
+	authCfg *promauth.Config
+
+	bodyTemplate string
+	contentType  string
+}
+
+func newWebhookNotifier(cfg WebhookConfig, gen AlertURLGenerator, relabelCfg *promrelabel.ParsedConfigs, timeout time.Duration) (*webhookNotifier, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url cannot be empty")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse url: %w", err)
+	}
+	if err := validateNotificationTemplate(cfg.BodyTemplate); err != nil {
+		return nil, fmt.Errorf("invalid body_template: %w", err)
+	}
+	client, authCfg, err := newHTTPClient(cfg.URL, cfg.HTTPClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init client for webhook URL=%q: %w", u.Redacted(), err)
+	}
+	wn := &webhookNotifier{
+		url:          u,
+		client:       client,
+		authCfg:      authCfg,
+		bodyTemplate: cfg.BodyTemplate,
+		contentType:  cfg.ContentType,
+	}
+	if wn.contentType == "" {
+		wn.contentType = "application/json"
+	}
+	addr := u.Redacted()
+	if *showNotifierURL {
+		addr = u.String()
+	}
+	wn.receiver = newReceiver("webhook", addr, cfg.ReceiverConfig, timeout, gen, relabelCfg, wn.notify)
+	return wn, nil
+}
+
+func (wn *webhookNotifier) notify(ctx context.Context, data *NotificationData) error {
+	var body []byte
+	if wn.bodyTemplate == "" {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return &permanentError{fmt.Errorf("cannot marshal notification: %w", err)}
+		}
+		body = b
+	} else {
+		s, err := execNotificationTemplate(wn.bodyTemplate, data)
+		if err != nil {
+			return &permanentError{err}
+		}
+		body = []byte(s)
+	}
+	return postNotification(ctx, wn.client, wn.authCfg, wn.url.String(), wn.Addr(), wn.contentType, body)
+}
+
+// postNotification sends body to the given reqURL.
+//
+// It returns permanentError if the request cannot succeed on retry.
+func postNotification(ctx context.Context, client *http.Client, authCfg *promauth.Config, reqURL, addr, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", contentType)
+	if authCfg != nil {
+		if err := authCfg.SetHeaders(req, true); err != nil {
+			return err
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4*1024))
+	err = fmt.Errorf("invalid SC %d from %q; response body: %s", resp.StatusCode, addr, respBody)
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout {
+		return &permanentError{err}
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func TestWebhookNotifier_Send(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "foo" || pass != "bar" {
+			t.Errorf("unexpected basic auth %q:%q", user, pass)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected Content-Type %q", ct)
+		}
+		var data NotificationData
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			t.Errorf("cannot decode notification: %s", err)
+		}
+		if data.Version != "4" || data.Receiver != "webhook" || data.Status != "firing" {
+			t.Errorf("unexpected notification: %+v", data)
+		}
+		if len(data.Alerts) != 1 || data.Alerts[0].GeneratorURL != "http://vmalert/alert/1" {
+			t.Errorf("unexpected alerts: %+v", data.Alerts)
+		}
+		if data.CommonLabels["alertname"] != "foo" || data.GroupLabels["env"] != "prod" {
+			t.Errorf("unexpected labels: common=%v, group=%v", data.CommonLabels, data.GroupLabels)
+		}
+	}))
+	defer srv.Close()
+
+	wn, err := newWebhookNotifier(WebhookConfig{
+		URL: srv.URL,
+		HTTPClientConfig: promauth.HTTPClientConfig{
+			BasicAuth: &promauth.BasicAuthConfig{
+				Username: "foo",
+				Password: promauth.NewSecret("bar"),
+			},
+		},
+		ReceiverConfig: ReceiverConfig{
+			GroupBy:      []string{"env"},
+			RetryBackoff: promutils.NewDuration(time.Millisecond),
+		},
+	}, func(_ Alert) string { return "http://vmalert/alert/1" }, nil, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer wn.Close()
+
+	if err := wn.Send(context.Background(), []Alert{newTestAlert(1, "foo", StateFiring)}, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expecting 2 requests to webhook; got %d", n)
+	}
+}
+
+func TestWebhookNotifier_BodyTemplate(t *testing.T) {
+	bodyCh := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodyCh <- r.Header.Get("Content-Type") + " " + string(b)
+	}))
+	defer srv.Close()
+
+	wn, err := newWebhookNotifier(WebhookConfig{
+		URL:          srv.URL,
+		ContentType:  "text/plain",
+		BodyTemplate: `{{ .Status }}: {{ range .Alerts }}{{ .Labels.alertname }}{{ end }}`,
+	}, nil, nil, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer wn.Close()
+	if err := wn.Send(context.Background(), []Alert{newTestAlert(1, "foo", StateFiring)}, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if body := <-bodyCh; body != "text/plain firing: foo" {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	// 4xx responses aren't retried
+	wn, err = newWebhookNotifier(WebhookConfig{
+		URL: srv.URL + "/bad",
+		ReceiverConfig: ReceiverConfig{
+			RetryBackoff: promutils.NewDuration(time.Hour),
+		},
+	}, nil, nil, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer wn.Close()
+	err = wn.Send(context.Background(), []Alert{newTestAlert(1, "foo", StateFiring)}, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid SC 400") {
+		t.Fatalf("expecting invalid SC error; got %v", err)
+	}
+}
+
+func TestNewWebhookNotifier_Failure(t *testing.T) {
+	f := func(cfg WebhookConfig, errExpected string) {
+		t.Helper()
+
+		_, err := newWebhookNotifier(cfg, nil, nil, time.Second)
+		if err == nil || !strings.Contains(err.Error(), errExpected) {
+			t.Fatalf("expecting error containing %q; got %v", errExpected, err)
+		}
+	}
+
+	f(WebhookConfig{}, "url cannot be empty")
+	f(WebhookConfig{URL: "http://localhost", BodyTemplate: "{{ .Status"}, "invalid body_template")
+}
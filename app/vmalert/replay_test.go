@@ -3,12 +3,18 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/config"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/remotewrite"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
 )
 
@@ -138,3 +144,95 @@ func TestReplay(t *testing.T) {
 		},
 	})
 }
+
+type fakeRWClient struct {
+	mu      sync.Mutex
+	samples []string
+}
+
+func (fc *fakeRWClient) Push(s prompbmarshal.TimeSeries) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	var name string
+	for _, l := range s.Labels {
+		if l.Name == "__name__" {
+			name = l.Value
+		}
+	}
+	for _, sample := range s.Samples {
+		t := time.UnixMilli(sample.Timestamp).UTC()
+		fc.samples = append(fc.samples, fmt.Sprintf("%s %s %v", name, t.Format("15:04:05"), sample.Value))
+	}
+	return nil
+}
+
+func (fc *fakeRWClient) Close() error {
+	return nil
+}
+
+func TestReplayVLogs(t *testing.T) {
+	var requests []string
+	// the handler emulates stats_query_range response with the number of minute of every bucket
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/select/logsql/stats_query_range" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		start, err := time.Parse(time.RFC3339Nano, r.FormValue("start"))
+		if err != nil {
+			t.Errorf("cannot parse start: %s", err)
+		}
+		end, err := time.Parse(time.RFC3339Nano, r.FormValue("end"))
+		if err != nil {
+			t.Errorf("cannot parse end: %s", err)
+		}
+		requests = append(requests, fmt.Sprintf("%s-%s step=%s", start.Format("15:04:05"), end.Format("15:04:05.999"), r.FormValue("step")))
+		var values []string
+		for ts := start; !ts.After(end); ts = ts.Add(time.Minute) {
+			values = append(values, fmt.Sprintf(`[%d,"%d"]`, ts.Unix(), ts.Minute()))
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"errors","app":"nginx"},"values":[%s]}]}}`, strings.Join(values, ","))
+	}))
+	defer srv.Close()
+
+	fromOrig, toOrig, maxDatapointsOrig := *replayFrom, *replayTo, *replayMaxDatapoints
+	delayOrig := *replayRulesDelay
+	defer func() {
+		*replayFrom, *replayTo, *replayMaxDatapoints = fromOrig, toOrig, maxDatapointsOrig
+		*replayRulesDelay = delayOrig
+	}()
+	*replayFrom = "2021-01-01T12:00:30Z"
+	*replayTo = "2021-01-01T12:03:00Z"
+	*replayMaxDatapoints = 2
+	*replayRulesDelay = time.Millisecond
+
+	cfg := []config.Group{{
+		Name:     "vlogs",
+		Type:     config.NewVLogsType(),
+		Interval: promutils.NewDuration(time.Minute),
+		Rules:    []config.Rule{{Record: "app:errors:count", Expr: "error | stats by (app) count() errors"}},
+	}}
+	rw := &fakeRWClient{}
+	qb := datasource.NewPrometheusClient(srv.URL, nil, false, srv.Client())
+	if err := replay(cfg, qb, rw); err != nil {
+		t.Fatalf("replay failed: %s", err)
+	}
+
+	// requests select only full buckets
+	requestsExpected := []string{
+		"12:00:00-12:01:59.999 step=60s",
+		"12:02:00-12:02:59.999 step=60s",
+	}
+	if !reflect.DeepEqual(requests, requestsExpected) {
+		t.Fatalf("unexpected requests\ngot\n%s\nwant\n%s", strings.Join(requests, "\n"), strings.Join(requestsExpected, "\n"))
+	}
+	// every sample is placed at the end of its bucket
+	samplesExpected := []string{
+		"app:errors:count 12:01:00 0",
+		"app:errors:count 12:02:00 1",
+		"app:errors:count 12:03:00 2",
+	}
+	if !reflect.DeepEqual(rw.samples, samplesExpected) {
+		t.Fatalf("unexpected samples\ngot\n%s\nwant\n%s", strings.Join(rw.samples, "\n"), strings.Join(samplesExpected, "\n"))
+	}
+}
@@ -37,10 +37,18 @@ type AlertingRule struct {
 	Debug         bool
 
 	q datasource.Querier
+	// logSamples is set only for rules with `log_samples` config
+	logSamples *logSamples
 
 	alertsMu sync.RWMutex
 	// stores list of active alerts
 	alerts map[uint64]*notifier.Alert
+	// logSamplesCache contains sample log lines for firing alerts.
+	// It is protected by alertsMu.
+	logSamplesCache map[uint64][]notifier.LogSample
+	// suppressedAlerts contains IDs of firing alerts, which were suppressed at the last updateSuppression call.
+	// It is protected by alertsMu.
+	suppressedAlerts map[uint64]struct{}
 
 	// state stores recent state changes
 	// during evaluations
@@ -81,8 +89,9 @@ func NewAlertingRule(qb datasource.QuerierBuilder, group *Group, cfg config.Rule
 			Headers:                   group.Headers,
 			Debug:                     cfg.Debug,
 		}),
-		alerts:  make(map[uint64]*notifier.Alert),
-		metrics: &alertingRuleMetrics{},
+		logSamples: newLogSamples(cfg.LogSamples, cfg.Expr, group.Interval),
+		alerts:     make(map[uint64]*notifier.Alert),
+		metrics:    &alertingRuleMetrics{},
 	}
 
 	entrySize := *ruleUpdateEntriesLimit
@@ -145,8 +154,9 @@ func NewAlertingRule(qb datasource.QuerierBuilder, group *Group, cfg config.Rule
 	return ar
 }
 
-// close unregisters rule metrics
+// close unregisters rule metrics and firing alerts
 func (ar *AlertingRule) close() {
+	notifier.UnregisterFiringAlerts(ar.GroupID, ar.RuleID)
 	ar.metrics.active.Unregister()
 	ar.metrics.pending.Unregister()
 	ar.metrics.errors.Unregister()
@@ -228,6 +238,7 @@ func (ar *AlertingRule) updateWith(r Rule) error {
 	ar.EvalInterval = nr.EvalInterval
 	ar.Debug = nr.Debug
 	ar.q = nr.q
+	ar.logSamples = nr.logSamples
 	ar.state = nr.state
 	return nil
 }
@@ -397,24 +408,38 @@ func (ar *AlertingRule) exec(ctx context.Context, ts time.Time, limit int) ([]pr
 		res, _, err := ar.q.Query(ctx, query, ts)
 		return res.Data, err
 	}
-
 	// template labels and annotations before updating ar.alerts,
 	// since they could use `query` function which takes a while to execute,
 	// see https://github.com/VictoriaMetrics/VictoriaMetrics/issues/6079.
 	expandedLabels := make([]*labelSet, len(res.Data))
+	for i, m := range res.Data {
+		ls, err := ar.toLabels(m, qFn)
+		if err != nil {
+			curState.Err = fmt.Errorf("failed to expand templates: failed to expand labels: %w", err)
+			return nil, curState.Err
+		}
+		expandedLabels[i] = ls
+	}
+	var logSamples map[uint64][]notifier.LogSample
+	if ar.logSamples != nil {
+		logSamples = ar.getLogSamples(ctx, expandedLabels, ts)
+	}
 	expandedAnnotations := make([]map[string]string, len(res.Data))
 	for i, m := range res.Data {
-		ls, as, err := ar.expandTemplates(m, qFn, ts)
+		ls := expandedLabels[i]
+		as, err := ar.expandAnnotations(m, ls, qFn, logSamples[hash(ls.processed)], ts)
 		if err != nil {
 			curState.Err = fmt.Errorf("failed to expand templates: %w", err)
 			return nil, curState.Err
 		}
-		expandedLabels[i] = ls
 		expandedAnnotations[i] = as
 	}
 
 	ar.alertsMu.Lock()
 	defer ar.alertsMu.Unlock()
+	if ar.logSamples != nil {
+		defer ar.updateLogSamplesCacheLocked(logSamples)
+	}
 
 	for h, a := range ar.alerts {
 		// cleanup inactive alerts from previous Exec
@@ -442,6 +467,7 @@ func (ar *AlertingRule) exec(ctx context.Context, ts time.Time, limit int) ([]pr
 				// back to notifier.StatePending
 				a.State = notifier.StatePending
 				a.ActiveAt = ts
+				a.FiringSent = false
 				ar.logDebugf(ts, a, "INACTIVE => PENDING")
 			}
 			a.Value = m.Values[0]
@@ -517,21 +543,29 @@ func (ar *AlertingRule) expandTemplates(m datasource.Metric, qFn templates.Query
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to expand labels: %w", err)
 	}
+	as, err := ar.expandAnnotations(m, ls, qFn, nil, ts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ls, as, nil
+}
 
+func (ar *AlertingRule) expandAnnotations(m datasource.Metric, ls *labelSet, qFn templates.QueryFn, logSamples []notifier.LogSample, ts time.Time) (map[string]string, error) {
 	tplData := notifier.AlertTplData{
-		Value:    m.Values[0],
-		Labels:   ls.origin,
-		Expr:     ar.Expr,
-		AlertID:  hash(ls.processed),
-		GroupID:  ar.GroupID,
-		ActiveAt: ts,
-		For:      ar.For,
+		Value:      m.Values[0],
+		Labels:     ls.origin,
+		Expr:       ar.Expr,
+		AlertID:    hash(ls.processed),
+		GroupID:    ar.GroupID,
+		ActiveAt:   ts,
+		For:        ar.For,
+		LogSamples: logSamples,
 	}
 	as, err := notifier.ExecTemplate(qFn, ar.Annotations, tplData)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to template annotations: %w", err)
+		return nil, fmt.Errorf("failed to template annotations: %w", err)
 	}
-	return ls, as, nil
+	return as, nil
 }
 
 // toTimeSeries creates `ALERTS` and `ALERTS_FOR_STATE` for active alerts
@@ -750,11 +784,50 @@ func (ar *AlertingRule) restore(ctx context.Context, q datasource.Querier, ts ti
 		}
 		a.ActiveAt = time.Unix(int64(series.Values[0]), 0)
 		a.Restored = true
+		// the alert could be sent to notifiers before the restart
+		a.FiringSent = true
 		logger.Infof("alert %q (%d) restored to state at %v", a.Name, a.ID, a.ActiveAt)
 	}
 	return nil
 }
 
+// updateSuppression registers firing alerts of ar, so they could inhibit other alerts,
+// and updates the suppression state of ar alerts according to irs and active silences at the given time.
+//
+// vmalert_alerts_suppressed_total is incremented only when a firing alert becomes suppressed.
+func (ar *AlertingRule) updateSuppression(irs []*notifier.InhibitRule, now time.Time) {
+	ar.alertsMu.Lock()
+	defer ar.alertsMu.Unlock()
+
+	alerts := make([]*notifier.Alert, 0, len(ar.alerts))
+	for _, a := range ar.alerts {
+		alerts = append(alerts, a)
+	}
+	notifier.RegisterFiringAlerts(ar.GroupID, ar.RuleID, alerts)
+	suppressedAlerts := make(map[uint64]struct{})
+	for _, a := range alerts {
+		if a.State == notifier.StateInactive {
+			a.SilencedBy, a.InhibitedBy = nil, nil
+			continue
+		}
+		a.SilencedBy = notifier.GetSilencedBy(a.Labels, now)
+		a.InhibitedBy = notifier.GetInhibitedBy(irs, a)
+		if a.State != notifier.StateFiring || !a.IsSuppressed() {
+			continue
+		}
+		suppressedAlerts[a.ID] = struct{}{}
+		if _, ok := ar.suppressedAlerts[a.ID]; ok {
+			continue
+		}
+		if len(a.SilencedBy) > 0 {
+			alertsSilenced.Inc()
+		} else {
+			alertsInhibited.Inc()
+		}
+	}
+	ar.suppressedAlerts = suppressedAlerts
+}
+
 // alertsToSend walks through the current alerts of AlertingRule
 // and returns only those which should be sent to notifier.
 // Isn't concurrent safe.
@@ -775,12 +848,23 @@ func (ar *AlertingRule) alertsToSend(resolveDuration, resendDelay time.Duration)
 
 	var alerts []notifier.Alert
 	for _, a := range ar.alerts {
+		if a.State == notifier.StateFiring && a.IsSuppressed() {
+			// Alerts muted by silences or inhibition rules mustn't be sent to notifiers.
+			continue
+		}
+		if a.State == notifier.StateInactive && !a.FiringSent {
+			// There is no need in sending resolved notification for the alert,
+			// which wasn't sent to notifiers while firing because of suppression.
+			continue
+		}
 		if !needsSending(a) {
 			continue
 		}
 		a.End = currentTime.Add(resolveDuration)
 		if a.State == notifier.StateInactive {
 			a.End = a.ResolvedAt
+		} else {
+			a.FiringSent = true
 		}
 		a.LastSent = currentTime
 		alerts = append(alerts, *a)
@@ -1221,15 +1221,27 @@ func TestAlertsToSend(t *testing.T) {
 
 	// check if resolved alerts need to be sent with non-zero resendDelay
 	f([]*notifier.Alert{
-		{Name: "a", State: notifier.StateInactive, ResolvedAt: ts, LastSent: ts.Add(-30 * time.Second)},
+		{Name: "a", State: notifier.StateInactive, ResolvedAt: ts, LastSent: ts.Add(-30 * time.Second), FiringSent: true},
 		// no need to resend resolved
-		{Name: "b", State: notifier.StateInactive, ResolvedAt: ts, LastSent: ts},
+		{Name: "b", State: notifier.StateInactive, ResolvedAt: ts, LastSent: ts, FiringSent: true},
 		// resend resolved
-		{Name: "c", State: notifier.StateInactive, ResolvedAt: ts.Add(-1 * time.Minute), LastSent: ts.Add(-1 * time.Minute)},
+		{Name: "c", State: notifier.StateInactive, ResolvedAt: ts.Add(-1 * time.Minute), LastSent: ts.Add(-1 * time.Minute), FiringSent: true},
+		// no need to send resolved for the alert, which wasn't sent while firing
+		{Name: "d", State: notifier.StateInactive, ResolvedAt: ts, LastSent: ts.Add(-30 * time.Second)},
 	},
 		[]*notifier.Alert{{Name: "a"}, {Name: "c"}},
 		5*time.Minute, time.Minute,
 	)
+
+	// check that suppressed firing alerts aren't sent
+	f([]*notifier.Alert{
+		{Name: "a", State: notifier.StateFiring, Start: ts, SilencedBy: []string{"1"}},
+		{Name: "b", State: notifier.StateFiring, Start: ts, InhibitedBy: []string{"2"}},
+		{Name: "c", State: notifier.StateFiring, Start: ts},
+	},
+		[]*notifier.Alert{{Name: "c"}},
+		5*time.Minute, time.Minute,
+	)
 }
 
 func newTestRuleWithLabels(name string, labels ...string) *AlertingRule {
@@ -57,6 +57,8 @@ type Group struct {
 	Params          url.Values
 	Headers         map[string]string
 	NotifierHeaders map[string]string
+	// InhibitRules contains rules for muting alerts of the group
+	InhibitRules []*notifier.InhibitRule
 
 	doneCh     chan struct{}
 	finishedCh chan struct{}
@@ -150,6 +152,15 @@ func NewGroup(cfg config.Group, qb datasource.QuerierBuilder, defaultInterval ti
 	for _, h := range cfg.NotifierHeaders {
 		g.NotifierHeaders[h.Key] = h.Value
 	}
+	for _, ir := range cfg.InhibitRules {
+		r, err := notifier.NewInhibitRule(ir.SourceMatchers, ir.TargetMatchers, ir.Equal)
+		if err != nil {
+			// inhibit rules are validated during config parsing, so this shouldn't happen
+			logger.Errorf("group %q: skipping invalid inhibit rule: %s", g.Name, err)
+			continue
+		}
+		g.InhibitRules = append(g.InhibitRules, r)
+	}
 	g.metrics = newGroupMetrics(g)
 	rules := make([]Rule, len(cfg.Rules))
 	for i, r := range cfg.Rules {
@@ -267,6 +278,7 @@ func (g *Group) updateWith(newGroup *Group) error {
 	g.Params = newGroup.Params
 	g.Headers = newGroup.Headers
 	g.NotifierHeaders = newGroup.NotifierHeaders
+	g.InhibitRules = newGroup.InhibitRules
 	g.Labels = newGroup.Labels
 	g.Limit = newGroup.Limit
 	g.Checksum = newGroup.Checksum
@@ -349,6 +361,7 @@ func (g *Group) Start(ctx context.Context, nts func() []notifier.Notifier, rw re
 		Rw:              rw,
 		Notifiers:       nts,
 		notifierHeaders: g.NotifierHeaders,
+		inhibitRules:    g.InhibitRules,
 	}
 
 	g.infof("started")
@@ -422,6 +435,7 @@ func (g *Group) Start(ctx context.Context, nts func() []notifier.Notifier, rw re
 			}
 
 			e.notifierHeaders = g.NotifierHeaders
+			e.inhibitRules = g.InhibitRules
 			g.mu.Unlock()
 
 			g.infof("re-started")
@@ -535,6 +549,7 @@ func (g *Group) ExecOnce(ctx context.Context, nts func() []notifier.Notifier, rw
 		Rw:              rw,
 		Notifiers:       nts,
 		notifierHeaders: g.NotifierHeaders,
+		inhibitRules:    g.InhibitRules,
 	}
 	if len(g.Rules) < 1 {
 		return nil
@@ -623,6 +638,7 @@ func (g *Group) getEvalDelay() time.Duration {
 type executor struct {
 	Notifiers       func() []notifier.Notifier
 	notifierHeaders map[string]string
+	inhibitRules    []*notifier.InhibitRule
 
 	Rw remotewrite.RWClient
 }
@@ -660,6 +676,9 @@ func (e *executor) execConcurrently(ctx context.Context, rules []Rule, ts time.T
 var (
 	alertsFired = metrics.NewCounter(`vmalert_alerts_fired_total`)
 
+	alertsSilenced  = metrics.NewCounter(`vmalert_alerts_suppressed_total{reason="silenced"}`)
+	alertsInhibited = metrics.NewCounter(`vmalert_alerts_suppressed_total{reason="inhibited"}`)
+
 	execTotal  = metrics.NewCounter(`vmalert_execution_total`)
 	execErrors = metrics.NewCounter(`vmalert_execution_errors_total`)
 )
@@ -698,6 +717,7 @@ func (e *executor) exec(ctx context.Context, r Rule, ts time.Time, resolveDurati
 		return nil
 	}
 
+	ar.updateSuppression(e.inhibitRules, time.Now())
 	alerts := ar.alertsToSend(resolveDuration, *resendDelay)
 	if len(alerts) < 1 {
 		return nil
@@ -6,6 +6,7 @@ import (
 	"math"
 	"net/url"
 	"os"
+	"reflect"
 	"sort"
 	"testing"
 	"time"
@@ -420,6 +421,107 @@ func TestFaultyNotifier(t *testing.T) {
 	t.Fatalf("alive notifier didn't receive notification by %v", deadline)
 }
 
+func TestExecSuppressedAlerts(t *testing.T) {
+	fqSource := &datasource.FakeQuerier{}
+	fqSource.Add(metricWithValueAndLabels(t, 1, "instance", "host1"))
+	source := newTestAlertingRule("NodeDown", 0)
+	source.RuleID = 1
+	source.q = fqSource
+	defer notifier.UnregisterFiringAlerts(source.GroupID, source.RuleID)
+
+	fqTarget := &datasource.FakeQuerier{}
+	fqTarget.Add(metricWithValueAndLabels(t, 1, "instance", "host1"))
+	fqTarget.Add(metricWithValueAndLabels(t, 1, "instance", "host2"))
+	target := newTestAlertingRule("ServiceDown", 0)
+	target.RuleID = 2
+	target.q = fqTarget
+	defer notifier.UnregisterFiringAlerts(target.GroupID, target.RuleID)
+
+	ir, err := notifier.NewInhibitRule([]string{`alertname="NodeDown"`}, []string{`alertname="ServiceDown"`}, []string{"instance"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	fn := &notifier.FakeNotifier{}
+	e := &executor{
+		Notifiers: func() []notifier.Notifier {
+			return []notifier.Notifier{fn}
+		},
+		inhibitRules: []*notifier.InhibitRule{ir},
+	}
+	exec := func(r Rule) {
+		t.Helper()
+		if err := e.exec(context.Background(), r, time.Now(), 0, 0); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	checkSent := func(countExpected int, instancesExpected ...string) {
+		t.Helper()
+		if n := fn.GetCounter(); n != countExpected {
+			t.Fatalf("unexpected number of sent alerts; got %d; want %d", n, countExpected)
+		}
+		var instances []string
+		for _, a := range fn.GetAlerts() {
+			instances = append(instances, a.Labels["instance"])
+		}
+		sort.Strings(instances)
+		if !reflect.DeepEqual(instances, instancesExpected) {
+			t.Fatalf("unexpected sent alerts; got %q; want %q", instances, instancesExpected)
+		}
+	}
+
+	exec(source)
+	checkSent(1, "host1")
+
+	// ServiceDown for host1 must be inhibited by NodeDown for host1
+	exec(target)
+	checkSent(2, "host2")
+	for _, a := range target.GetAlerts() {
+		inhibited := len(a.InhibitedBy) > 0
+		if inhibited != (a.Labels["instance"] == "host1") {
+			t.Fatalf("unexpected inhibition state for alert %v: %q", a.Labels, a.InhibitedBy)
+		}
+	}
+
+	// ServiceDown for host2 must be silenced
+	matchers, err := notifier.ParseMatchers([]string{`instance="host2"`})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	id, err := notifier.AddSilence(&notifier.Silence{
+		Matchers: matchers,
+		EndsAt:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("cannot add silence: %s", err)
+	}
+	defer func() {
+		if err := notifier.ExpireSilence(id); err != nil {
+			t.Fatalf("cannot expire silence: %s", err)
+		}
+	}()
+	silencedBefore := alertsSilenced.Get()
+	exec(target)
+	checkSent(2, "host2")
+	for _, a := range target.GetAlerts() {
+		if !a.IsSuppressed() {
+			t.Fatalf("expecting alert %v to be suppressed", a.Labels)
+		}
+	}
+
+	// the suppressed alert must be counted only once
+	exec(target)
+	checkSent(2, "host2")
+	if n := alertsSilenced.Get() - silencedBefore; n != 1 {
+		t.Fatalf("unexpected number of silenced alerts; got %d; want 1", n)
+	}
+
+	// resolved notification must be sent only for ServiceDown for host2,
+	// since ServiceDown for host1 wasn't sent while firing
+	fqTarget.Reset()
+	exec(target)
+	checkSent(3, "host2")
+}
+
 func TestFaultyRW(t *testing.T) {
 	fq := &datasource.FakeQuerier{}
 	fq.Add(metricWithValueAndLabels(t, 1, "__name__", "foo", "job", "bar"))
@@ -0,0 +1,214 @@
+package rule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/config"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+const defaultLogSamplesLimit = 5
+
+// logSamples contains parsed `log_samples` config of the alerting rule
+type logSamples struct {
+	// query is the LogsQL query for selecting sample log lines without filters by alert labels
+	query string
+	// byFields contains fields from `stats by (...)` clause of the rule expression,
+	// which are used for building filters by alert labels
+	byFields []string
+	limit    int
+	lookback time.Duration
+	// filterByFields defines whether to use field filters instead of stream filters
+	filterByFields bool
+}
+
+// newLogSamples returns logSamples for the given cfg of the alerting rule with the given expr.
+//
+// It returns nil if cfg is nil.
+func newLogSamples(cfg *config.LogSamples, expr string, evalInterval time.Duration) *logSamples {
+	if cfg == nil {
+		return nil
+	}
+	q, err := logstorage.ParseStatsQuery(expr, 0)
+	if err != nil {
+		logger.Panicf("BUG: the LogsQL query must be valid here; got error: %s; query=[%s]", err, expr)
+	}
+	byFields, err := q.GetStatsByFields()
+	if err != nil {
+		logger.Panicf("BUG: cannot obtain stats by fields from the LogsQL query: %s; query=[%s]", err, expr)
+	}
+	ls := &logSamples{
+		query:          cfg.Query,
+		byFields:       byFields,
+		limit:          cfg.Limit,
+		lookback:       cfg.Lookback.Duration(),
+		filterByFields: cfg.FilterBy == "fields",
+	}
+	if ls.query == "" {
+		q.DropAllPipes()
+		ls.query = q.String()
+	}
+	if ls.limit <= 0 {
+		ls.limit = defaultLogSamplesLimit
+	}
+	if ls.lookback <= 0 {
+		ls.lookback = evalInterval
+	}
+	return ls
+}
+
+// buildQuery returns LogsQL query for selecting sample log lines for the alert with the given labels
+func (ls *logSamples) buildQuery(labels map[string]string) string {
+	var streamFilters, filters []string
+	for _, f := range ls.byFields {
+		v, ok := labels[f]
+		if !ok {
+			continue
+		}
+		switch {
+		case f == "_time":
+			// time buckets are covered by lookback window
+		case f == "_stream":
+			filters = append(filters, v)
+		case f == "_stream_id":
+			filters = append(filters, fmt.Sprintf("_stream_id:%q", v))
+		case ls.filterByFields:
+			filters = append(filters, fmt.Sprintf("%q:=%q", f, v))
+		default:
+			streamFilters = append(streamFilters, fmt.Sprintf("%s=%q", f, v))
+		}
+	}
+	if len(streamFilters) > 0 {
+		filters = append(filters, "{"+strings.Join(streamFilters, ",")+"}")
+	}
+	if len(filters) == 0 {
+		return ls.query
+	}
+	return fmt.Sprintf("%s (%s)", strings.Join(filters, " "), ls.query)
+}
+
+var logSamplesLogger = logger.WithThrottler("logSamples", 5*time.Second)
+
+// logSamplesConcurrency is the maximum number of concurrent queries for log samples per alerting rule
+const logSamplesConcurrency = 8
+
+// getLogSamples returns sample log lines per alert ID for firing alerts with the given labels at ts.
+//
+// Sample log lines are fetched only when the alert becomes firing and are cached until the alert stops firing,
+// so the datasource isn't queried for every firing alert on every evaluation.
+func (ar *AlertingRule) getLogSamples(ctx context.Context, labels []*labelSet, ts time.Time) map[uint64][]notifier.LogSample {
+	m := make(map[uint64][]notifier.LogSample)
+	var pending []*labelSet
+	ar.alertsMu.RLock()
+	for _, ls := range labels {
+		alertID := hash(ls.processed)
+		if samples, ok := ar.logSamplesCache[alertID]; ok {
+			m[alertID] = samples
+			continue
+		}
+		if ar.isFiringAtLocked(alertID, ts) {
+			pending = append(pending, ls)
+		}
+	}
+	ar.alertsMu.RUnlock()
+
+	// Fetch log samples for alerts becoming firing concurrently, since there can be many such alerts
+	// after the rule start or after an incident.
+	samples := make([][]notifier.LogSample, len(pending))
+	concurrencyCh := make(chan struct{}, logSamplesConcurrency)
+	var wg sync.WaitGroup
+	for i, ls := range pending {
+		concurrencyCh <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-concurrencyCh
+				wg.Done()
+			}()
+			samples[i] = ar.fetchLogSamples(ctx, ls, ts)
+		}()
+	}
+	wg.Wait()
+
+	for i, ls := range pending {
+		if samples[i] != nil {
+			m[hash(ls.processed)] = samples[i]
+		}
+	}
+	return m
+}
+
+// updateLogSamplesCacheLocked updates ar.logSamplesCache with the log samples obtained via getLogSamples.
+//
+// Log samples are kept only for firing alerts, so they are fetched again when the alert becomes firing next time.
+//
+// ar.alertsMu must be locked when calling this function.
+func (ar *AlertingRule) updateLogSamplesCacheLocked(m map[uint64][]notifier.LogSample) {
+	cache := make(map[uint64][]notifier.LogSample, len(m))
+	for alertID, a := range ar.alerts {
+		if a.State != notifier.StateFiring {
+			continue
+		}
+		if samples, ok := m[alertID]; ok {
+			cache[alertID] = samples
+		} else if samples, ok := ar.logSamplesCache[alertID]; ok {
+			// The alert may be absent in the current evaluation, while it keeps firing because of keep_firing_for.
+			cache[alertID] = samples
+		}
+	}
+	ar.logSamplesCache = cache
+}
+
+// fetchLogSamples returns sample log lines for the alert with the given labels at ts.
+//
+// Errors are logged and nil is returned, since sample log lines are optional
+// and must not prevent alert from firing.
+func (ar *AlertingRule) fetchLogSamples(ctx context.Context, labels *labelSet, ts time.Time) []notifier.LogSample {
+	lq, ok := ar.q.(datasource.LogsQuerier)
+	if !ok {
+		logSamplesLogger.Warnf("rule %q: datasource doesn't support querying logs; skipping log samples", ar.Name)
+		return nil
+	}
+	query := ar.logSamples.buildQuery(labels.origin)
+	rows, err := lq.QueryLogs(ctx, query, ts.Add(-ar.logSamples.lookback), ts, ar.logSamples.limit)
+	if err != nil {
+		logSamplesLogger.Warnf("rule %q: cannot fetch log samples with query %q: %s", ar.Name, query, err)
+		return nil
+	}
+	samples := make([]notifier.LogSample, 0, len(rows))
+	for _, row := range rows {
+		samples = append(samples, notifier.LogSample{
+			Time:   row["_time"],
+			Msg:    row["_msg"],
+			Stream: row["_stream"],
+			Fields: row,
+		})
+	}
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Time < samples[j].Time
+	})
+	return samples
+}
+
+// isFiringAtLocked returns true if the alert with the given id is firing
+// or is going to become firing after the evaluation at ts.
+//
+// ar.alertsMu must be locked when calling this function.
+func (ar *AlertingRule) isFiringAtLocked(alertID uint64, ts time.Time) bool {
+	a, ok := ar.alerts[alertID]
+	if !ok || a.State == notifier.StateInactive {
+		return ar.For == 0
+	}
+	if a.State == notifier.StateFiring {
+		return true
+	}
+	return ts.Sub(a.ActiveAt) >= ar.For
+}
@@ -0,0 +1,187 @@
+package rule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/config"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+type fakeLogsQuerier struct {
+	datasource.FakeQuerier
+
+	rows []map[string]string
+	err  error
+
+	queriesLock sync.Mutex
+	queries     []string
+}
+
+func (fq *fakeLogsQuerier) QueryLogs(_ context.Context, query string, start, end time.Time, limit int) ([]map[string]string, error) {
+	fq.queriesLock.Lock()
+	defer fq.queriesLock.Unlock()
+
+	fq.queries = append(fq.queries, fmt.Sprintf("%s [%s] %d", query, end.Sub(start), limit))
+	if fq.err != nil {
+		return nil, fq.err
+	}
+	return fq.rows, nil
+}
+
+func TestLogSamples_BuildQuery(t *testing.T) {
+	f := func(cfg *config.LogSamples, expr string, labels map[string]string, resultExpected string) {
+		t.Helper()
+
+		ls := newLogSamples(cfg, expr, time.Minute)
+		result := ls.buildQuery(labels)
+		if result != resultExpected {
+			t.Fatalf("unexpected query; got %s; want %s", result, resultExpected)
+		}
+	}
+
+	// no by fields
+	f(&config.LogSamples{}, `error | stats count() errors`, map[string]string{"app": "nginx"}, `error`)
+
+	// stream filters
+	f(&config.LogSamples{}, `_time:5m error | stats by (app, host) count() errors | filter errors:>0`, map[string]string{
+		"app":  "nginx",
+		"host": "h1",
+		"env":  "prod",
+	}, `{app="nginx",host="h1"} (_time:5m error)`)
+
+	// field filters and custom query
+	f(&config.LogSamples{Query: "error -debug", FilterBy: "fields"}, `error | stats by (app, path) count() errors`, map[string]string{
+		"app":  "nginx",
+		"path": `/a"b`,
+	}, `"app":="nginx" "path":="/a\"b" (error -debug)`)
+
+	// _stream and _stream_id fields
+	f(&config.LogSamples{}, `error | stats by (_stream, _stream_id) count() errors`, map[string]string{
+		"_stream":    `{app="nginx"}`,
+		"_stream_id": "0000007b000001c8302bc96e02e54e5524b3a68ec271e55e",
+	}, `{app="nginx"} _stream_id:"0000007b000001c8302bc96e02e54e5524b3a68ec271e55e" (error)`)
+}
+
+func TestAlertingRule_LogSamples(t *testing.T) {
+	f := func(waitFor time.Duration, queryErr error, queriesExpected []string, annotationExpected string) {
+		t.Helper()
+
+		ar := newTestAlertingRule("errors", waitFor)
+		ar.Expr = `error | stats by (app) count() errors`
+		ar.Annotations = map[string]string{
+			"logs": `{{ range $logSamples }}{{ . }};{{ end }}`,
+		}
+		ar.logSamples = newLogSamples(&config.LogSamples{
+			Limit:    2,
+			Lookback: promutils.NewDuration(5 * time.Minute),
+		}, ar.Expr, time.Minute)
+		fq := &fakeLogsQuerier{
+			rows: []map[string]string{
+				{"_time": "2025-01-01T00:00:02Z", "_msg": "error 2"},
+				{"_time": "2025-01-01T00:00:01Z", "_msg": "error 1"},
+			},
+			err: queryErr,
+		}
+		fq.Add(metricWithValueAndLabels(t, 2, "app", "nginx"))
+		ar.q = fq
+
+		if _, err := ar.exec(context.Background(), time.Now(), 0); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if fmt.Sprintf("%q", fq.queries) != fmt.Sprintf("%q", queriesExpected) {
+			t.Fatalf("unexpected queries; got %q; want %q", fq.queries, queriesExpected)
+		}
+		if len(ar.alerts) != 1 {
+			t.Fatalf("expecting 1 alert; got %d", len(ar.alerts))
+		}
+		for _, a := range ar.alerts {
+			if a.Annotations["logs"] != annotationExpected {
+				t.Fatalf("unexpected annotation; got %q; want %q", a.Annotations["logs"], annotationExpected)
+			}
+		}
+	}
+
+	// log samples are fetched for firing alerts
+	f(0, nil, []string{`{app="nginx"} (error) [5m0s] 2`}, "2025-01-01T00:00:01Z error 1;2025-01-01T00:00:02Z error 2;")
+
+	// log samples aren't fetched for pending alerts
+	f(time.Minute, nil, nil, "")
+
+	// errors don't prevent alert from firing
+	f(0, fmt.Errorf("connection refused"), []string{`{app="nginx"} (error) [5m0s] 2`}, "")
+}
+
+func TestAlertingRule_IsFiringAt(t *testing.T) {
+	ts := time.Now()
+	ar := newTestAlertingRule("test", time.Minute)
+	ar.alerts[1] = &notifier.Alert{State: notifier.StateFiring, ActiveAt: ts}
+	ar.alerts[2] = &notifier.Alert{State: notifier.StatePending, ActiveAt: ts}
+	ar.alerts[3] = &notifier.Alert{State: notifier.StatePending, ActiveAt: ts.Add(-time.Minute)}
+	ar.alerts[4] = &notifier.Alert{State: notifier.StateInactive, ActiveAt: ts.Add(-time.Hour)}
+
+	f := func(alertID uint64, resultExpected bool) {
+		t.Helper()
+
+		if result := ar.isFiringAtLocked(alertID, ts); result != resultExpected {
+			t.Fatalf("unexpected result for alert %d; got %v; want %v", alertID, result, resultExpected)
+		}
+	}
+
+	f(1, true)
+	f(2, false)
+	f(3, true)
+	f(4, false)
+	f(5, false)
+}
+
+func TestAlertingRule_LogSamplesCache(t *testing.T) {
+	ar := newTestAlertingRule("errors", 0)
+	ar.Expr = `error | stats by (app) count() errors`
+	ar.Annotations = map[string]string{
+		"logs": `{{ range $logSamples }}{{ .Msg }};{{ end }}`,
+	}
+	ar.logSamples = newLogSamples(&config.LogSamples{}, ar.Expr, time.Minute)
+	fq := &fakeLogsQuerier{
+		rows: []map[string]string{
+			{"_time": "2025-01-01T00:00:01Z", "_msg": "error 1"},
+		},
+	}
+	ar.q = fq
+
+	exec := func(queriesExpected int) {
+		t.Helper()
+
+		if _, err := ar.exec(context.Background(), time.Now(), 0); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(fq.queries) != queriesExpected {
+			t.Fatalf("unexpected number of log samples queries; got %d; want %d", len(fq.queries), queriesExpected)
+		}
+		for _, a := range ar.alerts {
+			if a.State == notifier.StateFiring && a.Annotations["logs"] != "error 1;" {
+				t.Fatalf("unexpected annotation; got %q; want %q", a.Annotations["logs"], "error 1;")
+			}
+		}
+	}
+
+	// log samples are fetched when the alert becomes firing
+	fq.Add(metricWithValueAndLabels(t, 2, "app", "nginx"))
+	exec(1)
+
+	// log samples are fetched only for the new firing alert
+	fq.Add(metricWithValueAndLabels(t, 3, "app", "foo"))
+	exec(2)
+	exec(2)
+
+	// log samples are fetched again when the resolved alert becomes firing again
+	fq.Reset()
+	exec(2)
+	fq.Add(metricWithValueAndLabels(t, 2, "app", "nginx"))
+	exec(3)
+}
@@ -3,11 +3,13 @@ package main
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/rule"
@@ -19,7 +21,11 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
 )
 
-var reloadAuthKey = flagutil.NewPassword("reloadAuthKey", "Auth key for /-/reload http endpoint. It must be passed via authKey query arg. It overrides -httpAuth.*")
+var (
+	reloadAuthKey   = flagutil.NewPassword("reloadAuthKey", "Auth key for /-/reload http endpoint. It must be passed via authKey query arg. It overrides -httpAuth.*")
+	silencesAuthKey = flagutil.NewPassword("silencesAuthKey", "Auth key for creating and expiring silences via /api/v1/silences and /api/v1/silence http endpoints. "+
+		"It must be passed via authKey query arg. It overrides -httpAuth.*")
+)
 
 var (
 	apiLinks = [][2]string{
@@ -28,6 +34,7 @@ var (
 		{"api/v1/rules", "list all loaded groups and rules"},
 		{"api/v1/alerts", "list all active alerts"},
 		{fmt.Sprintf("api/v1/alert?%s=<int>&%s=<int>", paramGroupID, paramAlertID), "get alert status by group and alert ID"},
+		{"api/v1/silences", "list all silences"},
 	}
 	systemLinks = [][2]string{
 		{"flags", "command-line flags"},
@@ -166,6 +173,48 @@ func (rh *requestHandler) handler(w http.ResponseWriter, r *http.Request) bool {
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(data)
 		return true
+	case "/vmalert/api/v1/silences", "/api/v1/silences":
+		var data []byte
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			data, err = rh.listSilences()
+		case http.MethodPost:
+			if !httpserver.CheckAuthFlag(w, r, silencesAuthKey) {
+				return true
+			}
+			data, err = rh.addSilence(r)
+		default:
+			err = errResponse(fmt.Errorf("path %q supports only GET and POST methods", r.URL.Path), http.StatusMethodNotAllowed)
+		}
+		if err != nil {
+			httpserver.Errorf(w, r, "%s", err)
+			return true
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return true
+	case "/vmalert/api/v1/silence", "/api/v1/silence":
+		var data []byte
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			data, err = rh.getSilence(r)
+		case http.MethodDelete:
+			if !httpserver.CheckAuthFlag(w, r, silencesAuthKey) {
+				return true
+			}
+			data, err = rh.expireSilence(r)
+		default:
+			err = errResponse(fmt.Errorf("path %q supports only GET and DELETE methods", r.URL.Path), http.StatusMethodNotAllowed)
+		}
+		if err != nil {
+			httpserver.Errorf(w, r, "%s", err)
+			return true
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return true
 	case "/-/reload":
 		if !httpserver.CheckAuthFlag(w, r, reloadAuthKey) {
 			return true
@@ -382,6 +431,75 @@ func (rh *requestHandler) listAlerts() ([]byte, error) {
 	return b, nil
 }
 
+type listSilencesResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Silences []*apiSilence `json:"silences"`
+	} `json:"data"`
+}
+
+func (rh *requestHandler) listSilences() ([]byte, error) {
+	now := time.Now()
+	lr := listSilencesResponse{Status: "success"}
+	lr.Data.Silences = make([]*apiSilence, 0)
+	for _, s := range notifier.ListSilences() {
+		lr.Data.Silences = append(lr.Data.Silences, newSilenceAPI(s, now))
+	}
+	b, err := json.Marshal(lr)
+	if err != nil {
+		return nil, &httpserver.ErrorWithStatusCode{
+			Err:        fmt.Errorf(`error encoding list of silences: %w`, err),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	return b, nil
+}
+
+type addSilenceResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		SilenceID string `json:"silenceID"`
+	} `json:"data"`
+}
+
+func (rh *requestHandler) addSilence(r *http.Request) ([]byte, error) {
+	var s notifier.Silence
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		return nil, errResponse(fmt.Errorf("cannot parse silence: %w", err), http.StatusBadRequest)
+	}
+	id, err := notifier.AddSilence(&s)
+	if err != nil {
+		sc := http.StatusBadRequest
+		if errors.Is(err, notifier.ErrSilencesPersist) {
+			sc = http.StatusInternalServerError
+		}
+		return nil, errResponse(fmt.Errorf("cannot add silence: %w", err), sc)
+	}
+	resp := addSilenceResponse{Status: "success"}
+	resp.Data.SilenceID = id
+	return json.Marshal(resp)
+}
+
+func (rh *requestHandler) getSilence(r *http.Request) ([]byte, error) {
+	id := r.FormValue(paramSilenceID)
+	s, ok := notifier.GetSilence(id)
+	if !ok {
+		return nil, errResponse(fmt.Errorf("can't find silence with id %q", id), http.StatusNotFound)
+	}
+	return json.Marshal(newSilenceAPI(s, time.Now()))
+}
+
+func (rh *requestHandler) expireSilence(r *http.Request) ([]byte, error) {
+	id := r.FormValue(paramSilenceID)
+	if _, ok := notifier.GetSilence(id); !ok {
+		return nil, errResponse(fmt.Errorf("can't find silence with id %q", id), http.StatusNotFound)
+	}
+	if err := notifier.ExpireSilence(id); err != nil {
+		return nil, errResponse(fmt.Errorf("cannot expire silence: %w", err), http.StatusInternalServerError)
+	}
+	return []byte(`{"status":"success"}`), nil
+}
+
 func errResponse(err error, sc int) *httpserver.ErrorWithStatusCode {
 	return &httpserver.ErrorWithStatusCode{
 		Err:        err,
@@ -8,6 +8,7 @@ package main
 import (
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
@@ -15,203 +16,203 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/utils"
 )
 
-//line app/vmalert/web.qtpl:14
+//line app/vmalert/web.qtpl:15
 import (
 	qtio422016 "io"
 
 	qt422016 "github.com/valyala/quicktemplate"
 )
 
-//line app/vmalert/web.qtpl:14
+//line app/vmalert/web.qtpl:15
 var (
 	_ = qtio422016.Copy
 	_ = qt422016.AcquireByteBuffer
 )
 
-//line app/vmalert/web.qtpl:14
+//line app/vmalert/web.qtpl:15
 func StreamWelcome(qw422016 *qt422016.Writer, r *http.Request) {
-//line app/vmalert/web.qtpl:14
+//line app/vmalert/web.qtpl:15
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:15
+//line app/vmalert/web.qtpl:16
 	tpl.StreamHeader(qw422016, r, navItems, "vmalert", getLastConfigError())
-//line app/vmalert/web.qtpl:15
+//line app/vmalert/web.qtpl:16
 	qw422016.N().S(`
     <p>
         API:<br>
         `)
-//line app/vmalert/web.qtpl:18
+//line app/vmalert/web.qtpl:19
 	for _, p := range apiLinks {
-//line app/vmalert/web.qtpl:18
+//line app/vmalert/web.qtpl:19
 		qw422016.N().S(`
             `)
-//line app/vmalert/web.qtpl:19
+//line app/vmalert/web.qtpl:20
 		p, doc := p[0], p[1]
 
-//line app/vmalert/web.qtpl:19
+//line app/vmalert/web.qtpl:20
 		qw422016.N().S(`
             <a href="`)
-//line app/vmalert/web.qtpl:20
+//line app/vmalert/web.qtpl:21
 		qw422016.E().S(p)
-//line app/vmalert/web.qtpl:20
+//line app/vmalert/web.qtpl:21
 		qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:20
+//line app/vmalert/web.qtpl:21
 		qw422016.E().S(p)
-//line app/vmalert/web.qtpl:20
+//line app/vmalert/web.qtpl:21
 		qw422016.N().S(`</a> - `)
-//line app/vmalert/web.qtpl:20
+//line app/vmalert/web.qtpl:21
 		qw422016.E().S(doc)
-//line app/vmalert/web.qtpl:20
+//line app/vmalert/web.qtpl:21
 		qw422016.N().S(`<br/>
         `)
-//line app/vmalert/web.qtpl:21
+//line app/vmalert/web.qtpl:22
 	}
-//line app/vmalert/web.qtpl:21
+//line app/vmalert/web.qtpl:22
 	qw422016.N().S(`
         `)
-//line app/vmalert/web.qtpl:22
+//line app/vmalert/web.qtpl:23
 	if r.Header.Get("X-Forwarded-For") == "" {
-//line app/vmalert/web.qtpl:22
+//line app/vmalert/web.qtpl:23
 		qw422016.N().S(`
             System:<br>
             `)
-//line app/vmalert/web.qtpl:24
+//line app/vmalert/web.qtpl:25
 		for _, p := range systemLinks {
-//line app/vmalert/web.qtpl:24
+//line app/vmalert/web.qtpl:25
 			qw422016.N().S(`
                 `)
-//line app/vmalert/web.qtpl:25
+//line app/vmalert/web.qtpl:26
 			p, doc := p[0], p[1]
 
-//line app/vmalert/web.qtpl:25
+//line app/vmalert/web.qtpl:26
 			qw422016.N().S(`
                 <a href="`)
-//line app/vmalert/web.qtpl:26
+//line app/vmalert/web.qtpl:27
 			qw422016.E().S(p)
-//line app/vmalert/web.qtpl:26
+//line app/vmalert/web.qtpl:27
 			qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:26
+//line app/vmalert/web.qtpl:27
 			qw422016.E().S(p)
-//line app/vmalert/web.qtpl:26
+//line app/vmalert/web.qtpl:27
 			qw422016.N().S(`</a> - `)
-//line app/vmalert/web.qtpl:26
+//line app/vmalert/web.qtpl:27
 			qw422016.E().S(doc)
-//line app/vmalert/web.qtpl:26
+//line app/vmalert/web.qtpl:27
 			qw422016.N().S(`<br/>
             `)
-//line app/vmalert/web.qtpl:27
+//line app/vmalert/web.qtpl:28
 		}
-//line app/vmalert/web.qtpl:27
+//line app/vmalert/web.qtpl:28
 		qw422016.N().S(`
         `)
-//line app/vmalert/web.qtpl:28
+//line app/vmalert/web.qtpl:29
 	}
-//line app/vmalert/web.qtpl:28
+//line app/vmalert/web.qtpl:29
 	qw422016.N().S(`
     </p>
     `)
-//line app/vmalert/web.qtpl:30
+//line app/vmalert/web.qtpl:31
 	tpl.StreamFooter(qw422016, r)
-//line app/vmalert/web.qtpl:30
+//line app/vmalert/web.qtpl:31
 	qw422016.N().S(`
 `)
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 }
 
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 func WriteWelcome(qq422016 qtio422016.Writer, r *http.Request) {
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 	StreamWelcome(qw422016, r)
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 }
 
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 func Welcome(r *http.Request) string {
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 	WriteWelcome(qb422016, r)
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 	return qs422016
-//line app/vmalert/web.qtpl:31
+//line app/vmalert/web.qtpl:32
 }
 
-//line app/vmalert/web.qtpl:33
+//line app/vmalert/web.qtpl:34
 func streambuttonActive(qw422016 *qt422016.Writer, filter, expValue string) {
-//line app/vmalert/web.qtpl:33
+//line app/vmalert/web.qtpl:34
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:34
+//line app/vmalert/web.qtpl:35
 	if filter != expValue {
-//line app/vmalert/web.qtpl:34
+//line app/vmalert/web.qtpl:35
 		qw422016.N().S(`
 btn-secondary
     `)
-//line app/vmalert/web.qtpl:36
+//line app/vmalert/web.qtpl:37
 	} else {
-//line app/vmalert/web.qtpl:36
+//line app/vmalert/web.qtpl:37
 		qw422016.N().S(`
 btn-primary
     `)
-//line app/vmalert/web.qtpl:38
+//line app/vmalert/web.qtpl:39
 	}
-//line app/vmalert/web.qtpl:38
+//line app/vmalert/web.qtpl:39
 	qw422016.N().S(`
 `)
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 }
 
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 func writebuttonActive(qq422016 qtio422016.Writer, filter, expValue string) {
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 	streambuttonActive(qw422016, filter, expValue)
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 }
 
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 func buttonActive(filter, expValue string) string {
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 	writebuttonActive(qb422016, filter, expValue)
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 	return qs422016
-//line app/vmalert/web.qtpl:39
+//line app/vmalert/web.qtpl:40
 }
 
-//line app/vmalert/web.qtpl:41
+//line app/vmalert/web.qtpl:42
 func StreamListGroups(qw422016 *qt422016.Writer, r *http.Request, originGroups []apiGroup) {
-//line app/vmalert/web.qtpl:41
+//line app/vmalert/web.qtpl:42
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:42
+//line app/vmalert/web.qtpl:43
 	prefix := utils.Prefix(r.URL.Path)
 
-//line app/vmalert/web.qtpl:42
+//line app/vmalert/web.qtpl:43
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:43
+//line app/vmalert/web.qtpl:44
 	tpl.StreamHeader(qw422016, r, navItems, "Groups", getLastConfigError())
-//line app/vmalert/web.qtpl:43
+//line app/vmalert/web.qtpl:44
 	qw422016.N().S(`
         `)
-//line app/vmalert/web.qtpl:45
+//line app/vmalert/web.qtpl:46
 	filter := r.URL.Query().Get("filter")
 	rOk := make(map[string]int)
 	rNotOk := make(map[string]int)
@@ -240,26 +241,26 @@ func StreamListGroups(qw422016 *qt422016.Writer, r *http.Request, originGroups [
 		}
 	}
 
-//line app/vmalert/web.qtpl:72
+//line app/vmalert/web.qtpl:73
 	qw422016.N().S(`
         <div class="btn-toolbar mb-3" role="toolbar">
           <div>
             <a class="btn `)
-//line app/vmalert/web.qtpl:75
+//line app/vmalert/web.qtpl:76
 	streambuttonActive(qw422016, filter, "")
-//line app/vmalert/web.qtpl:75
+//line app/vmalert/web.qtpl:76
 	qw422016.N().S(`" role="button" onclick="window.location = window.location.pathname">All</a>
             <a class="btn btn-primary" role="button" onclick="collapseAll()">Collapse All</a>
             <a class="btn btn-primary" role="button" onclick="expandAll()">Expand All</a>
             <a class="btn `)
-//line app/vmalert/web.qtpl:78
+//line app/vmalert/web.qtpl:79
 	streambuttonActive(qw422016, filter, "unhealthy")
-//line app/vmalert/web.qtpl:78
+//line app/vmalert/web.qtpl:79
 	qw422016.N().S(`" role="button" onclick="location.href='?filter=unhealthy'" title="Show only rules with errors">Unhealthy</a>
             <a class="btn `)
-//line app/vmalert/web.qtpl:79
+//line app/vmalert/web.qtpl:80
 	streambuttonActive(qw422016, filter, "noMatch")
-//line app/vmalert/web.qtpl:79
+//line app/vmalert/web.qtpl:80
 	qw422016.N().S(`" role="button" onclick="location.href='?filter=noMatch'" title="Show only rules matching no time series during last evaluation">NoMatch</a>
           </div>
           <div class="col-md-4 col-lg-5">
@@ -274,156 +275,156 @@ func StreamListGroups(qw422016 *qt422016.Writer, r *http.Request, originGroups [
           </div>
         </div>
         `)
-//line app/vmalert/web.qtpl:92
+//line app/vmalert/web.qtpl:93
 	if len(groups) > 0 {
-//line app/vmalert/web.qtpl:92
+//line app/vmalert/web.qtpl:93
 		qw422016.N().S(`
             `)
-//line app/vmalert/web.qtpl:93
+//line app/vmalert/web.qtpl:94
 		for _, g := range groups {
-//line app/vmalert/web.qtpl:93
+//line app/vmalert/web.qtpl:94
 			qw422016.N().S(`
                   <div
                     class="group-heading`)
-//line app/vmalert/web.qtpl:95
+//line app/vmalert/web.qtpl:96
 			if rNotOk[g.ID] > 0 {
-//line app/vmalert/web.qtpl:95
+//line app/vmalert/web.qtpl:96
 				qw422016.N().S(` alert-danger`)
-//line app/vmalert/web.qtpl:95
+//line app/vmalert/web.qtpl:96
 			}
-//line app/vmalert/web.qtpl:95
+//line app/vmalert/web.qtpl:96
 			qw422016.N().S(`" data-bs-target="rules-`)
-//line app/vmalert/web.qtpl:95
+//line app/vmalert/web.qtpl:96
 			qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:95
+//line app/vmalert/web.qtpl:96
 			qw422016.N().S(`" data-group-name="`)
-//line app/vmalert/web.qtpl:95
+//line app/vmalert/web.qtpl:96
 			qw422016.E().S(g.Name)
-//line app/vmalert/web.qtpl:95
+//line app/vmalert/web.qtpl:96
 			qw422016.N().S(`">
                     <span class="anchor" id="group-`)
-//line app/vmalert/web.qtpl:96
+//line app/vmalert/web.qtpl:97
 			qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:96
+//line app/vmalert/web.qtpl:97
 			qw422016.N().S(`"></span>
                     <a href="#group-`)
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 			qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 			qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 			qw422016.E().S(g.Name)
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 			if g.Type != "prometheus" {
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 				qw422016.N().S(` (`)
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 				qw422016.E().S(g.Type)
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 				qw422016.N().S(`)`)
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 			}
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 			qw422016.N().S(` (every `)
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 			qw422016.N().FPrec(g.Interval, 0)
-//line app/vmalert/web.qtpl:97
+//line app/vmalert/web.qtpl:98
 			qw422016.N().S(`s) #</a>
                      `)
-//line app/vmalert/web.qtpl:98
+//line app/vmalert/web.qtpl:99
 			if rNotOk[g.ID] > 0 {
-//line app/vmalert/web.qtpl:98
+//line app/vmalert/web.qtpl:99
 				qw422016.N().S(`<span class="badge bg-danger" title="Number of rules with status Error">`)
-//line app/vmalert/web.qtpl:98
+//line app/vmalert/web.qtpl:99
 				qw422016.N().D(rNotOk[g.ID])
-//line app/vmalert/web.qtpl:98
+//line app/vmalert/web.qtpl:99
 				qw422016.N().S(`</span> `)
-//line app/vmalert/web.qtpl:98
+//line app/vmalert/web.qtpl:99
 			}
-//line app/vmalert/web.qtpl:98
+//line app/vmalert/web.qtpl:99
 			qw422016.N().S(`
                      `)
-//line app/vmalert/web.qtpl:99
+//line app/vmalert/web.qtpl:100
 			if rNoMatch[g.ID] > 0 {
-//line app/vmalert/web.qtpl:99
+//line app/vmalert/web.qtpl:100
 				qw422016.N().S(`<span class="badge bg-warning" title="Number of rules with status NoMatch">`)
-//line app/vmalert/web.qtpl:99
+//line app/vmalert/web.qtpl:100
 				qw422016.N().D(rNoMatch[g.ID])
-//line app/vmalert/web.qtpl:99
+//line app/vmalert/web.qtpl:100
 				qw422016.N().S(`</span> `)
-//line app/vmalert/web.qtpl:99
+//line app/vmalert/web.qtpl:100
 			}
-//line app/vmalert/web.qtpl:99
+//line app/vmalert/web.qtpl:100
 			qw422016.N().S(`
                     <span class="badge bg-success" title="Number of rules withs status Ok">`)
-//line app/vmalert/web.qtpl:100
+//line app/vmalert/web.qtpl:101
 			qw422016.N().D(rOk[g.ID])
-//line app/vmalert/web.qtpl:100
+//line app/vmalert/web.qtpl:101
 			qw422016.N().S(`</span>
                     <p class="fs-6 fw-lighter">`)
-//line app/vmalert/web.qtpl:101
+//line app/vmalert/web.qtpl:102
 			qw422016.E().S(g.File)
-//line app/vmalert/web.qtpl:101
+//line app/vmalert/web.qtpl:102
 			qw422016.N().S(`</p>
                     `)
-//line app/vmalert/web.qtpl:102
+//line app/vmalert/web.qtpl:103
 			if len(g.Params) > 0 {
-//line app/vmalert/web.qtpl:102
+//line app/vmalert/web.qtpl:103
 				qw422016.N().S(`
                         <div class="fs-6 fw-lighter">Extra params
                         `)
-//line app/vmalert/web.qtpl:104
+//line app/vmalert/web.qtpl:105
 				for _, param := range g.Params {
-//line app/vmalert/web.qtpl:104
+//line app/vmalert/web.qtpl:105
 					qw422016.N().S(`
                                 <span class="float-left badge bg-primary">`)
-//line app/vmalert/web.qtpl:105
+//line app/vmalert/web.qtpl:106
 					qw422016.E().S(param)
-//line app/vmalert/web.qtpl:105
+//line app/vmalert/web.qtpl:106
 					qw422016.N().S(`</span>
                         `)
-//line app/vmalert/web.qtpl:106
+//line app/vmalert/web.qtpl:107
 				}
-//line app/vmalert/web.qtpl:106
+//line app/vmalert/web.qtpl:107
 				qw422016.N().S(`
                         </div>
                     `)
-//line app/vmalert/web.qtpl:108
+//line app/vmalert/web.qtpl:109
 			}
-//line app/vmalert/web.qtpl:108
+//line app/vmalert/web.qtpl:109
 			qw422016.N().S(`
                     `)
-//line app/vmalert/web.qtpl:109
+//line app/vmalert/web.qtpl:110
 			if len(g.Headers) > 0 {
-//line app/vmalert/web.qtpl:109
+//line app/vmalert/web.qtpl:110
 				qw422016.N().S(`
                         <div class="fs-6 fw-lighter">Extra headers
                         `)
-//line app/vmalert/web.qtpl:111
+//line app/vmalert/web.qtpl:112
 				for _, header := range g.Headers {
-//line app/vmalert/web.qtpl:111
+//line app/vmalert/web.qtpl:112
 					qw422016.N().S(`
                                 <span class="float-left badge bg-primary">`)
-//line app/vmalert/web.qtpl:112
+//line app/vmalert/web.qtpl:113
 					qw422016.E().S(header)
-//line app/vmalert/web.qtpl:112
+//line app/vmalert/web.qtpl:113
 					qw422016.N().S(`</span>
                         `)
-//line app/vmalert/web.qtpl:113
+//line app/vmalert/web.qtpl:114
 				}
-//line app/vmalert/web.qtpl:113
+//line app/vmalert/web.qtpl:114
 				qw422016.N().S(`
                         </div>
                     `)
-//line app/vmalert/web.qtpl:115
+//line app/vmalert/web.qtpl:116
 			}
-//line app/vmalert/web.qtpl:115
+//line app/vmalert/web.qtpl:116
 			qw422016.N().S(`
                 </div>
                 <div class="collapse rule-table" id="rules-`)
-//line app/vmalert/web.qtpl:117
+//line app/vmalert/web.qtpl:118
 			qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:117
+//line app/vmalert/web.qtpl:118
 			qw422016.N().S(`">
                     <table class="table table-striped table-hover table-sm">
                         <thead>
@@ -435,251 +436,251 @@ func StreamListGroups(qw422016 *qt422016.Writer, r *http.Request, originGroups [
                         </thead>
                         <tbody>
                         `)
-//line app/vmalert/web.qtpl:127
+//line app/vmalert/web.qtpl:128
 			for _, r := range g.Rules {
-//line app/vmalert/web.qtpl:127
+//line app/vmalert/web.qtpl:128
 				qw422016.N().S(`
                             <tr class="rule`)
-//line app/vmalert/web.qtpl:128
+//line app/vmalert/web.qtpl:129
 				if r.LastError != "" {
-//line app/vmalert/web.qtpl:128
+//line app/vmalert/web.qtpl:129
 					qw422016.N().S(` alert-danger`)
-//line app/vmalert/web.qtpl:128
+//line app/vmalert/web.qtpl:129
 				}
-//line app/vmalert/web.qtpl:128
+//line app/vmalert/web.qtpl:129
 				qw422016.N().S(`" data-rule-name="`)
-//line app/vmalert/web.qtpl:128
+//line app/vmalert/web.qtpl:129
 				qw422016.E().S(r.Name)
-//line app/vmalert/web.qtpl:128
+//line app/vmalert/web.qtpl:129
 				qw422016.N().S(`" data-bs-target="`)
-//line app/vmalert/web.qtpl:128
+//line app/vmalert/web.qtpl:129
 				qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:128
+//line app/vmalert/web.qtpl:129
 				qw422016.N().S(`">
                                 <td>
                                     <div class="row">
                                         <div class="col-12 mb-2">
                                             `)
-//line app/vmalert/web.qtpl:132
+//line app/vmalert/web.qtpl:133
 				if r.Type == "alerting" {
-//line app/vmalert/web.qtpl:132
+//line app/vmalert/web.qtpl:133
 					qw422016.N().S(`
                                             `)
-//line app/vmalert/web.qtpl:133
+//line app/vmalert/web.qtpl:134
 					if r.KeepFiringFor > 0 {
-//line app/vmalert/web.qtpl:133
+//line app/vmalert/web.qtpl:134
 						qw422016.N().S(`
                                             <b>alert:</b> `)
-//line app/vmalert/web.qtpl:134
+//line app/vmalert/web.qtpl:135
 						qw422016.E().S(r.Name)
-//line app/vmalert/web.qtpl:134
+//line app/vmalert/web.qtpl:135
 						qw422016.N().S(` (for: `)
-//line app/vmalert/web.qtpl:134
+//line app/vmalert/web.qtpl:135
 						qw422016.E().V(r.Duration)
-//line app/vmalert/web.qtpl:134
+//line app/vmalert/web.qtpl:135
 						qw422016.N().S(` seconds, keep_firing_for: `)
-//line app/vmalert/web.qtpl:134
+//line app/vmalert/web.qtpl:135
 						qw422016.E().V(r.KeepFiringFor)
-//line app/vmalert/web.qtpl:134
+//line app/vmalert/web.qtpl:135
 						qw422016.N().S(` seconds)
                                             `)
-//line app/vmalert/web.qtpl:135
+//line app/vmalert/web.qtpl:136
 					} else {
-//line app/vmalert/web.qtpl:135
+//line app/vmalert/web.qtpl:136
 						qw422016.N().S(`
                                             <b>alert:</b> `)
-//line app/vmalert/web.qtpl:136
+//line app/vmalert/web.qtpl:137
 						qw422016.E().S(r.Name)
-//line app/vmalert/web.qtpl:136
+//line app/vmalert/web.qtpl:137
 						qw422016.N().S(` (for: `)
-//line app/vmalert/web.qtpl:136
+//line app/vmalert/web.qtpl:137
 						qw422016.E().V(r.Duration)
-//line app/vmalert/web.qtpl:136
+//line app/vmalert/web.qtpl:137
 						qw422016.N().S(` seconds)
                                             `)
-//line app/vmalert/web.qtpl:137
+//line app/vmalert/web.qtpl:138
 					}
-//line app/vmalert/web.qtpl:137
+//line app/vmalert/web.qtpl:138
 					qw422016.N().S(`
                                             `)
-//line app/vmalert/web.qtpl:138
+//line app/vmalert/web.qtpl:139
 				} else {
-//line app/vmalert/web.qtpl:138
+//line app/vmalert/web.qtpl:139
 					qw422016.N().S(`
                                             <b>record:</b> `)
-//line app/vmalert/web.qtpl:139
+//line app/vmalert/web.qtpl:140
 					qw422016.E().S(r.Name)
-//line app/vmalert/web.qtpl:139
+//line app/vmalert/web.qtpl:140
 					qw422016.N().S(`
                                             `)
-//line app/vmalert/web.qtpl:140
+//line app/vmalert/web.qtpl:141
 				}
-//line app/vmalert/web.qtpl:140
+//line app/vmalert/web.qtpl:141
 				qw422016.N().S(`
                                             |
                                             `)
-//line app/vmalert/web.qtpl:142
+//line app/vmalert/web.qtpl:143
 				streamseriesFetchedWarn(qw422016, r)
-//line app/vmalert/web.qtpl:142
+//line app/vmalert/web.qtpl:143
 				qw422016.N().S(`
                                             <span><a target="_blank" href="`)
-//line app/vmalert/web.qtpl:143
+//line app/vmalert/web.qtpl:144
 				qw422016.E().S(prefix + r.WebLink())
-//line app/vmalert/web.qtpl:143
+//line app/vmalert/web.qtpl:144
 				qw422016.N().S(`">Details</a></span>
                                         </div>
                                         <div class="col-12">
                                             <code><pre>`)
-//line app/vmalert/web.qtpl:146
+//line app/vmalert/web.qtpl:147
 				qw422016.E().S(r.Query)
-//line app/vmalert/web.qtpl:146
+//line app/vmalert/web.qtpl:147
 				qw422016.N().S(`</pre></code>
                                         </div>
                                         <div class="col-12 mb-2">
                                             `)
-//line app/vmalert/web.qtpl:149
+//line app/vmalert/web.qtpl:150
 				if len(r.Labels) > 0 {
-//line app/vmalert/web.qtpl:149
+//line app/vmalert/web.qtpl:150
 					qw422016.N().S(` <b>Labels:</b>`)
-//line app/vmalert/web.qtpl:149
+//line app/vmalert/web.qtpl:150
 				}
-//line app/vmalert/web.qtpl:149
+//line app/vmalert/web.qtpl:150
 				qw422016.N().S(`
                                             `)
-//line app/vmalert/web.qtpl:150
+//line app/vmalert/web.qtpl:151
 				for k, v := range r.Labels {
-//line app/vmalert/web.qtpl:150
+//line app/vmalert/web.qtpl:151
 					qw422016.N().S(`
                                                     <span class="ms-1 badge bg-primary label">`)
-//line app/vmalert/web.qtpl:151
+//line app/vmalert/web.qtpl:152
 					qw422016.E().S(k)
-//line app/vmalert/web.qtpl:151
+//line app/vmalert/web.qtpl:152
 					qw422016.N().S(`=`)
-//line app/vmalert/web.qtpl:151
+//line app/vmalert/web.qtpl:152
 					qw422016.E().S(v)
-//line app/vmalert/web.qtpl:151
+//line app/vmalert/web.qtpl:152
 					qw422016.N().S(`</span>
                                             `)
-//line app/vmalert/web.qtpl:152
+//line app/vmalert/web.qtpl:153
 				}
-//line app/vmalert/web.qtpl:152
+//line app/vmalert/web.qtpl:153
 				qw422016.N().S(`
                                         </div>
                                         `)
-//line app/vmalert/web.qtpl:154
+//line app/vmalert/web.qtpl:155
 				if r.LastError != "" {
-//line app/vmalert/web.qtpl:154
+//line app/vmalert/web.qtpl:155
 					qw422016.N().S(`
                                         <div class="col-12">
                                             <b>Error:</b>
                                             <div class="error-cell">
                                             `)
-//line app/vmalert/web.qtpl:158
+//line app/vmalert/web.qtpl:159
 					qw422016.E().S(r.LastError)
-//line app/vmalert/web.qtpl:158
+//line app/vmalert/web.qtpl:159
 					qw422016.N().S(`
                                             </div>
                                         </div>
                                         `)
-//line app/vmalert/web.qtpl:161
+//line app/vmalert/web.qtpl:162
 				}
-//line app/vmalert/web.qtpl:161
+//line app/vmalert/web.qtpl:162
 				qw422016.N().S(`
                                     </div>
                                 </td>
                                 <td class="text-center">`)
-//line app/vmalert/web.qtpl:164
+//line app/vmalert/web.qtpl:165
 				qw422016.N().D(r.LastSamples)
-//line app/vmalert/web.qtpl:164
+//line app/vmalert/web.qtpl:165
 				qw422016.N().S(`</td>
                                 <td class="text-center">`)
-//line app/vmalert/web.qtpl:165
+//line app/vmalert/web.qtpl:166
 				qw422016.N().FPrec(time.Since(r.LastEvaluation).Seconds(), 3)
-//line app/vmalert/web.qtpl:165
+//line app/vmalert/web.qtpl:166
 				qw422016.N().S(`s ago</td>
                             </tr>
                         `)
-//line app/vmalert/web.qtpl:167
+//line app/vmalert/web.qtpl:168
 			}
-//line app/vmalert/web.qtpl:167
+//line app/vmalert/web.qtpl:168
 			qw422016.N().S(`
                      </tbody>
                     </table>
                 </div>
             `)
-//line app/vmalert/web.qtpl:171
+//line app/vmalert/web.qtpl:172
 		}
-//line app/vmalert/web.qtpl:171
+//line app/vmalert/web.qtpl:172
 		qw422016.N().S(`
         `)
-//line app/vmalert/web.qtpl:172
+//line app/vmalert/web.qtpl:173
 	} else {
-//line app/vmalert/web.qtpl:172
+//line app/vmalert/web.qtpl:173
 		qw422016.N().S(`
             <div>
                 <p>No groups...</p>
             </div>
         `)
-//line app/vmalert/web.qtpl:176
+//line app/vmalert/web.qtpl:177
 	}
-//line app/vmalert/web.qtpl:176
+//line app/vmalert/web.qtpl:177
 	qw422016.N().S(`
 
     `)
-//line app/vmalert/web.qtpl:178
+//line app/vmalert/web.qtpl:179
 	tpl.StreamFooter(qw422016, r)
-//line app/vmalert/web.qtpl:178
+//line app/vmalert/web.qtpl:179
 	qw422016.N().S(`
 
 `)
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 }
 
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 func WriteListGroups(qq422016 qtio422016.Writer, r *http.Request, originGroups []apiGroup) {
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 	StreamListGroups(qw422016, r, originGroups)
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 }
 
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 func ListGroups(r *http.Request, originGroups []apiGroup) string {
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 	WriteListGroups(qb422016, r, originGroups)
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 	return qs422016
-//line app/vmalert/web.qtpl:180
+//line app/vmalert/web.qtpl:181
 }
 
-//line app/vmalert/web.qtpl:183
+//line app/vmalert/web.qtpl:184
 func StreamListAlerts(qw422016 *qt422016.Writer, r *http.Request, groupAlerts []groupAlerts) {
-//line app/vmalert/web.qtpl:183
+//line app/vmalert/web.qtpl:184
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:184
+//line app/vmalert/web.qtpl:185
 	prefix := utils.Prefix(r.URL.Path)
 
-//line app/vmalert/web.qtpl:184
+//line app/vmalert/web.qtpl:185
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:185
+//line app/vmalert/web.qtpl:186
 	tpl.StreamHeader(qw422016, r, navItems, "Alerts", getLastConfigError())
-//line app/vmalert/web.qtpl:185
+//line app/vmalert/web.qtpl:186
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:186
+//line app/vmalert/web.qtpl:187
 	if len(groupAlerts) > 0 {
-//line app/vmalert/web.qtpl:186
+//line app/vmalert/web.qtpl:187
 		qw422016.N().S(`
          <div class="btn-toolbar mb-3" role="toolbar">
               <div>
@@ -698,63 +699,63 @@ func StreamListAlerts(qw422016 *qt422016.Writer, r *http.Request, groupAlerts []
               </div>
           </div>
          `)
-//line app/vmalert/web.qtpl:203
+//line app/vmalert/web.qtpl:204
 		for _, ga := range groupAlerts {
-//line app/vmalert/web.qtpl:203
+//line app/vmalert/web.qtpl:204
 			qw422016.N().S(`
             `)
-//line app/vmalert/web.qtpl:204
+//line app/vmalert/web.qtpl:205
 			g := ga.Group
 
-//line app/vmalert/web.qtpl:204
+//line app/vmalert/web.qtpl:205
 			qw422016.N().S(`
             <div class="group-heading alert-danger" data-bs-target="rules-`)
-//line app/vmalert/web.qtpl:205
+//line app/vmalert/web.qtpl:206
 			qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:205
+//line app/vmalert/web.qtpl:206
 			qw422016.N().S(`" data-group-name="`)
-//line app/vmalert/web.qtpl:205
+//line app/vmalert/web.qtpl:206
 			qw422016.E().S(g.Name)
-//line app/vmalert/web.qtpl:205
+//line app/vmalert/web.qtpl:206
 			qw422016.N().S(`">
                 <span class="anchor" id="group-`)
-//line app/vmalert/web.qtpl:206
+//line app/vmalert/web.qtpl:207
 			qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:206
+//line app/vmalert/web.qtpl:207
 			qw422016.N().S(`"></span>
                 <a href="#group-`)
-//line app/vmalert/web.qtpl:207
+//line app/vmalert/web.qtpl:208
 			qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:207
+//line app/vmalert/web.qtpl:208
 			qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:207
+//line app/vmalert/web.qtpl:208
 			qw422016.E().S(g.Name)
-//line app/vmalert/web.qtpl:207
+//line app/vmalert/web.qtpl:208
 			if g.Type != "prometheus" {
-//line app/vmalert/web.qtpl:207
+//line app/vmalert/web.qtpl:208
 				qw422016.N().S(` (`)
-//line app/vmalert/web.qtpl:207
+//line app/vmalert/web.qtpl:208
 				qw422016.E().S(g.Type)
-//line app/vmalert/web.qtpl:207
+//line app/vmalert/web.qtpl:208
 				qw422016.N().S(`)`)
-//line app/vmalert/web.qtpl:207
+//line app/vmalert/web.qtpl:208
 			}
-//line app/vmalert/web.qtpl:207
+//line app/vmalert/web.qtpl:208
 			qw422016.N().S(`</a>
                 <span class="badge bg-danger" title="Number of active alerts">`)
-//line app/vmalert/web.qtpl:208
+//line app/vmalert/web.qtpl:209
 			qw422016.N().D(len(ga.Alerts))
-//line app/vmalert/web.qtpl:208
+//line app/vmalert/web.qtpl:209
 			qw422016.N().S(`</span>
                 <br>
                 <p class="fs-6 fw-lighter">`)
-//line app/vmalert/web.qtpl:210
+//line app/vmalert/web.qtpl:211
 			qw422016.E().S(g.File)
-//line app/vmalert/web.qtpl:210
+//line app/vmalert/web.qtpl:211
 			qw422016.N().S(`</p>
             </div>
             `)
-//line app/vmalert/web.qtpl:213
+//line app/vmalert/web.qtpl:214
 			var keys []string
 			alertsByRule := make(map[string][]*apiAlert)
 			for _, alert := range ga.Alerts {
@@ -765,20 +766,20 @@ func StreamListAlerts(qw422016 *qt422016.Writer, r *http.Request, groupAlerts []
 			}
 			sort.Strings(keys)
 
-//line app/vmalert/web.qtpl:222
+//line app/vmalert/web.qtpl:223
 			qw422016.N().S(`
             <div class="collapse rule-table" id="rules-`)
-//line app/vmalert/web.qtpl:223
+//line app/vmalert/web.qtpl:224
 			qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:223
+//line app/vmalert/web.qtpl:224
 			qw422016.N().S(`">
                 `)
-//line app/vmalert/web.qtpl:224
+//line app/vmalert/web.qtpl:225
 			for _, ruleID := range keys {
-//line app/vmalert/web.qtpl:224
+//line app/vmalert/web.qtpl:225
 				qw422016.N().S(`
                     `)
-//line app/vmalert/web.qtpl:226
+//line app/vmalert/web.qtpl:227
 				defaultAR := alertsByRule[ruleID][0]
 				var labelKeys []string
 				for k := range defaultAR.Labels {
@@ -786,37 +787,37 @@ func StreamListAlerts(qw422016 *qt422016.Writer, r *http.Request, groupAlerts []
 				}
 				sort.Strings(labelKeys)
 
-//line app/vmalert/web.qtpl:232
+//line app/vmalert/web.qtpl:233
 				qw422016.N().S(`
                     <br>
                     <div class="rule" data-rule-name="`)
-//line app/vmalert/web.qtpl:234
+//line app/vmalert/web.qtpl:235
 				qw422016.E().S(defaultAR.Name)
-//line app/vmalert/web.qtpl:234
+//line app/vmalert/web.qtpl:235
 				qw422016.N().S(`" data-bs-target="`)
-//line app/vmalert/web.qtpl:234
+//line app/vmalert/web.qtpl:235
 				qw422016.E().S(g.ID)
-//line app/vmalert/web.qtpl:234
+//line app/vmalert/web.qtpl:235
 				qw422016.N().S(`">
                       <b>alert:</b> `)
-//line app/vmalert/web.qtpl:235
+//line app/vmalert/web.qtpl:236
 				qw422016.E().S(defaultAR.Name)
-//line app/vmalert/web.qtpl:235
+//line app/vmalert/web.qtpl:236
 				qw422016.N().S(` (`)
-//line app/vmalert/web.qtpl:235
+//line app/vmalert/web.qtpl:236
 				qw422016.N().D(len(alertsByRule[ruleID]))
-//line app/vmalert/web.qtpl:235
+//line app/vmalert/web.qtpl:236
 				qw422016.N().S(`)
                        | <span><a target="_blank" href="`)
-//line app/vmalert/web.qtpl:236
+//line app/vmalert/web.qtpl:237
 				qw422016.E().S(defaultAR.SourceLink)
-//line app/vmalert/web.qtpl:236
+//line app/vmalert/web.qtpl:237
 				qw422016.N().S(`">Source</a></span>
                       <br>
                       <b>expr:</b><code><pre>`)
-//line app/vmalert/web.qtpl:238
+//line app/vmalert/web.qtpl:239
 				qw422016.E().S(defaultAR.Expression)
-//line app/vmalert/web.qtpl:238
+//line app/vmalert/web.qtpl:239
 				qw422016.N().S(`</pre></code>
                       <table class="table table-striped table-hover table-sm">
                           <thead>
@@ -830,213 +831,222 @@ func StreamListAlerts(qw422016 *qt422016.Writer, r *http.Request, groupAlerts []
                           </thead>
                           <tbody>
                           `)
-//line app/vmalert/web.qtpl:250
+//line app/vmalert/web.qtpl:251
 				for _, ar := range alertsByRule[ruleID] {
-//line app/vmalert/web.qtpl:250
+//line app/vmalert/web.qtpl:251
 					qw422016.N().S(`
                               <tr>
                                   <td>
                                       `)
-//line app/vmalert/web.qtpl:253
+//line app/vmalert/web.qtpl:254
 					for _, k := range labelKeys {
-//line app/vmalert/web.qtpl:253
+//line app/vmalert/web.qtpl:254
 						qw422016.N().S(`
                                           <span class="ms-1 badge bg-primary label">`)
-//line app/vmalert/web.qtpl:254
+//line app/vmalert/web.qtpl:255
 						qw422016.E().S(k)
-//line app/vmalert/web.qtpl:254
+//line app/vmalert/web.qtpl:255
 						qw422016.N().S(`=`)
-//line app/vmalert/web.qtpl:254
+//line app/vmalert/web.qtpl:255
 						qw422016.E().S(ar.Labels[k])
-//line app/vmalert/web.qtpl:254
+//line app/vmalert/web.qtpl:255
 						qw422016.N().S(`</span>
                                       `)
-//line app/vmalert/web.qtpl:255
+//line app/vmalert/web.qtpl:256
 					}
-//line app/vmalert/web.qtpl:255
+//line app/vmalert/web.qtpl:256
 					qw422016.N().S(`
                                   </td>
                                   <td>`)
-//line app/vmalert/web.qtpl:257
+//line app/vmalert/web.qtpl:258
 					streambadgeState(qw422016, ar.State)
-//line app/vmalert/web.qtpl:257
+//line app/vmalert/web.qtpl:258
 					qw422016.N().S(`</td>
                                   <td>
                                       `)
-//line app/vmalert/web.qtpl:259
+//line app/vmalert/web.qtpl:260
 					qw422016.E().S(ar.ActiveAt.Format("2006-01-02T15:04:05Z07:00"))
-//line app/vmalert/web.qtpl:259
+//line app/vmalert/web.qtpl:260
 					qw422016.N().S(`
                                       `)
-//line app/vmalert/web.qtpl:260
+//line app/vmalert/web.qtpl:261
 					if ar.Restored {
-//line app/vmalert/web.qtpl:260
+//line app/vmalert/web.qtpl:261
 						streambadgeRestored(qw422016)
-//line app/vmalert/web.qtpl:260
+//line app/vmalert/web.qtpl:261
 					}
-//line app/vmalert/web.qtpl:260
+//line app/vmalert/web.qtpl:261
 					qw422016.N().S(`
                                       `)
-//line app/vmalert/web.qtpl:261
+//line app/vmalert/web.qtpl:262
 					if ar.Stabilizing {
-//line app/vmalert/web.qtpl:261
+//line app/vmalert/web.qtpl:262
 						streambadgeStabilizing(qw422016)
-//line app/vmalert/web.qtpl:261
+//line app/vmalert/web.qtpl:262
 					}
-//line app/vmalert/web.qtpl:261
+//line app/vmalert/web.qtpl:262
+					qw422016.N().S(`
+                                      `)
+//line app/vmalert/web.qtpl:263
+					if ar.Suppressed {
+//line app/vmalert/web.qtpl:263
+						streambadgeSuppressed(qw422016, ar)
+//line app/vmalert/web.qtpl:263
+					}
+//line app/vmalert/web.qtpl:263
 					qw422016.N().S(`
                                   </td>
                                   <td>`)
-//line app/vmalert/web.qtpl:263
+//line app/vmalert/web.qtpl:265
 					qw422016.E().S(ar.Value)
-//line app/vmalert/web.qtpl:263
+//line app/vmalert/web.qtpl:265
 					qw422016.N().S(`</td>
                                   <td>
                                       <a href="`)
-//line app/vmalert/web.qtpl:265
+//line app/vmalert/web.qtpl:267
 					qw422016.E().S(prefix + ar.WebLink())
-//line app/vmalert/web.qtpl:265
+//line app/vmalert/web.qtpl:267
 					qw422016.N().S(`">Details</a>
                                   </td>
                               </tr>
                           `)
-//line app/vmalert/web.qtpl:268
+//line app/vmalert/web.qtpl:270
 				}
-//line app/vmalert/web.qtpl:268
+//line app/vmalert/web.qtpl:270
 				qw422016.N().S(`
                        </tbody>
                       </table>
                     </div>
                 `)
-//line app/vmalert/web.qtpl:272
+//line app/vmalert/web.qtpl:274
 			}
-//line app/vmalert/web.qtpl:272
+//line app/vmalert/web.qtpl:274
 			qw422016.N().S(`
             </div>
         `)
-//line app/vmalert/web.qtpl:274
+//line app/vmalert/web.qtpl:276
 		}
-//line app/vmalert/web.qtpl:274
+//line app/vmalert/web.qtpl:276
 		qw422016.N().S(`
 
     `)
-//line app/vmalert/web.qtpl:276
+//line app/vmalert/web.qtpl:278
 	} else {
-//line app/vmalert/web.qtpl:276
+//line app/vmalert/web.qtpl:278
 		qw422016.N().S(`
         <div>
             <p>No active alerts...</p>
         </div>
     `)
-//line app/vmalert/web.qtpl:280
+//line app/vmalert/web.qtpl:282
 	}
-//line app/vmalert/web.qtpl:280
+//line app/vmalert/web.qtpl:282
 	qw422016.N().S(`
 
     `)
-//line app/vmalert/web.qtpl:282
+//line app/vmalert/web.qtpl:284
 	tpl.StreamFooter(qw422016, r)
-//line app/vmalert/web.qtpl:282
+//line app/vmalert/web.qtpl:284
 	qw422016.N().S(`
 
 `)
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 }
 
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 func WriteListAlerts(qq422016 qtio422016.Writer, r *http.Request, groupAlerts []groupAlerts) {
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 	StreamListAlerts(qw422016, r, groupAlerts)
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 }
 
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 func ListAlerts(r *http.Request, groupAlerts []groupAlerts) string {
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 	WriteListAlerts(qb422016, r, groupAlerts)
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 	return qs422016
-//line app/vmalert/web.qtpl:284
+//line app/vmalert/web.qtpl:286
 }
 
-//line app/vmalert/web.qtpl:286
+//line app/vmalert/web.qtpl:288
 func StreamListTargets(qw422016 *qt422016.Writer, r *http.Request, targets map[notifier.TargetType][]notifier.Target) {
-//line app/vmalert/web.qtpl:286
+//line app/vmalert/web.qtpl:288
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:287
+//line app/vmalert/web.qtpl:289
 	tpl.StreamHeader(qw422016, r, navItems, "Notifiers", getLastConfigError())
-//line app/vmalert/web.qtpl:287
+//line app/vmalert/web.qtpl:289
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:288
+//line app/vmalert/web.qtpl:290
 	if len(targets) > 0 {
-//line app/vmalert/web.qtpl:288
+//line app/vmalert/web.qtpl:290
 		qw422016.N().S(`
          <a class="btn btn-primary" role="button" onclick="collapseAll()">Collapse All</a>
          <a class="btn btn-primary" role="button" onclick="expandAll()">Expand All</a>
 
          `)
-//line app/vmalert/web.qtpl:293
+//line app/vmalert/web.qtpl:295
 		var keys []string
 		for key := range targets {
 			keys = append(keys, string(key))
 		}
 		sort.Strings(keys)
 
-//line app/vmalert/web.qtpl:298
+//line app/vmalert/web.qtpl:300
 		qw422016.N().S(`
 
          `)
-//line app/vmalert/web.qtpl:300
+//line app/vmalert/web.qtpl:302
 		for i := range keys {
-//line app/vmalert/web.qtpl:300
+//line app/vmalert/web.qtpl:302
 			qw422016.N().S(`
            `)
-//line app/vmalert/web.qtpl:301
+//line app/vmalert/web.qtpl:303
 			typeK, ns := keys[i], targets[notifier.TargetType(keys[i])]
 			count := len(ns)
 
-//line app/vmalert/web.qtpl:303
+//line app/vmalert/web.qtpl:305
 			qw422016.N().S(`
            <div class="group-heading" data-bs-target="notifiers-`)
-//line app/vmalert/web.qtpl:304
+//line app/vmalert/web.qtpl:306
 			qw422016.E().S(typeK)
-//line app/vmalert/web.qtpl:304
+//line app/vmalert/web.qtpl:306
 			qw422016.N().S(`">
              <span class="anchor" id="group-`)
-//line app/vmalert/web.qtpl:305
+//line app/vmalert/web.qtpl:307
 			qw422016.E().S(typeK)
-//line app/vmalert/web.qtpl:305
+//line app/vmalert/web.qtpl:307
 			qw422016.N().S(`"></span>
              <a href="#group-`)
-//line app/vmalert/web.qtpl:306
+//line app/vmalert/web.qtpl:308
 			qw422016.E().S(typeK)
-//line app/vmalert/web.qtpl:306
+//line app/vmalert/web.qtpl:308
 			qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:306
+//line app/vmalert/web.qtpl:308
 			qw422016.E().S(typeK)
-//line app/vmalert/web.qtpl:306
+//line app/vmalert/web.qtpl:308
 			qw422016.N().S(` (`)
-//line app/vmalert/web.qtpl:306
+//line app/vmalert/web.qtpl:308
 			qw422016.N().D(count)
-//line app/vmalert/web.qtpl:306
+//line app/vmalert/web.qtpl:308
 			qw422016.N().S(`)</a>
          </div>
          <div class="collapse show" id="notifiers-`)
-//line app/vmalert/web.qtpl:308
+//line app/vmalert/web.qtpl:310
 			qw422016.E().S(typeK)
-//line app/vmalert/web.qtpl:308
+//line app/vmalert/web.qtpl:310
 			qw422016.N().S(`">
              <table class="table table-striped table-hover table-sm">
                  <thead>
@@ -1047,119 +1057,119 @@ func StreamListTargets(qw422016 *qt422016.Writer, r *http.Request, targets map[n
                  </thead>
                  <tbody>
                  `)
-//line app/vmalert/web.qtpl:317
+//line app/vmalert/web.qtpl:319
 			for _, n := range ns {
-//line app/vmalert/web.qtpl:317
+//line app/vmalert/web.qtpl:319
 				qw422016.N().S(`
                      <tr>
                          <td>
                               `)
-//line app/vmalert/web.qtpl:320
+//line app/vmalert/web.qtpl:322
 				for _, l := range n.Labels.GetLabels() {
-//line app/vmalert/web.qtpl:320
+//line app/vmalert/web.qtpl:322
 					qw422016.N().S(`
                                       <span class="ms-1 badge bg-primary">`)
-//line app/vmalert/web.qtpl:321
+//line app/vmalert/web.qtpl:323
 					qw422016.E().S(l.Name)
-//line app/vmalert/web.qtpl:321
+//line app/vmalert/web.qtpl:323
 					qw422016.N().S(`=`)
-//line app/vmalert/web.qtpl:321
+//line app/vmalert/web.qtpl:323
 					qw422016.E().S(l.Value)
-//line app/vmalert/web.qtpl:321
+//line app/vmalert/web.qtpl:323
 					qw422016.N().S(`</span>
                               `)
-//line app/vmalert/web.qtpl:322
+//line app/vmalert/web.qtpl:324
 				}
-//line app/vmalert/web.qtpl:322
+//line app/vmalert/web.qtpl:324
 				qw422016.N().S(`
                           </td>
                          <td>`)
-//line app/vmalert/web.qtpl:324
+//line app/vmalert/web.qtpl:326
 				qw422016.E().S(n.Notifier.Addr())
-//line app/vmalert/web.qtpl:324
+//line app/vmalert/web.qtpl:326
 				qw422016.N().S(`</td>
                      </tr>
                  `)
-//line app/vmalert/web.qtpl:326
+//line app/vmalert/web.qtpl:328
 			}
-//line app/vmalert/web.qtpl:326
+//line app/vmalert/web.qtpl:328
 			qw422016.N().S(`
               </tbody>
              </table>
          </div>
      `)
-//line app/vmalert/web.qtpl:330
+//line app/vmalert/web.qtpl:332
 		}
-//line app/vmalert/web.qtpl:330
+//line app/vmalert/web.qtpl:332
 		qw422016.N().S(`
 
     `)
-//line app/vmalert/web.qtpl:332
+//line app/vmalert/web.qtpl:334
 	} else {
-//line app/vmalert/web.qtpl:332
+//line app/vmalert/web.qtpl:334
 		qw422016.N().S(`
         <div>
             <p>No targets...</p>
         </div>
     `)
-//line app/vmalert/web.qtpl:336
+//line app/vmalert/web.qtpl:338
 	}
-//line app/vmalert/web.qtpl:336
+//line app/vmalert/web.qtpl:338
 	qw422016.N().S(`
 
     `)
-//line app/vmalert/web.qtpl:338
+//line app/vmalert/web.qtpl:340
 	tpl.StreamFooter(qw422016, r)
-//line app/vmalert/web.qtpl:338
+//line app/vmalert/web.qtpl:340
 	qw422016.N().S(`
 
 `)
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 }
 
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 func WriteListTargets(qq422016 qtio422016.Writer, r *http.Request, targets map[notifier.TargetType][]notifier.Target) {
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 	StreamListTargets(qw422016, r, targets)
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 }
 
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 func ListTargets(r *http.Request, targets map[notifier.TargetType][]notifier.Target) string {
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 	WriteListTargets(qb422016, r, targets)
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 	return qs422016
-//line app/vmalert/web.qtpl:340
+//line app/vmalert/web.qtpl:342
 }
 
-//line app/vmalert/web.qtpl:342
+//line app/vmalert/web.qtpl:344
 func StreamAlert(qw422016 *qt422016.Writer, r *http.Request, alert *apiAlert) {
-//line app/vmalert/web.qtpl:342
+//line app/vmalert/web.qtpl:344
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:343
+//line app/vmalert/web.qtpl:345
 	prefix := utils.Prefix(r.URL.Path)
 
-//line app/vmalert/web.qtpl:343
+//line app/vmalert/web.qtpl:345
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:344
+//line app/vmalert/web.qtpl:346
 	tpl.StreamHeader(qw422016, r, navItems, "", getLastConfigError())
-//line app/vmalert/web.qtpl:344
+//line app/vmalert/web.qtpl:346
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:346
+//line app/vmalert/web.qtpl:348
 	var labelKeys []string
 	for k := range alert.Labels {
 		labelKeys = append(labelKeys, k)
@@ -1172,29 +1182,41 @@ func StreamAlert(qw422016 *qt422016.Writer, r *http.Request, alert *apiAlert) {
 	}
 	sort.Strings(annotationKeys)
 
-//line app/vmalert/web.qtpl:357
+//line app/vmalert/web.qtpl:359
 	qw422016.N().S(`
     <div class="display-6 pb-3 mb-3">Alert: `)
-//line app/vmalert/web.qtpl:358
+//line app/vmalert/web.qtpl:360
 	qw422016.E().S(alert.Name)
-//line app/vmalert/web.qtpl:358
+//line app/vmalert/web.qtpl:360
 	qw422016.N().S(`<span class="ms-2 badge `)
-//line app/vmalert/web.qtpl:358
+//line app/vmalert/web.qtpl:360
 	if alert.State == "firing" {
-//line app/vmalert/web.qtpl:358
+//line app/vmalert/web.qtpl:360
 		qw422016.N().S(`bg-danger`)
-//line app/vmalert/web.qtpl:358
+//line app/vmalert/web.qtpl:360
 	} else {
-//line app/vmalert/web.qtpl:358
+//line app/vmalert/web.qtpl:360
 		qw422016.N().S(` bg-warning text-dark`)
-//line app/vmalert/web.qtpl:358
+//line app/vmalert/web.qtpl:360
 	}
-//line app/vmalert/web.qtpl:358
+//line app/vmalert/web.qtpl:360
 	qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:358
+//line app/vmalert/web.qtpl:360
 	qw422016.E().S(alert.State)
-//line app/vmalert/web.qtpl:358
-	qw422016.N().S(`</span></div>
+//line app/vmalert/web.qtpl:360
+	qw422016.N().S(`</span>`)
+//line app/vmalert/web.qtpl:360
+	if alert.Suppressed {
+//line app/vmalert/web.qtpl:360
+		qw422016.N().S(`<span class="ms-2">`)
+//line app/vmalert/web.qtpl:360
+		streambadgeSuppressed(qw422016, alert)
+//line app/vmalert/web.qtpl:360
+		qw422016.N().S(`</span>`)
+//line app/vmalert/web.qtpl:360
+	}
+//line app/vmalert/web.qtpl:360
+	qw422016.N().S(`</div>
     <div class="container border-bottom p-2">
       <div class="row">
         <div class="col-2">
@@ -1202,9 +1224,9 @@ func StreamAlert(qw422016 *qt422016.Writer, r *http.Request, alert *apiAlert) {
         </div>
         <div class="col">
           `)
-//line app/vmalert/web.qtpl:365
+//line app/vmalert/web.qtpl:367
 	qw422016.E().S(alert.ActiveAt.Format("2006-01-02T15:04:05Z07:00"))
-//line app/vmalert/web.qtpl:365
+//line app/vmalert/web.qtpl:367
 	qw422016.N().S(`
         </div>
       </div>
@@ -1216,9 +1238,9 @@ func StreamAlert(qw422016 *qt422016.Writer, r *http.Request, alert *apiAlert) {
         </div>
         <div class="col">
           <code><pre>`)
-//line app/vmalert/web.qtpl:375
+//line app/vmalert/web.qtpl:377
 	qw422016.E().S(alert.Expression)
-//line app/vmalert/web.qtpl:375
+//line app/vmalert/web.qtpl:377
 	qw422016.N().S(`</pre></code>
         </div>
       </div>
@@ -1230,23 +1252,23 @@ func StreamAlert(qw422016 *qt422016.Writer, r *http.Request, alert *apiAlert) {
         </div>
         <div class="col">
            `)
-//line app/vmalert/web.qtpl:385
+//line app/vmalert/web.qtpl:387
 	for _, k := range labelKeys {
-//line app/vmalert/web.qtpl:385
+//line app/vmalert/web.qtpl:387
 		qw422016.N().S(`
                 <span class="m-1 badge bg-primary">`)
-//line app/vmalert/web.qtpl:386
+//line app/vmalert/web.qtpl:388
 		qw422016.E().S(k)
-//line app/vmalert/web.qtpl:386
+//line app/vmalert/web.qtpl:388
 		qw422016.N().S(`=`)
-//line app/vmalert/web.qtpl:386
+//line app/vmalert/web.qtpl:388
 		qw422016.E().S(alert.Labels[k])
-//line app/vmalert/web.qtpl:386
+//line app/vmalert/web.qtpl:388
 		qw422016.N().S(`</span>
           `)
-//line app/vmalert/web.qtpl:387
+//line app/vmalert/web.qtpl:389
 	}
-//line app/vmalert/web.qtpl:387
+//line app/vmalert/web.qtpl:389
 	qw422016.N().S(`
         </div>
       </div>
@@ -1258,24 +1280,24 @@ func StreamAlert(qw422016 *qt422016.Writer, r *http.Request, alert *apiAlert) {
         </div>
         <div class="col">
            `)
-//line app/vmalert/web.qtpl:397
+//line app/vmalert/web.qtpl:399
 	for _, k := range annotationKeys {
-//line app/vmalert/web.qtpl:397
+//line app/vmalert/web.qtpl:399
 		qw422016.N().S(`
                 <b>`)
-//line app/vmalert/web.qtpl:398
+//line app/vmalert/web.qtpl:400
 		qw422016.E().S(k)
-//line app/vmalert/web.qtpl:398
+//line app/vmalert/web.qtpl:400
 		qw422016.N().S(`:</b><br>
                 <p>`)
-//line app/vmalert/web.qtpl:399
+//line app/vmalert/web.qtpl:401
 		qw422016.E().S(alert.Annotations[k])
-//line app/vmalert/web.qtpl:399
+//line app/vmalert/web.qtpl:401
 		qw422016.N().S(`</p>
           `)
-//line app/vmalert/web.qtpl:400
+//line app/vmalert/web.qtpl:402
 	}
-//line app/vmalert/web.qtpl:400
+//line app/vmalert/web.qtpl:402
 	qw422016.N().S(`
         </div>
       </div>
@@ -1287,17 +1309,17 @@ func StreamAlert(qw422016 *qt422016.Writer, r *http.Request, alert *apiAlert) {
         </div>
         <div class="col">
            <a target="_blank" href="`)
-//line app/vmalert/web.qtpl:410
+//line app/vmalert/web.qtpl:412
 	qw422016.E().S(prefix)
-//line app/vmalert/web.qtpl:410
+//line app/vmalert/web.qtpl:412
 	qw422016.N().S(`groups#group-`)
-//line app/vmalert/web.qtpl:410
+//line app/vmalert/web.qtpl:412
 	qw422016.E().S(alert.GroupID)
-//line app/vmalert/web.qtpl:410
+//line app/vmalert/web.qtpl:412
 	qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:410
+//line app/vmalert/web.qtpl:412
 	qw422016.E().S(alert.GroupID)
-//line app/vmalert/web.qtpl:410
+//line app/vmalert/web.qtpl:412
 	qw422016.N().S(`</a>
         </div>
       </div>
@@ -1309,66 +1331,66 @@ func StreamAlert(qw422016 *qt422016.Writer, r *http.Request, alert *apiAlert) {
         </div>
         <div class="col">
            <a target="_blank" href="`)
-//line app/vmalert/web.qtpl:420
+//line app/vmalert/web.qtpl:422
 	qw422016.E().S(alert.SourceLink)
-//line app/vmalert/web.qtpl:420
+//line app/vmalert/web.qtpl:422
 	qw422016.N().S(`">Link</a>
         </div>
       </div>
     </div>
     `)
-//line app/vmalert/web.qtpl:424
+//line app/vmalert/web.qtpl:426
 	tpl.StreamFooter(qw422016, r)
-//line app/vmalert/web.qtpl:424
+//line app/vmalert/web.qtpl:426
 	qw422016.N().S(`
 
 `)
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 }
 
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 func WriteAlert(qq422016 qtio422016.Writer, r *http.Request, alert *apiAlert) {
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 	StreamAlert(qw422016, r, alert)
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 }
 
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 func Alert(r *http.Request, alert *apiAlert) string {
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 	WriteAlert(qb422016, r, alert)
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 	return qs422016
-//line app/vmalert/web.qtpl:426
+//line app/vmalert/web.qtpl:428
 }
 
-//line app/vmalert/web.qtpl:429
+//line app/vmalert/web.qtpl:431
 func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule) {
-//line app/vmalert/web.qtpl:429
+//line app/vmalert/web.qtpl:431
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:430
+//line app/vmalert/web.qtpl:432
 	prefix := utils.Prefix(r.URL.Path)
 
-//line app/vmalert/web.qtpl:430
+//line app/vmalert/web.qtpl:432
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:431
+//line app/vmalert/web.qtpl:433
 	tpl.StreamHeader(qw422016, r, navItems, "", getLastConfigError())
-//line app/vmalert/web.qtpl:431
+//line app/vmalert/web.qtpl:433
 	qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:433
+//line app/vmalert/web.qtpl:435
 	var labelKeys []string
 	for k := range rule.Labels {
 		labelKeys = append(labelKeys, k)
@@ -1392,28 +1414,28 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
 		}
 	}
 
-//line app/vmalert/web.qtpl:456
+//line app/vmalert/web.qtpl:458
 	qw422016.N().S(`
     <div class="display-6 pb-3 mb-3">Rule: `)
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 	qw422016.E().S(rule.Name)
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 	qw422016.N().S(`<span class="ms-2 badge `)
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 	if rule.Health != "ok" {
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 		qw422016.N().S(`bg-danger`)
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 	} else {
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 		qw422016.N().S(` bg-success text-dark`)
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 	}
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 	qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 	qw422016.E().S(rule.Health)
-//line app/vmalert/web.qtpl:457
+//line app/vmalert/web.qtpl:459
 	qw422016.N().S(`</span></div>
     <div class="container border-bottom p-2">
       <div class="row">
@@ -1422,17 +1444,17 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
         </div>
         <div class="col">
           <code><pre>`)
-//line app/vmalert/web.qtpl:464
+//line app/vmalert/web.qtpl:466
 	qw422016.E().S(rule.Query)
-//line app/vmalert/web.qtpl:464
+//line app/vmalert/web.qtpl:466
 	qw422016.N().S(`</pre></code>
         </div>
       </div>
     </div>
     `)
-//line app/vmalert/web.qtpl:468
+//line app/vmalert/web.qtpl:470
 	if rule.Type == "alerting" {
-//line app/vmalert/web.qtpl:468
+//line app/vmalert/web.qtpl:470
 		qw422016.N().S(`
     <div class="container border-bottom p-2">
       <div class="row">
@@ -1441,17 +1463,17 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
         </div>
         <div class="col">
          `)
-//line app/vmalert/web.qtpl:475
+//line app/vmalert/web.qtpl:477
 		qw422016.E().V(rule.Duration)
-//line app/vmalert/web.qtpl:475
+//line app/vmalert/web.qtpl:477
 		qw422016.N().S(` seconds
         </div>
       </div>
     </div>
     `)
-//line app/vmalert/web.qtpl:479
+//line app/vmalert/web.qtpl:481
 		if rule.KeepFiringFor > 0 {
-//line app/vmalert/web.qtpl:479
+//line app/vmalert/web.qtpl:481
 			qw422016.N().S(`
     <div class="container border-bottom p-2">
       <div class="row">
@@ -1460,22 +1482,22 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
         </div>
         <div class="col">
          `)
-//line app/vmalert/web.qtpl:486
+//line app/vmalert/web.qtpl:488
 			qw422016.E().V(rule.KeepFiringFor)
-//line app/vmalert/web.qtpl:486
+//line app/vmalert/web.qtpl:488
 			qw422016.N().S(` seconds
         </div>
       </div>
     </div>
     `)
-//line app/vmalert/web.qtpl:490
+//line app/vmalert/web.qtpl:492
 		}
-//line app/vmalert/web.qtpl:490
+//line app/vmalert/web.qtpl:492
 		qw422016.N().S(`
     `)
-//line app/vmalert/web.qtpl:491
+//line app/vmalert/web.qtpl:493
 	}
-//line app/vmalert/web.qtpl:491
+//line app/vmalert/web.qtpl:493
 	qw422016.N().S(`
     <div class="container border-bottom p-2">
       <div class="row">
@@ -1484,31 +1506,31 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
         </div>
         <div class="col">
           `)
-//line app/vmalert/web.qtpl:498
+//line app/vmalert/web.qtpl:500
 	for _, k := range labelKeys {
-//line app/vmalert/web.qtpl:498
+//line app/vmalert/web.qtpl:500
 		qw422016.N().S(`
                 <span class="m-1 badge bg-primary">`)
-//line app/vmalert/web.qtpl:499
+//line app/vmalert/web.qtpl:501
 		qw422016.E().S(k)
-//line app/vmalert/web.qtpl:499
+//line app/vmalert/web.qtpl:501
 		qw422016.N().S(`=`)
-//line app/vmalert/web.qtpl:499
+//line app/vmalert/web.qtpl:501
 		qw422016.E().S(rule.Labels[k])
-//line app/vmalert/web.qtpl:499
+//line app/vmalert/web.qtpl:501
 		qw422016.N().S(`</span>
           `)
-//line app/vmalert/web.qtpl:500
+//line app/vmalert/web.qtpl:502
 	}
-//line app/vmalert/web.qtpl:500
+//line app/vmalert/web.qtpl:502
 	qw422016.N().S(`
         </div>
       </div>
     </div>
     `)
-//line app/vmalert/web.qtpl:504
+//line app/vmalert/web.qtpl:506
 	if rule.Type == "alerting" {
-//line app/vmalert/web.qtpl:504
+//line app/vmalert/web.qtpl:506
 		qw422016.N().S(`
     <div class="container border-bottom p-2">
       <div class="row">
@@ -1517,24 +1539,24 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
         </div>
         <div class="col">
           `)
-//line app/vmalert/web.qtpl:511
+//line app/vmalert/web.qtpl:513
 		for _, k := range annotationKeys {
-//line app/vmalert/web.qtpl:511
+//line app/vmalert/web.qtpl:513
 			qw422016.N().S(`
                 <b>`)
-//line app/vmalert/web.qtpl:512
+//line app/vmalert/web.qtpl:514
 			qw422016.E().S(k)
-//line app/vmalert/web.qtpl:512
+//line app/vmalert/web.qtpl:514
 			qw422016.N().S(`:</b><br>
                 <p>`)
-//line app/vmalert/web.qtpl:513
+//line app/vmalert/web.qtpl:515
 			qw422016.E().S(rule.Annotations[k])
-//line app/vmalert/web.qtpl:513
+//line app/vmalert/web.qtpl:515
 			qw422016.N().S(`</p>
           `)
-//line app/vmalert/web.qtpl:514
+//line app/vmalert/web.qtpl:516
 		}
-//line app/vmalert/web.qtpl:514
+//line app/vmalert/web.qtpl:516
 		qw422016.N().S(`
         </div>
       </div>
@@ -1546,17 +1568,17 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
         </div>
         <div class="col">
            `)
-//line app/vmalert/web.qtpl:524
+//line app/vmalert/web.qtpl:526
 		qw422016.E().V(rule.Debug)
-//line app/vmalert/web.qtpl:524
+//line app/vmalert/web.qtpl:526
 		qw422016.N().S(`
         </div>
       </div>
     </div>
     `)
-//line app/vmalert/web.qtpl:528
+//line app/vmalert/web.qtpl:530
 	}
-//line app/vmalert/web.qtpl:528
+//line app/vmalert/web.qtpl:530
 	qw422016.N().S(`
     <div class="container border-bottom p-2">
       <div class="row">
@@ -1565,17 +1587,17 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
         </div>
         <div class="col">
            <a target="_blank" href="`)
-//line app/vmalert/web.qtpl:535
+//line app/vmalert/web.qtpl:537
 	qw422016.E().S(prefix)
-//line app/vmalert/web.qtpl:535
+//line app/vmalert/web.qtpl:537
 	qw422016.N().S(`groups#group-`)
-//line app/vmalert/web.qtpl:535
+//line app/vmalert/web.qtpl:537
 	qw422016.E().S(rule.GroupID)
-//line app/vmalert/web.qtpl:535
+//line app/vmalert/web.qtpl:537
 	qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:535
+//line app/vmalert/web.qtpl:537
 	qw422016.E().S(rule.GroupID)
-//line app/vmalert/web.qtpl:535
+//line app/vmalert/web.qtpl:537
 	qw422016.N().S(`</a>
         </div>
       </div>
@@ -1583,9 +1605,9 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
 
     <br>
     `)
-//line app/vmalert/web.qtpl:541
+//line app/vmalert/web.qtpl:543
 	if seriesFetchedWarning {
-//line app/vmalert/web.qtpl:541
+//line app/vmalert/web.qtpl:543
 		qw422016.N().S(`
     <div class="alert alert-warning" role="alert">
        <strong>Warning:</strong> some of updates have "Series fetched" equal to 0.<br>
@@ -1599,18 +1621,18 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
        See more details about this detection <a target="_blank" href="https://github.com/VictoriaMetrics/VictoriaMetrics/issues/4039">here</a>.
     </div>
     `)
-//line app/vmalert/web.qtpl:553
+//line app/vmalert/web.qtpl:555
 	}
-//line app/vmalert/web.qtpl:553
+//line app/vmalert/web.qtpl:555
 	qw422016.N().S(`
     <div class="display-6 pb-3">Last `)
-//line app/vmalert/web.qtpl:554
+//line app/vmalert/web.qtpl:556
 	qw422016.N().D(len(rule.Updates))
-//line app/vmalert/web.qtpl:554
+//line app/vmalert/web.qtpl:556
 	qw422016.N().S(`/`)
-//line app/vmalert/web.qtpl:554
+//line app/vmalert/web.qtpl:556
 	qw422016.N().D(rule.MaxUpdates)
-//line app/vmalert/web.qtpl:554
+//line app/vmalert/web.qtpl:556
 	qw422016.N().S(` updates</span>:</div>
         <table class="table table-striped table-hover table-sm">
             <thead>
@@ -1618,13 +1640,13 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
                     <th scope="col" title="The time when event was created">Updated at</th>
                     <th scope="col" style="width: 10%" class="text-center" title="How many samples were returned">Samples</th>
                     `)
-//line app/vmalert/web.qtpl:560
+//line app/vmalert/web.qtpl:562
 	if seriesFetchedEnabled {
-//line app/vmalert/web.qtpl:560
+//line app/vmalert/web.qtpl:562
 		qw422016.N().S(`<th scope="col" style="width: 10%" class="text-center" title="How many series were scanned by datasource during the evaluation">Series fetched</th>`)
-//line app/vmalert/web.qtpl:560
+//line app/vmalert/web.qtpl:562
 	}
-//line app/vmalert/web.qtpl:560
+//line app/vmalert/web.qtpl:562
 	qw422016.N().S(`
                     <th scope="col" style="width: 10%" class="text-center" title="How many seconds request took">Duration</th>
                     <th scope="col" class="text-center" title="Time used for rule execution">Executed at</th>
@@ -1634,285 +1656,349 @@ func StreamRuleDetails(qw422016 *qt422016.Writer, r *http.Request, rule apiRule)
             <tbody>
 
      `)
-//line app/vmalert/web.qtpl:568
+//line app/vmalert/web.qtpl:570
 	for _, u := range rule.Updates {
-//line app/vmalert/web.qtpl:568
+//line app/vmalert/web.qtpl:570
 		qw422016.N().S(`
              <tr`)
-//line app/vmalert/web.qtpl:569
+//line app/vmalert/web.qtpl:571
 		if u.Err != nil {
-//line app/vmalert/web.qtpl:569
+//line app/vmalert/web.qtpl:571
 			qw422016.N().S(` class="alert-danger"`)
-//line app/vmalert/web.qtpl:569
+//line app/vmalert/web.qtpl:571
 		}
-//line app/vmalert/web.qtpl:569
+//line app/vmalert/web.qtpl:571
 		qw422016.N().S(`>
                  <td>
                     <span class="badge bg-primary rounded-pill me-3" title="Updated at">`)
-//line app/vmalert/web.qtpl:571
+//line app/vmalert/web.qtpl:573
 		qw422016.E().S(u.Time.Format(time.RFC3339))
-//line app/vmalert/web.qtpl:571
+//line app/vmalert/web.qtpl:573
 		qw422016.N().S(`</span>
                  </td>
                  <td class="text-center">`)
-//line app/vmalert/web.qtpl:573
+//line app/vmalert/web.qtpl:575
 		qw422016.N().D(u.Samples)
-//line app/vmalert/web.qtpl:573
+//line app/vmalert/web.qtpl:575
 		qw422016.N().S(`</td>
                  `)
-//line app/vmalert/web.qtpl:574
+//line app/vmalert/web.qtpl:576
 		if seriesFetchedEnabled {
-//line app/vmalert/web.qtpl:574
+//line app/vmalert/web.qtpl:576
 			qw422016.N().S(`<td class="text-center">`)
-//line app/vmalert/web.qtpl:574
+//line app/vmalert/web.qtpl:576
 			if u.SeriesFetched != nil {
-//line app/vmalert/web.qtpl:574
+//line app/vmalert/web.qtpl:576
 				qw422016.N().D(*u.SeriesFetched)
-//line app/vmalert/web.qtpl:574
+//line app/vmalert/web.qtpl:576
 			}
-//line app/vmalert/web.qtpl:574
+//line app/vmalert/web.qtpl:576
 			qw422016.N().S(`</td>`)
-//line app/vmalert/web.qtpl:574
+//line app/vmalert/web.qtpl:576
 		}
-//line app/vmalert/web.qtpl:574
+//line app/vmalert/web.qtpl:576
 		qw422016.N().S(`
                  <td class="text-center">`)
-//line app/vmalert/web.qtpl:575
+//line app/vmalert/web.qtpl:577
 		qw422016.N().FPrec(u.Duration.Seconds(), 3)
-//line app/vmalert/web.qtpl:575
+//line app/vmalert/web.qtpl:577
 		qw422016.N().S(`s</td>
                  <td class="text-center">`)
-//line app/vmalert/web.qtpl:576
+//line app/vmalert/web.qtpl:578
 		qw422016.E().S(u.At.Format(time.RFC3339))
-//line app/vmalert/web.qtpl:576
+//line app/vmalert/web.qtpl:578
 		qw422016.N().S(`</td>
                  <td>
                     <textarea class="curl-area" rows="1" onclick="this.focus();this.select()">`)
-//line app/vmalert/web.qtpl:578
+//line app/vmalert/web.qtpl:580
 		qw422016.E().S(u.Curl)
-//line app/vmalert/web.qtpl:578
+//line app/vmalert/web.qtpl:580
 		qw422016.N().S(`</textarea>
                 </td>
              </tr>
           </li>
           `)
-//line app/vmalert/web.qtpl:582
+//line app/vmalert/web.qtpl:584
 		if u.Err != nil {
-//line app/vmalert/web.qtpl:582
+//line app/vmalert/web.qtpl:584
 			qw422016.N().S(`
              <tr`)
-//line app/vmalert/web.qtpl:583
+//line app/vmalert/web.qtpl:585
 			if u.Err != nil {
-//line app/vmalert/web.qtpl:583
+//line app/vmalert/web.qtpl:585
 				qw422016.N().S(` class="alert-danger"`)
-//line app/vmalert/web.qtpl:583
+//line app/vmalert/web.qtpl:585
 			}
-//line app/vmalert/web.qtpl:583
+//line app/vmalert/web.qtpl:585
 			qw422016.N().S(`>
                <td colspan="`)
-//line app/vmalert/web.qtpl:584
+//line app/vmalert/web.qtpl:586
 			if seriesFetchedEnabled {
-//line app/vmalert/web.qtpl:584
+//line app/vmalert/web.qtpl:586
 				qw422016.N().S(`6`)
-//line app/vmalert/web.qtpl:584
+//line app/vmalert/web.qtpl:586
 			} else {
-//line app/vmalert/web.qtpl:584
+//line app/vmalert/web.qtpl:586
 				qw422016.N().S(`5`)
-//line app/vmalert/web.qtpl:584
+//line app/vmalert/web.qtpl:586
 			}
-//line app/vmalert/web.qtpl:584
+//line app/vmalert/web.qtpl:586
 			qw422016.N().S(`">
                    <span class="alert-danger">`)
-//line app/vmalert/web.qtpl:585
+//line app/vmalert/web.qtpl:587
 			qw422016.E().V(u.Err)
-//line app/vmalert/web.qtpl:585
+//line app/vmalert/web.qtpl:587
 			qw422016.N().S(`</span>
                </td>
              </tr>
           `)
-//line app/vmalert/web.qtpl:588
+//line app/vmalert/web.qtpl:590
 		}
-//line app/vmalert/web.qtpl:588
+//line app/vmalert/web.qtpl:590
 		qw422016.N().S(`
      `)
-//line app/vmalert/web.qtpl:589
+//line app/vmalert/web.qtpl:591
 	}
-//line app/vmalert/web.qtpl:589
+//line app/vmalert/web.qtpl:591
 	qw422016.N().S(`
 
     `)
-//line app/vmalert/web.qtpl:591
+//line app/vmalert/web.qtpl:593
 	tpl.StreamFooter(qw422016, r)
-//line app/vmalert/web.qtpl:591
+//line app/vmalert/web.qtpl:593
 	qw422016.N().S(`
 `)
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 }
 
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 func WriteRuleDetails(qq422016 qtio422016.Writer, r *http.Request, rule apiRule) {
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 	StreamRuleDetails(qw422016, r, rule)
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 }
 
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 func RuleDetails(r *http.Request, rule apiRule) string {
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 	WriteRuleDetails(qb422016, r, rule)
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 	return qs422016
-//line app/vmalert/web.qtpl:592
+//line app/vmalert/web.qtpl:594
 }
 
-//line app/vmalert/web.qtpl:596
+//line app/vmalert/web.qtpl:598
 func streambadgeState(qw422016 *qt422016.Writer, state string) {
-//line app/vmalert/web.qtpl:596
+//line app/vmalert/web.qtpl:598
 	qw422016.N().S(`
 `)
-//line app/vmalert/web.qtpl:598
+//line app/vmalert/web.qtpl:600
 	badgeClass := "bg-warning text-dark"
 	if state == "firing" {
 		badgeClass = "bg-danger"
 	}
 
-//line app/vmalert/web.qtpl:602
+//line app/vmalert/web.qtpl:604
 	qw422016.N().S(`
 <span class="badge `)
-//line app/vmalert/web.qtpl:603
+//line app/vmalert/web.qtpl:605
 	qw422016.E().S(badgeClass)
-//line app/vmalert/web.qtpl:603
+//line app/vmalert/web.qtpl:605
 	qw422016.N().S(`">`)
-//line app/vmalert/web.qtpl:603
+//line app/vmalert/web.qtpl:605
 	qw422016.E().S(state)
-//line app/vmalert/web.qtpl:603
+//line app/vmalert/web.qtpl:605
 	qw422016.N().S(`</span>
 `)
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 }
 
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 func writebadgeState(qq422016 qtio422016.Writer, state string) {
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 	streambadgeState(qw422016, state)
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 }
 
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 func badgeState(state string) string {
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 	writebadgeState(qb422016, state)
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 	return qs422016
-//line app/vmalert/web.qtpl:604
+//line app/vmalert/web.qtpl:606
 }
 
-//line app/vmalert/web.qtpl:606
+//line app/vmalert/web.qtpl:608
 func streambadgeRestored(qw422016 *qt422016.Writer) {
-//line app/vmalert/web.qtpl:606
+//line app/vmalert/web.qtpl:608
 	qw422016.N().S(`
 <span class="badge bg-warning text-dark" title="Alert state was restored after the service restart from remote storage">restored</span>
 `)
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 }
 
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 func writebadgeRestored(qq422016 qtio422016.Writer) {
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 	streambadgeRestored(qw422016)
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 }
 
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 func badgeRestored() string {
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 	writebadgeRestored(qb422016)
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 	return qs422016
-//line app/vmalert/web.qtpl:608
+//line app/vmalert/web.qtpl:610
 }
 
-//line app/vmalert/web.qtpl:610
+//line app/vmalert/web.qtpl:612
 func streambadgeStabilizing(qw422016 *qt422016.Writer) {
-//line app/vmalert/web.qtpl:610
+//line app/vmalert/web.qtpl:612
 	qw422016.N().S(`
 <span class="badge bg-warning text-dark" title="This firing state is kept because of `)
-//line app/vmalert/web.qtpl:610
+//line app/vmalert/web.qtpl:612
 	qw422016.N().S("`")
-//line app/vmalert/web.qtpl:610
+//line app/vmalert/web.qtpl:612
 	qw422016.N().S(`keep_firing_for`)
-//line app/vmalert/web.qtpl:610
+//line app/vmalert/web.qtpl:612
 	qw422016.N().S("`")
-//line app/vmalert/web.qtpl:610
+//line app/vmalert/web.qtpl:612
 	qw422016.N().S(`">stabilizing</span>
 `)
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 }
 
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 func writebadgeStabilizing(qq422016 qtio422016.Writer) {
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 	streambadgeStabilizing(qw422016)
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 }
 
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 func badgeStabilizing() string {
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 	writebadgeStabilizing(qb422016)
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 	return qs422016
-//line app/vmalert/web.qtpl:612
+//line app/vmalert/web.qtpl:614
 }
 
-//line app/vmalert/web.qtpl:614
+//line app/vmalert/web.qtpl:616
+func streambadgeSuppressed(qw422016 *qt422016.Writer, aa *apiAlert) {
+//line app/vmalert/web.qtpl:616
+	qw422016.N().S(`
+`)
+//line app/vmalert/web.qtpl:617
+	if len(aa.SilencedBy) > 0 {
+//line app/vmalert/web.qtpl:617
+		qw422016.N().S(`
+<span class="badge bg-secondary" title="Notifications are muted by silences: `)
+//line app/vmalert/web.qtpl:618
+		qw422016.E().S(strings.Join(aa.SilencedBy, ", "))
+//line app/vmalert/web.qtpl:618
+		qw422016.N().S(`">silenced</span>
+`)
+//line app/vmalert/web.qtpl:619
+	}
+//line app/vmalert/web.qtpl:619
+	qw422016.N().S(`
+`)
+//line app/vmalert/web.qtpl:620
+	if len(aa.InhibitedBy) > 0 {
+//line app/vmalert/web.qtpl:620
+		qw422016.N().S(`
+<span class="badge bg-secondary" title="Notifications are muted by firing alerts: `)
+//line app/vmalert/web.qtpl:621
+		qw422016.E().S(strings.Join(aa.InhibitedBy, ", "))
+//line app/vmalert/web.qtpl:621
+		qw422016.N().S(`">inhibited</span>
+`)
+//line app/vmalert/web.qtpl:622
+	}
+//line app/vmalert/web.qtpl:622
+	qw422016.N().S(`
+`)
+//line app/vmalert/web.qtpl:623
+}
+
+//line app/vmalert/web.qtpl:623
+func writebadgeSuppressed(qq422016 qtio422016.Writer, aa *apiAlert) {
+//line app/vmalert/web.qtpl:623
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmalert/web.qtpl:623
+	streambadgeSuppressed(qw422016, aa)
+//line app/vmalert/web.qtpl:623
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmalert/web.qtpl:623
+}
+
+//line app/vmalert/web.qtpl:623
+func badgeSuppressed(aa *apiAlert) string {
+//line app/vmalert/web.qtpl:623
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmalert/web.qtpl:623
+	writebadgeSuppressed(qb422016, aa)
+//line app/vmalert/web.qtpl:623
+	qs422016 := string(qb422016.B)
+//line app/vmalert/web.qtpl:623
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmalert/web.qtpl:623
+	return qs422016
+//line app/vmalert/web.qtpl:623
+}
+
+//line app/vmalert/web.qtpl:625
 func streamseriesFetchedWarn(qw422016 *qt422016.Writer, r apiRule) {
-//line app/vmalert/web.qtpl:614
+//line app/vmalert/web.qtpl:625
 	qw422016.N().S(`
 `)
-//line app/vmalert/web.qtpl:615
+//line app/vmalert/web.qtpl:626
 	if isNoMatch(r) {
-//line app/vmalert/web.qtpl:615
+//line app/vmalert/web.qtpl:626
 		qw422016.N().S(`
 <svg xmlns="http://www.w3.org/2000/svg"
     data-bs-toggle="tooltip"
@@ -1923,41 +2009,41 @@ func streamseriesFetchedWarn(qw422016 *qt422016.Writer, r apiRule) {
        <path d="M8 16A8 8 0 1 0 8 0a8 8 0 0 0 0 16zm.93-9.412-1 4.705c-.07.34.029.533.304.533.194 0 .487-.07.686-.246l-.088.416c-.287.346-.92.598-1.465.598-.703 0-1.002-.422-.808-1.319l.738-3.468c.064-.293.006-.399-.287-.47l-.451-.081.082-.381 2.29-.287zM8 5.5a1 1 0 1 1 0-2 1 1 0 0 1 0 2z"/>
 </svg>
 `)
-//line app/vmalert/web.qtpl:624
+//line app/vmalert/web.qtpl:635
 	}
-//line app/vmalert/web.qtpl:624
+//line app/vmalert/web.qtpl:635
 	qw422016.N().S(`
 `)
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 }
 
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 func writeseriesFetchedWarn(qq422016 qtio422016.Writer, r apiRule) {
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 	streamseriesFetchedWarn(qw422016, r)
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 }
 
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 func seriesFetchedWarn(r apiRule) string {
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 	writeseriesFetchedWarn(qb422016, r)
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 	qs422016 := string(qb422016.B)
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 	return qs422016
-//line app/vmalert/web.qtpl:625
+//line app/vmalert/web.qtpl:636
 }
 
-//line app/vmalert/web.qtpl:628
+//line app/vmalert/web.qtpl:639
 func isNoMatch(r apiRule) bool {
 	return r.LastSamples == 0 && r.LastSeriesFetched != nil && *r.LastSeriesFetched == 0
 }
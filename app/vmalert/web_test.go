@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -167,6 +168,105 @@ func TestHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("/api/v1/silences", func(t *testing.T) {
+		resp, err := http.Post(ts.URL+"/api/v1/silences", "application/json",
+			strings.NewReader(`{"matchers":[{"name":"alertname","value":"alert"}],"endsAt":"2100-01-01T00:00:00Z","comment":"maintenance"}`))
+		if err != nil {
+			t.Fatalf("unexpected err %s", err)
+		}
+		var asr addSilenceResponse
+		if err := json.NewDecoder(resp.Body).Decode(&asr); err != nil {
+			t.Fatalf("unexpected err %s", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != 200 || asr.Data.SilenceID == "" {
+			t.Fatalf("unexpected response: status code %d; silence id %q", resp.StatusCode, asr.Data.SilenceID)
+		}
+		id := asr.Data.SilenceID
+
+		lr := listSilencesResponse{}
+		getResp(t, ts.URL+"/api/v1/silences", &lr, 200)
+		if len(lr.Data.Silences) != 1 {
+			t.Fatalf("expected 1 silence got %d", len(lr.Data.Silences))
+		}
+		if lr.Data.Silences[0].ID != id || lr.Data.Silences[0].State != notifier.SilenceStateActive {
+			t.Fatalf("unexpected silence %+v", lr.Data.Silences[0])
+		}
+		getResp(t, ts.URL+"/api/v1/silence?silence_id="+id, nil, 200)
+		getResp(t, ts.URL+"/api/v1/silence?silence_id=missing", nil, 404)
+
+		// the alert must be suppressed after the next evaluation
+		g.ExecOnce(context.Background(), func() []notifier.Notifier { return nil }, nil, time.Time{})
+		alerts := ruleToAPIAlert(ar)
+		if len(alerts) == 0 || !alerts[0].Suppressed || !reflect.DeepEqual(alerts[0].SilencedBy, []string{id}) {
+			t.Fatalf("expected alert to be silenced; got %+v", alerts)
+		}
+
+		req, err := http.NewRequest(http.MethodDelete, ts.URL+"/api/v1/silence?silence_id="+id, nil)
+		if err != nil {
+			t.Fatalf("unexpected err %s", err)
+		}
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected err %s", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatalf("unexpected status code %d want 200", resp.StatusCode)
+		}
+		lr = listSilencesResponse{}
+		getResp(t, ts.URL+"/api/v1/silences", &lr, 200)
+		if len(lr.Data.Silences) != 1 || lr.Data.Silences[0].State != notifier.SilenceStateExpired {
+			t.Fatalf("expected the silence to be expired; got %+v", lr.Data.Silences)
+		}
+
+		// invalid silence
+		resp, err = http.Post(ts.URL+"/api/v1/silences", "application/json", strings.NewReader(`{"matchers":[]}`))
+		if err != nil {
+			t.Fatalf("unexpected err %s", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != 400 {
+			t.Fatalf("unexpected status code %d want 400", resp.StatusCode)
+		}
+	})
+	t.Run("/api/v1/silences with -silencesAuthKey", func(t *testing.T) {
+		if err := silencesAuthKey.Set("secret"); err != nil {
+			t.Fatalf("cannot set -silencesAuthKey: %s", err)
+		}
+		defer func() {
+			_ = silencesAuthKey.Set("")
+		}()
+
+		f := func(method, path string, statusCodeExpected int) {
+			t.Helper()
+			req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(`{"matchers":[]}`))
+			if err != nil {
+				t.Fatalf("unexpected err %s", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected err %s", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != statusCodeExpected {
+				t.Fatalf("unexpected status code for %s %s; got %d; want %d", method, path, resp.StatusCode, statusCodeExpected)
+			}
+		}
+
+		// missing or invalid authKey
+		f(http.MethodPost, "/api/v1/silences", 401)
+		f(http.MethodPost, "/api/v1/silences?authKey=foo", 401)
+		f(http.MethodDelete, "/api/v1/silence?silence_id=missing", 401)
+
+		// read-only requests do not require authKey
+		f(http.MethodGet, "/api/v1/silences", 200)
+
+		// valid authKey
+		f(http.MethodPost, "/api/v1/silences?authKey=secret", 400)
+		f(http.MethodDelete, "/api/v1/silence?silence_id=missing&authKey=secret", 404)
+	})
 	t.Run("/api/v1/rules&filters", func(t *testing.T) {
 		check := func(url string, expGroups, expRules int) {
 			t.Helper()
@@ -18,6 +18,8 @@ const (
 	paramAlertID = "alert_id"
 	// ParamRuleID is rule id key in url parameter
 	paramRuleID = "rule_id"
+	// ParamSilenceID is silence id key in url parameter
+	paramSilenceID = "silence_id"
 )
 
 // apiAlert represents a notifier.AlertingRule state
@@ -50,6 +52,13 @@ type apiAlert struct {
 	// Stabilizing shows when firing state is kept because of
 	// `keep_firing_for` instead of real alert
 	Stabilizing bool `json:"stabilizing"`
+	// Suppressed shows whether notifications for the Alert
+	// are muted by silences or inhibition rules
+	Suppressed bool `json:"suppressed"`
+	// SilencedBy contains IDs of active silences matching the Alert
+	SilencedBy []string `json:"silenced_by,omitempty"`
+	// InhibitedBy contains IDs of firing alerts, which inhibit the Alert
+	InhibitedBy []string `json:"inhibited_by,omitempty"`
 }
 
 // WebLink returns a link to the alert which can be used in UI.
@@ -318,9 +327,28 @@ func newAlertAPI(ar *rule.AlertingRule, a *notifier.Alert) *apiAlert {
 	if a.State == notifier.StateFiring && !a.KeepFiringSince.IsZero() {
 		aa.Stabilizing = true
 	}
+	if a.IsSuppressed() {
+		aa.Suppressed = true
+		aa.SilencedBy = a.SilencedBy
+		aa.InhibitedBy = a.InhibitedBy
+	}
 	return aa
 }
 
+// apiSilence represents notifier.Silence for web view
+type apiSilence struct {
+	*notifier.Silence
+	// State is the state of the Silence at the moment of the request
+	State notifier.SilenceState `json:"state"`
+}
+
+func newSilenceAPI(s *notifier.Silence, now time.Time) *apiSilence {
+	return &apiSilence{
+		Silence: s,
+		State:   s.State(now),
+	}
+}
+
 func groupToAPI(g *rule.Group) apiGroup {
 	g = g.DeepCopy()
 	ag := apiGroup{
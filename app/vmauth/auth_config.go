@@ -55,38 +55,46 @@ type AuthConfig struct {
 
 	// ms holds all the metrics for the given AuthConfig
 	ms *metrics.Set
+
+	// jwtUsers contains users with `jwt` section.
+	jwtUsers []*UserInfo
 }
 
 // UserInfo is user information read from authConfigPath
 type UserInfo struct {
 	Name string `yaml:"name,omitempty"`
 
-	BearerToken string `yaml:"bearer_token,omitempty"`
-	AuthToken   string `yaml:"auth_token,omitempty"`
-	Username    string `yaml:"username,omitempty"`
-	Password    string `yaml:"password,omitempty"`
-
-	URLPrefix              *URLPrefix  `yaml:"url_prefix,omitempty"`
-	DiscoverBackendIPs     *bool       `yaml:"discover_backend_ips,omitempty"`
-	URLMaps                []URLMap    `yaml:"url_map,omitempty"`
-	DumpRequestOnErrors    bool        `yaml:"dump_request_on_errors,omitempty"`
-	HeadersConf            HeadersConf `yaml:",inline"`
-	MaxConcurrentRequests  int         `yaml:"max_concurrent_requests,omitempty"`
-	DefaultURL             *URLPrefix  `yaml:"default_url,omitempty"`
-	RetryStatusCodes       []int       `yaml:"retry_status_codes,omitempty"`
-	LoadBalancingPolicy    string      `yaml:"load_balancing_policy,omitempty"`
-	DropSrcPathPrefixParts *int        `yaml:"drop_src_path_prefix_parts,omitempty"`
-	TLSCAFile              string      `yaml:"tls_ca_file,omitempty"`
-	TLSCertFile            string      `yaml:"tls_cert_file,omitempty"`
-	TLSKeyFile             string      `yaml:"tls_key_file,omitempty"`
-	TLSServerName          string      `yaml:"tls_server_name,omitempty"`
-	TLSInsecureSkipVerify  *bool       `yaml:"tls_insecure_skip_verify,omitempty"`
+	BearerToken string     `yaml:"bearer_token,omitempty"`
+	AuthToken   string     `yaml:"auth_token,omitempty"`
+	Username    string     `yaml:"username,omitempty"`
+	Password    string     `yaml:"password,omitempty"`
+	JWT         *JWTConfig `yaml:"jwt,omitempty"`
+
+	URLPrefix              *URLPrefix     `yaml:"url_prefix,omitempty"`
+	DiscoverBackendIPs     *bool          `yaml:"discover_backend_ips,omitempty"`
+	URLMaps                []URLMap       `yaml:"url_map,omitempty"`
+	DumpRequestOnErrors    bool           `yaml:"dump_request_on_errors,omitempty"`
+	HeadersConf            HeadersConf    `yaml:",inline"`
+	MaxConcurrentRequests  int            `yaml:"max_concurrent_requests,omitempty"`
+	RateLimitsConf         RateLimitsConf `yaml:",inline"`
+	DefaultURL             *URLPrefix     `yaml:"default_url,omitempty"`
+	RetryStatusCodes       []int          `yaml:"retry_status_codes,omitempty"`
+	LoadBalancingPolicy    string         `yaml:"load_balancing_policy,omitempty"`
+	DropSrcPathPrefixParts *int           `yaml:"drop_src_path_prefix_parts,omitempty"`
+	TLSCAFile              string         `yaml:"tls_ca_file,omitempty"`
+	TLSCertFile            string         `yaml:"tls_cert_file,omitempty"`
+	TLSKeyFile             string         `yaml:"tls_key_file,omitempty"`
+	TLSServerName          string         `yaml:"tls_server_name,omitempty"`
+	TLSInsecureSkipVerify  *bool          `yaml:"tls_insecure_skip_verify,omitempty"`
 
 	MetricLabels map[string]string `yaml:"metric_labels,omitempty"`
 
 	concurrencyLimitCh      chan struct{}
 	concurrencyLimitReached *metrics.Counter
 
+	// rl contains user-level rate limiters. It is nil if the user has no rate limits.
+	rl *rateLimiters
+
 	rt http.RoundTripper
 
 	requests         *metrics.Counter
@@ -184,6 +192,9 @@ type URLMap struct {
 
 	// DropSrcPathPrefixParts is the number of `/`-delimited request path prefix parts to drop before proxying the request to backend.
 	DropSrcPathPrefixParts *int `yaml:"drop_src_path_prefix_parts,omitempty"`
+
+	// RateLimitsConf is the config for rate limits applied to requests matching the given url_map entry.
+	RateLimitsConf RateLimitsConf `yaml:",inline"`
 }
 
 // QueryArg represents HTTP query arg
@@ -239,6 +250,9 @@ type URLPrefix struct {
 	// how many request path prefix parts to drop before routing the request to backendURL
 	dropSrcPathPrefixParts int
 
+	// rate limiters for requests routed via the given url_map entry. It is nil if there are no rate limits.
+	rl *rateLimiters
+
 	// busOriginal contains the original list of backends specified in yaml config.
 	busOriginal []*url.URL
 
@@ -751,6 +765,9 @@ func parseAuthConfig(data []byte) (*AuthConfig, error) {
 		if ui.Name != "" {
 			return nil, fmt.Errorf("field name can't be specified for unauthorized_user section")
 		}
+		if ui.JWT != nil {
+			return nil, fmt.Errorf("field jwt can't be specified for unauthorized_user section")
+		}
 		if err := ui.initURLs(); err != nil {
 			return nil, err
 		}
@@ -764,6 +781,9 @@ func parseAuthConfig(data []byte) (*AuthConfig, error) {
 		ui.requestsDuration = ac.ms.NewSummary(`vmauth_unauthorized_user_request_duration_seconds` + metricLabels)
 		ui.concurrencyLimitCh = make(chan struct{}, ui.getMaxConcurrentRequests())
 		ui.concurrencyLimitReached = ac.ms.NewCounter(`vmauth_unauthorized_user_concurrent_requests_limit_reached_total` + metricLabels)
+		if err := ui.initRateLimiters(ac.ms, `vmauth_unauthorized_user_requests_throttled_total`, metricLabels); err != nil {
+			return nil, fmt.Errorf("cannot initialize rate limits for unauthorized_user: %w", err)
+		}
 		_ = ac.ms.NewGauge(`vmauth_unauthorized_user_concurrent_requests_capacity`+metricLabels, func() float64 {
 			return float64(cap(ui.concurrencyLimitCh))
 		})
@@ -787,11 +807,24 @@ func parseAuthConfigUsers(ac *AuthConfig) (map[string]*UserInfo, error) {
 		// fast path for empty configuration
 		return byAuthToken, nil
 	}
+	var jwtUsers []*UserInfo
 	for i := range uis {
 		ui := &uis[i]
-		ats, err := getAuthTokens(ui.AuthToken, ui.BearerToken, ui.Username, ui.Password)
-		if err != nil {
-			return nil, err
+		var ats []string
+		if ui.JWT != nil {
+			if ui.AuthToken != "" || ui.BearerToken != "" || ui.Username != "" || ui.Password != "" {
+				return nil, fmt.Errorf("auth_token, bearer_token, username and password cannot be specified if jwt is set")
+			}
+			if err := ui.JWT.init(); err != nil {
+				return nil, fmt.Errorf("cannot initialize jwt for user %q: %w", ui.name(), err)
+			}
+			jwtUsers = append(jwtUsers, ui)
+		} else {
+			var err error
+			ats, err = getAuthTokens(ui.AuthToken, ui.BearerToken, ui.Username, ui.Password)
+			if err != nil {
+				return nil, err
+			}
 		}
 		for _, at := range ats {
 			if uiOld := byAuthToken[at]; uiOld != nil {
@@ -813,6 +846,9 @@ func parseAuthConfigUsers(ac *AuthConfig) (map[string]*UserInfo, error) {
 		mcr := ui.getMaxConcurrentRequests()
 		ui.concurrencyLimitCh = make(chan struct{}, mcr)
 		ui.concurrencyLimitReached = ac.ms.GetOrCreateCounter(`vmauth_user_concurrent_requests_limit_reached_total` + metricLabels)
+		if err := ui.initRateLimiters(ac.ms, `vmauth_user_requests_throttled_total`, metricLabels); err != nil {
+			return nil, fmt.Errorf("cannot initialize rate limits for user %q: %w", ui.name(), err)
+		}
 		_ = ac.ms.GetOrCreateGauge(`vmauth_user_concurrent_requests_capacity`+metricLabels, func() float64 {
 			return float64(cap(ui.concurrencyLimitCh))
 		})
@@ -830,6 +866,7 @@ func parseAuthConfigUsers(ac *AuthConfig) (map[string]*UserInfo, error) {
 			byAuthToken[at] = ui
 		}
 	}
+	ac.jwtUsers = jwtUsers
 	return byAuthToken, nil
 }
 
@@ -929,6 +966,23 @@ func (ui *UserInfo) initURLs() error {
 	return nil
 }
 
+func (ui *UserInfo) initRateLimiters(ms *metrics.Set, metricName, metricLabels string) error {
+	rl, err := newRateLimiters(&ui.RateLimitsConf, ms, metricName, metricLabels)
+	if err != nil {
+		return err
+	}
+	ui.rl = rl
+	for i := range ui.URLMaps {
+		e := &ui.URLMaps[i]
+		rl, err := newRateLimiters(&e.RateLimitsConf, ms, metricName, metricLabels)
+		if err != nil {
+			return fmt.Errorf("cannot initialize rate limits for `url_map`: %w", err)
+		}
+		e.URLPrefix.rl = rl
+	}
+	return nil
+}
+
 func (ui *UserInfo) name() string {
 	if ui.Name != "" {
 		return ui.Name
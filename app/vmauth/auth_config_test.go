@@ -36,6 +36,79 @@ users:
 - username: foo
 `)
 
+	// negative max_requests_per_second
+	f(`
+users:
+- username: foo
+  url_prefix: http://foo.bar
+  max_requests_per_second: -1
+`)
+	f(`
+users:
+- username: foo
+  url_map:
+  - src_paths: ["/foo"]
+    url_prefix: http://foo.bar
+    max_requests_per_second: -1
+`)
+
+	// invalid max_request_bytes_per_second
+	f(`
+users:
+- username: foo
+  url_prefix: http://foo.bar
+  max_request_bytes_per_second: foobar
+`)
+
+	// invalid max_response_bytes_per_second
+	f(`
+unauthorized_user:
+  url_prefix: http://foo.bar
+  max_response_bytes_per_second: 0
+`)
+
+	// jwt with username
+	f(`
+users:
+- username: foo
+  jwt:
+    jwks_file: testdata/jwks.json
+  url_prefix: http://foo.bar
+`)
+
+	// jwt without jwks
+	f(`
+users:
+- jwt:
+    issuer: foo
+  url_prefix: http://foo.bar
+`)
+
+	// jwt with both jwks_file and jwks_url
+	f(`
+users:
+- jwt:
+    jwks_file: testdata/jwks.json
+    jwks_url: http://foo.bar/jwks.json
+  url_prefix: http://foo.bar
+`)
+
+	// jwt with missing jwks_file
+	f(`
+users:
+- jwt:
+    jwks_file: non-existing-file.json
+  url_prefix: http://foo.bar
+`)
+
+	// jwt in unauthorized_user
+	f(`
+unauthorized_user:
+  jwt:
+    jwks_file: testdata/jwks.json
+  url_prefix: http://foo.bar
+`)
+
 	// Invalid url_prefix
 	f(`
 users:
@@ -0,0 +1,677 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs/fscore"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+// JWTConfig is the config for authorizing users via JWT.
+//
+// See https://docs.victoriametrics.com/vmauth/#jwt-authorization
+type JWTConfig struct {
+	// JWKSFile is the path to local file with JSON Web Key Set for verifying JWT signatures.
+	JWKSFile string `yaml:"jwks_file,omitempty"`
+
+	// JWKSURL is the url for JSON Web Key Set for verifying JWT signatures.
+	JWKSURL string `yaml:"jwks_url,omitempty"`
+
+	// Issuer is an optional issuer, which must match the `iss` claim.
+	Issuer string `yaml:"issuer,omitempty"`
+
+	// Audience is an optional audience, which must be present in the `aud` claim.
+	Audience string `yaml:"audience,omitempty"`
+
+	// MatchClaims is an optional set of claims, which must match the corresponding JWT claims.
+	MatchClaims map[string]string `yaml:"match_claims,omitempty"`
+
+	// AllowMissingExp allows tokens without `exp` claim. Such tokens are rejected by default, since they never expire.
+	AllowMissingExp bool `yaml:"allow_missing_exp,omitempty"`
+
+	// jwks contains keys for verifying JWT signatures.
+	jwks *jwksKeys
+}
+
+func (jc *JWTConfig) init() error {
+	if jc.JWKSFile == "" && jc.JWKSURL == "" {
+		return fmt.Errorf("missing `jwks_file` or `jwks_url` in `jwt` section")
+	}
+	if jc.JWKSFile != "" && jc.JWKSURL != "" {
+		return fmt.Errorf("`jwks_file` and `jwks_url` cannot be set simultaneously in `jwt` section")
+	}
+	path := jc.JWKSFile
+	if path == "" {
+		path = jc.JWKSURL
+	}
+	jwks, err := newJWKSKeys(path)
+	if err != nil {
+		return err
+	}
+	jc.jwks = jwks
+	return nil
+}
+
+// verify verifies the given JWT token and returns its claims.
+func (jc *JWTConfig) verify(token string) (jwtClaims, error) {
+	claims, err := jc.jwks.verifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	ct := int64(fasttime.UnixTimestamp())
+	exp, ok := claims.getNumber("exp")
+	if !ok {
+		if !jc.AllowMissingExp {
+			return nil, fmt.Errorf("the token has no `exp` claim; set `allow_missing_exp: true` in `jwt` section for accepting tokens without `exp` claim")
+		}
+	} else if ct >= exp {
+		return nil, fmt.Errorf("the token is expired")
+	}
+	if nbf, ok := claims.getNumber("nbf"); ok && ct < nbf {
+		return nil, fmt.Errorf("the token isn't valid yet")
+	}
+	if jc.Issuer != "" {
+		if iss, _ := claims.getString("iss"); iss != jc.Issuer {
+			return nil, fmt.Errorf("unexpected issuer %q; want %q", iss, jc.Issuer)
+		}
+	}
+	if jc.Audience != "" && !claims.hasAudience(jc.Audience) {
+		return nil, fmt.Errorf("the token audience doesn't contain %q", jc.Audience)
+	}
+	for name, value := range jc.MatchClaims {
+		v, err := claims.getString(name)
+		if err != nil {
+			return nil, err
+		}
+		if v != value {
+			return nil, fmt.Errorf("unexpected value for claim %q; got %q; want %q", name, v, value)
+		}
+	}
+	return claims, nil
+}
+
+// getJWTToken returns JWT token from the given auth tokens.
+//
+// Empty string is returned if auth tokens do not contain bearer token.
+func getJWTToken(ats []string) string {
+	for _, at := range ats {
+		if token, ok := strings.CutPrefix(at, "http_auth:Bearer "); ok && strings.Count(token, ".") == 2 {
+			return token
+		}
+	}
+	return ""
+}
+
+// getUserInfoByJWT returns the first user from uis, which accepts the given JWT token.
+func getUserInfoByJWT(uis []*UserInfo, token string) (*UserInfo, jwtClaims, error) {
+	var errs []string
+	for _, ui := range uis {
+		claims, err := ui.JWT.verify(token)
+		if err == nil {
+			return ui, claims, nil
+		}
+		errs = append(errs, fmt.Sprintf("user %q: %s", ui.name(), err))
+	}
+	return nil, nil, fmt.Errorf("cannot verify JWT token: %s", strings.Join(errs, "; "))
+}
+
+// jwtClaims contains claims from the verified JWT.
+type jwtClaims map[string]any
+
+// getValue returns the value for the claim with the given name.
+//
+// The name may refer to nested claims via `.` delimiter, e.g. `vm_access.tenant`.
+func (c jwtClaims) getValue(name string) (any, bool) {
+	if v, ok := c[name]; ok {
+		return v, true
+	}
+	var m map[string]any = c
+	for {
+		n := strings.IndexByte(name, '.')
+		if n < 0 {
+			v, ok := m[name]
+			return v, ok
+		}
+		v, ok := m[name[:n]]
+		if !ok {
+			return nil, false
+		}
+		m, ok = v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		name = name[n+1:]
+	}
+}
+
+// getString returns string representation for the claim with the given name.
+//
+// Only string, number and bool claims can be converted to string.
+func (c jwtClaims) getString(name string) (string, error) {
+	v, ok := c.getValue(name)
+	if !ok {
+		return "", fmt.Errorf("missing claim %q", name)
+	}
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case json.Number:
+		return t.String(), nil
+	case bool:
+		if t {
+			return "true", nil
+		}
+		return "false", nil
+	default:
+		return "", fmt.Errorf("claim %q must be string, number or bool; got %T", name, v)
+	}
+}
+
+func (c jwtClaims) getNumber(name string) (int64, bool) {
+	v, ok := c[name].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	n, err := v.Int64()
+	if err != nil {
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		n = int64(f)
+	}
+	return n, true
+}
+
+func (c jwtClaims) hasAudience(audience string) bool {
+	switch t := c["aud"].(type) {
+	case string:
+		return t == audience
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimPlaceholderRegexp matches `{{.claim_name}}` placeholders.
+var claimPlaceholderRegexp = regexp.MustCompile(`\{\{\.([^{}\s]+)\}\}`)
+
+func hasClaimPlaceholders(s string) bool {
+	return strings.Contains(s, "{{") && claimPlaceholderRegexp.MatchString(s)
+}
+
+// replaceClaimPlaceholders replaces `{{.claim_name}}` placeholders in s with the corresponding claim values.
+//
+// checkValue is called for every substituted value.
+func (c jwtClaims) replaceClaimPlaceholders(s string, checkValue func(name, value string) error) (string, error) {
+	if !hasClaimPlaceholders(s) {
+		return s, nil
+	}
+	var firstErr error
+	result := claimPlaceholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := placeholder[len("{{.") : len(placeholder)-len("}}")]
+		value, err := c.getString(name)
+		if err == nil {
+			err = checkValue(name, value)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return ""
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return result, nil
+}
+
+// applyToURL returns a copy of u with claim placeholders replaced with claim values.
+//
+// Placeholders are supported in the path and in query arg values.
+func (c jwtClaims) applyToURL(u *url.URL) (*url.URL, error) {
+	if c == nil {
+		return u, nil
+	}
+	uCopy := *u
+
+	path, err := c.replaceClaimPlaceholders(u.Path, checkClaimValueForPath)
+	if err != nil {
+		return nil, err
+	}
+	if path != u.Path {
+		uCopy.Path = path
+		uCopy.RawPath = ""
+	}
+
+	if u.RawQuery != "" {
+		args := u.Query()
+		for _, values := range args {
+			for i, v := range values {
+				vNew, err := c.replaceClaimPlaceholders(v, checkClaimValueForQueryArg)
+				if err != nil {
+					return nil, err
+				}
+				values[i] = vNew
+			}
+		}
+		uCopy.RawQuery = args.Encode()
+	}
+	return &uCopy, nil
+}
+
+// applyToHeaders returns headers with claim placeholders replaced with claim values.
+func (c jwtClaims) applyToHeaders(headers []*Header) ([]*Header, error) {
+	if c == nil {
+		return headers, nil
+	}
+	var result []*Header
+	for i, h := range headers {
+		value, err := c.replaceClaimPlaceholders(h.Value, checkClaimValueForHeader)
+		if err != nil {
+			return nil, err
+		}
+		if value == h.Value {
+			if result != nil {
+				result = append(result, h)
+			}
+			continue
+		}
+		if result == nil {
+			result = append(result, headers[:i]...)
+		}
+		result = append(result, &Header{
+			Name:  h.Name,
+			Value: value,
+		})
+	}
+	if result == nil {
+		return headers, nil
+	}
+	return result, nil
+}
+
+func checkClaimValueForPath(name, value string) error {
+	if value == "" || value == "." || value == ".." || strings.ContainsAny(value, "/\\?#") {
+		return fmt.Errorf("claim %q=%q cannot be used in url path", name, value)
+	}
+	return nil
+}
+
+func checkClaimValueForQueryArg(_, _ string) error {
+	return nil
+}
+
+func checkClaimValueForHeader(name, value string) error {
+	for i := 0; i < len(value); i++ {
+		if value[i] < ' ' || value[i] == 0x7f {
+			return fmt.Errorf("claim %q=%q cannot be used in http header", name, value)
+		}
+	}
+	return nil
+}
+
+// jwksKeys holds JSON Web Key Set for verifying JWT signatures.
+type jwksKeys struct {
+	// path is either local file path or http url for the JSON Web Key Set.
+	path string
+
+	// mu protects keys and lastRefresh.
+	mu          sync.Mutex
+	keys        []*jwk
+	lastRefresh uint64
+
+	// refreshGroup deduplicates concurrent refreshes of the JSON Web Key Set.
+	refreshGroup singleflight.Group
+}
+
+// jwksMinRefreshInterval is the minimum interval between JSON Web Key Set refreshes on unknown key id.
+const jwksMinRefreshInterval = 30 * time.Second
+
+func newJWKSKeys(path string) (*jwksKeys, error) {
+	keys, err := loadJWKS(path)
+	if err != nil {
+		return nil, err
+	}
+	jwks := &jwksKeys{
+		path:        path,
+		keys:        keys,
+		lastRefresh: fasttime.UnixTimestamp(),
+	}
+	return jwks, nil
+}
+
+func loadJWKS(path string) ([]*jwk, error) {
+	data, err := fscore.ReadFileOrHTTP(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read JSON Web Key Set: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse JSON Web Key Set from %q: %w", path, err)
+	}
+	return keys, nil
+}
+
+// getKeys returns keys for the given kid.
+//
+// The JSON Web Key Set is re-read if there are no keys with the given kid, since the keys could be rotated.
+func (jwks *jwksKeys) getKeys(kid string) []*jwk {
+	jwks.mu.Lock()
+	keys := filterJWKsByKeyID(jwks.keys, kid)
+	jwks.mu.Unlock()
+
+	if len(keys) > 0 || kid == "" {
+		return keys
+	}
+	v, _, _ := jwks.refreshGroup.Do("", func() (any, error) {
+		return jwks.refresh(), nil
+	})
+	return filterJWKsByKeyID(v.([]*jwk), kid)
+}
+
+// refresh re-reads the JSON Web Key Set if it wasn't refreshed during the last jwksMinRefreshInterval and returns the current keys.
+//
+// The JSON Web Key Set is read without holding jwks.mu, so the verification of tokens with known keys isn't blocked by slow identity provider.
+func (jwks *jwksKeys) refresh() []*jwk {
+	jwks.mu.Lock()
+	ct := fasttime.UnixTimestamp()
+	if ct < jwks.lastRefresh+uint64(jwksMinRefreshInterval.Seconds()) {
+		keys := jwks.keys
+		jwks.mu.Unlock()
+		return keys
+	}
+	jwks.lastRefresh = ct
+	jwks.mu.Unlock()
+
+	keysNew, err := loadJWKS(jwks.path)
+
+	jwks.mu.Lock()
+	defer jwks.mu.Unlock()
+
+	if err != nil {
+		logger.Errorf("cannot refresh JSON Web Key Set: %s; using the previously loaded keys", err)
+		return jwks.keys
+	}
+	jwks.keys = keysNew
+	return keysNew
+}
+
+func filterJWKsByKeyID(keys []*jwk, kid string) []*jwk {
+	if kid == "" {
+		return keys
+	}
+	var result []*jwk
+	for _, k := range keys {
+		if k.kid == kid {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// verifyToken verifies the signature for the given JWT token and returns its claims.
+func (jwks *jwksKeys) verifyToken(token string) (jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unexpected number of JWT parts; got %d; want 3", len(parts))
+	}
+
+	headerData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode JWT header: %w", err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerData, &header); err != nil {
+		return nil, fmt.Errorf("cannot parse JWT header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode JWT signature: %w", err)
+	}
+
+	signingInput := token[:len(parts[0])+1+len(parts[1])]
+	keys := jwks.getKeys(header.Kid)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("cannot find key with kid=%q in JSON Web Key Set", header.Kid)
+	}
+	verified := false
+	for _, k := range keys {
+		if err := k.verify(header.Alg, signingInput, signature); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("cannot verify JWT signature with alg=%q", header.Alg)
+	}
+
+	claimsData, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode JWT claims: %w", err)
+	}
+	var claims jwtClaims
+	d := json.NewDecoder(bytes.NewReader(claimsData))
+	d.UseNumber()
+	if err := d.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("cannot parse JWT claims: %w", err)
+	}
+	return claims, nil
+}
+
+// jwk is a single JSON Web Key.
+type jwk struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+func parseJWKS(data []byte) ([]*jwk, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+
+	var keys []*jwk
+	for i, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode `n` for key #%d: %w", i+1, err)
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode `e` for key #%d: %w", i+1, err)
+			}
+			if !e.IsInt64() || e.Int64() > 1<<31-1 {
+				return nil, fmt.Errorf("too big `e` for key #%d", i+1)
+			}
+			key = &rsa.PublicKey{
+				N: n,
+				E: int(e.Int64()),
+			}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				return nil, fmt.Errorf("unsupported `crv`=%q for key #%d", k.Crv, i+1)
+			}
+			x, err := decodeBigInt(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode `x` for key #%d: %w", i+1, err)
+			}
+			y, err := decodeBigInt(k.Y)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode `y` for key #%d: %w", i+1, err)
+			}
+			if !curve.IsOnCurve(x, y) {
+				return nil, fmt.Errorf("invalid point for key #%d", i+1)
+			}
+			key = &ecdsa.PublicKey{
+				Curve: curve,
+				X:     x,
+				Y:     y,
+			}
+		case "OKP":
+			if k.Crv != "Ed25519" {
+				return nil, fmt.Errorf("unsupported `crv`=%q for key #%d", k.Crv, i+1)
+			}
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode `x` for key #%d: %w", i+1, err)
+			}
+			if len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("unexpected `x` size for key #%d; got %d bytes; want %d bytes", i+1, len(x), ed25519.PublicKeySize)
+			}
+			key = ed25519.PublicKey(x)
+		default:
+			// Skip unsupported keys such as symmetric keys.
+			continue
+		}
+		keys = append(keys, &jwk{
+			kid: k.Kid,
+			alg: k.Alg,
+			key: key,
+		})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("missing supported signing keys")
+	}
+	return keys, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// verify verifies the signature for signingInput with the given alg.
+func (k *jwk) verify(alg, signingInput string, signature []byte) error {
+	if k.alg != "" && k.alg != alg {
+		return fmt.Errorf("unexpected alg=%q; want %q", alg, k.alg)
+	}
+	switch key := k.key.(type) {
+	case *rsa.PublicKey:
+		h, digest, err := getHashForAlg(alg, "RS", "PS")
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(key, h, digest(signingInput), signature, &rsa.PSSOptions{
+				SaltLength: rsa.PSSSaltLengthEqualsHash,
+			})
+		}
+		return rsa.VerifyPKCS1v15(key, h, digest(signingInput), signature)
+	case *ecdsa.PublicKey:
+		_, digest, err := getHashForAlg(alg, "ES")
+		if err != nil {
+			return err
+		}
+		keySize := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*keySize {
+			return fmt.Errorf("unexpected signature size; got %d bytes; want %d bytes", len(signature), 2*keySize)
+		}
+		r := new(big.Int).SetBytes(signature[:keySize])
+		s := new(big.Int).SetBytes(signature[keySize:])
+		if !ecdsa.Verify(key, digest(signingInput), r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if alg != "EdDSA" {
+			return fmt.Errorf("unexpected alg=%q for Ed25519 key", alg)
+		}
+		if !ed25519.Verify(key, []byte(signingInput), signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported key type %T", k.key)
+	}
+}
+
+// getHashForAlg returns hash function for the given alg with one of the given prefixes.
+func getHashForAlg(alg string, prefixes ...string) (crypto.Hash, func(s string) []byte, error) {
+	for _, prefix := range prefixes {
+		suffix, ok := strings.CutPrefix(alg, prefix)
+		if !ok {
+			continue
+		}
+		switch suffix {
+		case "256":
+			return crypto.SHA256, func(s string) []byte {
+				h := sha256.Sum256([]byte(s))
+				return h[:]
+			}, nil
+		case "384":
+			return crypto.SHA384, func(s string) []byte {
+				h := sha512.Sum384([]byte(s))
+				return h[:]
+			}, nil
+		case "512":
+			return crypto.SHA512, func(s string) []byte {
+				h := sha512.Sum512([]byte(s))
+				return h[:]
+			}, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("unsupported alg=%q", alg)
+}
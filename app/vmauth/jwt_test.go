@@ -0,0 +1,617 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+)
+
+func TestParseJWKSFailure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+		keys, err := parseJWKS([]byte(data))
+		if err == nil {
+			t.Fatalf("expecting non-nil error; got %d keys", len(keys))
+		}
+	}
+
+	// invalid json
+	f(`foobar`)
+
+	// missing keys
+	f(`{}`)
+	f(`{"keys":[]}`)
+
+	// only unsupported keys
+	f(`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`)
+
+	// keys not for signing
+	f(`{"keys":[{"kty":"OKP","crv":"Ed25519","use":"enc","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`)
+
+	// invalid RSA key
+	f(`{"keys":[{"kty":"RSA","n":"","e":"AQAB"}]}`)
+	f(`{"keys":[{"kty":"RSA","n":"foo!","e":"AQAB"}]}`)
+
+	// invalid EC key
+	f(`{"keys":[{"kty":"EC","crv":"P-192","x":"AQ","y":"AQ"}]}`)
+	f(`{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`)
+
+	// invalid Ed25519 key
+	f(`{"keys":[{"kty":"OKP","crv":"Ed25519","x":"AQ"}]}`)
+	f(`{"keys":[{"kty":"OKP","crv":"X25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`)
+}
+
+func TestJWTVerify(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate ecdsa key: %s", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate rsa key: %s", err)
+	}
+	edPublicKey, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate ed25519 key: %s", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate ecdsa key: %s", err)
+	}
+
+	jwksPath := t.Name() + ".json"
+	mustWriteTestJWKS(jwksPath, map[string]crypto.PublicKey{
+		"ec":  &ecKey.PublicKey,
+		"rsa": &rsaKey.PublicKey,
+		"ed":  edPublicKey,
+	})
+	defer fs.MustRemoveAll(jwksPath)
+
+	jc := &JWTConfig{
+		JWKSFile: jwksPath,
+		Issuer:   "https://idp.example.com",
+		Audience: "vmauth",
+		MatchClaims: map[string]string{
+			"vm_access.team": "ops",
+		},
+	}
+	if err := jc.init(); err != nil {
+		t.Fatalf("cannot initialize jwt config: %s", err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	validClaims := map[string]any{
+		"iss": "https://idp.example.com",
+		"aud": []string{"grafana", "vmauth"},
+		"exp": exp,
+		"vm_access": map[string]any{
+			"team":   "ops",
+			"tenant": 42,
+		},
+	}
+
+	fSuccess := func(token string) {
+		t.Helper()
+		claims, err := jc.verify(token)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		tenant, err := claims.getString("vm_access.tenant")
+		if err != nil {
+			t.Fatalf("cannot obtain tenant claim: %s", err)
+		}
+		if tenant != "42" {
+			t.Fatalf("unexpected tenant claim; got %q; want %q", tenant, "42")
+		}
+	}
+	fFailure := func(token string) {
+		t.Helper()
+		if _, err := jc.verify(token); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	fSuccess(mustSignTestJWT("ES256", "ec", ecKey, validClaims))
+	fSuccess(mustSignTestJWT("RS256", "rsa", rsaKey, validClaims))
+	fSuccess(mustSignTestJWT("PS256", "rsa", rsaKey, validClaims))
+	fSuccess(mustSignTestJWT("EdDSA", "ed", edPrivateKey, validClaims))
+
+	// missing kid
+	fSuccess(mustSignTestJWT("ES256", "", ecKey, validClaims))
+
+	// unknown kid
+	fFailure(mustSignTestJWT("ES256", "unknown", ecKey, validClaims))
+
+	// unknown key
+	fFailure(mustSignTestJWT("ES256", "ec", otherKey, validClaims))
+	fFailure(mustSignTestJWT("ES256", "", otherKey, validClaims))
+
+	// alg mismatch
+	fFailure(mustSignTestJWT("RS256", "ec", rsaKey, validClaims))
+
+	// unsigned token
+	fFailure(mustSignTestJWT("none", "ec", nil, validClaims))
+
+	// malformed token
+	fFailure("foo.bar.baz")
+	fFailure("foobar")
+
+	// tampered claims
+	token := mustSignTestJWT("ES256", "ec", ecKey, validClaims)
+	parts := strings.Split(token, ".")
+	parts[1] = mustMarshalTestJWTPart(map[string]any{
+		"iss": "https://idp.example.com",
+		"aud": "vmauth",
+		"vm_access": map[string]any{
+			"team":   "ops",
+			"tenant": 1,
+		},
+	})
+	fFailure(strings.Join(parts, "."))
+
+	claimsWith := func(name string, value any) map[string]any {
+		m := make(map[string]any, len(validClaims))
+		for k, v := range validClaims {
+			m[k] = v
+		}
+		m[name] = value
+		return m
+	}
+
+	// expired token
+	fFailure(mustSignTestJWT("ES256", "ec", ecKey, claimsWith("exp", time.Now().Add(-time.Minute).Unix())))
+
+	// not valid yet
+	fFailure(mustSignTestJWT("ES256", "ec", ecKey, claimsWith("nbf", time.Now().Add(time.Hour).Unix())))
+
+	// unexpected issuer
+	fFailure(mustSignTestJWT("ES256", "ec", ecKey, claimsWith("iss", "https://other.example.com")))
+
+	// unexpected audience
+	fFailure(mustSignTestJWT("ES256", "ec", ecKey, claimsWith("aud", "grafana")))
+
+	// claims mismatch
+	fFailure(mustSignTestJWT("ES256", "ec", ecKey, claimsWith("vm_access", map[string]any{"team": "dev", "tenant": 42})))
+	fFailure(mustSignTestJWT("ES256", "ec", ecKey, claimsWith("vm_access", "ops")))
+
+	// missing exp
+	claimsWithoutExp := claimsWith("exp", nil)
+	delete(claimsWithoutExp, "exp")
+	fFailure(mustSignTestJWT("ES256", "ec", ecKey, claimsWithoutExp))
+	jc.AllowMissingExp = true
+	fSuccess(mustSignTestJWT("ES256", "ec", ecKey, claimsWithoutExp))
+}
+
+func TestJWKSKeysRefresh(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate ecdsa key: %s", err)
+	}
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate ecdsa key: %s", err)
+	}
+
+	mustReadTestJWKS := func(keys map[string]crypto.PublicKey) []byte {
+		path := t.Name() + ".json"
+		mustWriteTestJWKS(path, keys)
+		defer fs.MustRemoveAll(path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("cannot read JWKS: %s", err)
+		}
+		return data
+	}
+	data := mustReadTestJWKS(map[string]crypto.PublicKey{
+		"ec": &ecKey.PublicKey,
+	})
+	dataNew := mustReadTestJWKS(map[string]crypto.PublicKey{
+		"ec":  &ecKey.PublicKey,
+		"new": &newKey.PublicKey,
+	})
+
+	var requests atomic.Int32
+	unblockCh := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) == 1 {
+			_, _ = w.Write(data)
+			return
+		}
+		<-unblockCh
+		_, _ = w.Write(dataNew)
+	}))
+	defer srv.Close()
+
+	jwks, err := newJWKSKeys(srv.URL)
+	if err != nil {
+		t.Fatalf("cannot load JWKS: %s", err)
+	}
+	// Allow refreshing the keys immediately.
+	jwks.lastRefresh = 0
+
+	// Concurrent requests for the unknown kid must result in a single refresh.
+	const workers = 10
+	var wg sync.WaitGroup
+	var keysFound atomic.Int32
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if len(jwks.getKeys("new")) == 1 {
+				keysFound.Add(1)
+			}
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for JWKS refresh")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The known keys must be available while the refresh is in progress.
+	doneCh := make(chan struct{})
+	go func() {
+		if len(jwks.getKeys("ec")) != 1 {
+			t.Errorf("cannot find the known key during refresh")
+		}
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("getKeys for the known key is blocked by JWKS refresh")
+	}
+
+	close(unblockCh)
+	wg.Wait()
+	if n := keysFound.Load(); n != workers {
+		t.Fatalf("unexpected number of workers, which found the new key; got %d; want %d", n, workers)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("unexpected number of JWKS requests; got %d; want 2", n)
+	}
+}
+
+func TestJWTClaimsApplyToURL(t *testing.T) {
+	claims := jwtClaims{
+		"tenant": "42:1",
+		"team":   "ops",
+		"nested": map[string]any{
+			"env": "prod",
+		},
+		"https://example.com/project": "foo bar",
+		"bad_path":                    "../admin",
+		"list":                        []any{"a", "b"},
+	}
+
+	f := func(urlStr, resultExpected string) {
+		t.Helper()
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", urlStr, err)
+		}
+		uNew, err := claims.applyToURL(u)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		result := uNew.String()
+		if result != resultExpected {
+			t.Fatalf("unexpected result; got %q; want %q", result, resultExpected)
+		}
+		if u.String() == result && strings.Contains(urlStr, "{{") {
+			t.Fatalf("the original url mustn't contain placeholders after the substitution")
+		}
+	}
+
+	f("http://vmselect:8481/select/{{.tenant}}/prometheus", "http://vmselect:8481/select/42:1/prometheus")
+	f("http://vmselect:8481/select/0/prometheus?extra_label=team={{.team}}&extra_label=env={{.nested.env}}",
+		"http://vmselect:8481/select/0/prometheus?extra_label=team%3Dops&extra_label=env%3Dprod")
+	f("http://foo/{{.https://example.com/project}}", "http://foo/foo%20bar")
+	f("http://foo/bar", "http://foo/bar")
+
+	fFailure := func(urlStr string) {
+		t.Helper()
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", urlStr, err)
+		}
+		if _, err := claims.applyToURL(u); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// missing claim
+	fFailure("http://foo/{{.missing}}")
+	fFailure("http://foo/?extra_label=team={{.missing}}")
+
+	// claim value cannot be used in path
+	fFailure("http://foo/{{.bad_path}}/bar")
+
+	// unsupported claim type
+	fFailure("http://foo/?extra_label=team={{.list}}")
+}
+
+func TestJWTClaimsApplyToHeaders(t *testing.T) {
+	claims := jwtClaims{
+		"tenant": "42",
+		"bad":    "foo\r\nX-Injected: bar",
+	}
+
+	headers := []*Header{
+		{
+			Name:  "X-Scope-OrgID",
+			Value: "{{.tenant}}",
+		},
+		{
+			Name:  "X-Static",
+			Value: "foo",
+		},
+	}
+	result, err := claims.applyToHeaders(headers)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("unexpected number of headers; got %d; want 2", len(result))
+	}
+	if result[0].Name != "X-Scope-OrgID" || result[0].Value != "42" {
+		t.Fatalf("unexpected header: %s: %s", result[0].Name, result[0].Value)
+	}
+	if result[1] != headers[1] {
+		t.Fatalf("unexpected header: %s: %s", result[1].Name, result[1].Value)
+	}
+	if headers[0].Value != "{{.tenant}}" {
+		t.Fatalf("the original header mustn't be modified; got %q", headers[0].Value)
+	}
+
+	headers = []*Header{
+		{
+			Name:  "X-Bad",
+			Value: "{{.bad}}",
+		},
+	}
+	if _, err := claims.applyToHeaders(headers); err == nil {
+		t.Fatalf("expecting non-nil error for header with control chars")
+	}
+}
+
+func TestRequestHandlerJWT(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate ecdsa key: %s", err)
+	}
+	jwksPath := t.Name() + ".json"
+	mustWriteTestJWKS(jwksPath, map[string]crypto.PublicKey{
+		"key1": &key.PublicKey,
+	})
+	defer fs.MustRemoveAll(jwksPath)
+
+	cfgStr := fmt.Sprintf(`
+users:
+- username: foo
+  password: bar
+  url_prefix: {BACKEND}/basic
+- name: ops
+  jwt:
+    jwks_file: %q
+    match_claims:
+      team: ops
+  url_prefix: "{BACKEND}/select/{{.tenant}}/prometheus?extra_label=team={{.team}}"
+  headers:
+  - "X-Tenant: {{.tenant}}"
+`, jwksPath)
+
+	backendHandler := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "requested_url=http://%s%s\nX-Tenant: %s", r.Host, r.URL, r.Header.Get("X-Tenant"))
+	}
+
+	f := func(authHeader, requestURL, responseExpected string) {
+		t.Helper()
+		origLogInvalidAuthTokens := *logInvalidAuthTokens
+		*logInvalidAuthTokens = false
+		defer func() {
+			*logInvalidAuthTokens = origLogInvalidAuthTokens
+		}()
+
+		ts := httptest.NewServer(http.HandlerFunc(backendHandler))
+		defer ts.Close()
+
+		cfgData := strings.ReplaceAll(cfgStr, "{BACKEND}", ts.URL)
+		responseExpected = strings.ReplaceAll(responseExpected, "{BACKEND}", ts.URL)
+
+		cfgOrigP := authConfigData.Load()
+		if _, err := reloadAuthConfigData([]byte(cfgData)); err != nil {
+			t.Fatalf("cannot load config data: %s", err)
+		}
+		defer func() {
+			cfgOrig := []byte("unauthorized_user:\n  url_prefix: http://foo/bar")
+			if cfgOrigP != nil {
+				cfgOrig = *cfgOrigP
+			}
+			if _, err := reloadAuthConfigData(cfgOrig); err != nil {
+				t.Fatalf("cannot load the original config: %s", err)
+			}
+		}()
+
+		r, err := http.NewRequest(http.MethodGet, requestURL, nil)
+		if err != nil {
+			t.Fatalf("cannot initialize http request: %s", err)
+		}
+		r.RequestURI = r.URL.RequestURI()
+		r.RemoteAddr = "42.2.3.84:6789"
+		if authHeader != "" {
+			r.Header.Set("Authorization", authHeader)
+		}
+
+		w := &fakeResponseWriter{}
+		if !requestHandler(w, r) {
+			t.Fatalf("unexpected false is returned from requestHandler")
+		}
+
+		response := w.getResponse()
+		response = strings.ReplaceAll(response, "\r\n", "\n")
+		response = strings.TrimSpace(response)
+		responseExpected = strings.TrimSpace(responseExpected)
+		if response != responseExpected {
+			t.Fatalf("unexpected response\ngot\n%s\nwant\n%s", response, responseExpected)
+		}
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	// valid token
+	token := mustSignTestJWT("ES256", "key1", key, map[string]any{
+		"exp":    exp,
+		"team":   "ops",
+		"tenant": "42",
+	})
+	f("Bearer "+token, "http://some-host.com/api/v1/query?query=up&extra_label=team=dev", `
+statusCode=200
+requested_url={BACKEND}/select/42/prometheus/api/v1/query?extra_label=team%3Dops&query=up
+X-Tenant: 42`)
+
+	// basic auth still works
+	f("Basic "+base64.StdEncoding.EncodeToString([]byte("foo:bar")), "http://some-host.com/api/v1/query", `
+statusCode=200
+requested_url={BACKEND}/basic/api/v1/query
+X-Tenant:`)
+
+	// token with non-matching claims
+	token = mustSignTestJWT("ES256", "key1", key, map[string]any{
+		"exp":    exp,
+		"team":   "dev",
+		"tenant": "42",
+	})
+	f("Bearer "+token, "http://some-host.com/api/v1/query", `
+statusCode=401
+Unauthorized`)
+
+	// expired token
+	token = mustSignTestJWT("ES256", "key1", key, map[string]any{
+		"exp":    time.Now().Add(-time.Hour).Unix(),
+		"team":   "ops",
+		"tenant": "42",
+	})
+	f("Bearer "+token, "http://some-host.com/api/v1/query", `
+statusCode=401
+Unauthorized`)
+
+	// token with missing tenant claim
+	token = mustSignTestJWT("ES256", "key1", key, map[string]any{
+		"exp":  exp,
+		"team": "ops",
+	})
+	f("Bearer "+token, "http://some-host.com/api/v1/query", `
+statusCode=403
+remoteAddr: "42.2.3.84:6789"; requestURI: /api/v1/query; cannot apply JWT claims: missing claim "tenant"`)
+}
+
+func mustWriteTestJWKS(path string, keys map[string]crypto.PublicKey) {
+	var jwks []map[string]string
+	for kid, key := range keys {
+		m := map[string]string{
+			"kid": kid,
+			"use": "sig",
+		}
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			m["kty"] = "EC"
+			m["crv"] = k.Curve.Params().Name
+			m["x"] = base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, 32)))
+			m["y"] = base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, 32)))
+		case *rsa.PublicKey:
+			m["kty"] = "RSA"
+			m["n"] = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			m["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			m["kty"] = "OKP"
+			m["crv"] = "Ed25519"
+			m["x"] = base64.RawURLEncoding.EncodeToString(k)
+		default:
+			panic(fmt.Errorf("BUG: unexpected key type %T", key))
+		}
+		jwks = append(jwks, m)
+	}
+	data, err := json.Marshal(map[string]any{
+		"keys": jwks,
+	})
+	if err != nil {
+		panic(fmt.Errorf("cannot marshal JWKS: %w", err))
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		panic(fmt.Errorf("cannot write JWKS to %q: %w", path, err))
+	}
+}
+
+func mustSignTestJWT(alg, kid string, key crypto.Signer, claims map[string]any) string {
+	header := map[string]any{
+		"alg": alg,
+		"typ": "JWT",
+	}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	signingInput := mustMarshalTestJWTPart(header) + "." + mustMarshalTestJWTPart(claims)
+
+	var signature []byte
+	switch alg {
+	case "none":
+	case "EdDSA":
+		signature = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signingInput))
+	case "ES256":
+		h := sha256.Sum256([]byte(signingInput))
+		r, s, err := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), h[:])
+		if err != nil {
+			panic(fmt.Errorf("cannot sign JWT: %w", err))
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "RS256":
+		h := sha256.Sum256([]byte(signingInput))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, h[:])
+		if err != nil {
+			panic(fmt.Errorf("cannot sign JWT: %w", err))
+		}
+		signature = sig
+	case "PS256":
+		h := sha256.Sum256([]byte(signingInput))
+		sig, err := rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, h[:], &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		})
+		if err != nil {
+			panic(fmt.Errorf("cannot sign JWT: %w", err))
+		}
+		signature = sig
+	default:
+		panic(fmt.Errorf("BUG: unsupported alg %q", alg))
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func mustMarshalTestJWTPart(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Errorf("cannot marshal JWT part: %w", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/pushmetrics"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/ratelimiter"
 )
 
 var (
@@ -126,7 +127,7 @@ func requestHandler(w http.ResponseWriter, r *http.Request) bool {
 		// Process requests for unauthorized users
 		ui := authConfig.Load().UnauthorizedUser
 		if ui != nil {
-			processUserRequest(w, r, ui)
+			processUserRequest(w, r, ui, nil)
 			return true
 		}
 
@@ -135,16 +136,29 @@ func requestHandler(w http.ResponseWriter, r *http.Request) bool {
 	}
 
 	ui := getUserInfoByAuthTokens(ats)
+	var claims jwtClaims
+	var jwtErr error
+	if ui == nil {
+		// Try authorizing the request via JWT
+		if token := getJWTToken(ats); token != "" {
+			if uis := authConfig.Load().jwtUsers; len(uis) > 0 {
+				ui, claims, jwtErr = getUserInfoByJWT(uis, token)
+			}
+		}
+	}
 	if ui == nil {
 		uu := authConfig.Load().UnauthorizedUser
 		if uu != nil {
-			processUserRequest(w, r, uu)
+			processUserRequest(w, r, uu, nil)
 			return true
 		}
 
 		invalidAuthTokenRequests.Inc()
 		if *logInvalidAuthTokens {
 			err := fmt.Errorf("cannot authorize request with auth tokens %q", ats)
+			if jwtErr != nil {
+				err = fmt.Errorf("%w: %w", err, jwtErr)
+			}
 			err = &httpserver.ErrorWithStatusCode{
 				Err:        err,
 				StatusCode: http.StatusUnauthorized,
@@ -156,7 +170,7 @@ func requestHandler(w http.ResponseWriter, r *http.Request) bool {
 		return true
 	}
 
-	processUserRequest(w, r, ui)
+	processUserRequest(w, r, ui, claims)
 	return true
 }
 
@@ -171,7 +185,10 @@ func getUserInfoByAuthTokens(ats []string) *UserInfo {
 	return nil
 }
 
-func processUserRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo) {
+// processUserRequest processes the request r for the given ui.
+//
+// claims must contain JWT claims if the request is authorized via JWT.
+func processUserRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo, claims jwtClaims) {
 	startTime := time.Now()
 	defer ui.requestsDuration.UpdateDuration(startTime)
 
@@ -192,12 +209,12 @@ func processUserRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo) {
 		handleConcurrencyLimitError(w, r, err)
 		return
 	}
-	processRequest(w, r, ui)
+	processRequest(w, r, ui, claims)
 	ui.endConcurrencyLimit()
 	<-concurrencyLimitCh
 }
 
-func processRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo) {
+func processRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo, claims jwtClaims) {
 	u := normalizeURL(r.URL)
 	up, hc := ui.getURLPrefixAndHeaders(u, r.Host, r.Header)
 	isDefault := false
@@ -206,7 +223,7 @@ func processRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo) {
 			// Authorization should be requested for http requests without credentials
 			// to a route that is not in the configuration for unauthorized user.
 			// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/5236
-			if ui.BearerToken == "" && ui.Username == "" && len(*authUsers.Load()) > 0 {
+			if ui.BearerToken == "" && ui.Username == "" && ui.JWT == nil && len(*authUsers.Load()) > 0 {
 				handleMissingAuthorizationError(w)
 				return
 			}
@@ -222,8 +239,25 @@ func processRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo) {
 		isDefault = true
 	}
 
-	rtb := newReadTrackingBody(r.Body, maxRequestBodySizeToRetry.IntN())
+	if d, err := checkRateLimits(ui, up); err != nil {
+		handleRateLimitError(w, r, d, err)
+		return
+	}
+
+	if claims != nil {
+		// Substitute JWT claims into request headers.
+		requestHeaders, err := claims.applyToHeaders(hc.RequestHeaders)
+		if err != nil {
+			handleInvalidClaimsError(w, r, err)
+			return
+		}
+		hc.RequestHeaders = requestHeaders
+	}
+
+	body := newRateLimitedReader(r.Body, getRequestBytesLimiters(ui.rl, up.rl))
+	rtb := newReadTrackingBody(body, maxRequestBodySizeToRetry.IntN())
 	r.Body = rtb
+	responseBytesLimiters := getResponseBytesLimiters(ui.rl, up.rl)
 
 	maxAttempts := up.getBackendsCount()
 	for i := 0; i < maxAttempts; i++ {
@@ -231,7 +265,12 @@ func processRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo) {
 		if bu == nil {
 			break
 		}
-		targetURL := bu.url
+		targetURL, err := claims.applyToURL(bu.url)
+		if err != nil {
+			bu.put()
+			handleInvalidClaimsError(w, r, err)
+			return
+		}
 		// Don't change path and add request_path query param for default route.
 		if isDefault {
 			query := targetURL.Query()
@@ -243,7 +282,7 @@ func processRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo) {
 
 		wasLocalRetry := false
 	again:
-		ok, needLocalRetry := tryProcessingRequest(w, r, targetURL, hc, up.retryStatusCodes, ui)
+		ok, needLocalRetry := tryProcessingRequest(w, r, targetURL, hc, up.retryStatusCodes, ui, responseBytesLimiters)
 		if needLocalRetry && !wasLocalRetry {
 			wasLocalRetry = true
 			goto again
@@ -263,7 +302,8 @@ func processRequest(w http.ResponseWriter, r *http.Request, ui *UserInfo) {
 	ui.backendErrors.Inc()
 }
 
-func tryProcessingRequest(w http.ResponseWriter, r *http.Request, targetURL *url.URL, hc HeadersConf, retryStatusCodes []int, ui *UserInfo) (bool, bool) {
+func tryProcessingRequest(w http.ResponseWriter, r *http.Request, targetURL *url.URL, hc HeadersConf, retryStatusCodes []int, ui *UserInfo,
+	responseBytesLimiters []*ratelimiter.RateLimiter) (bool, bool) {
 	req := sanitizeRequestHeaders(r)
 
 	req.URL = targetURL
@@ -343,7 +383,7 @@ func tryProcessingRequest(w http.ResponseWriter, r *http.Request, targetURL *url
 
 	copyBuf := copyBufPool.Get()
 	copyBuf.B = bytesutil.ResizeNoCopyNoOverallocate(copyBuf.B, 16*1024)
-	_, err = io.CopyBuffer(w, res.Body, copyBuf.B)
+	_, err = io.CopyBuffer(w, newRateLimitedReader(res.Body, responseBytesLimiters), copyBuf.B)
 	copyBufPool.Put(copyBuf)
 	_ = res.Body.Close()
 	if err != nil && !netutil.IsTrivialNetworkError(err) {
@@ -441,6 +481,7 @@ var (
 	configReloadRequests     = metrics.NewCounter(`vmauth_http_requests_total{path="/-/reload"}`)
 	invalidAuthTokenRequests = metrics.NewCounter(`vmauth_http_request_errors_total{reason="invalid_auth_token"}`)
 	missingRouteRequests     = metrics.NewCounter(`vmauth_http_request_errors_total{reason="missing_route"}`)
+	invalidJWTClaimsRequests = metrics.NewCounter(`vmauth_http_request_errors_total{reason="invalid_jwt_claims"}`)
 )
 
 func newRoundTripper(caFileOpt, certFileOpt, keyFileOpt, serverNameOpt string, insecureSkipVerifyP *bool) (http.RoundTripper, error) {
@@ -524,6 +565,15 @@ func handleMissingAuthorizationError(w http.ResponseWriter) {
 	http.Error(w, "missing 'Authorization' request header", http.StatusUnauthorized)
 }
 
+func handleInvalidClaimsError(w http.ResponseWriter, r *http.Request, err error) {
+	invalidJWTClaimsRequests.Inc()
+	err = &httpserver.ErrorWithStatusCode{
+		Err:        fmt.Errorf("cannot apply JWT claims: %w", err),
+		StatusCode: http.StatusForbidden,
+	}
+	httpserver.Errorf(w, r, "%s", err)
+}
+
 func handleConcurrencyLimitError(w http.ResponseWriter, r *http.Request, err error) {
 	w.Header().Add("Retry-After", "10")
 	err = &httpserver.ErrorWithStatusCode{
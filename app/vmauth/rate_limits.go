@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/ratelimiter"
+)
+
+// RateLimitsConf represents config for request rate and bandwidth limits.
+//
+// See https://docs.victoriametrics.com/vmauth/#rate-limiting
+type RateLimitsConf struct {
+	// MaxRequestsPerSecond is the maximum number of requests per second.
+	MaxRequestsPerSecond int `yaml:"max_requests_per_second,omitempty"`
+
+	// MaxRequestBytesPerSecond is the maximum number of request body bytes per second, which can be sent to backends.
+	MaxRequestBytesPerSecond *Bytes `yaml:"max_request_bytes_per_second,omitempty"`
+
+	// MaxResponseBytesPerSecond is the maximum number of response body bytes per second, which can be received from backends.
+	MaxResponseBytesPerSecond *Bytes `yaml:"max_response_bytes_per_second,omitempty"`
+}
+
+// Bytes is the number of bytes, which may contain optional suffixes such as KB, MB, KiB, MiB.
+type Bytes struct {
+	N int64
+
+	sOriginal string
+}
+
+// UnmarshalYAML unmarshals b from f.
+func (b *Bytes) UnmarshalYAML(f func(any) error) error {
+	var s string
+	if err := f(&s); err != nil {
+		return err
+	}
+	n, err := flagutil.ParseBytes(s)
+	if err != nil {
+		return fmt.Errorf("cannot parse bytes value %q: %w", s, err)
+	}
+	if n <= 0 {
+		return fmt.Errorf("bytes value must be positive; got %q", s)
+	}
+	b.N = n
+	b.sOriginal = s
+	return nil
+}
+
+// MarshalYAML marshals b to yaml.
+func (b *Bytes) MarshalYAML() (any, error) {
+	return b.sOriginal, nil
+}
+
+// rateLimiters contains rate limiters initialized from RateLimitsConf.
+type rateLimiters struct {
+	conf *RateLimitsConf
+
+	requests      *ratelimiter.RateLimiter
+	requestBytes  *ratelimiter.RateLimiter
+	responseBytes *ratelimiter.RateLimiter
+}
+
+// newRateLimiters returns rate limiters for rlc.
+//
+// nil is returned if rlc has no limits.
+// metricName and metricLabels are used for registering the counter for throttled requests at ms.
+func newRateLimiters(rlc *RateLimitsConf, ms *metrics.Set, metricName, metricLabels string) (*rateLimiters, error) {
+	if rlc.MaxRequestsPerSecond < 0 {
+		return nil, fmt.Errorf("max_requests_per_second cannot be negative; got %d", rlc.MaxRequestsPerSecond)
+	}
+	if rlc.MaxRequestsPerSecond == 0 && rlc.MaxRequestBytesPerSecond == nil && rlc.MaxResponseBytesPerSecond == nil {
+		return nil, nil
+	}
+
+	newRateLimiter := func(limit int64, reason string) *ratelimiter.RateLimiter {
+		if limit <= 0 {
+			return nil
+		}
+		labels := fmt.Sprintf(`reason=%q`, reason)
+		if metricLabels != "" {
+			labels = strings.TrimSuffix(strings.TrimPrefix(metricLabels, "{"), "}") + "," + labels
+		}
+		limitReached := ms.GetOrCreateCounter(metricName + "{" + labels + "}")
+		return ratelimiter.New(limit, limitReached, nil)
+	}
+	rls := &rateLimiters{
+		conf:     rlc,
+		requests: newRateLimiter(int64(rlc.MaxRequestsPerSecond), "max_requests_per_second"),
+	}
+	if rlc.MaxRequestBytesPerSecond != nil {
+		rls.requestBytes = newRateLimiter(rlc.MaxRequestBytesPerSecond.N, "max_request_bytes_per_second")
+	}
+	if rlc.MaxResponseBytesPerSecond != nil {
+		rls.responseBytes = newRateLimiter(rlc.MaxResponseBytesPerSecond.N, "max_response_bytes_per_second")
+	}
+	return rls, nil
+}
+
+// check verifies whether a new request can be processed according to rls.
+//
+// It returns non-nil error together with the duration to wait before retrying the request if the request must be rejected.
+func (rls *rateLimiters) check() (time.Duration, error) {
+	if rls == nil {
+		return 0, nil
+	}
+
+	// Check bandwidth limits at first, so the request isn't counted by requests limiter if it is rejected.
+	if d := rls.requestBytes.TryRegister(0); d > 0 {
+		return d, fmt.Errorf("max_request_bytes_per_second=%s is exceeded", rls.conf.MaxRequestBytesPerSecond.sOriginal)
+	}
+	if d := rls.responseBytes.TryRegister(0); d > 0 {
+		return d, fmt.Errorf("max_response_bytes_per_second=%s is exceeded", rls.conf.MaxResponseBytesPerSecond.sOriginal)
+	}
+	if d := rls.requests.TryRegister(1); d > 0 {
+		return d, fmt.Errorf("max_requests_per_second=%d is exceeded", rls.conf.MaxRequestsPerSecond)
+	}
+	return 0, nil
+}
+
+// checkRateLimits verifies whether the request from ui can be processed according to user-level and route-level rate limits.
+//
+// It returns non-nil error together with the duration to wait before retrying the request if the request must be rejected.
+func checkRateLimits(ui *UserInfo, up *URLPrefix) (time.Duration, error) {
+	d, err := ui.rl.check()
+	if err == nil {
+		d, err = up.rl.check()
+	}
+	if err == nil {
+		return 0, nil
+	}
+	if name := ui.name(); name != "" {
+		return d, fmt.Errorf("cannot process the request from user %q: %w", name, err)
+	}
+	return d, fmt.Errorf("cannot process the request: %w", err)
+}
+
+// getRequestBytesLimiters returns limiters for request body bytes from the given rls.
+func getRequestBytesLimiters(rls ...*rateLimiters) []*ratelimiter.RateLimiter {
+	var a []*ratelimiter.RateLimiter
+	for _, rl := range rls {
+		if rl != nil && rl.requestBytes != nil {
+			a = append(a, rl.requestBytes)
+		}
+	}
+	return a
+}
+
+// getResponseBytesLimiters returns limiters for response body bytes from the given rls.
+func getResponseBytesLimiters(rls ...*rateLimiters) []*ratelimiter.RateLimiter {
+	var a []*ratelimiter.RateLimiter
+	for _, rl := range rls {
+		if rl != nil && rl.responseBytes != nil {
+			a = append(a, rl.responseBytes)
+		}
+	}
+	return a
+}
+
+// rateLimitedReader registers all the bytes read from r at rls.
+//
+// It never blocks, so the bandwidth limits are enforced by rejecting subsequent requests
+// until the budget for the read bytes is restored.
+type rateLimitedReader struct {
+	r   io.ReadCloser
+	rls []*ratelimiter.RateLimiter
+}
+
+func newRateLimitedReader(r io.ReadCloser, rls []*ratelimiter.RateLimiter) io.ReadCloser {
+	if r == nil || len(rls) == 0 {
+		return r
+	}
+	return &rateLimitedReader{
+		r:   r,
+		rls: rls,
+	}
+}
+
+// Read implements io.Reader interface.
+func (lr *rateLimitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		for _, rl := range lr.rls {
+			rl.RegisterNonBlocking(n)
+		}
+	}
+	return n, err
+}
+
+// Close implements io.Closer interface.
+func (lr *rateLimitedReader) Close() error {
+	return lr.r.Close()
+}
+
+func handleRateLimitError(w http.ResponseWriter, r *http.Request, d time.Duration, err error) {
+	rateLimitedRequests.Inc()
+	retryAfter := int(math.Ceil(d.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	err = &httpserver.ErrorWithStatusCode{
+		Err:        err,
+		StatusCode: http.StatusTooManyRequests,
+	}
+	httpserver.Errorf(w, r, "%s", err)
+}
+
+var rateLimitedRequests = metrics.NewCounter(`vmauth_http_request_errors_total{reason="rate_limit_reached"}`)
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestHandlerRateLimits(t *testing.T) {
+	f := func(cfgStr string, requestURLs []string, backendHandler http.HandlerFunc, responsesExpected []string) {
+		t.Helper()
+
+		ts := httptest.NewServer(backendHandler)
+		defer ts.Close()
+
+		cfgStr = strings.ReplaceAll(cfgStr, "{BACKEND}", ts.URL)
+
+		cfgOrigP := authConfigData.Load()
+		if _, err := reloadAuthConfigData([]byte(cfgStr)); err != nil {
+			t.Fatalf("cannot load config data: %s", err)
+		}
+		defer func() {
+			cfgOrig := []byte("unauthorized_user:\n  url_prefix: http://foo/bar")
+			if cfgOrigP != nil {
+				cfgOrig = *cfgOrigP
+			}
+			if _, err := reloadAuthConfigData(cfgOrig); err != nil {
+				t.Fatalf("cannot load the original config: %s", err)
+			}
+		}()
+
+		for i, requestURL := range requestURLs {
+			r, err := http.NewRequest(http.MethodGet, requestURL, nil)
+			if err != nil {
+				t.Fatalf("cannot initialize http request: %s", err)
+			}
+			r.RequestURI = r.URL.RequestURI()
+			r.RemoteAddr = "42.2.3.84:6789"
+
+			w := &fakeResponseWriter{}
+			if !requestHandler(w, r) {
+				t.Fatalf("unexpected false is returned from requestHandler")
+			}
+
+			response := w.getResponse()
+			response = strings.ReplaceAll(response, "\r\n", "\n")
+			response = strings.TrimSpace(response)
+			responseExpected := strings.TrimSpace(responsesExpected[i])
+			if response != responseExpected {
+				t.Fatalf("unexpected response for request #%d\ngot\n%s\nwant\n%s", i, response, responseExpected)
+			}
+		}
+	}
+
+	backendHandler := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "requested_url=%s", r.URL)
+	}
+
+	// user-level requests limit
+	cfgStr := `
+unauthorized_user:
+  url_prefix: "{BACKEND}/foo"
+  max_requests_per_second: 2`
+	requestURL := "http://some-host.com/abc"
+	f(cfgStr, []string{requestURL, requestURL, requestURL}, backendHandler, []string{`
+statusCode=200
+requested_url=/foo/abc`, `
+statusCode=200
+requested_url=/foo/abc`, `
+statusCode=429
+Retry-After: 1
+remoteAddr: "42.2.3.84:6789"; requestURI: /abc; cannot process the request: max_requests_per_second=2 is exceeded`})
+
+	// url_map-level requests limit doesn't affect other routes
+	cfgStr = `
+unauthorized_user:
+  url_map:
+  - src_paths: ["/limited"]
+    url_prefix: "{BACKEND}/foo"
+    max_requests_per_second: 1
+  - src_paths: ["/unlimited"]
+    url_prefix: "{BACKEND}/bar"`
+	f(cfgStr, []string{
+		"http://some-host.com/limited",
+		"http://some-host.com/limited",
+		"http://some-host.com/unlimited",
+		"http://some-host.com/unlimited",
+	}, backendHandler, []string{`
+statusCode=200
+requested_url=/foo/limited`, `
+statusCode=429
+Retry-After: 1
+remoteAddr: "42.2.3.84:6789"; requestURI: /limited; cannot process the request: max_requests_per_second=1 is exceeded`, `
+statusCode=200
+requested_url=/bar/unlimited`, `
+statusCode=200
+requested_url=/bar/unlimited`})
+
+	// response bytes limit
+	bigResponseHandler := func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "%s", strings.Repeat("x", 2500))
+	}
+	cfgStr = `
+unauthorized_user:
+  url_prefix: "{BACKEND}/foo"
+  max_response_bytes_per_second: 1KiB`
+	f(cfgStr, []string{requestURL, requestURL}, bigResponseHandler, []string{`
+statusCode=200
+` + strings.Repeat("x", 2500), `
+statusCode=429
+Retry-After: 2
+remoteAddr: "42.2.3.84:6789"; requestURI: /abc; cannot process the request: max_response_bytes_per_second=1KiB is exceeded`})
+}
@@ -9,8 +9,10 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/relabel"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	parserCommon "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/ratelimiter"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/slicesutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
@@ -53,6 +55,12 @@ type InsertCtx struct {
 	mrs            []storage.MetricRow
 	metricNamesBuf []byte
 
+	mms []storage.MetricMetadata
+
+	ers                    []storage.ExemplarRow
+	exemplarLabels         []storage.ExemplarLabel
+	pendingExemplarLabelsN int
+
 	relabelCtx    relabel.Ctx
 	streamAggrCtx streamAggrCtx
 
@@ -75,6 +83,16 @@ func (ctx *InsertCtx) Reset(rowsLen int) {
 	ctx.mrs = mrs[:0]
 
 	ctx.metricNamesBuf = ctx.metricNamesBuf[:0]
+
+	clear(ctx.mms)
+	ctx.mms = ctx.mms[:0]
+
+	clear(ctx.ers)
+	ctx.ers = ctx.ers[:0]
+	clear(ctx.exemplarLabels)
+	ctx.exemplarLabels = ctx.exemplarLabels[:0]
+	ctx.pendingExemplarLabelsN = 0
+
 	ctx.relabelCtx.Reset()
 	ctx.streamAggrCtx.Reset()
 	ctx.skipStreamAggr = false
@@ -131,6 +149,61 @@ func (ctx *InsertCtx) WriteDataPointExt(metricNameRaw []byte, labels []prompbmar
 	return metricNameRaw, err
 }
 
+// WriteMetricMetadata writes metadata for the given metricFamilyName into ctx buffer.
+//
+// The metadata is ignored if -enableMetadata command-line flag isn't set.
+// mt must contain MetricMetadata.MetricType enum value from Prometheus remote write protocol.
+func (ctx *InsertCtx) WriteMetricMetadata(metricFamilyName string, mt uint32, help, unit string) {
+	if !parserCommon.IsMetadataEnabled() {
+		return
+	}
+	ctx.mms = append(ctx.mms, storage.MetricMetadata{
+		MetricFamilyName: metricFamilyName,
+		Type:             mt,
+		Help:             help,
+		Unit:             unit,
+	})
+}
+
+// AddExemplarLabel adds (name, value) label to the exemplar, which is written by the next WriteExemplar call.
+//
+// name and value must exist until ctx.FlushBufs is called.
+func (ctx *InsertCtx) AddExemplarLabel(name, value string) {
+	ctx.exemplarLabels = append(ctx.exemplarLabels, storage.ExemplarLabel{
+		Name:  name,
+		Value: value,
+	})
+	ctx.pendingExemplarLabelsN++
+}
+
+// WriteExemplar writes exemplar with the given value and timestamp for the time series with the given metricNameRaw and labels into ctx buffer.
+//
+// The exemplar contains labels added via AddExemplarLabel since the previous WriteExemplar call.
+// The current time is used as exemplar timestamp if timestamp <= 0.
+//
+// caller must invoke TryPrepareLabels before using this function
+//
+// It returns metricNameRaw for the given labels if len(metricNameRaw) == 0.
+func (ctx *InsertCtx) WriteExemplar(metricNameRaw []byte, labels []prompbmarshal.Label, value float64, timestamp int64) []byte {
+	if len(metricNameRaw) == 0 {
+		metricNameRaw = ctx.marshalMetricNameRaw(nil, labels)
+	}
+	if timestamp <= 0 {
+		timestamp = int64(fasttime.UnixTimestamp()) * 1000
+	}
+	exemplarLabels := ctx.exemplarLabels[len(ctx.exemplarLabels)-ctx.pendingExemplarLabelsN:]
+	ctx.pendingExemplarLabelsN = 0
+	ctx.ers = append(ctx.ers, storage.ExemplarRow{
+		MetricNameRaw: metricNameRaw,
+		Exemplar: storage.Exemplar{
+			Labels:    exemplarLabels[:len(exemplarLabels):len(exemplarLabels)],
+			Value:     value,
+			Timestamp: timestamp,
+		},
+	})
+	return metricNameRaw
+}
+
 func (ctx *InsertCtx) addRow(metricNameRaw []byte, timestamp int64, value float64) error {
 	mrs := ctx.mrs
 	if cap(mrs) > len(mrs) {
@@ -211,6 +284,13 @@ func (ctx *InsertCtx) FlushBufs() error {
 	// used at every stream.Parse() call under lib/protoparser/*
 
 	err := vmstorage.AddRows(ctx.mrs)
+	if err == nil && len(ctx.ers) > 0 {
+		// Exemplars must be added after the rows, since they are stored only for the existing time series.
+		err = vmstorage.AddExemplars(ctx.ers)
+	}
+	if err == nil && len(ctx.mms) > 0 {
+		err = vmstorage.AddMetricMetadata(ctx.mms)
+	}
 	ctx.Reset(0)
 	if err == nil {
 		return nil
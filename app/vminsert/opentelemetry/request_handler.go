@@ -72,6 +72,14 @@ func insertRows(tss []prompbmarshal.TimeSeries, extraLabels []prompbmarshal.Labe
 				return err
 			}
 		}
+		exemplars := ts.Exemplars
+		for i := range exemplars {
+			e := &exemplars[i]
+			for _, label := range e.Labels {
+				ctx.AddExemplarLabel(label.Name, label.Value)
+			}
+			metricNameRaw = ctx.WriteExemplar(metricNameRaw, ctx.Labels, e.Value, e.Timestamp)
+		}
 	}
 	rowsInserted.Add(rowsTotal)
 	rowsPerInsert.Update(float64(rowsTotal))
@@ -6,6 +6,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/common"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/relabel"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	parserCommon "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
 	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
@@ -29,14 +30,14 @@ func InsertHandler(req *http.Request) error {
 		return err
 	}
 	isGzipped := req.Header.Get("Content-Encoding") == "gzip"
-	return stream.Parse(req.Body, defaultTimestamp, isGzipped, true, func(rows []parser.Row) error {
-		return insertRows(rows, extraLabels)
+	return stream.Parse(req.Body, defaultTimestamp, isGzipped, true, func(rows []parser.Row, mms []parser.Metadata) error {
+		return insertRows(rows, mms, extraLabels)
 	}, func(s string) {
 		httpserver.LogError(req, s)
 	})
 }
 
-func insertRows(rows []parser.Row, extraLabels []prompbmarshal.Label) error {
+func insertRows(rows []parser.Row, mms []parser.Metadata, extraLabels []prompbmarshal.Label) error {
 	ctx := common.GetInsertCtx()
 	defer common.PutInsertCtx(ctx)
 
@@ -57,9 +58,26 @@ func insertRows(rows []parser.Row, extraLabels []prompbmarshal.Label) error {
 		if !ctx.TryPrepareLabels(hasRelabeling) {
 			continue
 		}
-		if err := ctx.WriteDataPoint(nil, ctx.Labels, r.Timestamp, r.Value); err != nil {
+		metricNameRaw, err := ctx.WriteDataPointExt(nil, ctx.Labels, r.Timestamp, r.Value)
+		if err != nil {
 			return err
 		}
+		if r.HasExemplar {
+			e := &r.Exemplar
+			for j := range e.Tags {
+				tag := &e.Tags[j]
+				ctx.AddExemplarLabel(tag.Key, tag.Value)
+			}
+			timestamp := e.Timestamp
+			if timestamp == 0 {
+				timestamp = r.Timestamp
+			}
+			ctx.WriteExemplar(metricNameRaw, ctx.Labels, e.Value, timestamp)
+		}
+	}
+	for i := range mms {
+		mm := &mms[i]
+		ctx.WriteMetricMetadata(mm.Metric, uint32(prompb.GetMetricType(mm.Type)), mm.Help, mm.Unit)
 	}
 	rowsInserted.Add(len(rows))
 	rowsPerInsert.Update(float64(len(rows)))
@@ -619,7 +619,9 @@ func mergeSortBlocks(dst *Result, sbh *sortBlocksHeap, dedupInterval int64) {
 			putSortBlock(top)
 		}
 	}
-	timestamps, values := storage.DeduplicateSamples(dst.Timestamps, dst.Values, dedupInterval)
+	// Apply downsampling to samples, which weren't downsampled by background merges yet.
+	currentTimestamp := int64(fasttime.UnixTimestamp() * 1000)
+	timestamps, values := storage.DownsampleSamples(dst.Timestamps, dst.Values, dedupInterval, currentTimestamp)
 	dedups := len(dst.Timestamps) - len(timestamps)
 	dedupsDuringSelect.Add(dedups)
 	dst.Timestamps = timestamps
@@ -960,6 +962,20 @@ func SeriesCount(qt *querytracer.Tracer, deadline searchutils.Deadline) (uint64,
 	return n, nil
 }
 
+// MetricMetadata returns metric metadata for the given metricFamilyName.
+//
+// Metadata for all the metric families is returned if metricFamilyName is empty.
+func MetricMetadata(qt *querytracer.Tracer, metricFamilyName string, limit, limitPerMetric int, deadline searchutils.Deadline) ([]storage.MetricMetadata, error) {
+	qt = qt.NewChild("get metric metadata: metric=%q, limit=%d, limitPerMetric=%d", metricFamilyName, limit, limitPerMetric)
+	defer qt.Done()
+	if deadline.Exceeded() {
+		return nil, fmt.Errorf("timeout exceeded before starting the query processing: %s", deadline.String())
+	}
+	mms := vmstorage.SearchMetricMetadata(metricFamilyName, limit, limitPerMetric)
+	qt.Printf("found %d metadata entries", len(mms))
+	return mms, nil
+}
+
 func getStorageSearch() *storage.Search {
 	v := ssPool.Get()
 	if v == nil {
@@ -1118,6 +1134,35 @@ func SearchMetricNames(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline
 	return metricNames, nil
 }
 
+// SearchExemplars returns exemplars for time series matching the given sq until the given deadline.
+func SearchExemplars(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline searchutils.Deadline) ([]storage.SeriesExemplars, error) {
+	qt = qt.NewChild("fetch exemplars: %s", sq)
+	defer qt.Done()
+	if deadline.Exceeded() {
+		return nil, fmt.Errorf("timeout exceeded before starting to search exemplars: %s", deadline.String())
+	}
+
+	// Setup search.
+	tr := sq.GetTimeRange()
+	if err := vmstorage.CheckTimeRange(tr); err != nil {
+		return nil, err
+	}
+	tfss, err := setupTfss(qt, tr, sq.TagFilterss, sq.MaxMetrics, deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	ses, err := vmstorage.SearchExemplars(qt, tfss, tr, sq.MaxMetrics, deadline.Deadline())
+	if err != nil {
+		return nil, fmt.Errorf("cannot find exemplars: %w", err)
+	}
+	sort.Slice(ses, func(i, j int) bool {
+		return string(ses[i].MetricName) < string(ses[j].MetricName)
+	})
+	qt.Printf("sort exemplars for %d series", len(ses))
+	return ses, nil
+}
+
 // ProcessSearchQuery performs sq until the given deadline.
 //
 // Results.RunParallel or Results.Cancel must be called on the returned Results.
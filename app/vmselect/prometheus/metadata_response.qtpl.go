@@ -0,0 +1,115 @@
+// Code generated by qtc from "metadata_response.qtpl". DO NOT EDIT.
+// See https://github.com/valyala/quicktemplate for details.
+
+//line app/vmselect/prometheus/metadata_response.qtpl:3
+package prometheus
+
+//line app/vmselect/prometheus/metadata_response.qtpl:3
+import (
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+// MetadataResponse generates response for /api/v1/metadata .mms must be sorted by MetricFamilyName.See https://prometheus.io/docs/prometheus/latest/querying/api/#querying-metric-metadata
+
+//line app/vmselect/prometheus/metadata_response.qtpl:12
+import (
+	qtio422016 "io"
+
+	qt422016 "github.com/valyala/quicktemplate"
+)
+
+//line app/vmselect/prometheus/metadata_response.qtpl:12
+var (
+	_ = qtio422016.Copy
+	_ = qt422016.AcquireByteBuffer
+)
+
+//line app/vmselect/prometheus/metadata_response.qtpl:12
+func StreamMetadataResponse(qw422016 *qt422016.Writer, mms []storage.MetricMetadata, qt *querytracer.Tracer) {
+//line app/vmselect/prometheus/metadata_response.qtpl:12
+	qw422016.N().S(`{"status":"success","data":{`)
+//line app/vmselect/prometheus/metadata_response.qtpl:16
+	for i := range mms {
+//line app/vmselect/prometheus/metadata_response.qtpl:17
+		mm := &mms[i]
+
+//line app/vmselect/prometheus/metadata_response.qtpl:18
+		if i == 0 || mms[i-1].MetricFamilyName != mm.MetricFamilyName {
+//line app/vmselect/prometheus/metadata_response.qtpl:19
+			if i > 0 {
+//line app/vmselect/prometheus/metadata_response.qtpl:19
+				qw422016.N().S(`],`)
+//line app/vmselect/prometheus/metadata_response.qtpl:19
+			}
+//line app/vmselect/prometheus/metadata_response.qtpl:20
+			qw422016.N().Q(mm.MetricFamilyName)
+//line app/vmselect/prometheus/metadata_response.qtpl:20
+			qw422016.N().S(`:[`)
+//line app/vmselect/prometheus/metadata_response.qtpl:21
+		} else {
+//line app/vmselect/prometheus/metadata_response.qtpl:21
+			qw422016.N().S(`,`)
+//line app/vmselect/prometheus/metadata_response.qtpl:23
+		}
+//line app/vmselect/prometheus/metadata_response.qtpl:23
+		qw422016.N().S(`{"type":`)
+//line app/vmselect/prometheus/metadata_response.qtpl:25
+		qw422016.N().Q(prompb.MetricType(mm.Type).String())
+//line app/vmselect/prometheus/metadata_response.qtpl:25
+		qw422016.N().S(`,"help":`)
+//line app/vmselect/prometheus/metadata_response.qtpl:26
+		qw422016.N().Q(mm.Help)
+//line app/vmselect/prometheus/metadata_response.qtpl:26
+		qw422016.N().S(`,"unit":`)
+//line app/vmselect/prometheus/metadata_response.qtpl:27
+		qw422016.N().Q(mm.Unit)
+//line app/vmselect/prometheus/metadata_response.qtpl:27
+		qw422016.N().S(`}`)
+//line app/vmselect/prometheus/metadata_response.qtpl:29
+	}
+//line app/vmselect/prometheus/metadata_response.qtpl:30
+	if len(mms) > 0 {
+//line app/vmselect/prometheus/metadata_response.qtpl:30
+		qw422016.N().S(`]`)
+//line app/vmselect/prometheus/metadata_response.qtpl:30
+	}
+//line app/vmselect/prometheus/metadata_response.qtpl:30
+	qw422016.N().S(`}`)
+//line app/vmselect/prometheus/metadata_response.qtpl:33
+	qt.Printf("generate response for %d metadata entries", len(mms))
+	qt.Done()
+
+//line app/vmselect/prometheus/metadata_response.qtpl:36
+	streamdumpQueryTrace(qw422016, qt)
+//line app/vmselect/prometheus/metadata_response.qtpl:36
+	qw422016.N().S(`}`)
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+}
+
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+func WriteMetadataResponse(qq422016 qtio422016.Writer, mms []storage.MetricMetadata, qt *querytracer.Tracer) {
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+	StreamMetadataResponse(qw422016, mms, qt)
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+}
+
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+func MetadataResponse(mms []storage.MetricMetadata, qt *querytracer.Tracer) string {
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+	WriteMetadataResponse(qb422016, mms, qt)
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+	qs422016 := string(qb422016.B)
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+	return qs422016
+//line app/vmselect/prometheus/metadata_response.qtpl:38
+}
@@ -649,6 +649,38 @@ func SeriesCountHandler(startTime time.Time, w http.ResponseWriter, r *http.Requ
 
 var seriesCountDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/series/count"}`)
 
+// MetadataHandler processes /api/v1/metadata request.
+//
+// See https://prometheus.io/docs/prometheus/latest/querying/api/#querying-metric-metadata
+func MetadataHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	defer metadataDuration.UpdateDuration(startTime)
+
+	limit, err := httputils.GetInt(r, "limit")
+	if err != nil {
+		return err
+	}
+	limitPerMetric, err := httputils.GetInt(r, "limit_per_metric")
+	if err != nil {
+		return err
+	}
+	metricFamilyName := r.FormValue("metric")
+	deadline := searchutils.GetDeadlineForStatusRequest(r, startTime)
+	mms, err := netstorage.MetricMetadata(qt, metricFamilyName, limit, limitPerMetric, deadline)
+	if err != nil {
+		return fmt.Errorf("cannot obtain metric metadata: %w", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	WriteMetadataResponse(bw, mms, qt)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot send metric metadata response to remote client: %w", err)
+	}
+	return nil
+}
+
+var metadataDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/metadata"}`)
+
 // SeriesHandler processes /api/v1/series request.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#finding-series-by-label-matchers
@@ -689,6 +721,63 @@ func SeriesHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseW
 
 var seriesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/series"}`)
 
+// QueryExemplarsHandler processes /api/v1/query_exemplars request.
+//
+// See https://prometheus.io/docs/prometheus/latest/querying/api/#querying-exemplars
+func QueryExemplarsHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	defer queryExemplarsDuration.UpdateDuration(startTime)
+
+	query := r.FormValue("query")
+	if len(query) == 0 {
+		return fmt.Errorf("missing `query` arg")
+	}
+	if len(query) > maxQueryLen.IntN() {
+		return fmt.Errorf("too long query; got %d bytes; mustn't exceed `-search.maxQueryLen=%d` bytes", len(query), maxQueryLen.N)
+	}
+	e, err := metricsql.Parse(query)
+	if err != nil {
+		return fmt.Errorf("cannot parse query %q: %w", query, err)
+	}
+	var filterss [][]storage.TagFilter
+	metricsql.VisitAll(e, func(expr metricsql.Expr) {
+		if me, ok := expr.(*metricsql.MetricExpr); ok {
+			filterss = append(filterss, searchutils.ToTagFilterss(me.LabelFilterss)...)
+		}
+	})
+	if len(filterss) == 0 {
+		return fmt.Errorf("query %q doesn't contain series selectors", query)
+	}
+
+	// Do not set start to httputils.minTimeMsecs by default as Prometheus does,
+	// since this leads to fetching and scanning all the data from the storage.
+	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/91
+	cp, err := getCommonParamsForLabelsAPI(r, startTime, false)
+	if err != nil {
+		return err
+	}
+	etfs, err := searchutils.GetExtraTagFilters(r)
+	if err != nil {
+		return err
+	}
+	filterss = searchutils.JoinTagFilterss(filterss, etfs)
+
+	sq := storage.NewSearchQuery(cp.start, cp.end, filterss, *maxSeriesLimit)
+	ses, err := netstorage.SearchExemplars(qt, sq, cp.deadline)
+	if err != nil {
+		return fmt.Errorf("cannot fetch exemplars for %q: %w", sq, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	qtDone := func() {
+		qt.Donef("start=%d, end=%d", cp.start, cp.end)
+	}
+	WriteQueryExemplarsResponse(bw, ses, qt, qtDone)
+	return bw.Flush()
+}
+
+var queryExemplarsDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/query_exemplars"}`)
+
 // QueryHandler processes /api/v1/query request.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#instant-queries
@@ -0,0 +1,178 @@
+// Code generated by qtc from "query_exemplars_response.qtpl". DO NOT EDIT.
+// See https://github.com/valyala/quicktemplate for details.
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:3
+package prometheus
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:3
+import (
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+// QueryExemplarsResponse generates response for /api/v1/query_exemplars .See https://prometheus.io/docs/prometheus/latest/querying/api/#querying-exemplars
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:10
+import (
+	qtio422016 "io"
+
+	qt422016 "github.com/valyala/quicktemplate"
+)
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:10
+var (
+	_ = qtio422016.Copy
+	_ = qt422016.AcquireByteBuffer
+)
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:10
+func StreamQueryExemplarsResponse(qw422016 *qt422016.Writer, ses []storage.SeriesExemplars, qt *querytracer.Tracer, qtDone func()) {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:10
+	qw422016.N().S(`{"status":"success","data":[`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:14
+	var mn storage.MetricName
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:15
+	for i := range ses {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:16
+		se := &ses[i]
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:16
+		qw422016.N().S(`{"seriesLabels":`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:19
+		err := mn.Unmarshal(se.MetricName)
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:20
+		if err != nil {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:21
+			qw422016.N().Q(err.Error())
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:22
+		} else {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:23
+			streammetricNameObject(qw422016, &mn)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:24
+		}
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:24
+		qw422016.N().S(`,"exemplars":[`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:26
+		for j := range se.Exemplars {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:27
+			streamexemplarObject(qw422016, &se.Exemplars[j])
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:28
+			if j+1 < len(se.Exemplars) {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:28
+				qw422016.N().S(`,`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:28
+			}
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:29
+		}
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:29
+		qw422016.N().S(`]}`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:32
+		if i+1 < len(ses) {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:32
+			qw422016.N().S(`,`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:32
+		}
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:33
+	}
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:33
+	qw422016.N().S(`]`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:36
+	qt.Printf("generate response: series=%d", len(ses))
+	qtDone()
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:39
+	streamdumpQueryTrace(qw422016, qt)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:39
+	qw422016.N().S(`}`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+}
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+func WriteQueryExemplarsResponse(qq422016 qtio422016.Writer, ses []storage.SeriesExemplars, qt *querytracer.Tracer, qtDone func()) {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+	StreamQueryExemplarsResponse(qw422016, ses, qt, qtDone)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+}
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+func QueryExemplarsResponse(ses []storage.SeriesExemplars, qt *querytracer.Tracer, qtDone func()) string {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+	WriteQueryExemplarsResponse(qb422016, ses, qt, qtDone)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+	qs422016 := string(qb422016.B)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+	return qs422016
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:41
+}
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:43
+func streamexemplarObject(qw422016 *qt422016.Writer, e *storage.Exemplar) {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:43
+	qw422016.N().S(`{"labels":{`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:46
+	for i := range e.Labels {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:47
+		label := &e.Labels[i]
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:48
+		qw422016.N().Q(label.Name)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:48
+		qw422016.N().S(`:`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:48
+		qw422016.N().Q(label.Value)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:49
+		if i+1 < len(e.Labels) {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:49
+			qw422016.N().S(`,`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:49
+		}
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:50
+	}
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:50
+	qw422016.N().S(`},"value":"`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:52
+	qw422016.N().F(e.Value)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:52
+	qw422016.N().S(`","timestamp":`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:53
+	qw422016.N().F(float64(e.Timestamp) / 1e3)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:53
+	qw422016.N().S(`}`)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+}
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+func writeexemplarObject(qq422016 qtio422016.Writer, e *storage.Exemplar) {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+	streamexemplarObject(qw422016, e)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+}
+
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+func exemplarObject(e *storage.Exemplar) string {
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+	writeexemplarObject(qb422016, e)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+	qs422016 := string(qb422016.B)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+	return qs422016
+//line app/vmselect/prometheus/query_exemplars_response.qtpl:55
+}
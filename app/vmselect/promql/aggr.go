@@ -26,6 +26,7 @@ var aggrFuncs = map[string]aggrFunc{
 	"bottomk_median": newAggrFuncRangeTopK(medianValue, true),
 	"bottomk_last":   newAggrFuncRangeTopK(lastValue, true),
 	"bottomk_min":    newAggrFuncRangeTopK(minValue, true),
+	"correlationk":   aggrFuncCorrelationK,
 	"count":          newAggrFunc(aggrFuncCount),
 	"count_values":   aggrFuncCountValues,
 	"distinct":       newAggrFunc(aggrFuncDistinct),
@@ -44,6 +45,7 @@ var aggrFuncs = map[string]aggrFunc{
 	"quantile":       aggrFuncQuantile,
 	"quantiles":      aggrFuncQuantiles,
 	"share":          aggrFuncShare,
+	"similarityk":    aggrFuncSimilarityK,
 	"stddev":         newAggrFunc(aggrFuncStddev),
 	"stdvar":         newAggrFunc(aggrFuncStdvar),
 	"sum":            newAggrFunc(aggrFuncSum),
@@ -54,6 +56,7 @@ var aggrFuncs = map[string]aggrFunc{
 	"topk_median":    newAggrFuncRangeTopK(medianValue, false),
 	"topk_last":      newAggrFuncRangeTopK(lastValue, false),
 	"topk_min":       newAggrFuncRangeTopK(minValue, false),
+	"xcorrelationk":  aggrFuncXCorrelationK,
 	"zscore":         aggrFuncZScore,
 }
 
@@ -1069,6 +1072,255 @@ func aggrFuncOutliersK(afa *aggrFuncArg) ([]*timeseries, error) {
 	return aggrFuncExt(afe, args[1], &afa.ae.Modifier, afa.ae.Limit, true)
 }
 
+func aggrFuncCorrelationK(afa *aggrFuncArg) ([]*timeseries, error) {
+	return aggrFuncScoreK(afa, 3, pearsonCorrelation, false)
+}
+
+func aggrFuncXCorrelationK(afa *aggrFuncArg) ([]*timeseries, error) {
+	args := afa.args
+	if len(args) < 4 {
+		return nil, fmt.Errorf(`unexpected number of args; got %d; want at least %d`, len(args), 4)
+	}
+	maxLags, err := getScalar(args[3], 3)
+	if err != nil {
+		return nil, err
+	}
+	// Convert max_lag from seconds to the number of points.
+	maxLag := 0
+	if len(maxLags) > 0 && maxLags[0] > 0 {
+		maxLag = int(maxLags[0] * 1e3 / float64(afa.ec.Step))
+	}
+	f := func(ref, values []float64) float64 {
+		return crossCorrelation(ref, values, maxLag)
+	}
+	return aggrFuncScoreK(afa, 4, f, false)
+}
+
+func aggrFuncSimilarityK(afa *aggrFuncArg) ([]*timeseries, error) {
+	return aggrFuncScoreK(afa, 3, dtwDistance, true)
+}
+
+// aggrFuncScoreK returns top k time series from args[2] with the best score
+// calculated by scoreFunc against the reference time series from args[1].
+//
+// argsNum is the number of mandatory args. The optional arg after them may contain label name for storing the score.
+// If isReverse is set, then lower scores are better.
+func aggrFuncScoreK(afa *aggrFuncArg, argsNum int, scoreFunc func(ref, values []float64) float64, isReverse bool) ([]*timeseries, error) {
+	args := afa.args
+	if len(args) < argsNum {
+		return nil, fmt.Errorf(`unexpected number of args; got %d; want at least %d`, len(args), argsNum)
+	}
+	if len(args) > argsNum+1 {
+		return nil, fmt.Errorf(`unexpected number of args; got %d; want no more than %d`, len(args), argsNum+1)
+	}
+	ks, err := getScalar(args[0], 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(args[1]) > 1 {
+		return nil, fmt.Errorf(`reference arg must return a single time series; got %d time series`, len(args[1]))
+	}
+	scoreLabel := ""
+	if len(args) > argsNum {
+		scoreLabel, err = getString(args[argsNum], argsNum)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(args[1]) == 0 {
+		// Nothing to compare with.
+		return nil, nil
+	}
+	// Copy reference values, since they may be shared with the time series modified by getScoreTopKTimeseries.
+	ref := append([]float64{}, args[1][0].Values...)
+	afe := func(tss []*timeseries, _ *metricsql.ModifierExpr) []*timeseries {
+		return getScoreTopKTimeseries(tss, ks, ref, scoreFunc, scoreLabel, isReverse)
+	}
+	return aggrFuncExt(afe, args[2], &afa.ae.Modifier, afa.ae.Limit, true)
+}
+
+func getScoreTopKTimeseries(tss []*timeseries, ks, ref []float64, scoreFunc func(ref, values []float64) float64, scoreLabel string, isReverse bool) []*timeseries {
+	type tsWithScore struct {
+		ts    *timeseries
+		score float64
+	}
+	scores := make([]tsWithScore, 0, len(tss))
+	for _, ts := range tss {
+		score := scoreFunc(ref, ts.Values)
+		if math.IsNaN(score) {
+			continue
+		}
+		scores = append(scores, tsWithScore{
+			ts:    ts,
+			score: score,
+		})
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		if isReverse {
+			return scores[i].score < scores[j].score
+		}
+		return scores[i].score > scores[j].score
+	})
+	rvs := tss[:0]
+	for i, s := range scores {
+		ts := s.ts
+		values := ts.Values
+		for n := range values {
+			if i >= getIntK(ks[n], len(scores)) {
+				values[n] = nan
+			} else if scoreLabel == "" {
+				values[n] = s.score
+			}
+		}
+		if scoreLabel == "" {
+			ts.MetricName.ResetMetricGroup()
+		} else {
+			ts.MetricName.RemoveTag(scoreLabel)
+			ts.MetricName.AddTag(scoreLabel, strconv.FormatFloat(s.score, 'g', -1, 64))
+		}
+		rvs = append(rvs, ts)
+	}
+	return removeEmptySeries(rvs)
+}
+
+// pearsonCorrelation returns Pearson correlation coefficient between a and b.
+//
+// Points with NaN values in a or b are ignored.
+// See https://en.wikipedia.org/wiki/Pearson_correlation_coefficient
+func pearsonCorrelation(a, b []float64) float64 {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	var sumA, sumB, sumAA, sumBB, sumAB float64
+	count := 0
+	for i := 0; i < n; i++ {
+		va := a[i]
+		vb := b[i]
+		if math.IsNaN(va) || math.IsNaN(vb) {
+			continue
+		}
+		sumA += va
+		sumB += vb
+		sumAA += va * va
+		sumBB += vb * vb
+		sumAB += va * vb
+		count++
+	}
+	if count < 2 {
+		return nan
+	}
+	c := float64(count)
+	cov := sumAB - sumA*sumB/c
+	varA := sumAA - sumA*sumA/c
+	varB := sumBB - sumB*sumB/c
+	if varA <= 0 || varB <= 0 {
+		return nan
+	}
+	r := cov / math.Sqrt(varA*varB)
+	// Protect from floating point rounding errors.
+	if r > 1 {
+		r = 1
+	} else if r < -1 {
+		r = -1
+	}
+	return r
+}
+
+// crossCorrelation returns the maximum Pearson correlation coefficient between a and b shifted by up to maxLag points in both directions.
+func crossCorrelation(a, b []float64, maxLag int) float64 {
+	if maxLag >= len(a) {
+		maxLag = len(a) - 1
+	}
+	maxR := nan
+	for lag := -maxLag; lag <= maxLag; lag++ {
+		var r float64
+		if lag >= 0 {
+			r = pearsonCorrelation(a[:len(a)-lag], b[lag:])
+		} else {
+			r = pearsonCorrelation(a[-lag:], b[:len(b)+lag])
+		}
+		if math.IsNaN(maxR) || r > maxR {
+			maxR = r
+		}
+	}
+	return maxR
+}
+
+// dtwDistance returns dynamic time warping distance between z-normalized a and b.
+//
+// NaN values are ignored. The returned distance is normalized by the length of the warping path upper bound,
+// so it doesn't depend on the number of points. Lower distance means more similar shapes.
+// The warping is limited by Sakoe-Chiba band in order to avoid pathological alignments and to reduce computational complexity.
+// See https://en.wikipedia.org/wiki/Dynamic_time_warping
+func dtwDistance(a, b []float64) float64 {
+	x := zNormalize(a)
+	y := zNormalize(b)
+	n := len(x)
+	m := len(y)
+	if n < 2 || m < 2 {
+		return nan
+	}
+	w := n / 10
+	if d := n - m; d > w {
+		w = d
+	} else if -d > w {
+		w = -d
+	}
+	if w < 1 {
+		w = 1
+	}
+	inf := math.Inf(1)
+	prev := make([]float64, m+1)
+	curr := make([]float64, m+1)
+	for j := range prev {
+		prev[j] = inf
+	}
+	prev[0] = 0
+	for i := 1; i <= n; i++ {
+		for j := range curr {
+			curr[j] = inf
+		}
+		jStart := i - w
+		if jStart < 1 {
+			jStart = 1
+		}
+		jEnd := i + w
+		if jEnd > m {
+			jEnd = m
+		}
+		for j := jStart; j <= jEnd; j++ {
+			cost := math.Abs(x[i-1] - y[j-1])
+			curr[j] = cost + math.Min(prev[j-1], math.Min(prev[j], curr[j-1]))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[m] / float64(n+m)
+}
+
+// zNormalize returns non-NaN values from src normalized to zero mean and unit standard deviation.
+func zNormalize(src []float64) []float64 {
+	dst := make([]float64, 0, len(src))
+	for _, v := range src {
+		if !math.IsNaN(v) {
+			dst = append(dst, v)
+		}
+	}
+	if len(dst) == 0 {
+		return dst
+	}
+	avg := avgValue(dst)
+	sd := stddev(dst)
+	for i, v := range dst {
+		d := v - avg
+		if sd > 0 {
+			d /= sd
+		}
+		dst[i] = d
+	}
+	return dst
+}
+
 func getPerPointMedians(tss []*timeseries) []float64 {
 	if len(tss) == 0 {
 		logger.Panicf("BUG: expecting non-empty tss")
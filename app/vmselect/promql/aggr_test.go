@@ -82,3 +82,78 @@ func TestModeNoNaNs(t *testing.T) {
 	f(1, []float64{2, 3, 3, 4, 4}, 3)
 	f(1, []float64{4, 3, 2, 3, 4}, 3)
 }
+
+func TestPearsonCorrelation(t *testing.T) {
+	f := func(a, b []float64, resultExpected float64) {
+		t.Helper()
+		result := pearsonCorrelation(a, b)
+		if math.IsNaN(resultExpected) {
+			if !math.IsNaN(result) {
+				t.Fatalf("unexpected result; got %v; want %v", result, resultExpected)
+			}
+			return
+		}
+		if math.Abs(result-resultExpected) > 1e-12 {
+			t.Fatalf("unexpected result; got %v; want %v", result, resultExpected)
+		}
+	}
+
+	f(nil, nil, nan)
+	f([]float64{1}, []float64{2}, nan)
+	f([]float64{1, 1, 1}, []float64{1, 2, 3}, nan)
+	f([]float64{1, 2, 3}, []float64{2, 4, 6}, 1)
+	f([]float64{1, 2, 3}, []float64{3, 2, 1}, -1)
+	f([]float64{1, 2, nan, 3}, []float64{2, 4, 100, 6}, 1)
+	f([]float64{1, 2, 3, 4}, []float64{1, 3, 2, 4}, 0.8)
+}
+
+func TestCrossCorrelation(t *testing.T) {
+	f := func(a, b []float64, maxLag int, resultExpected float64) {
+		t.Helper()
+		result := crossCorrelation(a, b, maxLag)
+		if math.Abs(result-resultExpected) > 1e-12 {
+			t.Fatalf("unexpected result; got %v; want %v", result, resultExpected)
+		}
+	}
+
+	a := []float64{0, 0, 1, 5, 1, 0, 0, 0}
+	b := []float64{0, 0, 0, 0, 1, 5, 1, 0}
+	f(a, b, 0, -0.24550898203592814)
+	f(a, b, 1, 0.15)
+	f(a, b, 2, 1)
+	f(b, a, 2, 1)
+	f(a, b, 100, 1)
+}
+
+func TestDTWDistance(t *testing.T) {
+	f := func(a, b []float64, resultExpected float64) {
+		t.Helper()
+		result := dtwDistance(a, b)
+		if math.IsNaN(resultExpected) {
+			if !math.IsNaN(result) {
+				t.Fatalf("unexpected result; got %v; want %v", result, resultExpected)
+			}
+			return
+		}
+		if math.Abs(result-resultExpected) > 1e-12 {
+			t.Fatalf("unexpected result; got %v; want %v", result, resultExpected)
+		}
+	}
+
+	f(nil, nil, nan)
+	f([]float64{1}, []float64{1, 2}, nan)
+
+	// the same shape with different scale
+	f([]float64{1, 2, 3, 2, 1}, []float64{10, 20, 30, 20, 10}, 0)
+
+	// NaNs are ignored
+	f([]float64{1, 2, nan, 3, 2, 1}, []float64{10, 20, 30, 20, 10}, 0)
+
+	// shifted shape is more similar than the inverted shape
+	a := []float64{0, 0, 1, 5, 1, 0, 0, 0}
+	shifted := dtwDistance(a, []float64{0, 0, 0, 1, 5, 1, 0, 0})
+	inverted := dtwDistance(a, []float64{0, 0, -1, -5, -1, 0, 0, 0})
+	if shifted >= inverted {
+		t.Fatalf("expecting distance for shifted shape %v to be smaller than the distance for inverted shape %v", shifted, inverted)
+	}
+}
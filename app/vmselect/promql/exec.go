@@ -113,7 +113,7 @@ func maySortResults(e metricsql.Expr) bool {
 		}
 	case *metricsql.AggrFuncExpr:
 		switch strings.ToLower(v.Name) {
-		case "topk", "bottomk", "outliersk",
+		case "topk", "bottomk", "outliersk", "correlationk", "xcorrelationk", "similarityk",
 			"topk_max", "topk_min", "topk_avg", "topk_median", "topk_last",
 			"bottomk_max", "bottomk_min", "bottomk_avg", "bottomk_median", "bottomk_last":
 			// Results already sorted
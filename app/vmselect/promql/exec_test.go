@@ -4150,6 +4150,36 @@ func TestExecSuccess(t *testing.T) {
 		resultExpected := []netstorage.Result{r}
 		f(q, resultExpected)
 	})
+	t.Run(`histogram_sum()`, func(t *testing.T) {
+		t.Parallel()
+		q := `histogram_sum(union(
+			label_set(100, "le", "10"),
+			label_set(300, "le", "30"),
+			label_set(500, "le", "+Inf"),
+		))`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{10500, 10500, 10500, 10500, 10500, 10500},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`histogram_count()`, func(t *testing.T) {
+		t.Parallel()
+		q := `histogram_count(union(
+			label_set(100, "le", "10"),
+			label_set(300, "le", "30"),
+			label_set(500, "le", "+Inf"),
+		))`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{500, 500, 500, 500, 500, 500},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
 	t.Run(`histogram_share(single-value-valid-le)`, func(t *testing.T) {
 		t.Parallel()
 		q := `histogram_share(80, label_set(100, "le", "200"))`
@@ -7186,6 +7216,126 @@ func TestExecSuccess(t *testing.T) {
 		resultExpected := []netstorage.Result{}
 		f(q, resultExpected)
 	})
+	t.Run(`correlationk(1)`, func(t *testing.T) {
+		t.Parallel()
+		q := `round(correlationk(1, time(), (
+			label_set(time()^2, "foo", "bar"),
+			label_set(-time(), "baz", "sss"),
+			label_set(time()*2+10, "x", "y"),
+		)), 0.001)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{1, 1, 1, 1, 1, 1},
+			Timestamps: timestampsExpected,
+		}
+		r.MetricName.Tags = []storage.Tag{{
+			Key:   []byte("x"),
+			Value: []byte("y"),
+		}}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`correlationk(score_label)`, func(t *testing.T) {
+		t.Parallel()
+		q := `correlationk(2, time(), (
+			label_set(time()^2, "foo", "bar"),
+			label_set(-time(), "baz", "sss"),
+			label_set(time()*2+10, "x", "y"),
+		), "score")`
+		r1 := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{2010, 2410, 2810, 3210, 3610, 4010},
+			Timestamps: timestampsExpected,
+		}
+		r1.MetricName.Tags = []storage.Tag{
+			{
+				Key:   []byte("score"),
+				Value: []byte("1"),
+			},
+			{
+				Key:   []byte("x"),
+				Value: []byte("y"),
+			},
+		}
+		r2 := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{1e6, 1.44e6, 1.96e6, 2.56e6, 3.24e6, 4e6},
+			Timestamps: timestampsExpected,
+		}
+		r2.MetricName.Tags = []storage.Tag{
+			{
+				Key:   []byte("foo"),
+				Value: []byte("bar"),
+			},
+			{
+				Key:   []byte("score"),
+				Value: []byte("0.9952927070186717"),
+			},
+		}
+		resultExpected := []netstorage.Result{r1, r2}
+		f(q, resultExpected)
+	})
+	t.Run(`correlationk(no_reference)`, func(t *testing.T) {
+		t.Parallel()
+		q := `correlationk(1, time() > 3000, label_set(time(), "foo", "bar"))`
+		resultExpected := []netstorage.Result{}
+		f(q, resultExpected)
+	})
+	t.Run(`xcorrelationk(1)`, func(t *testing.T) {
+		t.Parallel()
+		q := `round(xcorrelationk(1, time() % 400, (
+			label_set((time()+200) % 400, "foo", "bar"),
+			label_set(time(), "baz", "sss"),
+		), 200), 0.001)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{1, 1, 1, 1, 1, 1},
+			Timestamps: timestampsExpected,
+		}
+		r.MetricName.Tags = []storage.Tag{{
+			Key:   []byte("foo"),
+			Value: []byte("bar"),
+		}}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`xcorrelationk(no_lag)`, func(t *testing.T) {
+		t.Parallel()
+		q := `round(xcorrelationk(1, time() % 400, (
+			label_set((time()+200) % 400, "foo", "bar"),
+			label_set(time(), "baz", "sss"),
+		), 0), 0.001)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{-0.293, -0.293, -0.293, -0.293, -0.293, -0.293},
+			Timestamps: timestampsExpected,
+		}
+		r.MetricName.Tags = []storage.Tag{{
+			Key:   []byte("baz"),
+			Value: []byte("sss"),
+		}}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`similarityk(1)`, func(t *testing.T) {
+		t.Parallel()
+		q := `round(similarityk(1, time(), (
+			label_set(time()^2, "foo", "bar"),
+			label_set(-time(), "baz", "sss"),
+			label_set(time()*2+10, "x", "y"),
+		)), 0.001)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{0, 0, 0, 0, 0, 0},
+			Timestamps: timestampsExpected,
+		}
+		r.MetricName.Tags = []storage.Tag{{
+			Key:   []byte("x"),
+			Value: []byte("y"),
+		}}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
 	t.Run(`outliersk(0)`, func(t *testing.T) {
 		t.Parallel()
 		q := `outliersk(0, (
@@ -7548,6 +7698,39 @@ func TestExecSuccess(t *testing.T) {
 		resultExpected := []netstorage.Result{r}
 		f(q, resultExpected)
 	})
+	t.Run(`seasonal_naive_forecast(time())`, func(t *testing.T) {
+		t.Parallel()
+		q := `seasonal_naive_forecast(time()[300s:10s], 100)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{900, 1100, 1300, 1500, 1700, 1900},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`seasonal_decompose_over_time(seasonal)`, func(t *testing.T) {
+		t.Parallel()
+		q := `seasonal_decompose_over_time((time() % 100)[300s:10s], 100, "residual")`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{0, 0, 0, 0, 0, 0},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`seasonal_anomaly_score(seasonal)`, func(t *testing.T) {
+		t.Parallel()
+		q := `seasonal_anomaly_score((time() % 100)[400s:10s], 100, 30)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{0, 0, 0, 0, 0, 0},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
 	t.Run(`integrate(1)`, func(t *testing.T) {
 		t.Parallel()
 		q := `integrate(1)`
@@ -9570,9 +9753,20 @@ func TestExecError(t *testing.T) {
 	f(`outliers_mad()`)
 	f(`outliers_mad(1)`)
 	f(`outliersk()`)
+	f(`correlationk()`)
+	f(`correlationk(1, time())`)
+	f(`correlationk(1, time(), time(), "foo", "bar")`)
+	f(`xcorrelationk(1, time(), time())`)
+	f(`similarityk(1, (label_set(1, "foo", "bar"), label_set(2, "x", "y")), time())`)
 	f(`outliersk(1)`)
 	f(`mode_over_time()`)
 	f(`rate_over_sum()`)
+	f(`seasonal_anomaly_score()`)
+	f(`seasonal_anomaly_score(1, 2)`)
+	f(`seasonal_decompose_over_time()`)
+	f(`seasonal_decompose_over_time(1, 2, "foo")`)
+	f(`seasonal_naive_forecast()`)
+	f(`seasonal_naive_forecast(1, 2, "foo")`)
 	f(`zscore_over_time()`)
 	f(`mode()`)
 	f(`share()`)
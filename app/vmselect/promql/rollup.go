@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,78 +23,81 @@ var minStalenessInterval = flag.Duration("search.minStalenessInterval", 0, "The
 	"See also '-search.maxStalenessInterval'")
 
 var rollupFuncs = map[string]newRollupFunc{
-	"absent_over_time":        newRollupFuncOneArg(rollupAbsent),
-	"aggr_over_time":          newRollupFuncTwoArgs(rollupFake),
-	"ascent_over_time":        newRollupFuncOneArg(rollupAscentOverTime),
-	"avg_over_time":           newRollupFuncOneArg(rollupAvg),
-	"changes":                 newRollupFuncOneArg(rollupChanges),
-	"changes_prometheus":      newRollupFuncOneArg(rollupChangesPrometheus),
-	"count_eq_over_time":      newRollupCountEQ,
-	"count_gt_over_time":      newRollupCountGT,
-	"count_le_over_time":      newRollupCountLE,
-	"count_ne_over_time":      newRollupCountNE,
-	"count_over_time":         newRollupFuncOneArg(rollupCount),
-	"count_values_over_time":  newRollupCountValues,
-	"decreases_over_time":     newRollupFuncOneArg(rollupDecreases),
-	"default_rollup":          newRollupFuncOneArg(rollupDefault), // default rollup func
-	"delta":                   newRollupFuncOneArg(rollupDelta),
-	"delta_prometheus":        newRollupFuncOneArg(rollupDeltaPrometheus),
-	"deriv":                   newRollupFuncOneArg(rollupDerivSlow),
-	"deriv_fast":              newRollupFuncOneArg(rollupDerivFast),
-	"descent_over_time":       newRollupFuncOneArg(rollupDescentOverTime),
-	"distinct_over_time":      newRollupFuncOneArg(rollupDistinct),
-	"duration_over_time":      newRollupDurationOverTime,
-	"first_over_time":         newRollupFuncOneArg(rollupFirst),
-	"geomean_over_time":       newRollupFuncOneArg(rollupGeomean),
-	"histogram_over_time":     newRollupFuncOneArg(rollupHistogram),
-	"hoeffding_bound_lower":   newRollupHoeffdingBoundLower,
-	"hoeffding_bound_upper":   newRollupHoeffdingBoundUpper,
-	"holt_winters":            newRollupHoltWinters,
-	"idelta":                  newRollupFuncOneArg(rollupIdelta),
-	"ideriv":                  newRollupFuncOneArg(rollupIderiv),
-	"increase":                newRollupFuncOneArg(rollupDelta),           // + rollupFuncsRemoveCounterResets
-	"increase_prometheus":     newRollupFuncOneArg(rollupDeltaPrometheus), // + rollupFuncsRemoveCounterResets
-	"increase_pure":           newRollupFuncOneArg(rollupIncreasePure),    // + rollupFuncsRemoveCounterResets
-	"increases_over_time":     newRollupFuncOneArg(rollupIncreases),
-	"integrate":               newRollupFuncOneArg(rollupIntegrate),
-	"irate":                   newRollupFuncOneArg(rollupIderiv), // + rollupFuncsRemoveCounterResets
-	"lag":                     newRollupFuncOneArg(rollupLag),
-	"last_over_time":          newRollupFuncOneArg(rollupLast),
-	"lifetime":                newRollupFuncOneArg(rollupLifetime),
-	"mad_over_time":           newRollupFuncOneArg(rollupMAD),
-	"max_over_time":           newRollupFuncOneArg(rollupMax),
-	"median_over_time":        newRollupFuncOneArg(rollupMedian),
-	"min_over_time":           newRollupFuncOneArg(rollupMin),
-	"mode_over_time":          newRollupFuncOneArg(rollupModeOverTime),
-	"outlier_iqr_over_time":   newRollupFuncOneArg(rollupOutlierIQR),
-	"predict_linear":          newRollupPredictLinear,
-	"present_over_time":       newRollupFuncOneArg(rollupPresent),
-	"quantile_over_time":      newRollupQuantile,
-	"quantiles_over_time":     newRollupQuantiles,
-	"range_over_time":         newRollupFuncOneArg(rollupRange),
-	"rate":                    newRollupFuncOneArg(rollupDerivFast), // + rollupFuncsRemoveCounterResets
-	"rate_over_sum":           newRollupFuncOneArg(rollupRateOverSum),
-	"resets":                  newRollupFuncOneArg(rollupResets),
-	"rollup":                  newRollupFuncOneOrTwoArgs(rollupFake),
-	"rollup_candlestick":      newRollupFuncOneOrTwoArgs(rollupFake),
-	"rollup_delta":            newRollupFuncOneOrTwoArgs(rollupFake),
-	"rollup_deriv":            newRollupFuncOneOrTwoArgs(rollupFake),
-	"rollup_increase":         newRollupFuncOneOrTwoArgs(rollupFake), // + rollupFuncsRemoveCounterResets
-	"rollup_rate":             newRollupFuncOneOrTwoArgs(rollupFake), // + rollupFuncsRemoveCounterResets
-	"rollup_scrape_interval":  newRollupFuncOneOrTwoArgs(rollupFake),
-	"scrape_interval":         newRollupFuncOneArg(rollupScrapeInterval),
-	"share_eq_over_time":      newRollupShareEQ,
-	"share_gt_over_time":      newRollupShareGT,
-	"share_le_over_time":      newRollupShareLE,
-	"stale_samples_over_time": newRollupFuncOneArg(rollupStaleSamples),
-	"stddev_over_time":        newRollupFuncOneArg(rollupStddev),
-	"stdvar_over_time":        newRollupFuncOneArg(rollupStdvar),
-	"sum_eq_over_time":        newRollupSumEQ,
-	"sum_gt_over_time":        newRollupSumGT,
-	"sum_le_over_time":        newRollupSumLE,
-	"sum_over_time":           newRollupFuncOneArg(rollupSum),
-	"sum2_over_time":          newRollupFuncOneArg(rollupSum2),
-	"tfirst_over_time":        newRollupFuncOneArg(rollupTfirst),
+	"absent_over_time":             newRollupFuncOneArg(rollupAbsent),
+	"aggr_over_time":               newRollupFuncTwoArgs(rollupFake),
+	"ascent_over_time":             newRollupFuncOneArg(rollupAscentOverTime),
+	"avg_over_time":                newRollupFuncOneArg(rollupAvg),
+	"changes":                      newRollupFuncOneArg(rollupChanges),
+	"changes_prometheus":           newRollupFuncOneArg(rollupChangesPrometheus),
+	"count_eq_over_time":           newRollupCountEQ,
+	"count_gt_over_time":           newRollupCountGT,
+	"count_le_over_time":           newRollupCountLE,
+	"count_ne_over_time":           newRollupCountNE,
+	"count_over_time":              newRollupFuncOneArg(rollupCount),
+	"count_values_over_time":       newRollupCountValues,
+	"decreases_over_time":          newRollupFuncOneArg(rollupDecreases),
+	"default_rollup":               newRollupFuncOneArg(rollupDefault), // default rollup func
+	"delta":                        newRollupFuncOneArg(rollupDelta),
+	"delta_prometheus":             newRollupFuncOneArg(rollupDeltaPrometheus),
+	"deriv":                        newRollupFuncOneArg(rollupDerivSlow),
+	"deriv_fast":                   newRollupFuncOneArg(rollupDerivFast),
+	"descent_over_time":            newRollupFuncOneArg(rollupDescentOverTime),
+	"distinct_over_time":           newRollupFuncOneArg(rollupDistinct),
+	"duration_over_time":           newRollupDurationOverTime,
+	"first_over_time":              newRollupFuncOneArg(rollupFirst),
+	"geomean_over_time":            newRollupFuncOneArg(rollupGeomean),
+	"histogram_over_time":          newRollupFuncOneArg(rollupHistogram),
+	"hoeffding_bound_lower":        newRollupHoeffdingBoundLower,
+	"hoeffding_bound_upper":        newRollupHoeffdingBoundUpper,
+	"holt_winters":                 newRollupHoltWinters,
+	"idelta":                       newRollupFuncOneArg(rollupIdelta),
+	"ideriv":                       newRollupFuncOneArg(rollupIderiv),
+	"increase":                     newRollupFuncOneArg(rollupDelta),           // + rollupFuncsRemoveCounterResets
+	"increase_prometheus":          newRollupFuncOneArg(rollupDeltaPrometheus), // + rollupFuncsRemoveCounterResets
+	"increase_pure":                newRollupFuncOneArg(rollupIncreasePure),    // + rollupFuncsRemoveCounterResets
+	"increases_over_time":          newRollupFuncOneArg(rollupIncreases),
+	"integrate":                    newRollupFuncOneArg(rollupIntegrate),
+	"irate":                        newRollupFuncOneArg(rollupIderiv), // + rollupFuncsRemoveCounterResets
+	"lag":                          newRollupFuncOneArg(rollupLag),
+	"last_over_time":               newRollupFuncOneArg(rollupLast),
+	"lifetime":                     newRollupFuncOneArg(rollupLifetime),
+	"mad_over_time":                newRollupFuncOneArg(rollupMAD),
+	"max_over_time":                newRollupFuncOneArg(rollupMax),
+	"median_over_time":             newRollupFuncOneArg(rollupMedian),
+	"min_over_time":                newRollupFuncOneArg(rollupMin),
+	"mode_over_time":               newRollupFuncOneArg(rollupModeOverTime),
+	"outlier_iqr_over_time":        newRollupFuncOneArg(rollupOutlierIQR),
+	"predict_linear":               newRollupPredictLinear,
+	"present_over_time":            newRollupFuncOneArg(rollupPresent),
+	"quantile_over_time":           newRollupQuantile,
+	"quantiles_over_time":          newRollupQuantiles,
+	"range_over_time":              newRollupFuncOneArg(rollupRange),
+	"rate":                         newRollupFuncOneArg(rollupDerivFast), // + rollupFuncsRemoveCounterResets
+	"rate_over_sum":                newRollupFuncOneArg(rollupRateOverSum),
+	"resets":                       newRollupFuncOneArg(rollupResets),
+	"rollup":                       newRollupFuncOneOrTwoArgs(rollupFake),
+	"rollup_candlestick":           newRollupFuncOneOrTwoArgs(rollupFake),
+	"rollup_delta":                 newRollupFuncOneOrTwoArgs(rollupFake),
+	"rollup_deriv":                 newRollupFuncOneOrTwoArgs(rollupFake),
+	"rollup_increase":              newRollupFuncOneOrTwoArgs(rollupFake), // + rollupFuncsRemoveCounterResets
+	"rollup_rate":                  newRollupFuncOneOrTwoArgs(rollupFake), // + rollupFuncsRemoveCounterResets
+	"rollup_scrape_interval":       newRollupFuncOneOrTwoArgs(rollupFake),
+	"scrape_interval":              newRollupFuncOneArg(rollupScrapeInterval),
+	"seasonal_anomaly_score":       newRollupSeasonalAnomalyScore,
+	"seasonal_decompose_over_time": newRollupSeasonalDecompose,
+	"seasonal_naive_forecast":      newRollupSeasonalNaiveForecast,
+	"share_eq_over_time":           newRollupShareEQ,
+	"share_gt_over_time":           newRollupShareGT,
+	"share_le_over_time":           newRollupShareLE,
+	"stale_samples_over_time":      newRollupFuncOneArg(rollupStaleSamples),
+	"stddev_over_time":             newRollupFuncOneArg(rollupStddev),
+	"stdvar_over_time":             newRollupFuncOneArg(rollupStdvar),
+	"sum_eq_over_time":             newRollupSumEQ,
+	"sum_gt_over_time":             newRollupSumGT,
+	"sum_le_over_time":             newRollupSumLE,
+	"sum_over_time":                newRollupFuncOneArg(rollupSum),
+	"sum2_over_time":               newRollupFuncOneArg(rollupSum2),
+	"tfirst_over_time":             newRollupFuncOneArg(rollupTfirst),
 	// `timestamp` function must return timestamp for the last datapoint on the current window
 	// in order to properly handle offset and timestamps unaligned to the current step.
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/415 for details.
@@ -262,25 +266,27 @@ var rollupFuncsSamplesScannedPerCall = map[string]int{
 // These functions don't change physical meaning of input time series,
 // so they don't drop metric name
 var rollupFuncsKeepMetricName = map[string]bool{
-	"avg_over_time":         true,
-	"default_rollup":        true,
-	"first_over_time":       true,
-	"geomean_over_time":     true,
-	"hoeffding_bound_lower": true,
-	"hoeffding_bound_upper": true,
-	"holt_winters":          true,
-	"iqr_over_time":         true,
-	"last_over_time":        true,
-	"max_over_time":         true,
-	"median_over_time":      true,
-	"min_over_time":         true,
-	"mode_over_time":        true,
-	"predict_linear":        true,
-	"quantile_over_time":    true,
-	"quantiles_over_time":   true,
-	"rollup":                true,
-	"rollup_candlestick":    true,
-	"timestamp_with_name":   true,
+	"avg_over_time":                true,
+	"default_rollup":               true,
+	"first_over_time":              true,
+	"geomean_over_time":            true,
+	"hoeffding_bound_lower":        true,
+	"hoeffding_bound_upper":        true,
+	"holt_winters":                 true,
+	"iqr_over_time":                true,
+	"last_over_time":               true,
+	"max_over_time":                true,
+	"median_over_time":             true,
+	"min_over_time":                true,
+	"mode_over_time":               true,
+	"predict_linear":               true,
+	"quantile_over_time":           true,
+	"quantiles_over_time":          true,
+	"rollup":                       true,
+	"rollup_candlestick":           true,
+	"seasonal_decompose_over_time": true,
+	"seasonal_naive_forecast":      true,
+	"timestamp_with_name":          true,
 }
 
 func getRollupAggrFuncNames(expr metricsql.Expr) ([]string, error) {
@@ -2336,6 +2342,280 @@ func rollupZScoreOverTime(rfa *rollupFuncArg) float64 {
 	return d / rollupStddev(rfa)
 }
 
+func newRollupSeasonalDecompose(args []any) (rollupFunc, error) {
+	if err := expectRollupArgsNum(args, 3); err != nil {
+		return nil, err
+	}
+	periods, err := getScalar(args[1], 1)
+	if err != nil {
+		return nil, err
+	}
+	tssComponent, ok := args[2].([]*timeseries)
+	if !ok {
+		return nil, fmt.Errorf(`unexpected type for component arg; got %T; want %T`, args[2], tssComponent)
+	}
+	component, err := getString(tssComponent, 2)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get component: %w", err)
+	}
+	switch component {
+	case "trend", "seasonal", "residual":
+	default:
+		return nil, fmt.Errorf(`unexpected component: %q; want "trend", "seasonal" or "residual"`, component)
+	}
+	rf := func(rfa *rollupFuncArg) float64 {
+		period := int64(periods[rfa.idx] * 1e3)
+		trend, seasonal, residual := seasonalDecompose(rfa, period)
+		switch component {
+		case "trend":
+			return trend
+		case "seasonal":
+			return seasonal
+		default:
+			return residual
+		}
+	}
+	return rf, nil
+}
+
+// seasonalDecompose returns robust STL-style decomposition of the last value at rfa into trend, seasonal and residual components
+// for the given period in milliseconds.
+//
+// The trend is the median over the last period. The seasonal component is the median of detrended values
+// at the same phase of the previous periods on the lookbehind window. The residual is the remaining part of the last value.
+// Medians are used instead of LOESS smoothing in order to make the decomposition robust to outliers.
+func seasonalDecompose(rfa *rollupFuncArg, period int64) (float64, float64, float64) {
+	// There is no need in handling NaNs here, since they must be cleaned up
+	// before calling rollup funcs.
+	values := rfa.values
+	timestamps := rfa.timestamps
+	if len(values) < 2 || period <= 0 {
+		return nan, nan, nan
+	}
+	windowStart := rfa.currTimestamp - rfa.window
+	tolerance := getSeasonalTolerance(timestamps)
+
+	a := getFloat64s()
+	defer putFloat64s(a)
+	ds := a.A[:0]
+	trend := seasonalTrendAt(values, timestamps, rfa.currTimestamp, period)
+	for ts := rfa.currTimestamp - period; ts-period >= windowStart; ts -= period {
+		v := seasonalValueAt(values, timestamps, ts, tolerance)
+		if math.IsNaN(v) {
+			continue
+		}
+		ds = append(ds, v-seasonalTrendAt(values, timestamps, ts, period))
+	}
+	a.A = ds
+	if len(ds) == 0 || math.IsNaN(trend) {
+		return nan, nan, nan
+	}
+	seasonal := quantile(0.5, ds)
+	residual := values[len(values)-1] - trend - seasonal
+	return trend, seasonal, residual
+}
+
+// seasonalTrendAt returns the median over values on the (ts-period, ts] time range.
+func seasonalTrendAt(values []float64, timestamps []int64, ts, period int64) float64 {
+	i := sort.Search(len(timestamps), func(n int) bool { return timestamps[n] > ts-period })
+	j := sort.Search(len(timestamps), func(n int) bool { return timestamps[n] > ts })
+	return quantile(0.5, values[i:j])
+}
+
+// seasonalValueAt returns the value with the timestamp closest to ts.
+//
+// nan is returned if there are no values on the [ts-tolerance, ts+tolerance] time range.
+func seasonalValueAt(values []float64, timestamps []int64, ts, tolerance int64) float64 {
+	i := sort.Search(len(timestamps), func(n int) bool { return timestamps[n] >= ts })
+	if i > 0 && (i == len(timestamps) || ts-timestamps[i-1] < timestamps[i]-ts) {
+		i--
+	}
+	if i == len(timestamps) {
+		return nan
+	}
+	d := timestamps[i] - ts
+	if d < 0 {
+		d = -d
+	}
+	if d > tolerance {
+		return nan
+	}
+	return values[i]
+}
+
+// getSeasonalTolerance returns the max distance between the expected timestamp and the timestamp of the sample
+// in order to treat this sample as the sample for the expected timestamp.
+//
+// The tolerance equals to the half of the average interval between samples.
+func getSeasonalTolerance(timestamps []int64) int64 {
+	if len(timestamps) < 2 {
+		return 0
+	}
+	return (timestamps[len(timestamps)-1] - timestamps[0]) / int64(2*(len(timestamps)-1))
+}
+
+func newRollupSeasonalNaiveForecast(args []any) (rollupFunc, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("unexpected number of args; got %d; want 2...3", len(args))
+	}
+	periods, err := getScalar(args[1], 1)
+	if err != nil {
+		return nil, err
+	}
+	bound := "forecast"
+	if len(args) == 3 {
+		tssBound, ok := args[2].([]*timeseries)
+		if !ok {
+			return nil, fmt.Errorf(`unexpected type for bound arg; got %T; want %T`, args[2], tssBound)
+		}
+		bound, err = getString(tssBound, 2)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get bound: %w", err)
+		}
+	}
+	var k float64
+	switch bound {
+	case "forecast":
+	case "lower":
+		k = -seasonalForecastZ
+	case "upper":
+		k = seasonalForecastZ
+	default:
+		return nil, fmt.Errorf(`unexpected bound: %q; want "forecast", "lower" or "upper"`, bound)
+	}
+	rf := func(rfa *rollupFuncArg) float64 {
+		period := int64(periods[rfa.idx] * 1e3)
+		forecast, sigma := seasonalNaiveForecast(rfa, period)
+		if k == 0 {
+			return forecast
+		}
+		return forecast + k*sigma
+	}
+	return rf, nil
+}
+
+// seasonalForecastZ is the z-score for 95% confidence bands returned by seasonal_naive_forecast
+const seasonalForecastZ = 1.96
+
+// seasonalNaiveForecast returns seasonal naive forecast for rfa.currTimestamp and the standard deviation of the forecast error
+// for the given period in milliseconds.
+//
+// The forecast is the value observed one period ago. The standard deviation is calculated as root mean square
+// of seasonal differences on the lookbehind window.
+// See https://otexts.com/fpp3/simple-methods.html#seasonal-na%C3%AFve-method
+func seasonalNaiveForecast(rfa *rollupFuncArg, period int64) (float64, float64) {
+	// There is no need in handling NaNs here, since they must be cleaned up
+	// before calling rollup funcs.
+	values := rfa.values
+	timestamps := rfa.timestamps
+	if len(values) < 2 || period <= 0 {
+		return nan, nan
+	}
+	windowStart := rfa.currTimestamp - rfa.window
+	tolerance := getSeasonalTolerance(timestamps)
+	forecast := seasonalValueAt(values, timestamps, rfa.currTimestamp-period, tolerance)
+	if math.IsNaN(forecast) {
+		return nan, nan
+	}
+	var sum float64
+	var n int
+	for i, ts := range timestamps {
+		if ts-period < windowStart {
+			continue
+		}
+		v := seasonalValueAt(values, timestamps, ts-period, tolerance)
+		if math.IsNaN(v) {
+			continue
+		}
+		d := values[i] - v
+		sum += d * d
+		n++
+	}
+	if n == 0 {
+		return forecast, nan
+	}
+	return forecast, math.Sqrt(sum / float64(n))
+}
+
+func newRollupSeasonalAnomalyScore(args []any) (rollupFunc, error) {
+	if err := expectRollupArgsNum(args, 3); err != nil {
+		return nil, err
+	}
+	periods, err := getScalar(args[1], 1)
+	if err != nil {
+		return nil, err
+	}
+	windows, err := getScalar(args[2], 2)
+	if err != nil {
+		return nil, err
+	}
+	rf := func(rfa *rollupFuncArg) float64 {
+		period := int64(periods[rfa.idx] * 1e3)
+		window := int64(windows[rfa.idx] * 1e3)
+		return seasonalAnomalyScore(rfa, period, window)
+	}
+	return rf, nil
+}
+
+// seasonalAnomalyScore returns robust z-score for the average value on the last window
+// relative to average values on the same windows in the previous periods on the lookbehind window.
+//
+// period and window must be in milliseconds.
+func seasonalAnomalyScore(rfa *rollupFuncArg, period, window int64) float64 {
+	// There is no need in handling NaNs here, since they must be cleaned up
+	// before calling rollup funcs.
+	values := rfa.values
+	timestamps := rfa.timestamps
+	if len(values) == 0 || period <= 0 || window <= 0 {
+		return nan
+	}
+	windowStart := rfa.currTimestamp - rfa.window
+	v := seasonalAvgAt(values, timestamps, rfa.currTimestamp, window)
+	if math.IsNaN(v) {
+		return nan
+	}
+
+	a := getFloat64s()
+	defer putFloat64s(a)
+	hs := a.A[:0]
+	for ts := rfa.currTimestamp - period; ts-window >= windowStart; ts -= period {
+		h := seasonalAvgAt(values, timestamps, ts, window)
+		if math.IsNaN(h) {
+			continue
+		}
+		hs = append(hs, h)
+	}
+	a.A = hs
+	if len(hs) < 2 {
+		return nan
+	}
+	median := quantile(0.5, hs)
+	d := v - median
+	if d == 0 {
+		return 0
+	}
+	// See https://en.wikipedia.org/wiki/Median_absolute_deviation#Relation_to_standard_deviation
+	sigma := 1.4826 * mad(hs)
+	if sigma == 0 {
+		sigma = stddev(hs)
+	}
+	return d / sigma
+}
+
+// seasonalAvgAt returns the average over values on the (ts-window, ts] time range.
+func seasonalAvgAt(values []float64, timestamps []int64, ts, window int64) float64 {
+	i := sort.Search(len(timestamps), func(n int) bool { return timestamps[n] > ts-window })
+	j := sort.Search(len(timestamps), func(n int) bool { return timestamps[n] > ts })
+	if i >= j {
+		return nan
+	}
+	var sum float64
+	for _, v := range values[i:j] {
+		sum += v
+	}
+	return sum / float64(j-i)
+}
+
 func rollupFirst(rfa *rollupFuncArg) float64 {
 	// There is no need in handling NaNs here, since they must be cleaned up
 	// before calling rollup funcs.
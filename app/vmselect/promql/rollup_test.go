@@ -536,6 +536,75 @@ func TestRollupPredictLinear(t *testing.T) {
 	f(200e-3, 11.702330309860756)
 }
 
+func TestRollupSeasonalFuncs(t *testing.T) {
+	// daily-like pattern with the period of 4 samples and a spike at the last sample
+	values := []float64{2, 6, 2, -4, 2, 6, 2, -2, 2, 6, 2, -2, 2, 6, 2, 10}
+	timestamps := []int64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110, 120, 130, 140, 150, 160}
+	newScalar := func(v float64) []*timeseries {
+		return []*timeseries{{
+			Values:     []float64{v},
+			Timestamps: []int64{123},
+		}}
+	}
+	f := func(funcName string, args []any, vExpected float64) {
+		t.Helper()
+
+		rf, err := getRollupFunc(funcName)(args)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		rfa := &rollupFuncArg{
+			prevValue:     nan,
+			values:        values,
+			timestamps:    timestamps,
+			currTimestamp: 160,
+			window:        160,
+		}
+		v := rf(rfa)
+		if math.IsNaN(vExpected) {
+			if !math.IsNaN(v) {
+				t.Fatalf("unexpected value; got %v; want %v", v, vExpected)
+			}
+			return
+		}
+		if math.Abs(v-vExpected) > 1e-12 {
+			t.Fatalf("unexpected value; got %v; want %v", v, vExpected)
+		}
+	}
+
+	var me metricsql.MetricExpr
+	re := &metricsql.RollupExpr{Expr: &me}
+	period := newScalar(0.04)
+
+	// seasonal_decompose_over_time
+	f("seasonal_decompose_over_time", []any{re, period, newTestString("trend")}, 4)
+	f("seasonal_decompose_over_time", []any{re, period, newTestString("seasonal")}, -4)
+	f("seasonal_decompose_over_time", []any{re, period, newTestString("residual")}, 10)
+	f("seasonal_decompose_over_time", []any{re, newScalar(0.2), newTestString("trend")}, nan)
+	f("seasonal_decompose_over_time", []any{re, newScalar(0), newTestString("trend")}, nan)
+
+	// seasonal_naive_forecast
+	sigma := math.Sqrt(148.0 / 12)
+	f("seasonal_naive_forecast", []any{re, period}, -2)
+	f("seasonal_naive_forecast", []any{re, period, newTestString("forecast")}, -2)
+	f("seasonal_naive_forecast", []any{re, period, newTestString("lower")}, -2-1.96*sigma)
+	f("seasonal_naive_forecast", []any{re, period, newTestString("upper")}, -2+1.96*sigma)
+	f("seasonal_naive_forecast", []any{re, newScalar(0.2)}, nan)
+
+	// seasonal_anomaly_score
+	f("seasonal_anomaly_score", []any{re, period, newScalar(0.02)}, 6/math.Sqrt(2.0/9))
+	f("seasonal_anomaly_score", []any{re, period, newScalar(0.01)}, 12/math.Sqrt(8.0/9))
+	f("seasonal_anomaly_score", []any{re, newScalar(0.08), newScalar(0.02)}, nan)
+}
+
+func newTestString(s string) []*timeseries {
+	var ts timeseries
+	ts.MetricName.MetricGroup = []byte(s)
+	ts.Values = []float64{nan}
+	ts.Timestamps = []int64{123}
+	return []*timeseries{&ts}
+}
+
 func TestLinearRegression(t *testing.T) {
 	f := func(values []float64, timestamps []int64, expV, expK float64) {
 		t.Helper()
@@ -721,6 +790,11 @@ func TestRollupNewRollupFuncError(t *testing.T) {
 	f("predict_linear", []any{me, 123})
 	f("quantile_over_time", []any{123, 123})
 	f("quantiles_over_time", []any{123, 123})
+	f("seasonal_decompose_over_time", []any{me, scalarTs})
+	f("seasonal_decompose_over_time", []any{me, scalarTs, 123})
+	f("seasonal_decompose_over_time", []any{me, scalarTs, newTestString("foo")})
+	f("seasonal_naive_forecast", []any{me, scalarTs, newTestString("foo")})
+	f("seasonal_anomaly_score", []any{me, scalarTs})
 }
 
 func TestRollupNoWindowNoPoints(t *testing.T) {
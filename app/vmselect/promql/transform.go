@@ -51,11 +51,14 @@ var transformFuncs = map[string]transformFunc{
 	"exp":                        newTransformFuncOneArg(transformExp),
 	"floor":                      newTransformFuncOneArg(transformFloor),
 	"histogram_avg":              transformHistogramAvg,
+	"histogram_count":            transformHistogramCount,
+	"histogram_fraction":         transformHistogramFraction,
 	"histogram_quantile":         transformHistogramQuantile,
 	"histogram_quantiles":        transformHistogramQuantiles,
 	"histogram_share":            transformHistogramShare,
 	"histogram_stddev":           transformHistogramStddev,
 	"histogram_stdvar":           transformHistogramStdvar,
+	"histogram_sum":              transformHistogramSum,
 	"hour":                       newTransformFuncDateTime(transformHour),
 	"interpolate":                transformInterpolate,
 	"keep_last_value":            transformKeepLastValue,
@@ -750,6 +753,162 @@ func transformHistogramAvg(tfa *transformFuncArg) ([]*timeseries, error) {
 	return rvs, nil
 }
 
+func transformHistogramCount(tfa *transformFuncArg) ([]*timeseries, error) {
+	args := tfa.args
+	if err := expectTransformArgsNum(args, 1); err != nil {
+		return nil, err
+	}
+	tss := vmrangeBucketsToLE(args[0])
+	m := groupLeTimeseries(tss)
+	rvs := make([]*timeseries, 0, len(m))
+	for _, xss := range m {
+		sort.Slice(xss, func(i, j int) bool {
+			return xss[i].le < xss[j].le
+		})
+		xss = mergeSameLE(xss)
+		dst := xss[0].ts
+		for i := range dst.Values {
+			dst.Values[i] = countForLeTimeseries(i, xss)
+		}
+		rvs = append(rvs, dst)
+	}
+	return rvs, nil
+}
+
+func transformHistogramSum(tfa *transformFuncArg) ([]*timeseries, error) {
+	args := tfa.args
+	if err := expectTransformArgsNum(args, 1); err != nil {
+		return nil, err
+	}
+	tss := vmrangeBucketsToLE(args[0])
+	m := groupLeTimeseries(tss)
+	rvs := make([]*timeseries, 0, len(m))
+	for _, xss := range m {
+		sort.Slice(xss, func(i, j int) bool {
+			return xss[i].le < xss[j].le
+		})
+		xss = mergeSameLE(xss)
+		dst := xss[0].ts
+		for i := range dst.Values {
+			dst.Values[i] = sumForLeTimeseries(i, xss)
+		}
+		rvs = append(rvs, dst)
+	}
+	return rvs, nil
+}
+
+func transformHistogramFraction(tfa *transformFuncArg) ([]*timeseries, error) {
+	args := tfa.args
+	if err := expectTransformArgsNum(args, 3); err != nil {
+		return nil, err
+	}
+	lowers, err := getScalar(args[0], 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse lower: %w", err)
+	}
+	uppers, err := getScalar(args[1], 1)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse upper: %w", err)
+	}
+
+	// Convert buckets with `vmrange` labels to buckets with `le` labels.
+	tss := vmrangeBucketsToLE(args[2])
+
+	// Group metrics by all tags excluding "le"
+	m := groupLeTimeseries(tss)
+	rvs := make([]*timeseries, 0, len(m))
+	for _, xss := range m {
+		sort.Slice(xss, func(i, j int) bool {
+			return xss[i].le < xss[j].le
+		})
+		xss = mergeSameLE(xss)
+		dst := xss[0].ts
+		for i := range dst.Values {
+			dst.Values[i] = fractionForLeTimeseries(i, lowers[i], uppers[i], xss)
+		}
+		rvs = append(rvs, dst)
+	}
+	return rvs, nil
+}
+
+// sumForLeTimeseries returns the estimated sum of observations in xss at the point i.
+//
+// The sum is estimated from bucket middles in the same way as histogram_avg does, since buckets do not contain the exact sum.
+// Observations in the +Inf bucket are counted at the upper bound of the last finite bucket.
+//
+// xss must be sorted by le.
+func sumForLeTimeseries(i int, xss []leTimeseries) float64 {
+	lePrev := float64(0)
+	vPrev := float64(0)
+	sum := float64(0)
+	for _, xs := range xss {
+		v := xs.ts.Values[i]
+		if math.IsInf(xs.le, 0) {
+			sum += lePrev * (v - vPrev)
+			break
+		}
+		le := xs.le
+		n := (le + lePrev) / 2
+		sum += n * (v - vPrev)
+		lePrev = le
+		vPrev = v
+	}
+	return sum
+}
+
+// countForLeTimeseries returns the total number of observations in xss at the point i.
+//
+// xss must be sorted by le.
+func countForLeTimeseries(i int, xss []leTimeseries) float64 {
+	fixBrokenBuckets(i, xss)
+	return xss[len(xss)-1].ts.Values[i]
+}
+
+// fractionForLeTimeseries returns the estimated fraction of observations in the range (lower...upper] at the point i.
+//
+// xss must be sorted by le.
+func fractionForLeTimeseries(i int, lower, upper float64, xss []leTimeseries) float64 {
+	if math.IsNaN(lower) || math.IsNaN(upper) {
+		return nan
+	}
+	count := countForLeTimeseries(i, xss)
+	if count == 0 || math.IsNaN(count) {
+		return nan
+	}
+	if lower >= upper {
+		return 0
+	}
+	return (rankForLeTimeseries(i, upper, xss) - rankForLeTimeseries(i, lower, xss)) / count
+}
+
+// rankForLeTimeseries returns the estimated number of observations, which don't exceed leReq at the point i.
+//
+// The number is linearly interpolated inside the bucket containing leReq in the same way as histogram_share does.
+// The lower bound for the first bucket is assumed to be 0 if the bucket has positive le.
+func rankForLeTimeseries(i int, leReq float64, xss []leTimeseries) float64 {
+	lePrev := float64(0)
+	vPrev := float64(0)
+	for j, xs := range xss {
+		le := xs.le
+		v := xs.ts.Values[i]
+		if leReq >= le {
+			lePrev = le
+			vPrev = v
+			continue
+		}
+		// precondition: lePrev <= leReq < le
+		if math.IsInf(le, 1) {
+			return vPrev
+		}
+		if j == 0 && (le <= 0 || leReq <= 0) {
+			return 0
+		}
+		return vPrev + (v-vPrev)*(leReq-lePrev)/(le-lePrev)
+	}
+	// precondition: leReq >= leLast
+	return vPrev
+}
+
 func transformHistogramStddev(tfa *transformFuncArg) ([]*timeseries, error) {
 	args := tfa.args
 	if err := expectTransformArgsNum(args, 1); err != nil {
@@ -2,6 +2,7 @@ package promql
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -63,6 +64,52 @@ func TestFixBrokenBucketsMultipleValues(t *testing.T) {
 	f([][]float64{{10, 1}, {11, 2}, {13, 3}}, [][]float64{{10, 1}, {11, 2}, {13, 3}})
 }
 
+func TestHistogramStatsForLeTimeseries(t *testing.T) {
+	f := func(les, values []float64, countExpected, sumExpected float64, fractions [][3]float64) {
+		t.Helper()
+		xss := make([]leTimeseries, len(les))
+		for i, le := range les {
+			xss[i] = leTimeseries{
+				le: le,
+				ts: &timeseries{
+					Values: []float64{values[i]},
+				},
+			}
+		}
+		if count := countForLeTimeseries(0, xss); count != countExpected {
+			t.Fatalf("unexpected count; got %v; want %v", count, countExpected)
+		}
+		if sum := sumForLeTimeseries(0, xss); sum != sumExpected {
+			t.Fatalf("unexpected sum; got %v; want %v", sum, sumExpected)
+		}
+		for _, x := range fractions {
+			lower, upper, fractionExpected := x[0], x[1], x[2]
+			fraction := fractionForLeTimeseries(0, lower, upper, xss)
+			if fraction != fractionExpected {
+				t.Fatalf("unexpected fraction for (%v...%v]; got %v; want %v", lower, upper, fraction, fractionExpected)
+			}
+		}
+	}
+
+	inf := math.Inf(1)
+
+	// classic buckets with non-empty +Inf bucket
+	f([]float64{10, 30, inf}, []float64{0, 100, 300}, 300, 8000, [][3]float64{
+		{20, 30, 1.0 / 6},
+		{0, inf, 1},
+		{-inf, 10, 0},
+		{30, inf, 2.0 / 3},
+		{30, 20, 0},
+	})
+
+	// buckets with negative bounds obtained from vmrange buckets
+	f([]float64{-2, -1, 1, 2, 4, inf}, []float64{0, 1, 3, 6, 10, 10}, 10, 15, [][3]float64{
+		{-1, 2, 0.5},
+		{-inf, inf, 1},
+		{2, 3, 0.2},
+	})
+}
+
 func TestVmrangeBucketsToLE(t *testing.T) {
 	f := func(buckets, bucketsExpected string) {
 		t.Helper()
@@ -59,6 +59,25 @@ var (
 		"Excess series are logged and dropped. This can be useful for limiting series churn rate. See https://docs.victoriametrics.com/#cardinality-limiter . "+
 		"See also -storage.maxHourlySeries")
 
+	maxMetricMetadataEntries = flag.Int("storage.maxMetadataEntries", 100_000, "The maximum number of unique metric metadata entries, which can be stored in the storage. "+
+		"Excess entries are dropped. Metric metadata is accepted only if -enableMetadata command-line flag is set. See https://docs.victoriametrics.com/#metrics-metadata")
+
+	exemplarsRetentionPeriod = flagutil.NewRetentionDuration("exemplars.retentionPeriod", "3d", "Exemplars with timestamps outside the retention are automatically deleted. "+
+		"See https://docs.victoriametrics.com/#exemplars")
+	maxExemplarsPerSeries = flag.Int("exemplars.maxPerSeries", 10, "The maximum number of the most recent exemplars to store per each time series. "+
+		"Exemplars aren't stored if this flag is set to 0. See https://docs.victoriametrics.com/#exemplars")
+	maxSeriesWithExemplars = flag.Int("exemplars.maxSeries", 1_000_000, "The maximum number of time series with exemplars, which can be stored in the storage. "+
+		"Exemplars for new time series are dropped when the limit is reached. See https://docs.victoriametrics.com/#exemplars")
+
+	downsamplingPeriods = flagutil.NewArrayString("downsampling.period", "Comma-separated downsampling periods in the form 'offset:interval'. "+
+		"For example, -downsampling.period=30d:1m,180d:5m leaves a single sample per minute for samples older than 30 days "+
+		"and a single sample per 5 minutes for samples older than 180 days. Downsampling is performed during background merges and during querying. "+
+		"See https://docs.victoriametrics.com/#downsampling")
+	retentionFilters = flagutil.NewArrayString("retentionFilter", "Retention filter in the form 'filter:retention'. For example, '{env=\"dev\"}:7d' sets 7 days retention "+
+		"for time series with env=\"dev\" label. The retention may be bigger than -retentionPeriod. Time series, which do not match any filter, "+
+		"use -retentionPeriod. If time series matches multiple filters, then the smallest retention is applied. "+
+		"See https://docs.victoriametrics.com/#retention-filters")
+
 	minFreeDiskSpaceBytes = flagutil.NewBytes("storage.minFreeDiskSpaceBytes", 10e6, "The minimum free disk space at -storageDataPath after which the storage stops accepting new data")
 
 	cacheSizeStorageTSID = flagutil.NewBytes("storage.cacheSizeStorageTSID", 0, "Overrides max size for storage/tsid cache. "+
@@ -73,17 +92,21 @@ var (
 		"See https://docs.victoriametrics.com/single-server-victoriametrics/#cache-tuning")
 )
 
+// maxRetentionMsecs is the maximum retention across -retentionPeriod and -retentionFilter.
+var maxRetentionMsecs int64
+
 // CheckTimeRange returns true if the given tr is denied for querying.
 func CheckTimeRange(tr storage.TimeRange) error {
 	if !*denyQueriesOutsideRetention {
 		return nil
 	}
-	minAllowedTimestamp := int64(fasttime.UnixTimestamp()*1000) - retentionPeriod.Milliseconds()
+	minAllowedTimestamp := int64(fasttime.UnixTimestamp()*1000) - maxRetentionMsecs
 	if tr.MinTimestamp > minAllowedTimestamp {
 		return nil
 	}
 	return &httpserver.ErrorWithStatusCode{
-		Err:        fmt.Errorf("the given time range %s is outside the allowed -retentionPeriod=%s according to -denyQueriesOutsideRetention", &tr, retentionPeriod),
+		Err: fmt.Errorf("the given time range %s is outside the allowed -retentionPeriod=%s and -retentionFilter according to -denyQueriesOutsideRetention",
+			&tr, retentionPeriod),
 		StatusCode: http.StatusServiceUnavailable,
 	}
 }
@@ -98,6 +121,31 @@ func Init(resetCacheIfNeeded func(mrs []storage.MetricRow)) {
 	storage.SetLogNewSeries(*logNewSeries)
 	storage.SetRetentionTimezoneOffset(*retentionTimezoneOffset)
 	storage.SetFreeDiskSpaceLimit(minFreeDiskSpaceBytes.N)
+	storage.SetMaxMetricMetadataEntries(*maxMetricMetadataEntries)
+	storage.SetExemplarsRetention(exemplarsRetentionPeriod.Duration())
+	storage.SetMaxExemplarsPerSeries(*maxExemplarsPerSeries)
+	storage.SetMaxSeriesWithExemplars(*maxSeriesWithExemplars)
+	periods, err := storage.ParseDownsamplingPeriods(*downsamplingPeriods)
+	if err != nil {
+		logger.Fatalf("invalid -downsampling.period: %s", err)
+	}
+	if dedupInterval := storage.GetDedupInterval(); dedupInterval > 0 {
+		for _, p := range periods {
+			if p.Interval%dedupInterval != 0 {
+				logger.Fatalf("invalid -downsampling.period: interval %dms must be a multiple of -dedup.minScrapeInterval=%dms", p.Interval, dedupInterval)
+			}
+		}
+	}
+	storage.SetDownsamplingPeriods(periods)
+	rfs, err := storage.ParseRetentionFilters(*retentionFilters)
+	if err != nil {
+		logger.Fatalf("invalid -retentionFilter: %s", err)
+	}
+	maxRetentionMsecs = retentionPeriod.Milliseconds()
+	for _, rf := range rfs {
+		maxRetentionMsecs = max(maxRetentionMsecs, rf.RetentionMsecs())
+	}
+	storage.SetRetentionFilters(rfs)
 	storage.SetTSIDCacheSize(cacheSizeStorageTSID.IntN())
 	storage.SetTagFiltersCacheSize(cacheSizeIndexDBTagFilters.IntN())
 	mergeset.SetIndexBlocksCacheSize(cacheSizeIndexDBIndexBlocks.IntN())
@@ -164,6 +212,46 @@ func AddRows(mrs []storage.MetricRow) error {
 
 var errReadOnly = errors.New("the storage is in read-only mode; check -storage.minFreeDiskSpaceBytes command-line flag value")
 
+// AddMetricMetadata adds mms to the storage.
+func AddMetricMetadata(mms []storage.MetricMetadata) error {
+	if Storage.IsReadOnly() {
+		return errReadOnly
+	}
+	WG.Add(1)
+	Storage.AddMetricMetadata(mms)
+	WG.Done()
+	return nil
+}
+
+// AddExemplars adds ers to the storage.
+func AddExemplars(ers []storage.ExemplarRow) error {
+	if Storage.IsReadOnly() {
+		return errReadOnly
+	}
+	WG.Add(1)
+	Storage.AddExemplars(ers)
+	WG.Done()
+	return nil
+}
+
+// SearchExemplars returns exemplars for time series matching the given tfss on the given tr.
+func SearchExemplars(qt *querytracer.Tracer, tfss []*storage.TagFilters, tr storage.TimeRange, maxMetrics int, deadline uint64) ([]storage.SeriesExemplars, error) {
+	WG.Add(1)
+	ses, err := Storage.SearchExemplars(qt, tfss, tr, maxMetrics, deadline)
+	WG.Done()
+	return ses, err
+}
+
+// SearchMetricMetadata returns metric metadata for the given metricFamilyName.
+//
+// Metadata for all the metric families is returned if metricFamilyName is empty.
+func SearchMetricMetadata(metricFamilyName string, limit, limitPerMetric int) []storage.MetricMetadata {
+	WG.Add(1)
+	mms := Storage.SearchMetricMetadata(metricFamilyName, limit, limitPerMetric)
+	WG.Done()
+	return mms
+}
+
 // RegisterMetricNames registers all the metrics from mrs in the storage.
 func RegisterMetricNames(qt *querytracer.Tracer, mrs []storage.MetricRow) {
 	WG.Add(1)
@@ -646,6 +734,11 @@ func writeStorageMetrics(w io.Writer, strg *storage.Storage) {
 
 	metrics.WriteGaugeUint64(w, `vm_next_retention_seconds`, m.NextRetentionSeconds)
 
+	metrics.WriteGaugeUint64(w, `vm_metric_metadata_entries`, m.MetricMetadataEntries)
+	metrics.WriteCounterUint64(w, `vm_metric_metadata_dropped_entries_total`, m.MetricMetadataDroppedEntries)
+	metrics.WriteGaugeUint64(w, `vm_series_with_exemplars`, m.SeriesWithExemplars)
+	metrics.WriteCounterUint64(w, `vm_exemplars_dropped_total`, m.ExemplarsDropped)
+
 	metrics.WriteGaugeUint64(w, `vm_downsampling_partitions_scheduled`, tm.ScheduledDownsamplingPartitions)
 	metrics.WriteGaugeUint64(w, `vm_downsampling_partitions_scheduled_size_bytes`, tm.ScheduledDownsamplingPartitionsSize)
 }
@@ -19,7 +19,7 @@ import (
 
 // Backup performs backup according to the provided settings.
 //
-// Note that the backup works only for VictoriaMetrics snapshots
+// Note that the backup works only for VictoriaMetrics and VictoriaLogs snapshots
 // made via `/snapshot/create`. It works improperly on mutable files.
 type Backup struct {
 	// Concurrency is the number of concurrent workers during the backup.
@@ -18,7 +18,7 @@ import (
 
 // Restore restores data according to the provided settings.
 //
-// Note that the restore works only for VictoriaMetrics backups made from snapshots.
+// Note that the restore works only for VictoriaMetrics and VictoriaLogs backups made from snapshots.
 // It works improperly on mutable files.
 type Restore struct {
 	// Concurrency is the number of concurrent workers to run during restore.
@@ -214,6 +214,9 @@ func (bs *blockSearch) search(bsw *blockSearchWork, bm *bitmap) {
 	bm.setBits()
 	bs.bsw.so.filter.applyToBlockSearch(bs, bm)
 
+	// exclude rows matching the pending delete tasks
+	bs.applyDeleteFilters(bm)
+
 	if bm.isZero() {
 		// The filter doesn't match any logs in the current block.
 		return
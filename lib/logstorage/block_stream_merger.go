@@ -11,10 +11,12 @@ import (
 
 // mustMergeBlockStreams merges bsrs to bsw and updates ph accordingly.
 //
+// Log entries matching rd are dropped during the merge if rd isn't nil.
+//
 // Finalize() is guaranteed to be called on bsrs and bsw before returning from the func.
-func mustMergeBlockStreams(ph *partHeader, bsw *blockStreamWriter, bsrs []*blockStreamReader, stopCh <-chan struct{}) {
+func mustMergeBlockStreams(ph *partHeader, bsw *blockStreamWriter, bsrs []*blockStreamReader, rd *rowsDeleter, stopCh <-chan struct{}) {
 	bsm := getBlockStreamMerger()
-	bsm.mustInit(bsw, bsrs)
+	bsm.mustInit(bsw, bsrs, rd)
 	for len(bsm.readersHeap) > 0 {
 		if needStop(stopCh) {
 			break
@@ -46,6 +48,9 @@ type blockStreamMerger struct {
 	// readersHeap contains a heap of readers to read blocks to merge.
 	readersHeap blockStreamReadersHeap
 
+	// rd is an optional deleter for log entries, which must be dropped during the merge.
+	rd *rowsDeleter
+
 	// streamID is the stream ID for the pending data.
 	streamID streamID
 
@@ -88,6 +93,8 @@ func (bsm *blockStreamMerger) reset() {
 	}
 	bsm.readersHeap = rhs[:0]
 
+	bsm.rd = nil
+
 	bsm.streamID.reset()
 	bsm.resetRows()
 }
@@ -111,11 +118,12 @@ func (bsm *blockStreamMerger) resetRows() {
 	bsm.uniqueFields = 0
 }
 
-func (bsm *blockStreamMerger) mustInit(bsw *blockStreamWriter, bsrs []*blockStreamReader) {
+func (bsm *blockStreamMerger) mustInit(bsw *blockStreamWriter, bsrs []*blockStreamReader, rd *rowsDeleter) {
 	bsm.reset()
 
 	bsm.bsw = bsw
 	bsm.bsrs = bsrs
+	bsm.rd = rd
 
 	rsh := bsm.readersHeap[:0]
 	for _, bsr := range bsrs {
@@ -130,7 +138,22 @@ func (bsm *blockStreamMerger) mustInit(bsw *blockStreamWriter, bsrs []*blockStre
 // mustWriteBlock writes bd to bsm
 func (bsm *blockStreamMerger) mustWriteBlock(bd *blockData, bsw *blockStreamWriter) {
 	bsm.checkNextBlock(bd)
+	if bsm.rd.needDeleteBlock(bd) {
+		// Fast path - all the log entries in bd must be deleted.
+		return
+	}
 	uniqueFields := len(bd.columnsData) + len(bd.constColumns)
+	if bsm.rd.needDeleteRows(bd) {
+		// Slow path - the bd may contain log entries, which must be deleted.
+		// Unpack the bd into log entries, so they could be filtered by mustMergeRows.
+		if !bd.streamID.equal(&bsm.streamID) || bsm.uniqueFields+uniqueFields > maxColumnsPerBlock {
+			bsm.mustFlushRows()
+			bsm.streamID = bd.streamID
+		}
+		bsm.mustMergeRows(bd)
+		bsm.uniqueFields += uniqueFields
+		return
+	}
 	switch {
 	case !bd.streamID.equal(&bsm.streamID):
 		// The bd contains another streamID.
@@ -254,6 +277,9 @@ func (bsm *blockStreamMerger) mustUnmarshalRows(bd *blockData) {
 	if err := bd.unmarshalRows(&bsm.rows, bsm.sbu, bsm.vd); err != nil {
 		logger.Panicf("FATAL: cannot merge %s: cannot unmarshal log entries from blockData: %s", bsm.ReadersPaths(), err)
 	}
+	if bsm.rd.needDeleteRows(bd) {
+		bsm.rd.deleteRows(&bsm.rows, rowsLen, &bd.streamID)
+	}
 	bsm.uncompressedRowsSizeBytes += uncompressedRowsSizeBytes(bsm.rows.rows[rowsLen:])
 }
 
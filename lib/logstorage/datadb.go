@@ -114,6 +114,13 @@ type partWrapper struct {
 	// isInMerge is set to true if the part takes part in merge.
 	isInMerge bool
 
+	// deleteTaskSeq is the delete task sequence number at the moment when the part data has been ingested or merged.
+	//
+	// Delete tasks with sequence numbers smaller or equal to deleteTaskSeq are already applied to the part,
+	// or the part contains only log entries ingested after the creation of these tasks.
+	// Parts loaded from disk have zero deleteTaskSeq, so they are processed by all the delete tasks.
+	deleteTaskSeq uint64
+
 	// The deadline when in-memory part must be flushed to disk.
 	flushDeadline time.Time
 }
@@ -519,6 +526,9 @@ func (ddb *datadb) mustMergeParts(pws []*partWrapper, isFinal bool) {
 		mp := pws[0].mp
 		mp.MustStoreToDisk(dstPartPath)
 		pwNew := ddb.openCreatedPart(&mp.ph, pws, nil, dstPartPath)
+		if pwNew != nil {
+			pwNew.deleteTaskSeq = pws[0].deleteTaskSeq
+		}
 		ddb.swapSrcWithDstParts(pws, pwNew, dstPartType)
 		return
 	}
@@ -557,7 +567,11 @@ func (ddb *datadb) mustMergeParts(pws []*partWrapper, isFinal bool) {
 		// The final merge shouldn't be stopped even if ddb.stopCh is closed.
 		stopCh = nil
 	}
-	mustMergeBlockStreams(&ph, bsw, bsrs, stopCh)
+	rd, deleteTaskSeq := newRowsDeleter(ddb.pt)
+	mustMergeBlockStreams(&ph, bsw, bsrs, rd, stopCh)
+	if rd != nil && rd.rowsDeleted > 0 {
+		ddb.pt.s.rowsDeleted.Add(rd.rowsDeleted)
+	}
 	putBlockStreamWriter(bsw)
 	for _, bsr := range bsrs {
 		putBlockStreamReader(bsr)
@@ -581,6 +595,9 @@ func (ddb *datadb) mustMergeParts(pws []*partWrapper, isFinal bool) {
 
 	// Atomically swap the source parts with the newly created part.
 	pwNew := ddb.openCreatedPart(&ph, pws, mpNew, dstPartPath)
+	if pwNew != nil {
+		pwNew.deleteTaskSeq = deleteTaskSeq
+	}
 
 	dstSize := uint64(0)
 	dstRowsCount := uint64(0)
@@ -678,6 +695,7 @@ func (ddb *datadb) mustAddRows(lr *LogRows) {
 
 	flushDeadline := time.Now().Add(ddb.flushInterval)
 	pw := newPartWrapper(p, mp, flushDeadline)
+	pw.deleteTaskSeq = ddb.pt.s.getDeleteTaskSeq()
 
 	ddb.partsLock.Lock()
 	ddb.inmemoryParts = append(ddb.inmemoryParts, pw)
@@ -799,6 +817,44 @@ func (ddb *datadb) debugFlush() {
 	// Nothing to do, since all the ingested data is available for search via ddb.inmemoryParts.
 }
 
+// mustCreateSnapshotAt creates a snapshot for ddb at dstDir.
+//
+// In-memory parts are flushed to disk before creating the snapshot, while file parts are hard-linked into dstDir.
+func (ddb *datadb) mustCreateSnapshotAt(dstDir string) {
+	ddb.mustFlushInmemoryPartsToFiles(true)
+
+	ddb.partsLock.Lock()
+	smallParts := append([]*partWrapper{}, ddb.smallParts...)
+	bigParts := append([]*partWrapper{}, ddb.bigParts...)
+	for _, pw := range smallParts {
+		pw.incRef()
+	}
+	for _, pw := range bigParts {
+		pw.incRef()
+	}
+	ddb.partsLock.Unlock()
+
+	fs.MustMkdirFailIfExist(dstDir)
+
+	for _, pws := range [][]*partWrapper{smallParts, bigParts} {
+		for _, pw := range pws {
+			srcPartPath := pw.p.path
+			dstPartPath := filepath.Join(dstDir, filepath.Base(srcPartPath))
+			fs.MustHardLinkFiles(srcPartPath, dstPartPath)
+		}
+	}
+	mustWritePartNames(dstDir, getPartNames(smallParts), getPartNames(bigParts))
+
+	for _, pw := range smallParts {
+		pw.decRef()
+	}
+	for _, pw := range bigParts {
+		pw.decRef()
+	}
+
+	fs.MustSyncPath(dstDir)
+}
+
 func (ddb *datadb) swapSrcWithDstParts(pws []*partWrapper, pwNew *partWrapper, dstPartType partType) {
 	// Atomically unregister old parts and add new part to pt.
 	partsToRemove := partsToMap(pws)
@@ -1268,6 +1324,21 @@ func (ddb *datadb) mustForceMergeAllParts() {
 	putWaitGroup(wg)
 }
 
+// hasPartsForDeleteTask returns true if ddb contains parts, which must be processed by the delete task with the given seq.
+func (ddb *datadb) hasPartsForDeleteTask(seq uint64) bool {
+	ddb.partsLock.Lock()
+	defer ddb.partsLock.Unlock()
+
+	for _, pws := range [][]*partWrapper{ddb.inmemoryParts, ddb.smallParts, ddb.bigParts} {
+		for _, pw := range pws {
+			if pw.deleteTaskSeq < seq {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func appendAllPartsForMergeLocked(dst, src []*partWrapper) []*partWrapper {
 	for _, pw := range src {
 		if !pw.isInMerge {
@@ -17,7 +17,10 @@ const (
 	metadataFilename = "metadata.json"
 	partsFilename    = "parts.json"
 
+	deleteTasksFilename = "delete_tasks.json"
+
 	indexdbDirname    = "indexdb"
 	datadbDirname     = "datadb"
 	partitionsDirname = "partitions"
+	snapshotsDirname  = "snapshots"
 )
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -152,6 +153,60 @@ func (idb *indexdb) hasStreamID(sid *streamID) bool {
 	return len(kb.B) == len(ts.Item)
 }
 
+// searchTenantIDs returns sorted list of tenants with the registered streams at idb.
+func (idb *indexdb) searchTenantIDs() []TenantID {
+	is := idb.getIndexSearch()
+	defer idb.putIndexSearch(is)
+
+	ts := &is.ts
+	kb := &is.kb
+
+	var tenantIDs []TenantID
+	var tenantID TenantID
+	for {
+		kb.B = marshalCommonPrefix(kb.B[:0], nsPrefixStreamID, tenantID)
+		ts.Seek(kb.B)
+		if !ts.NextItem() {
+			break
+		}
+		_, nsPrefix, err := unmarshalCommonPrefix(&tenantID, ts.Item)
+		if err != nil {
+			logger.Panicf("FATAL: cannot unmarshal tenantID from (tenantID:streamID) entry: %s", err)
+		}
+		if nsPrefix != nsPrefixStreamID {
+			break
+		}
+		tenantIDs = append(tenantIDs, tenantID)
+
+		// Jump to the next tenant.
+		nextTenantID, ok := getNextTenantID(tenantID)
+		if !ok {
+			break
+		}
+		tenantID = nextTenantID
+	}
+	if err := ts.Error(); err != nil {
+		logger.Panicf("FATAL: unexpected error: %s", err)
+	}
+	return tenantIDs
+}
+
+// getNextTenantID returns the tenantID next to the given tenantID.
+//
+// false is returned if there is no next tenantID.
+func getNextTenantID(tenantID TenantID) (TenantID, bool) {
+	if tenantID.ProjectID < math.MaxUint32 {
+		tenantID.ProjectID++
+		return tenantID, true
+	}
+	if tenantID.AccountID < math.MaxUint32 {
+		tenantID.AccountID++
+		tenantID.ProjectID = 0
+		return tenantID, true
+	}
+	return tenantID, false
+}
+
 type indexSearch struct {
 	idb *indexdb
 	ts  mergeset.TableSearch
@@ -0,0 +1,120 @@
+package logstorage
+
+import (
+	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/slicesutil"
+)
+
+// InsertRow represents a single log entry, which is transferred from vlinsert to vlstorage in cluster mode.
+type InsertRow struct {
+	// TenantID is the tenant for the log entry.
+	TenantID TenantID
+
+	// StreamTagsCanonical is the canonical representation of stream tags for the log entry.
+	StreamTagsCanonical string
+
+	// Timestamp is the log entry timestamp in nanoseconds.
+	Timestamp int64
+
+	// Fields contains log entry fields.
+	Fields []Field
+}
+
+// Reset resets r to zero value.
+func (r *InsertRow) Reset() {
+	r.TenantID.Reset()
+	r.StreamTagsCanonical = ""
+	r.Timestamp = 0
+
+	clear(r.Fields)
+	r.Fields = r.Fields[:0]
+}
+
+// Marshal appends marshaled r to dst and returns the result.
+func (r *InsertRow) Marshal(dst []byte) []byte {
+	dst = r.TenantID.marshal(dst)
+	dst = encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(r.StreamTagsCanonical))
+	dst = encoding.MarshalUint64(dst, uint64(r.Timestamp))
+	dst = encoding.MarshalVarUint64(dst, uint64(len(r.Fields)))
+	for i := range r.Fields {
+		dst = r.Fields[i].marshal(dst, true)
+	}
+	return dst
+}
+
+// UnmarshalInplace unmarshals r from src and returns the remaining tail.
+//
+// r refers to src, so src must remain unchanged while r is in use.
+func (r *InsertRow) UnmarshalInplace(src []byte) ([]byte, error) {
+	r.Reset()
+
+	tail, err := r.TenantID.unmarshal(src)
+	if err != nil {
+		return src, err
+	}
+	src = tail
+
+	streamTagsCanonical, nSize := encoding.UnmarshalBytes(src)
+	if nSize <= 0 {
+		return src, fmt.Errorf("cannot unmarshal stream tags")
+	}
+	src = src[nSize:]
+	r.StreamTagsCanonical = bytesutil.ToUnsafeString(streamTagsCanonical)
+
+	if len(src) < 8 {
+		return src, fmt.Errorf("cannot unmarshal timestamp from %d bytes; need at least 8 bytes", len(src))
+	}
+	r.Timestamp = int64(encoding.UnmarshalUint64(src))
+	src = src[8:]
+
+	fieldsLen, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return src, fmt.Errorf("cannot unmarshal the number of fields")
+	}
+	src = src[nSize:]
+	if fieldsLen > uint64(len(src)) {
+		return src, fmt.Errorf("too big number of fields: %d; cannot exceed %d", fieldsLen, len(src))
+	}
+
+	r.Fields = slicesutil.SetLength(r.Fields, int(fieldsLen))
+	for i := range r.Fields {
+		tail, err := r.Fields[i].unmarshalNoArena(src, true)
+		if err != nil {
+			return src, fmt.Errorf("cannot unmarshal field #%d: %w", i, err)
+		}
+		src = tail
+	}
+
+	return src, nil
+}
+
+// ForEachRow calls callback for every row stored in lr.
+//
+// streamHash is the hash of the log stream for the row. It can be used for spreading log streams among storage nodes with GetStreamNodeIdx.
+//
+// callback cannot hold references to r after returning.
+func (lr *LogRows) ForEachRow(callback func(streamHash uint64, r *InsertRow)) {
+	var r InsertRow
+	for i, fields := range lr.rows {
+		sid := &lr.streamIDs[i]
+		r.TenantID = sid.tenantID
+		r.StreamTagsCanonical = bytesutil.ToUnsafeString(lr.streamTagsCanonicals[i])
+		r.Timestamp = lr.timestamps[i]
+		r.Fields = fields
+		callback(sid.id.lo, &r)
+	}
+}
+
+// MustAddInsertRow adds r to lr.
+//
+// It is OK to modify r after returning from the function, since lr copies all the data from r.
+func (lr *LogRows) MustAddInsertRow(r *InsertRow) {
+	var sid streamID
+	sid.tenantID = r.TenantID
+	sid.id = hash128(bytesutil.ToUnsafeBytes(r.StreamTagsCanonical))
+
+	lr.mustAddInternal(sid, r.Timestamp, r.Fields, bytesutil.ToUnsafeBytes(r.StreamTagsCanonical))
+}
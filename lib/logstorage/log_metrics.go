@@ -0,0 +1,648 @@
+package logstorage
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"gopkg.in/yaml.v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+// defaultLogMetricsMaxSeries is the default limit on the number of series per log metrics rule.
+const defaultLogMetricsMaxSeries = 10_000
+
+// LogMetrics contains rules for generating metrics from the ingested logs.
+//
+// LogMetrics must be obtained via ParseLogMetrics.
+type LogMetrics struct {
+	rules []*logMetricsRule
+
+	// neededFields contains fields needed by filters of all the rules.
+	neededFields fieldsSet
+
+	matchersPool sync.Pool
+}
+
+type logMetricsRule struct {
+	// metric is the name of the generated metric.
+	metric string
+
+	// f is the filter for the log entries to generate metric from.
+	f filter
+
+	// byFields contains log fields to group the generated metric by.
+	//
+	// The _msg field is stored as an empty name, in the same way as it is stored in LogRows.
+	byFields []string
+
+	// labelNames contains label names for the corresponding byFields.
+	labelNames []string
+
+	// output is the type of the generated metric.
+	output logMetricsOutput
+
+	// fieldName is the numeric field for sum() and histogram() outputs.
+	//
+	// The _msg field is stored as an empty name, in the same way as it is stored in LogRows.
+	fieldName string
+
+	// tenantIDs contains tenants to generate metric for. The metric is generated for all the tenants if tenantIDs is empty.
+	tenantIDs []TenantID
+
+	// maxSeries is the maximum number of series the rule can generate.
+	maxSeries int
+
+	// configKey uniquely identifies the rule config. It is used for preserving the state on config reload.
+	configKey string
+
+	state *logMetricsRuleState
+}
+
+type logMetricsRuleState struct {
+	mu sync.Mutex
+
+	// series contains series generated by the rule, keyed by marshaled labels.
+	series map[string]*logMetricsSeries
+}
+
+type logMetricsSeries struct {
+	// labelValues contains values for the corresponding logMetricsRule.labelNames.
+	labelValues []string
+
+	count uint64
+	sum   float64
+
+	// h is non-nil only for histogram() output.
+	h *metrics.Histogram
+}
+
+type logMetricsOutput int
+
+const (
+	logMetricsOutputCount logMetricsOutput = iota
+	logMetricsOutputSum
+	logMetricsOutputHistogram
+)
+
+type logMetricsRuleConfig struct {
+	Metric    string   `yaml:"metric"`
+	Filter    string   `yaml:"filter,omitempty"`
+	By        []string `yaml:"by,omitempty"`
+	Output    string   `yaml:"output,omitempty"`
+	Tenants   []string `yaml:"tenants,omitempty"`
+	MaxSeries int      `yaml:"max_series,omitempty"`
+}
+
+var (
+	metricNameRegexp   = regexp.MustCompile("^[a-zA-Z_:][a-zA-Z0-9_:]*$")
+	invalidLabelChars  = regexp.MustCompile("[^a-zA-Z0-9_]")
+	logMetricsOutputRe = regexp.MustCompile(`^(count|sum|histogram)\s*\(\s*(.*?)\s*\)$`)
+)
+
+// ParseLogMetrics parses log metrics rules from YAML data.
+//
+// The data must contain a list of rules in the following form:
+//
+//   - metric: nginx_requests_total
+//     filter: 'app:=nginx'
+//     by: [host, status]
+//     output: count()
+//
+// The filter must contain LogsQL filter without pipes. All the log entries are matched if the filter is empty.
+// The output can be count(), sum(field) or histogram(field). The default output is count().
+//
+// If prev isn't nil, then the generated metrics are preserved for rules with unchanged configs.
+func ParseLogMetrics(data []byte, prev *LogMetrics) (*LogMetrics, error) {
+	var cfgs []logMetricsRuleConfig
+	if err := yaml.UnmarshalStrict(data, &cfgs); err != nil {
+		return nil, fmt.Errorf("cannot parse log metrics rules: %w", err)
+	}
+
+	prevStates := make(map[string]*logMetricsRuleState)
+	if prev != nil {
+		for _, r := range prev.rules {
+			prevStates[r.configKey] = r.state
+		}
+	}
+
+	lm := &LogMetrics{
+		neededFields: newFieldsSet(),
+	}
+	seenMetrics := make(map[string]struct{}, len(cfgs))
+	for i := range cfgs {
+		cfg := &cfgs[i]
+		r, err := newLogMetricsRule(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse log metrics rule #%d: %w", i+1, err)
+		}
+		if _, ok := seenMetrics[r.metric]; ok {
+			return nil, fmt.Errorf("duplicate `metric: %s` at log metrics rule #%d", r.metric, i+1)
+		}
+		seenMetrics[r.metric] = struct{}{}
+
+		if st := prevStates[r.configKey]; st != nil {
+			r.state = st
+		}
+		r.f.updateNeededFields(lm.neededFields)
+		lm.rules = append(lm.rules, r)
+	}
+	return lm, nil
+}
+
+func newLogMetricsRule(cfg *logMetricsRuleConfig) (*logMetricsRule, error) {
+	if cfg.Metric == "" {
+		return nil, fmt.Errorf("missing `metric` field")
+	}
+	if !metricNameRegexp.MatchString(cfg.Metric) {
+		return nil, fmt.Errorf("invalid `metric: %s`; it must match %s", cfg.Metric, metricNameRegexp)
+	}
+
+	filterStr := cfg.Filter
+	if filterStr == "" {
+		filterStr = "*"
+	}
+	f, err := ParseFilter(filterStr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse `filter: %s`: %w", cfg.Filter, err)
+	}
+
+	byFields := make([]string, len(cfg.By))
+	labelNames := make([]string, len(cfg.By))
+	seenLabels := make(map[string]struct{}, len(cfg.By))
+	for i, fieldName := range cfg.By {
+		if fieldName == "" {
+			return nil, fmt.Errorf("`by` cannot contain empty field names")
+		}
+		labelName := getLogMetricsLabelName(fieldName)
+		if _, ok := seenLabels[labelName]; ok {
+			return nil, fmt.Errorf("`by` field %q results in duplicate label %q", fieldName, labelName)
+		}
+		seenLabels[labelName] = struct{}{}
+		byFields[i] = getRowsFieldName(fieldName)
+		labelNames[i] = labelName
+	}
+
+	output, fieldName, err := parseLogMetricsOutput(cfg.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	var tenantIDs []TenantID
+	for _, s := range cfg.Tenants {
+		tenantID, err := ParseTenantID(s)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse `tenants`: %w", err)
+		}
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+
+	maxSeries := cfg.MaxSeries
+	if maxSeries < 0 {
+		return nil, fmt.Errorf("`max_series` cannot be negative; got %d", maxSeries)
+	}
+	if maxSeries == 0 {
+		maxSeries = defaultLogMetricsMaxSeries
+	}
+
+	configKey, err := yaml.Marshal(cfg)
+	if err != nil {
+		logger.Panicf("BUG: cannot marshal log metrics rule config: %s", err)
+	}
+
+	r := &logMetricsRule{
+		metric:     cfg.Metric,
+		f:          f.f,
+		byFields:   byFields,
+		labelNames: labelNames,
+		output:     output,
+		fieldName:  getRowsFieldName(fieldName),
+		tenantIDs:  tenantIDs,
+		maxSeries:  maxSeries,
+		configKey:  string(configKey),
+		state: &logMetricsRuleState{
+			series: make(map[string]*logMetricsSeries),
+		},
+	}
+	return r, nil
+}
+
+func parseLogMetricsOutput(s string) (logMetricsOutput, string, error) {
+	if s == "" {
+		return logMetricsOutputCount, "", nil
+	}
+	a := logMetricsOutputRe.FindStringSubmatch(strings.TrimSpace(s))
+	if a == nil {
+		return 0, "", fmt.Errorf("unsupported `output: %s`; supported values: count(), sum(field), histogram(field)", s)
+	}
+	funcName, fieldName := a[1], a[2]
+	if funcName == "count" {
+		if fieldName != "" {
+			return 0, "", fmt.Errorf("unexpected arg %q for count() at `output: %s`", fieldName, s)
+		}
+		return logMetricsOutputCount, "", nil
+	}
+	if fieldName == "" {
+		return 0, "", fmt.Errorf("missing field name for %s() at `output: %s`", funcName, s)
+	}
+	if funcName == "sum" {
+		return logMetricsOutputSum, fieldName, nil
+	}
+	return logMetricsOutputHistogram, fieldName, nil
+}
+
+// getRowsFieldName returns the name for the given fieldName as it is stored in LogRows.
+func getRowsFieldName(fieldName string) string {
+	if fieldName == "_msg" {
+		return ""
+	}
+	return fieldName
+}
+
+// getLogMetricsLabelName converts log field name to Prometheus-compatible label name.
+func getLogMetricsLabelName(fieldName string) string {
+	s := invalidLabelChars.ReplaceAllString(fieldName, "_")
+	if s[0] >= '0' && s[0] <= '9' {
+		s = "_" + s
+	}
+	return s
+}
+
+// SetLogMetrics sets log metrics rules for s.
+//
+// Metrics are generated according to lm for the log entries accepted by s. Metrics generation is disabled if lm is nil.
+func (s *Storage) SetLogMetrics(lm *LogMetrics) {
+	s.logMetrics.Store(lm)
+}
+
+// AddRows updates metrics for lr rows matching lm rules.
+func (lm *LogMetrics) AddRows(lr *LogRows) {
+	if lm == nil || len(lm.rules) == 0 || len(lr.timestamps) == 0 {
+		return
+	}
+
+	m := lm.getMatcher()
+	m.init(lr, lm.neededFields)
+
+	bm := getBitmap(len(lr.timestamps))
+	for _, r := range lm.rules {
+		bm.setBits()
+		if len(r.tenantIDs) > 0 {
+			bm.forEachSetBit(func(idx int) bool {
+				return r.hasTenantID(&lr.streamIDs[idx].tenantID)
+			})
+		}
+		if bm.isZero() {
+			continue
+		}
+		r.f.applyToBlockResult(&m.br, bm)
+		if bm.isZero() {
+			continue
+		}
+		r.addMatchingRows(lr, bm, &m.bb)
+	}
+	putBitmap(bm)
+
+	m.reset()
+	lm.matchersPool.Put(m)
+}
+
+func (lm *LogMetrics) getMatcher() *logRowsMatcher {
+	v := lm.matchersPool.Get()
+	if v == nil {
+		return &logRowsMatcher{}
+	}
+	return v.(*logRowsMatcher)
+}
+
+func (r *logMetricsRule) hasTenantID(tenantID *TenantID) bool {
+	for i := range r.tenantIDs {
+		if r.tenantIDs[i].equal(tenantID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *logMetricsRule) addMatchingRows(lr *LogRows, bm *bitmap, bb *bytesutil.ByteBuffer) {
+	rowsDropped := 0
+
+	st := r.state
+	st.mu.Lock()
+	bm.forEachSetBitReadonly(func(idx int) {
+		fields := lr.rows[idx]
+
+		v := float64(0)
+		if r.output != logMetricsOutputCount {
+			f, ok := tryParseNumber(getFieldValue(fields, r.fieldName))
+			if !ok {
+				return
+			}
+			v = f
+		}
+
+		bb.B = r.marshalLabels(bb.B[:0], fields)
+		s := st.series[string(bb.B)]
+		if s == nil {
+			if len(st.series) >= r.maxSeries {
+				rowsDropped++
+				return
+			}
+			s = &logMetricsSeries{
+				labelValues: r.getLabelValues(fields),
+			}
+			if r.output == logMetricsOutputHistogram {
+				s.h = &metrics.Histogram{}
+			}
+			st.series[string(bb.B)] = s
+		}
+
+		s.count++
+		s.sum += v
+		if s.h != nil {
+			s.h.Update(v)
+		}
+	})
+	st.mu.Unlock()
+
+	if rowsDropped > 0 {
+		logMetricsSeriesLimitLogger.Warnf("dropping %d log entries for `metric: %s`, since the number of series exceeds `max_series: %d`; "+
+			"reduce the number of `by` fields or increase `max_series`", rowsDropped, r.metric, r.maxSeries)
+	}
+}
+
+var logMetricsSeriesLimitLogger = logger.WithThrottler("log_metrics_series_limit", 5*time.Second)
+
+// marshalLabels appends Prometheus labels for the given fields to dst without the surrounding curly braces.
+func (r *logMetricsRule) marshalLabels(dst []byte, fields []Field) []byte {
+	for i, fieldName := range r.byFields {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, r.labelNames[i]...)
+		dst = append(dst, '=')
+		dst = appendPrometheusLabelValue(dst, getFieldValue(fields, fieldName))
+	}
+	return dst
+}
+
+// getLabelValues returns label values for the given fields.
+func (r *logMetricsRule) getLabelValues(fields []Field) []string {
+	labelValues := make([]string, len(r.byFields))
+	for i, fieldName := range r.byFields {
+		labelValues[i] = strings.Clone(getFieldValue(fields, fieldName))
+	}
+	return labelValues
+}
+
+func appendPrometheusLabelValue(dst []byte, v string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; c {
+		case '\\':
+			dst = append(dst, `\\`...)
+		case '"':
+			dst = append(dst, `\"`...)
+		case '\n':
+			dst = append(dst, `\n`...)
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '"')
+}
+
+// WritePrometheus writes metrics generated by lm to w in Prometheus text exposition format.
+func (lm *LogMetrics) WritePrometheus(w io.Writer) {
+	if lm == nil {
+		return
+	}
+
+	var bb bytesutil.ByteBuffer
+	for _, r := range lm.rules {
+		bb.B = r.appendPrometheus(bb.B[:0])
+		_, _ = w.Write(bb.B)
+	}
+}
+
+func (r *logMetricsRule) appendPrometheus(dst []byte) []byte {
+	st := r.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	keys := make([]string, 0, len(st.series))
+	for k := range st.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := st.series[k]
+		switch r.output {
+		case logMetricsOutputCount:
+			dst = appendPrometheusLine(dst, r.metric, "", k, "")
+			dst = strconv.AppendUint(dst, s.count, 10)
+			dst = append(dst, '\n')
+		case logMetricsOutputSum:
+			dst = appendPrometheusLine(dst, r.metric, "", k, "")
+			dst = strconv.AppendFloat(dst, s.sum, 'g', -1, 64)
+			dst = append(dst, '\n')
+		case logMetricsOutputHistogram:
+			s.h.VisitNonZeroBuckets(func(vmrange string, count uint64) {
+				dst = appendPrometheusLine(dst, r.metric, "_bucket", k, vmrange)
+				dst = strconv.AppendUint(dst, count, 10)
+				dst = append(dst, '\n')
+			})
+			dst = appendPrometheusLine(dst, r.metric, "_sum", k, "")
+			dst = strconv.AppendFloat(dst, s.sum, 'g', -1, 64)
+			dst = append(dst, '\n')
+			dst = appendPrometheusLine(dst, r.metric, "_count", k, "")
+			dst = strconv.AppendUint(dst, s.count, 10)
+			dst = append(dst, '\n')
+		default:
+			logger.Panicf("BUG: unexpected output type: %d", r.output)
+		}
+	}
+	return dst
+}
+
+// AppendTimeSeries appends metrics generated by lm with the given timestamp in milliseconds to dst and returns the result.
+//
+// extraLabels are added to every generated time series.
+func (lm *LogMetrics) AppendTimeSeries(dst []prompbmarshal.TimeSeries, extraLabels []prompbmarshal.Label, timestamp int64) []prompbmarshal.TimeSeries {
+	if lm == nil {
+		return dst
+	}
+	for _, r := range lm.rules {
+		dst = r.appendTimeSeries(dst, extraLabels, timestamp)
+	}
+	return dst
+}
+
+func (r *logMetricsRule) appendTimeSeries(dst []prompbmarshal.TimeSeries, extraLabels []prompbmarshal.Label, timestamp int64) []prompbmarshal.TimeSeries {
+	st := r.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, s := range st.series {
+		switch r.output {
+		case logMetricsOutputCount:
+			dst = r.appendTimeSeriesSample(dst, "", s.labelValues, extraLabels, "", float64(s.count), timestamp)
+		case logMetricsOutputSum:
+			dst = r.appendTimeSeriesSample(dst, "", s.labelValues, extraLabels, "", s.sum, timestamp)
+		case logMetricsOutputHistogram:
+			s.h.VisitNonZeroBuckets(func(vmrange string, count uint64) {
+				dst = r.appendTimeSeriesSample(dst, "_bucket", s.labelValues, extraLabels, vmrange, float64(count), timestamp)
+			})
+			dst = r.appendTimeSeriesSample(dst, "_sum", s.labelValues, extraLabels, "", s.sum, timestamp)
+			dst = r.appendTimeSeriesSample(dst, "_count", s.labelValues, extraLabels, "", float64(s.count), timestamp)
+		default:
+			logger.Panicf("BUG: unexpected output type: %d", r.output)
+		}
+	}
+	return dst
+}
+
+func (r *logMetricsRule) appendTimeSeriesSample(dst []prompbmarshal.TimeSeries, suffix string, labelValues []string, extraLabels []prompbmarshal.Label,
+	vmrange string, value float64, timestamp int64) []prompbmarshal.TimeSeries {
+	labels := make([]prompbmarshal.Label, 0, 2+len(labelValues)+len(extraLabels))
+	labels = append(labels, prompbmarshal.Label{
+		Name:  "__name__",
+		Value: r.metric + suffix,
+	})
+	for i, v := range labelValues {
+		labels = append(labels, prompbmarshal.Label{
+			Name:  r.labelNames[i],
+			Value: v,
+		})
+	}
+	if vmrange != "" {
+		labels = append(labels, prompbmarshal.Label{
+			Name:  "vmrange",
+			Value: vmrange,
+		})
+	}
+	labels = append(labels, extraLabels...)
+
+	return append(dst, prompbmarshal.TimeSeries{
+		Labels: labels,
+		Samples: []prompbmarshal.Sample{
+			{
+				Value:     value,
+				Timestamp: timestamp,
+			},
+		},
+	})
+}
+
+// appendPrometheusLine appends `metric+suffix{labels,vmrange="..."} ` to dst.
+func appendPrometheusLine(dst []byte, metric, suffix, labels, vmrange string) []byte {
+	dst = append(dst, metric...)
+	dst = append(dst, suffix...)
+	if labels != "" || vmrange != "" {
+		dst = append(dst, '{')
+		dst = append(dst, labels...)
+		if vmrange != "" {
+			if labels != "" {
+				dst = append(dst, ',')
+			}
+			dst = append(dst, `vmrange="`...)
+			dst = append(dst, vmrange...)
+			dst = append(dst, '"')
+		}
+		dst = append(dst, '}')
+	}
+	return append(dst, ' ')
+}
+
+// logRowsMatcher converts LogRows to blockResult, so filters could be applied to it.
+type logRowsMatcher struct {
+	rcs []resultColumn
+	br  blockResult
+
+	// bb is used for marshaling labels.
+	bb bytesutil.ByteBuffer
+}
+
+func (m *logRowsMatcher) reset() {
+	m.br.reset()
+
+	rcs := m.rcs
+	for i := range rcs {
+		rcs[i].reset()
+	}
+	m.rcs = rcs[:0]
+
+	m.bb.Reset()
+}
+
+// init initializes m from lr rows.
+//
+// Only neededFields are added to m.br.
+func (m *logRowsMatcher) init(lr *LogRows, neededFields fieldsSet) {
+	rows := lr.rows
+
+	rcs := m.rcs[:0]
+	columnIdxs := getColumnIdxs()
+	for i, fields := range rows {
+		for _, f := range fields {
+			if !neededFields.contains(f.Name) {
+				continue
+			}
+			idx, ok := columnIdxs[f.Name]
+			if !ok {
+				idx = len(rcs)
+				columnIdxs[f.Name] = idx
+				rcs = appendResultColumnWithName(rcs, getCanonicalColumnName(f.Name))
+			}
+			rc := &rcs[idx]
+			for len(rc.values) < i {
+				rc.addValue("")
+			}
+			rc.addValue(f.Value)
+		}
+	}
+	putColumnIdxs(columnIdxs)
+	for i := range rcs {
+		rc := &rcs[i]
+		for len(rc.values) < len(rows) {
+			rc.addValue("")
+		}
+	}
+
+	if neededFields.contains("_stream") {
+		rcs = appendResultColumnWithName(rcs, "_stream")
+		rc := &rcs[len(rcs)-1]
+		streams := make(map[string]string)
+		for _, streamTagsCanonical := range lr.streamTagsCanonicals {
+			s, ok := streams[string(streamTagsCanonical)]
+			if !ok {
+				s = getStreamTagsString(streamTagsCanonical)
+				streams[string(streamTagsCanonical)] = s
+			}
+			rc.addValue(s)
+		}
+	}
+	if neededFields.contains("_stream_id") {
+		rcs = appendResultColumnWithName(rcs, "_stream_id")
+		rc := &rcs[len(rcs)-1]
+		for i := range lr.streamIDs {
+			rc.addValue(string(lr.streamIDs[i].marshalString(nil)))
+		}
+	}
+	m.rcs = rcs
+
+	br := &m.br
+	br.setResultColumns(rcs, len(rows))
+	br.addTimeColumn()
+	br.timestampsBuf = append(br.timestampsBuf[:0], lr.timestamps...)
+}
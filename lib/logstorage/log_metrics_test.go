@@ -0,0 +1,292 @@
+package logstorage
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+func TestParseLogMetricsFailure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+
+		_, err := ParseLogMetrics([]byte(data), nil)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// invalid yaml
+	f(`foo`)
+
+	// unknown field
+	f(`- metric: foo
+  bar: baz`)
+
+	// missing metric
+	f(`- filter: error`)
+
+	// invalid metric name
+	f(`- metric: foo-bar`)
+
+	// duplicate metric
+	f(`- metric: foo
+- metric: foo
+  filter: error`)
+
+	// invalid filter
+	f(`- metric: foo
+  filter: 'foo:('`)
+
+	// filter with pipes
+	f(`- metric: foo
+  filter: 'error | count()'`)
+
+	// duplicate labels
+	f(`- metric: foo
+  by: [a.b, a_b]`)
+
+	// invalid output
+	f(`- metric: foo
+  output: max(x)`)
+	f(`- metric: foo
+  output: sum()`)
+	f(`- metric: foo
+  output: count(x)`)
+
+	// invalid tenant
+	f(`- metric: foo
+  tenants: [bar]`)
+
+	// negative max_series
+	f(`- metric: foo
+  max_series: -1`)
+}
+
+func TestLogMetrics(t *testing.T) {
+	data := `
+- metric: requests_total
+  filter: 'app:=nginx'
+  by: [host, status]
+- metric: errors_total
+  filter: 'error'
+  by: [kubernetes.pod]
+  tenants: ["0:0"]
+- metric: bytes_sent_total
+  filter: '{app="nginx"}'
+  output: sum(bytes)
+- metric: request_duration_seconds
+  filter: 'app:=nginx status:>=500'
+  output: histogram(duration)
+- metric: limited_total
+  by: [host]
+  max_series: 1
+`
+	lm, err := ParseLogMetrics([]byte(data), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	addRows := func(lm *LogMetrics) {
+		lr := GetLogRows([]string{"app"}, nil, nil, "")
+		defer PutLogRows(lr)
+
+		tenantID := TenantID{}
+		lr.MustAdd(tenantID, 1, []Field{
+			{Name: "app", Value: "nginx"},
+			{Name: "host", Value: "h1"},
+			{Name: "status", Value: "200"},
+			{Name: "bytes", Value: "100"},
+			{Name: "_msg", Value: "GET /"},
+		}, nil)
+		lr.MustAdd(tenantID, 2, []Field{
+			{Name: "app", Value: "nginx"},
+			{Name: "host", Value: "h1"},
+			{Name: "status", Value: "200"},
+			{Name: "bytes", Value: "1KB"},
+			{Name: "_msg", Value: "GET /foo"},
+		}, nil)
+		lr.MustAdd(tenantID, 3, []Field{
+			{Name: "app", Value: "nginx"},
+			{Name: "host", Value: `h"2`},
+			{Name: "status", Value: "503"},
+			{Name: "duration", Value: "0.5"},
+			{Name: "_msg", Value: "error: GET /bar"},
+		}, nil)
+		lr.MustAdd(tenantID, 4, []Field{
+			{Name: "app", Value: "api"},
+			{Name: "kubernetes.pod", Value: "pod1"},
+			{Name: "_msg", Value: "unexpected error"},
+		}, nil)
+		lr.MustAdd(TenantID{AccountID: 1}, 5, []Field{
+			{Name: "app", Value: "api"},
+			{Name: "kubernetes.pod", Value: "pod2"},
+			{Name: "_msg", Value: "unexpected error"},
+		}, nil)
+
+		lm.AddRows(lr)
+	}
+
+	f := func(lm *LogMetrics, resultExpected string) {
+		t.Helper()
+
+		var bb bytes.Buffer
+		lm.WritePrometheus(&bb)
+		if result := bb.String(); result != resultExpected {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+
+	addRows(lm)
+	f(lm, `requests_total{host="h1",status="200"} 2
+requests_total{host="h\"2",status="503"} 1
+errors_total{kubernetes_pod=""} 1
+errors_total{kubernetes_pod="pod1"} 1
+bytes_sent_total 1100
+request_duration_seconds_bucket{vmrange="4.642e-01...5.275e-01"} 1
+request_duration_seconds_sum 0.5
+request_duration_seconds_count 1
+limited_total{host="h1"} 2
+`)
+
+	// Reload the config with changed and unchanged rules. The state must be preserved only for unchanged rules.
+	data = `
+- metric: requests_total
+  filter: 'app:=nginx'
+  by: [host, status]
+- metric: errors_total
+  filter: 'error'
+  by: [kubernetes.pod]
+  tenants: ["0:0", "1:0"]
+`
+	lm, err = ParseLogMetrics([]byte(data), lm)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addRows(lm)
+	f(lm, `requests_total{host="h1",status="200"} 4
+requests_total{host="h\"2",status="503"} 2
+errors_total{kubernetes_pod=""} 1
+errors_total{kubernetes_pod="pod1"} 1
+errors_total{kubernetes_pod="pod2"} 1
+`)
+}
+
+func TestLogMetricsAppendTimeSeries(t *testing.T) {
+	data := `
+- metric: requests_total
+  by: [host]
+- metric: request_duration_seconds
+  output: histogram(duration)
+`
+	lm, err := ParseLogMetrics([]byte(data), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	lr := GetLogRows(nil, nil, nil, "")
+	lr.MustAdd(TenantID{}, 1, []Field{
+		{Name: "host", Value: `h"1`},
+		{Name: "duration", Value: "0.5"},
+	}, nil)
+	lr.MustAdd(TenantID{}, 2, []Field{
+		{Name: "host", Value: "h2"},
+	}, nil)
+	lm.AddRows(lr)
+	PutLogRows(lr)
+
+	extraLabels := []prompbmarshal.Label{
+		{
+			Name:  "instance",
+			Value: "foo",
+		},
+	}
+	tss := lm.AppendTimeSeries(nil, extraLabels, 123)
+
+	a := make([]string, len(tss))
+	for i, ts := range tss {
+		labels := make([]string, len(ts.Labels))
+		for j, label := range ts.Labels {
+			labels[j] = fmt.Sprintf("%s=%q", label.Name, label.Value)
+		}
+		if len(ts.Samples) != 1 {
+			t.Fatalf("unexpected number of samples for %s; got %d; want 1", labels, len(ts.Samples))
+		}
+		sample := ts.Samples[0]
+		a[i] = fmt.Sprintf("{%s} %v %d", strings.Join(labels, ","), sample.Value, sample.Timestamp)
+	}
+	sort.Strings(a)
+	result := strings.Join(a, "\n")
+	resultExpected := `{__name__="request_duration_seconds_bucket",vmrange="4.642e-01...5.275e-01",instance="foo"} 1 123
+{__name__="request_duration_seconds_count",instance="foo"} 1 123
+{__name__="request_duration_seconds_sum",instance="foo"} 0.5 123
+{__name__="requests_total",host="h2",instance="foo"} 1 123
+{__name__="requests_total",host="h\"1",instance="foo"} 1 123`
+	if result != resultExpected {
+		t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+}
+
+func TestStorageLogMetricsAcceptedRowsOnly(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	tl, err := ParseTenantLimits([]byte(`
+- tenant: "1:2"
+  max_bytes_per_day: 1KiB
+`))
+	if err != nil {
+		t.Fatalf("cannot parse tenant limits: %s", err)
+	}
+	s := MustOpenStorage(path, &StorageConfig{
+		TenantLimits: tl,
+	})
+
+	lm, err := ParseLogMetrics([]byte(`
+- metric: rows_total
+  tenants: ["1:2"]
+`), nil)
+	if err != nil {
+		t.Fatalf("cannot parse log metrics: %s", err)
+	}
+	s.SetLogMetrics(lm)
+
+	tenantID := TenantID{AccountID: 1, ProjectID: 2}
+	const rowsCount = 100
+	now := time.Now().UnixNano()
+	lr := GetLogRows(nil, nil, nil, "")
+	for i := 0; i < rowsCount; i++ {
+		fields := []Field{
+			{
+				Name:  "_msg",
+				Value: fmt.Sprintf("some log message number %d", i),
+			},
+		}
+		lr.MustAdd(tenantID, now+int64(i), fields, nil)
+	}
+	s.MustAddRows(lr)
+	PutLogRows(lr)
+	s.debugFlush()
+
+	// Metrics must be generated only for the rows accepted by the storage.
+	n := testCountRows(t, s, tenantID)
+	if n == 0 || n >= rowsCount {
+		t.Fatalf("unexpected number of stored rows; got %d; want (0 ... %d)", n, rowsCount)
+	}
+	var bb bytes.Buffer
+	lm.WritePrometheus(&bb)
+	resultExpected := fmt.Sprintf("rows_total %d\n", n)
+	if result := bb.String(); result != resultExpected {
+		t.Fatalf("unexpected metrics;\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
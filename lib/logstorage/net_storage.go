@@ -0,0 +1,298 @@
+package logstorage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/slicesutil"
+)
+
+// DataBlock is a block of query results, which is transferred from storage nodes to vlselect in cluster mode.
+type DataBlock struct {
+	// Timestamps contains timestamps for the rows in the block.
+	Timestamps []int64
+
+	// Columns contains the block columns. Every column must contain len(Timestamps) values.
+	Columns []BlockColumn
+}
+
+// Reset resets db to zero value.
+func (db *DataBlock) Reset() {
+	db.Timestamps = db.Timestamps[:0]
+
+	cs := db.Columns
+	for i := range cs {
+		cs[i].reset()
+	}
+	db.Columns = cs[:0]
+}
+
+// RowsCount returns the number of rows in db.
+func (db *DataBlock) RowsCount() int {
+	return len(db.Timestamps)
+}
+
+// Marshal appends marshaled db to dst and returns the result.
+func (db *DataBlock) Marshal(dst []byte) []byte {
+	dst = encoding.MarshalVarUint64(dst, uint64(len(db.Timestamps)))
+	prevTimestamp := int64(0)
+	for _, ts := range db.Timestamps {
+		dst = encoding.MarshalVarInt64(dst, ts-prevTimestamp)
+		prevTimestamp = ts
+	}
+
+	dst = encoding.MarshalVarUint64(dst, uint64(len(db.Columns)))
+	for i := range db.Columns {
+		c := &db.Columns[i]
+		dst = encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(c.Name))
+		if areConstValues(c.Values) {
+			// Marshal constant column as a single value in order to reduce network bandwidth usage.
+			dst = append(dst, 1)
+			dst = encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(c.Values[0]))
+			continue
+		}
+		dst = append(dst, 0)
+		for _, v := range c.Values {
+			dst = encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(v))
+		}
+	}
+	return dst
+}
+
+// UnmarshalInplace unmarshals db from src and returns the remaining tail.
+//
+// valuesBuf is used as a buffer for column values. The updated buffer is returned, so it could be reused.
+//
+// db refers to src, so src must remain unchanged while db is in use.
+func (db *DataBlock) UnmarshalInplace(src []byte, valuesBuf []string) ([]byte, []string, error) {
+	db.Reset()
+
+	rowsCount, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return src, valuesBuf, fmt.Errorf("cannot unmarshal the number of rows")
+	}
+	src = src[nSize:]
+	if rowsCount > uint64(len(src)) {
+		return src, valuesBuf, fmt.Errorf("too big number of rows: %d; cannot exceed %d", rowsCount, len(src))
+	}
+
+	db.Timestamps = slicesutil.SetLength(db.Timestamps, int(rowsCount))
+	prevTimestamp := int64(0)
+	for i := range db.Timestamps {
+		delta, nSize := encoding.UnmarshalVarInt64(src)
+		if nSize <= 0 {
+			return src, valuesBuf, fmt.Errorf("cannot unmarshal timestamp #%d", i)
+		}
+		src = src[nSize:]
+		prevTimestamp += delta
+		db.Timestamps[i] = prevTimestamp
+	}
+
+	columnsCount, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return src, valuesBuf, fmt.Errorf("cannot unmarshal the number of columns")
+	}
+	src = src[nSize:]
+	if columnsCount > uint64(len(src)) {
+		return src, valuesBuf, fmt.Errorf("too big number of columns: %d; cannot exceed %d", columnsCount, len(src))
+	}
+
+	db.Columns = slicesutil.SetLength(db.Columns, int(columnsCount))
+	for i := range db.Columns {
+		c := &db.Columns[i]
+
+		name, nSize := encoding.UnmarshalBytes(src)
+		if nSize <= 0 {
+			return src, valuesBuf, fmt.Errorf("cannot unmarshal name for column #%d", i)
+		}
+		src = src[nSize:]
+		c.Name = bytesutil.ToUnsafeString(name)
+
+		if len(src) < 1 {
+			return src, valuesBuf, fmt.Errorf("cannot unmarshal type for column %q", c.Name)
+		}
+		isConst := src[0] == 1
+		src = src[1:]
+
+		valuesBufLen := len(valuesBuf)
+		if isConst {
+			v, nSize := encoding.UnmarshalBytes(src)
+			if nSize <= 0 {
+				return src, valuesBuf, fmt.Errorf("cannot unmarshal constant value for column %q", c.Name)
+			}
+			src = src[nSize:]
+			s := bytesutil.ToUnsafeString(v)
+			for j := uint64(0); j < rowsCount; j++ {
+				valuesBuf = append(valuesBuf, s)
+			}
+		} else {
+			for j := uint64(0); j < rowsCount; j++ {
+				v, nSize := encoding.UnmarshalBytes(src)
+				if nSize <= 0 {
+					return src, valuesBuf, fmt.Errorf("cannot unmarshal value #%d for column %q", j, c.Name)
+				}
+				src = src[nSize:]
+				valuesBuf = append(valuesBuf, bytesutil.ToUnsafeString(v))
+			}
+		}
+		c.Values = valuesBuf[valuesBufLen:]
+	}
+
+	return src, valuesBuf, nil
+}
+
+// WriteDataBlockFunc must process the given db.
+//
+// WriteDataBlockFunc cannot hold references to db after returning.
+type WriteDataBlockFunc func(workerID uint, db *DataBlock)
+
+// RunNetQueryFunc must run q at all the storage nodes and call writeBlock for the returned data blocks.
+//
+// writeBlock must be called with workerID smaller than the workersCount passed to NewNetStorage.
+// writeBlock cannot be called concurrently with the same workerID.
+type RunNetQueryFunc func(ctx context.Context, tenantIDs []TenantID, q *Query, writeBlock WriteDataBlockFunc) error
+
+// NetStorage executes queries over storage nodes in cluster mode.
+//
+// It sends the query part, which can be executed at storage nodes, via RunNetQueryFunc
+// and then merges the results returned from all the storage nodes at the current node.
+type NetStorage struct {
+	workersCount int
+	runNetQuery  RunNetQueryFunc
+}
+
+// NewNetStorage returns new NetStorage, which executes queries via runNetQuery.
+//
+// workersCount is the maximum number of concurrent workers, which can be used by runNetQuery.
+func NewNetStorage(workersCount int, runNetQuery RunNetQueryFunc) *NetStorage {
+	return &NetStorage{
+		workersCount: workersCount,
+		runNetQuery:  runNetQuery,
+	}
+}
+
+// RunQuery runs the given q and calls writeBlock for results.
+func (ns *NetStorage) RunQuery(ctx context.Context, tenantIDs []TenantID, q *Query, writeBlock WriteBlockFunc) error {
+	writeBlockResult := newWriteBlockResultFunc(writeBlock)
+	return ns.runQuery(ctx, tenantIDs, q, writeBlockResult)
+}
+
+func (ns *NetStorage) runQuery(ctx context.Context, tenantIDs []TenantID, q *Query, writeBlockResultFunc func(workerID uint, br *blockResult)) error {
+	qNew, err := initFilterInValues(ctx, ns.runQuery, tenantIDs, q)
+	if err != nil {
+		return err
+	}
+
+	qRemote, pipesLocal := qNew.splitToRemoteAndLocal()
+
+	qLocal := *qNew
+	qLocal.pipes = pipesLocal
+	qNew, err = initJoinMaps(ctx, ns.runQuery, tenantIDs, &qLocal)
+	if err != nil {
+		return err
+	}
+	qNew, err = initUnionQueries(ns.runQuery, tenantIDs, qNew)
+	if err != nil {
+		return err
+	}
+	pipesLocal = qNew.pipes
+
+	ctxOrig := ctx
+	ppMain := newDefaultPipeProcessor(writeBlockResultFunc)
+	pp := ppMain
+	stopCh := ctx.Done()
+	cancels := make([]func(), len(pipesLocal))
+	pps := make([]pipeProcessor, len(pipesLocal))
+	for i := len(pipesLocal) - 1; i >= 0; i-- {
+		p := pipesLocal[i]
+		ctxChild, cancel := context.WithCancel(ctx)
+		pp = p.newPipeProcessor(ns.workersCount, stopCh, cancel, pp)
+
+		stopCh = ctxChild.Done()
+		ctx = ctxChild
+
+		cancels[i] = cancel
+		pps[i] = pp
+	}
+
+	writeDataBlock := func(workerID uint, db *DataBlock) {
+		if db.RowsCount() == 0 || needStop(stopCh) {
+			return
+		}
+
+		rcs := make([]resultColumn, len(db.Columns))
+		for i := range db.Columns {
+			c := &db.Columns[i]
+			rcs[i].name = c.Name
+			rcs[i].values = c.Values
+		}
+
+		var br blockResult
+		br.setResultColumns(rcs, db.RowsCount())
+		br.timestampsBuf = append(br.timestampsBuf[:0], db.Timestamps...)
+		pp.writeBlock(workerID, &br)
+	}
+
+	errRemote := ns.runNetQuery(ctx, tenantIDs, qRemote, writeDataBlock)
+	if errRemote != nil && needStop(stopCh) && ctxOrig.Err() == nil {
+		// The remote query has been canceled by some of the local pipes such as `limit`.
+		errRemote = nil
+	}
+
+	var errFlush error
+	for i, pp := range pps {
+		if err := pp.flush(); err != nil && errFlush == nil {
+			errFlush = err
+		}
+		cancel := cancels[i]
+		cancel()
+	}
+	if err := ppMain.flush(); err != nil && errFlush == nil {
+		errFlush = err
+	}
+
+	if errRemote != nil {
+		return errRemote
+	}
+	return errFlush
+}
+
+// GetFieldNames returns field names from q results for the given tenantIDs.
+func (ns *NetStorage) GetFieldNames(ctx context.Context, tenantIDs []TenantID, q *Query) ([]ValueWithHits, error) {
+	return getFieldNames(ctx, ns.runQuery, tenantIDs, q)
+}
+
+// GetFieldValues returns unique values with the number of hits for the given fieldName returned by q for the given tenantIDs.
+//
+// If limit > 0, then up to limit unique values are returned.
+func (ns *NetStorage) GetFieldValues(ctx context.Context, tenantIDs []TenantID, q *Query, fieldName string, limit uint64) ([]ValueWithHits, error) {
+	return getFieldValues(ctx, ns.runQuery, tenantIDs, q, fieldName, limit)
+}
+
+// GetStreamFieldNames returns stream field names from q results for the given tenantIDs.
+func (ns *NetStorage) GetStreamFieldNames(ctx context.Context, tenantIDs []TenantID, q *Query) ([]ValueWithHits, error) {
+	return getStreamFieldNames(ctx, ns.runQuery, tenantIDs, q)
+}
+
+// GetStreamFieldValues returns stream field values for the given fieldName from q results for the given tenantIDs.
+//
+// If limit > 0, then up to limit unique values are returned.
+func (ns *NetStorage) GetStreamFieldValues(ctx context.Context, tenantIDs []TenantID, q *Query, fieldName string, limit uint64) ([]ValueWithHits, error) {
+	return getStreamFieldValues(ctx, ns.runQuery, tenantIDs, q, fieldName, limit)
+}
+
+// GetStreams returns streams from q results for the given tenantIDs.
+//
+// If limit > 0, then up to limit unique streams are returned.
+func (ns *NetStorage) GetStreams(ctx context.Context, tenantIDs []TenantID, q *Query, limit uint64) ([]ValueWithHits, error) {
+	return ns.GetFieldValues(ctx, tenantIDs, q, "_stream", limit)
+}
+
+// GetStreamIDs returns stream_id field values from q results for the given tenantIDs.
+//
+// If limit > 0, then up to limit unique streams are returned.
+func (ns *NetStorage) GetStreamIDs(ctx context.Context, tenantIDs []TenantID, q *Query, limit uint64) ([]ValueWithHits, error) {
+	return ns.GetFieldValues(ctx, tenantIDs, q, "_stream_id", limit)
+}
@@ -0,0 +1,361 @@
+package logstorage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+)
+
+func TestDataBlockMarshalUnmarshal(t *testing.T) {
+	f := func(db *DataBlock) {
+		t.Helper()
+
+		data := db.Marshal(nil)
+
+		var db2 DataBlock
+		tail, _, err := db2.UnmarshalInplace(data, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(tail) > 0 {
+			t.Fatalf("unexpected non-empty tail left after unmarshaling: %X", tail)
+		}
+		if db2.RowsCount() != db.RowsCount() {
+			t.Fatalf("unexpected number of rows; got %d; want %d", db2.RowsCount(), db.RowsCount())
+		}
+		if len(db.Timestamps) > 0 && !reflect.DeepEqual(db.Timestamps, db2.Timestamps) {
+			t.Fatalf("unexpected timestamps\ngot\n%v\nwant\n%v", db2.Timestamps, db.Timestamps)
+		}
+		if len(db.Columns) > 0 && !reflect.DeepEqual(db.Columns, db2.Columns) {
+			t.Fatalf("unexpected columns\ngot\n%v\nwant\n%v", db2.Columns, db.Columns)
+		}
+	}
+
+	f(&DataBlock{})
+	f(&DataBlock{
+		Timestamps: []int64{123, 100, 1e18},
+	})
+	f(&DataBlock{
+		Timestamps: []int64{-10, 20},
+		Columns: []BlockColumn{
+			{
+				Name:   "foo",
+				Values: []string{"a", "b"},
+			},
+			{
+				Name:   "",
+				Values: []string{"const", "const"},
+			},
+			{
+				Name:   "bar",
+				Values: []string{"", ""},
+			},
+		},
+	})
+}
+
+func TestInsertRowMarshalUnmarshal(t *testing.T) {
+	f := func(r *InsertRow) {
+		t.Helper()
+
+		data := r.Marshal(nil)
+
+		var r2 InsertRow
+		tail, err := r2.UnmarshalInplace(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(tail) > 0 {
+			t.Fatalf("unexpected non-empty tail left after unmarshaling: %X", tail)
+		}
+		if len(r.Fields) == 0 {
+			r2.Fields = nil
+		}
+		if !reflect.DeepEqual(r, &r2) {
+			t.Fatalf("unexpected row\ngot\n%v\nwant\n%v", &r2, r)
+		}
+	}
+
+	f(&InsertRow{})
+	f(&InsertRow{
+		TenantID: TenantID{
+			AccountID: 123,
+			ProjectID: 456,
+		},
+		StreamTagsCanonical: "foobar",
+		Timestamp:           -1234,
+		Fields: []Field{
+			{
+				Name:  "",
+				Value: "some message",
+			},
+			{
+				Name:  "foo",
+				Value: "bar",
+			},
+		},
+	})
+}
+
+func TestNetStorageRunQuery(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	const nodesCount = 3
+	const streamsCount = 10
+	const rowsPerStream = 20
+
+	sc := &StorageConfig{
+		Retention: 24 * time.Hour,
+	}
+	sRef := MustOpenStorage(filepath.Join(path, "ref"), sc)
+	nodesRF1 := make([]*Storage, nodesCount)
+	nodesRF2 := make([]*Storage, nodesCount)
+	for i := 0; i < nodesCount; i++ {
+		nodesRF1[i] = MustOpenStorage(filepath.Join(path, fmt.Sprintf("rf1-%d", i)), sc)
+		nodesRF2[i] = MustOpenStorage(filepath.Join(path, fmt.Sprintf("rf2-%d", i)), sc)
+	}
+
+	// fill the storages with data
+	tenantID := TenantID{
+		AccountID: 12,
+		ProjectID: 34,
+	}
+	tenantIDs := []TenantID{tenantID}
+	baseTimestamp := time.Now().UnixNano() - 3600*1e9
+	lr := GetLogRows([]string{"instance"}, nil, nil, "")
+	for i := 0; i < streamsCount; i++ {
+		for j := 0; j < rowsPerStream; j++ {
+			n := i*rowsPerStream + j
+			fields := []Field{
+				{
+					Name:  "instance",
+					Value: fmt.Sprintf("host-%d", i),
+				},
+				{
+					Name:  "_msg",
+					Value: fmt.Sprintf("message %d", j%7),
+				},
+				{
+					Name:  "n",
+					Value: fmt.Sprintf("%d", n),
+				},
+			}
+			lr.MustAdd(tenantID, baseTimestamp+int64(n)*1e9, fields, nil)
+		}
+	}
+	sRef.MustAddRows(lr)
+	sRef.debugFlush()
+
+	addRowsToNodes := func(nodes []*Storage, replicationFactor int) {
+		lrs := make([]*LogRows, len(nodes))
+		for i := range lrs {
+			lrs[i] = GetLogRows(nil, nil, nil, "")
+		}
+		var buf []byte
+		var r InsertRow
+		lr.ForEachRow(func(streamHash uint64, row *InsertRow) {
+			buf = row.Marshal(buf[:0])
+			if _, err := r.UnmarshalInplace(buf); err != nil {
+				t.Fatalf("cannot unmarshal row: %s", err)
+			}
+			idx := GetStreamNodeIdx(streamHash, len(nodes))
+			for i := 0; i < replicationFactor; i++ {
+				lrs[idx].MustAddInsertRow(&r)
+				idx = (idx + 1) % len(nodes)
+			}
+		})
+		for i, s := range nodes {
+			s.MustAddRows(lrs[i])
+			s.debugFlush()
+			PutLogRows(lrs[i])
+		}
+	}
+	addRowsToNodes(nodesRF1, 1)
+	addRowsToNodes(nodesRF2, 2)
+	PutLogRows(lr)
+
+	newNetStorage := func(nodes []*Storage, replicationFactor int, unavailableNodeIdxs []int) *NetStorage {
+		runNetQuery := func(ctx context.Context, tenantIDs []TenantID, q *Query, writeBlock WriteDataBlockFunc) error {
+			qStr := q.String()
+			var wg sync.WaitGroup
+			errs := make([]error, len(nodes))
+			for i, s := range nodes {
+				if slices.Contains(unavailableNodeIdxs, i) {
+					continue
+				}
+				wg.Add(1)
+				go func(nodeIdx int, s *Storage) {
+					defer wg.Done()
+
+					// Pass the query to the node in the same way as it is passed over the network.
+					q, err := ParseQueryAtTimestamp(qStr, q.GetTimestamp())
+					if err != nil {
+						errs[nodeIdx] = err
+						return
+					}
+					ss := &StreamSharding{
+						NodeIdx:             nodeIdx,
+						NodesCount:          len(nodes),
+						ReplicationFactor:   replicationFactor,
+						UnavailableNodeIdxs: unavailableNodeIdxs,
+					}
+					var db DataBlock
+					var data []byte
+					var valuesBuf []string
+					errs[nodeIdx] = s.RunRemoteQuery(ctx, tenantIDs, q, ss, func(_ uint, timestamps []int64, columns []BlockColumn) {
+						dbSrc := DataBlock{
+							Timestamps: timestamps,
+							Columns:    columns,
+						}
+						data = dbSrc.Marshal(data[:0])
+						var err error
+						if _, valuesBuf, err = db.UnmarshalInplace(data, valuesBuf[:0]); err != nil {
+							panic(fmt.Errorf("cannot unmarshal data block: %w", err))
+						}
+						writeBlock(uint(nodeIdx), &db)
+					})
+				}(i, s)
+			}
+			wg.Wait()
+			for _, err := range errs {
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return NewNetStorage(len(nodes), runNetQuery)
+	}
+
+	getRows := func(runQuery func(ctx context.Context, tenantIDs []TenantID, q *Query, writeBlock WriteBlockFunc) error, qStr string, timestamp int64) []string {
+		t.Helper()
+
+		q, err := ParseQueryAtTimestamp(qStr, timestamp)
+		if err != nil {
+			t.Fatalf("cannot parse query [%s]: %s", qStr, err)
+		}
+
+		var rows []string
+		var rowsLock sync.Mutex
+		writeBlock := func(_ uint, timestamps []int64, columns []BlockColumn) {
+			rowsLock.Lock()
+			defer rowsLock.Unlock()
+			for i := range timestamps {
+				fields := make([]string, 0, len(columns))
+				for _, c := range columns {
+					fields = append(fields, fmt.Sprintf("%s=%q", c.Name, c.Values[i]))
+				}
+				sort.Strings(fields)
+				rows = append(rows, strings.Join(fields, ","))
+			}
+		}
+		if err := runQuery(context.Background(), tenantIDs, q, writeBlock); err != nil {
+			t.Fatalf("unexpected error when running query [%s]: %s", qStr, err)
+		}
+		sort.Strings(rows)
+		return rows
+	}
+
+	netStorages := map[string]*NetStorage{
+		"rf1":             newNetStorage(nodesRF1, 1, nil),
+		"rf2":             newNetStorage(nodesRF2, 2, nil),
+		"rf2-unavailable": newNetStorage(nodesRF2, 2, []int{1}),
+	}
+
+	f := func(qStr string) {
+		t.Helper()
+
+		timestamp := time.Now().UnixNano()
+		rowsExpected := getRows(sRef.RunQuery, qStr, timestamp)
+		if len(rowsExpected) == 0 {
+			t.Fatalf("the query [%s] must return non-empty results", qStr)
+		}
+		for name, ns := range netStorages {
+			rows := getRows(ns.RunQuery, qStr, timestamp)
+			if !reflect.DeepEqual(rows, rowsExpected) {
+				t.Fatalf("unexpected rows for the query [%s] at %s\ngot\n%s\nwant\n%s", qStr, name, strings.Join(rows, "\n"), strings.Join(rowsExpected, "\n"))
+			}
+		}
+	}
+
+	f(`*`)
+	f(`_time:2h message | fields instance, n, _msg`)
+	f(`* | stats count() rows`)
+	f(`* | stats by (instance) count() rows, sum(n) sum_n, avg(n) avg_n, min(n) min_n, max(n) max_n, count_empty(foo) empty, sum_len(n) len_n`)
+	f(`* | stats by (_msg) count_uniq(instance) uniqs, count_uniq_hash(n) hashes, uniq_values(instance) instances, median(n) median_n, quantile(0.9, n) q90`)
+	f(`* | stats by (instance) row_min(n, _msg) rmin, row_max(n) rmax, histogram(n) hist, count() if (_msg:"message 3") x`)
+	f(`* | stats by (_msg) count() rows | stats count() groups, sum(rows) total`)
+	f(`* | sort by (n desc) limit 5`)
+	f(`* | sort by (n) offset 7 limit 4 | fields n`)
+	f(`* | first 3 by (n)`)
+	f(`* | last 2 by (n) rank as r`)
+	f(`* | top 3 by (_msg)`)
+	f(`* | patterns | fields pattern, hits`)
+	f(`* | patterns at instance limit 3 | fields pattern, hits`)
+	f(`* | uniq by (instance) with hits`)
+	f(`* | uniq by (_msg) limit 100`)
+	f(`* | field_values instance`)
+	f(`* | field_names`)
+	f(`* | limit 1000 | stats count() rows`)
+	f(`n:in(instance:host-3 | fields n) | fields n`)
+	f(`* | join by (instance) (instance:host-2 | stats by (instance) count() host2_rows) | filter host2_rows:* | stats count() rows`)
+	f(`* | union (instance:host-5 | limit 2 | fields instance) | stats count() rows`)
+	f(`_msg:"message 2" | stream_context before 1 | fields n`)
+
+	// Verify Get* functions
+	ctx := context.Background()
+	q := mustParseQuery(`*`)
+	namesExpected, err := sRef.GetFieldNames(ctx, tenantIDs, q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	streamsExpected, err := sRef.GetStreams(ctx, tenantIDs, q, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	valuesExpected, err := sRef.GetStreamFieldValues(ctx, tenantIDs, q, "instance", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for name, ns := range netStorages {
+		names, err := ns.GetFieldNames(ctx, tenantIDs, q)
+		if err != nil {
+			t.Fatalf("unexpected error at %s: %s", name, err)
+		}
+		if !reflect.DeepEqual(names, namesExpected) {
+			t.Fatalf("unexpected field names at %s\ngot\n%v\nwant\n%v", name, names, namesExpected)
+		}
+		streams, err := ns.GetStreams(ctx, tenantIDs, q, 100)
+		if err != nil {
+			t.Fatalf("unexpected error at %s: %s", name, err)
+		}
+		if !reflect.DeepEqual(streams, streamsExpected) {
+			t.Fatalf("unexpected streams at %s\ngot\n%v\nwant\n%v", name, streams, streamsExpected)
+		}
+		values, err := ns.GetStreamFieldValues(ctx, tenantIDs, q, "instance", 0)
+		if err != nil {
+			t.Fatalf("unexpected error at %s: %s", name, err)
+		}
+		if !reflect.DeepEqual(values, valuesExpected) {
+			t.Fatalf("unexpected stream field values at %s\ngot\n%v\nwant\n%v", name, values, valuesExpected)
+		}
+	}
+
+	sRef.MustClose()
+	for i := 0; i < nodesCount; i++ {
+		nodesRF1[i].MustClose()
+		nodesRF2[i].MustClose()
+	}
+	fs.MustRemoveAll(path)
+}
@@ -39,6 +39,9 @@ type lexer struct {
 	// currentTimestamp is the current timestamp in nanoseconds
 	currentTimestamp int64
 
+	// isTimestampDependent is set to true if the parsed query depends on currentTimestamp, e.g. it contains _time:1h filter
+	isTimestampDependent bool
+
 	// opts is a stack of options for nested parsed queries
 	optss []*queryOptions
 }
@@ -92,6 +95,14 @@ func newLexer(s string, timestamp int64) *lexer {
 	return lex
 }
 
+// getCurrentTimestamp returns the current timestamp for parsing relative timestamps.
+//
+// It marks the parsed query as dependent on the current timestamp.
+func (lex *lexer) getCurrentTimestamp() int64 {
+	lex.isTimestampDependent = true
+	return lex.currentTimestamp
+}
+
 func (lex *lexer) isEnd() bool {
 	return len(lex.s) == 0 && len(lex.token) == 0 && len(lex.rawToken) == 0
 }
@@ -252,6 +263,9 @@ type Query struct {
 
 	// timestamp is the timestamp context used for parsing the query.
 	timestamp int64
+
+	// isTimestampDependent is set to true if the query results depend on the timestamp context, e.g. if the query contains _time:1h filter.
+	isTimestampDependent bool
 }
 
 type queryOptions struct {
@@ -307,6 +321,63 @@ func (q *Query) CanLiveTail() bool {
 	return true
 }
 
+// splitToRemoteAndLocal splits q into the query for execution at storage nodes and the pipes for execution at vlselect in cluster mode.
+//
+// The returned local pipes must be applied to the merged results of the remote query from all the storage nodes.
+func (q *Query) splitToRemoteAndLocal() (*Query, []pipe) {
+	var pipesRemote, pipesLocal []pipe
+	for i, p := range q.pipes {
+		pRemote, psLocal := p.splitToRemoteAndLocal(q.timestamp)
+		if pRemote != nil {
+			pipesRemote = append(pipesRemote, pRemote)
+		}
+		if len(psLocal) > 0 {
+			pipesLocal = append(pipesLocal, psLocal...)
+			pipesLocal = append(pipesLocal, q.pipes[i+1:]...)
+			break
+		}
+	}
+
+	qRemote := *q
+	qRemote.pipes = pipesRemote
+	return &qRemote, pipesLocal
+}
+
+// CanSplitByTime returns true if q results on the given time range can be obtained by merging q results on adjacent time ranges,
+// which are aligned to `_time` buckets at the last `stats` pipe.
+//
+// This is the case if q doesn't depend on the current timestamp, doesn't contain subqueries
+// and all the pipes before the last `stats` pipe process every log entry independently.
+func (q *Query) CanSplitByTime() bool {
+	if q.isTimestampDependent {
+		return false
+	}
+	if q.opts != nil && q.opts.ignoreGlobalTimeFilter != nil && *q.opts.ignoreGlobalTimeFilter {
+		return false
+	}
+
+	hasSubqueries := false
+	q.visitSubqueries(func(qSub *Query) {
+		if qSub != q {
+			hasSubqueries = true
+		}
+	})
+	if hasSubqueries {
+		return false
+	}
+
+	pipes := q.pipes
+	if idx := getLastPipeStatsIdx(pipes); idx >= 0 {
+		pipes = pipes[:idx]
+	}
+	for _, p := range pipes {
+		if !p.canLiveTail() {
+			return false
+		}
+	}
+	return true
+}
+
 func (q *Query) getStreamIDs() []streamID {
 	switch t := q.f.(type) {
 	case *filterAnd:
@@ -1123,6 +1194,7 @@ func ParseQueryAtTimestamp(s string, timestamp int64) (*Query, error) {
 		return nil, fmt.Errorf("unexpected unparsed tail after [%s]; context: [%s]; tail: [%s]", q, lex.context(), lex.s)
 	}
 	q.optimize()
+	q.isTimestampDependent = lex.isTimestampDependent
 
 	start, end := q.GetFilterTimeRange()
 	if start != math.MinInt64 && end != math.MaxInt64 {
@@ -2394,7 +2466,7 @@ func parseFilterTimeRange(lex *lexer) (*filterTime, error) {
 	if lex.isKeyword("offset") {
 		ft := &filterTime{
 			minTimestamp: math.MinInt64,
-			maxTimestamp: lex.currentTimestamp,
+			maxTimestamp: lex.getCurrentTimestamp(),
 		}
 		offset, offsetStr, err := parseTimeOffset(lex)
 		if err != nil {
@@ -2456,7 +2528,7 @@ func parseFilterTime(lex *lexer) (*filterTime, error) {
 		sLower := strings.ToLower(s)
 		if sLower == "now" || startsWithYear(s) {
 			// Parse '_time:YYYY-MM-DD', which transforms to '_time:[YYYY-MM-DD, YYYY-MM-DD+1)'
-			nsecs, err := promutils.ParseTimeAt(s, lex.currentTimestamp)
+			nsecs, err := promutils.ParseTimeAt(s, lex.getCurrentTimestamp())
 			if err != nil {
 				return nil, fmt.Errorf("cannot parse _time filter: %w", err)
 			}
@@ -2479,9 +2551,10 @@ func parseFilterTime(lex *lexer) (*filterTime, error) {
 		if d < 0 {
 			d = -d
 		}
+		currentTimestamp := lex.getCurrentTimestamp()
 		ft := &filterTime{
-			minTimestamp: lex.currentTimestamp - int64(d),
-			maxTimestamp: lex.currentTimestamp,
+			minTimestamp: currentTimestamp - int64(d),
+			maxTimestamp: currentTimestamp,
 
 			stringRepr: s,
 		}
@@ -2694,7 +2767,7 @@ func parseTime(lex *lexer) (int64, string, error) {
 	if err != nil {
 		return 0, "", err
 	}
-	nsecs, err := promutils.ParseTimeAt(s, lex.currentTimestamp)
+	nsecs, err := promutils.ParseTimeAt(s, lex.getCurrentTimestamp())
 	if err != nil {
 		return 0, "", err
 	}
@@ -2483,6 +2483,55 @@ func TestQueryCanLiveTail(t *testing.T) {
 	f("* | hash(a)", true)
 }
 
+func TestQueryCanSplitByTime(t *testing.T) {
+	f := func(qStr string, resultExpected bool) {
+		t.Helper()
+
+		q, err := ParseQuery(qStr)
+		if err != nil {
+			t.Fatalf("cannot parse [%s]: %s", qStr, err)
+		}
+		result := q.CanSplitByTime()
+		if result != resultExpected {
+			t.Fatalf("unexpected result for CanSplitByTime(%q); got %v; want %v", qStr, result, resultExpected)
+		}
+
+		// The cloned query must return the same result
+		qCopy := q.Clone(q.GetTimestamp())
+		result = qCopy.CanSplitByTime()
+		if result != resultExpected {
+			t.Fatalf("unexpected result for CanSplitByTime(%q) on the cloned query; got %v; want %v", qStr, result, resultExpected)
+		}
+	}
+
+	f("foo", true)
+	f("{app=nginx} error | stats count() hits", true)
+	f("error | extract 'user=<user> ' | stats by (user) count() hits | filter hits:>10 | sort by (hits desc) limit 5", true)
+	f("error | stats by (host) count() hits | stats count() hosts", false)
+	f("error | uniq by (host) | stats count() hosts", false)
+	f("error | limit 10 | stats count() hits", false)
+
+	// relative time filters
+	f("_time:5m error | stats count() hits", false)
+	f("_time:2025-01-01 error | stats count() hits", false)
+	f("_time:[now-1h, now) error", false)
+	f("_time:offset 1h error", false)
+	f("error | stats count() if (_time:5m) hits", false)
+
+	// time filters, which do not depend on the current time
+	f("_time:day_range[08:00, 18:00) error | stats count() hits", true)
+	f("_time:week_range[Mon, Fri] error | stats count() hits", true)
+
+	// subqueries
+	f("user:in(error | fields user) | stats count() hits", false)
+	f("* | join by (user) (error | stats by (user) count() errors) | stats count() hits", false)
+	f("* | union (error) | stats count() hits", false)
+
+	// ignore_global_time_filter option
+	f("options(ignore_global_time_filter=true) error | stats count() hits", false)
+	f("options(concurrency=2) error | stats count() hits", true)
+}
+
 func TestQueryDropAllPipes(t *testing.T) {
 	f := func(qStr, resultExpected string) {
 		t.Helper()
@@ -2787,3 +2836,35 @@ func TestQuery_AddExtraFilters(t *testing.T) {
 	f(`foo x:in(bar | union (baz) | keep x) | count() if (a:in(b | keep a)) z`, `tenant:=123`, `tenant:=123 foo x:in(tenant:=123 bar | union (tenant:=123 baz) | fields x) | stats count(*) if (a:in(tenant:=123 b | fields a)) as z`)
 	f(`foo x:in(bar | union (baz) | keep x) | count() if (a:in(b | keep a)) z`, `{tenant=123}`, `{tenant="123"} foo x:in({tenant="123"} bar | union ({tenant="123"} baz) | fields x) | stats count(*) if (a:in({tenant="123"} b | fields a)) as z`)
 }
+
+func TestQuerySplitToRemoteAndLocal(t *testing.T) {
+	f := func(qStr, remoteExpected, localExpected string) {
+		t.Helper()
+
+		q, err := ParseQuery(qStr)
+		if err != nil {
+			t.Fatalf("unexpected error in ParseQuery: %s", err)
+		}
+		qRemote, pipesLocal := q.splitToRemoteAndLocal()
+		if s := qRemote.String(); s != remoteExpected {
+			t.Fatalf("unexpected remote query;\ngot\n%s\nwant\n%s", s, remoteExpected)
+		}
+		a := make([]string, len(pipesLocal))
+		for i, p := range pipesLocal {
+			a[i] = p.String()
+		}
+		if s := strings.Join(a, " | "); s != localExpected {
+			t.Fatalf("unexpected local pipes;\ngot\n%s\nwant\n%s", s, localExpected)
+		}
+	}
+
+	f(`*`, `*`, ``)
+	f(`foo | fields bar | limit 10`, `foo | fields bar | limit 10`, `limit 10`)
+	f(`foo | count() x | sort by (x)`, `foo | stats count(*) as x`, `stats count(*) as x | sort by (x)`)
+	f(`foo | sort by (x) offset 5 limit 10 | fields x`, `foo | sort by (x) limit 15`, `sort by (x) offset 5 limit 10 | fields x`)
+	f(`foo | sort by (x)`, `foo`, `sort by (x)`)
+	f(`foo | uniq by (x) with hits`, `foo | uniq by (x) with hits`, `stats by (x) sum(hits) as hits`)
+	f(`foo | join by (x) (bar) | fields x`, `foo`, `join by (x) (bar) | fields x`)
+	f(`foo | patterns at x limit 5 | fields pattern`, `foo | patterns at x`, `patterns at x limit 5 | fields pattern`)
+	f(`options(concurrency=2) foo | stream_context before 3 | count()`, `options(concurrency=2) foo | stream_context before 3 | stats count(*) as "count(*)"`, `stats count(*) as "count(*)"`)
+}
@@ -1,6 +1,7 @@
 package logstorage
 
 import (
+	"fmt"
 	"path/filepath"
 	"sort"
 
@@ -102,6 +103,7 @@ func mustClosePartition(pt *partition) {
 
 func (pt *partition) mustAddRows(lr *LogRows) {
 	// Register rows in indexdb
+	var rejectedStreamIDs []streamID
 	var pendingRows []int
 	streamIDs := lr.streamIDs
 	for i := range lr.timestamps {
@@ -128,6 +130,10 @@ func (pt *partition) mustAddRows(lr *LogRows) {
 				continue
 			}
 			if !pt.idb.hasStreamID(streamID) {
+				if !pt.canRegisterStream(streamID, lr.timestamps[rowIdx]) {
+					rejectedStreamIDs = append(rejectedStreamIDs, *streamID)
+					continue
+				}
 				streamTagsCanonical := streamTagsCanonicals[rowIdx]
 				pt.idb.mustRegisterStream(streamID, streamTagsCanonical)
 				if logNewStreams {
@@ -138,13 +144,41 @@ func (pt *partition) mustAddRows(lr *LogRows) {
 		}
 	}
 
+	if len(rejectedStreamIDs) > 0 {
+		// Drop rows for streams rejected by per-tenant limits.
+		lr = pt.s.dropRowsForStreams(lr, rejectedStreamIDs)
+		defer PutLogRows(lr)
+	}
+
 	// Add rows to datadb
 	pt.ddb.mustAddRows(lr)
+
+	// Generate metrics only for the accepted rows.
+	pt.s.logMetrics.Load().AddRows(lr)
+
 	if pt.s.logIngestedRows {
 		pt.logIngestedRows(lr)
 	}
 }
 
+// canRegisterStream returns true if the given new streamID with the log entry at the given timestamp can be registered at pt
+// according to per-tenant limits.
+//
+// The stream isn't considered new if it is registered in the partition for the previous day.
+func (pt *partition) canRegisterStream(sid *streamID, timestamp int64) bool {
+	s := pt.s
+	lim := s.getMaxNewStreamsPerHourLimit(sid.tenantID)
+	if lim == nil {
+		// Fast path - the tenant has no limit on new streams.
+		return true
+	}
+	day := timestamp / nsecsPerDay
+	if s.hasStreamIDForDay(sid, day-1) {
+		return true
+	}
+	return s.canCreateNewStream(sid.tenantID, lim)
+}
+
 func (pt *partition) logNewStream(streamTagsCanonical []byte, fields []Field) {
 	streamTags := getStreamTagsString(streamTagsCanonical)
 	rf := RowFormatter(fields)
@@ -191,6 +225,26 @@ func (pt *partition) updateStats(ps *PartitionStats) {
 	pt.idb.updateStats(&ps.IndexdbStats)
 }
 
+// createSnapshotAt creates a snapshot for pt at dstDir.
+//
+// The snapshot can be opened with mustOpenPartition.
+func (pt *partition) createSnapshotAt(dstDir string) error {
+	fs.MustMkdirFailIfExist(dstDir)
+
+	// Create datadb snapshot before indexdb snapshot, so the indexdb snapshot contains all the streams
+	// referred by the datadb snapshot.
+	datadbPath := filepath.Join(dstDir, datadbDirname)
+	pt.ddb.mustCreateSnapshotAt(datadbPath)
+
+	indexdbPath := filepath.Join(dstDir, indexdbDirname)
+	if err := pt.idb.tb.CreateSnapshotAt(indexdbPath); err != nil {
+		return fmt.Errorf("cannot create indexdb snapshot: %w", err)
+	}
+
+	fs.MustSyncPath(dstDir)
+	return nil
+}
+
 // mustForceMerge runs forced merge for all the parts in pt.
 func (pt *partition) mustForceMerge() {
 	pt.ddb.mustForceMergeAllParts()
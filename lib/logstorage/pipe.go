@@ -2,6 +2,8 @@ package logstorage
 
 import (
 	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 )
 
 type pipe interface {
@@ -13,6 +15,15 @@ type pipe interface {
 	// See https://docs.victoriametrics.com/victorialogs/querying/#live-tailing
 	canLiveTail() bool
 
+	// splitToRemoteAndLocal must return the pipe for execution at storage nodes and the pipes for execution at vlselect in cluster mode.
+	//
+	// The local pipes are applied to the results returned by the remote pipe from all the storage nodes.
+	// If the returned local pipes are empty, then the next pipe can be split too.
+	// If the returned remote pipe is nil, then the pipe must be executed at vlselect only.
+	//
+	// timestamp is the timestamp context used for parsing the query.
+	splitToRemoteAndLocal(timestamp int64) (pipe, []pipe)
+
 	// updateNeededFields must update neededFields and unneededFields with fields it needs and not needs at the input.
 	updateNeededFields(neededFields, unneededFields fieldsSet)
 
@@ -97,6 +108,18 @@ func parsePipes(lex *lexer) ([]pipe, error) {
 	}
 }
 
+func mustParsePipes(s string, timestamp int64) []pipe {
+	lex := newLexer(s, timestamp)
+	pipes, err := parsePipes(lex)
+	if err != nil {
+		logger.Panicf("BUG: cannot parse [%s]: %s", s, err)
+	}
+	if !lex.isEnd() {
+		logger.Panicf("BUG: unexpected tail left after parsing pipes [%s]: %q", s, lex.s)
+	}
+	return pipes
+}
+
 func parsePipe(lex *lexer) (pipe, error) {
 	switch {
 	case lex.isKeyword("block_stats"):
@@ -243,6 +266,12 @@ func parsePipe(lex *lexer) (pipe, error) {
 			return nil, fmt.Errorf("cannot parse 'pack_logfmt' pipe: %w", err)
 		}
 		return pp, nil
+	case lex.isKeyword("patterns"):
+		pp, err := parsePipePatterns(lex)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse 'patterns' pipe: %w", err)
+		}
+		return pp, nil
 	case lex.isKeyword("rename", "mv"):
 		pr, err := parsePipeRename(lex)
 		if err != nil {
@@ -368,6 +397,7 @@ var pipeNames = func() map[string]struct{} {
 		"offset", "skip",
 		"pack_json",
 		"pack_logmft",
+		"patterns",
 		"rename", "mv",
 		"replace",
 		"replace_regexp",
@@ -22,6 +22,10 @@ func (ps *pipeBlockStats) canLiveTail() bool {
 	return false
 }
 
+func (ps *pipeBlockStats) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return ps, nil
+}
+
 func (ps *pipeBlockStats) hasFilterInWithQuery() bool {
 	return false
 }
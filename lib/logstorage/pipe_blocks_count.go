@@ -26,6 +26,15 @@ func (pc *pipeBlocksCount) canLiveTail() bool {
 	return false
 }
 
+func (pc *pipeBlocksCount) splitToRemoteAndLocal(timestamp int64) (pipe, []pipe) {
+	// Sum the number of blocks returned from storage nodes.
+	resultName := quoteTokenIfNeeded(pc.resultName)
+	pLocalStr := fmt.Sprintf("stats sum(%s) as %s", resultName, resultName)
+	psLocal := mustParsePipes(pLocalStr, timestamp)
+
+	return pc, psLocal
+}
+
 func (pc *pipeBlocksCount) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	neededFields.reset()
 	unneededFields.reset()
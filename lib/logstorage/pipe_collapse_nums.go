@@ -39,6 +39,10 @@ func (pc *pipeCollapseNums) canLiveTail() bool {
 	return true
 }
 
+func (pc *pipeCollapseNums) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pc, nil
+}
+
 func (pc *pipeCollapseNums) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	updateNeededFieldsForUpdatePipe(neededFields, unneededFields, pc.field, pc.iff)
 }
@@ -35,6 +35,10 @@ func (pc *pipeCopy) canLiveTail() bool {
 	return true
 }
 
+func (pc *pipeCopy) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pc, nil
+}
+
 func (pc *pipeCopy) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	for i := len(pc.srcFields) - 1; i >= 0; i-- {
 		srcField := pc.srcFields[i]
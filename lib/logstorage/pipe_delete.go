@@ -26,6 +26,10 @@ func (pd *pipeDelete) canLiveTail() bool {
 	return true
 }
 
+func (pd *pipeDelete) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pd, nil
+}
+
 func (pd *pipeDelete) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	if neededFields.contains("*") {
 		unneededFields.addFields(pd.fields)
@@ -21,6 +21,10 @@ func (pd *pipeDropEmptyFields) canLiveTail() bool {
 	return true
 }
 
+func (pd *pipeDropEmptyFields) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pd, nil
+}
+
 func (pd *pipeDropEmptyFields) hasFilterInWithQuery() bool {
 	return false
 }
@@ -45,6 +45,10 @@ func (pe *pipeExtract) canLiveTail() bool {
 	return true
 }
 
+func (pe *pipeExtract) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pe, nil
+}
+
 func (pe *pipeExtract) hasFilterInWithQuery() bool {
 	return pe.iff.hasFilterInWithQuery()
 }
@@ -47,6 +47,10 @@ func (pe *pipeExtractRegexp) canLiveTail() bool {
 	return true
 }
 
+func (pe *pipeExtractRegexp) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pe, nil
+}
+
 func (pe *pipeExtractRegexp) hasFilterInWithQuery() bool {
 	return pe.iff.hasFilterInWithQuery()
 }
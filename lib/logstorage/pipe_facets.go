@@ -58,6 +58,10 @@ func (pf *pipeFacets) canLiveTail() bool {
 	return false
 }
 
+func (pf *pipeFacets) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return nil, []pipe{pf}
+}
+
 func (pf *pipeFacets) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	neededFields.add("*")
 	unneededFields.reset()
@@ -29,6 +29,14 @@ func (pf *pipeFieldNames) canLiveTail() bool {
 	return false
 }
 
+func (pf *pipeFieldNames) splitToRemoteAndLocal(timestamp int64) (pipe, []pipe) {
+	// Sum hits for the field names returned from storage nodes.
+	pLocalStr := fmt.Sprintf("stats by (%s) sum(hits) as hits", quoteTokenIfNeeded(pf.resultName))
+	psLocal := mustParsePipes(pLocalStr, timestamp)
+
+	return pf, psLocal
+}
+
 func (pf *pipeFieldNames) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	if pf.isFirstPipe {
 		neededFields.reset()
@@ -25,6 +25,21 @@ func (pf *pipeFieldValues) canLiveTail() bool {
 	return false
 }
 
+func (pf *pipeFieldValues) splitToRemoteAndLocal(timestamp int64) (pipe, []pipe) {
+	// Sum hits for the field values returned from storage nodes.
+	hitsFieldName := "hits"
+	if hitsFieldName == pf.field {
+		hitsFieldName = "hitss"
+	}
+	pLocalStr := fmt.Sprintf("stats by (%s) sum(%s) as %s", quoteTokenIfNeeded(pf.field), hitsFieldName, hitsFieldName)
+	if pf.limit > 0 {
+		pLocalStr += fmt.Sprintf(" | limit %d", pf.limit)
+	}
+	psLocal := mustParsePipes(pLocalStr, timestamp)
+
+	return pf, psLocal
+}
+
 func (pf *pipeFieldValues) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	if neededFields.isEmpty() {
 		neededFields.add(pf.field)
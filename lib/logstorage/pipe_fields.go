@@ -29,6 +29,10 @@ func (pf *pipeFields) canLiveTail() bool {
 	return true
 }
 
+func (pf *pipeFields) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pf, nil
+}
+
 func (pf *pipeFields) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	if pf.containsStar {
 		return
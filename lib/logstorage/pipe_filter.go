@@ -21,6 +21,10 @@ func (pf *pipeFilter) canLiveTail() bool {
 	return true
 }
 
+func (pf *pipeFilter) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pf, nil
+}
+
 func (pf *pipeFilter) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	if neededFields.contains("*") {
 		fs := newFieldsSet()
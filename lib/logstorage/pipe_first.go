@@ -19,6 +19,11 @@ func (pf *pipeFirst) canLiveTail() bool {
 	return false
 }
 
+func (pf *pipeFirst) splitToRemoteAndLocal(timestamp int64) (pipe, []pipe) {
+	psRemote, _ := pf.ps.splitToRemoteAndLocal(timestamp)
+	return psRemote, []pipe{pf}
+}
+
 func (pf *pipeFirst) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	pf.ps.updateNeededFields(neededFields, unneededFields)
 }
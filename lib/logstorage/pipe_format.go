@@ -52,6 +52,10 @@ func (pf *pipeFormat) canLiveTail() bool {
 	return true
 }
 
+func (pf *pipeFormat) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pf, nil
+}
+
 func (pf *pipeFormat) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	if neededFields.isEmpty() {
 		if pf.iff != nil {
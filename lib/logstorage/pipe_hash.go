@@ -31,6 +31,10 @@ func (ph *pipeHash) canLiveTail() bool {
 	return true
 }
 
+func (ph *pipeHash) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return ph, nil
+}
+
 func (ph *pipeHash) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	if neededFields.contains("*") {
 		if !unneededFields.contains(ph.resultField) {
@@ -44,6 +44,11 @@ func (pj *pipeJoin) canLiveTail() bool {
 	return true
 }
 
+func (pj *pipeJoin) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	// The join map is built at vlselect, so the pipe must be executed there.
+	return nil, []pipe{pj}
+}
+
 func (pj *pipeJoin) hasFilterInWithQuery() bool {
 	// Do not check for in(...) filters at pj.q, since they are checked separately during pj.q execution.
 	return false
@@ -44,6 +44,11 @@ func (pl *pipeLast) canLiveTail() bool {
 	return false
 }
 
+func (pl *pipeLast) splitToRemoteAndLocal(timestamp int64) (pipe, []pipe) {
+	psRemote, _ := pl.ps.splitToRemoteAndLocal(timestamp)
+	return psRemote, []pipe{pl}
+}
+
 func (pl *pipeLast) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	pl.ps.updateNeededFields(neededFields, unneededFields)
 }
@@ -29,6 +29,10 @@ func (pl *pipeLen) canLiveTail() bool {
 	return true
 }
 
+func (pl *pipeLen) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pl, nil
+}
+
 func (pl *pipeLen) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	if neededFields.contains("*") {
 		if !unneededFields.contains(pl.resultField) {
@@ -20,6 +20,10 @@ func (pl *pipeLimit) canLiveTail() bool {
 	return false
 }
 
+func (pl *pipeLimit) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pl, []pipe{pl}
+}
+
 func (pl *pipeLimit) updateNeededFields(_, _ fieldsSet) {
 	// nothing to do
 }
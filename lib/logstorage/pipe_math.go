@@ -73,6 +73,10 @@ func (pm *pipeMath) canLiveTail() bool {
 	return true
 }
 
+func (pm *pipeMath) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pm, nil
+}
+
 func (me *mathEntry) String() string {
 	s := me.expr.String()
 	if isMathBinaryOp(me.expr.op) {
@@ -20,6 +20,10 @@ func (po *pipeOffset) canLiveTail() bool {
 	return false
 }
 
+func (po *pipeOffset) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return nil, []pipe{po}
+}
+
 func (po *pipeOffset) updateNeededFields(_, _ fieldsSet) {
 	// nothing to do
 }
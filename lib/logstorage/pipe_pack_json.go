@@ -29,6 +29,10 @@ func (pp *pipePackJSON) canLiveTail() bool {
 	return true
 }
 
+func (pp *pipePackJSON) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pp, nil
+}
+
 func (pp *pipePackJSON) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	updateNeededFieldsForPipePack(neededFields, unneededFields, pp.resultField, pp.fields)
 }
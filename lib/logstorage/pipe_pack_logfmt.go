@@ -29,6 +29,10 @@ func (pp *pipePackLogfmt) canLiveTail() bool {
 	return true
 }
 
+func (pp *pipePackLogfmt) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pp, nil
+}
+
 func (pp *pipePackLogfmt) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	updateNeededFieldsForPipePack(neededFields, unneededFields, pp.resultField, pp.fields)
 }
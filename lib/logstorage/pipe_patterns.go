@@ -0,0 +1,554 @@
+package logstorage
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/memory"
+)
+
+const (
+	// pipePatternsWildcard is the placeholder for the varying tokens in patterns.
+	pipePatternsWildcard = "<*>"
+
+	// pipePatternsSimilarityThreshold is the minimum share of matching tokens for adding a value to the existing pattern.
+	pipePatternsSimilarityThreshold = 0.5
+
+	// pipePatternsMaxTokens is the maximum number of tokens per pattern.
+	//
+	// The remaining tokens are treated as a single token.
+	pipePatternsMaxTokens = 100
+
+	// pipePatternsMaxClustersPerGroup is the maximum number of patterns per group of values with the same number of tokens and the same first token.
+	//
+	// Values, which do not match the existing patterns in a full group, are added to the most similar pattern.
+	pipePatternsMaxClustersPerGroup = 100
+
+	// pipePatternsMaxCacheEntries is the maximum number of cached preprocessed values per shard.
+	pipePatternsMaxCacheEntries = 100_000
+)
+
+// pipePatterns processes '| patterns ...' pipe.
+//
+// See https://docs.victoriametrics.com/victorialogs/logsql/#patterns-pipe
+type pipePatterns struct {
+	// field is the field to extract patterns from
+	field string
+
+	// limit is the maximum number of patterns to return. Zero means no limit.
+	limit uint64
+
+	// isMerge is set if the pipe merges patterns returned from storage nodes in cluster mode.
+	//
+	// In this case the pipe reads patterns from the output fields of the remote patterns pipe.
+	isMerge bool
+}
+
+func (pp *pipePatterns) String() string {
+	s := "patterns"
+	if pp.field != "_msg" {
+		s += " at " + quoteTokenIfNeeded(pp.field)
+	}
+	if pp.limit > 0 {
+		s += fmt.Sprintf(" limit %d", pp.limit)
+	}
+	return s
+}
+
+func (pp *pipePatterns) canLiveTail() bool {
+	return false
+}
+
+func (pp *pipePatterns) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	// Every storage node returns all the patterns, which are then merged at vlselect.
+	ppRemote := *pp
+	ppRemote.limit = 0
+
+	ppLocal := *pp
+	ppLocal.isMerge = true
+
+	return &ppRemote, []pipe{&ppLocal}
+}
+
+func (pp *pipePatterns) updateNeededFields(neededFields, unneededFields fieldsSet) {
+	neededFields.reset()
+	unneededFields.reset()
+
+	if pp.isMerge {
+		neededFields.addFields([]string{"pattern", "hits", "sample"})
+	} else {
+		neededFields.add(pp.field)
+	}
+}
+
+func (pp *pipePatterns) hasFilterInWithQuery() bool {
+	return false
+}
+
+func (pp *pipePatterns) initFilterInValues(_ *inValuesCache, _ getFieldValuesFunc) (pipe, error) {
+	return pp, nil
+}
+
+func (pp *pipePatterns) visitSubqueries(_ func(q *Query)) {
+	// nothing to do
+}
+
+func (pp *pipePatterns) newPipeProcessor(workersCount int, stopCh <-chan struct{}, cancel func(), ppNext pipeProcessor) pipeProcessor {
+	maxStateSize := int64(float64(memory.Allowed()) * 0.2)
+
+	shards := make([]pipePatternsProcessorShard, workersCount)
+	for i := range shards {
+		shards[i] = pipePatternsProcessorShard{
+			pipePatternsProcessorShardNopad: pipePatternsProcessorShardNopad{
+				pp: pp,
+			},
+		}
+		shards[i].m.init(&shards[i].stateSizeBudget)
+	}
+
+	ppp := &pipePatternsProcessor{
+		pp:     pp,
+		stopCh: stopCh,
+		cancel: cancel,
+		ppNext: ppNext,
+
+		shards: shards,
+
+		maxStateSize: maxStateSize,
+	}
+	ppp.stateSizeBudget.Store(maxStateSize)
+
+	return ppp
+}
+
+type pipePatternsProcessor struct {
+	pp     *pipePatterns
+	stopCh <-chan struct{}
+	cancel func()
+	ppNext pipeProcessor
+
+	shards []pipePatternsProcessorShard
+
+	maxStateSize    int64
+	stateSizeBudget atomic.Int64
+}
+
+type pipePatternsProcessorShard struct {
+	pipePatternsProcessorShardNopad
+
+	// The padding prevents false sharing on widespread platforms with 128 mod (cache line size) = 0 .
+	_ [128 - unsafe.Sizeof(pipePatternsProcessorShardNopad{})%128]byte
+}
+
+type pipePatternsProcessorShardNopad struct {
+	// pp points to the parent pipePatterns.
+	pp *pipePatterns
+
+	// m holds the patterns for the shard.
+	m patternsMap
+
+	// buf and prettifyBuf are temporary buffers for preprocessing values.
+	buf         []byte
+	prettifyBuf []byte
+
+	// tokens is a temporary buffer for value tokens.
+	tokens []string
+
+	// stateSizeBudget is the remaining budget for the whole state size for the shard.
+	// The per-shard budget is provided in chunks from the parent pipePatternsProcessor.
+	stateSizeBudget int
+}
+
+// writeBlock writes br to shard.
+func (shard *pipePatternsProcessorShard) writeBlock(br *blockResult) {
+	if shard.pp.isMerge {
+		shard.writeBlockMerge(br)
+		return
+	}
+
+	c := br.getColumnByName(shard.pp.field)
+	if c.isConst {
+		v := c.valuesEncoded[0]
+		shard.addValue(v, uint64(br.rowsLen))
+		return
+	}
+	if c.valueType == valueTypeDict {
+		c.forEachDictValueWithHits(br, shard.addValue)
+		return
+	}
+
+	values := c.getValues(br)
+	var pc *patternsCluster
+	for rowIdx, v := range values {
+		if rowIdx > 0 && v == values[rowIdx-1] {
+			pc.hits++
+			continue
+		}
+		pc = shard.addValueInternal(v, 1)
+	}
+}
+
+func (shard *pipePatternsProcessorShard) addValue(v string, hits uint64) {
+	_ = shard.addValueInternal(v, hits)
+}
+
+func (shard *pipePatternsProcessorShard) addValueInternal(v string, hits uint64) *patternsCluster {
+	// Replace numbers, timestamps, ip addresses and uuids with placeholders in the same way as collapse_nums pipe does,
+	// since they usually represent varying parts of the log message.
+	shard.buf = appendCollapseNums(shard.buf[:0], v)
+	shard.prettifyBuf = appendPrettifyCollapsedNums(shard.prettifyBuf[:0], shard.buf)
+	s := bytesutil.ToUnsafeString(shard.prettifyBuf)
+
+	if pc := shard.m.cache[s]; pc != nil {
+		pc.hits += hits
+		return pc
+	}
+
+	shard.tokens = tokenizePatternValue(shard.tokens[:0], s)
+	pc := shard.m.addTokens(shard.tokens, hits, v)
+
+	if len(shard.m.cache) < pipePatternsMaxCacheEntries {
+		sCopy := strings.Clone(s)
+		shard.m.cache[sCopy] = pc
+		shard.stateSizeBudget -= len(sCopy) + int(unsafe.Sizeof(sCopy)+unsafe.Sizeof(pc))
+	}
+
+	return pc
+}
+
+func (shard *pipePatternsProcessorShard) writeBlockMerge(br *blockResult) {
+	patterns := br.getColumnByName("pattern").getValues(br)
+	hitss := br.getColumnByName("hits").getValues(br)
+	samples := br.getColumnByName("sample").getValues(br)
+
+	for i := range patterns {
+		hits, ok := tryParseUint64(hitss[i])
+		if !ok {
+			continue
+		}
+		shard.tokens = tokenizePatternValue(shard.tokens[:0], patterns[i])
+		_ = shard.m.addTokens(shard.tokens, hits, samples[i])
+	}
+}
+
+func (ppp *pipePatternsProcessor) writeBlock(workerID uint, br *blockResult) {
+	if br.rowsLen == 0 {
+		return
+	}
+
+	shard := &ppp.shards[workerID]
+
+	for shard.stateSizeBudget < 0 {
+		// steal some budget for the state size from the global budget.
+		remaining := ppp.stateSizeBudget.Add(-stateSizeBudgetChunk)
+		if remaining < 0 {
+			// The state size is too big. Stop processing data in order to avoid OOM crash.
+			if remaining+stateSizeBudgetChunk >= 0 {
+				// Notify worker goroutines to stop calling writeBlock() in order to save CPU time.
+				ppp.cancel()
+			}
+			return
+		}
+		shard.stateSizeBudget += stateSizeBudgetChunk
+	}
+
+	shard.writeBlock(br)
+}
+
+func (ppp *pipePatternsProcessor) flush() error {
+	if n := ppp.stateSizeBudget.Load(); n <= 0 {
+		return fmt.Errorf("cannot calculate [%s], since it requires more than %dMB of memory", ppp.pp.String(), ppp.maxStateSize/(1<<20))
+	}
+
+	// merge patterns across shards
+	m := &ppp.shards[0].m
+	for i := 1; i < len(ppp.shards); i++ {
+		if needStop(ppp.stopCh) {
+			return nil
+		}
+		m.mergeFrom(&ppp.shards[i].m)
+	}
+
+	pcs := m.getClusters()
+	slices.SortFunc(pcs, func(a, b *patternsCluster) int {
+		if a.hits != b.hits {
+			if a.hits > b.hits {
+				return -1
+			}
+			return 1
+		}
+		return slices.Compare(a.tokens, b.tokens)
+	})
+	if limit := ppp.pp.limit; limit > 0 && uint64(len(pcs)) > limit {
+		pcs = pcs[:limit]
+	}
+
+	// write result
+	wctx := &pipePatternsWriteContext{
+		ppp: ppp,
+	}
+	wctx.rcs = appendResultColumnWithName(wctx.rcs, "pattern")
+	wctx.rcs = appendResultColumnWithName(wctx.rcs, "hits")
+	wctx.rcs = appendResultColumnWithName(wctx.rcs, "sample")
+
+	for _, pc := range pcs {
+		if needStop(ppp.stopCh) {
+			return nil
+		}
+
+		pattern := string(pc.appendPattern(nil))
+		hits := string(marshalUint64String(nil, pc.hits))
+		wctx.writeRow(pattern, hits, pc.sample)
+	}
+
+	wctx.flush()
+
+	return nil
+}
+
+type pipePatternsWriteContext struct {
+	ppp *pipePatternsProcessor
+	rcs []resultColumn
+	br  blockResult
+
+	// rowsCount is the number of rows in the current block
+	rowsCount int
+
+	// valuesLen is the total length of values in the current block
+	valuesLen int
+}
+
+func (wctx *pipePatternsWriteContext) writeRow(pattern, hits, sample string) {
+	rcs := wctx.rcs
+	rcs[0].addValue(pattern)
+	rcs[1].addValue(hits)
+	rcs[2].addValue(sample)
+	wctx.valuesLen += len(pattern) + len(hits) + len(sample)
+
+	wctx.rowsCount++
+
+	// The 64_000 limit provides the best performance results.
+	if wctx.valuesLen >= 64_000 {
+		wctx.flush()
+	}
+}
+
+func (wctx *pipePatternsWriteContext) flush() {
+	rcs := wctx.rcs
+	br := &wctx.br
+
+	wctx.valuesLen = 0
+
+	// Flush rcs to ppNext
+	br.setResultColumns(rcs, wctx.rowsCount)
+	wctx.rowsCount = 0
+	wctx.ppp.ppNext.writeBlock(0, br)
+	br.reset()
+	for i := range rcs {
+		rcs[i].resetValues()
+	}
+}
+
+// patternsMap clusters values into patterns.
+//
+// The clustering is performed in the spirit of Drain algorithm - see https://jiemingzhu.github.io/pub/pjhe_icws2017.pdf :
+// values are split into groups by the number of tokens and by the first token, then every value is added
+// to the most similar pattern in the group. Tokens, which differ between the pattern and the added value,
+// are replaced with the wildcard in the pattern.
+type patternsMap struct {
+	stateSizeBudget *int
+
+	// groups contains patterns grouped by the number of tokens and by the first token.
+	groups map[string]*patternsGroup
+
+	// cache maps the preprocessed values to their patterns.
+	cache map[string]*patternsCluster
+
+	// keyBuf is a temporary buffer for building keys for groups.
+	keyBuf []byte
+}
+
+type patternsGroup struct {
+	clusters []*patternsCluster
+}
+
+type patternsCluster struct {
+	// tokens contains pattern tokens. Varying tokens are replaced with pipePatternsWildcard.
+	tokens []string
+
+	// hits is the number of values matching the pattern.
+	hits uint64
+
+	// sample is the first value added to the pattern.
+	sample string
+}
+
+func (m *patternsMap) init(stateSizeBudget *int) {
+	m.stateSizeBudget = stateSizeBudget
+	m.groups = make(map[string]*patternsGroup)
+	m.cache = make(map[string]*patternsCluster)
+}
+
+// addTokens adds the value with the given tokens and hits to m and returns the pattern the value is added to.
+//
+// The sample is used as a sample value for the pattern if a new pattern is created.
+func (m *patternsMap) addTokens(tokens []string, hits uint64, sample string) *patternsCluster {
+	m.keyBuf = strconv.AppendInt(m.keyBuf[:0], int64(len(tokens)), 10)
+	if len(tokens) > 0 {
+		m.keyBuf = append(m.keyBuf, ' ')
+		m.keyBuf = append(m.keyBuf, tokens[0]...)
+	}
+
+	g := m.groups[string(m.keyBuf)]
+	if g == nil {
+		key := string(m.keyBuf)
+		g = &patternsGroup{}
+		m.groups[key] = g
+		*m.stateSizeBudget -= len(key) + int(unsafe.Sizeof(key)+unsafe.Sizeof(g)+unsafe.Sizeof(*g))
+	}
+
+	var pcBest *patternsCluster
+	bestSimilarity := -1.0
+	for _, pc := range g.clusters {
+		similarity := pc.similarity(tokens)
+		if similarity > bestSimilarity {
+			pcBest = pc
+			bestSimilarity = similarity
+		}
+	}
+
+	if pcBest != nil && (bestSimilarity >= pipePatternsSimilarityThreshold || len(g.clusters) >= pipePatternsMaxClustersPerGroup) {
+		pcBest.merge(tokens, hits)
+		return pcBest
+	}
+
+	pc := &patternsCluster{
+		tokens: make([]string, len(tokens)),
+		hits:   hits,
+		sample: strings.Clone(sample),
+	}
+	stateSize := int(unsafe.Sizeof(*pc)+unsafe.Sizeof(pc)) + len(pc.sample)
+	for i, token := range tokens {
+		pc.tokens[i] = strings.Clone(token)
+		stateSize += len(token) + int(unsafe.Sizeof(token))
+	}
+	g.clusters = append(g.clusters, pc)
+	*m.stateSizeBudget -= stateSize
+
+	return pc
+}
+
+// mergeFrom merges patterns from src to m.
+func (m *patternsMap) mergeFrom(src *patternsMap) {
+	for _, g := range src.groups {
+		for _, pc := range g.clusters {
+			_ = m.addTokens(pc.tokens, pc.hits, pc.sample)
+		}
+	}
+}
+
+func (m *patternsMap) getClusters() []*patternsCluster {
+	var pcs []*patternsCluster
+	for _, g := range m.groups {
+		pcs = append(pcs, g.clusters...)
+	}
+	return pcs
+}
+
+// similarity returns the share of tokens, which match the pattern.
+//
+// tokens must have the same length as pc.tokens.
+func (pc *patternsCluster) similarity(tokens []string) float64 {
+	if len(tokens) == 0 {
+		return 1
+	}
+	n := 0
+	for i, token := range tokens {
+		if pc.tokens[i] == token || pc.tokens[i] == pipePatternsWildcard {
+			n++
+		}
+	}
+	return float64(n) / float64(len(tokens))
+}
+
+func (pc *patternsCluster) merge(tokens []string, hits uint64) {
+	for i, token := range tokens {
+		if pc.tokens[i] != token {
+			pc.tokens[i] = pipePatternsWildcard
+		}
+	}
+	pc.hits += hits
+}
+
+func (pc *patternsCluster) appendPattern(dst []byte) []byte {
+	for i, token := range pc.tokens {
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, token...)
+	}
+	return dst
+}
+
+// tokenizePatternValue appends whitespace-delimited tokens from s to dst and returns the result.
+//
+// The tail of s after pipePatternsMaxTokens-1 tokens is returned as a single token.
+func tokenizePatternValue(dst []string, s string) []string {
+	tokensCount := 0
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if s == "" {
+			return dst
+		}
+		if tokensCount == pipePatternsMaxTokens-1 {
+			return append(dst, strings.TrimRight(s, " \t\r\n"))
+		}
+		n := strings.IndexAny(s, " \t\r\n")
+		if n < 0 {
+			return append(dst, s)
+		}
+		dst = append(dst, s[:n])
+		s = s[n:]
+		tokensCount++
+	}
+}
+
+func parsePipePatterns(lex *lexer) (pipe, error) {
+	if !lex.isKeyword("patterns") {
+		return nil, fmt.Errorf("unexpected token: %q; want %q", lex.token, "patterns")
+	}
+	lex.nextToken()
+
+	field := "_msg"
+	if lex.isKeyword("at") {
+		lex.nextToken()
+		f, err := parseFieldName(lex)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse 'at' field after 'patterns': %w", err)
+		}
+		field = f
+	}
+
+	limit := uint64(0)
+	if lex.isKeyword("limit") {
+		lex.nextToken()
+		n, ok := tryParseUint64(lex.token)
+		if !ok {
+			return nil, fmt.Errorf("cannot parse 'limit %s'", lex.token)
+		}
+		lex.nextToken()
+		limit = n
+	}
+
+	pp := &pipePatterns{
+		field: field,
+		limit: limit,
+	}
+
+	return pp, nil
+}
@@ -0,0 +1,299 @@
+package logstorage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePipePatternsSuccess(t *testing.T) {
+	f := func(pipeStr string) {
+		t.Helper()
+		expectParsePipeSuccess(t, pipeStr)
+	}
+
+	f(`patterns`)
+	f(`patterns at foo`)
+	f(`patterns limit 20`)
+	f(`patterns at foo limit 20`)
+	f(`patterns at "foo bar" limit 1`)
+}
+
+func TestParsePipePatternsFailure(t *testing.T) {
+	f := func(pipeStr string) {
+		t.Helper()
+		expectParsePipeFailure(t, pipeStr)
+	}
+
+	f(`patterns foo`)
+	f(`patterns at`)
+	f(`patterns at foo bar`)
+	f(`patterns limit`)
+	f(`patterns limit foo`)
+	f(`patterns limit -1`)
+	f(`patterns limit 10 at foo`)
+}
+
+func TestPipePatterns(t *testing.T) {
+	f := func(pipeStr string, rows, rowsExpected [][]Field) {
+		t.Helper()
+		expectPipeResults(t, pipeStr, rows, rowsExpected)
+	}
+
+	// identical messages
+	f("patterns", [][]Field{
+		{
+			{"_msg", "foo bar"},
+			{"a", "x"},
+		},
+		{
+			{"_msg", "foo bar"},
+		},
+		{
+			{"_msg", "baz"},
+		},
+	}, [][]Field{
+		{
+			{"pattern", "foo bar"},
+			{"hits", "2"},
+			{"sample", "foo bar"},
+		},
+		{
+			{"pattern", "baz"},
+			{"hits", "1"},
+			{"sample", "baz"},
+		},
+	})
+
+	// numbers, ip addresses and timestamps are replaced with placeholders
+	f("patterns", [][]Field{
+		{
+			{"_msg", "2024-10-12T10:20:30Z connected to 10.0.0.1:443 in 1.5ms"},
+		},
+	}, [][]Field{
+		{
+			{"pattern", "<DATETIME> connected to <IP4>:<N> in <N>.<N>ms"},
+			{"hits", "1"},
+			{"sample", "2024-10-12T10:20:30Z connected to 10.0.0.1:443 in 1.5ms"},
+		},
+	})
+
+	// missing field
+	f("patterns at x", [][]Field{
+		{
+			{"_msg", "foo"},
+		},
+		{
+			{"_msg", "bar"},
+		},
+	}, [][]Field{
+		{
+			{"pattern", ""},
+			{"hits", "2"},
+			{"sample", ""},
+		},
+	})
+}
+
+func TestPipePatternsWithoutSamples(t *testing.T) {
+	// The sample for the pattern depends on the order of the processed values,
+	// so it is dropped from the results.
+	f := func(pipeStr string, rows, rowsExpected [][]Field) {
+		t.Helper()
+
+		lex := newLexer(pipeStr, 0)
+		p, err := parsePipe(lex)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", pipeStr, err)
+		}
+
+		workersCount := 5
+		stopCh := make(chan struct{})
+		cancel := func() {}
+		ppTest := newTestPipeProcessor()
+		ppFields := mustParsePipes("fields pattern, hits", 0)[0].newPipeProcessor(1, stopCh, cancel, ppTest)
+		pp := p.newPipeProcessor(workersCount, stopCh, cancel, ppFields)
+
+		brw := newTestBlockResultWriter(workersCount, pp)
+		for _, row := range rows {
+			brw.writeRow(row)
+		}
+		brw.flush()
+		if err := pp.flush(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := ppFields.flush(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		ppTest.expectRows(t, rowsExpected)
+	}
+
+	// varying tokens are replaced with wildcards
+	f("patterns", [][]Field{
+		{
+			{"_msg", "user alice logged in"},
+		},
+		{
+			{"_msg", "user bob logged in"},
+		},
+		{
+			{"_msg", "user bob logged out"},
+		},
+		{
+			{"_msg", "connection   closed"},
+		},
+		{
+			{"_msg", "connection reset"},
+		},
+		{
+			{"_msg", "error"},
+		},
+		{
+			{"_msg", "warning"},
+		},
+		{
+			{"_msg", ""},
+		},
+	}, [][]Field{
+		{
+			{"pattern", "user <*> logged <*>"},
+			{"hits", "3"},
+		},
+		{
+			{"pattern", "connection <*>"},
+			{"hits", "2"},
+		},
+		{
+			{"pattern", ""},
+			{"hits", "1"},
+		},
+		{
+			{"pattern", "error"},
+			{"hits", "1"},
+		},
+		{
+			{"pattern", "warning"},
+			{"hits", "1"},
+		},
+	})
+
+	// patterns at the given field with limit
+	f("patterns at x limit 1", [][]Field{
+		{
+			{"_msg", "foo"},
+			{"x", "GET /api/v1/users took 12ms"},
+		},
+		{
+			{"_msg", "foo"},
+			{"x", "GET /api/v1/orders took 5ms"},
+		},
+		{
+			{"_msg", "bar"},
+			{"x", "POST /api/v1/orders took 1s"},
+		},
+	}, [][]Field{
+		{
+			{"pattern", "GET <*> took <N>ms"},
+			{"hits", "2"},
+		},
+	})
+}
+
+func TestPipePatternsMerge(t *testing.T) {
+	f := func(pipeStr string, rows, rowsExpected [][]Field) {
+		t.Helper()
+
+		lex := newLexer(pipeStr, 0)
+		p, err := parsePipe(lex)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", pipeStr, err)
+		}
+		_, pipesLocal := p.splitToRemoteAndLocal(0)
+		if len(pipesLocal) != 1 {
+			t.Fatalf("unexpected number of local pipes; got %d; want 1", len(pipesLocal))
+		}
+
+		ppTest := newTestPipeProcessor()
+		pp := pipesLocal[0].newPipeProcessor(1, make(chan struct{}), func() {}, ppTest)
+		brw := newTestBlockResultWriter(1, pp)
+		for _, row := range rows {
+			brw.writeRow(row)
+		}
+		brw.flush()
+		if err := pp.flush(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		ppTest.expectRows(t, rowsExpected)
+	}
+
+	f("patterns limit 2", [][]Field{
+		{
+			{"pattern", "user alice logged <*>"},
+			{"hits", "3"},
+			{"sample", "user alice logged in"},
+		},
+		{
+			{"pattern", "user bob logged in"},
+			{"hits", "4"},
+			{"sample", "user bob logged in"},
+		},
+		{
+			{"pattern", "error"},
+			{"hits", "5"},
+			{"sample", "error"},
+		},
+		{
+			{"pattern", "warning"},
+			{"hits", "1"},
+			{"sample", "warning"},
+		},
+	}, [][]Field{
+		{
+			{"pattern", "user <*> logged <*>"},
+			{"hits", "7"},
+			{"sample", "user alice logged in"},
+		},
+		{
+			{"pattern", "error"},
+			{"hits", "5"},
+			{"sample", "error"},
+		},
+	})
+}
+
+func TestTokenizePatternValue(t *testing.T) {
+	f := func(s string, tokensExpected []string) {
+		t.Helper()
+
+		tokens := tokenizePatternValue(nil, s)
+		if !reflect.DeepEqual(tokens, tokensExpected) {
+			t.Fatalf("unexpected tokens for %q; got %q; want %q", s, tokens, tokensExpected)
+		}
+	}
+
+	f("", nil)
+	f("   ", nil)
+	f("foo", []string{"foo"})
+	f(" foo  bar\tbaz\n", []string{"foo", "bar", "baz"})
+	f("a=b, c=<N>", []string{"a=b,", "c=<N>"})
+}
+
+func TestPipePatternsUpdateNeededFields(t *testing.T) {
+	f := func(s, neededFields, unneededFields, neededFieldsExpected, unneededFieldsExpected string) {
+		t.Helper()
+		expectPipeNeededFields(t, s, neededFields, unneededFields, neededFieldsExpected, unneededFieldsExpected)
+	}
+
+	// all the needed fields
+	f("patterns", "*", "", "_msg", "")
+	f("patterns at x", "*", "", "x", "")
+
+	// unneeded fields intersect with src
+	f("patterns at x", "*", "x,y", "x", "")
+
+	// needed fields do not intersect with src
+	f("patterns at x", "f1,f2", "", "x", "")
+
+	// needed fields intersect with src
+	f("patterns at x", "x,f1", "", "x", "")
+}
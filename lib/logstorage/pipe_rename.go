@@ -35,6 +35,10 @@ func (pr *pipeRename) canLiveTail() bool {
 	return true
 }
 
+func (pr *pipeRename) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pr, nil
+}
+
 func (pr *pipeRename) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	for i := len(pr.srcFields) - 1; i >= 0; i-- {
 		srcField := pr.srcFields[i]
@@ -41,6 +41,10 @@ func (pr *pipeReplace) canLiveTail() bool {
 	return true
 }
 
+func (pr *pipeReplace) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pr, nil
+}
+
 func (pr *pipeReplace) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	updateNeededFieldsForUpdatePipe(neededFields, unneededFields, pr.field, pr.iff)
 }
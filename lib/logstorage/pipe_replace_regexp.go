@@ -41,6 +41,10 @@ func (pr *pipeReplaceRegexp) canLiveTail() bool {
 	return true
 }
 
+func (pr *pipeReplaceRegexp) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pr, nil
+}
+
 func (pr *pipeReplaceRegexp) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	updateNeededFieldsForUpdatePipe(neededFields, unneededFields, pr.field, pr.iff)
 }
@@ -78,6 +78,21 @@ func (ps *pipeSort) canLiveTail() bool {
 	return false
 }
 
+func (ps *pipeSort) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	if ps.limit == 0 {
+		// All the rows must be sorted at vlselect.
+		return nil, []pipe{ps}
+	}
+
+	// Every storage node returns up to offset+limit top rows, which are then sorted at vlselect.
+	psRemote := *ps
+	psRemote.offset = 0
+	psRemote.limit = ps.offset + ps.limit
+	psRemote.rankFieldName = ""
+
+	return &psRemote, []pipe{ps}
+}
+
 func (ps *pipeSort) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	if neededFields.isEmpty() {
 		return
@@ -2,6 +2,7 @@ package logstorage
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,6 +27,16 @@ type pipeStats struct {
 
 	// funcs contains stats functions to execute.
 	funcs []pipeStatsFunc
+
+	// exportState is set to true if the pipe must return the marshaled states of stats functions instead of the final stats.
+	//
+	// This is used at storage nodes in cluster mode. See Query.splitToRemoteAndLocal.
+	exportState bool
+
+	// importState is set to true if the pipe must merge the states returned by the pipe with exportState=true.
+	//
+	// This is used at vlselect nodes in cluster mode. See Query.splitToRemoteAndLocal.
+	importState bool
 }
 
 type pipeStatsFunc struct {
@@ -75,6 +86,19 @@ type statsProcessor interface {
 	//
 	// finalizeStats must immediately return if stopCh is closed.
 	finalizeStats(sf statsFunc, dst []byte, stopCh <-chan struct{}) []byte
+
+	// exportState must append the marshaled internal state of statsProcessor to dst and return it.
+	//
+	// The exported state is merged with importState at another node, which then calls finalizeStats.
+	// This allows calculating stats across multiple storage nodes in cluster mode.
+	//
+	// exportState must immediately return if stopCh is closed.
+	exportState(sf statsFunc, dst []byte, stopCh <-chan struct{}) []byte
+
+	// importState must merge the state exported by exportState from src into statsProcessor state.
+	//
+	// It must return the change of internal state size in bytes for the statsProcessor.
+	importState(sf statsFunc, src []byte, stopCh <-chan struct{}) (int, error)
 }
 
 func (ps *pipeStats) String() string {
@@ -107,6 +131,17 @@ func (ps *pipeStats) canLiveTail() bool {
 	return false
 }
 
+func (ps *pipeStats) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	// Storage nodes export the states of stats functions, which are then merged at vlselect.
+	psRemote := *ps
+	psRemote.exportState = true
+
+	psLocal := *ps
+	psLocal.importState = true
+
+	return &psRemote, []pipe{&psLocal}
+}
+
 func (ps *pipeStats) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	neededFieldsOrig := neededFields.clone()
 	neededFields.reset()
@@ -246,6 +281,10 @@ type pipeStatsProcessor struct {
 
 	maxStateSize    int64
 	stateSizeBudget atomic.Int64
+
+	// importErr contains the first error occurred when importing stats states.
+	importErr     error
+	importErrLock sync.Mutex
 }
 
 type pipeStatsProcessorShard struct {
@@ -615,6 +654,63 @@ type pipeStatsGroup struct {
 	sfps  []statsProcessor
 }
 
+// importStates merges stats states from br, which is returned by the pipe with exportState=true.
+func (shard *pipeStatsProcessorShard) importStates(br *blockResult, stopCh <-chan struct{}) error {
+	byFields := shard.ps.byFields
+	funcs := shard.ps.funcs
+
+	// Obtain columns for byFields and for states of stats functions.
+	// There is no need in applying buckets to byFields values, since they are already applied by the exporting pipe.
+	columnValues := shard.columnValues[:0]
+	for _, bf := range byFields {
+		c := br.getColumnByName(bf.name)
+		columnValues = append(columnValues, c.getValues(br))
+	}
+	for _, f := range funcs {
+		c := br.getColumnByName(f.resultName)
+		columnValues = append(columnValues, c.getValues(br))
+	}
+	shard.columnValues = columnValues
+	stateValues := columnValues[len(byFields):]
+
+	keyBuf := shard.keyBuf[:0]
+	for rowIdx := 0; rowIdx < br.rowsLen; rowIdx++ {
+		if needStop(stopCh) {
+			return nil
+		}
+
+		var psg *pipeStatsGroup
+		switch len(byFields) {
+		case 0:
+			psg = shard.m.getPipeStatsGroupString(nil)
+		case 1:
+			psg = shard.m.getPipeStatsGroupGeneric(columnValues[0][rowIdx])
+		default:
+			keyBuf = keyBuf[:0]
+			for _, values := range columnValues[:len(byFields)] {
+				keyBuf = encoding.MarshalBytes(keyBuf, bytesutil.ToUnsafeBytes(values[rowIdx]))
+			}
+			psg = shard.m.getPipeStatsGroupString(keyBuf)
+		}
+
+		for i, sfp := range psg.sfps {
+			state := bytesutil.ToUnsafeBytes(stateValues[i][rowIdx])
+			stateSizeIncrease, err := sfp.importState(psg.funcs[i].f, state, stopCh)
+			shard.stateSizeBudget -= stateSizeIncrease
+			if err != nil {
+				if needStop(stopCh) {
+					// The error may be caused by the interrupted import.
+					return nil
+				}
+				return fmt.Errorf("cannot import state for [%s]: %w", psg.funcs[i].f, err)
+			}
+		}
+	}
+	shard.keyBuf = keyBuf
+
+	return nil
+}
+
 func (psg *pipeStatsGroup) mergeState(src *pipeStatsGroup) {
 	for i, sfp := range psg.sfps {
 		sfp.mergeState(psg.funcs[i].f, src.sfps[i])
@@ -669,6 +765,18 @@ func (psp *pipeStatsProcessor) writeBlock(workerID uint, br *blockResult) {
 		shard.stateSizeBudget += stateSizeBudgetChunk
 	}
 
+	if psp.ps.importState {
+		if err := shard.importStates(br, psp.stopCh); err != nil {
+			psp.importErrLock.Lock()
+			if psp.importErr == nil {
+				psp.importErr = err
+			}
+			psp.importErrLock.Unlock()
+			psp.cancel()
+		}
+		return
+	}
+
 	shard.writeBlock(br)
 }
 
@@ -676,6 +784,9 @@ func (psp *pipeStatsProcessor) flush() error {
 	if n := psp.stateSizeBudget.Load(); n <= 0 {
 		return fmt.Errorf("cannot calculate [%s], since it requires more than %dMB of memory", psp.ps.String(), psp.maxStateSize/(1<<20))
 	}
+	if psp.importErr != nil {
+		return fmt.Errorf("cannot calculate [%s]: %w", psp.ps.String(), psp.importErr)
+	}
 
 	// Merge states across shards in parallel
 	psms, err := psp.mergeShardsParallel()
@@ -744,9 +855,14 @@ func newPipeStatsWriter(psp *pipeStatsProcessor, workerID uint) *pipeStatsWriter
 }
 
 func (psw *pipeStatsWriter) writePipeStatsGroup(psg *pipeStatsGroup) {
+	exportState := psw.psp.ps.exportState
 	for i, sfp := range psg.sfps {
 		bufLen := len(psw.valuesBuf)
-		psw.valuesBuf = sfp.finalizeStats(psg.funcs[i].f, psw.valuesBuf, psw.psp.stopCh)
+		if exportState {
+			psw.valuesBuf = sfp.exportState(psg.funcs[i].f, psw.valuesBuf, psw.psp.stopCh)
+		} else {
+			psw.valuesBuf = sfp.finalizeStats(psg.funcs[i].f, psw.valuesBuf, psw.psp.stopCh)
+		}
 		value := bytesutil.ToUnsafeString(psw.valuesBuf[bufLen:])
 		psw.values = append(psw.values, value)
 	}
@@ -1406,3 +1522,125 @@ func areConstValues(values []string) bool {
 	}
 	return true
 }
+
+func marshalStatsStateFloat64(dst []byte, f float64) []byte {
+	return encoding.MarshalUint64(dst, math.Float64bits(f))
+}
+
+func marshalStatsStateString(dst []byte, s string) []byte {
+	return encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(s))
+}
+
+// statsStateDecoder decodes the state marshaled by statsProcessor.exportState.
+//
+// The first decoding error is stored in err, while the subsequent decoding calls return zero values.
+type statsStateDecoder struct {
+	src []byte
+	err error
+}
+
+func (d *statsStateDecoder) uint64() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	n, nSize := encoding.UnmarshalVarUint64(d.src)
+	if nSize <= 0 {
+		d.err = fmt.Errorf("cannot unmarshal varuint64")
+		return 0
+	}
+	d.src = d.src[nSize:]
+	return n
+}
+
+func (d *statsStateDecoder) fixedUint64() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.src) < 8 {
+		d.err = fmt.Errorf("cannot unmarshal uint64 from %d bytes; need at least 8 bytes", len(d.src))
+		return 0
+	}
+	n := encoding.UnmarshalUint64(d.src)
+	d.src = d.src[8:]
+	return n
+}
+
+func (d *statsStateDecoder) float64() float64 {
+	return math.Float64frombits(d.fixedUint64())
+}
+
+func (d *statsStateDecoder) bool() bool {
+	return d.uint64() != 0
+}
+
+// string returns the next string from d.
+//
+// The returned string refers to the decoded state, so it must be cloned before storing it in the statsProcessor.
+func (d *statsStateDecoder) string() string {
+	if d.err != nil {
+		return ""
+	}
+	b, nSize := encoding.UnmarshalBytes(d.src)
+	if nSize <= 0 {
+		d.err = fmt.Errorf("cannot unmarshal string")
+		return ""
+	}
+	d.src = d.src[nSize:]
+	return bytesutil.ToUnsafeString(b)
+}
+
+// count returns the number of items to decode next.
+//
+// It verifies that the remaining state contains at least minItemSize bytes per every item.
+func (d *statsStateDecoder) count(minItemSize int) int {
+	n := d.uint64()
+	if d.err != nil {
+		return 0
+	}
+	if minItemSize > 0 && n > uint64(len(d.src)/minItemSize) {
+		d.err = fmt.Errorf("too big number of items: %d; the remaining state contains only %d bytes", n, len(d.src))
+		return 0
+	}
+	return int(n)
+}
+
+func (d *statsStateDecoder) finish() error {
+	if d.err != nil {
+		return fmt.Errorf("cannot decode stats state: %w", d.err)
+	}
+	if len(d.src) > 0 {
+		return fmt.Errorf("unexpected non-empty tail left after decoding stats state; len(tail)=%d", len(d.src))
+	}
+	return nil
+}
+
+func marshalStatsStateFields(dst []byte, fields []Field) []byte {
+	dst = encoding.MarshalVarUint64(dst, uint64(len(fields)))
+	for _, f := range fields {
+		dst = marshalStatsStateString(dst, f.Name)
+		dst = marshalStatsStateString(dst, f.Value)
+	}
+	return dst
+}
+
+// fields decodes fields marshaled with marshalStatsStateFields.
+//
+// It returns copies of the decoded fields together with their size in bytes.
+func (d *statsStateDecoder) fields() ([]Field, int) {
+	n := d.count(2)
+	if n == 0 {
+		return nil, 0
+	}
+	fields := make([]Field, n)
+	size := 0
+	for i := range fields {
+		name := strings.Clone(d.string())
+		value := strings.Clone(d.string())
+		fields[i] = Field{
+			Name:  name,
+			Value: value,
+		}
+		size += len(name) + len(value)
+	}
+	return fields, size
+}
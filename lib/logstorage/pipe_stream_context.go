@@ -54,6 +54,11 @@ func (pc *pipeStreamContext) canLiveTail() bool {
 	return false
 }
 
+func (pc *pipeStreamContext) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	// All the logs for the given stream are stored at a single storage node, so the stream context can be obtained there.
+	return pc, nil
+}
+
 var neededFieldsForStreamContext = []string{
 	"_time",
 	"_stream_id",
@@ -62,6 +62,10 @@ func (pt *pipeTop) canLiveTail() bool {
 	return false
 }
 
+func (pt *pipeTop) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return nil, []pipe{pt}
+}
+
 func (pt *pipeTop) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	neededFields.reset()
 	unneededFields.reset()
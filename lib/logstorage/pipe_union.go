@@ -32,6 +32,10 @@ func (pu *pipeUnion) canLiveTail() bool {
 	return false
 }
 
+func (pu *pipeUnion) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return nil, []pipe{pu}
+}
+
 func (pu *pipeUnion) hasFilterInWithQuery() bool {
 	// The pu.q query with possible in(...) filters is processed independently at pu.flush(), so return false here.
 	return false
@@ -45,6 +45,22 @@ func (pu *pipeUniq) canLiveTail() bool {
 	return false
 }
 
+func (pu *pipeUniq) splitToRemoteAndLocal(timestamp int64) (pipe, []pipe) {
+	if pu.hitsFieldName == "" {
+		return pu, []pipe{pu}
+	}
+
+	// Sum hits for the unique values returned from storage nodes.
+	hitsFieldName := quoteTokenIfNeeded(pu.hitsFieldName)
+	pLocalStr := fmt.Sprintf("stats by (%s) sum(%s) as %s", fieldNamesString(pu.byFields), hitsFieldName, hitsFieldName)
+	if pu.limit > 0 {
+		pLocalStr += fmt.Sprintf(" | limit %d", pu.limit)
+	}
+	psLocal := mustParsePipes(pLocalStr, timestamp)
+
+	return pu, psLocal
+}
+
 func (pu *pipeUniq) updateNeededFields(neededFields, unneededFields fieldsSet) {
 	neededFields.reset()
 	unneededFields.reset()
@@ -36,6 +36,10 @@ func (pu *pipeUnroll) canLiveTail() bool {
 	return true
 }
 
+func (pu *pipeUnroll) splitToRemoteAndLocal(_ int64) (pipe, []pipe) {
+	return pu, nil
+}
+
 func (pu *pipeUnroll) hasFilterInWithQuery() bool {
 	return pu.iff.hasFilterInWithQuery()
 }
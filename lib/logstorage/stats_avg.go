@@ -5,6 +5,8 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 )
 
 type statsAvg struct {
@@ -87,6 +89,26 @@ func (sap *statsAvgProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-chan st
 	return strconv.AppendFloat(dst, avg, 'f', -1, 64)
 }
 
+func (sap *statsAvgProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	dst = marshalStatsStateFloat64(dst, sap.sum)
+	dst = encoding.MarshalVarUint64(dst, sap.count)
+	return dst
+}
+
+func (sap *statsAvgProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	sum := d.float64()
+	count := d.uint64()
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	sap.sum += sum
+	sap.count += count
+	return 0, nil
+}
+
 func parseStatsAvg(lex *lexer) (*statsAvg, error) {
 	fields, err := parseStatsFuncFields(lex, "avg")
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"slices"
 	"strconv"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 )
 
@@ -197,6 +198,22 @@ func (scp *statsCountProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-chan
 	return strconv.AppendUint(dst, scp.rowsCount, 10)
 }
 
+func (scp *statsCountProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	return encoding.MarshalVarUint64(dst, scp.rowsCount)
+}
+
+func (scp *statsCountProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	rowsCount := d.uint64()
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	scp.rowsCount += rowsCount
+	return 0, nil
+}
+
 func parseStatsCount(lex *lexer) (*statsCount, error) {
 	fields, err := parseStatsFuncFields(lex, "count")
 	if err != nil {
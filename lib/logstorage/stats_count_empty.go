@@ -4,6 +4,7 @@ import (
 	"slices"
 	"strconv"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 )
 
@@ -194,6 +195,22 @@ func (scp *statsCountEmptyProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-
 	return strconv.AppendUint(dst, scp.rowsCount, 10)
 }
 
+func (scp *statsCountEmptyProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	return encoding.MarshalVarUint64(dst, scp.rowsCount)
+}
+
+func (scp *statsCountEmptyProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	rowsCount := d.uint64()
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	scp.rowsCount += rowsCount
+	return 0, nil
+}
+
 func parseStatsCountEmpty(lex *lexer) (*statsCountEmpty, error) {
 	fields, err := parseStatsFuncFields(lex, "count_empty")
 	if err != nil {
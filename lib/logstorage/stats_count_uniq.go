@@ -511,6 +511,88 @@ func (sup *statsCountUniqProcessor) finalizeStats(sf statsFunc, dst []byte, stop
 	return strconv.AppendUint(dst, n, 10)
 }
 
+func (sup *statsCountUniqProcessor) exportState(_ statsFunc, dst []byte, stopCh <-chan struct{}) []byte {
+	ms := append([]*statsCountUniqSet{&sup.m}, sup.ms...)
+
+	dst = marshalUint64Sets(dst, ms, func(sus *statsCountUniqSet) map[uint64]struct{} { return sus.timestamps }, stopCh)
+	dst = marshalUint64Sets(dst, ms, func(sus *statsCountUniqSet) map[uint64]struct{} { return sus.u64 }, stopCh)
+	dst = marshalUint64Sets(dst, ms, func(sus *statsCountUniqSet) map[uint64]struct{} { return sus.negative64 }, stopCh)
+
+	n := 0
+	for _, sus := range ms {
+		n += len(sus.strings)
+	}
+	dst = encoding.MarshalVarUint64(dst, uint64(n))
+	for _, sus := range ms {
+		for k := range sus.strings {
+			if needStop(stopCh) {
+				return dst
+			}
+			dst = marshalStatsStateString(dst, k)
+		}
+	}
+	return dst
+}
+
+func (sup *statsCountUniqProcessor) importState(_ statsFunc, src []byte, stopCh <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	stateSizeIncrease := 0
+	stateSizeIncrease += d.uint64Set(sup.m.timestamps, stopCh)
+	stateSizeIncrease += d.uint64Set(sup.m.u64, stopCh)
+	stateSizeIncrease += d.uint64Set(sup.m.negative64, stopCh)
+
+	n := d.count(1)
+	for i := 0; i < n; i++ {
+		if needStop(stopCh) {
+			return stateSizeIncrease, nil
+		}
+		stateSizeIncrease += sup.m.updateStateString(sup.a, d.string())
+	}
+	if err := d.finish(); err != nil {
+		return stateSizeIncrease, err
+	}
+	return stateSizeIncrease, nil
+}
+
+// marshalUint64Sets marshals the union of sets returned by getSet for the given ms.
+//
+// The union may contain duplicate items. They are removed on unmarshaling with statsStateDecoder.uint64Set.
+func marshalUint64Sets[T any](dst []byte, ms []T, getSet func(sus T) map[uint64]struct{}, stopCh <-chan struct{}) []byte {
+	n := 0
+	for _, sus := range ms {
+		n += len(getSet(sus))
+	}
+	dst = encoding.MarshalVarUint64(dst, uint64(n))
+	for _, sus := range ms {
+		for k := range getSet(sus) {
+			if needStop(stopCh) {
+				return dst
+			}
+			dst = encoding.MarshalUint64(dst, k)
+		}
+	}
+	return dst
+}
+
+// uint64Set adds the items marshaled by marshalUint64Sets to dst and returns the state size increase for dst.
+func (d *statsStateDecoder) uint64Set(dst map[uint64]struct{}, stopCh <-chan struct{}) int {
+	stateSizeIncrease := 0
+	n := d.count(8)
+	for i := 0; i < n; i++ {
+		if needStop(stopCh) {
+			return stateSizeIncrease
+		}
+		k := d.fixedUint64()
+		if _, ok := dst[k]; !ok {
+			dst[k] = struct{}{}
+			stateSizeIncrease += 8
+		}
+	}
+	return stateSizeIncrease
+}
+
 func countUniqParallel(ms []*statsCountUniqSet, stopCh <-chan struct{}) uint64 {
 	shardsLen := len(ms)
 	cpusCount := cgroup.AvailableCPUs()
@@ -493,6 +493,31 @@ func (sup *statsCountUniqHashProcessor) finalizeStats(sf statsFunc, dst []byte,
 	return strconv.AppendUint(dst, n, 10)
 }
 
+func (sup *statsCountUniqHashProcessor) exportState(_ statsFunc, dst []byte, stopCh <-chan struct{}) []byte {
+	ms := append([]*statsCountUniqHashSet{&sup.m}, sup.ms...)
+
+	dst = marshalUint64Sets(dst, ms, func(sus *statsCountUniqHashSet) map[uint64]struct{} { return sus.timestamps }, stopCh)
+	dst = marshalUint64Sets(dst, ms, func(sus *statsCountUniqHashSet) map[uint64]struct{} { return sus.u64 }, stopCh)
+	dst = marshalUint64Sets(dst, ms, func(sus *statsCountUniqHashSet) map[uint64]struct{} { return sus.negative64 }, stopCh)
+	dst = marshalUint64Sets(dst, ms, func(sus *statsCountUniqHashSet) map[uint64]struct{} { return sus.strings }, stopCh)
+	return dst
+}
+
+func (sup *statsCountUniqHashProcessor) importState(_ statsFunc, src []byte, stopCh <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	stateSizeIncrease := 0
+	stateSizeIncrease += d.uint64Set(sup.m.timestamps, stopCh)
+	stateSizeIncrease += d.uint64Set(sup.m.u64, stopCh)
+	stateSizeIncrease += d.uint64Set(sup.m.negative64, stopCh)
+	stateSizeIncrease += d.uint64Set(sup.m.strings, stopCh)
+	if err := d.finish(); err != nil {
+		return stateSizeIncrease, err
+	}
+	return stateSizeIncrease, nil
+}
+
 func countUniqHashParallel(ms []*statsCountUniqHashSet, stopCh <-chan struct{}) uint64 {
 	shardsLen := len(ms)
 	cpusCount := cgroup.AvailableCPUs()
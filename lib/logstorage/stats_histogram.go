@@ -2,8 +2,15 @@ package logstorage
 
 import (
 	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
 
 	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 )
 
 type statsHistogram struct {
@@ -24,6 +31,11 @@ func (sh *statsHistogram) newStatsProcessor(a *chunkedAllocator) statsProcessor
 
 type statsHistogramProcessor struct {
 	h metrics.Histogram
+
+	// imported contains bucket counters imported from other nodes via importState.
+	//
+	// They are stored separately, since metrics.Histogram doesn't allow updating bucket counters directly.
+	imported map[string]uint64
 }
 
 func (shp *statsHistogramProcessor) updateStatsForAllRows(sf statsFunc, br *blockResult) int {
@@ -157,11 +169,14 @@ func (shp *statsHistogramProcessor) updateStatsForRow(sf statsFunc, br *blockRes
 func (shp *statsHistogramProcessor) mergeState(_ statsFunc, sfp statsProcessor) {
 	src := sfp.(*statsHistogramProcessor)
 	shp.h.Merge(&src.h)
+	for vmrange, count := range src.imported {
+		shp.addImportedBucket(vmrange, count)
+	}
 }
 
 func (shp *statsHistogramProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
 	dst = append(dst, '[')
-	shp.h.VisitNonZeroBuckets(func(vmrange string, count uint64) {
+	shp.visitNonZeroBuckets(func(vmrange string, count uint64) {
 		dst = append(dst, `{"vmrange":"`...)
 		dst = append(dst, vmrange...)
 		dst = append(dst, `","hits":`...)
@@ -173,6 +188,87 @@ func (shp *statsHistogramProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-c
 	return dst
 }
 
+func (shp *statsHistogramProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	n := 0
+	shp.visitNonZeroBuckets(func(_ string, _ uint64) {
+		n++
+	})
+	dst = encoding.MarshalVarUint64(dst, uint64(n))
+	shp.visitNonZeroBuckets(func(vmrange string, count uint64) {
+		dst = marshalStatsStateString(dst, vmrange)
+		dst = encoding.MarshalVarUint64(dst, count)
+	})
+	return dst
+}
+
+func (shp *statsHistogramProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	stateSizeIncrease := 0
+	n := d.count(2)
+	for i := 0; i < n; i++ {
+		vmrange := d.string()
+		count := d.uint64()
+		if d.err != nil {
+			break
+		}
+		stateSizeIncrease += shp.addImportedBucket(vmrange, count)
+	}
+	if err := d.finish(); err != nil {
+		return stateSizeIncrease, err
+	}
+	return stateSizeIncrease, nil
+}
+
+func (shp *statsHistogramProcessor) addImportedBucket(vmrange string, count uint64) int {
+	if shp.imported == nil {
+		shp.imported = make(map[string]uint64)
+	}
+	if _, ok := shp.imported[vmrange]; ok {
+		shp.imported[vmrange] += count
+		return 0
+	}
+	vmrange = strings.Clone(vmrange)
+	shp.imported[vmrange] = count
+	return len(vmrange) + int(unsafe.Sizeof(vmrange)) + 8
+}
+
+// visitNonZeroBuckets calls f for non-zero buckets in shp in ascending order of their ranges.
+func (shp *statsHistogramProcessor) visitNonZeroBuckets(f func(vmrange string, count uint64)) {
+	if len(shp.imported) == 0 {
+		shp.h.VisitNonZeroBuckets(f)
+		return
+	}
+
+	m := maps.Clone(shp.imported)
+	shp.h.VisitNonZeroBuckets(func(vmrange string, count uint64) {
+		m[vmrange] += count
+	})
+	vmranges := make([]string, 0, len(m))
+	for vmrange := range m {
+		vmranges = append(vmranges, vmrange)
+	}
+	sort.Slice(vmranges, func(i, j int) bool {
+		return getVMRangeLowerBound(vmranges[i]) < getVMRangeLowerBound(vmranges[j])
+	})
+	for _, vmrange := range vmranges {
+		f(vmrange, m[vmrange])
+	}
+}
+
+func getVMRangeLowerBound(vmrange string) float64 {
+	n := strings.Index(vmrange, "...")
+	if n < 0 {
+		return 0
+	}
+	f, err := strconv.ParseFloat(vmrange[:n], 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
 func parseStatsHistogram(lex *lexer) (*statsHistogram, error) {
 	fields, err := parseStatsFuncFields(lex, "histogram")
 	if err != nil {
@@ -167,6 +167,34 @@ func (smp *statsMaxProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-chan st
 	return append(dst, smp.max...)
 }
 
+func (smp *statsMaxProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	if !smp.hasItems {
+		return append(dst, 0)
+	}
+	dst = append(dst, 1)
+	return marshalStatsStateString(dst, smp.max)
+}
+
+func (smp *statsMaxProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	hasItems := d.bool()
+	v := ""
+	if hasItems {
+		v = d.string()
+	}
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	if !hasItems {
+		return 0, nil
+	}
+	maxLen := len(smp.max)
+	smp.updateStateString(v)
+	return len(smp.max) - maxLen, nil
+}
+
 func parseStatsMax(lex *lexer) (*statsMax, error) {
 	fields, err := parseStatsFuncFields(lex, "max")
 	if err != nil {
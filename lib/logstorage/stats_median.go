@@ -41,6 +41,16 @@ func (smp *statsMedianProcessor) finalizeStats(sf statsFunc, dst []byte, stopCh
 	return smp.sqp.finalizeStats(sm.sq, dst, stopCh)
 }
 
+func (smp *statsMedianProcessor) exportState(sf statsFunc, dst []byte, stopCh <-chan struct{}) []byte {
+	sm := sf.(*statsMedian)
+	return smp.sqp.exportState(sm.sq, dst, stopCh)
+}
+
+func (smp *statsMedianProcessor) importState(sf statsFunc, src []byte, stopCh <-chan struct{}) (int, error) {
+	sm := sf.(*statsMedian)
+	return smp.sqp.importState(sm.sq, src, stopCh)
+}
+
 func parseStatsMedian(lex *lexer) (*statsMedian, error) {
 	fields, err := parseStatsFuncFields(lex, "median")
 	if err != nil {
@@ -169,6 +169,34 @@ func (smp *statsMinProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-chan st
 	return append(dst, smp.min...)
 }
 
+func (smp *statsMinProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	if !smp.hasItems {
+		return append(dst, 0)
+	}
+	dst = append(dst, 1)
+	return marshalStatsStateString(dst, smp.min)
+}
+
+func (smp *statsMinProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	hasItems := d.bool()
+	v := ""
+	if hasItems {
+		v = d.string()
+	}
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	if !hasItems {
+		return 0, nil
+	}
+	minLen := len(smp.min)
+	smp.updateStateString(v)
+	return len(smp.min) - minLen, nil
+}
+
 func parseStatsMin(lex *lexer) (*statsMin, error) {
 	fields, err := parseStatsFuncFields(lex, "min")
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	"github.com/valyala/fastrand"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 )
 
@@ -198,6 +199,20 @@ func (sqp *statsQuantileProcessor) finalizeStats(sf statsFunc, dst []byte, _ <-c
 	return append(dst, q...)
 }
 
+func (sqp *statsQuantileProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	return sqp.h.marshalState(dst)
+}
+
+func (sqp *statsQuantileProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	var h histogram
+	stateSizeIncrease, err := h.unmarshalState(src)
+	if err != nil {
+		return 0, err
+	}
+	sqp.h.mergeState(&h)
+	return stateSizeIncrease, nil
+}
+
 func parseStatsQuantile(lex *lexer) (*statsQuantile, error) {
 	if !lex.isKeyword("quantile") {
 		return nil, fmt.Errorf("unexpected token: %q; want %q", lex.token, "quantile")
@@ -301,6 +316,46 @@ func (h *histogram) mergeState(src *histogram) {
 	h.count += src.count
 }
 
+func (h *histogram) marshalState(dst []byte) []byte {
+	dst = encoding.MarshalVarUint64(dst, h.count)
+	dst = marshalStatsStateString(dst, h.min)
+	dst = marshalStatsStateString(dst, h.max)
+	dst = encoding.MarshalVarUint64(dst, uint64(len(h.a)))
+	for _, v := range h.a {
+		dst = marshalStatsStateString(dst, v)
+	}
+	return dst
+}
+
+// unmarshalState unmarshals h from src marshaled with marshalState.
+//
+// It returns the size of the unmarshaled state in bytes.
+func (h *histogram) unmarshalState(src []byte) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	h.count = d.uint64()
+	h.min = strings.Clone(d.string())
+	h.max = strings.Clone(d.string())
+	stateSize := len(h.min) + len(h.max)
+	n := d.count(1)
+	h.a = make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		v := d.string()
+		if len(h.a) > 0 && v == h.a[len(h.a)-1] {
+			h.a = append(h.a, h.a[len(h.a)-1])
+		} else {
+			h.a = append(h.a, strings.Clone(v))
+			stateSize += len(v)
+		}
+		stateSize += int(unsafe.Sizeof(v))
+	}
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	return stateSize, nil
+}
+
 func (h *histogram) quantile(phi float64) string {
 	if len(h.a) == 0 {
 		return ""
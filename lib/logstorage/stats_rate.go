@@ -3,6 +3,8 @@ package logstorage
 import (
 	"fmt"
 	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 )
 
 type statsRate struct {
@@ -50,6 +52,22 @@ func (srp *statsRateProcessor) finalizeStats(sf statsFunc, dst []byte, _ <-chan
 	return strconv.AppendFloat(dst, rate, 'f', -1, 64)
 }
 
+func (srp *statsRateProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	return encoding.MarshalVarUint64(dst, srp.rowsCount)
+}
+
+func (srp *statsRateProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	rowsCount := d.uint64()
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	srp.rowsCount += rowsCount
+	return 0, nil
+}
+
 func parseStatsRate(lex *lexer) (*statsRate, error) {
 	fields, err := parseStatsFuncFields(lex, "rate")
 	if err != nil {
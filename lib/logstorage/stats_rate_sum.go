@@ -54,6 +54,16 @@ func (srp *statsRateSumProcessor) finalizeStats(sf statsFunc, dst []byte, _ <-ch
 	return strconv.AppendFloat(dst, rate, 'f', -1, 64)
 }
 
+func (srp *statsRateSumProcessor) exportState(sf statsFunc, dst []byte, stopCh <-chan struct{}) []byte {
+	ss := sf.(*statsRateSum)
+	return srp.ssp.exportState(ss.ss, dst, stopCh)
+}
+
+func (srp *statsRateSumProcessor) importState(sf statsFunc, src []byte, stopCh <-chan struct{}) (int, error) {
+	ss := sf.(*statsRateSum)
+	return srp.ssp.importState(ss.ss, src, stopCh)
+}
+
 func parseStatsRateSum(lex *lexer) (*statsRateSum, error) {
 	fields, err := parseStatsFuncFields(lex, "rate_sum")
 	if err != nil {
@@ -97,6 +97,35 @@ func (sap *statsRowAnyProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-chan
 	return MarshalFieldsToJSON(dst, sap.fields)
 }
 
+func (sap *statsRowAnyProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	if !sap.captured {
+		return append(dst, 0)
+	}
+	dst = append(dst, 1)
+	return marshalStatsStateFields(dst, sap.fields)
+}
+
+func (sap *statsRowAnyProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	captured := d.bool()
+	var fields []Field
+	stateSizeIncrease := 0
+	if captured {
+		fields, stateSizeIncrease = d.fields()
+	}
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	if !captured || sap.captured {
+		return 0, nil
+	}
+	sap.captured = true
+	sap.fields = fields
+	return stateSizeIncrease, nil
+}
+
 func parseStatsRowAny(lex *lexer) (*statsRowAny, error) {
 	if !lex.isKeyword("row_any") {
 		return nil, fmt.Errorf("unexpected func; got %q; want 'row_any'", lex.token)
@@ -218,6 +218,34 @@ func (smp *statsRowMaxProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-chan
 	return MarshalFieldsToJSON(dst, smp.fields)
 }
 
+func (smp *statsRowMaxProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	dst = marshalStatsStateString(dst, smp.max)
+	return marshalStatsStateFields(dst, smp.fields)
+}
+
+func (smp *statsRowMaxProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	v := d.string()
+	fields, fieldsSize := d.fields()
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	if !smp.needUpdateStateString(v) {
+		return 0, nil
+	}
+	v = strings.Clone(v)
+
+	stateSizeIncrease := len(v) - len(smp.max) + fieldsSize
+	for _, f := range smp.fields {
+		stateSizeIncrease -= len(f.Name) + len(f.Value)
+	}
+	smp.max = v
+	smp.fields = fields
+	return stateSizeIncrease, nil
+}
+
 func parseStatsRowMax(lex *lexer) (*statsRowMax, error) {
 	if !lex.isKeyword("row_max") {
 		return nil, fmt.Errorf("unexpected func; got %q; want 'row_max'", lex.token)
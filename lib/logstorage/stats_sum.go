@@ -95,6 +95,24 @@ func (ssp *statsSumProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-chan st
 	return strconv.AppendFloat(dst, ssp.sum, 'f', -1, 64)
 }
 
+func (ssp *statsSumProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	return marshalStatsStateFloat64(dst, ssp.sum)
+}
+
+func (ssp *statsSumProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	sum := d.float64()
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	if !math.IsNaN(sum) {
+		ssp.updateState(sum)
+	}
+	return 0, nil
+}
+
 func parseStatsSum(lex *lexer) (*statsSum, error) {
 	fields, err := parseStatsFuncFields(lex, "sum")
 	if err != nil {
@@ -2,6 +2,8 @@ package logstorage
 
 import (
 	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 )
 
 type statsSumLen struct {
@@ -71,6 +73,22 @@ func (ssp *statsSumLenProcessor) finalizeStats(_ statsFunc, dst []byte, _ <-chan
 	return strconv.AppendUint(dst, ssp.sumLen, 10)
 }
 
+func (ssp *statsSumLenProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	return encoding.MarshalVarUint64(dst, ssp.sumLen)
+}
+
+func (ssp *statsSumLenProcessor) importState(_ statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	sumLen := d.uint64()
+	if err := d.finish(); err != nil {
+		return 0, err
+	}
+	ssp.sumLen += sumLen
+	return 0, nil
+}
+
 func parseStatsSumLen(lex *lexer) (*statsSumLen, error) {
 	fields, err := parseStatsFuncFields(lex, "sum_len")
 	if err != nil {
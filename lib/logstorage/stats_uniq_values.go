@@ -12,6 +12,7 @@ import (
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/cgroup"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 )
 
 type statsUniqValues struct {
@@ -174,6 +175,51 @@ func (sup *statsUniqValuesProcessor) finalizeStats(sf statsFunc, dst []byte, sto
 	return marshalJSONArray(dst, items)
 }
 
+func (sup *statsUniqValuesProcessor) exportState(_ statsFunc, dst []byte, stopCh <-chan struct{}) []byte {
+	n := len(sup.m)
+	for _, m := range sup.ms {
+		n += len(m)
+	}
+	dst = encoding.MarshalVarUint64(dst, uint64(n))
+	dst = marshalStringSet(dst, sup.m, stopCh)
+	for _, m := range sup.ms {
+		dst = marshalStringSet(dst, m, stopCh)
+	}
+	return dst
+}
+
+func marshalStringSet(dst []byte, m map[string]struct{}, stopCh <-chan struct{}) []byte {
+	for k := range m {
+		if needStop(stopCh) {
+			return dst
+		}
+		dst = marshalStatsStateString(dst, k)
+	}
+	return dst
+}
+
+func (sup *statsUniqValuesProcessor) importState(_ statsFunc, src []byte, stopCh <-chan struct{}) (int, error) {
+	d := statsStateDecoder{
+		src: src,
+	}
+	stateSizeIncrease := 0
+	n := d.count(1)
+	for i := 0; i < n; i++ {
+		if needStop(stopCh) {
+			return stateSizeIncrease, nil
+		}
+		v := d.string()
+		if d.err != nil {
+			break
+		}
+		stateSizeIncrease += sup.updateState(v)
+	}
+	if err := d.finish(); err != nil {
+		return stateSizeIncrease, err
+	}
+	return stateSizeIncrease, nil
+}
+
 func mergeSetsParallel(ms []map[string]struct{}, stopCh <-chan struct{}) []string {
 	shardsLen := len(ms)
 	cpusCount := cgroup.AvailableCPUs()
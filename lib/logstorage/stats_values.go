@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"unsafe"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 )
 
 type statsValues struct {
@@ -182,6 +184,40 @@ func (svp *statsValuesProcessor) finalizeStats(sf statsFunc, dst []byte, _ <-cha
 	return marshalJSONArray(dst, items)
 }
 
+func (svp *statsValuesProcessor) exportState(_ statsFunc, dst []byte, _ <-chan struct{}) []byte {
+	dst = encoding.MarshalVarUint64(dst, uint64(len(svp.values)))
+	for _, v := range svp.values {
+		dst = marshalStatsStateString(dst, v)
+	}
+	return dst
+}
+
+func (svp *statsValuesProcessor) importState(sf statsFunc, src []byte, _ <-chan struct{}) (int, error) {
+	sv := sf.(*statsValues)
+	d := statsStateDecoder{
+		src: src,
+	}
+	stateSizeIncrease := 0
+	n := d.count(1)
+	for i := 0; i < n; i++ {
+		v := d.string()
+		if d.err != nil || svp.limitReached(sv) {
+			break
+		}
+		v = strings.Clone(v)
+		svp.values = append(svp.values, v)
+		stateSizeIncrease += len(v) + int(unsafe.Sizeof(v))
+	}
+	if svp.limitReached(sv) {
+		// The remaining values aren't needed.
+		return stateSizeIncrease, nil
+	}
+	if err := d.finish(); err != nil {
+		return stateSizeIncrease, err
+	}
+	return stateSizeIncrease, nil
+}
+
 func (svp *statsValuesProcessor) limitReached(sv *statsValues) bool {
 	limit := sv.limit
 	return limit > 0 && uint64(len(svp.values)) > limit
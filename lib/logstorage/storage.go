@@ -9,6 +9,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/backupnames"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timeutil"
@@ -22,6 +23,12 @@ type StorageStats struct {
 	// RowsDroppedTooSmallTimestamp is the number of rows dropped during data ingestion because their timestamp is bigger than the maximum allowed
 	RowsDroppedTooSmallTimestamp uint64
 
+	// RowsDeleted is the number of rows dropped during background merges because of delete tasks
+	RowsDeleted uint64
+
+	// ActiveDeleteTasks is the number of unfinished delete tasks
+	ActiveDeleteTasks uint64
+
 	// PartitionsCount is the number of partitions in the storage
 	PartitionsCount uint64
 
@@ -71,12 +78,18 @@ type StorageConfig struct {
 	//
 	// This can be useful for debugging of data ingestion.
 	LogIngestedRows bool
+
+	// TenantLimits contains optional per-tenant limits.
+	//
+	// TenantLimits can be obtained via ParseTenantLimits. It can be updated later via Storage.SetTenantLimits.
+	TenantLimits *TenantLimits
 }
 
 // Storage is the storage for log entries.
 type Storage struct {
 	rowsDroppedTooBigTimestamp   atomic.Uint64
 	rowsDroppedTooSmallTimestamp atomic.Uint64
+	rowsDeleted                  atomic.Uint64
 
 	// path is the path to the Storage directory
 	path string
@@ -140,6 +153,42 @@ type Storage struct {
 	//
 	// It reduces the load on persistent storage during querying by _stream:{...} filter.
 	filterStreamCache *cache
+
+	// deleteTasks contains unfinished delete tasks.
+	//
+	// Log entries matching these tasks are hidden from search results and are dropped during background merges.
+	//
+	// It must be accessed under deleteTasksLock.
+	deleteTasks []*deleteTask
+
+	// finishedDeleteTasks contains recently finished delete tasks.
+	//
+	// It must be accessed under deleteTasksLock.
+	finishedDeleteTasks []*deleteTask
+
+	// deleteTasksLock protects deleteTasks and finishedDeleteTasks.
+	deleteTasksLock sync.Mutex
+
+	// deleteTasksWakeupCh is used for notifying the delete tasks worker about new tasks.
+	deleteTasksWakeupCh chan struct{}
+
+	// nextDeleteTaskID is used for generating unique ids for delete tasks.
+	nextDeleteTaskID atomic.Uint64
+
+	// snapshotLock prevents from concurrent creation of snapshots.
+	snapshotLock sync.Mutex
+
+	// tenantLimits contains per-tenant limits. It is nil if per-tenant limits aren't configured.
+	tenantLimits atomic.Pointer[TenantLimits]
+
+	// logMetrics contains rules for generating metrics from the accepted log entries. It is nil if log metrics aren't configured.
+	logMetrics atomic.Pointer[LogMetrics]
+
+	// tenantsUsage contains per-tenant usage stats.
+	tenantsUsage tenantsUsage
+
+	// ingestionHistory contains the history of ingested and deleted log entries per tenant.
+	ingestionHistory ingestionHistory
 }
 
 type partitionWrapper struct {
@@ -240,6 +289,17 @@ func MustOpenStorage(path string, cfg *StorageConfig) *Storage {
 
 	flockF := fs.MustCreateFlockFile(path)
 
+	// Check whether restore process finished successfully
+	restoreLockF := filepath.Join(path, backupnames.RestoreInProgressFilename)
+	if fs.IsPathExist(restoreLockF) {
+		logger.Panicf("FATAL: incomplete vmrestore run; run vmrestore again or remove lock file %q", restoreLockF)
+	}
+
+	// Pre-create snapshots directory if it is missing.
+	snapshotsPath := filepath.Join(path, snapshotsDirname)
+	fs.MustMkdirIfNotExist(snapshotsPath)
+	fs.MustRemoveTemporaryDirs(snapshotsPath)
+
 	// Load caches
 	streamIDCache := newCache()
 	filterStreamCache := newCache()
@@ -258,7 +318,12 @@ func MustOpenStorage(path string, cfg *StorageConfig) *Storage {
 
 		streamIDCache:     streamIDCache,
 		filterStreamCache: filterStreamCache,
+
+		deleteTasks:         mustLoadDeleteTasks(path),
+		deleteTasksWakeupCh: make(chan struct{}, 1),
 	}
+	s.nextDeleteTaskID.Store(uint64(time.Now().UnixNano()))
+	s.tenantLimits.Store(cfg.TenantLimits)
 
 	partitionsPath := filepath.Join(path, partitionsDirname)
 	fs.MustMkdirIfNotExist(partitionsPath)
@@ -304,6 +369,7 @@ func MustOpenStorage(path string, cfg *StorageConfig) *Storage {
 	s.partitions = ptws
 	s.runRetentionWatcher()
 	s.runMaxDiskSpaceUsageWatcher()
+	s.runDeleteTasksWorker()
 	return s
 }
 
@@ -357,11 +423,13 @@ func (s *Storage) watchRetention() {
 		s.partitionsLock.Unlock()
 
 		for _, ptw := range ptwsToDelete {
-			logger.Infof("the partition %s is scheduled to be deleted because it is outside the -retentionPeriod=%dd", ptw.pt.path, durationToDays(s.retention))
+			logger.Infof("the partition %s is scheduled to be deleted because it is outside the -retentionPeriod=%dd", ptw.pt.path, durationToDays(s.getMaxRetention()))
 			ptw.mustDrop.Store(true)
 			ptw.decRef()
 		}
 
+		s.purgeExpiredTenantLogs()
+
 		select {
 		case <-s.stopCh:
 			return
@@ -427,7 +495,7 @@ func (s *Storage) watchMaxDiskSpaceUsage() {
 }
 
 func (s *Storage) getMinAllowedDay() int64 {
-	return time.Now().UTC().Add(-s.retention).UnixNano() / nsecsPerDay
+	return time.Now().UTC().Add(-s.getMaxRetention()).UnixNano() / nsecsPerDay
 }
 
 func (s *Storage) getMaxAllowedDay() int64 {
@@ -504,6 +572,13 @@ func (s *Storage) MustForceMerge(partitionNamePrefix string) {
 // It is recommended checking whether the s is in read-only mode by calling IsReadOnly()
 // before calling MustAddRows.
 func (s *Storage) MustAddRows(lr *LogRows) {
+	if lrAccepted := s.applyTenantLimits(lr); lrAccepted != lr {
+		// Some rows were rejected by per-tenant limits.
+		defer PutLogRows(lrAccepted)
+		lr = lrAccepted
+	}
+	s.ingestionHistory.registerRows(lr, time.Now())
+
 	// Fast path - try adding all the rows to the hot partition
 	s.partitionsLock.Lock()
 	ptwHot := s.ptwHot
@@ -533,7 +608,7 @@ func (s *Storage) MustAddRows(lr *LogRows) {
 			minAllowedTsf := TimeFormatter(minAllowedDay * nsecsPerDay)
 			tooSmallTimestampLogger.Warnf("skipping log entry with too small timestamp=%s; it must be bigger than %s according "+
 				"to the configured -retentionPeriod=%dd. See https://docs.victoriametrics.com/victorialogs/#retention ; "+
-				"log entry: %s", &tsf, &minAllowedTsf, durationToDays(s.retention), &rf)
+				"log entry: %s", &tsf, &minAllowedTsf, durationToDays(s.getMaxRetention()), &rf)
 			s.rowsDroppedTooSmallTimestamp.Add(1)
 			continue
 		}
@@ -619,6 +694,11 @@ func (s *Storage) getPartitionForDay(day int64) *partitionWrapper {
 func (s *Storage) UpdateStats(ss *StorageStats) {
 	ss.RowsDroppedTooBigTimestamp += s.rowsDroppedTooBigTimestamp.Load()
 	ss.RowsDroppedTooSmallTimestamp += s.rowsDroppedTooSmallTimestamp.Load()
+	ss.RowsDeleted += s.rowsDeleted.Load()
+
+	s.deleteTasksLock.Lock()
+	ss.ActiveDeleteTasks += uint64(len(s.deleteTasks))
+	s.deleteTasksLock.Unlock()
 
 	s.partitionsLock.Lock()
 	ss.PartitionsCount += uint64(len(s.partitions))
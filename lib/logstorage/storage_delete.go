@@ -0,0 +1,700 @@
+package logstorage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+// DeleteTask contains information about the task for deleting log entries.
+//
+// See Storage.DeleteRunTask.
+type DeleteTask struct {
+	// TaskID is the unique id of the task.
+	TaskID string `json:"task_id"`
+
+	// TenantIDs contains tenants to delete log entries from.
+	TenantIDs []TenantID `json:"tenant_ids"`
+
+	// Filter is the LogsQL filter for log entries to delete.
+	Filter string `json:"filter"`
+
+	// Start is the start of the time range in nanoseconds for log entries to delete.
+	Start int64 `json:"start"`
+
+	// End is the end of the time range in nanoseconds for log entries to delete.
+	End int64 `json:"end"`
+
+	// CreatedAt is the unix timestamp in seconds when the task has been created.
+	CreatedAt int64 `json:"created_at"`
+
+	// FinishedAt is the unix timestamp in seconds when the task has been finished.
+	FinishedAt int64 `json:"finished_at,omitempty"`
+
+	// Status is the task status. It may be one of the following values:
+	//
+	//   - pending - the task waits for the execution
+	//   - running - the task is being executed
+	//   - done - the task is finished and the matching log entries are removed from the storage
+	//
+	// Log entries matching pending and running tasks are already invisible to queries.
+	Status string `json:"status"`
+}
+
+const (
+	deleteTaskStatusPending = "pending"
+	deleteTaskStatusRunning = "running"
+	deleteTaskStatusDone    = "done"
+)
+
+// maxFinishedDeleteTasks is the maximum number of recently finished delete tasks to keep in memory.
+const maxFinishedDeleteTasks = 100
+
+// deleteTask is the task for deleting log entries.
+type deleteTask struct {
+	// info contains the task information.
+	//
+	// It must be accessed under Storage.deleteTasksLock.
+	info DeleteTask
+
+	// df is the filter for log entries to delete.
+	df *deleteFilter
+
+	// seq is the sequence number of the task.
+	//
+	// The task applies only to parts with deleteTaskSeq smaller than seq, e.g. to log entries ingested before the task creation.
+	seq uint64
+}
+
+// deleteFilter is the filter for log entries to delete.
+//
+// Log entries matching the filter are hidden from search results and are removed during background merges.
+type deleteFilter struct {
+	// tenantIDs is a sorted list of tenants for log entries to delete.
+	tenantIDs []TenantID
+
+	// minTimestamp is the minimum timestamp in nanoseconds for log entries to delete.
+	minTimestamp int64
+
+	// maxTimestamp is the maximum timestamp in nanoseconds for log entries to delete.
+	maxTimestamp int64
+
+	// f is the filter for log entries to delete. It includes the filter on [minTimestamp ... maxTimestamp] time range.
+	f filter
+
+	// excludeTenantIDs is set to true if the filter applies to all the tenants except of tenantIDs.
+	excludeTenantIDs bool
+
+	// matchAllRows is set to true if f matches all the log entries on the [minTimestamp ... maxTimestamp] time range.
+	//
+	// This allows dropping the whole blocks during background merges without inspecting their log entries.
+	matchAllRows bool
+}
+
+func newDeleteFilter(tenantIDs []TenantID, f filter, minTimestamp, maxTimestamp int64) *deleteFilter {
+	tenantIDs = append([]TenantID{}, tenantIDs...)
+	slices.SortFunc(tenantIDs, func(a, b TenantID) int {
+		if a.less(&b) {
+			return -1
+		}
+		if b.less(&a) {
+			return 1
+		}
+		return 0
+	})
+	tenantIDs = slices.CompactFunc(tenantIDs, func(a, b TenantID) bool {
+		return a.equal(&b)
+	})
+
+	startStr := marshalTimestampRFC3339NanoString(nil, minTimestamp)
+	endStr := marshalTimestampRFC3339NanoString(nil, maxTimestamp)
+	ft := &filterTime{
+		minTimestamp: minTimestamp,
+		maxTimestamp: maxTimestamp,
+		stringRepr:   fmt.Sprintf("[%s, %s]", startStr, endStr),
+	}
+
+	return &deleteFilter{
+		tenantIDs:    tenantIDs,
+		minTimestamp: minTimestamp,
+		maxTimestamp: maxTimestamp,
+		f: &filterAnd{
+			filters: []filter{ft, f},
+		},
+	}
+}
+
+// newRetentionDeleteFilter returns a filter for all the log entries with timestamps up to maxTimestamp for the given tenantIDs.
+//
+// If excludeTenantIDs is set, then the filter applies to all the tenants except of the given tenantIDs.
+func newRetentionDeleteFilter(tenantIDs []TenantID, excludeTenantIDs bool, maxTimestamp int64) *deleteFilter {
+	df := newDeleteFilter(tenantIDs, &filterNoop{}, math.MinInt64, maxTimestamp)
+	df.excludeTenantIDs = excludeTenantIDs
+	df.matchAllRows = true
+	return df
+}
+
+// hasTenantID returns true if df applies to log entries for the given tenantID.
+func (df *deleteFilter) hasTenantID(tenantID *TenantID) bool {
+	found := false
+	for i := range df.tenantIDs {
+		if df.tenantIDs[i].equal(tenantID) {
+			found = true
+			break
+		}
+	}
+	return found != df.excludeTenantIDs
+}
+
+// hasAnyTenantID returns true if df applies to log entries for any of the given tenantIDs.
+//
+// It returns true if tenantIDs is empty.
+func (df *deleteFilter) hasAnyTenantID(tenantIDs []TenantID) bool {
+	if len(tenantIDs) == 0 {
+		return true
+	}
+	for i := range tenantIDs {
+		if df.hasTenantID(&tenantIDs[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchBlock returns true if the block for the given sid on the [minTimestamp ... maxTimestamp] time range may contain log entries to delete.
+func (df *deleteFilter) matchBlock(sid *streamID, minTimestamp, maxTimestamp int64) bool {
+	if minTimestamp > df.maxTimestamp || maxTimestamp < df.minTimestamp {
+		return false
+	}
+	return df.hasTenantID(&sid.tenantID)
+}
+
+// DeleteRunTask starts a background task for deleting log entries matching f on the [start ... end] time range for the given tenantIDs.
+//
+// Matching log entries become invisible to queries immediately after the call. They are removed from the storage
+// by background merges of the affected partitions. The end is limited by the current time, so log entries ingested
+// after the call aren't deleted. The task is finished when all the log entries ingested before the call are processed,
+// so log entries ingested during the task execution may remain in the storage.
+//
+// The returned task id can be passed to GetDeleteTask for obtaining the task status.
+func (s *Storage) DeleteRunTask(tenantIDs []TenantID, f *Filter, start, end int64) (string, error) {
+	if len(tenantIDs) == 0 {
+		return "", fmt.Errorf("missing tenants for deleting log entries")
+	}
+	if f == nil || f.f == nil {
+		return "", fmt.Errorf("missing filter for deleting log entries")
+	}
+	if hasFilterInWithQueryForFilter(f.f) {
+		return "", fmt.Errorf("filter [%s] cannot contain subqueries", f)
+	}
+	if hasFilterTime(f.f) {
+		return "", fmt.Errorf("filter [%s] cannot contain _time filters; pass the time range for deletion via start and end args instead", f)
+	}
+
+	currentTime := time.Now()
+	end = min(end, currentTime.UnixNano())
+	if start > end {
+		return "", fmt.Errorf("start=%d cannot exceed end=%d", start, end)
+	}
+
+	dt := &deleteTask{
+		info: DeleteTask{
+			TenantIDs: append([]TenantID{}, tenantIDs...),
+			Filter:    f.String(),
+			Start:     start,
+			End:       end,
+			CreatedAt: currentTime.Unix(),
+			Status:    deleteTaskStatusPending,
+		},
+		df: newDeleteFilter(tenantIDs, f.f, start, end),
+	}
+
+	s.deleteTasksLock.Lock()
+	// The task sequence number must be generated under the lock, so getDeleteTaskSeq never returns
+	// the sequence number for the task, which isn't registered yet.
+	dt.seq = s.nextDeleteTaskID.Add(1)
+	dt.info.TaskID = fmt.Sprintf("%016X", dt.seq)
+	s.deleteTasks = append(s.deleteTasks, dt)
+	s.mustSaveDeleteTasksLocked()
+	s.deleteTasksLock.Unlock()
+
+	// Register the deleted time range, so the cached query results for this time range are invalidated.
+	s.ingestionHistory.registerTenants(tenantIDs, start, currentTime)
+
+	// Notify the worker about the new task.
+	select {
+	case s.deleteTasksWakeupCh <- struct{}{}:
+	default:
+	}
+
+	return dt.info.TaskID, nil
+}
+
+// GetDeleteTask returns the delete task with the given taskID for the given tenantID.
+//
+// false is returned if the task isn't found or if it doesn't belong to the given tenantID.
+func (s *Storage) GetDeleteTask(tenantID TenantID, taskID string) (*DeleteTask, bool) {
+	s.deleteTasksLock.Lock()
+	defer s.deleteTasksLock.Unlock()
+
+	for _, tasks := range [][]*deleteTask{s.deleteTasks, s.finishedDeleteTasks} {
+		for _, dt := range tasks {
+			if dt.info.TaskID == taskID && dt.df.hasTenantID(&tenantID) {
+				info := dt.info
+				return &info, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// GetDeleteTasks returns active and recently finished delete tasks for the given tenantID.
+func (s *Storage) GetDeleteTasks(tenantID TenantID) []DeleteTask {
+	s.deleteTasksLock.Lock()
+	defer s.deleteTasksLock.Unlock()
+
+	a := make([]DeleteTask, 0)
+	for _, tasks := range [][]*deleteTask{s.deleteTasks, s.finishedDeleteTasks} {
+		for _, dt := range tasks {
+			if dt.df.hasTenantID(&tenantID) {
+				a = append(a, dt.info)
+			}
+		}
+	}
+	return a
+}
+
+// getDeleteTaskSeq returns the sequence number of the last registered delete task.
+func (s *Storage) getDeleteTaskSeq() uint64 {
+	s.deleteTasksLock.Lock()
+	seq := s.nextDeleteTaskID.Load()
+	s.deleteTasksLock.Unlock()
+	return seq
+}
+
+// getDeleteFilters returns filters for log entries to delete for the given tenantIDs on the [minTimestamp ... maxTimestamp] time range.
+//
+// Filters for all the tenants are returned if tenantIDs is empty.
+func (s *Storage) getDeleteFilters(tenantIDs []TenantID, minTimestamp, maxTimestamp int64) []*deleteFilter {
+	var dfs []*deleteFilter
+	for _, df := range s.getRetentionDeleteFilters() {
+		if minTimestamp > df.maxTimestamp || !df.hasAnyTenantID(tenantIDs) {
+			continue
+		}
+		dfs = append(dfs, df)
+	}
+
+	s.deleteTasksLock.Lock()
+	defer s.deleteTasksLock.Unlock()
+
+	for _, dt := range s.deleteTasks {
+		df := dt.df
+		if minTimestamp > df.maxTimestamp || maxTimestamp < df.minTimestamp {
+			continue
+		}
+		if !df.hasAnyTenantID(tenantIDs) {
+			continue
+		}
+		dfs = append(dfs, df)
+	}
+	return dfs
+}
+
+func (s *Storage) runDeleteTasksWorker() {
+	s.wg.Add(1)
+	go func() {
+		s.processDeleteTasks()
+		s.wg.Done()
+	}()
+}
+
+func (s *Storage) processDeleteTasks() {
+	for {
+		dt := s.startNextDeleteTask()
+		if dt == nil {
+			select {
+			case <-s.stopCh:
+				return
+			case <-s.deleteTasksWakeupCh:
+				continue
+			}
+		}
+
+		logger.Infof("started delete task %s", dt.info.TaskID)
+		startTime := time.Now()
+		if !s.runDeleteTask(dt) {
+			// The storage is stopped. The task is resumed after the restart, since it is persisted at the storage.
+			return
+		}
+		s.finishDeleteTask(dt)
+		logger.Infof("finished delete task %s in %.3f seconds", dt.info.TaskID, time.Since(startTime).Seconds())
+	}
+}
+
+func (s *Storage) startNextDeleteTask() *deleteTask {
+	s.deleteTasksLock.Lock()
+	defer s.deleteTasksLock.Unlock()
+
+	if len(s.deleteTasks) == 0 {
+		return nil
+	}
+	dt := s.deleteTasks[0]
+	dt.info.Status = deleteTaskStatusRunning
+	return dt
+}
+
+// runDeleteTask removes log entries matching dt from the storage.
+//
+// The task is bound to log entries ingested before its creation, so it is finished even if new log entries
+// matching the task are continuously ingested.
+//
+// It returns false if the storage is stopped before the task is finished.
+func (s *Storage) runDeleteTask(dt *deleteTask) bool {
+	df := dt.df
+	for {
+		// Background merges drop log entries matching the active delete tasks,
+		// so force merge all the partitions with the matching log entries.
+		s.forceMergePartitionsInTimeRange(df.minTimestamp, df.maxTimestamp)
+
+		// Verify whether all the parts, which existed at the task creation, are processed.
+		// Some of them may remain in parts, which were merged concurrently with the forced merge
+		// by background merges started before the task creation.
+		if !s.hasPartsForDeleteTask(dt) {
+			return true
+		}
+		if needStop(s.stopCh) {
+			return false
+		}
+		logger.Infof("delete task %s: some of the parts aren't processed yet; repeating the merge", dt.info.TaskID)
+
+		t := time.NewTimer(time.Second)
+		select {
+		case <-s.stopCh:
+			t.Stop()
+			return false
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Storage) forceMergePartitionsInTimeRange(minTimestamp, maxTimestamp int64) {
+	minDay := minTimestamp / nsecsPerDay
+	maxDay := maxTimestamp / nsecsPerDay
+
+	var ptws []*partitionWrapper
+	s.partitionsLock.Lock()
+	for _, ptw := range s.partitions {
+		if ptw.day >= minDay && ptw.day <= maxDay {
+			ptw.incRef()
+			ptws = append(ptws, ptw)
+		}
+	}
+	s.partitionsLock.Unlock()
+
+	for _, ptw := range ptws {
+		if !needStop(s.stopCh) {
+			ptw.pt.mustForceMerge()
+		}
+		ptw.decRef()
+	}
+}
+
+// hasPartsForDeleteTask returns true if the storage contains parts, which must be processed by dt.
+func (s *Storage) hasPartsForDeleteTask(dt *deleteTask) bool {
+	minDay := dt.df.minTimestamp / nsecsPerDay
+	maxDay := dt.df.maxTimestamp / nsecsPerDay
+
+	var ptws []*partitionWrapper
+	s.partitionsLock.Lock()
+	for _, ptw := range s.partitions {
+		if ptw.day >= minDay && ptw.day <= maxDay {
+			ptw.incRef()
+			ptws = append(ptws, ptw)
+		}
+	}
+	s.partitionsLock.Unlock()
+
+	found := false
+	for _, ptw := range ptws {
+		if !found && ptw.pt.ddb.hasPartsForDeleteTask(dt.seq) {
+			found = true
+		}
+		ptw.decRef()
+	}
+	return found
+}
+
+func (s *Storage) finishDeleteTask(dt *deleteTask) {
+	s.deleteTasksLock.Lock()
+	defer s.deleteTasksLock.Unlock()
+
+	dt.info.Status = deleteTaskStatusDone
+	dt.info.FinishedAt = time.Now().Unix()
+
+	s.deleteTasks = slices.DeleteFunc(s.deleteTasks, func(x *deleteTask) bool {
+		return x == dt
+	})
+	s.mustSaveDeleteTasksLocked()
+
+	s.finishedDeleteTasks = append(s.finishedDeleteTasks, dt)
+	if n := len(s.finishedDeleteTasks) - maxFinishedDeleteTasks; n > 0 {
+		s.finishedDeleteTasks = append(s.finishedDeleteTasks[:0], s.finishedDeleteTasks[n:]...)
+	}
+}
+
+func (s *Storage) mustSaveDeleteTasksLocked() {
+	infos := make([]DeleteTask, len(s.deleteTasks))
+	for i, dt := range s.deleteTasks {
+		infos[i] = dt.info
+		infos[i].Status = deleteTaskStatusPending
+	}
+	data, err := json.Marshal(infos)
+	if err != nil {
+		logger.Panicf("BUG: cannot marshal delete tasks to JSON: %s", err)
+	}
+	deleteTasksPath := filepath.Join(s.path, deleteTasksFilename)
+	fs.MustWriteAtomic(deleteTasksPath, data, true)
+}
+
+// mustLoadDeleteTasks loads unfinished delete tasks from the storage directory at path.
+func mustLoadDeleteTasks(path string) []*deleteTask {
+	deleteTasksPath := filepath.Join(path, deleteTasksFilename)
+	if !fs.IsPathExist(deleteTasksPath) {
+		return nil
+	}
+	data, err := os.ReadFile(deleteTasksPath)
+	if err != nil {
+		logger.Panicf("FATAL: cannot read %s: %s", deleteTasksPath, err)
+	}
+	var infos []DeleteTask
+	if err := json.Unmarshal(data, &infos); err != nil {
+		logger.Panicf("FATAL: cannot parse %s: %s", deleteTasksPath, err)
+	}
+
+	dts := make([]*deleteTask, len(infos))
+	for i, info := range infos {
+		f, err := ParseFilter(info.Filter)
+		if err != nil {
+			logger.Panicf("FATAL: cannot parse filter for delete task %s at %s: %s", info.TaskID, deleteTasksPath, err)
+		}
+		dts[i] = &deleteTask{
+			info: info,
+			df:   newDeleteFilter(info.TenantIDs, f.f, info.Start, info.End),
+
+			// Parts loaded from disk have zero deleteTaskSeq, while parts created after the start
+			// have bigger deleteTaskSeq, so the loaded task applies only to parts loaded from disk.
+			seq: 1,
+		}
+	}
+	return dts
+}
+
+func hasFilterTime(f filter) bool {
+	visitFunc := func(f filter) bool {
+		_, ok := f.(*filterTime)
+		return ok
+	}
+	return visitFilter(f, visitFunc)
+}
+
+// applyDeleteFilters resets bits in bm for log entries matching the delete filters for the current block.
+func (bs *blockSearch) applyDeleteFilters(bm *bitmap) {
+	dfs := bs.bsw.so.deleteFilters
+	if len(dfs) == 0 {
+		return
+	}
+
+	bh := &bs.bsw.bh
+	th := &bh.timestampsHeader
+	bmTmp := getBitmap(bm.bitsLen)
+	for _, df := range dfs {
+		if bm.isZero() {
+			break
+		}
+		if !df.matchBlock(&bh.streamID, th.minTimestamp, th.maxTimestamp) {
+			continue
+		}
+		bmTmp.copyFrom(bm)
+		df.f.applyToBlockSearch(bs, bmTmp)
+		bm.andNot(bmTmp)
+	}
+	putBitmap(bmTmp)
+}
+
+// initDeleteFiltersForSearch returns delete filters, which can be used for the search at pt.
+func (pt *partition) initDeleteFiltersForSearch(dfs []*deleteFilter) []*deleteFilter {
+	dfsNew := make([]*deleteFilter, len(dfs))
+	for i, df := range dfs {
+		if !hasStreamFilters(df.f) {
+			dfsNew[i] = df
+			continue
+		}
+		dfCopy := *df
+		dfCopy.f = initStreamFilters(df.tenantIDs, pt.idb, df.f)
+		dfsNew[i] = &dfCopy
+	}
+	return dfsNew
+}
+
+// rowsDeleter drops log entries matching the delete filters during background merges.
+type rowsDeleter struct {
+	// dfs contains the delete filters to apply.
+	dfs []*deleteFilter
+
+	// idb is used for obtaining _stream values for the merged log entries.
+	idb *indexdb
+
+	// rowsDeleted is the number of deleted log entries.
+	rowsDeleted uint64
+
+	br  blockResult
+	rcs []resultColumn
+	bb  bytesutil.ByteBuffer
+}
+
+// newRowsDeleter returns rowsDeleter for the merge at pt plus the delete task sequence number for the merged part.
+//
+// nil rowsDeleter is returned if there are no log entries to delete.
+func newRowsDeleter(pt *partition) (*rowsDeleter, uint64) {
+	// The sequence number must be obtained before the delete filters, so all the tasks up to seq are either applied or finished.
+	seq := pt.s.getDeleteTaskSeq()
+	dfs := pt.s.getDeleteFilters(nil, math.MinInt64, math.MaxInt64)
+	if len(dfs) == 0 {
+		return nil, seq
+	}
+	rd := &rowsDeleter{
+		dfs: dfs,
+		idb: pt.idb,
+	}
+	return rd, seq
+}
+
+// needDeleteRows returns true if bd may contain log entries to delete.
+func (rd *rowsDeleter) needDeleteRows(bd *blockData) bool {
+	if rd == nil || bd.rowsCount == 0 {
+		return false
+	}
+	td := &bd.timestampsData
+	for _, df := range rd.dfs {
+		if df.matchBlock(&bd.streamID, td.minTimestamp, td.maxTimestamp) {
+			return true
+		}
+	}
+	return false
+}
+
+// needDeleteBlock returns true if all the log entries in bd must be deleted.
+//
+// The number of deleted log entries is registered in rd.rowsDeleted if true is returned.
+func (rd *rowsDeleter) needDeleteBlock(bd *blockData) bool {
+	if rd == nil || bd.rowsCount == 0 {
+		return false
+	}
+	td := &bd.timestampsData
+	for _, df := range rd.dfs {
+		if df.matchAllRows && td.minTimestamp >= df.minTimestamp && td.maxTimestamp <= df.maxTimestamp && df.hasTenantID(&bd.streamID.tenantID) {
+			rd.rowsDeleted += bd.rowsCount
+			return true
+		}
+	}
+	return false
+}
+
+// deleteRows drops log entries matching rd filters from rs starting from rowsLen index.
+//
+// All the log entries in rs starting from rowsLen index must belong to the given sid.
+func (rd *rowsDeleter) deleteRows(rs *rows, rowsLen int, sid *streamID) {
+	timestamps := rs.timestamps[rowsLen:]
+	rows := rs.rows[rowsLen:]
+	if len(timestamps) == 0 {
+		return
+	}
+
+	bm := getBitmap(len(timestamps))
+	defer putBitmap(bm)
+	rd.initDeletedRows(bm, sid, timestamps, rows)
+	if bm.isZero() {
+		return
+	}
+
+	dstTimestamps := timestamps[:0]
+	dstRows := rows[:0]
+	for i := range timestamps {
+		if !bm.isSetBit(i) {
+			dstTimestamps = append(dstTimestamps, timestamps[i])
+			dstRows = append(dstRows, rows[i])
+		}
+	}
+	rd.rowsDeleted += uint64(len(timestamps) - len(dstTimestamps))
+	clear(rows[len(dstRows):])
+	rs.timestamps = rs.timestamps[:rowsLen+len(dstTimestamps)]
+	rs.rows = rs.rows[:rowsLen+len(dstRows)]
+}
+
+// initDeletedRows sets bits in bm for log entries, which must be deleted.
+func (rd *rowsDeleter) initDeletedRows(bm *bitmap, sid *streamID, timestamps []int64, rows [][]Field) {
+	// Convert log entries to blockResult, so the delete filters could be applied to it.
+	rcs := rd.rcs[:0]
+	columnIdxs := getColumnIdxs()
+	for i, fields := range rows {
+		for _, f := range fields {
+			idx, ok := columnIdxs[f.Name]
+			if !ok {
+				idx = len(rcs)
+				columnIdxs[f.Name] = idx
+				rcs = appendResultColumnWithName(rcs, getCanonicalColumnName(f.Name))
+			}
+			rc := &rcs[idx]
+			for len(rc.values) < i {
+				rc.addValue("")
+			}
+			rc.addValue(f.Value)
+		}
+	}
+	putColumnIdxs(columnIdxs)
+	for i := range rcs {
+		rc := &rcs[i]
+		for len(rc.values) < len(rows) {
+			rc.addValue("")
+		}
+	}
+	rd.rcs = rcs
+
+	br := &rd.br
+	br.setResultColumns(rcs, len(rows))
+	br.addTimeColumn()
+	br.timestampsBuf = append(br.timestampsBuf[:0], timestamps...)
+
+	rd.bb.B = sid.marshalString(rd.bb.B[:0])
+	br.addConstColumn("_stream_id", bytesutil.ToUnsafeString(rd.bb.B))
+
+	rd.bb.B = rd.idb.appendStreamTagsByStreamID(rd.bb.B[:0], sid)
+	if len(rd.bb.B) > 0 {
+		st := GetStreamTags()
+		mustUnmarshalStreamTags(st, rd.bb.B)
+		rd.bb.B = st.marshalString(rd.bb.B[:0])
+		PutStreamTags(st)
+		br.addConstColumn("_stream", bytesutil.ToUnsafeString(rd.bb.B))
+	}
+
+	bmTmp := getBitmap(len(rows))
+	for _, df := range rd.dfs {
+		if !df.hasTenantID(&sid.tenantID) {
+			continue
+		}
+		bmTmp.setBits()
+		df.f.applyToBlockResult(br, bmTmp)
+		bm.or(bmTmp)
+	}
+	putBitmap(bmTmp)
+
+	br.reset()
+}
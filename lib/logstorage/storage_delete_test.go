@@ -0,0 +1,310 @@
+package logstorage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+)
+
+func TestStorageDeleteRunTask(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	sc := &StorageConfig{
+		Retention: 24 * time.Hour,
+	}
+	s := MustOpenStorage(path, sc)
+
+	tenantIDs := []TenantID{
+		{AccountID: 1, ProjectID: 2},
+		{AccountID: 3, ProjectID: 4},
+	}
+	const streamsPerTenant = 3
+	const rowsPerStream = 100
+	baseTimestamp := time.Now().UnixNano() - 3600*1e9
+	for _, tenantID := range tenantIDs {
+		for i := 0; i < streamsPerTenant; i++ {
+			lr := GetLogRows([]string{"instance"}, nil, nil, "")
+			for j := 0; j < rowsPerStream; j++ {
+				level := "info"
+				if j%2 == 0 {
+					level = "error"
+				}
+				fields := []Field{
+					{
+						Name:  "instance",
+						Value: fmt.Sprintf("host-%d", i),
+					},
+					{
+						Name:  "_msg",
+						Value: fmt.Sprintf("%s message %d", level, j),
+					},
+					{
+						Name:  "level",
+						Value: level,
+					},
+				}
+				lr.MustAdd(tenantID, baseTimestamp+int64(j)*1e9, fields, nil)
+			}
+			s.MustAddRows(lr)
+			PutLogRows(lr)
+		}
+	}
+	s.debugFlush()
+
+	getRowsCount := func(tenantID TenantID) uint64 {
+		t.Helper()
+		q := mustParseQuery(`*`)
+		var rowsCount atomic.Uint64
+		writeBlock := func(_ uint, timestamps []int64, _ []BlockColumn) {
+			rowsCount.Add(uint64(len(timestamps)))
+		}
+		if err := s.RunQuery(context.Background(), []TenantID{tenantID}, q, writeBlock); err != nil {
+			t.Fatalf("unexpected error in query: %s", err)
+		}
+		return rowsCount.Load()
+	}
+	getStoredRowsCount := func() uint64 {
+		var ss StorageStats
+		s.UpdateStats(&ss)
+		return ss.RowsCount()
+	}
+
+	// Delete errors at the second half of the time range for instance="host-1" at the first tenant.
+	f, err := ParseFilter(`{instance="host-1"} level:error`)
+	if err != nil {
+		t.Fatalf("cannot parse filter: %s", err)
+	}
+	start := baseTimestamp + rowsPerStream/2*1e9
+	taskID, err := s.DeleteRunTask(tenantIDs[:1], f, start, math.MaxInt64)
+	if err != nil {
+		t.Fatalf("cannot start delete task: %s", err)
+	}
+	const rowsCountDeleted = rowsPerStream / 4
+
+	// The task must be invisible to other tenants.
+	if _, ok := s.GetDeleteTask(tenantIDs[1], taskID); ok {
+		t.Fatalf("the delete task %s mustn't be visible to tenant %s", taskID, &tenantIDs[1])
+	}
+	if tasks := s.GetDeleteTasks(tenantIDs[1]); len(tasks) != 0 {
+		t.Fatalf("unexpected delete tasks for tenant %s: %v", &tenantIDs[1], tasks)
+	}
+	if tasks := s.GetDeleteTasks(tenantIDs[0]); len(tasks) != 1 || tasks[0].TaskID != taskID {
+		t.Fatalf("unexpected delete tasks for tenant %s: %v", &tenantIDs[0], tasks)
+	}
+
+	// The matching log entries must become invisible immediately.
+	rowsCountExpected := uint64(streamsPerTenant*rowsPerStream - rowsCountDeleted)
+	if n := getRowsCount(tenantIDs[0]); n != rowsCountExpected {
+		t.Fatalf("unexpected number of rows for the first tenant; got %d; want %d", n, rowsCountExpected)
+	}
+	if n := getRowsCount(tenantIDs[1]); n != streamsPerTenant*rowsPerStream {
+		t.Fatalf("unexpected number of rows for the second tenant; got %d; want %d", n, streamsPerTenant*rowsPerStream)
+	}
+
+	// Wait until the task is finished.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		dt, ok := s.GetDeleteTask(tenantIDs[0], taskID)
+		if !ok {
+			t.Fatalf("cannot find delete task %s", taskID)
+		}
+		if dt.Status == deleteTaskStatusDone {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout while waiting for delete task %s; status: %s", taskID, dt.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The matching log entries must be removed from the storage.
+	if n := getStoredRowsCount(); n != uint64(len(tenantIDs)*streamsPerTenant*rowsPerStream-rowsCountDeleted) {
+		t.Fatalf("unexpected number of stored rows; got %d; want %d", n, len(tenantIDs)*streamsPerTenant*rowsPerStream-rowsCountDeleted)
+	}
+	if n := getRowsCount(tenantIDs[0]); n != rowsCountExpected {
+		t.Fatalf("unexpected number of rows for the first tenant after the deletion; got %d; want %d", n, rowsCountExpected)
+	}
+	var ss StorageStats
+	s.UpdateStats(&ss)
+	if ss.RowsDeleted != rowsCountDeleted {
+		t.Fatalf("unexpected number of deleted rows; got %d; want %d", ss.RowsDeleted, rowsCountDeleted)
+	}
+	if ss.ActiveDeleteTasks != 0 {
+		t.Fatalf("unexpected number of active delete tasks; got %d; want 0", ss.ActiveDeleteTasks)
+	}
+
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
+
+func TestStorageDeleteRunTaskConcurrentIngestion(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	sc := &StorageConfig{
+		Retention: 24 * time.Hour,
+	}
+	s := MustOpenStorage(path, sc)
+
+	tenantID := TenantID{AccountID: 1}
+	baseTimestamp := time.Now().UnixNano() - 3600*1e9
+	addRows := func() {
+		lr := GetLogRows(nil, nil, nil, "")
+		for i := 0; i < 100; i++ {
+			fields := []Field{
+				{
+					Name:  "_msg",
+					Value: fmt.Sprintf("error message %d", i),
+				},
+			}
+			lr.MustAdd(tenantID, baseTimestamp+int64(i)*1e9, fields, nil)
+		}
+		s.MustAddRows(lr)
+		PutLogRows(lr)
+	}
+	addRows()
+	s.debugFlush()
+
+	f, err := ParseFilter(`error`)
+	if err != nil {
+		t.Fatalf("cannot parse filter: %s", err)
+	}
+	taskID, err := s.DeleteRunTask([]TenantID{tenantID}, f, baseTimestamp, math.MaxInt64)
+	if err != nil {
+		t.Fatalf("cannot start delete task: %s", err)
+	}
+
+	// Continuously ingest log entries matching the delete task into the deleted time range.
+	// The task must be finished anyway, since it applies only to log entries ingested before its creation.
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for {
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+			addRows()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		dt, ok := s.GetDeleteTask(tenantID, taskID)
+		if !ok {
+			t.Fatalf("cannot find delete task %s", taskID)
+		}
+		if dt.Status == deleteTaskStatusDone {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout while waiting for delete task %s; status: %s", taskID, dt.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(stopCh)
+	<-doneCh
+
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
+
+func TestStorageDeleteRunTaskFailure(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	s := MustOpenStorage(path, &StorageConfig{})
+	defer func() {
+		s.MustClose()
+		fs.MustRemoveAll(path)
+	}()
+
+	tenantIDs := []TenantID{{}}
+
+	f := func(tenantIDs []TenantID, filter string, start, end int64) {
+		t.Helper()
+		fl, err := ParseFilter(filter)
+		if err != nil {
+			t.Fatalf("cannot parse filter %q: %s", filter, err)
+		}
+		if _, err := s.DeleteRunTask(tenantIDs, fl, start, end); err == nil {
+			t.Fatalf("expecting non-nil error for filter %q", filter)
+		}
+	}
+
+	// missing tenants
+	f(nil, "foo", 0, math.MaxInt64)
+
+	// _time filter
+	f(tenantIDs, "_time:5m foo", 0, math.MaxInt64)
+
+	// subquery
+	f(tenantIDs, "x:in(foo | fields x)", 0, math.MaxInt64)
+
+	// start exceeds end
+	f(tenantIDs, "foo", 2, 1)
+
+	if tasks := s.GetDeleteTasks(tenantIDs[0]); len(tasks) != 0 {
+		t.Fatalf("unexpected delete tasks: %v", tasks)
+	}
+}
+
+func TestStorageDeleteTasksPersistence(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	fs.MustMkdirFailIfExist(path)
+	s := &Storage{
+		path: path,
+	}
+
+	fl, err := ParseFilter(`{app="foo"} error`)
+	if err != nil {
+		t.Fatalf("cannot parse filter: %s", err)
+	}
+	tenantIDs := []TenantID{{AccountID: 5, ProjectID: 6}}
+	dt := &deleteTask{
+		info: DeleteTask{
+			TaskID:    "foo",
+			TenantIDs: tenantIDs,
+			Filter:    fl.String(),
+			Start:     123,
+			End:       456,
+			Status:    deleteTaskStatusPending,
+		},
+		df: newDeleteFilter(tenantIDs, fl.f, 123, 456),
+	}
+	s.deleteTasksLock.Lock()
+	s.deleteTasks = append(s.deleteTasks, dt)
+	s.mustSaveDeleteTasksLocked()
+	s.deleteTasksLock.Unlock()
+
+	dts := mustLoadDeleteTasks(path)
+	if len(dts) != 1 {
+		t.Fatalf("unexpected number of loaded delete tasks; got %d; want 1", len(dts))
+	}
+	info := dts[0].info
+	if info.TaskID != "foo" || info.Filter != `{app="foo"} error` || info.Start != 123 || info.End != 456 || len(info.TenantIDs) != 1 || info.TenantIDs[0] != tenantIDs[0] {
+		t.Fatalf("unexpected delete task loaded: %+v", info)
+	}
+	fStr := dts[0].df.f.String()
+	fStrExpected := `_time:[1970-01-01T00:00:00.000000123Z, 1970-01-01T00:00:00.000000456Z] {app="foo"} error`
+	if fStr != fStrExpected {
+		t.Fatalf("unexpected filter for the loaded delete task\ngot\n%s\nwant\n%s", fStr, fStrExpected)
+	}
+
+	fs.MustRemoveAll(path)
+}
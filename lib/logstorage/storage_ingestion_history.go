@@ -0,0 +1,143 @@
+package logstorage
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// ingestionHistorySlotDuration is the duration of a single slot at ingestion history.
+	ingestionHistorySlotDuration = 10 * time.Second
+
+	// ingestionHistoryDuration is the duration of the tracked ingestion history.
+	ingestionHistoryDuration = time.Hour
+)
+
+// ingestionHistory tracks the minimum timestamps of log entries, which were ingested or deleted per every tenant during the last ingestionHistoryDuration.
+//
+// It is used for detecting changes in the past data, which may be cached by query callers. See Storage.GetMinIngestedTimestamp.
+type ingestionHistory struct {
+	mu sync.Mutex
+	m  map[TenantID]*tenantIngestionHistory
+}
+
+// tenantIngestionHistory contains ingestion history for a single tenant.
+type tenantIngestionHistory struct {
+	// slots contains ingestion slots sorted by idx.
+	slots []ingestionSlot
+}
+
+type ingestionSlot struct {
+	// idx is the slot index. It equals to the ingestion time divided by ingestionHistorySlotDuration.
+	idx int64
+
+	// minTimestamp is the minimum timestamp across log entries ingested during the slot.
+	minTimestamp int64
+}
+
+// registerRows registers log entries from lr at ih at the given currentTime.
+func (ih *ingestionHistory) registerRows(lr *LogRows, currentTime time.Time) {
+	if len(lr.timestamps) == 0 {
+		return
+	}
+
+	ih.mu.Lock()
+	defer ih.mu.Unlock()
+
+	// Log entries are usually grouped by tenant, so the minimum timestamp is registered only when the tenant changes.
+	tenantIDPrev := lr.streamIDs[0].tenantID
+	minTimestamp := int64(math.MaxInt64)
+	for i, sid := range lr.streamIDs {
+		if !sid.tenantID.equal(&tenantIDPrev) {
+			ih.registerLocked(tenantIDPrev, minTimestamp, currentTime)
+			tenantIDPrev = sid.tenantID
+			minTimestamp = math.MaxInt64
+		}
+		minTimestamp = min(minTimestamp, lr.timestamps[i])
+	}
+	ih.registerLocked(tenantIDPrev, minTimestamp, currentTime)
+}
+
+// registerTenants registers changes of log entries starting from minTimestamp for the given tenantIDs at the given currentTime.
+func (ih *ingestionHistory) registerTenants(tenantIDs []TenantID, minTimestamp int64, currentTime time.Time) {
+	ih.mu.Lock()
+	defer ih.mu.Unlock()
+
+	for _, tenantID := range tenantIDs {
+		ih.registerLocked(tenantID, minTimestamp, currentTime)
+	}
+}
+
+func (ih *ingestionHistory) registerLocked(tenantID TenantID, minTimestamp int64, currentTime time.Time) {
+	tih := ih.m[tenantID]
+	if tih == nil {
+		if ih.m == nil {
+			ih.m = make(map[TenantID]*tenantIngestionHistory)
+		}
+		tih = &tenantIngestionHistory{}
+		ih.m[tenantID] = tih
+	}
+
+	idx := getIngestionSlotIdx(currentTime.UnixNano())
+	slots := tih.slots
+	if n := len(slots); n > 0 && slots[n-1].idx >= idx {
+		slots[n-1].minTimestamp = min(slots[n-1].minTimestamp, minTimestamp)
+		return
+	}
+
+	// Drop slots outside the ingestion history.
+	minIdx := idx - int64(ingestionHistoryDuration/ingestionHistorySlotDuration)
+	n := 0
+	for n < len(slots) && slots[n].idx < minIdx {
+		n++
+	}
+	slots = append(slots[:0], slots[n:]...)
+
+	tih.slots = append(slots, ingestionSlot{
+		idx:          idx,
+		minTimestamp: minTimestamp,
+	})
+}
+
+// getMinTimestamp returns the minimum timestamp of log entries registered at ih for the given tenantIDs since the given timestamp.
+func (ih *ingestionHistory) getMinTimestamp(tenantIDs []TenantID, since int64) int64 {
+	sinceIdx := getIngestionSlotIdx(since)
+
+	ih.mu.Lock()
+	defer ih.mu.Unlock()
+
+	minTimestamp := int64(math.MaxInt64)
+	for _, tenantID := range tenantIDs {
+		tih := ih.m[tenantID]
+		if tih == nil {
+			continue
+		}
+		slots := tih.slots
+		for i := len(slots) - 1; i >= 0 && slots[i].idx >= sinceIdx; i-- {
+			minTimestamp = min(minTimestamp, slots[i].minTimestamp)
+		}
+	}
+	return minTimestamp
+}
+
+func getIngestionSlotIdx(timestamp int64) int64 {
+	return timestamp / int64(ingestionHistorySlotDuration)
+}
+
+// GetMinIngestedTimestamp returns the minimum timestamp of log entries ingested or deleted for the given tenantIDs since the given unix timestamp in nanoseconds.
+//
+// math.MaxInt64 is returned if there were no changes for the given tenantIDs since the given timestamp.
+// math.MinInt64 is returned if the given timestamp is outside the tracked ingestion history, e.g. any log entries could change since then.
+//
+// The result can be used for invalidating cached query results for the past time ranges.
+func (s *Storage) GetMinIngestedTimestamp(tenantIDs []TenantID, since int64) int64 {
+	// Ingested log entries become visible for search with some delay,
+	// so take into account log entries ingested during the previous slot.
+	since -= int64(ingestionHistorySlotDuration)
+
+	if since < time.Now().UnixNano()-int64(ingestionHistoryDuration) {
+		return math.MinInt64
+	}
+	return s.ingestionHistory.getMinTimestamp(tenantIDs, since)
+}
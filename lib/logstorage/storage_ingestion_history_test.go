@@ -0,0 +1,57 @@
+package logstorage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestIngestionHistory(t *testing.T) {
+	var ih ingestionHistory
+
+	tenant1 := TenantID{AccountID: 1}
+	tenant2 := TenantID{AccountID: 2}
+	tenant3 := TenantID{AccountID: 3}
+
+	currentTime := time.Unix(1_700_000_000, 0)
+	ts := currentTime.UnixNano()
+
+	f := func(tenantIDs []TenantID, since time.Time, resultExpected int64) {
+		t.Helper()
+
+		result := ih.getMinTimestamp(tenantIDs, since.UnixNano())
+		if result != resultExpected {
+			t.Fatalf("unexpected min timestamp for tenants %v since %s; got %d; want %d", tenantIDs, since, result, resultExpected)
+		}
+	}
+
+	// empty history
+	f([]TenantID{tenant1}, currentTime, math.MaxInt64)
+
+	lr := GetLogRows(nil, nil, nil, "")
+	lr.mustAddInternal(streamID{tenantID: tenant1}, ts-100, nil, nil)
+	lr.mustAddInternal(streamID{tenantID: tenant1}, ts-300, nil, nil)
+	lr.mustAddInternal(streamID{tenantID: tenant2}, ts-50, nil, nil)
+	lr.mustAddInternal(streamID{tenantID: tenant1}, ts-200, nil, nil)
+	ih.registerRows(lr, currentTime)
+	PutLogRows(lr)
+
+	f([]TenantID{tenant1}, currentTime, ts-300)
+	f([]TenantID{tenant2}, currentTime, ts-50)
+	f([]TenantID{tenant3}, currentTime, math.MaxInt64)
+	f([]TenantID{tenant2, tenant3}, currentTime, ts-50)
+	f([]TenantID{tenant1}, currentTime.Add(time.Minute), math.MaxInt64)
+
+	// delete the logs for the last day at tenant2 and tenant3 a minute later
+	ih.registerTenants([]TenantID{tenant2, tenant3}, ts-int64(24*time.Hour), currentTime.Add(time.Minute))
+	f([]TenantID{tenant2}, currentTime, ts-int64(24*time.Hour))
+	f([]TenantID{tenant3}, currentTime.Add(time.Minute), ts-int64(24*time.Hour))
+	f([]TenantID{tenant1}, currentTime.Add(time.Minute), math.MaxInt64)
+
+	// old slots are dropped from the history
+	ih.registerTenants([]TenantID{tenant2}, ts, currentTime.Add(2*time.Hour))
+	if n := len(ih.m[tenant2].slots); n != 1 {
+		t.Fatalf("unexpected number of slots for tenant2; got %d; want 1", n)
+	}
+	f([]TenantID{tenant2}, currentTime, ts)
+}
@@ -43,6 +43,14 @@ type genericSearchOptions struct {
 
 	// needAllColumns is set to true when all the columns except of unneededColumnNames must be returned in the result
 	needAllColumns bool
+
+	// ignoreDeleteFilters is set to true when log entries matching the pending delete tasks must be returned in the result
+	ignoreDeleteFilters bool
+
+	// streamSharding is an optional sharding of log streams among storage nodes in cluster mode.
+	//
+	// If it is set, then only log streams owned by the current storage node are returned in the result.
+	streamSharding *StreamSharding
 }
 
 type searchOptions struct {
@@ -73,6 +81,12 @@ type searchOptions struct {
 
 	// needAllColumns is set to true when all the columns except of unneededColumnNames must be returned in the result
 	needAllColumns bool
+
+	// deleteFilters contains filters for log entries, which must be excluded from the result because of the pending delete tasks
+	deleteFilters []*deleteFilter
+
+	// streamSharding is an optional sharding of log streams among storage nodes in cluster mode
+	streamSharding *StreamSharding
 }
 
 // WriteBlockFunc must write a block with the given timestamps and columns.
@@ -82,7 +96,35 @@ type WriteBlockFunc func(workerID uint, timestamps []int64, columns []BlockColum
 
 // RunQuery runs the given q and calls writeBlock for results.
 func (s *Storage) RunQuery(ctx context.Context, tenantIDs []TenantID, q *Query, writeBlock WriteBlockFunc) error {
-	writeBlockResult := func(workerID uint, br *blockResult) {
+	writeBlockResult := newWriteBlockResultFunc(writeBlock)
+	return s.runQuery(ctx, tenantIDs, q, writeBlockResult)
+}
+
+// RunRemoteQuery runs the given q received from vlselect in cluster mode and calls writeBlock for results.
+//
+// q must be obtained via Query.splitToRemoteAndLocal at vlselect. The state of the trailing `stats` pipe is returned
+// in the form suitable for merging at vlselect instead of the final stats values.
+//
+// ss is an optional sharding of log streams among storage nodes. Only log streams owned by the current node are returned if it is set.
+func (s *Storage) RunRemoteQuery(ctx context.Context, tenantIDs []TenantID, q *Query, ss *StreamSharding, writeBlock WriteBlockFunc) error {
+	if n := len(q.pipes); n > 0 {
+		if ps, ok := q.pipes[n-1].(*pipeStats); ok {
+			psCopy := *ps
+			psCopy.exportState = true
+
+			qCopy := *q
+			qCopy.pipes = append([]pipe{}, q.pipes...)
+			qCopy.pipes[n-1] = &psCopy
+			q = &qCopy
+		}
+	}
+
+	writeBlockResult := newWriteBlockResultFunc(writeBlock)
+	return s.runQueryWithSharding(ctx, tenantIDs, q, ss, writeBlockResult)
+}
+
+func newWriteBlockResultFunc(writeBlock WriteBlockFunc) func(workerID uint, br *blockResult) {
+	return func(workerID uint, br *blockResult) {
 		if br.rowsLen == 0 {
 			return
 		}
@@ -106,19 +148,25 @@ func (s *Storage) RunQuery(ctx context.Context, tenantIDs []TenantID, q *Query,
 		putBlockRows(brs)
 	}
 
-	return s.runQuery(ctx, tenantIDs, q, writeBlockResult)
 }
 
+// runQueryFunc must run q for the given tenantIDs and call writeBlock for the results.
+type runQueryFunc func(ctx context.Context, tenantIDs []TenantID, q *Query, writeBlock func(workerID uint, br *blockResult)) error
+
 func (s *Storage) runQuery(ctx context.Context, tenantIDs []TenantID, q *Query, writeBlockResultFunc func(workerID uint, br *blockResult)) error {
-	qNew, err := s.initFilterInValues(ctx, tenantIDs, q)
+	return s.runQueryWithSharding(ctx, tenantIDs, q, nil, writeBlockResultFunc)
+}
+
+func (s *Storage) runQueryWithSharding(ctx context.Context, tenantIDs []TenantID, q *Query, ss *StreamSharding, writeBlockResultFunc func(workerID uint, br *blockResult)) error {
+	qNew, err := initFilterInValues(ctx, s.runQuery, tenantIDs, q)
 	if err != nil {
 		return err
 	}
-	qNew, err = s.initJoinMaps(ctx, tenantIDs, qNew)
+	qNew, err = initJoinMaps(ctx, s.runQuery, tenantIDs, qNew)
 	if err != nil {
 		return err
 	}
-	qNew, err = s.initUnionQueries(tenantIDs, qNew)
+	qNew, err = initUnionQueries(s.runQuery, tenantIDs, qNew)
 	if err != nil {
 		return err
 	}
@@ -141,6 +189,7 @@ func (s *Storage) runQuery(ctx context.Context, tenantIDs []TenantID, q *Query,
 		neededColumnNames:   neededColumnNames,
 		unneededColumnNames: unneededColumnNames,
 		needAllColumns:      slices.Contains(neededColumnNames, "*"),
+		streamSharding:      ss,
 	}
 
 	workersCount := cgroup.AvailableCPUs()
@@ -204,6 +253,10 @@ func (s *Storage) runQuery(ctx context.Context, tenantIDs []TenantID, q *Query,
 
 // GetFieldNames returns field names from q results for the given tenantIDs.
 func (s *Storage) GetFieldNames(ctx context.Context, tenantIDs []TenantID, q *Query) ([]ValueWithHits, error) {
+	return getFieldNames(ctx, s.runQuery, tenantIDs, q)
+}
+
+func getFieldNames(ctx context.Context, runQuery runQueryFunc, tenantIDs []TenantID, q *Query) ([]ValueWithHits, error) {
 	pipes := append([]pipe{}, q.pipes...)
 	pipeStr := "field_names"
 	lex := newLexer(pipeStr, q.timestamp)
@@ -221,15 +274,14 @@ func (s *Storage) GetFieldNames(ctx context.Context, tenantIDs []TenantID, q *Qu
 
 	pipes = append(pipes, pf)
 
-	q = &Query{
-		f:     q.f,
-		pipes: pipes,
-	}
+	qNew := *q
+	qNew.pipes = pipes
+	q = &qNew
 
-	return s.runValuesWithHitsQuery(ctx, tenantIDs, q)
+	return runValuesWithHitsQuery(ctx, runQuery, tenantIDs, q)
 }
 
-func (s *Storage) getJoinMap(ctx context.Context, tenantIDs []TenantID, q *Query, byFields []string, prefix string) (map[string][][]Field, error) {
+func getJoinMap(ctx context.Context, runQuery runQueryFunc, tenantIDs []TenantID, q *Query, byFields []string, prefix string) (map[string][][]Field, error) {
 	// TODO: track memory usage
 
 	m := make(map[string][][]Field)
@@ -284,7 +336,7 @@ func (s *Storage) getJoinMap(ctx context.Context, tenantIDs []TenantID, q *Query
 		}
 	}
 
-	if err := s.runQuery(ctx, tenantIDs, q, writeBlockResult); err != nil {
+	if err := runQuery(ctx, tenantIDs, q, writeBlockResult); err != nil {
 		return nil, err
 	}
 
@@ -298,7 +350,7 @@ func marshalStrings(dst []byte, a []string) []byte {
 	return dst
 }
 
-func (s *Storage) getFieldValuesNoHits(ctx context.Context, tenantIDs []TenantID, q *Query, fieldName string) ([]string, error) {
+func getFieldValuesNoHits(ctx context.Context, runQuery runQueryFunc, tenantIDs []TenantID, q *Query, fieldName string) ([]string, error) {
 	// TODO: track memory usage
 
 	pipes := append([]pipe{}, q.pipes...)
@@ -317,15 +369,14 @@ func (s *Storage) getFieldValuesNoHits(ctx context.Context, tenantIDs []TenantID
 
 	pipes = append(pipes, pu)
 
-	q = &Query{
-		f:     q.f,
-		pipes: pipes,
-	}
+	qNew := *q
+	qNew.pipes = pipes
+	q = &qNew
 
-	cpusCount := cgroup.AvailableCPUs()
-	valuesPerCPU := make([][]string, cpusCount)
-	allocatorsPerCPU := make([]chunkedAllocator, cpusCount)
-	writeBlockResult := func(workerID uint, br *blockResult) {
+	var valuesAll []string
+	var valuesLock sync.Mutex
+	var a chunkedAllocator
+	writeBlockResult := func(_ uint, br *blockResult) {
 		if br.rowsLen == 0 {
 			return
 		}
@@ -337,28 +388,18 @@ func (s *Storage) getFieldValuesNoHits(ctx context.Context, tenantIDs []TenantID
 
 		columnValues := cs[0].getValues(br)
 
-		valuesDst := valuesPerCPU[workerID]
-		a := allocatorsPerCPU[workerID]
+		valuesLock.Lock()
 		for i := range columnValues {
 			vCopy := a.cloneString(columnValues[i])
-			valuesDst = append(valuesDst, vCopy)
+			valuesAll = append(valuesAll, vCopy)
 		}
-		valuesPerCPU[workerID] = valuesDst
+		valuesLock.Unlock()
 	}
 
-	if err := s.runQuery(ctx, tenantIDs, q, writeBlockResult); err != nil {
+	if err := runQuery(ctx, tenantIDs, q, writeBlockResult); err != nil {
 		return nil, err
 	}
 
-	valuesLen := 0
-	for _, values := range valuesPerCPU {
-		valuesLen += len(values)
-	}
-	valuesAll := make([]string, 0, valuesLen)
-	for _, values := range valuesPerCPU {
-		valuesAll = append(valuesAll, values...)
-	}
-
 	return valuesAll, nil
 }
 
@@ -366,6 +407,10 @@ func (s *Storage) getFieldValuesNoHits(ctx context.Context, tenantIDs []TenantID
 //
 // If limit > 0, then up to limit unique values are returned.
 func (s *Storage) GetFieldValues(ctx context.Context, tenantIDs []TenantID, q *Query, fieldName string, limit uint64) ([]ValueWithHits, error) {
+	return getFieldValues(ctx, s.runQuery, tenantIDs, q, fieldName, limit)
+}
+
+func getFieldValues(ctx context.Context, runQuery runQueryFunc, tenantIDs []TenantID, q *Query, fieldName string, limit uint64) ([]ValueWithHits, error) {
 	pipes := append([]pipe{}, q.pipes...)
 	quotedFieldName := quoteTokenIfNeeded(fieldName)
 	pipeStr := fmt.Sprintf("field_values %s limit %d", quotedFieldName, limit)
@@ -382,12 +427,11 @@ func (s *Storage) GetFieldValues(ctx context.Context, tenantIDs []TenantID, q *Q
 
 	pipes = append(pipes, pu)
 
-	q = &Query{
-		f:     q.f,
-		pipes: pipes,
-	}
+	qNew := *q
+	qNew.pipes = pipes
+	q = &qNew
 
-	return s.runValuesWithHitsQuery(ctx, tenantIDs, q)
+	return runValuesWithHitsQuery(ctx, runQuery, tenantIDs, q)
 }
 
 // ValueWithHits contains value and hits.
@@ -429,7 +473,11 @@ func sortValuesWithHits(results []ValueWithHits) {
 
 // GetStreamFieldNames returns stream field names from q results for the given tenantIDs.
 func (s *Storage) GetStreamFieldNames(ctx context.Context, tenantIDs []TenantID, q *Query) ([]ValueWithHits, error) {
-	streams, err := s.GetStreams(ctx, tenantIDs, q, math.MaxUint64)
+	return getStreamFieldNames(ctx, s.runQuery, tenantIDs, q)
+}
+
+func getStreamFieldNames(ctx context.Context, runQuery runQueryFunc, tenantIDs []TenantID, q *Query) ([]ValueWithHits, error) {
+	streams, err := getFieldValues(ctx, runQuery, tenantIDs, q, "_stream", math.MaxUint64)
 	if err != nil {
 		return nil, err
 	}
@@ -453,7 +501,11 @@ func (s *Storage) GetStreamFieldNames(ctx context.Context, tenantIDs []TenantID,
 //
 // If limit > 9, then up to limit unique values are returned.
 func (s *Storage) GetStreamFieldValues(ctx context.Context, tenantIDs []TenantID, q *Query, fieldName string, limit uint64) ([]ValueWithHits, error) {
-	streams, err := s.GetStreams(ctx, tenantIDs, q, math.MaxUint64)
+	return getStreamFieldValues(ctx, s.runQuery, tenantIDs, q, fieldName, limit)
+}
+
+func getStreamFieldValues(ctx context.Context, runQuery runQueryFunc, tenantIDs []TenantID, q *Query, fieldName string, limit uint64) ([]ValueWithHits, error) {
+	streams, err := getFieldValues(ctx, runQuery, tenantIDs, q, "_stream", math.MaxUint64)
 	if err != nil {
 		return nil, err
 	}
@@ -493,7 +545,7 @@ func (s *Storage) GetStreamIDs(ctx context.Context, tenantIDs []TenantID, q *Que
 	return s.GetFieldValues(ctx, tenantIDs, q, "_stream_id", limit)
 }
 
-func (s *Storage) runValuesWithHitsQuery(ctx context.Context, tenantIDs []TenantID, q *Query) ([]ValueWithHits, error) {
+func runValuesWithHitsQuery(ctx context.Context, runQuery runQueryFunc, tenantIDs []TenantID, q *Query) ([]ValueWithHits, error) {
 	var results []ValueWithHits
 	var resultsLock sync.Mutex
 	writeBlockResult := func(_ uint, br *blockResult) {
@@ -522,7 +574,7 @@ func (s *Storage) runValuesWithHitsQuery(ctx context.Context, tenantIDs []Tenant
 		resultsLock.Unlock()
 	}
 
-	err := s.runQuery(ctx, tenantIDs, q, writeBlockResult)
+	err := runQuery(ctx, tenantIDs, q, writeBlockResult)
 	if err != nil {
 		return nil, err
 	}
@@ -531,13 +583,13 @@ func (s *Storage) runValuesWithHitsQuery(ctx context.Context, tenantIDs []Tenant
 	return results, nil
 }
 
-func (s *Storage) initFilterInValues(ctx context.Context, tenantIDs []TenantID, q *Query) (*Query, error) {
+func initFilterInValues(ctx context.Context, runQuery runQueryFunc, tenantIDs []TenantID, q *Query) (*Query, error) {
 	if !hasFilterInWithQueryForFilter(q.f) && !hasFilterInWithQueryForPipes(q.pipes) {
 		return q, nil
 	}
 
 	getFieldValues := func(q *Query, fieldName string) ([]string, error) {
-		return s.getFieldValuesNoHits(ctx, tenantIDs, q, fieldName)
+		return getFieldValuesNoHits(ctx, runQuery, tenantIDs, q, fieldName)
 	}
 	var cache inValuesCache
 	fNew, err := initFilterInValuesForFilter(&cache, q.f, getFieldValues)
@@ -548,24 +600,23 @@ func (s *Storage) initFilterInValues(ctx context.Context, tenantIDs []TenantID,
 	if err != nil {
 		return nil, err
 	}
-	qNew := &Query{
-		f:     fNew,
-		pipes: pipesNew,
-	}
-	return qNew, nil
+	qNew := *q
+	qNew.f = fNew
+	qNew.pipes = pipesNew
+	return &qNew, nil
 }
 
 type inValuesCache struct {
 	m map[string][]string
 }
 
-func (s *Storage) initUnionQueries(tenantIDs []TenantID, q *Query) (*Query, error) {
+func initUnionQueries(runQuery runQueryFunc, tenantIDs []TenantID, q *Query) (*Query, error) {
 	if !hasUnionPipes(q.pipes) {
 		return q, nil
 	}
 
 	runUnionQuery := func(ctx context.Context, q *Query, writeBlock func(workerID uint, br *blockResult)) error {
-		return s.runQuery(ctx, tenantIDs, q, writeBlock)
+		return runQuery(ctx, tenantIDs, q, writeBlock)
 	}
 
 	pipesNew := make([]pipe, len(q.pipes))
@@ -575,11 +626,9 @@ func (s *Storage) initUnionQueries(tenantIDs []TenantID, q *Query) (*Query, erro
 		}
 		pipesNew[i] = p
 	}
-	qNew := &Query{
-		f:     q.f,
-		pipes: pipesNew,
-	}
-	return qNew, nil
+	qNew := *q
+	qNew.pipes = pipesNew
+	return &qNew, nil
 }
 
 func hasUnionPipes(pipes []pipe) bool {
@@ -593,13 +642,13 @@ func hasUnionPipes(pipes []pipe) bool {
 
 type getJoinMapFunc func(q *Query, byFields []string, prefix string) (map[string][][]Field, error)
 
-func (s *Storage) initJoinMaps(ctx context.Context, tenantIDs []TenantID, q *Query) (*Query, error) {
+func initJoinMaps(ctx context.Context, runQuery runQueryFunc, tenantIDs []TenantID, q *Query) (*Query, error) {
 	if !hasJoinPipes(q.pipes) {
 		return q, nil
 	}
 
 	getJoinMap := func(q *Query, byFields []string, prefix string) (map[string][][]Field, error) {
-		return s.getJoinMap(ctx, tenantIDs, q, byFields, prefix)
+		return getJoinMap(ctx, runQuery, tenantIDs, q, byFields, prefix)
 	}
 
 	pipesNew := make([]pipe, len(q.pipes))
@@ -613,11 +662,9 @@ func (s *Storage) initJoinMaps(ctx context.Context, tenantIDs []TenantID, q *Que
 		}
 		pipesNew[i] = p
 	}
-	qNew := &Query{
-		f:     q.f,
-		pipes: pipesNew,
-	}
-	return qNew, nil
+	qNew := *q
+	qNew.pipes = pipesNew
+	return &qNew, nil
 }
 
 func hasJoinPipes(pipes []pipe) bool {
@@ -936,6 +983,11 @@ func (pt *partition) search(sf *StreamFilter, f filter, so *genericSearchOptions
 	if hasStreamFilters(f) {
 		f = initStreamFilters(so.tenantIDs, pt.idb, f)
 	}
+	var deleteFilters []*deleteFilter
+	if !so.ignoreDeleteFilters {
+		dfs := pt.s.getDeleteFilters(so.tenantIDs, so.minTimestamp, so.maxTimestamp)
+		deleteFilters = pt.initDeleteFiltersForSearch(dfs)
+	}
 	soInternal := &searchOptions{
 		tenantIDs:           tenantIDs,
 		streamIDs:           streamIDs,
@@ -945,6 +997,8 @@ func (pt *partition) search(sf *StreamFilter, f filter, so *genericSearchOptions
 		neededColumnNames:   so.neededColumnNames,
 		unneededColumnNames: so.unneededColumnNames,
 		needAllColumns:      so.needAllColumns,
+		deleteFilters:       deleteFilters,
+		streamSharding:      so.streamSharding,
 	}
 	return pt.ddb.search(soInternal, workCh, stopCh)
 }
@@ -1077,6 +1131,9 @@ func (p *part) searchByTenantIDs(so *searchOptions, bhss *blockHeaders, workCh c
 
 	bswb := getBlockSearchWorkBatch()
 	scheduleBlockSearch := func(bh *blockHeader) bool {
+		if !so.streamSharding.needStream(&bh.streamID) {
+			return true
+		}
 		if bswb.appendBlockSearchWork(p, so, bh) {
 			return true
 		}
@@ -1179,6 +1236,9 @@ func (p *part) searchByStreamIDs(so *searchOptions, bhss *blockHeaders, workCh c
 
 	bswb := getBlockSearchWorkBatch()
 	scheduleBlockSearch := func(bh *blockHeader) bool {
+		if !so.streamSharding.needStream(&bh.streamID) {
+			return true
+		}
 		if bswb.appendBlockSearchWork(p, so, bh) {
 			return true
 		}
@@ -0,0 +1,148 @@
+package logstorage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/snapshot/snapshotutil"
+)
+
+// CreateSnapshot creates an instant snapshot for s and returns its name.
+//
+// The snapshot is created at <storagePath>/snapshots/<snapshotName>. It has the same layout as the storage directory,
+// so it can be opened with MustOpenStorage after copying it to another place. The snapshot can be backed up with vmbackup.
+//
+// The snapshot files are hard links to the storage files, so the snapshot doesn't occupy additional disk space
+// until the storage files are removed by background merges.
+func (s *Storage) CreateSnapshot() (string, error) {
+	logger.Infof("creating Storage snapshot for %q...", s.path)
+	startTime := time.Now()
+
+	s.snapshotLock.Lock()
+	defer s.snapshotLock.Unlock()
+
+	snapshotName := snapshotutil.NewName()
+	dstDir := filepath.Join(s.path, snapshotsDirname, snapshotName)
+	if err := s.createSnapshotAt(dstDir); err != nil {
+		fs.MustRemoveAll(dstDir)
+		return "", err
+	}
+
+	logger.Infof("created Storage snapshot for %q at %q in %.3f seconds", s.path, dstDir, time.Since(startTime).Seconds())
+	return snapshotName, nil
+}
+
+func (s *Storage) createSnapshotAt(dstDir string) error {
+	var ptws []*partitionWrapper
+
+	s.partitionsLock.Lock()
+	for _, ptw := range s.partitions {
+		ptw.incRef()
+		ptws = append(ptws, ptw)
+	}
+	s.partitionsLock.Unlock()
+
+	defer func() {
+		for _, ptw := range ptws {
+			ptw.decRef()
+		}
+	}()
+
+	s.wg.Add(1)
+	defer s.wg.Done()
+
+	fs.MustMkdirFailIfExist(dstDir)
+
+	dstPartitionsPath := filepath.Join(dstDir, partitionsDirname)
+	fs.MustMkdirFailIfExist(dstPartitionsPath)
+	for _, ptw := range ptws {
+		dstPartitionPath := filepath.Join(dstPartitionsPath, ptw.pt.name)
+		if err := ptw.pt.createSnapshotAt(dstPartitionPath); err != nil {
+			return fmt.Errorf("cannot create snapshot for partition %q: %w", ptw.pt.name, err)
+		}
+	}
+	fs.MustSyncPath(dstPartitionsPath)
+
+	// Copy unfinished delete tasks, so they are resumed after restoring the storage from the snapshot.
+	s.deleteTasksLock.Lock()
+	srcDeleteTasksPath := filepath.Join(s.path, deleteTasksFilename)
+	if fs.IsPathExist(srcDeleteTasksPath) {
+		dstDeleteTasksPath := filepath.Join(dstDir, deleteTasksFilename)
+		fs.MustCopyFile(srcDeleteTasksPath, dstDeleteTasksPath)
+	}
+	s.deleteTasksLock.Unlock()
+
+	fs.MustSyncPath(dstDir)
+	fs.MustSyncPath(filepath.Dir(dstDir))
+
+	return nil
+}
+
+// ListSnapshots returns sorted list of existing snapshots for s.
+func (s *Storage) ListSnapshots() ([]string, error) {
+	snapshotsPath := filepath.Join(s.path, snapshotsDirname)
+	d, err := os.Open(snapshotsPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open snapshots directory: %w", err)
+	}
+	defer fs.MustClose(d)
+
+	fnames, err := d.Readdirnames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read snapshots directory at %q: %w", snapshotsPath, err)
+	}
+	snapshotNames := make([]string, 0, len(fnames))
+	for _, fname := range fnames {
+		if err := snapshotutil.Validate(fname); err != nil {
+			continue
+		}
+		snapshotNames = append(snapshotNames, fname)
+	}
+	sort.Strings(snapshotNames)
+	return snapshotNames, nil
+}
+
+// DeleteSnapshot deletes the snapshot with the given snapshotName.
+func (s *Storage) DeleteSnapshot(snapshotName string) error {
+	if err := snapshotutil.Validate(snapshotName); err != nil {
+		return fmt.Errorf("invalid snapshotName %q: %w", snapshotName, err)
+	}
+	snapshotPath := filepath.Join(s.path, snapshotsDirname, snapshotName)
+	if !fs.IsPathExist(snapshotPath) {
+		return fmt.Errorf("cannot find snapshot %q", snapshotName)
+	}
+
+	logger.Infof("deleting snapshot %q...", snapshotPath)
+	startTime := time.Now()
+
+	fs.MustRemoveDirAtomic(snapshotPath)
+
+	logger.Infof("deleted snapshot %q in %.3f seconds", snapshotPath, time.Since(startTime).Seconds())
+	return nil
+}
+
+// DeleteStaleSnapshots deletes snapshots older than the given maxAge.
+func (s *Storage) DeleteStaleSnapshots(maxAge time.Duration) error {
+	snapshotNames, err := s.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	expireDeadline := time.Now().UTC().Add(-maxAge)
+	for _, snapshotName := range snapshotNames {
+		t, err := snapshotutil.Time(snapshotName)
+		if err != nil {
+			return fmt.Errorf("cannot parse snapshot date from %q: %w", snapshotName, err)
+		}
+		if t.Before(expireDeadline) {
+			if err := s.DeleteSnapshot(snapshotName); err != nil {
+				return fmt.Errorf("cannot delete snapshot %q: %w", snapshotName, err)
+			}
+		}
+	}
+	return nil
+}
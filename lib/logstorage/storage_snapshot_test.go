@@ -0,0 +1,156 @@
+package logstorage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+)
+
+func TestStorageSnapshot(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	sc := &StorageConfig{
+		Retention: 7 * 24 * time.Hour,
+	}
+	s := MustOpenStorage(path, sc)
+
+	tenantID := TenantID{AccountID: 1, ProjectID: 2}
+	const days = 3
+	const rowsPerDay = 100
+	now := time.Now().UnixNano()
+	for day := 0; day < days; day++ {
+		lr := GetLogRows([]string{"instance"}, nil, nil, "")
+		for i := 0; i < rowsPerDay; i++ {
+			fields := []Field{
+				{
+					Name:  "instance",
+					Value: fmt.Sprintf("host-%d", i%3),
+				},
+				{
+					Name:  "_msg",
+					Value: fmt.Sprintf("message %d at day %d", i, day),
+				},
+			}
+			lr.MustAdd(tenantID, now-int64(day)*nsecsPerDay-int64(i)*1e9, fields, nil)
+		}
+		s.MustAddRows(lr)
+		PutLogRows(lr)
+	}
+	s.debugFlush()
+
+	snapshotName, err := s.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("cannot create snapshot: %s", err)
+	}
+
+	// Add more rows after the snapshot creation. They mustn't be visible in the snapshot.
+	lr := GetLogRows(nil, nil, nil, "")
+	lr.MustAdd(tenantID, now, []Field{{Name: "_msg", Value: "after the snapshot"}}, nil)
+	s.MustAddRows(lr)
+	PutLogRows(lr)
+	s.debugFlush()
+
+	snapshots, err := s.ListSnapshots()
+	if err != nil {
+		t.Fatalf("cannot list snapshots: %s", err)
+	}
+	if len(snapshots) != 1 || snapshots[0] != snapshotName {
+		t.Fatalf("unexpected snapshots; got %q; want %q", snapshots, []string{snapshotName})
+	}
+
+	// Open the storage from the snapshot and verify its contents.
+	snapshotPath := filepath.Join(path, snapshotsDirname, snapshotName)
+	sSnapshot := MustOpenStorage(snapshotPath, sc)
+	if n := testCountRows(t, sSnapshot, tenantID); n != days*rowsPerDay {
+		t.Fatalf("unexpected number of rows in the snapshot; got %d; want %d", n, days*rowsPerDay)
+	}
+	sSnapshot.MustClose()
+
+	if n := testCountRows(t, s, tenantID); n != days*rowsPerDay+1 {
+		t.Fatalf("unexpected number of rows in the storage; got %d; want %d", n, days*rowsPerDay+1)
+	}
+
+	if err := s.DeleteSnapshot(snapshotName); err != nil {
+		t.Fatalf("cannot delete snapshot: %s", err)
+	}
+	snapshots, err = s.ListSnapshots()
+	if err != nil {
+		t.Fatalf("cannot list snapshots: %s", err)
+	}
+	if len(snapshots) != 0 {
+		t.Fatalf("unexpected snapshots after the deletion: %q", snapshots)
+	}
+	if err := s.DeleteSnapshot(snapshotName); err == nil {
+		t.Fatalf("expecting non-nil error when deleting missing snapshot")
+	}
+	if err := s.DeleteSnapshot("../foo"); err == nil {
+		t.Fatalf("expecting non-nil error when deleting snapshot with invalid name")
+	}
+
+	// The storage must remain readable after the snapshot deletion.
+	if n := testCountRows(t, s, tenantID); n != days*rowsPerDay+1 {
+		t.Fatalf("unexpected number of rows in the storage after snapshot deletion; got %d; want %d", n, days*rowsPerDay+1)
+	}
+
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
+
+func TestStorageDeleteStaleSnapshots(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	s := MustOpenStorage(path, &StorageConfig{})
+
+	snapshotName, err := s.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("cannot create snapshot: %s", err)
+	}
+
+	// The snapshot isn't stale yet.
+	if err := s.DeleteStaleSnapshots(time.Hour); err != nil {
+		t.Fatalf("cannot delete stale snapshots: %s", err)
+	}
+	snapshots, err := s.ListSnapshots()
+	if err != nil {
+		t.Fatalf("cannot list snapshots: %s", err)
+	}
+	if len(snapshots) != 1 || snapshots[0] != snapshotName {
+		t.Fatalf("unexpected snapshots; got %q; want %q", snapshots, []string{snapshotName})
+	}
+
+	if err := s.DeleteStaleSnapshots(-time.Hour); err != nil {
+		t.Fatalf("cannot delete stale snapshots: %s", err)
+	}
+	snapshots, err = s.ListSnapshots()
+	if err != nil {
+		t.Fatalf("cannot list snapshots: %s", err)
+	}
+	if len(snapshots) != 0 {
+		t.Fatalf("unexpected snapshots after deleting stale snapshots: %q", snapshots)
+	}
+
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
+
+func testCountRows(t *testing.T, s *Storage, tenantID TenantID) uint64 {
+	t.Helper()
+	q := mustParseQuery(`*`)
+	var rowsCount atomic.Uint64
+	writeBlock := func(_ uint, timestamps []int64, _ []BlockColumn) {
+		rowsCount.Add(uint64(len(timestamps)))
+	}
+	if err := s.RunQuery(context.Background(), []TenantID{tenantID}, q, writeBlock); err != nil {
+		t.Fatalf("unexpected error in query: %s", err)
+	}
+	return rowsCount.Load()
+}
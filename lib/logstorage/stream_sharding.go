@@ -0,0 +1,101 @@
+package logstorage
+
+import (
+	"fmt"
+	"slices"
+)
+
+// StreamSharding contains the information about the distribution of log streams among storage nodes in cluster mode.
+//
+// Every log stream is stored at ReplicationFactor consecutive storage nodes starting from the node returned by GetStreamNodeIdx.
+// Log entries, which couldn't be sent to any of these nodes during ingestion, are stored at some other storage node.
+type StreamSharding struct {
+	// NodeIdx is the index of the current storage node.
+	NodeIdx int
+
+	// NodesCount is the total number of storage nodes.
+	NodesCount int
+
+	// ReplicationFactor is the number of storage nodes every log stream is replicated to.
+	ReplicationFactor int
+
+	// UnavailableNodeIdxs contains indexes of storage nodes, which do not participate in the query.
+	//
+	// Log streams replicated to unavailable nodes are returned by the first available replica.
+	UnavailableNodeIdxs []int
+
+	// RetryNodeIdxs contains indexes of storage nodes, which failed to execute the query before returning any data.
+	//
+	// If it is non-empty, then only log streams, which had to be returned by these nodes, are returned by the next available replica.
+	RetryNodeIdxs []int
+}
+
+// String returns string representation of ss.
+func (ss *StreamSharding) String() string {
+	return fmt.Sprintf("node %d of %d (replicationFactor=%d, unavailableNodes=%v, retryNodes=%v)",
+		ss.NodeIdx, ss.NodesCount, ss.ReplicationFactor, ss.UnavailableNodeIdxs, ss.RetryNodeIdxs)
+}
+
+// GetStreamNodeIdx returns the index of the first storage node for the log stream with the given streamHash.
+//
+// The rest of replicas for the log stream are stored at the next nodes.
+func GetStreamNodeIdx(streamHash uint64, nodesCount int) int {
+	return int(streamHash % uint64(nodesCount))
+}
+
+// needStream returns true if the stream with the given sid must be returned by the current node.
+func (ss *StreamSharding) needStream(sid *streamID) bool {
+	if ss == nil || ss.ReplicationFactor <= 1 {
+		// Every log stream is stored at a single node, so return all the logs stored at the current node.
+		return true
+	}
+
+	idx := GetStreamNodeIdx(sid.id.lo, ss.NodesCount)
+	if !ss.isReplica(idx) {
+		// The current node isn't a replica for the given stream, so it may contain only log entries,
+		// which were rerouted from unavailable replicas during ingestion. These log entries are stored only at the current node.
+		// They are returned during the initial query, so they mustn't be returned again during the retry.
+		return len(ss.RetryNodeIdxs) == 0
+	}
+
+	nodeIdx := ss.getFirstAvailableReplica(idx, nil)
+	if len(ss.RetryNodeIdxs) == 0 {
+		return nodeIdx == ss.NodeIdx
+	}
+	if !slices.Contains(ss.RetryNodeIdxs, nodeIdx) {
+		// The stream has been already returned by other node.
+		return false
+	}
+	return ss.getFirstAvailableReplica(idx, ss.RetryNodeIdxs) == ss.NodeIdx
+}
+
+// isReplica returns true if the current node is a replica for the log stream starting at the node with the given idx.
+func (ss *StreamSharding) isReplica(idx int) bool {
+	for i := 0; i < ss.ReplicationFactor; i++ {
+		if idx == ss.NodeIdx {
+			return true
+		}
+		idx++
+		if idx >= ss.NodesCount {
+			idx = 0
+		}
+	}
+	return false
+}
+
+// getFirstAvailableReplica returns the index of the first replica starting at the node with the given idx,
+// which is missing in ss.UnavailableNodeIdxs and in excludeNodeIdxs.
+//
+// -1 is returned if there are no such replicas.
+func (ss *StreamSharding) getFirstAvailableReplica(idx int, excludeNodeIdxs []int) int {
+	for i := 0; i < ss.ReplicationFactor; i++ {
+		if !slices.Contains(ss.UnavailableNodeIdxs, idx) && !slices.Contains(excludeNodeIdxs, idx) {
+			return idx
+		}
+		idx++
+		if idx >= ss.NodesCount {
+			idx = 0
+		}
+	}
+	return -1
+}
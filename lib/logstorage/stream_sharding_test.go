@@ -0,0 +1,59 @@
+package logstorage
+
+import (
+	"testing"
+)
+
+func TestStreamShardingNeedStream(t *testing.T) {
+	f := func(streamNodeIdx int, unavailableNodeIdxs, retryNodeIdxs []int, nodeIdxsExpected []int) {
+		t.Helper()
+
+		sid := &streamID{
+			id: u128{
+				lo: uint64(streamNodeIdx),
+			},
+		}
+		var nodeIdxs []int
+		for nodeIdx := 0; nodeIdx < 4; nodeIdx++ {
+			ss := &StreamSharding{
+				NodeIdx:             nodeIdx,
+				NodesCount:          4,
+				ReplicationFactor:   2,
+				UnavailableNodeIdxs: unavailableNodeIdxs,
+				RetryNodeIdxs:       retryNodeIdxs,
+			}
+			if ss.needStream(sid) {
+				nodeIdxs = append(nodeIdxs, nodeIdx)
+			}
+		}
+		if len(nodeIdxs) != len(nodeIdxsExpected) {
+			t.Fatalf("unexpected nodes for the stream; got %v; want %v", nodeIdxs, nodeIdxsExpected)
+		}
+		for i := range nodeIdxs {
+			if nodeIdxs[i] != nodeIdxsExpected[i] {
+				t.Fatalf("unexpected nodes for the stream; got %v; want %v", nodeIdxs, nodeIdxsExpected)
+			}
+		}
+	}
+
+	// The stream is returned by the first replica and by non-replica nodes, which may contain rerouted logs.
+	f(1, nil, nil, []int{0, 1, 3})
+	f(3, nil, nil, []int{1, 2, 3})
+
+	// The stream is returned by the next replica if the first replica is unavailable.
+	f(1, []int{1}, nil, []int{0, 2, 3})
+
+	// The stream isn't returned by replicas if all of them are unavailable.
+	f(1, []int{1, 2}, nil, []int{0, 3})
+
+	// The stream is returned only by the next replica during the retry for the failed first replica.
+	f(1, nil, []int{1}, []int{2})
+	f(1, []int{0}, []int{1}, []int{2})
+
+	// The stream isn't returned during the retry for the node, which isn't its first available replica.
+	f(1, nil, []int{2}, nil)
+	f(1, nil, []int{0}, nil)
+
+	// The stream cannot be returned during the retry if all of its replicas are unavailable.
+	f(1, []int{2}, []int{1}, nil)
+}
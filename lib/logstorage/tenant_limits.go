@@ -0,0 +1,618 @@
+package logstorage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// TenantLimits contains per-tenant limits.
+//
+// TenantLimits must be obtained via ParseTenantLimits and passed to Storage via StorageConfig.TenantLimits or Storage.SetTenantLimits.
+type TenantLimits struct {
+	// m contains limits per each configured tenant.
+	m map[TenantID]*tenantLimit
+
+	// tenantIDs contains sorted list of tenants from m.
+	tenantIDs []TenantID
+
+	// maxRetention is the maximum retention across the configured tenants.
+	maxRetention time.Duration
+}
+
+type tenantLimit struct {
+	// retention is the retention for the tenant logs. The default retention is used if it is zero.
+	retention time.Duration
+
+	// maxBytesPerDay is the maximum number of bytes, which can be ingested for the tenant per day. There is no limit if it is zero.
+	maxBytesPerDay uint64
+
+	// maxNewStreamsPerHour is the maximum number of new streams, which can be created for the tenant per hour. There is no limit if it is zero.
+	maxNewStreamsPerHour uint64
+}
+
+type tenantLimitConfig struct {
+	Tenant               string              `yaml:"tenant"`
+	Retention            *promutils.Duration `yaml:"retention,omitempty"`
+	MaxBytesPerDay       string              `yaml:"max_bytes_per_day,omitempty"`
+	MaxNewStreamsPerHour uint64              `yaml:"max_new_streams_per_hour,omitempty"`
+}
+
+// ParseTenantLimits parses per-tenant limits from YAML data.
+//
+// The data must contain a list of per-tenant limits in the following form:
+//
+//   - tenant: "<accountID>:<projectID>"
+//     retention: 30d
+//     max_bytes_per_day: 10GiB
+//     max_new_streams_per_hour: 1000
+//
+// All the limits are optional. Tenants, which are missing in the list, have no limits and use the default retention.
+func ParseTenantLimits(data []byte) (*TenantLimits, error) {
+	var cfgs []tenantLimitConfig
+	if err := yaml.UnmarshalStrict(data, &cfgs); err != nil {
+		return nil, fmt.Errorf("cannot parse tenant limits: %w", err)
+	}
+	tl := &TenantLimits{
+		m: make(map[TenantID]*tenantLimit, len(cfgs)),
+	}
+	for i := range cfgs {
+		cfg := &cfgs[i]
+		if cfg.Tenant == "" {
+			return nil, fmt.Errorf("missing `tenant` field at tenant limits #%d", i+1)
+		}
+		tenantID, err := ParseTenantID(cfg.Tenant)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse `tenant` at tenant limits #%d: %w", i+1, err)
+		}
+		if _, ok := tl.m[tenantID]; ok {
+			return nil, fmt.Errorf("duplicate limits for tenant %s", &tenantID)
+		}
+		var lim tenantLimit
+		if cfg.Retention != nil {
+			lim.retention = cfg.Retention.Duration()
+			if lim.retention < 24*time.Hour {
+				return nil, fmt.Errorf("retention for tenant %s cannot be smaller than a day; got %s", &tenantID, lim.retention)
+			}
+		}
+		if cfg.MaxBytesPerDay != "" {
+			n, err := flagutil.ParseBytes(cfg.MaxBytesPerDay)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse `max_bytes_per_day` for tenant %s: %w", &tenantID, err)
+			}
+			if n < 0 {
+				return nil, fmt.Errorf("`max_bytes_per_day` for tenant %s cannot be negative; got %d", &tenantID, n)
+			}
+			lim.maxBytesPerDay = uint64(n)
+		}
+		lim.maxNewStreamsPerHour = cfg.MaxNewStreamsPerHour
+
+		tl.m[tenantID] = &lim
+		tl.tenantIDs = append(tl.tenantIDs, tenantID)
+		tl.maxRetention = max(tl.maxRetention, lim.retention)
+	}
+	sort.Slice(tl.tenantIDs, func(i, j int) bool {
+		return tl.tenantIDs[i].less(&tl.tenantIDs[j])
+	})
+	return tl, nil
+}
+
+func (tl *TenantLimits) getLimit(tenantID TenantID) *tenantLimit {
+	if tl == nil {
+		return nil
+	}
+	return tl.m[tenantID]
+}
+
+// SetTenantLimits sets per-tenant limits for s.
+//
+// tl must be obtained via ParseTenantLimits. Per-tenant limits are disabled if tl is nil.
+func (s *Storage) SetTenantLimits(tl *TenantLimits) {
+	s.tenantLimits.Store(tl)
+}
+
+// getMaxRetention returns the maximum retention across the default retention and per-tenant retentions.
+//
+// Partitions outside the maximum retention are dropped.
+func (s *Storage) getMaxRetention() time.Duration {
+	tl := s.tenantLimits.Load()
+	if tl == nil {
+		return s.retention
+	}
+	return max(s.retention, tl.maxRetention)
+}
+
+// getRetentionForTenant returns the retention for the given tenantID.
+func (s *Storage) getRetentionForTenant(tl *TenantLimits, tenantID TenantID) time.Duration {
+	lim := tl.getLimit(tenantID)
+	if lim == nil || lim.retention <= 0 {
+		return s.retention
+	}
+	return lim.retention
+}
+
+// getRetentionDeleteFilters returns filters for log entries outside the per-tenant retention.
+//
+// Per-tenant retention is applied with the precision of a day, e.g. in the same way as the retention for partitions.
+func (s *Storage) getRetentionDeleteFilters() []*deleteFilter {
+	tl := s.tenantLimits.Load()
+	if tl == nil {
+		return nil
+	}
+	maxRetention := s.getMaxRetention()
+
+	now := time.Now().UTC()
+	getMaxTimestamp := func(retention time.Duration) int64 {
+		minAllowedDay := now.Add(-retention).UnixNano() / nsecsPerDay
+		return minAllowedDay*nsecsPerDay - 1
+	}
+
+	var dfs []*deleteFilter
+	var tenantIDs []TenantID
+	for _, tenantID := range tl.tenantIDs {
+		retention := tl.m[tenantID].retention
+		if retention <= 0 {
+			// The default retention is applied to the tenant.
+			continue
+		}
+		tenantIDs = append(tenantIDs, tenantID)
+		if retention < maxRetention {
+			df := newRetentionDeleteFilter([]TenantID{tenantID}, false, getMaxTimestamp(retention))
+			dfs = append(dfs, df)
+		}
+	}
+	if s.retention < maxRetention {
+		// Apply the default retention to all the tenants except of tenants with the configured retention.
+		df := newRetentionDeleteFilter(tenantIDs, true, getMaxTimestamp(s.retention))
+		dfs = append(dfs, df)
+	}
+	return dfs
+}
+
+// tenantUsage contains usage stats for a single tenant.
+type tenantUsage struct {
+	// day is the current day for bytesIngested and rowsIngested.
+	day int64
+
+	// bytesIngested is the estimated number of bytes ingested for the tenant during the current day.
+	bytesIngested uint64
+
+	// rowsIngested is the number of log entries ingested for the tenant during the current day.
+	rowsIngested uint64
+
+	// hour is the current hour for newStreams.
+	hour int64
+
+	// newStreams is the number of new streams created for the tenant during the current hour.
+	newStreams uint64
+}
+
+// tenantRowsRejected contains the number of log entries rejected for a single tenant since the storage start.
+type tenantRowsRejected struct {
+	// retention is the number of log entries rejected because they are outside the tenant retention.
+	retention uint64
+
+	// maxBytesPerDay is the number of log entries rejected because of max_bytes_per_day limit.
+	maxBytesPerDay uint64
+
+	// maxNewStreamsPerHour is the number of log entries rejected because of max_new_streams_per_hour limit.
+	maxNewStreamsPerHour uint64
+}
+
+func (tu *tenantUsage) updateTime(currentTime time.Time) {
+	day := currentTime.UnixNano() / nsecsPerDay
+	if day != tu.day {
+		tu.day = day
+		tu.bytesIngested = 0
+		tu.rowsIngested = 0
+	}
+	hour := currentTime.Unix() / 3600
+	if hour != tu.hour {
+		tu.hour = hour
+		tu.newStreams = 0
+	}
+}
+
+// tenantsUsage tracks usage stats per tenant with the configured limits.
+type tenantsUsage struct {
+	mu sync.Mutex
+	m  map[TenantID]*tenantUsage
+
+	// day is the current day. It is used for evicting stats for inactive tenants.
+	day int64
+
+	// rowsRejected contains the number of rejected log entries per tenant.
+	//
+	// It isn't evicted together with m, since it is exported as counters, which mustn't go backwards.
+	// It contains only tenants with the configured limits, so it doesn't grow indefinitely.
+	rowsRejected map[TenantID]*tenantRowsRejected
+}
+
+// getLocked returns usage stats for the given tenantID.
+//
+// tsu.mu must be locked when calling this function.
+func (tsu *tenantsUsage) getLocked(tenantID TenantID, currentTime time.Time) *tenantUsage {
+	if day := currentTime.UnixNano() / nsecsPerDay; day != tsu.day {
+		tsu.day = day
+		// Evict stats for tenants without activity during the previous day, so they do not accumulate indefinitely.
+		for tenantID, tu := range tsu.m {
+			if tu.day < day-1 {
+				delete(tsu.m, tenantID)
+			}
+		}
+	}
+
+	tu := tsu.m[tenantID]
+	if tu == nil {
+		if tsu.m == nil {
+			tsu.m = make(map[TenantID]*tenantUsage)
+		}
+		tu = &tenantUsage{}
+		tsu.m[tenantID] = tu
+	}
+	tu.updateTime(currentTime)
+	return tu
+}
+
+// getRowsRejectedLocked returns the number of rejected log entries for the given tenantID.
+//
+// tsu.mu must be locked when calling this function.
+func (tsu *tenantsUsage) getRowsRejectedLocked(tenantID TenantID) *tenantRowsRejected {
+	rr := tsu.rowsRejected[tenantID]
+	if rr == nil {
+		if tsu.rowsRejected == nil {
+			tsu.rowsRejected = make(map[TenantID]*tenantRowsRejected)
+		}
+		rr = &tenantRowsRejected{}
+		tsu.rowsRejected[tenantID] = rr
+	}
+	return rr
+}
+
+// applyTenantLimits applies per-tenant limits to lr and updates per-tenant usage stats.
+//
+// It returns lr if all the log entries are accepted. Otherwise it returns new LogRows with the accepted log entries.
+// The returned LogRows must be returned to the pool via PutLogRows if it differs from lr.
+func (s *Storage) applyTenantLimits(lr *LogRows) *LogRows {
+	tl := s.tenantLimits.Load()
+	if tl == nil {
+		// Fast path - per-tenant limits aren't configured.
+		return lr
+	}
+
+	currentTime := time.Now().UTC()
+	maxRetention := s.getMaxRetention()
+
+	var lrAccepted *LogRows
+	var tenantIDPrev TenantID
+	var tu *tenantUsage
+	var rr *tenantRowsRejected
+	var lim *tenantLimit
+	var retention time.Duration
+	var minAllowedDay int64
+	tenantChecked := false
+
+	tsu := &s.tenantsUsage
+	locked := false
+	for i, sid := range lr.streamIDs {
+		tenantID := sid.tenantID
+		if !tenantChecked || !tenantID.equal(&tenantIDPrev) {
+			// Log entries are usually grouped by tenant, so limits are obtained only when the tenant changes.
+			tenantChecked = true
+			tenantIDPrev = tenantID
+			lim = tl.getLimit(tenantID)
+			tu = nil
+			rr = nil
+			if lim != nil {
+				// Usage stats are tracked only for tenants with the configured limits,
+				// so the ingestion for the rest of tenants isn't serialized on tsu.mu.
+				if !locked {
+					tsu.mu.Lock()
+					locked = true
+				}
+				tu = tsu.getLocked(tenantID, currentTime)
+				rr = tsu.getRowsRejectedLocked(tenantID)
+			}
+			retention = s.getRetentionForTenant(tl, tenantID)
+			minAllowedDay = math.MinInt64
+			if retention < maxRetention {
+				// Log entries outside the maximum retention are dropped by MustAddRows.
+				minAllowedDay = currentTime.Add(-retention).UnixNano() / nsecsPerDay
+			}
+		}
+
+		ok := true
+		var rowLen uint64
+		if tu != nil {
+			rowLen = uint64(EstimatedJSONRowLen(lr.rows[i]))
+		}
+		if lr.timestamps[i]/nsecsPerDay < minAllowedDay {
+			rf := RowFormatter(lr.rows[i])
+			tsf := TimeFormatter(lr.timestamps[i])
+			minAllowedTsf := TimeFormatter(minAllowedDay * nsecsPerDay)
+			tooSmallTimestampLogger.Warnf("skipping log entry with too small timestamp=%s for tenant %s; it must be bigger than %s according "+
+				"to the configured retention=%dd for the tenant. See https://docs.victoriametrics.com/victorialogs/#per-tenant-limits ; "+
+				"log entry: %s", &tsf, &tenantID, &minAllowedTsf, durationToDays(retention), &rf)
+			s.rowsDroppedTooSmallTimestamp.Add(1)
+			if rr != nil {
+				rr.retention++
+			}
+			ok = false
+		} else if tu != nil && lim.maxBytesPerDay > 0 && tu.bytesIngested+rowLen > lim.maxBytesPerDay {
+			tenantLimitsLogger.Warnf("skipping log entry for tenant %s, since it exceeds max_bytes_per_day=%d limit for the tenant; "+
+				"see https://docs.victoriametrics.com/victorialogs/#per-tenant-limits", &tenantID, lim.maxBytesPerDay)
+			rr.maxBytesPerDay++
+			ok = false
+		}
+
+		if ok {
+			if tu != nil {
+				tu.bytesIngested += rowLen
+				tu.rowsIngested++
+			}
+			if lrAccepted != nil {
+				lrAccepted.mustAddInternal(sid, lr.timestamps[i], lr.rows[i], lr.streamTagsCanonicals[i])
+			}
+			continue
+		}
+		if lrAccepted == nil {
+			// Copy the previously accepted rows to lrAccepted.
+			lrAccepted = GetLogRows(nil, nil, nil, "")
+			for j := 0; j < i; j++ {
+				lrAccepted.mustAddInternal(lr.streamIDs[j], lr.timestamps[j], lr.rows[j], lr.streamTagsCanonicals[j])
+			}
+		}
+	}
+	if locked {
+		tsu.mu.Unlock()
+	}
+
+	if lrAccepted == nil {
+		return lr
+	}
+	return lrAccepted
+}
+
+// getMaxNewStreamsPerHourLimit returns limits for the given tenantID if it has max_new_streams_per_hour limit.
+//
+// nil is returned if the tenant has no max_new_streams_per_hour limit.
+func (s *Storage) getMaxNewStreamsPerHourLimit(tenantID TenantID) *tenantLimit {
+	lim := s.tenantLimits.Load().getLimit(tenantID)
+	if lim == nil || lim.maxNewStreamsPerHour == 0 {
+		return nil
+	}
+	return lim
+}
+
+// canCreateNewStream returns true if a new stream can be created for the given tenantID according to max_new_streams_per_hour limit at lim.
+//
+// It updates the number of new streams for the tenant if true is returned.
+func (s *Storage) canCreateNewStream(tenantID TenantID, lim *tenantLimit) bool {
+	tsu := &s.tenantsUsage
+	tsu.mu.Lock()
+	defer tsu.mu.Unlock()
+
+	tu := tsu.getLocked(tenantID, time.Now().UTC())
+	if tu.newStreams >= lim.maxNewStreamsPerHour {
+		tenantLimitsLogger.Warnf("skipping log entries for new stream at tenant %s, since it exceeds max_new_streams_per_hour=%d limit for the tenant; "+
+			"see https://docs.victoriametrics.com/victorialogs/#per-tenant-limits", &tenantID, lim.maxNewStreamsPerHour)
+		return false
+	}
+	tu.newStreams++
+	return true
+}
+
+// hasStreamIDForDay returns true if the given sid is registered in the partition for the given day.
+func (s *Storage) hasStreamIDForDay(sid *streamID, day int64) bool {
+	s.partitionsLock.Lock()
+	ptws := s.partitions
+	n := sort.Search(len(ptws), func(i int) bool {
+		return ptws[i].day >= day
+	})
+	var ptw *partitionWrapper
+	if n < len(ptws) && ptws[n].day == day {
+		ptw = ptws[n]
+		ptw.incRef()
+	}
+	s.partitionsLock.Unlock()
+
+	if ptw == nil {
+		return false
+	}
+	ok := ptw.pt.idb.hasStreamID(sid)
+	ptw.decRef()
+	return ok
+}
+
+// dropRowsForStreams returns new LogRows with the rows from lr, which do not belong to the given rejected streamIDs.
+//
+// The dropped rows are registered as rejected by max_new_streams_per_hour limit.
+// The returned LogRows must be returned to the pool via PutLogRows when no longer needed.
+func (s *Storage) dropRowsForStreams(lr *LogRows, rejectedStreamIDs []streamID) *LogRows {
+	rejected := make(map[streamID]struct{}, len(rejectedStreamIDs))
+	for _, sid := range rejectedStreamIDs {
+		rejected[sid] = struct{}{}
+	}
+
+	rejectedRows := make(map[TenantID]uint64)
+	lrNew := GetLogRows(nil, nil, nil, "")
+	for i := range lr.timestamps {
+		sid := &lr.streamIDs[i]
+		if _, ok := rejected[*sid]; ok {
+			rejectedRows[sid.tenantID]++
+			continue
+		}
+		lrNew.mustAddInternal(*sid, lr.timestamps[i], lr.rows[i], lr.streamTagsCanonicals[i])
+	}
+
+	tsu := &s.tenantsUsage
+	tsu.mu.Lock()
+	for tenantID, n := range rejectedRows {
+		rr := tsu.getRowsRejectedLocked(tenantID)
+		rr.maxNewStreamsPerHour += n
+	}
+	tsu.mu.Unlock()
+
+	return lrNew
+}
+
+var tenantLimitsLogger = logger.WithThrottler("tenant_limits", 5*time.Second)
+
+// TenantStats contains usage stats and limits for a single tenant.
+type TenantStats struct {
+	// TenantID is the tenant for the stats.
+	TenantID TenantID
+
+	// HasLimits is set to true if the tenant has the configured limits.
+	HasLimits bool
+
+	// Retention is the retention for the tenant logs.
+	Retention time.Duration
+
+	// MaxBytesPerDay is the limit on the number of bytes, which can be ingested per day. Zero means no limit.
+	MaxBytesPerDay uint64
+
+	// MaxNewStreamsPerHour is the limit on the number of new streams, which can be created per hour. Zero means no limit.
+	MaxNewStreamsPerHour uint64
+
+	// BytesIngestedToday is the estimated number of bytes ingested during the current day (UTC).
+	BytesIngestedToday uint64
+
+	// RowsIngestedToday is the number of log entries ingested during the current day (UTC).
+	RowsIngestedToday uint64
+
+	// NewStreamsThisHour is the number of new streams created during the current hour.
+	NewStreamsThisHour uint64
+
+	// RowsRejectedRetention is the number of log entries rejected since the storage start because they are outside the tenant retention.
+	RowsRejectedRetention uint64
+
+	// RowsRejectedMaxBytesPerDay is the number of log entries rejected since the storage start because of MaxBytesPerDay limit.
+	RowsRejectedMaxBytesPerDay uint64
+
+	// RowsRejectedMaxNewStreamsPerHour is the number of log entries rejected since the storage start because of MaxNewStreamsPerHour limit.
+	RowsRejectedMaxNewStreamsPerHour uint64
+}
+
+// GetTenantStats returns usage stats for tenants with the configured limits.
+//
+// The returned stats are sorted by tenant.
+func (s *Storage) GetTenantStats() []TenantStats {
+	tl := s.tenantLimits.Load()
+	if tl == nil {
+		return nil
+	}
+	currentTime := time.Now().UTC()
+
+	tsu := &s.tenantsUsage
+	tsu.mu.Lock()
+	tss := make([]TenantStats, 0, len(tl.tenantIDs))
+	for _, tenantID := range tl.tenantIDs {
+		lim := tl.m[tenantID]
+		ts := TenantStats{
+			TenantID:             tenantID,
+			HasLimits:            true,
+			Retention:            s.getRetentionForTenant(tl, tenantID),
+			MaxBytesPerDay:       lim.maxBytesPerDay,
+			MaxNewStreamsPerHour: lim.maxNewStreamsPerHour,
+		}
+		if tu := tsu.m[tenantID]; tu != nil {
+			tu.updateTime(currentTime)
+			ts.BytesIngestedToday = tu.bytesIngested
+			ts.RowsIngestedToday = tu.rowsIngested
+			ts.NewStreamsThisHour = tu.newStreams
+		}
+		if rr := tsu.rowsRejected[tenantID]; rr != nil {
+			ts.RowsRejectedRetention = rr.retention
+			ts.RowsRejectedMaxBytesPerDay = rr.maxBytesPerDay
+			ts.RowsRejectedMaxNewStreamsPerHour = rr.maxNewStreamsPerHour
+		}
+		tss = append(tss, ts)
+	}
+	tsu.mu.Unlock()
+
+	return tss
+}
+
+// purgeExpiredTenantLogs removes logs outside the per-tenant retention from partitions, which are fully outside the retention for some tenants.
+//
+// Logs outside the per-tenant retention are invisible to queries, so this function just frees up disk space.
+func (s *Storage) purgeExpiredTenantLogs() {
+	tl := s.tenantLimits.Load()
+	if tl == nil {
+		return
+	}
+
+	s.partitionsLock.Lock()
+	ptws := append([]*partitionWrapper{}, s.partitions...)
+	for _, ptw := range ptws {
+		ptw.incRef()
+	}
+	s.partitionsLock.Unlock()
+
+	defer func() {
+		for _, ptw := range ptws {
+			ptw.decRef()
+		}
+	}()
+
+	minAllowedDay := func(tenantID TenantID) int64 {
+		retention := s.getRetentionForTenant(tl, tenantID)
+		return time.Now().UTC().Add(-retention).UnixNano() / nsecsPerDay
+	}
+
+	for _, ptw := range ptws {
+		if needStop(s.stopCh) {
+			return
+		}
+
+		var expiredTenantIDs []TenantID
+		for _, tenantID := range ptw.pt.idb.searchTenantIDs() {
+			if ptw.day < minAllowedDay(tenantID) {
+				expiredTenantIDs = append(expiredTenantIDs, tenantID)
+			}
+		}
+		if len(expiredTenantIDs) == 0 {
+			continue
+		}
+		if !s.hasLogsForTenantsOnDay(expiredTenantIDs, ptw.day) {
+			continue
+		}
+
+		logger.Infof("removing logs outside the per-tenant retention from partition %s", ptw.pt.name)
+		startTime := time.Now()
+		ptw.pt.mustForceMerge()
+		logger.Infof("finished removing logs outside the per-tenant retention from partition %s in %.3f seconds", ptw.pt.name, time.Since(startTime).Seconds())
+	}
+}
+
+// hasLogsForTenantsOnDay returns true if the storage contains logs for the given tenantIDs at the given day.
+func (s *Storage) hasLogsForTenantsOnDay(tenantIDs []TenantID, day int64) bool {
+	so := &genericSearchOptions{
+		tenantIDs:           tenantIDs,
+		minTimestamp:        day * nsecsPerDay,
+		maxTimestamp:        (day+1)*nsecsPerDay - 1,
+		filter:              &filterNoop{},
+		ignoreDeleteFilters: true,
+	}
+
+	var found atomic.Bool
+	stopCh := make(chan struct{})
+	processBlockResult := func(_ uint, _ *blockResult) {
+		if !found.Swap(true) {
+			close(stopCh)
+		}
+	}
+	s.search(1, so, stopCh, processBlockResult)
+
+	return found.Load()
+}
@@ -0,0 +1,349 @@
+package logstorage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+)
+
+func TestParseTenantLimitsSuccess(t *testing.T) {
+	data := `
+- tenant: "1:2"
+  retention: 3d
+  max_bytes_per_day: 10KiB
+  max_new_streams_per_hour: 100
+- tenant: "0:0"
+  max_new_streams_per_hour: 5
+`
+	tl, err := ParseTenantLimits([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(tl.tenantIDs) != 2 {
+		t.Fatalf("unexpected number of tenants; got %d; want 2", len(tl.tenantIDs))
+	}
+	if tl.maxRetention != 3*24*time.Hour {
+		t.Fatalf("unexpected maxRetention; got %s; want %s", tl.maxRetention, 3*24*time.Hour)
+	}
+
+	lim := tl.getLimit(TenantID{AccountID: 1, ProjectID: 2})
+	if lim == nil {
+		t.Fatalf("missing limits for tenant 1:2")
+	}
+	if lim.retention != 3*24*time.Hour {
+		t.Fatalf("unexpected retention; got %s; want %s", lim.retention, 3*24*time.Hour)
+	}
+	if lim.maxBytesPerDay != 10*1024 {
+		t.Fatalf("unexpected maxBytesPerDay; got %d; want %d", lim.maxBytesPerDay, 10*1024)
+	}
+	if lim.maxNewStreamsPerHour != 100 {
+		t.Fatalf("unexpected maxNewStreamsPerHour; got %d; want %d", lim.maxNewStreamsPerHour, 100)
+	}
+
+	lim = tl.getLimit(TenantID{})
+	if lim == nil {
+		t.Fatalf("missing limits for tenant 0:0")
+	}
+	if lim.retention != 0 || lim.maxBytesPerDay != 0 || lim.maxNewStreamsPerHour != 5 {
+		t.Fatalf("unexpected limits for tenant 0:0: %+v", lim)
+	}
+
+	if lim := tl.getLimit(TenantID{AccountID: 3}); lim != nil {
+		t.Fatalf("unexpected limits for tenant 3:0: %+v", lim)
+	}
+}
+
+func TestParseTenantLimitsFailure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+		_, err := ParseTenantLimits([]byte(data))
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// invalid yaml
+	f(`foo`)
+
+	// unknown field
+	f(`- tenant: "1:2"
+  foo: bar`)
+
+	// missing tenant
+	f(`- retention: 3d`)
+
+	// invalid tenant
+	f(`- tenant: "foo"`)
+
+	// duplicate tenant
+	f(`
+- tenant: "1:2"
+- tenant: "1:2"
+`)
+
+	// too small retention
+	f(`- tenant: "1:2"
+  retention: 1h`)
+
+	// invalid max_bytes_per_day
+	f(`- tenant: "1:2"
+  max_bytes_per_day: foo`)
+}
+
+func TestStorageTenantLimitsMaxBytesPerDay(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	tl, err := ParseTenantLimits([]byte(`
+- tenant: "1:2"
+  max_bytes_per_day: 1KiB
+`))
+	if err != nil {
+		t.Fatalf("cannot parse tenant limits: %s", err)
+	}
+	s := MustOpenStorage(path, &StorageConfig{
+		TenantLimits: tl,
+	})
+
+	tenantIDLimited := TenantID{AccountID: 1, ProjectID: 2}
+	tenantIDOther := TenantID{AccountID: 3, ProjectID: 4}
+	const rowsCount = 100
+	now := time.Now().UnixNano()
+	lr := GetLogRows(nil, nil, nil, "")
+	for i := 0; i < rowsCount; i++ {
+		fields := []Field{
+			{
+				Name:  "_msg",
+				Value: fmt.Sprintf("some log message number %d", i),
+			},
+		}
+		lr.MustAdd(tenantIDLimited, now+int64(i), fields, nil)
+		lr.MustAdd(tenantIDOther, now+int64(i), fields, nil)
+	}
+	s.MustAddRows(lr)
+	PutLogRows(lr)
+	s.debugFlush()
+
+	nLimited := testCountRows(t, s, tenantIDLimited)
+	if nLimited == 0 || nLimited >= rowsCount {
+		t.Fatalf("unexpected number of rows for the limited tenant; got %d; want (0 ... %d)", nLimited, rowsCount)
+	}
+	if n := testCountRows(t, s, tenantIDOther); n != rowsCount {
+		t.Fatalf("unexpected number of rows for the tenant without limits; got %d; want %d", n, rowsCount)
+	}
+
+	// Stats must be returned only for the tenant with limits.
+	tss := s.GetTenantStats()
+	if len(tss) != 1 {
+		t.Fatalf("unexpected number of tenant stats; got %d; want 1", len(tss))
+	}
+	ts := &tss[0]
+	if !ts.TenantID.equal(&tenantIDLimited) {
+		t.Fatalf("unexpected tenant; got %s; want %s", &ts.TenantID, &tenantIDLimited)
+	}
+	if ts.RowsIngestedToday != nLimited {
+		t.Fatalf("unexpected RowsIngestedToday; got %d; want %d", ts.RowsIngestedToday, nLimited)
+	}
+	if ts.RowsRejectedMaxBytesPerDay != rowsCount-nLimited {
+		t.Fatalf("unexpected RowsRejectedMaxBytesPerDay; got %d; want %d", ts.RowsRejectedMaxBytesPerDay, rowsCount-nLimited)
+	}
+	if ts.BytesIngestedToday > 1024 {
+		t.Fatalf("BytesIngestedToday=%d exceeds max_bytes_per_day=1024", ts.BytesIngestedToday)
+	}
+	if ts.MaxBytesPerDay != 1024 {
+		t.Fatalf("unexpected MaxBytesPerDay; got %d; want 1024", ts.MaxBytesPerDay)
+	}
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
+
+func TestStorageTenantLimitsMaxNewStreamsPerHour(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	tl, err := ParseTenantLimits([]byte(`
+- tenant: "1:2"
+  max_new_streams_per_hour: 2
+`))
+	if err != nil {
+		t.Fatalf("cannot parse tenant limits: %s", err)
+	}
+	s := MustOpenStorage(path, &StorageConfig{
+		TenantLimits: tl,
+	})
+
+	tenantID := TenantID{AccountID: 1, ProjectID: 2}
+	const streamsCount = 5
+	const rowsPerStream = 10
+	now := time.Now().UnixNano()
+	addRows := func() {
+		lr := GetLogRows([]string{"host"}, nil, nil, "")
+		for i := 0; i < streamsCount; i++ {
+			for j := 0; j < rowsPerStream; j++ {
+				fields := []Field{
+					{
+						Name:  "host",
+						Value: fmt.Sprintf("host-%d", i),
+					},
+					{
+						Name:  "_msg",
+						Value: fmt.Sprintf("message %d", j),
+					},
+				}
+				lr.MustAdd(tenantID, now+int64(j), fields, nil)
+			}
+		}
+		s.MustAddRows(lr)
+		PutLogRows(lr)
+	}
+
+	addRows()
+	s.debugFlush()
+	if n := testCountRows(t, s, tenantID); n != 2*rowsPerStream {
+		t.Fatalf("unexpected number of rows; got %d; want %d", n, 2*rowsPerStream)
+	}
+
+	// Rows for already registered streams must be accepted, while rows for new streams must be rejected.
+	addRows()
+	s.debugFlush()
+	if n := testCountRows(t, s, tenantID); n != 4*rowsPerStream {
+		t.Fatalf("unexpected number of rows; got %d; want %d", n, 4*rowsPerStream)
+	}
+
+	tss := s.GetTenantStats()
+	if len(tss) != 1 {
+		t.Fatalf("unexpected number of tenant stats; got %d; want 1", len(tss))
+	}
+	ts := &tss[0]
+	if ts.NewStreamsThisHour != 2 {
+		t.Fatalf("unexpected NewStreamsThisHour; got %d; want 2", ts.NewStreamsThisHour)
+	}
+	if want := uint64(2 * (streamsCount - 2) * rowsPerStream); ts.RowsRejectedMaxNewStreamsPerHour != want {
+		t.Fatalf("unexpected RowsRejectedMaxNewStreamsPerHour; got %d; want %d", ts.RowsRejectedMaxNewStreamsPerHour, want)
+	}
+
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
+
+func TestStorageTenantLimitsRetention(t *testing.T) {
+	t.Parallel()
+
+	path := t.Name()
+
+	s := MustOpenStorage(path, &StorageConfig{
+		Retention: 30 * 24 * time.Hour,
+	})
+
+	tenantIDLimited := TenantID{AccountID: 1, ProjectID: 2}
+	tenantIDOther := TenantID{AccountID: 3, ProjectID: 4}
+	const days = 5
+	const rowsPerDay = 10
+	now := time.Now().UnixNano()
+	addRows := func(tenantID TenantID) {
+		lr := GetLogRows(nil, nil, nil, "")
+		for day := 0; day < days; day++ {
+			for i := 0; i < rowsPerDay; i++ {
+				fields := []Field{
+					{
+						Name:  "_msg",
+						Value: fmt.Sprintf("message %d at day %d", i, day),
+					},
+				}
+				lr.MustAdd(tenantID, now-int64(day)*nsecsPerDay-int64(i), fields, nil)
+			}
+		}
+		s.MustAddRows(lr)
+		PutLogRows(lr)
+	}
+	addRows(tenantIDLimited)
+	addRows(tenantIDOther)
+	s.debugFlush()
+
+	// Reduce the retention for the limited tenant. Logs outside the retention must become invisible.
+	tl, err := ParseTenantLimits([]byte(`
+- tenant: "1:2"
+  retention: 2d
+`))
+	if err != nil {
+		t.Fatalf("cannot parse tenant limits: %s", err)
+	}
+	s.SetTenantLimits(tl)
+
+	// The retention is applied with the precision of a day, so logs for 3 days must remain: today, yesterday and the day before yesterday.
+	if n := testCountRows(t, s, tenantIDLimited); n != 3*rowsPerDay {
+		t.Fatalf("unexpected number of rows for the limited tenant; got %d; want %d", n, 3*rowsPerDay)
+	}
+	if n := testCountRows(t, s, tenantIDOther); n != days*rowsPerDay {
+		t.Fatalf("unexpected number of rows for the tenant without limits; got %d; want %d", n, days*rowsPerDay)
+	}
+
+	// Logs outside the retention must be dropped during ingestion.
+	addRows(tenantIDLimited)
+	s.debugFlush()
+	if n := testCountRows(t, s, tenantIDLimited); n != 6*rowsPerDay {
+		t.Fatalf("unexpected number of rows for the limited tenant; got %d; want %d", n, 6*rowsPerDay)
+	}
+	tss := s.GetTenantStats()
+	if len(tss) != 1 {
+		t.Fatalf("unexpected number of tenant stats; got %d; want 1", len(tss))
+	}
+	if tss[0].RowsRejectedRetention != 2*rowsPerDay {
+		t.Fatalf("unexpected RowsRejectedRetention; got %d; want %d", tss[0].RowsRejectedRetention, 2*rowsPerDay)
+	}
+	if tss[0].Retention != 2*24*time.Hour {
+		t.Fatalf("unexpected Retention for the limited tenant; got %s; want %s", tss[0].Retention, 2*24*time.Hour)
+	}
+
+	// Logs outside the retention must be removed from the storage.
+	s.purgeExpiredTenantLogs()
+	var ss StorageStats
+	s.UpdateStats(&ss)
+	if ss.RowsDeleted != 2*rowsPerDay {
+		t.Fatalf("unexpected number of deleted rows; got %d; want %d", ss.RowsDeleted, 2*rowsPerDay)
+	}
+	if n := testCountRows(t, s, tenantIDOther); n != days*rowsPerDay {
+		t.Fatalf("unexpected number of rows for the tenant without limits after purging; got %d; want %d", n, days*rowsPerDay)
+	}
+
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
+
+func TestTenantsUsageEvictInactiveTenants(t *testing.T) {
+	var tsu tenantsUsage
+
+	tenantIDActive := TenantID{AccountID: 1}
+	tenantIDInactive := TenantID{AccountID: 2}
+
+	currentTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	tsu.getLocked(tenantIDActive, currentTime).rowsIngested++
+	tsu.getLocked(tenantIDInactive, currentTime).rowsIngested++
+	tsu.getRowsRejectedLocked(tenantIDInactive).maxBytesPerDay++
+
+	// Both tenants must remain on the next day, since they were active during the previous day.
+	currentTime = currentTime.Add(24 * time.Hour)
+	tsu.getLocked(tenantIDActive, currentTime)
+	if len(tsu.m) != 2 {
+		t.Fatalf("unexpected number of tenants; got %d; want 2", len(tsu.m))
+	}
+
+	// The inactive tenant must be evicted on the day rollover.
+	currentTime = currentTime.Add(24 * time.Hour)
+	tsu.getLocked(tenantIDActive, currentTime)
+	if len(tsu.m) != 1 {
+		t.Fatalf("unexpected number of tenants; got %d; want 1", len(tsu.m))
+	}
+	if tsu.m[tenantIDInactive] != nil {
+		t.Fatalf("the inactive tenant must be evicted")
+	}
+
+	// The number of rejected rows mustn't be reset on eviction, since it is exported as a counter.
+	if n := tsu.getRowsRejectedLocked(tenantIDInactive).maxBytesPerDay; n != 1 {
+		t.Fatalf("unexpected number of rejected rows for the evicted tenant; got %d; want 1", n)
+	}
+}
@@ -82,6 +82,17 @@ func (c *Cache) PutEntry(k string, e Entry) {
 	shard.PutEntry(k, e)
 }
 
+// RemoveEntry removes the Entry for the given key k from c.
+func (c *Cache) RemoveEntry(k string) {
+	idx := uint64(0)
+	if len(c.shards) > 1 {
+		h := hashUint64(k)
+		idx = h % uint64(len(c.shards))
+	}
+	shard := c.shards[idx]
+	shard.RemoveEntry(k)
+}
+
 // Len returns the number of blocks in the cache c.
 func (c *Cache) Len() int {
 	n := 0
@@ -260,6 +271,19 @@ func (c *cache) PutEntry(k string, e Entry) {
 	}
 }
 
+func (c *cache) RemoveEntry(k string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ce := c.m[k]
+	if ce == nil {
+		return
+	}
+	c.updateSizeBytes(-ce.e.SizeBytes())
+	delete(c.m, k)
+	heap.Remove(&c.lah, ce.heapIdx)
+}
+
 func (c *cache) removeLeastRecentlyAccessedItem() {
 	ce := c.lah[0]
 	c.updateSizeBytes(-ce.e.SizeBytes())
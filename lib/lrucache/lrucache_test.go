@@ -83,6 +83,19 @@ func TestCache(t *testing.T) {
 	if n := c.SizeBytes(); n != entrySize {
 		t.Fatalf("unexpected SizeBytes(); got %d; want %d", n, entrySize)
 	}
+	// Remove the entry from the cache.
+	c.RemoveEntry(k)
+	if n := c.Len(); n != 0 {
+		t.Fatalf("unexpected number of items in the cache; got %d; want %d", n, 0)
+	}
+	if n := c.SizeBytes(); n != 0 {
+		t.Fatalf("unexpected SizeBytes(); got %d; want %d", n, 0)
+	}
+	if e1 := c.GetEntry(k); e1 != nil {
+		t.Fatalf("unexpected non-nil entry obtained for the removed key: %v", e1)
+	}
+	// Remove non-existing entry from the cache.
+	c.RemoveEntry("non-existing-key")
 }
 
 func TestCacheConcurrentAccess(_ *testing.T) {
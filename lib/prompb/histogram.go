@@ -0,0 +1,250 @@
+package prompb
+
+import (
+	"fmt"
+
+	"github.com/VictoriaMetrics/easyproto"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+)
+
+// Histogram is a Prometheus native histogram sample.
+//
+// See https://prometheus.io/docs/specs/native_histograms/
+type Histogram struct {
+	// Count is the total number of observations in the histogram.
+	Count float64
+
+	// Sum is the sum of observations in the histogram.
+	Sum float64
+
+	// Schema defines bucket boundaries.
+	//
+	// Buckets are exponential for schema in the range [-4...8].
+	// Buckets have custom boundaries from CustomValues for schema=-53.
+	Schema int32
+
+	// ZeroThreshold is the width of the zero bucket, which contains observations in the range [-ZeroThreshold...ZeroThreshold].
+	ZeroThreshold float64
+
+	// ZeroCount is the number of observations in the zero bucket.
+	ZeroCount float64
+
+	// NegativeSpans and PositiveSpans define indexes for buckets with negative and positive observations.
+	NegativeSpans []BucketSpan
+	PositiveSpans []BucketSpan
+
+	// NegativeDeltas and PositiveDeltas contain bucket counts as deltas to the previous bucket count for integer histograms.
+	NegativeDeltas []int64
+	PositiveDeltas []int64
+
+	// NegativeCounts and PositiveCounts contain absolute bucket counts for float histograms.
+	NegativeCounts []float64
+	PositiveCounts []float64
+
+	// Timestamp is unix timestamp for the histogram in milliseconds.
+	Timestamp int64
+
+	// CustomValues contains upper bounds for buckets with custom boundaries if Schema=-53.
+	CustomValues []float64
+}
+
+// BucketSpan defines a range of consecutive buckets in Histogram.
+type BucketSpan struct {
+	// Offset is the gap to the previous span or the starting index for the first span.
+	Offset int32
+
+	// Length is the number of consecutive buckets in the span.
+	Length uint32
+}
+
+// CustomBucketsSchema is the Histogram schema for buckets with custom boundaries.
+const CustomBucketsSchema = -53
+
+// IsStale returns true if h is a staleness marker.
+func (h *Histogram) IsStale() bool {
+	return decimal.IsStaleNaN(h.Sum)
+}
+
+// ForEachBucket calls f for each non-empty bucket in h except of the zero bucket.
+//
+// index is the bucket index according to h.Schema, while count is the number of observations in the bucket.
+// Negative buckets are passed with isNegative=true.
+func (h *Histogram) ForEachBucket(f func(index int32, count float64, isNegative bool)) {
+	forEachBucket(h.NegativeSpans, h.NegativeDeltas, h.NegativeCounts, func(index int32, count float64) {
+		f(index, count, true)
+	})
+	forEachBucket(h.PositiveSpans, h.PositiveDeltas, h.PositiveCounts, func(index int32, count float64) {
+		f(index, count, false)
+	})
+}
+
+func forEachBucket(spans []BucketSpan, deltas []int64, counts []float64, f func(index int32, count float64)) {
+	var index int32
+	var n int
+	var countInt int64
+	for i, span := range spans {
+		if i == 0 {
+			index = span.Offset
+		} else {
+			index += span.Offset
+		}
+		for j := uint32(0); j < span.Length; j++ {
+			var count float64
+			if n < len(counts) {
+				count = counts[n]
+			} else if n < len(deltas) {
+				countInt += deltas[n]
+				count = float64(countInt)
+			} else {
+				return
+			}
+			n++
+			if count > 0 {
+				f(index, count)
+			}
+			index++
+		}
+	}
+}
+
+func (h *Histogram) reset() {
+	h.Count = 0
+	h.Sum = 0
+	h.Schema = 0
+	h.ZeroThreshold = 0
+	h.ZeroCount = 0
+	h.NegativeSpans = h.NegativeSpans[:0]
+	h.PositiveSpans = h.PositiveSpans[:0]
+	h.NegativeDeltas = h.NegativeDeltas[:0]
+	h.PositiveDeltas = h.PositiveDeltas[:0]
+	h.NegativeCounts = h.NegativeCounts[:0]
+	h.PositiveCounts = h.PositiveCounts[:0]
+	h.Timestamp = 0
+	h.CustomValues = h.CustomValues[:0]
+}
+
+func (h *Histogram) unmarshalProtobuf(src []byte) (err error) {
+	// message Histogram {
+	//   oneof count {
+	//     uint64 count_int   = 1;
+	//     double count_float = 2;
+	//   }
+	//   double sum                     = 3;
+	//   sint32 schema                  = 4;
+	//   double zero_threshold          = 5;
+	//   oneof zero_count {
+	//     uint64 zero_count_int   = 6;
+	//     double zero_count_float = 7;
+	//   }
+	//   repeated BucketSpan negative_spans = 8;
+	//   repeated sint64 negative_deltas    = 9;
+	//   repeated double negative_counts    = 10;
+	//   repeated BucketSpan positive_spans = 11;
+	//   repeated sint64 positive_deltas    = 12;
+	//   repeated double positive_counts    = 13;
+	//   ResetHint reset_hint               = 14;
+	//   int64 timestamp                    = 15;
+	//   repeated double custom_values      = 16;
+	// }
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read the next field: %w", err)
+		}
+		var ok bool
+		switch fc.FieldNum {
+		case 1:
+			var count uint64
+			count, ok = fc.Uint64()
+			h.Count = float64(count)
+		case 2:
+			h.Count, ok = fc.Double()
+		case 3:
+			h.Sum, ok = fc.Double()
+		case 4:
+			h.Schema, ok = fc.Sint32()
+		case 5:
+			h.ZeroThreshold, ok = fc.Double()
+		case 6:
+			var count uint64
+			count, ok = fc.Uint64()
+			h.ZeroCount = float64(count)
+		case 7:
+			h.ZeroCount, ok = fc.Double()
+		case 8:
+			h.NegativeSpans, err = appendBucketSpan(h.NegativeSpans, &fc)
+			if err != nil {
+				return err
+			}
+			ok = true
+		case 9:
+			h.NegativeDeltas, ok = fc.UnpackSint64s(h.NegativeDeltas)
+		case 10:
+			h.NegativeCounts, ok = fc.UnpackDoubles(h.NegativeCounts)
+		case 11:
+			h.PositiveSpans, err = appendBucketSpan(h.PositiveSpans, &fc)
+			if err != nil {
+				return err
+			}
+			ok = true
+		case 12:
+			h.PositiveDeltas, ok = fc.UnpackSint64s(h.PositiveDeltas)
+		case 13:
+			h.PositiveCounts, ok = fc.UnpackDoubles(h.PositiveCounts)
+		case 15:
+			h.Timestamp, ok = fc.Int64()
+		case 16:
+			h.CustomValues, ok = fc.UnpackDoubles(h.CustomValues)
+		default:
+			ok = true
+		}
+		if !ok {
+			return fmt.Errorf("cannot read histogram field #%d", fc.FieldNum)
+		}
+	}
+	return nil
+}
+
+func appendBucketSpan(dst []BucketSpan, fc *easyproto.FieldContext) ([]BucketSpan, error) {
+	data, ok := fc.MessageData()
+	if !ok {
+		return dst, fmt.Errorf("cannot read bucket span data")
+	}
+	dst = append(dst, BucketSpan{})
+	bs := &dst[len(dst)-1]
+	if err := bs.unmarshalProtobuf(data); err != nil {
+		return dst, fmt.Errorf("cannot unmarshal bucket span: %w", err)
+	}
+	return dst, nil
+}
+
+func (bs *BucketSpan) unmarshalProtobuf(src []byte) (err error) {
+	// message BucketSpan {
+	//   sint32 offset = 1;
+	//   uint32 length = 2;
+	// }
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read the next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			offset, ok := fc.Sint32()
+			if !ok {
+				return fmt.Errorf("cannot read bucket span offset")
+			}
+			bs.Offset = offset
+		case 2:
+			length, ok := fc.Uint32()
+			if !ok {
+				return fmt.Errorf("cannot read bucket span length")
+			}
+			bs.Length = length
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package prompb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/easyproto"
+)
+
+func TestHistogramUnmarshalProtobuf(t *testing.T) {
+	var m easyproto.Marshaler
+	mm := m.MessageMarshaler()
+	ts := mm.AppendMessage(1)
+	label := ts.AppendMessage(1)
+	label.AppendString(1, "__name__")
+	label.AppendString(2, "foo")
+	h := ts.AppendMessage(4)
+	h.AppendUint64(1, 10)
+	h.AppendDouble(3, 12.5)
+	h.AppendSint32(4, 1)
+	h.AppendDouble(5, 0.001)
+	h.AppendUint64(6, 1)
+	span := h.AppendMessage(8)
+	span.AppendSint32(1, 2)
+	span.AppendUint32(2, 1)
+	h.AppendSint64s(9, []int64{3})
+	span = h.AppendMessage(11)
+	span.AppendSint32(1, -1)
+	span.AppendUint32(2, 2)
+	span = h.AppendMessage(11)
+	span.AppendSint32(1, 3)
+	span.AppendUint32(2, 1)
+	h.AppendSint64s(12, []int64{2, -2, 4})
+	h.AppendInt64(15, 1234)
+	data := m.Marshal(nil)
+
+	var wr WriteRequest
+	if err := wr.UnmarshalProtobuf(data); err != nil {
+		t.Fatalf("cannot unmarshal protobuf: %s", err)
+	}
+	if len(wr.Timeseries) != 1 {
+		t.Fatalf("unexpected number of time series; got %d; want 1", len(wr.Timeseries))
+	}
+	hs := wr.Timeseries[0].Histograms
+	if len(hs) != 1 {
+		t.Fatalf("unexpected number of histograms; got %d; want 1", len(hs))
+	}
+	hResult := &hs[0]
+	if hResult.Count != 10 || hResult.Sum != 12.5 || hResult.Schema != 1 || hResult.ZeroThreshold != 0.001 || hResult.ZeroCount != 1 || hResult.Timestamp != 1234 {
+		t.Fatalf("unexpected histogram: %+v", hResult)
+	}
+
+	type bucket struct {
+		index      int32
+		count      float64
+		isNegative bool
+	}
+	var buckets []bucket
+	hResult.ForEachBucket(func(index int32, count float64, isNegative bool) {
+		buckets = append(buckets, bucket{
+			index:      index,
+			count:      count,
+			isNegative: isNegative,
+		})
+	})
+	bucketsExpected := []bucket{
+		{index: 2, count: 3, isNegative: true},
+		{index: -1, count: 2},
+		{index: 4, count: 4},
+	}
+	if !reflect.DeepEqual(buckets, bucketsExpected) {
+		t.Fatalf("unexpected buckets\ngot\n%+v\nwant\n%+v", buckets, bucketsExpected)
+	}
+}
@@ -11,8 +11,19 @@ type WriteRequest struct {
 	// Timeseries is a list of time series in the given WriteRequest
 	Timeseries []TimeSeries
 
-	labelsPool  []Label
-	samplesPool []Sample
+	// Metadata is a list of metric metadata in the given WriteRequest
+	Metadata []MetricMetadata
+
+	labelsPool         []Label
+	samplesPool        []Sample
+	exemplarsPool      []Exemplar
+	exemplarLabelsPool []Label
+	histogramsPool     []Histogram
+
+	// symbolsPool, labelsRefsBuf and metadataFamilies are used by UnmarshalProtobufV2.
+	symbolsPool      []string
+	labelsRefsBuf    []uint32
+	metadataFamilies map[string]struct{}
 }
 
 // Reset resets wr for subsequent re-use.
@@ -23,6 +34,12 @@ func (wr *WriteRequest) Reset() {
 	}
 	wr.Timeseries = tss[:0]
 
+	mms := wr.Metadata
+	for i := range mms {
+		mms[i] = MetricMetadata{}
+	}
+	wr.Metadata = mms[:0]
+
 	labelsPool := wr.labelsPool
 	for i := range labelsPool {
 		labelsPool[i] = Label{}
@@ -34,6 +51,26 @@ func (wr *WriteRequest) Reset() {
 		samplesPool[i] = Sample{}
 	}
 	wr.samplesPool = samplesPool[:0]
+
+	exemplarsPool := wr.exemplarsPool
+	for i := range exemplarsPool {
+		exemplarsPool[i] = Exemplar{}
+	}
+	wr.exemplarsPool = exemplarsPool[:0]
+
+	exemplarLabelsPool := wr.exemplarLabelsPool
+	for i := range exemplarLabelsPool {
+		exemplarLabelsPool[i] = Label{}
+	}
+	wr.exemplarLabelsPool = exemplarLabelsPool[:0]
+
+	// Histograms do not refer to the unmarshaled data, so they are kept in the pool in order to re-use their buckets.
+	wr.histogramsPool = wr.histogramsPool[:0]
+
+	clear(wr.symbolsPool)
+	wr.symbolsPool = wr.symbolsPool[:0]
+	wr.labelsRefsBuf = wr.labelsRefsBuf[:0]
+	clear(wr.metadataFamilies)
 }
 
 // TimeSeries is a timeseries.
@@ -43,6 +80,26 @@ type TimeSeries struct {
 
 	// Samples is a list of samples for the given TimeSeries
 	Samples []Sample
+
+	// Exemplars is a list of exemplars for the given TimeSeries
+	Exemplars []Exemplar
+
+	// Histograms is a list of native histogram samples for the given TimeSeries
+	Histograms []Histogram
+}
+
+// Exemplar is an exemplar attached to timeseries sample.
+//
+// See https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md#exemplars
+type Exemplar struct {
+	// Labels is a list of exemplar labels such as trace_id.
+	Labels []Label
+
+	// Value is exemplar value.
+	Value float64
+
+	// Timestamp is unix timestamp for the exemplar in milliseconds.
+	Timestamp int64
 }
 
 // Sample is a timeseries sample.
@@ -54,6 +111,73 @@ type Sample struct {
 	Timestamp int64
 }
 
+// MetricMetadata contains metadata for the given metric family.
+//
+// See https://github.com/prometheus/prometheus/blob/main/prompb/types.proto
+type MetricMetadata struct {
+	// Type is the metric type.
+	Type MetricType
+
+	// MetricFamilyName is the name of the metric family the metadata refers to.
+	MetricFamilyName string
+
+	// Help is the metric description.
+	Help string
+
+	// Unit is the metric unit.
+	Unit string
+}
+
+// MetricType is the type of the metric in MetricMetadata.
+type MetricType uint32
+
+// Metric types supported by MetricMetadata.
+//
+// The values must match MetricMetadata.MetricType enum from Prometheus remote write protocol.
+const (
+	MetricTypeUnknown        MetricType = 0
+	MetricTypeCounter        MetricType = 1
+	MetricTypeGauge          MetricType = 2
+	MetricTypeHistogram      MetricType = 3
+	MetricTypeGaugeHistogram MetricType = 4
+	MetricTypeSummary        MetricType = 5
+	MetricTypeInfo           MetricType = 6
+	MetricTypeStateset       MetricType = 7
+)
+
+var metricTypeNames = [...]string{
+	MetricTypeUnknown:        "unknown",
+	MetricTypeCounter:        "counter",
+	MetricTypeGauge:          "gauge",
+	MetricTypeHistogram:      "histogram",
+	MetricTypeGaugeHistogram: "gaugehistogram",
+	MetricTypeSummary:        "summary",
+	MetricTypeInfo:           "info",
+	MetricTypeStateset:       "stateset",
+}
+
+// String returns string representation for mt as used in Prometheus exposition format.
+func (mt MetricType) String() string {
+	if int(mt) < len(metricTypeNames) {
+		return metricTypeNames[mt]
+	}
+	return "unknown"
+}
+
+// GetMetricType returns MetricType for the given s.
+//
+// s must contain the metric type as used in `# TYPE` lines in Prometheus text exposition format.
+// MetricTypeUnknown is returned for unsupported types.
+func GetMetricType(s string) MetricType {
+	for i, name := range metricTypeNames {
+		if name == s {
+			return MetricType(i)
+		}
+	}
+	// Prometheus text exposition format uses `untyped` instead of `unknown`.
+	return MetricTypeUnknown
+}
+
 // Label is a timeseries label.
 type Label struct {
 	// Name is label name.
@@ -71,10 +195,11 @@ func (wr *WriteRequest) UnmarshalProtobuf(src []byte) (err error) {
 
 	// message WriteRequest {
 	//    repeated TimeSeries timeseries = 1;
+	//    reserved 2;
+	//    repeated MetricMetadata metadata = 3;
 	// }
 	tss := wr.Timeseries
-	labelsPool := wr.labelsPool
-	samplesPool := wr.samplesPool
+	mms := wr.Metadata
 	var fc easyproto.FieldContext
 	for len(src) > 0 {
 		src, err = fc.NextField(src)
@@ -93,37 +218,57 @@ func (wr *WriteRequest) UnmarshalProtobuf(src []byte) (err error) {
 				tss = append(tss, TimeSeries{})
 			}
 			ts := &tss[len(tss)-1]
-			labelsPool, samplesPool, err = ts.unmarshalProtobuf(data, labelsPool, samplesPool)
-			if err != nil {
+			if err := ts.unmarshalProtobuf(data, wr); err != nil {
 				return fmt.Errorf("cannot unmarshal timeseries: %w", err)
 			}
+		case 3:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read metadata data")
+			}
+			if len(mms) < cap(mms) {
+				mms = mms[:len(mms)+1]
+			} else {
+				mms = append(mms, MetricMetadata{})
+			}
+			mm := &mms[len(mms)-1]
+			if err := mm.unmarshalProtobuf(data); err != nil {
+				return fmt.Errorf("cannot unmarshal metadata: %w", err)
+			}
 		}
 	}
 	wr.Timeseries = tss
-	wr.labelsPool = labelsPool
-	wr.samplesPool = samplesPool
+	wr.Metadata = mms
 	return nil
 }
 
-func (ts *TimeSeries) unmarshalProtobuf(src []byte, labelsPool []Label, samplesPool []Sample) ([]Label, []Sample, error) {
+func (ts *TimeSeries) unmarshalProtobuf(src []byte, wr *WriteRequest) error {
 	// message TimeSeries {
-	//   repeated Label labels   = 1;
-	//   repeated Sample samples = 2;
+	//   repeated Label labels       = 1;
+	//   repeated Sample samples     = 2;
+	//   repeated Exemplar exemplars = 3;
+	//   repeated Histogram histograms = 4;
 	// }
+	labelsPool := wr.labelsPool
+	samplesPool := wr.samplesPool
+	exemplarsPool := wr.exemplarsPool
+	histogramsPool := wr.histogramsPool
 	labelsPoolLen := len(labelsPool)
 	samplesPoolLen := len(samplesPool)
+	exemplarsPoolLen := len(exemplarsPool)
+	histogramsPoolLen := len(histogramsPool)
 	var fc easyproto.FieldContext
 	for len(src) > 0 {
 		var err error
 		src, err = fc.NextField(src)
 		if err != nil {
-			return labelsPool, samplesPool, fmt.Errorf("cannot read the next field: %w", err)
+			return fmt.Errorf("cannot read the next field: %w", err)
 		}
 		switch fc.FieldNum {
 		case 1:
 			data, ok := fc.MessageData()
 			if !ok {
-				return labelsPool, samplesPool, fmt.Errorf("cannot read label data")
+				return fmt.Errorf("cannot read label data")
 			}
 			if len(labelsPool) < cap(labelsPool) {
 				labelsPool = labelsPool[:len(labelsPool)+1]
@@ -132,12 +277,12 @@ func (ts *TimeSeries) unmarshalProtobuf(src []byte, labelsPool []Label, samplesP
 			}
 			label := &labelsPool[len(labelsPool)-1]
 			if err := label.unmarshalProtobuf(data); err != nil {
-				return labelsPool, samplesPool, fmt.Errorf("cannot unmarshal label: %w", err)
+				return fmt.Errorf("cannot unmarshal label: %w", err)
 			}
 		case 2:
 			data, ok := fc.MessageData()
 			if !ok {
-				return labelsPool, samplesPool, fmt.Errorf("cannot read the sample data")
+				return fmt.Errorf("cannot read the sample data")
 			}
 			if len(samplesPool) < cap(samplesPool) {
 				samplesPool = samplesPool[:len(samplesPool)+1]
@@ -146,13 +291,96 @@ func (ts *TimeSeries) unmarshalProtobuf(src []byte, labelsPool []Label, samplesP
 			}
 			sample := &samplesPool[len(samplesPool)-1]
 			if err := sample.unmarshalProtobuf(data); err != nil {
-				return labelsPool, samplesPool, fmt.Errorf("cannot unmarshal sample: %w", err)
+				return fmt.Errorf("cannot unmarshal sample: %w", err)
+			}
+		case 3:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read the exemplar data")
+			}
+			if len(exemplarsPool) < cap(exemplarsPool) {
+				exemplarsPool = exemplarsPool[:len(exemplarsPool)+1]
+			} else {
+				exemplarsPool = append(exemplarsPool, Exemplar{})
+			}
+			exemplar := &exemplarsPool[len(exemplarsPool)-1]
+			if err := exemplar.unmarshalProtobuf(data, wr); err != nil {
+				return fmt.Errorf("cannot unmarshal exemplar: %w", err)
+			}
+		case 4:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read the histogram data")
+			}
+			if len(histogramsPool) < cap(histogramsPool) {
+				histogramsPool = histogramsPool[:len(histogramsPool)+1]
+			} else {
+				histogramsPool = append(histogramsPool, Histogram{})
+			}
+			h := &histogramsPool[len(histogramsPool)-1]
+			h.reset()
+			if err := h.unmarshalProtobuf(data); err != nil {
+				return fmt.Errorf("cannot unmarshal histogram: %w", err)
 			}
 		}
 	}
 	ts.Labels = labelsPool[labelsPoolLen:]
 	ts.Samples = samplesPool[samplesPoolLen:]
-	return labelsPool, samplesPool, nil
+	ts.Exemplars = exemplarsPool[exemplarsPoolLen:]
+	ts.Histograms = histogramsPool[histogramsPoolLen:]
+	wr.labelsPool = labelsPool
+	wr.samplesPool = samplesPool
+	wr.exemplarsPool = exemplarsPool
+	wr.histogramsPool = histogramsPool
+	return nil
+}
+
+func (e *Exemplar) unmarshalProtobuf(src []byte, wr *WriteRequest) (err error) {
+	// message Exemplar {
+	//   repeated Label labels = 1;
+	//   double value          = 2;
+	//   int64 timestamp       = 3;
+	// }
+	labelsPool := wr.exemplarLabelsPool
+	labelsPoolLen := len(labelsPool)
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read the next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read label data")
+			}
+			if len(labelsPool) < cap(labelsPool) {
+				labelsPool = labelsPool[:len(labelsPool)+1]
+			} else {
+				labelsPool = append(labelsPool, Label{})
+			}
+			label := &labelsPool[len(labelsPool)-1]
+			if err := label.unmarshalProtobuf(data); err != nil {
+				return fmt.Errorf("cannot unmarshal label: %w", err)
+			}
+		case 2:
+			value, ok := fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read exemplar value")
+			}
+			e.Value = value
+		case 3:
+			timestamp, ok := fc.Int64()
+			if !ok {
+				return fmt.Errorf("cannot read exemplar timestamp")
+			}
+			e.Timestamp = timestamp
+		}
+	}
+	e.Labels = labelsPool[labelsPoolLen:]
+	wr.exemplarLabelsPool = labelsPool
+	return nil
 }
 
 func (lbl *Label) unmarshalProtobuf(src []byte) (err error) {
@@ -212,3 +440,46 @@ func (s *Sample) unmarshalProtobuf(src []byte) (err error) {
 	}
 	return nil
 }
+
+func (mm *MetricMetadata) unmarshalProtobuf(src []byte) (err error) {
+	// message MetricMetadata {
+	//   MetricType type           = 1;
+	//   string metric_family_name = 2;
+	//   string help               = 4;
+	//   string unit               = 5;
+	// }
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read the next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			mt, ok := fc.Uint32()
+			if !ok {
+				return fmt.Errorf("cannot read metric type")
+			}
+			mm.Type = MetricType(mt)
+		case 2:
+			name, ok := fc.String()
+			if !ok {
+				return fmt.Errorf("cannot read metric family name")
+			}
+			mm.MetricFamilyName = name
+		case 4:
+			help, ok := fc.String()
+			if !ok {
+				return fmt.Errorf("cannot read help")
+			}
+			mm.Help = help
+		case 5:
+			unit, ok := fc.String()
+			if !ok {
+				return fmt.Errorf("cannot read unit")
+			}
+			mm.Unit = unit
+		}
+	}
+	return nil
+}
@@ -36,9 +36,33 @@ func TestWriteRequestUnmarshalProtobuf(t *testing.T) {
 					Timestamp: sample.Timestamp,
 				})
 			}
+			var exemplars []prompbmarshal.Exemplar
+			for _, exemplar := range ts.Exemplars {
+				var exemplarLabels []prompbmarshal.Label
+				for _, label := range exemplar.Labels {
+					exemplarLabels = append(exemplarLabels, prompbmarshal.Label{
+						Name:  label.Name,
+						Value: label.Value,
+					})
+				}
+				exemplars = append(exemplars, prompbmarshal.Exemplar{
+					Labels:    exemplarLabels,
+					Value:     exemplar.Value,
+					Timestamp: exemplar.Timestamp,
+				})
+			}
 			wrm.Timeseries = append(wrm.Timeseries, prompbmarshal.TimeSeries{
-				Labels:  labels,
-				Samples: samples,
+				Labels:    labels,
+				Samples:   samples,
+				Exemplars: exemplars,
+			})
+		}
+		for _, mm := range wr.Metadata {
+			wrm.Metadata = append(wrm.Metadata, prompbmarshal.MetricMetadata{
+				Type:             uint32(mm.Type),
+				MetricFamilyName: mm.MetricFamilyName,
+				Help:             mm.Help,
+				Unit:             mm.Unit,
 			})
 		}
 		dataResult := wrm.MarshalProtobuf(nil)
@@ -170,4 +194,135 @@ func TestWriteRequestUnmarshalProtobuf(t *testing.T) {
 	}
 	data = wrm.MarshalProtobuf(data[:0])
 	f(data)
+
+	wrm.Reset()
+	wrm.Metadata = []prompbmarshal.MetricMetadata{
+		{
+			Type:             uint32(prompb.MetricTypeCounter),
+			MetricFamilyName: "process_cpu_seconds_total",
+			Help:             "Total user and system CPU time spent in seconds.",
+			Unit:             "seconds",
+		},
+		{
+			MetricFamilyName: "foo",
+		},
+	}
+	data = wrm.MarshalProtobuf(data[:0])
+	f(data)
+
+	wrm.Reset()
+	wrm.Timeseries = []prompbmarshal.TimeSeries{
+		{
+			Labels: []prompbmarshal.Label{
+				{
+					Name:  "__name__",
+					Value: "node_memory_free_bytes",
+				},
+			},
+			Samples: []prompbmarshal.Sample{
+				{
+					Value:     1234,
+					Timestamp: 8939432423,
+				},
+			},
+		},
+	}
+	wrm.Metadata = []prompbmarshal.MetricMetadata{
+		{
+			Type:             uint32(prompb.MetricTypeGauge),
+			MetricFamilyName: "node_memory_free_bytes",
+			Help:             "Free memory",
+		},
+	}
+	data = wrm.MarshalProtobuf(data[:0])
+	f(data)
+
+	wrm.Reset()
+	wrm.Timeseries = []prompbmarshal.TimeSeries{
+		{
+			Labels: []prompbmarshal.Label{
+				{
+					Name:  "__name__",
+					Value: "http_request_duration_seconds_bucket",
+				},
+				{
+					Name:  "le",
+					Value: "0.5",
+				},
+			},
+			Samples: []prompbmarshal.Sample{
+				{
+					Value:     42,
+					Timestamp: 8939432423,
+				},
+			},
+			Exemplars: []prompbmarshal.Exemplar{
+				{
+					Labels: []prompbmarshal.Label{
+						{
+							Name:  "trace_id",
+							Value: "abc123",
+						},
+						{
+							Name:  "span_id",
+							Value: "def",
+						},
+					},
+					Value:     0.34,
+					Timestamp: 8939432400,
+				},
+				{
+					Value: 0.12,
+				},
+			},
+		},
+		{
+			Labels: []prompbmarshal.Label{
+				{
+					Name:  "foo",
+					Value: "bar",
+				},
+			},
+			Exemplars: []prompbmarshal.Exemplar{
+				{
+					Labels: []prompbmarshal.Label{
+						{
+							Name:  "trace_id",
+							Value: "qwe",
+						},
+					},
+					Value:     1,
+					Timestamp: 8939432401,
+				},
+			},
+		},
+	}
+	data = wrm.MarshalProtobuf(data[:0])
+	f(data)
+}
+
+func TestMetricType(t *testing.T) {
+	f := func(s string, mtExpected prompb.MetricType, resultExpected string) {
+		t.Helper()
+
+		mt := prompb.GetMetricType(s)
+		if mt != mtExpected {
+			t.Fatalf("unexpected metric type for %q; got %d; want %d", s, mt, mtExpected)
+		}
+		result := mt.String()
+		if result != resultExpected {
+			t.Fatalf("unexpected string representation for metric type %q; got %q; want %q", s, result, resultExpected)
+		}
+	}
+
+	f("counter", prompb.MetricTypeCounter, "counter")
+	f("gauge", prompb.MetricTypeGauge, "gauge")
+	f("histogram", prompb.MetricTypeHistogram, "histogram")
+	f("gaugehistogram", prompb.MetricTypeGaugeHistogram, "gaugehistogram")
+	f("summary", prompb.MetricTypeSummary, "summary")
+	f("info", prompb.MetricTypeInfo, "info")
+	f("stateset", prompb.MetricTypeStateset, "stateset")
+	f("unknown", prompb.MetricTypeUnknown, "unknown")
+	f("untyped", prompb.MetricTypeUnknown, "unknown")
+	f("foobar", prompb.MetricTypeUnknown, "unknown")
 }
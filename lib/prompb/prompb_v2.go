@@ -0,0 +1,562 @@
+package prompb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/VictoriaMetrics/easyproto"
+)
+
+// UnmarshalProtobufV2 unmarshals Prometheus remote write 2.0 request from src into wr.
+//
+// See https://prometheus.io/docs/specs/prw/remote_write_spec_2_0/
+//
+// The request is converted into the representation used by Prometheus remote write 1.0:
+// label references are resolved via the symbols table, while per-series metadata is put into wr.Metadata
+// with the metric family name obtained from the series name.
+// Created timestamps are ignored, since they aren't stored by VictoriaMetrics.
+//
+// src mustn't change while wr is in use, since wr points to src.
+func (wr *WriteRequest) UnmarshalProtobufV2(src []byte) (err error) {
+	wr.Reset()
+
+	// message Request {
+	//   reserved 1 to 3;
+	//   repeated string symbols        = 4;
+	//   repeated TimeSeries timeseries = 5;
+	// }
+	//
+	// The symbols table must be read before time series, since time series refer to it.
+	// The fields may be marshaled in arbitrary order, so read the symbols table in a separate pass.
+	symbols := wr.symbolsPool
+	var fc easyproto.FieldContext
+	tail := src
+	for len(tail) > 0 {
+		tail, err = fc.NextField(tail)
+		if err != nil {
+			return fmt.Errorf("cannot read the next field: %w", err)
+		}
+		if fc.FieldNum == 4 {
+			symbol, ok := fc.String()
+			if !ok {
+				return fmt.Errorf("cannot read symbol")
+			}
+			symbols = append(symbols, symbol)
+		}
+	}
+	wr.symbolsPool = symbols
+
+	tss := wr.Timeseries
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read the next field: %w", err)
+		}
+		if fc.FieldNum != 5 {
+			continue
+		}
+		data, ok := fc.MessageData()
+		if !ok {
+			return fmt.Errorf("cannot read timeseries data")
+		}
+		if len(tss) < cap(tss) {
+			tss = tss[:len(tss)+1]
+		} else {
+			tss = append(tss, TimeSeries{})
+		}
+		ts := &tss[len(tss)-1]
+		if err := ts.unmarshalProtobufV2(data, wr); err != nil {
+			return fmt.Errorf("cannot unmarshal timeseries: %w", err)
+		}
+	}
+	wr.Timeseries = tss
+	return nil
+}
+
+func (ts *TimeSeries) unmarshalProtobufV2(src []byte, wr *WriteRequest) (err error) {
+	// message TimeSeries {
+	//   repeated uint32 labels_refs   = 1;
+	//   repeated Sample samples       = 2;
+	//   repeated Histogram histograms = 3;
+	//   repeated Exemplar exemplars   = 4;
+	//   Metadata metadata             = 5;
+	//   int64 created_timestamp       = 6;
+	// }
+	samplesPool := wr.samplesPool
+	exemplarsPool := wr.exemplarsPool
+	histogramsPool := wr.histogramsPool
+	samplesPoolLen := len(samplesPool)
+	exemplarsPoolLen := len(exemplarsPool)
+	histogramsPoolLen := len(histogramsPool)
+	refs := wr.labelsRefsBuf[:0]
+	var metadata []byte
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read the next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			var ok bool
+			refs, ok = fc.UnpackUint32s(refs)
+			if !ok {
+				return fmt.Errorf("cannot read labels refs")
+			}
+		case 2:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read the sample data")
+			}
+			if len(samplesPool) < cap(samplesPool) {
+				samplesPool = samplesPool[:len(samplesPool)+1]
+			} else {
+				samplesPool = append(samplesPool, Sample{})
+			}
+			sample := &samplesPool[len(samplesPool)-1]
+			if err := sample.unmarshalProtobuf(data); err != nil {
+				return fmt.Errorf("cannot unmarshal sample: %w", err)
+			}
+		case 3:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read the histogram data")
+			}
+			if len(histogramsPool) < cap(histogramsPool) {
+				histogramsPool = histogramsPool[:len(histogramsPool)+1]
+			} else {
+				histogramsPool = append(histogramsPool, Histogram{})
+			}
+			h := &histogramsPool[len(histogramsPool)-1]
+			h.reset()
+			if err := h.unmarshalProtobuf(data); err != nil {
+				return fmt.Errorf("cannot unmarshal histogram: %w", err)
+			}
+		case 4:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read the exemplar data")
+			}
+			if len(exemplarsPool) < cap(exemplarsPool) {
+				exemplarsPool = exemplarsPool[:len(exemplarsPool)+1]
+			} else {
+				exemplarsPool = append(exemplarsPool, Exemplar{})
+			}
+			exemplar := &exemplarsPool[len(exemplarsPool)-1]
+			if err := exemplar.unmarshalProtobufV2(data, wr); err != nil {
+				return fmt.Errorf("cannot unmarshal exemplar: %w", err)
+			}
+		case 5:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read the metadata")
+			}
+			metadata = data
+		}
+	}
+	wr.labelsRefsBuf = refs
+
+	labelsPoolLen := len(wr.labelsPool)
+	wr.labelsPool, err = wr.appendLabelsFromRefs(wr.labelsPool, refs)
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal labels: %w", err)
+	}
+	ts.Labels = wr.labelsPool[labelsPoolLen:]
+	ts.Samples = samplesPool[samplesPoolLen:]
+	ts.Exemplars = exemplarsPool[exemplarsPoolLen:]
+	ts.Histograms = histogramsPool[histogramsPoolLen:]
+	wr.samplesPool = samplesPool
+	wr.exemplarsPool = exemplarsPool
+	wr.histogramsPool = histogramsPool
+
+	if metadata != nil {
+		if err := wr.addMetadataV2(metadata, ts.Labels); err != nil {
+			return fmt.Errorf("cannot unmarshal metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *Exemplar) unmarshalProtobufV2(src []byte, wr *WriteRequest) (err error) {
+	// message Exemplar {
+	//   repeated uint32 labels_refs = 1;
+	//   double value                = 2;
+	//   int64 timestamp             = 3;
+	// }
+	var refs []uint32
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read the next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			var ok bool
+			refs, ok = fc.UnpackUint32s(refs)
+			if !ok {
+				return fmt.Errorf("cannot read labels refs")
+			}
+		case 2:
+			value, ok := fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read exemplar value")
+			}
+			e.Value = value
+		case 3:
+			timestamp, ok := fc.Int64()
+			if !ok {
+				return fmt.Errorf("cannot read exemplar timestamp")
+			}
+			e.Timestamp = timestamp
+		}
+	}
+	labelsPoolLen := len(wr.exemplarLabelsPool)
+	wr.exemplarLabelsPool, err = wr.appendLabelsFromRefs(wr.exemplarLabelsPool, refs)
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal labels: %w", err)
+	}
+	e.Labels = wr.exemplarLabelsPool[labelsPoolLen:]
+	return nil
+}
+
+// addMetadataV2 unmarshals metadata for the series with the given labels from src and adds it to wr.Metadata.
+//
+// Metadata for the same metric family is added only once per request.
+func (wr *WriteRequest) addMetadataV2(src []byte, labels []Label) (err error) {
+	// message Metadata {
+	//   MetricType type = 1;
+	//   uint32 help_ref = 3;
+	//   uint32 unit_ref = 4;
+	// }
+	var mm MetricMetadata
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read the next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			mt, ok := fc.Uint32()
+			if !ok {
+				return fmt.Errorf("cannot read metric type")
+			}
+			mm.Type = MetricType(mt)
+		case 3:
+			ref, ok := fc.Uint32()
+			if !ok {
+				return fmt.Errorf("cannot read help ref")
+			}
+			mm.Help, err = wr.getSymbol(ref)
+			if err != nil {
+				return fmt.Errorf("cannot read help: %w", err)
+			}
+		case 4:
+			ref, ok := fc.Uint32()
+			if !ok {
+				return fmt.Errorf("cannot read unit ref")
+			}
+			mm.Unit, err = wr.getSymbol(ref)
+			if err != nil {
+				return fmt.Errorf("cannot read unit: %w", err)
+			}
+		}
+	}
+	if mm.Type == MetricTypeUnknown && mm.Help == "" && mm.Unit == "" {
+		return nil
+	}
+
+	var metricName string
+	for _, label := range labels {
+		if label.Name == "__name__" {
+			metricName = label.Value
+			break
+		}
+	}
+	if metricName == "" {
+		return nil
+	}
+	mm.MetricFamilyName = getMetricFamilyName(metricName, mm.Type)
+
+	if wr.metadataFamilies == nil {
+		wr.metadataFamilies = make(map[string]struct{})
+	}
+	if _, ok := wr.metadataFamilies[mm.MetricFamilyName]; ok {
+		return nil
+	}
+	wr.metadataFamilies[mm.MetricFamilyName] = struct{}{}
+	wr.Metadata = append(wr.Metadata, mm)
+	return nil
+}
+
+func (wr *WriteRequest) appendLabelsFromRefs(dst []Label, refs []uint32) ([]Label, error) {
+	if len(refs)%2 != 0 {
+		return dst, fmt.Errorf("labels refs must contain even number of items; got %d items", len(refs))
+	}
+	for i := 0; i < len(refs); i += 2 {
+		name, err := wr.getSymbol(refs[i])
+		if err != nil {
+			return dst, fmt.Errorf("cannot read label name: %w", err)
+		}
+		value, err := wr.getSymbol(refs[i+1])
+		if err != nil {
+			return dst, fmt.Errorf("cannot read label value: %w", err)
+		}
+		dst = append(dst, Label{
+			Name:  name,
+			Value: value,
+		})
+	}
+	return dst, nil
+}
+
+func (wr *WriteRequest) getSymbol(ref uint32) (string, error) {
+	if uint64(ref) >= uint64(len(wr.symbolsPool)) {
+		return "", fmt.Errorf("symbol ref %d exceeds the symbols table size %d", ref, len(wr.symbolsPool))
+	}
+	return wr.symbolsPool[ref], nil
+}
+
+// getMetricFamilyName returns metric family name for the series with the given metricName and the given mt type.
+func getMetricFamilyName(metricName string, mt MetricType) string {
+	var suffixes []string
+	switch mt {
+	case MetricTypeHistogram, MetricTypeGaugeHistogram:
+		suffixes = histogramSuffixes
+	case MetricTypeSummary:
+		suffixes = summarySuffixes
+	}
+	for _, suffix := range suffixes {
+		if s, ok := strings.CutSuffix(metricName, suffix); ok {
+			return s
+		}
+	}
+	return metricName
+}
+
+var (
+	histogramSuffixes = []string{"_bucket", "_sum", "_count"}
+	summarySuffixes   = []string{"_sum", "_count"}
+)
+
+// MarshalProtobufV2 appends Prometheus remote write 2.0 representation of wr to dst and returns the result.
+//
+// See https://prometheus.io/docs/specs/prw/remote_write_spec_2_0/
+//
+// wr.Metadata is attached to time series with the matching metric family names.
+// Metadata without the matching time series is marshaled as time series without samples.
+func (wr *WriteRequest) MarshalProtobufV2(dst []byte) []byte {
+	st := getSymbolsTable()
+	defer putSymbolsTable(st)
+
+	for i := range wr.Metadata {
+		mm := &wr.Metadata[i]
+		if _, ok := st.metadataIdxs[mm.MetricFamilyName]; !ok {
+			st.metadataIdxs[mm.MetricFamilyName] = i
+		}
+	}
+
+	m := mp.Get()
+	mmRequest := m.MessageMarshaler()
+	for i := range wr.Timeseries {
+		ts := &wr.Timeseries[i]
+		mmTS := mmRequest.AppendMessage(5)
+		st.refs = st.appendLabelsRefs(st.refs[:0], ts.Labels)
+		mmTS.AppendUint32s(1, st.refs)
+		for _, s := range ts.Samples {
+			mmSample := mmTS.AppendMessage(2)
+			mmSample.AppendDouble(1, s.Value)
+			mmSample.AppendInt64(2, s.Timestamp)
+		}
+		for j := range ts.Histograms {
+			ts.Histograms[j].marshalProtobuf(mmTS.AppendMessage(3))
+		}
+		for _, e := range ts.Exemplars {
+			mmExemplar := mmTS.AppendMessage(4)
+			st.refs = st.appendLabelsRefs(st.refs[:0], e.Labels)
+			mmExemplar.AppendUint32s(1, st.refs)
+			mmExemplar.AppendDouble(2, e.Value)
+			mmExemplar.AppendInt64(3, e.Timestamp)
+		}
+		if mm := st.getMetadataForLabels(wr.Metadata, ts.Labels); mm != nil {
+			st.marshalMetadata(mmTS.AppendMessage(5), mm)
+		}
+	}
+
+	// Metadata without the matching time series.
+	for i := range wr.Metadata {
+		mm := &wr.Metadata[i]
+		if st.metadataIdxs[mm.MetricFamilyName] != i {
+			continue
+		}
+		if _, ok := st.attachedFamilies[mm.MetricFamilyName]; ok {
+			continue
+		}
+		mmTS := mmRequest.AppendMessage(5)
+		mmTS.AppendUint32s(1, []uint32{st.getRef("__name__"), st.getRef(mm.MetricFamilyName)})
+		st.marshalMetadata(mmTS.AppendMessage(5), mm)
+	}
+
+	for _, symbol := range st.symbols {
+		mmRequest.AppendString(4, symbol)
+	}
+	dst = m.Marshal(dst)
+	mp.Put(m)
+	return dst
+}
+
+func (h *Histogram) marshalProtobuf(mm *easyproto.MessageMarshaler) {
+	// Integer histograms are marshaled with deltas, while float histograms are marshaled with absolute counts.
+	isInt := len(h.NegativeDeltas) > 0 || len(h.PositiveDeltas) > 0
+	if isInt {
+		mm.AppendUint64(1, uint64(h.Count))
+	} else {
+		mm.AppendDouble(2, h.Count)
+	}
+	mm.AppendDouble(3, h.Sum)
+	mm.AppendSint32(4, h.Schema)
+	mm.AppendDouble(5, h.ZeroThreshold)
+	if isInt {
+		mm.AppendUint64(6, uint64(h.ZeroCount))
+	} else {
+		mm.AppendDouble(7, h.ZeroCount)
+	}
+	for _, bs := range h.NegativeSpans {
+		bs.marshalProtobuf(mm.AppendMessage(8))
+	}
+	if len(h.NegativeDeltas) > 0 {
+		mm.AppendSint64s(9, h.NegativeDeltas)
+	}
+	if len(h.NegativeCounts) > 0 {
+		mm.AppendDoubles(10, h.NegativeCounts)
+	}
+	for _, bs := range h.PositiveSpans {
+		bs.marshalProtobuf(mm.AppendMessage(11))
+	}
+	if len(h.PositiveDeltas) > 0 {
+		mm.AppendSint64s(12, h.PositiveDeltas)
+	}
+	if len(h.PositiveCounts) > 0 {
+		mm.AppendDoubles(13, h.PositiveCounts)
+	}
+	mm.AppendInt64(15, h.Timestamp)
+	if len(h.CustomValues) > 0 {
+		mm.AppendDoubles(16, h.CustomValues)
+	}
+}
+
+func (bs *BucketSpan) marshalProtobuf(mm *easyproto.MessageMarshaler) {
+	mm.AppendSint32(1, bs.Offset)
+	mm.AppendUint32(2, bs.Length)
+}
+
+var mp easyproto.MarshalerPool
+
+// symbolsTable is used for building the symbols table at MarshalProtobufV2.
+type symbolsTable struct {
+	symbols []string
+	refsMap map[string]uint32
+
+	refs []uint32
+
+	// metadataIdxs maps metric family names to the index of the first metadata entry with this name.
+	metadataIdxs map[string]int
+
+	// attachedFamilies contains metric family names with the metadata attached to some time series.
+	attachedFamilies map[string]struct{}
+}
+
+func (st *symbolsTable) reset() {
+	clear(st.symbols)
+	st.symbols = st.symbols[:0]
+	clear(st.refsMap)
+	st.refs = st.refs[:0]
+	clear(st.metadataIdxs)
+	clear(st.attachedFamilies)
+}
+
+func (st *symbolsTable) getRef(s string) uint32 {
+	if ref, ok := st.refsMap[s]; ok {
+		return ref
+	}
+	ref := uint32(len(st.symbols))
+	st.symbols = append(st.symbols, s)
+	st.refsMap[s] = ref
+	return ref
+}
+
+func (st *symbolsTable) appendLabelsRefs(dst []uint32, labels []Label) []uint32 {
+	for _, label := range labels {
+		dst = append(dst, st.getRef(label.Name), st.getRef(label.Value))
+	}
+	return dst
+}
+
+func (st *symbolsTable) getMetadataForLabels(mms []MetricMetadata, labels []Label) *MetricMetadata {
+	if len(st.metadataIdxs) == 0 {
+		return nil
+	}
+	var metricName string
+	for _, label := range labels {
+		if label.Name == "__name__" {
+			metricName = label.Value
+			break
+		}
+	}
+	if metricName == "" {
+		return nil
+	}
+	if idx, ok := st.metadataIdxs[metricName]; ok {
+		mm := &mms[idx]
+		st.attachedFamilies[mm.MetricFamilyName] = struct{}{}
+		return mm
+	}
+	for _, suffix := range histogramSuffixes {
+		family, ok := strings.CutSuffix(metricName, suffix)
+		if !ok {
+			continue
+		}
+		idx, ok := st.metadataIdxs[family]
+		if !ok {
+			return nil
+		}
+		mm := &mms[idx]
+		if getMetricFamilyName(metricName, mm.Type) != family {
+			return nil
+		}
+		st.attachedFamilies[mm.MetricFamilyName] = struct{}{}
+		return mm
+	}
+	return nil
+}
+
+func (st *symbolsTable) marshalMetadata(mm *easyproto.MessageMarshaler, metadata *MetricMetadata) {
+	mm.AppendUint32(1, uint32(metadata.Type))
+	mm.AppendUint32(3, st.getRef(metadata.Help))
+	mm.AppendUint32(4, st.getRef(metadata.Unit))
+}
+
+func getSymbolsTable() *symbolsTable {
+	v := symbolsTablePool.Get()
+	if v == nil {
+		return &symbolsTable{
+			// The first symbol must be an empty string according to the spec.
+			symbols:          []string{""},
+			refsMap:          map[string]uint32{"": 0},
+			metadataIdxs:     make(map[string]int),
+			attachedFamilies: make(map[string]struct{}),
+		}
+	}
+	return v.(*symbolsTable)
+}
+
+func putSymbolsTable(st *symbolsTable) {
+	st.reset()
+	st.symbols = append(st.symbols, "")
+	st.refsMap[""] = 0
+	symbolsTablePool.Put(st)
+}
+
+var symbolsTablePool sync.Pool
@@ -0,0 +1,270 @@
+package prompb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/VictoriaMetrics/easyproto"
+)
+
+func TestWriteRequestMarshalUnmarshalProtobufV2(t *testing.T) {
+	f := func(wrSrc *WriteRequest, metadataExpected []MetricMetadata) {
+		t.Helper()
+
+		data := wrSrc.MarshalProtobufV2(nil)
+
+		var wr WriteRequest
+		if err := wr.UnmarshalProtobufV2(data); err != nil {
+			t.Fatalf("cannot unmarshal protobuf: %s", err)
+		}
+
+		// Time series for metadata without the matching samples are added at the end.
+		tss := wr.Timeseries
+		for len(tss) > 0 {
+			ts := &tss[len(tss)-1]
+			if len(ts.Samples) > 0 || len(ts.Histograms) > 0 {
+				break
+			}
+			tss = tss[:len(tss)-1]
+		}
+		result := fmt.Sprintf("%+v", tss)
+		resultExpected := fmt.Sprintf("%+v", wrSrc.Timeseries)
+		if result != resultExpected {
+			t.Fatalf("unexpected time series\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+
+		result = fmt.Sprintf("%+v", wr.Metadata)
+		resultExpected = fmt.Sprintf("%+v", metadataExpected)
+		if result != resultExpected {
+			t.Fatalf("unexpected metadata\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+
+	// empty request
+	f(&WriteRequest{}, nil)
+
+	// samples and exemplars
+	f(&WriteRequest{
+		Timeseries: []TimeSeries{
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "foo"},
+					{Name: "job", Value: "bar"},
+				},
+				Samples: []Sample{
+					{Value: 1.5, Timestamp: 123},
+					{Value: -2, Timestamp: 456},
+				},
+				Exemplars: []Exemplar{
+					{
+						Labels: []Label{
+							{Name: "trace_id", Value: "abc"},
+						},
+						Value:     1.5,
+						Timestamp: 120,
+					},
+				},
+			},
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "foo"},
+					{Name: "job", Value: "baz"},
+				},
+				Samples: []Sample{
+					{Value: 3, Timestamp: 789},
+				},
+			},
+		},
+	}, nil)
+
+	// native histograms
+	f(&WriteRequest{
+		Timeseries: []TimeSeries{
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "int_histogram"},
+				},
+				Histograms: []Histogram{
+					{
+						Count:          10,
+						Sum:            12.5,
+						Schema:         1,
+						ZeroThreshold:  0.001,
+						ZeroCount:      1,
+						NegativeSpans:  []BucketSpan{{Offset: 2, Length: 1}},
+						NegativeDeltas: []int64{3},
+						PositiveSpans:  []BucketSpan{{Offset: -1, Length: 2}, {Offset: 3, Length: 1}},
+						PositiveDeltas: []int64{2, -2, 4},
+						Timestamp:      1234,
+					},
+				},
+			},
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "float_histogram"},
+				},
+				Histograms: []Histogram{
+					{
+						Count:          3.5,
+						Sum:            10,
+						Schema:         CustomBucketsSchema,
+						PositiveSpans:  []BucketSpan{{Offset: 0, Length: 2}},
+						PositiveCounts: []float64{1.5, 2},
+						Timestamp:      4567,
+						CustomValues:   []float64{0.5, 1},
+					},
+				},
+			},
+		},
+	}, nil)
+
+	// metadata
+	f(&WriteRequest{
+		Timeseries: []TimeSeries{
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "request_duration_seconds_bucket"},
+					{Name: "le", Value: "0.1"},
+				},
+				Samples: []Sample{{Value: 1, Timestamp: 10}},
+			},
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "request_duration_seconds_count"},
+				},
+				Samples: []Sample{{Value: 2, Timestamp: 10}},
+			},
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "requests_total"},
+				},
+				Samples: []Sample{{Value: 3, Timestamp: 10}},
+			},
+		},
+		Metadata: []MetricMetadata{
+			{
+				Type:             MetricTypeHistogram,
+				MetricFamilyName: "request_duration_seconds",
+				Help:             "Request duration",
+				Unit:             "seconds",
+			},
+			{
+				Type:             MetricTypeCounter,
+				MetricFamilyName: "requests_total",
+				Help:             "The number of requests",
+			},
+			{
+				Type:             MetricTypeGauge,
+				MetricFamilyName: "metric_without_samples",
+				Help:             "Some gauge",
+			},
+		},
+	}, []MetricMetadata{
+		{
+			Type:             MetricTypeHistogram,
+			MetricFamilyName: "request_duration_seconds",
+			Help:             "Request duration",
+			Unit:             "seconds",
+		},
+		{
+			Type:             MetricTypeCounter,
+			MetricFamilyName: "requests_total",
+			Help:             "The number of requests",
+		},
+		{
+			Type:             MetricTypeGauge,
+			MetricFamilyName: "metric_without_samples",
+			Help:             "Some gauge",
+		},
+	})
+}
+
+func TestWriteRequestUnmarshalProtobufV2Failure(t *testing.T) {
+	f := func(data []byte) {
+		t.Helper()
+
+		var wr WriteRequest
+		if err := wr.UnmarshalProtobufV2(data); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// invalid protobuf
+	f([]byte("foobar"))
+
+	// odd number of labels refs
+	var m easyproto.Marshaler
+	mm := m.MessageMarshaler()
+	mm.AppendString(4, "")
+	mm.AppendString(4, "__name__")
+	ts := mm.AppendMessage(5)
+	ts.AppendUint32s(1, []uint32{1})
+	f(m.Marshal(nil))
+
+	// labels ref outside the symbols table
+	m.Reset()
+	mm = m.MessageMarshaler()
+	mm.AppendString(4, "")
+	mm.AppendString(4, "__name__")
+	ts = mm.AppendMessage(5)
+	ts.AppendUint32s(1, []uint32{1, 2})
+	f(m.Marshal(nil))
+
+	// metadata help ref outside the symbols table
+	m.Reset()
+	mm = m.MessageMarshaler()
+	mm.AppendString(4, "")
+	mm.AppendString(4, "__name__")
+	mm.AppendString(4, "foo")
+	ts = mm.AppendMessage(5)
+	ts.AppendUint32s(1, []uint32{1, 2})
+	metadata := ts.AppendMessage(5)
+	metadata.AppendUint32(1, uint32(MetricTypeGauge))
+	metadata.AppendUint32(3, 10)
+	f(m.Marshal(nil))
+}
+
+func TestWriteRequestUnmarshalProtobufV2MetadataDedup(t *testing.T) {
+	// Symbols are marshaled after time series in order to verify that the order of fields doesn't matter.
+	var m easyproto.Marshaler
+	mm := m.MessageMarshaler()
+	for _, le := range []uint32{3, 4} {
+		ts := mm.AppendMessage(5)
+		ts.AppendUint32s(1, []uint32{1, 2, 5, le})
+		sample := ts.AppendMessage(2)
+		sample.AppendDouble(1, 1)
+		sample.AppendInt64(2, 10)
+		metadata := ts.AppendMessage(5)
+		metadata.AppendUint32(1, uint32(MetricTypeHistogram))
+		metadata.AppendUint32(3, 6)
+	}
+	for _, symbol := range []string{"", "__name__", "foo_bucket", "0.5", "+Inf", "le", "foo help"} {
+		mm.AppendString(4, symbol)
+	}
+	data := m.Marshal(nil)
+
+	var wr WriteRequest
+	if err := wr.UnmarshalProtobufV2(data); err != nil {
+		t.Fatalf("cannot unmarshal protobuf: %s", err)
+	}
+	result := fmt.Sprintf("%+v", wr.Timeseries)
+	resultExpected := "[{Labels:[{Name:__name__ Value:foo_bucket} {Name:le Value:0.5}] Samples:[{Value:1 Timestamp:10}] Exemplars:[] Histograms:[]} " +
+		"{Labels:[{Name:__name__ Value:foo_bucket} {Name:le Value:+Inf}] Samples:[{Value:1 Timestamp:10}] Exemplars:[] Histograms:[]}]"
+	if result != resultExpected {
+		t.Fatalf("unexpected time series\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+	result = fmt.Sprintf("%+v", wr.Metadata)
+	resultExpected = "[{Type:histogram MetricFamilyName:foo Help:foo help Unit:}]"
+	if result != resultExpected {
+		t.Fatalf("unexpected metadata\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+
+	// Verify that metadata is reset between requests
+	if err := wr.UnmarshalProtobufV2(data); err != nil {
+		t.Fatalf("cannot unmarshal protobuf: %s", err)
+	}
+	result = fmt.Sprintf("%+v", wr.Metadata)
+	if result != resultExpected {
+		t.Fatalf("unexpected metadata on the second unmarshal\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+}
@@ -22,6 +22,7 @@ func (wr *WriteRequest) MarshalProtobuf(dst []byte) []byte {
 // Reset resets wr.
 func (wr *WriteRequest) Reset() {
 	wr.Timeseries = ResetTimeSeries(wr.Timeseries)
+	wr.Metadata = ResetMetadata(wr.Metadata)
 }
 
 // ResetTimeSeries clears all the GC references from tss and returns an empty tss ready for further use.
@@ -30,6 +31,12 @@ func ResetTimeSeries(tss []TimeSeries) []TimeSeries {
 	return tss[:0]
 }
 
+// ResetMetadata clears all the GC references from mms and returns an empty mms ready for further use.
+func ResetMetadata(mms []MetricMetadata) []MetricMetadata {
+	clear(mms)
+	return mms[:0]
+}
+
 // MustParsePromMetrics parses metrics in Prometheus text exposition format from s and returns them.
 //
 // Metrics must be delimited with newlines.
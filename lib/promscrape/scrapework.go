@@ -20,9 +20,11 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/leveledbytebufferpool"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
 	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus/stream"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
@@ -476,6 +478,7 @@ func (sw *scrapeWork) processDataOneShot(scrapeTimestamp, realTimestamp int64, b
 	for i := range srcRows {
 		sw.addRowToTimeseries(wc, &srcRows[i], scrapeTimestamp, true)
 	}
+	addMetadata(wc, wc.rows.Metadata)
 	samplesPostRelabeling := len(wc.writeRequest.Timeseries)
 	if sw.Config.SampleLimit > 0 && samplesPostRelabeling > sw.Config.SampleLimit {
 		wc.resetNoRows()
@@ -538,7 +541,7 @@ func (sw *scrapeWork) processDataInStreamMode(scrapeTimestamp, realTimestamp int
 
 	r := body.NewReader()
 	var mu sync.Mutex
-	err := stream.Parse(r, scrapeTimestamp, false, false, func(rows []parser.Row) error {
+	err := stream.Parse(r, scrapeTimestamp, false, false, func(rows []parser.Row, mms []parser.Metadata) error {
 		mu.Lock()
 		defer mu.Unlock()
 
@@ -546,6 +549,7 @@ func (sw *scrapeWork) processDataInStreamMode(scrapeTimestamp, realTimestamp int
 		for i := range rows {
 			sw.addRowToTimeseries(wc, &rows[i], scrapeTimestamp, true)
 		}
+		addMetadata(wc, mms)
 		samplesPostRelabeling += len(wc.writeRequest.Timeseries)
 		if sw.Config.SampleLimit > 0 && samplesPostRelabeling > sw.Config.SampleLimit {
 			wc.resetNoRows()
@@ -609,6 +613,26 @@ func (sw *scrapeWork) processDataInStreamMode(scrapeTimestamp, realTimestamp int
 	return err
 }
 
+// addMetadata adds mms to wc.writeRequest if -enableMetadata command-line flag is set.
+//
+// mms must remain valid until wc.writeRequest is pushed.
+func addMetadata(wc *writeRequestCtx, mms []parser.Metadata) {
+	if !common.IsMetadataEnabled() {
+		return
+	}
+	dst := wc.writeRequest.Metadata
+	for i := range mms {
+		mm := &mms[i]
+		dst = append(dst, prompbmarshal.MetricMetadata{
+			Type:             uint32(prompb.GetMetricType(mm.Type)),
+			MetricFamilyName: mm.Metric,
+			Help:             mm.Help,
+			Unit:             mm.Unit,
+		})
+	}
+	wc.writeRequest.Metadata = dst
+}
+
 func (sw *scrapeWork) pushData(at *auth.Token, wr *prompbmarshal.WriteRequest) {
 	startTime := time.Now()
 	sw.PushData(at, wr)
@@ -675,6 +699,7 @@ type writeRequestCtx struct {
 	writeRequest prompbmarshal.WriteRequest
 	labels       []prompbmarshal.Label
 	samples      []prompbmarshal.Sample
+	exemplars    []prompbmarshal.Exemplar
 }
 
 func (wc *writeRequestCtx) reset() {
@@ -689,6 +714,9 @@ func (wc *writeRequestCtx) resetNoRows() {
 	wc.labels = wc.labels[:0]
 
 	wc.samples = wc.samples[:0]
+
+	clear(wc.exemplars)
+	wc.exemplars = wc.exemplars[:0]
 }
 
 var writeRequestCtxPool leveledWriteRequestCtxPool
@@ -757,7 +785,7 @@ func (sw *scrapeWork) sendStaleSeries(lastScrape, currScrape string, timestamp i
 		// and https://github.com/VictoriaMetrics/VictoriaMetrics/issues/3675
 		var mu sync.Mutex
 		br := bytes.NewBufferString(bodyString)
-		err := stream.Parse(br, timestamp, false, false, func(rows []parser.Row) error {
+		err := stream.Parse(br, timestamp, false, false, func(rows []parser.Row, _ []parser.Metadata) error {
 			mu.Lock()
 			defer mu.Unlock()
 			for i := range rows {
@@ -874,6 +902,7 @@ func (sw *scrapeWork) addAutoTimeseries(wc *writeRequestCtx, name string, value
 	sw.tmpRow.Tags = nil
 	sw.tmpRow.Value = value
 	sw.tmpRow.Timestamp = timestamp
+	sw.tmpRow.HasExemplar = false
 	sw.addRowToTimeseries(wc, &sw.tmpRow, timestamp, false)
 }
 
@@ -921,11 +950,38 @@ func (sw *scrapeWork) addRowToTimeseries(wc *writeRequestCtx, r *parser.Row, tim
 		Value:     r.Value,
 		Timestamp: sampleTimestamp,
 	})
+	seriesLabels := wc.labels[labelsLen:]
+	var exemplars []prompbmarshal.Exemplar
+	if r.HasExemplar && *scrapeExemplars {
+		exemplars = wc.addExemplar(&r.Exemplar, sampleTimestamp)
+	}
 	wr := &wc.writeRequest
 	wr.Timeseries = append(wr.Timeseries, prompbmarshal.TimeSeries{
-		Labels:  wc.labels[labelsLen:],
-		Samples: wc.samples[len(wc.samples)-1:],
+		Labels:    seriesLabels[:len(seriesLabels):len(seriesLabels)],
+		Samples:   wc.samples[len(wc.samples)-1:],
+		Exemplars: exemplars,
+	})
+}
+
+// addExemplar adds e to wc and returns a slice with the added exemplar.
+func (wc *writeRequestCtx) addExemplar(e *parser.Exemplar, sampleTimestamp int64) []prompbmarshal.Exemplar {
+	labelsLen := len(wc.labels)
+	for _, tag := range e.Tags {
+		wc.labels = append(wc.labels, prompbmarshal.Label{
+			Name:  tag.Key,
+			Value: tag.Value,
+		})
+	}
+	timestamp := e.Timestamp
+	if timestamp == 0 {
+		timestamp = sampleTimestamp
+	}
+	wc.exemplars = append(wc.exemplars, prompbmarshal.Exemplar{
+		Labels:    wc.labels[labelsLen:],
+		Value:     e.Value,
+		Timestamp: timestamp,
 	})
+	return wc.exemplars[len(wc.exemplars)-1:]
 }
 
 var bbPool bytesutil.ByteBufferPool
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"math"
+	"strconv"
+)
+
+// ExponentialBucketBounds returns bounds for the bucket with the given index in exponential histogram with the given schema.
+//
+// The bucket contains observations in the range (lower...upper], where upper=2^(index*2^-schema) and lower=2^((index-1)*2^-schema).
+// This matches bucket indexes in Prometheus native histograms.
+// Bucket indexes in OpenTelemetry exponential histograms must be incremented by one before passing them to this function.
+//
+// See https://prometheus.io/docs/specs/native_histograms/#exponential-buckets
+func ExponentialBucketBounds(schema, index int32) (float64, float64) {
+	ratio := math.Exp2(-float64(schema))
+	lower := math.Exp2(float64(index-1) * ratio)
+	upper := math.Exp2(float64(index) * ratio)
+	return lower, upper
+}
+
+// AppendVMRange appends `vmrange` label value for the histogram bucket with the given bounds to dst and returns the result.
+//
+// Bounds are formatted with the precision needed for distinguishing adjacent exponential buckets,
+// so the same bucket is always converted to the same `vmrange` label value.
+func AppendVMRange(dst []byte, lower, upper float64) []byte {
+	dst = appendVMRangeBound(dst, lower)
+	dst = append(dst, "..."...)
+	return appendVMRangeBound(dst, upper)
+}
+
+func appendVMRangeBound(dst []byte, v float64) []byte {
+	if v == 0 {
+		// Avoid `-0` bound.
+		v = 0
+	}
+	return strconv.AppendFloat(dst, v, 'e', -1, 64)
+}
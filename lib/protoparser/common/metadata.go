@@ -0,0 +1,14 @@
+package common
+
+import (
+	"flag"
+)
+
+var enableMetadata = flag.Bool("enableMetadata", false, "Whether to process metric metadata such as `# HELP`, `# TYPE` and `# UNIT` lines "+
+	"from scrape targets and Prometheus text exposition format, and metadata from Prometheus remote write requests. "+
+	"See https://docs.victoriametrics.com/#metrics-metadata")
+
+// IsMetadataEnabled returns true if metric metadata must be processed according to -enableMetadata command-line flag.
+func IsMetadataEnabled() bool {
+	return *enableMetadata
+}
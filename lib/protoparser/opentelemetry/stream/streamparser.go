@@ -1,6 +1,7 @@
 package stream
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"math"
@@ -121,6 +122,7 @@ func (wr *writeContext) appendSampleFromNumericPoint(metricName string, p *pb.Nu
 	wr.pointLabels = appendAttributesToPromLabels(wr.pointLabels[:0], p.Attributes)
 
 	wr.appendSample(metricName, t, v, isStale)
+	wr.appendExemplarsToLastSeries(p.Exemplars, t, math.Inf(-1), math.Inf(1))
 }
 
 // appendSamplesFromSummary appends summary p to wr.tss
@@ -160,55 +162,56 @@ func (wr *writeContext) appendSamplesFromHistogram(metricName string, p *pb.Hist
 	}
 
 	var cumulative uint64
+	prevBound := math.Inf(-1)
 	for index, bound := range p.ExplicitBounds {
 		cumulative += p.BucketCounts[index]
 		boundLabelValue := strconv.FormatFloat(bound, 'f', -1, 64)
 		wr.appendSampleWithExtraLabel(metricName+"_bucket", "le", boundLabelValue, t, float64(cumulative), isStale)
+		wr.appendExemplarsToLastSeries(p.Exemplars, t, prevBound, bound)
+		prevBound = bound
 	}
 	cumulative += p.BucketCounts[len(p.BucketCounts)-1]
 	wr.appendSampleWithExtraLabel(metricName+"_bucket", "le", "+Inf", t, float64(cumulative), isStale)
+	wr.appendExemplarsToLastSeries(p.Exemplars, t, prevBound, math.Inf(1))
 }
 
 // appendSamplesFromExponentialHistogram appends histogram p to wr.tss
+//
+// Buckets are converted to buckets with `vmrange` labels in the same way as Prometheus native histograms.
+// See https://opentelemetry.io/docs/specs/otel/metrics/data-model/#exponentialhistogram
 func (wr *writeContext) appendSamplesFromExponentialHistogram(metricName string, p *pb.ExponentialHistogramDataPoint) {
 	t := int64(p.TimeUnixNano / 1e6)
 	isStale := (p.Flags)&uint32(1) != 0
 	wr.pointLabels = appendAttributesToPromLabels(wr.pointLabels[:0], p.Attributes)
 	wr.appendSample(metricName+"_count", t, float64(p.Count), isStale)
-	if p.Sum == nil {
-		// fast path, convert metric as simple counter.
-		// given buckets cannot be used for histogram functions.
-		// Negative threshold buckets MAY be used, but then the Histogram MetricPoint MUST NOT contain a sum value as it would no longer be a counter semantically.
+	wr.appendExemplarsToLastSeries(p.Exemplars, t, math.Inf(-1), math.Inf(1))
+	if p.Sum != nil {
+		// The sum may be missing if the histogram contains negative observations.
 		// https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md#histogram
-		return
+		wr.appendSample(metricName+"_sum", t, *p.Sum, isStale)
 	}
 
-	wr.appendSample(metricName+"_sum", t, *p.Sum, isStale)
+	var buf []byte
 	if p.ZeroCount > 0 {
-		vmRange := fmt.Sprintf("%.3e...%.3e", 0.0, p.ZeroThreshold)
-		wr.appendSampleWithExtraLabel(metricName+"_bucket", "vmrange", vmRange, t, float64(p.ZeroCount), isStale)
+		buf = common.AppendVMRange(buf[:0], -p.ZeroThreshold, p.ZeroThreshold)
+		wr.appendSampleWithExtraLabel(metricName+"_bucket", "vmrange", string(buf), t, float64(p.ZeroCount), isStale)
 	}
-	ratio := math.Pow(2, -float64(p.Scale))
-	base := math.Pow(2, ratio)
-	if p.Positive != nil {
-		bound := math.Pow(2, float64(p.Positive.Offset)*ratio)
-		for i, s := range p.Positive.BucketCounts {
+	if p.Negative != nil {
+		for i, s := range p.Negative.BucketCounts {
 			if s > 0 {
-				lowerBound := bound * math.Pow(base, float64(i))
-				upperBound := lowerBound * base
-				vmRange := fmt.Sprintf("%.3e...%.3e", lowerBound, upperBound)
-				wr.appendSampleWithExtraLabel(metricName+"_bucket", "vmrange", vmRange, t, float64(s), isStale)
+				// OpenTelemetry bucket with index i contains observations in the range (base^i...base^(i+1)].
+				lowerBound, upperBound := common.ExponentialBucketBounds(p.Scale, p.Negative.Offset+int32(i)+1)
+				buf = common.AppendVMRange(buf[:0], -upperBound, -lowerBound)
+				wr.appendSampleWithExtraLabel(metricName+"_bucket", "vmrange", string(buf), t, float64(s), isStale)
 			}
 		}
 	}
-	if p.Negative != nil {
-		bound := math.Pow(2, -float64(p.Negative.Offset)*ratio)
-		for i, s := range p.Negative.BucketCounts {
+	if p.Positive != nil {
+		for i, s := range p.Positive.BucketCounts {
 			if s > 0 {
-				upperBound := bound * math.Pow(base, float64(i))
-				lowerBound := upperBound / base
-				vmRange := fmt.Sprintf("%.3e...%.3e", lowerBound, upperBound)
-				wr.appendSampleWithExtraLabel(metricName+"_bucket", "vmrange", vmRange, t, float64(s), isStale)
+				lowerBound, upperBound := common.ExponentialBucketBounds(p.Scale, p.Positive.Offset+int32(i)+1)
+				buf = common.AppendVMRange(buf[:0], lowerBound, upperBound)
+				wr.appendSampleWithExtraLabel(metricName+"_bucket", "vmrange", string(buf), t, float64(s), isStale)
 			}
 		}
 	}
@@ -262,6 +265,58 @@ func (wr *writeContext) appendSampleWithExtraLabel(metricName, labelName, labelV
 	rowsRead.Inc()
 }
 
+// appendExemplarsToLastSeries attaches exemplars with values in the (lowerBound ... upperBound] range to the last time series in wr.tss.
+//
+// t is used as exemplar timestamp if the exemplar has no timestamp.
+func (wr *writeContext) appendExemplarsToLastSeries(exemplars []*pb.Exemplar, t int64, lowerBound, upperBound float64) {
+	if len(exemplars) == 0 {
+		return
+	}
+
+	labelsPool := wr.labelsPool
+	exemplarsPool := wr.exemplarsPool
+	exemplarsLen := len(exemplarsPool)
+	for _, e := range exemplars {
+		var v float64
+		switch {
+		case e.IntValue != nil:
+			v = float64(*e.IntValue)
+		case e.DoubleValue != nil:
+			v = *e.DoubleValue
+		}
+		if v <= lowerBound || v > upperBound {
+			continue
+		}
+		labelsLen := len(labelsPool)
+		labelsPool = appendAttributesToPromLabels(labelsPool, e.FilteredAttributes)
+		if len(e.TraceID) > 0 {
+			labelsPool = append(labelsPool, prompbmarshal.Label{
+				Name:  "trace_id",
+				Value: hex.EncodeToString(e.TraceID),
+			})
+		}
+		if len(e.SpanID) > 0 {
+			labelsPool = append(labelsPool, prompbmarshal.Label{
+				Name:  "span_id",
+				Value: hex.EncodeToString(e.SpanID),
+			})
+		}
+		exemplarTimestamp := int64(e.TimeUnixNano / 1e6)
+		if exemplarTimestamp <= 0 {
+			exemplarTimestamp = t
+		}
+		exemplarsPool = append(exemplarsPool, prompbmarshal.Exemplar{
+			Labels:    labelsPool[labelsLen:],
+			Value:     v,
+			Timestamp: exemplarTimestamp,
+		})
+	}
+	wr.tss[len(wr.tss)-1].Exemplars = exemplarsPool[exemplarsLen:]
+
+	wr.labelsPool = labelsPool
+	wr.exemplarsPool = exemplarsPool
+}
+
 // appendAttributesToPromLabels appends attributes to dst and returns the result.
 func appendAttributesToPromLabels(dst []prompbmarshal.Label, attributes []*pb.KeyValue) []prompbmarshal.Label {
 	for _, at := range attributes {
@@ -287,8 +342,9 @@ type writeContext struct {
 	pointLabels []prompbmarshal.Label
 
 	// pools are used for reducing memory allocations when parsing time series
-	labelsPool  []prompbmarshal.Label
-	samplesPool []prompbmarshal.Sample
+	labelsPool    []prompbmarshal.Label
+	samplesPool   []prompbmarshal.Sample
+	exemplarsPool []prompbmarshal.Exemplar
 }
 
 func (wr *writeContext) reset() {
@@ -302,6 +358,9 @@ func (wr *writeContext) reset() {
 
 	wr.labelsPool = resetLabels(wr.labelsPool)
 	wr.samplesPool = wr.samplesPool[:0]
+
+	clear(wr.exemplarsPool)
+	wr.exemplarsPool = wr.exemplarsPool[:0]
 }
 
 func resetLabels(labels []prompbmarshal.Label) []prompbmarshal.Label {
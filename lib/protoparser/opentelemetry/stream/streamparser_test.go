@@ -206,14 +206,103 @@ func TestParseStream(t *testing.T) {
 			generateExpHistogram("test-histogram", "m/s"),
 		},
 		[]prompbmarshal.TimeSeries{
-			newPromPBTs("test_histogram_meters_per_second_bucket", 15000, 5.0, jobLabelValue, kvLabel("label1", "value1"), kvLabel("vmrange", "1.061e+00...1.067e+00")),
-			newPromPBTs("test_histogram_meters_per_second_bucket", 15000, 10.0, jobLabelValue, kvLabel("label1", "value1"), kvLabel("vmrange", "1.067e+00...1.073e+00")),
-			newPromPBTs("test_histogram_meters_per_second_bucket", 15000, 1.0, jobLabelValue, kvLabel("label1", "value1"), kvLabel("vmrange", "1.085e+00...1.091e+00")),
+			newPromPBTs("test_histogram_meters_per_second_bucket", 15000, 5.0, jobLabelValue, kvLabel("label1", "value1"), kvLabel("vmrange", "1.061377227289262e+00...1.0671404006768237e+00")),
+			newPromPBTs("test_histogram_meters_per_second_bucket", 15000, 10.0, jobLabelValue, kvLabel("label1", "value1"), kvLabel("vmrange", "1.0671404006768237e+00...1.0729348675259756e+00")),
+			newPromPBTs("test_histogram_meters_per_second_bucket", 15000, 1.0, jobLabelValue, kvLabel("label1", "value1"), kvLabel("vmrange", "1.0846183622133092e+00...1.0905077326652577e+00")),
 			newPromPBTs("test_histogram_meters_per_second_count", 15000, 20.0, jobLabelValue, kvLabel("label1", "value1")),
 			newPromPBTs("test_histogram_meters_per_second_sum", 15000, 4578.0, jobLabelValue, kvLabel("label1", "value1")),
 		},
 		true,
 	)
+
+	// Test exponential histograms with zero and negative buckets
+	f(
+		[]*pb.Metric{
+			generateExpHistogramWithNegativeBuckets("test-histogram"),
+		},
+		[]prompbmarshal.TimeSeries{
+			newPromPBTs("test_histogram_bucket", 15000, 1.0, jobLabelValue, kvLabel("label1", "value1"), kvLabel("vmrange", "-5e-01...5e-01")),
+			newPromPBTs("test_histogram_bucket", 15000, 2.0, jobLabelValue, kvLabel("label1", "value1"), kvLabel("vmrange", "-4e+00...-2e+00")),
+			newPromPBTs("test_histogram_bucket", 15000, 3.0, jobLabelValue, kvLabel("label1", "value1"), kvLabel("vmrange", "1e+00...2e+00")),
+			newPromPBTs("test_histogram_count", 15000, 6.0, jobLabelValue, kvLabel("label1", "value1")),
+		},
+		true,
+	)
+}
+
+func TestParseStreamExemplars(t *testing.T) {
+	v := 0.3
+	n := int64(42)
+	m := generateHistogram("my-histogram", "", true)
+	m.Histogram.DataPoints[0].Exemplars = []*pb.Exemplar{
+		{
+			FilteredAttributes: attributesFromKV("foo", "bar"),
+			TimeUnixNano:       uint64(29 * time.Second),
+			DoubleValue:        &v,
+			TraceID:            []byte{0x01, 0xab},
+			SpanID:             []byte{0xcd},
+		},
+		{
+			IntValue: &n,
+		},
+	}
+	req := &pb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*pb.ResourceMetrics{
+			generateOTLPSamples([]*pb.Metric{m}),
+		},
+	}
+
+	exemplarsExpected := map[string][]prompbmarshal.Exemplar{
+		"0.5": {
+			{
+				Labels: []prompbmarshal.Label{
+					{
+						Name:  "foo",
+						Value: "bar",
+					},
+					{
+						Name:  "trace_id",
+						Value: "01ab",
+					},
+					{
+						Name:  "span_id",
+						Value: "cd",
+					},
+				},
+				Value:     0.3,
+				Timestamp: 29000,
+			},
+		},
+		"+Inf": {
+			{
+				Labels:    []prompbmarshal.Label{},
+				Value:     42,
+				Timestamp: 30000,
+			},
+		},
+	}
+	checkSeries := func(tss []prompbmarshal.TimeSeries) error {
+		for _, ts := range tss {
+			le := ""
+			for _, label := range ts.Labels {
+				if label.Name == "le" {
+					le = label.Value
+				}
+			}
+			exemplars := exemplarsExpected[le]
+			if len(ts.Exemplars) != len(exemplars) {
+				return fmt.Errorf("unexpected number of exemplars for le=%q; got %d; want %d", le, len(ts.Exemplars), len(exemplars))
+			}
+			if len(exemplars) > 0 && !reflect.DeepEqual(ts.Exemplars, exemplars) {
+				return fmt.Errorf("unexpected exemplars for le=%q\ngot\n%v\nwant\n%v", le, ts.Exemplars, exemplars)
+			}
+		}
+		return nil
+	}
+	pbData := req.MarshalProtobuf(nil)
+	if err := checkParseStream(pbData, checkSeries); err != nil {
+		t.Fatalf("cannot parse protobuf: %s", err)
+	}
 }
 
 func checkParseStream(data []byte, checkSeries func(tss []prompbmarshal.TimeSeries) error) error {
@@ -273,6 +362,32 @@ func generateExpHistogram(name, unit string) *pb.Metric {
 	}
 }
 
+func generateExpHistogramWithNegativeBuckets(name string) *pb.Metric {
+	return &pb.Metric{
+		Name: name,
+		ExponentialHistogram: &pb.ExponentialHistogram{
+			AggregationTemporality: pb.AggregationTemporalityCumulative,
+			DataPoints: []*pb.ExponentialHistogramDataPoint{
+				{
+					Attributes:    attributesFromKV("label1", "value1"),
+					TimeUnixNano:  uint64(15 * time.Second),
+					Count:         6,
+					ZeroCount:     1,
+					ZeroThreshold: 0.5,
+					Negative: &pb.Buckets{
+						Offset:       1,
+						BucketCounts: []uint64{2},
+					},
+					Positive: &pb.Buckets{
+						Offset:       0,
+						BucketCounts: []uint64{3},
+					},
+				},
+			},
+		},
+	}
+}
+
 func generateGauge(name, unit string) *pb.Metric {
 	n := int64(15)
 	points := []*pb.NumberDataPoint{
@@ -16,6 +16,9 @@ import (
 type Rows struct {
 	Rows []Row
 
+	// Metadata contains metric metadata parsed from `# HELP`, `# TYPE` and `# UNIT` lines.
+	Metadata []Metadata
+
 	tagsPool []Tag
 }
 
@@ -28,6 +31,11 @@ func (rs *Rows) Reset() {
 	}
 	rs.Rows = rs.Rows[:0]
 
+	for i := range rs.Metadata {
+		rs.Metadata[i].reset()
+	}
+	rs.Metadata = rs.Metadata[:0]
+
 	for i := range rs.tagsPool {
 		rs.tagsPool[i].reset()
 	}
@@ -54,7 +62,7 @@ func stdErrLogger(s string) {
 // s shouldn't be modified while rs is in use.
 func (rs *Rows) UnmarshalWithErrLogger(s string, errLogger func(s string)) {
 	noEscapes := strings.IndexByte(s, '\\') < 0
-	rs.Rows, rs.tagsPool = unmarshalRows(rs.Rows[:0], s, rs.tagsPool[:0], noEscapes, errLogger)
+	rs.Rows, rs.tagsPool, rs.Metadata = unmarshalRows(rs.Rows[:0], s, rs.tagsPool[:0], rs.Metadata[:0], noEscapes, errLogger)
 }
 
 // Row is a single Prometheus row.
@@ -63,6 +71,23 @@ type Row struct {
 	Tags      []Tag
 	Value     float64
 	Timestamp int64
+
+	// HasExemplar is set to true if the row contains an exemplar.
+	HasExemplar bool
+
+	// Exemplar is the exemplar attached to the row. It is valid only if HasExemplar is set.
+	Exemplar Exemplar
+}
+
+// Exemplar is an OpenMetrics exemplar.
+//
+// See https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md#exemplars
+type Exemplar struct {
+	Tags  []Tag
+	Value float64
+
+	// Timestamp is exemplar timestamp in milliseconds. It is set to 0 if the exemplar has no timestamp.
+	Timestamp int64
 }
 
 func (r *Row) reset() {
@@ -70,14 +95,8 @@ func (r *Row) reset() {
 	r.Tags = nil
 	r.Value = 0
 	r.Timestamp = 0
-}
-
-func skipTrailingComment(s string) string {
-	n := strings.IndexByte(s, '#')
-	if n < 0 {
-		return s
-	}
-	return s[:n]
+	r.HasExemplar = false
+	r.Exemplar = Exemplar{}
 }
 
 func skipLeadingWhitespace(s string) string {
@@ -114,6 +133,10 @@ func (r *Row) unmarshal(s string, tagsPool []Tag, noEscapes bool) ([]Tag, error)
 	r.reset()
 	s = skipLeadingWhitespace(s)
 	n := strings.IndexByte(s, '{')
+	if n >= 0 && nextWhitespace(skipTrailingWhitespace(s[:n])) >= 0 {
+		// The '{' is located after the value, e.g. it belongs to the exemplar.
+		n = -1
+	}
 	if n >= 0 {
 		// Tags found. Parse them.
 		r.Metric = skipTrailingWhitespace(s[:n])
@@ -143,36 +166,86 @@ func (r *Row) unmarshal(s string, tagsPool []Tag, noEscapes bool) ([]Tag, error)
 		return tagsPool, fmt.Errorf("metric cannot be empty")
 	}
 	s = skipLeadingWhitespace(s)
-	s = skipTrailingComment(s)
+	if n := strings.IndexByte(s, '#'); n >= 0 {
+		comment := skipLeadingWhitespace(s[n+1:])
+		s = s[:n]
+		if len(comment) > 0 && comment[0] == '{' {
+			tagsStart := len(tagsPool)
+			var err error
+			tagsPool, err = r.Exemplar.unmarshal(comment[1:], tagsPool, noEscapes)
+			if err == nil {
+				r.HasExemplar = true
+			} else {
+				// Drop the invalid exemplar and keep the sample, since the trailing comment
+				// may be emitted by a target, which doesn't follow OpenMetrics format.
+				tagsPool = tagsPool[:tagsStart]
+				r.Exemplar = Exemplar{}
+				invalidExemplars.Inc()
+			}
+		}
+	}
 	if len(s) == 0 {
 		return tagsPool, fmt.Errorf("value cannot be empty")
 	}
-	n = nextWhitespace(s)
+	v, ts, err := unmarshalValueAndTimestamp(s)
+	if err != nil {
+		return tagsPool, err
+	}
+	r.Value = v
+	r.Timestamp = ts
+	return tagsPool, nil
+}
+
+// unmarshal unmarshals exemplar from s, which must contain `labels} value [timestamp]` part of the exemplar.
+func (e *Exemplar) unmarshal(s string, tagsPool []Tag, noEscapes bool) ([]Tag, error) {
+	tagsStart := len(tagsPool)
+	s, tagsPool, err := unmarshalTags(tagsPool, s, noEscapes)
+	if err != nil {
+		return tagsPool, fmt.Errorf("cannot unmarshal tags: %w", err)
+	}
+	tags := tagsPool[tagsStart:]
+	e.Tags = tags[:len(tags):len(tags)]
+	s = skipLeadingWhitespace(s)
+	if len(s) == 0 {
+		return tagsPool, fmt.Errorf("value cannot be empty")
+	}
+	v, ts, err := unmarshalValueAndTimestamp(s)
+	if err != nil {
+		return tagsPool, err
+	}
+	e.Value = v
+	e.Timestamp = ts
+	return tagsPool, nil
+}
+
+// unmarshalValueAndTimestamp unmarshals `value [timestamp]` from s.
+//
+// The returned timestamp is in milliseconds. It is set to 0 if s has no timestamp.
+func unmarshalValueAndTimestamp(s string) (float64, int64, error) {
+	n := nextWhitespace(s)
 	if n < 0 {
 		// There is no timestamp.
 		v, err := fastfloat.Parse(s)
 		if err != nil {
-			return tagsPool, fmt.Errorf("cannot parse value %q: %w", s, err)
+			return 0, 0, fmt.Errorf("cannot parse value %q: %w", s, err)
 		}
-		r.Value = v
-		return tagsPool, nil
+		return v, 0, nil
 	}
 	// There is a timestamp.
 	v, err := fastfloat.Parse(s[:n])
 	if err != nil {
-		return tagsPool, fmt.Errorf("cannot parse value %q: %w", s[:n], err)
+		return 0, 0, fmt.Errorf("cannot parse value %q: %w", s[:n], err)
 	}
-	r.Value = v
 	s = skipLeadingWhitespace(s[n+1:])
 	if len(s) == 0 {
 		// There is no timestamp - just a whitespace after the value.
-		return tagsPool, nil
+		return v, 0, nil
 	}
 	// There are some whitespaces after timestamp
 	s = skipTrailingWhitespace(s)
 	ts, err := fastfloat.Parse(s)
 	if err != nil {
-		return tagsPool, fmt.Errorf("cannot parse timestamp %q: %w", s, err)
+		return 0, 0, fmt.Errorf("cannot parse timestamp %q: %w", s, err)
 	}
 	if ts >= -1<<31 && ts < 1<<31 {
 		// This looks like OpenMetrics timestamp in Unix seconds.
@@ -181,26 +254,138 @@ func (r *Row) unmarshal(s string, tagsPool []Tag, noEscapes bool) ([]Tag, error)
 		// See https://github.com/OpenObservability/OpenMetrics/blob/master/specification/OpenMetrics.md#timestamps
 		ts *= 1000
 	}
-	r.Timestamp = int64(ts)
-	return tagsPool, nil
+	return v, int64(ts), nil
 }
 
 var rowsReadScrape = metrics.NewCounter(`vm_protoparser_rows_read_total{type="promscrape"}`)
 
-func unmarshalRows(dst []Row, s string, tagsPool []Tag, noEscapes bool, errLogger func(s string)) ([]Row, []Tag) {
+func unmarshalRows(dst []Row, s string, tagsPool []Tag, mms []Metadata, noEscapes bool, errLogger func(s string)) ([]Row, []Tag, []Metadata) {
 	dstLen := len(dst)
 	for len(s) > 0 {
+		line := s
 		n := strings.IndexByte(s, '\n')
 		if n < 0 {
 			// The last line.
-			dst, tagsPool = unmarshalRow(dst, s, tagsPool, noEscapes, errLogger)
-			break
+			s = ""
+		} else {
+			line = s[:n]
+			s = s[n+1:]
 		}
-		dst, tagsPool = unmarshalRow(dst, s[:n], tagsPool, noEscapes, errLogger)
-		s = s[n+1:]
+		if strings.HasPrefix(line, "# ") {
+			mms = unmarshalMetadata(mms, line[len("# "):])
+			continue
+		}
+		dst, tagsPool = unmarshalRow(dst, line, tagsPool, noEscapes, errLogger)
 	}
 	rowsReadScrape.Add(len(dst) - dstLen)
-	return dst, tagsPool
+	return dst, tagsPool, mms
+}
+
+// Metadata contains metadata for a metric family.
+//
+// It is parsed from `# HELP`, `# TYPE` and `# UNIT` lines.
+// See https://github.com/prometheus/docs/blob/master/content/docs/instrumenting/exposition_formats.md#comments-help-text-and-type-information
+type Metadata struct {
+	// Metric is the name of the metric family.
+	Metric string
+
+	// Type is the metric type such as counter, gauge, histogram or summary.
+	Type string
+
+	// Help is the metric description.
+	Help string
+
+	// Unit is the metric unit. It is set only in OpenMetrics format.
+	Unit string
+}
+
+func (mm *Metadata) reset() {
+	mm.Metric = ""
+	mm.Type = ""
+	mm.Help = ""
+	mm.Unit = ""
+}
+
+// unmarshalMetadata parses metadata from comment line s without the leading `# `
+// and merges it into the last item at mms if it refers to the same metric family.
+func unmarshalMetadata(mms []Metadata, s string) []Metadata {
+	if len(s) > 0 && s[len(s)-1] == '\r' {
+		s = s[:len(s)-1]
+	}
+	n := strings.IndexByte(s, ' ')
+	if n < 0 {
+		return mms
+	}
+	kind := s[:n]
+	if kind != "HELP" && kind != "TYPE" && kind != "UNIT" {
+		// Skip ordinary comment
+		return mms
+	}
+	s = skipLeadingWhitespace(s[n+1:])
+	metric := s
+	value := ""
+	n = nextWhitespace(s)
+	if n >= 0 {
+		metric = s[:n]
+		value = skipLeadingWhitespace(s[n+1:])
+	}
+	if len(metric) == 0 {
+		return mms
+	}
+	var mm *Metadata
+	if len(mms) > 0 && mms[len(mms)-1].Metric == metric {
+		mm = &mms[len(mms)-1]
+	} else {
+		if cap(mms) > len(mms) {
+			mms = mms[:len(mms)+1]
+		} else {
+			mms = append(mms, Metadata{})
+		}
+		mm = &mms[len(mms)-1]
+		mm.reset()
+		mm.Metric = metric
+	}
+	switch kind {
+	case "HELP":
+		mm.Help = unescapeHelp(value)
+	case "TYPE":
+		mm.Type = skipTrailingWhitespace(value)
+	case "UNIT":
+		mm.Unit = skipTrailingWhitespace(value)
+	}
+	return mms
+}
+
+func unescapeHelp(s string) string {
+	n := strings.IndexByte(s, '\\')
+	if n < 0 {
+		// Fast path - nothing to unescape
+		return s
+	}
+	// HELP text may contain any sequence of UTF-8 characters (after the metric name), but the backslash
+	// and the line feed characters have to be escaped as \\ and \n, respectively.
+	// See https://github.com/prometheus/docs/blob/master/content/docs/instrumenting/exposition_formats.md#comments-help-text-and-type-information
+	b := make([]byte, 0, len(s))
+	for n >= 0 {
+		b = append(b, s[:n]...)
+		s = s[n+1:]
+		if len(s) == 0 {
+			b = append(b, '\\')
+			break
+		}
+		switch s[0] {
+		case '\\':
+			b = append(b, '\\')
+		case 'n':
+			b = append(b, '\n')
+		default:
+			b = append(b, '\\', s[0])
+		}
+		s = s[1:]
+		n = strings.IndexByte(s, '\\')
+	}
+	b = append(b, s...)
+	return string(b)
 }
 
 func unmarshalRow(dst []Row, s string, tagsPool []Tag, noEscapes bool, errLogger func(s string)) ([]Row, []Tag) {
@@ -237,6 +422,8 @@ func unmarshalRow(dst []Row, s string, tagsPool []Tag, noEscapes bool, errLogger
 
 var invalidLines = metrics.NewCounter(`vm_rows_invalid_total{type="prometheus"}`)
 
+var invalidExemplars = metrics.NewCounter(`vm_exemplars_invalid_total{type="prometheus"}`)
+
 func unmarshalTags(dst []Tag, s string, noEscapes bool) (string, []Tag, error) {
 	for {
 		s = skipLeadingWhitespace(s)
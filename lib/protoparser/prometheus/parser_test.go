@@ -380,7 +380,18 @@ cassandra_token_ownership_ratio 78.9`, &Rows{
 						Value: "#b",
 					},
 				},
-				Value: 17,
+				Value:       17,
+				HasExemplar: true,
+				Exemplar: Exemplar{
+					Tags: []Tag{
+						{
+							Key:   "trace_id",
+							Value: "oHg5SJ#YRHA0",
+						},
+					},
+					Value:     9.8,
+					Timestamp: 1520879607789,
+				},
 			},
 			{
 				Metric:    "abc",
@@ -394,6 +405,58 @@ cassandra_token_ownership_ratio 78.9`, &Rows{
 		},
 	})
 
+	// Exemplar without timestamp
+	f(`foo_total 5 # {span_id="a",trace_id="b"} 1`, &Rows{
+		Rows: []Row{{
+			Metric:      "foo_total",
+			Value:       5,
+			HasExemplar: true,
+			Exemplar: Exemplar{
+				Tags: []Tag{
+					{
+						Key:   "span_id",
+						Value: "a",
+					},
+					{
+						Key:   "trace_id",
+						Value: "b",
+					},
+				},
+				Value: 1,
+			},
+		}},
+	})
+
+	// Invalid exemplars must be dropped, while the samples must be preserved
+	f(`foo 123 # {trace_id="a"
+	   bar{x="y"} 2 # {trace_id="a"}
+	   baz 3 # {trace_id="a"} bar
+	   qux 4 456 # {trace_id="a"} 1 bar`, &Rows{
+		Rows: []Row{
+			{
+				Metric: "foo",
+				Value:  123,
+			},
+			{
+				Metric: "bar",
+				Tags: []Tag{{
+					Key:   "x",
+					Value: "y",
+				}},
+				Value: 2,
+			},
+			{
+				Metric: "baz",
+				Value:  3,
+			},
+			{
+				Metric:    "qux",
+				Value:     4,
+				Timestamp: 456000,
+			},
+		},
+	})
+
 	// "Infinity" word - this has been added in OpenMetrics.
 	// See https://github.com/OpenObservability/OpenMetrics/blob/master/OpenMetrics.md
 	// Checks for https://github.com/VictoriaMetrics/VictoriaMetrics/issues/924
@@ -573,3 +636,70 @@ cassandra_token_ownership_ratio 78.9`, &Rows{
 		},
 	})
 }
+
+func TestRowsUnmarshalMetadata(t *testing.T) {
+	f := func(s string, mmsExpected []Metadata) {
+		t.Helper()
+		var rows Rows
+		rows.Unmarshal(s)
+		if !reflect.DeepEqual(rows.Metadata, mmsExpected) {
+			t.Fatalf("unexpected metadata;\ngot\n%+v;\nwant\n%+v", rows.Metadata, mmsExpected)
+		}
+
+		// Try unmarshaling again
+		rows.Unmarshal(s)
+		if !reflect.DeepEqual(rows.Metadata, mmsExpected) {
+			t.Fatalf("unexpected metadata;\ngot\n%+v;\nwant\n%+v", rows.Metadata, mmsExpected)
+		}
+
+		rows.Reset()
+		if len(rows.Metadata) != 0 {
+			t.Fatalf("non-empty metadata after reset: %+v", rows.Metadata)
+		}
+	}
+
+	// No metadata
+	f("", nil)
+	f("foo 123\n# foobar\n#HELP foo bar", nil)
+	f("# HELP", nil)
+
+	// Help without type
+	f("# HELP foo some help\nfoo 1", []Metadata{{
+		Metric: "foo",
+		Help:   "some help",
+	}})
+
+	// Escaped help
+	f(`# HELP foo some \\ help\nwith newline`, []Metadata{{
+		Metric: "foo",
+		Help:   "some \\ help\nwith newline",
+	}})
+
+	// Full metadata
+	f(`# HELP process_cpu_seconds_total Total user and system CPU time spent in seconds.
+# TYPE process_cpu_seconds_total counter
+# UNIT process_cpu_seconds_total seconds
+process_cpu_seconds_total 1.23
+# TYPE go_goroutines gauge
+go_goroutines 42
+# TYPE http_request_duration_seconds histogram
+# HELP http_request_duration_seconds Request duration.`+"\r"+`
+http_request_duration_seconds_bucket{le="+Inf"} 3
+`, []Metadata{
+		{
+			Metric: "process_cpu_seconds_total",
+			Type:   "counter",
+			Help:   "Total user and system CPU time spent in seconds.",
+			Unit:   "seconds",
+		},
+		{
+			Metric: "go_goroutines",
+			Type:   "gauge",
+		},
+		{
+			Metric: "http_request_duration_seconds",
+			Type:   "histogram",
+			Help:   "Request duration.",
+		},
+	})
+}
@@ -14,16 +14,16 @@ import (
 	"github.com/VictoriaMetrics/metrics"
 )
 
-// Parse parses lines with Prometheus exposition format from r and calls callback for the parsed rows.
+// Parse parses lines with Prometheus exposition format from r and calls callback for the parsed rows and metadata.
 //
 // The callback can be called concurrently multiple times for streamed data from r.
 //
-// callback shouldn't hold rows after returning.
+// callback shouldn't hold rows and mms after returning.
 //
 // limitConcurrency defines whether to control the number of concurrent calls to this function.
 // It is recommended setting limitConcurrency=true if the caller doesn't have concurrency limits set,
 // like /api/v1/write calls.
-func Parse(r io.Reader, defaultTimestamp int64, isGzipped, limitConcurrency bool, callback func(rows []prometheus.Row) error, errLogger func(string)) error {
+func Parse(r io.Reader, defaultTimestamp int64, isGzipped, limitConcurrency bool, callback func(rows []prometheus.Row, mms []prometheus.Metadata) error, errLogger func(string)) error {
 	if limitConcurrency {
 		wcr := writeconcurrencylimiter.GetReader(r)
 		defer writeconcurrencylimiter.PutReader(wcr)
@@ -136,7 +136,7 @@ var streamContextPool sync.Pool
 type unmarshalWork struct {
 	rows             prometheus.Rows
 	ctx              *streamContext
-	callback         func(rows []prometheus.Row) error
+	callback         func(rows []prometheus.Row, mms []prometheus.Metadata) error
 	errLogger        func(string)
 	defaultTimestamp int64
 	reqBuf           []byte
@@ -151,9 +151,9 @@ func (uw *unmarshalWork) reset() {
 	uw.reqBuf = uw.reqBuf[:0]
 }
 
-func (uw *unmarshalWork) runCallback(rows []prometheus.Row) {
+func (uw *unmarshalWork) runCallback(rows []prometheus.Row, mms []prometheus.Metadata) {
 	ctx := uw.ctx
-	if err := uw.callback(rows); err != nil {
+	if err := uw.callback(rows, mms); err != nil {
 		ctx.callbackErrLock.Lock()
 		if ctx.callbackErr == nil {
 			ctx.callbackErr = fmt.Errorf("error when processing imported data: %w", err)
@@ -185,7 +185,7 @@ func (uw *unmarshalWork) Unmarshal() {
 		}
 	}
 
-	uw.runCallback(rows)
+	uw.runCallback(rows, uw.rows.Metadata)
 	putUnmarshalWork(uw)
 }
 
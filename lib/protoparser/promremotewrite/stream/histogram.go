@@ -0,0 +1,170 @@
+package stream
+
+import (
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+)
+
+// histogramsCtx converts Prometheus native histograms to VictoriaMetrics histograms.
+//
+// Every native histogram sample is converted to `<name>_count`, `<name>_sum` and `<name>_bucket` samples.
+// Exponential buckets are converted to buckets with `vmrange` labels, while custom buckets are converted to buckets with `le` labels.
+// See https://valyala.medium.com/improving-histogram-usability-for-prometheus-and-grafana-bc7e5df0e350
+type histogramsCtx struct {
+	tss     []prompb.TimeSeries
+	labels  []prompb.Label
+	samples []prompb.Sample
+
+	// labelsEnds contains the end index at labels for every time series at tss.
+	labelsEnds []int
+
+	// buf holds label values for the converted time series.
+	buf []byte
+
+	// customCounts is a temporary buffer for bucket counts of histograms with custom buckets.
+	customCounts []float64
+}
+
+func (ctx *histogramsCtx) reset() {
+	clear(ctx.tss)
+	ctx.tss = ctx.tss[:0]
+	clear(ctx.labels)
+	ctx.labels = ctx.labels[:0]
+	ctx.samples = ctx.samples[:0]
+	ctx.labelsEnds = ctx.labelsEnds[:0]
+	ctx.buf = ctx.buf[:0]
+	ctx.customCounts = ctx.customCounts[:0]
+}
+
+// convertHistograms converts native histograms from tss to time series and returns them.
+//
+// The returned time series are valid until ctx.reset() call.
+func (ctx *histogramsCtx) convertHistograms(tss []prompb.TimeSeries) []prompb.TimeSeries {
+	for i := range tss {
+		ts := &tss[i]
+		if len(ts.Histograms) == 0 {
+			continue
+		}
+		metricName := getMetricName(ts.Labels)
+		for j := range ts.Histograms {
+			ctx.convertHistogram(ts.Labels, metricName, &ts.Histograms[j])
+		}
+	}
+
+	// Initialize Labels and Samples for the converted time series only after all the conversions,
+	// since ctx.labels and ctx.samples may be re-allocated during the conversion.
+	labelsStart := 0
+	for i := range ctx.tss {
+		labelsEnd := ctx.labelsEnds[i]
+		ctx.tss[i].Labels = ctx.labels[labelsStart:labelsEnd]
+		ctx.tss[i].Samples = ctx.samples[i : i+1]
+		labelsStart = labelsEnd
+	}
+	return ctx.tss
+}
+
+func (ctx *histogramsCtx) convertHistogram(labels []prompb.Label, metricName string, h *prompb.Histogram) {
+	timestamp := h.Timestamp
+	if h.IsStale() {
+		ctx.appendSample(labels, metricName, "_count", "", "", timestamp, decimal.StaleNaN)
+		ctx.appendSample(labels, metricName, "_sum", "", "", timestamp, decimal.StaleNaN)
+		return
+	}
+	ctx.appendSample(labels, metricName, "_count", "", "", timestamp, h.Count)
+	ctx.appendSample(labels, metricName, "_sum", "", "", timestamp, h.Sum)
+
+	if h.Schema == prompb.CustomBucketsSchema {
+		ctx.convertCustomBuckets(labels, metricName, h)
+		return
+	}
+	if h.ZeroCount > 0 {
+		bufLen := len(ctx.buf)
+		ctx.buf = common.AppendVMRange(ctx.buf, -h.ZeroThreshold, h.ZeroThreshold)
+		vmrange := bytesutil.ToUnsafeString(ctx.buf[bufLen:])
+		ctx.appendSample(labels, metricName, "_bucket", "vmrange", vmrange, timestamp, h.ZeroCount)
+	}
+	h.ForEachBucket(func(index int32, count float64, isNegative bool) {
+		lower, upper := common.ExponentialBucketBounds(h.Schema, index)
+		if isNegative {
+			lower, upper = -upper, -lower
+		}
+		bufLen := len(ctx.buf)
+		ctx.buf = common.AppendVMRange(ctx.buf, lower, upper)
+		vmrange := bytesutil.ToUnsafeString(ctx.buf[bufLen:])
+		ctx.appendSample(labels, metricName, "_bucket", "vmrange", vmrange, timestamp, count)
+	})
+}
+
+func (ctx *histogramsCtx) convertCustomBuckets(labels []prompb.Label, metricName string, h *prompb.Histogram) {
+	// The last bucket contains observations above the last custom value.
+	counts := ctx.customCounts[:0]
+	for range len(h.CustomValues) + 1 {
+		counts = append(counts, 0)
+	}
+	h.ForEachBucket(func(index int32, count float64, isNegative bool) {
+		if isNegative || index < 0 || int(index) >= len(counts) {
+			// Histograms with custom buckets cannot contain such buckets.
+			return
+		}
+		counts[index] += count
+	})
+	ctx.customCounts = counts
+
+	timestamp := h.Timestamp
+	cumulative := float64(0)
+	for i, upper := range h.CustomValues {
+		cumulative += counts[i]
+		bufLen := len(ctx.buf)
+		ctx.buf = strconv.AppendFloat(ctx.buf, upper, 'f', -1, 64)
+		le := bytesutil.ToUnsafeString(ctx.buf[bufLen:])
+		ctx.appendSample(labels, metricName, "_bucket", "le", le, timestamp, cumulative)
+	}
+	cumulative += counts[len(counts)-1]
+	ctx.appendSample(labels, metricName, "_bucket", "le", "+Inf", timestamp, cumulative)
+}
+
+// appendSample appends a sample with the given value and timestamp for the time series with the given labels.
+//
+// The metric name for the time series is set to metricName+suffix. The (extraName=extraValue) label is added to labels if extraName isn't empty.
+func (ctx *histogramsCtx) appendSample(labels []prompb.Label, metricName, suffix, extraName, extraValue string, timestamp int64, value float64) {
+	bufLen := len(ctx.buf)
+	ctx.buf = append(ctx.buf, metricName...)
+	ctx.buf = append(ctx.buf, suffix...)
+	name := bytesutil.ToUnsafeString(ctx.buf[bufLen:])
+
+	ctx.labels = append(ctx.labels, prompb.Label{
+		Name:  "__name__",
+		Value: name,
+	})
+	for _, label := range labels {
+		if label.Name == "__name__" || label.Name == extraName {
+			continue
+		}
+		ctx.labels = append(ctx.labels, label)
+	}
+	if extraName != "" {
+		ctx.labels = append(ctx.labels, prompb.Label{
+			Name:  extraName,
+			Value: extraValue,
+		})
+	}
+	ctx.samples = append(ctx.samples, prompb.Sample{
+		Value:     value,
+		Timestamp: timestamp,
+	})
+	ctx.tss = append(ctx.tss, prompb.TimeSeries{})
+	ctx.labelsEnds = append(ctx.labelsEnds, len(ctx.labels))
+}
+
+func getMetricName(labels []prompb.Label) string {
+	for _, label := range labels {
+		if label.Name == "__name__" {
+			return label.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+)
+
+func TestHistogramsCtxConvertHistograms(t *testing.T) {
+	f := func(h *prompb.Histogram, resultExpected []string) {
+		t.Helper()
+
+		tss := []prompb.TimeSeries{{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "foo"},
+				{Name: "job", Value: "bar"},
+			},
+			Histograms: []prompb.Histogram{*h},
+		}}
+		var ctx histogramsCtx
+		var result []string
+		for _, ts := range ctx.convertHistograms(tss) {
+			var labels []string
+			for _, label := range ts.Labels {
+				labels = append(labels, fmt.Sprintf("%s=%q", label.Name, label.Value))
+			}
+			for _, sample := range ts.Samples {
+				v := fmt.Sprintf("%g", sample.Value)
+				if decimal.IsStaleNaN(sample.Value) {
+					v = "stale"
+				}
+				result = append(result, fmt.Sprintf("{%s} %s %d", strings.Join(labels, ","), v, sample.Timestamp))
+			}
+		}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", strings.Join(result, "\n"), strings.Join(resultExpected, "\n"))
+		}
+	}
+
+	// exponential buckets
+	f(&prompb.Histogram{
+		Count:         6,
+		Sum:           3.5,
+		Schema:        0,
+		ZeroThreshold: 0.5,
+		ZeroCount:     1,
+		NegativeSpans: []prompb.BucketSpan{{Offset: 2, Length: 1}},
+		NegativeDeltas: []int64{
+			2,
+		},
+		PositiveSpans: []prompb.BucketSpan{{Offset: 1, Length: 2}},
+		PositiveDeltas: []int64{
+			1, 1,
+		},
+		Timestamp: 1000,
+	}, []string{
+		`{__name__="foo_count",job="bar"} 6 1000`,
+		`{__name__="foo_sum",job="bar"} 3.5 1000`,
+		`{__name__="foo_bucket",job="bar",vmrange="-5e-01...5e-01"} 1 1000`,
+		`{__name__="foo_bucket",job="bar",vmrange="-4e+00...-2e+00"} 2 1000`,
+		`{__name__="foo_bucket",job="bar",vmrange="1e+00...2e+00"} 1 1000`,
+		`{__name__="foo_bucket",job="bar",vmrange="2e+00...4e+00"} 2 1000`,
+	})
+
+	// custom buckets
+	f(&prompb.Histogram{
+		Count:          4,
+		Sum:            10,
+		Schema:         prompb.CustomBucketsSchema,
+		PositiveSpans:  []prompb.BucketSpan{{Offset: 0, Length: 1}, {Offset: 1, Length: 1}},
+		PositiveCounts: []float64{1, 3},
+		CustomValues:   []float64{0.5, 1, 2.5},
+		Timestamp:      2000,
+	}, []string{
+		`{__name__="foo_count",job="bar"} 4 2000`,
+		`{__name__="foo_sum",job="bar"} 10 2000`,
+		`{__name__="foo_bucket",job="bar",le="0.5"} 1 2000`,
+		`{__name__="foo_bucket",job="bar",le="1"} 1 2000`,
+		`{__name__="foo_bucket",job="bar",le="2.5"} 4 2000`,
+		`{__name__="foo_bucket",job="bar",le="+Inf"} 4 2000`,
+	})
+
+	// staleness marker
+	f(&prompb.Histogram{
+		Sum:       decimal.StaleNaN,
+		Timestamp: 3000,
+	}, []string{
+		`{__name__="foo_count",job="bar"} stale 3000`,
+		`{__name__="foo_sum",job="bar"} stale 3000`,
+	})
+}
@@ -4,6 +4,9 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"mime"
+	"net/http"
+	"strconv"
 	"sync"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
@@ -18,10 +21,65 @@ import (
 
 var maxInsertRequestSize = flagutil.NewBytes("maxInsertRequestSize", 32*1024*1024, "The maximum size in bytes of a single Prometheus remote_write API request")
 
-// Parse parses Prometheus remote_write message from reader and calls callback for the parsed timeseries.
+// Content types for Prometheus remote write protobuf messages.
 //
-// callback shouldn't hold tss after returning.
-func Parse(r io.Reader, isVMRemoteWrite bool, callback func(tss []prompb.TimeSeries) error) error {
+// See https://prometheus.io/docs/specs/prw/remote_write_spec_2_0/#protocol
+const (
+	ContentTypeV1 = "application/x-protobuf;proto=prometheus.WriteRequest"
+	ContentTypeV2 = "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+)
+
+// IsRemoteWriteV2 returns true if the request with the given contentType contains Prometheus remote write 2.0 message.
+//
+// Prometheus remote write 1.0 is assumed if contentType doesn't contain `proto` parameter.
+// An error is returned if contentType refers to unsupported protobuf message.
+// Such requests must be rejected with `415 Unsupported Media Type` status code.
+func IsRemoteWriteV2(contentType string) (bool, error) {
+	if contentType == "" {
+		return false, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "application/x-protobuf" {
+		// Old clients may send arbitrary Content-Type headers for remote write 1.0 requests.
+		return false, nil
+	}
+	switch proto := params["proto"]; proto {
+	case "", "prometheus.WriteRequest":
+		return false, nil
+	case "io.prometheus.write.v2.Request":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported proto=%q at Content-Type=%q; supported values: prometheus.WriteRequest, io.prometheus.write.v2.Request", proto, contentType)
+	}
+}
+
+// WriteStats contains the number of samples, native histograms and exemplars written from a single remote write request.
+type WriteStats struct {
+	Samples    int
+	Histograms int
+	Exemplars  int
+}
+
+// SetResponseHeaders sets X-Prometheus-Remote-Write-*-Written response headers at h according to ws.
+//
+// See https://prometheus.io/docs/specs/prw/remote_write_spec_2_0/#required-written-response-headers
+func (ws *WriteStats) SetResponseHeaders(h http.Header) {
+	h.Set("X-Prometheus-Remote-Write-Samples-Written", strconv.Itoa(ws.Samples))
+	h.Set("X-Prometheus-Remote-Write-Histograms-Written", strconv.Itoa(ws.Histograms))
+	h.Set("X-Prometheus-Remote-Write-Exemplars-Written", strconv.Itoa(ws.Exemplars))
+}
+
+// Parse parses Prometheus remote_write message from reader and calls callback for the parsed timeseries and metadata.
+//
+// The message must be encoded in Prometheus remote write 2.0 format if isRemoteWriteV2 is set.
+// See https://prometheus.io/docs/specs/prw/remote_write_spec_2_0/
+//
+// callback shouldn't hold tss and mms after returning.
+//
+// The number of written samples, histograms and exemplars is returned on success.
+func Parse(r io.Reader, isVMRemoteWrite, isRemoteWriteV2 bool, callback func(tss []prompb.TimeSeries, mms []prompb.MetricMetadata) error) (WriteStats, error) {
+	var ws WriteStats
+
 	wcr := writeconcurrencylimiter.GetReader(r)
 	defer writeconcurrencylimiter.PutReader(wcr)
 	r = wcr
@@ -29,7 +87,7 @@ func Parse(r io.Reader, isVMRemoteWrite bool, callback func(tss []prompb.TimeSer
 	ctx := getPushCtx(r)
 	defer putPushCtx(ctx)
 	if err := ctx.Read(); err != nil {
-		return err
+		return ws, err
 	}
 
 	// Synchronously process the request in order to properly return errors to Parse caller,
@@ -47,7 +105,7 @@ func Parse(r io.Reader, isVMRemoteWrite bool, callback func(tss []prompb.TimeSer
 			zstdErr := err
 			bb.B, err = snappy.Decode(bb.B[:cap(bb.B)], ctx.reqBuf.B)
 			if err != nil {
-				return fmt.Errorf("cannot decompress zstd-encoded request with length %d: %w", len(ctx.reqBuf.B), zstdErr)
+				return ws, fmt.Errorf("cannot decompress zstd-encoded request with length %d: %w", len(ctx.reqBuf.B), zstdErr)
 			}
 		}
 	} else {
@@ -59,31 +117,54 @@ func Parse(r io.Reader, isVMRemoteWrite bool, callback func(tss []prompb.TimeSer
 			snappyErr := err
 			bb.B, err = zstd.Decompress(bb.B[:0], ctx.reqBuf.B)
 			if err != nil {
-				return fmt.Errorf("cannot decompress snappy-encoded request with length %d: %w", len(ctx.reqBuf.B), snappyErr)
+				return ws, fmt.Errorf("cannot decompress snappy-encoded request with length %d: %w", len(ctx.reqBuf.B), snappyErr)
 			}
 		}
 	}
 	if int64(len(bb.B)) > maxInsertRequestSize.N {
-		return fmt.Errorf("too big unpacked request; mustn't exceed `-maxInsertRequestSize=%d` bytes; got %d bytes", maxInsertRequestSize.N, len(bb.B))
+		return ws, fmt.Errorf("too big unpacked request; mustn't exceed `-maxInsertRequestSize=%d` bytes; got %d bytes", maxInsertRequestSize.N, len(bb.B))
 	}
 	wr := getWriteRequest()
 	defer putWriteRequest(wr)
-	if err := wr.UnmarshalProtobuf(bb.B); err != nil {
-		unmarshalErrors.Inc()
-		return fmt.Errorf("cannot unmarshal prompb.WriteRequest with size %d bytes: %w", len(bb.B), err)
+	if isRemoteWriteV2 {
+		if err := wr.UnmarshalProtobufV2(bb.B); err != nil {
+			unmarshalErrors.Inc()
+			return ws, fmt.Errorf("cannot unmarshal io.prometheus.write.v2.Request with size %d bytes: %w", len(bb.B), err)
+		}
+	} else {
+		if err := wr.UnmarshalProtobuf(bb.B); err != nil {
+			unmarshalErrors.Inc()
+			return ws, fmt.Errorf("cannot unmarshal prompb.WriteRequest with size %d bytes: %w", len(bb.B), err)
+		}
 	}
 
-	rows := 0
+	// Collect write stats before the conversion of native histograms, since the conversion adds samples.
 	tss := wr.Timeseries
+	for i := range tss {
+		ts := &tss[i]
+		ws.Samples += len(ts.Samples)
+		ws.Histograms += len(ts.Histograms)
+		ws.Exemplars += len(ts.Exemplars)
+	}
+
+	// Convert native histograms to VictoriaMetrics histograms, since they cannot be stored as is.
+	if htss := ctx.hctx.convertHistograms(tss); len(htss) > 0 {
+		tss = append(tss, htss...)
+		wr.Timeseries = tss
+	}
+
+	rows := 0
 	for i := range tss {
 		rows += len(tss[i].Samples)
 	}
 	rowsRead.Add(rows)
+	histogramsRead.Add(ws.Histograms)
+	metadataRead.Add(len(wr.Metadata))
 
-	if err := callback(tss); err != nil {
-		return fmt.Errorf("error when processing imported data: %w", err)
+	if err := callback(tss, wr.Metadata); err != nil {
+		return ws, fmt.Errorf("error when processing imported data: %w", err)
 	}
-	return nil
+	return ws, nil
 }
 
 var bodyBufferPool bytesutil.ByteBufferPool
@@ -91,11 +172,13 @@ var bodyBufferPool bytesutil.ByteBufferPool
 type pushCtx struct {
 	br     *bufio.Reader
 	reqBuf bytesutil.ByteBuffer
+	hctx   histogramsCtx
 }
 
 func (ctx *pushCtx) reset() {
 	ctx.br.Reset(nil)
 	ctx.reqBuf.Reset()
+	ctx.hctx.reset()
 }
 
 func (ctx *pushCtx) Read() error {
@@ -118,6 +201,8 @@ var (
 	readCalls       = metrics.NewCounter(`vm_protoparser_read_calls_total{type="promremotewrite"}`)
 	readErrors      = metrics.NewCounter(`vm_protoparser_read_errors_total{type="promremotewrite"}`)
 	rowsRead        = metrics.NewCounter(`vm_protoparser_rows_read_total{type="promremotewrite"}`)
+	metadataRead    = metrics.NewCounter(`vm_protoparser_metadata_read_total{type="promremotewrite"}`)
+	histogramsRead  = metrics.NewCounter(`vm_protoparser_native_histograms_read_total{type="promremotewrite"}`)
 	unmarshalErrors = metrics.NewCounter(`vm_protoparser_unmarshal_errors_total{type="promremotewrite"}`)
 )
 
@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+)
+
+func TestIsRemoteWriteV2(t *testing.T) {
+	f := func(contentType string, resultExpected bool) {
+		t.Helper()
+
+		result, err := IsRemoteWriteV2(contentType)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result != resultExpected {
+			t.Fatalf("unexpected result for Content-Type=%q; got %v; want %v", contentType, result, resultExpected)
+		}
+	}
+
+	f("", false)
+	f("application/x-protobuf", false)
+	f(ContentTypeV1, false)
+	f("application/x-protobuf; proto=prometheus.WriteRequest", false)
+	f(ContentTypeV2, true)
+	f("application/x-protobuf; proto=io.prometheus.write.v2.Request", true)
+
+	// arbitrary Content-Type headers sent by old clients
+	f("text/plain", false)
+	f("foo bar", false)
+}
+
+func TestIsRemoteWriteV2Failure(t *testing.T) {
+	f := func(contentType string) {
+		t.Helper()
+
+		if _, err := IsRemoteWriteV2(contentType); err == nil {
+			t.Fatalf("expecting non-nil error for Content-Type=%q", contentType)
+		}
+	}
+
+	f("application/x-protobuf;proto=foo.Bar")
+	f("application/x-protobuf;proto=io.prometheus.write.v3.Request")
+}
+
+func TestParseRemoteWriteV2(t *testing.T) {
+	wrSrc := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "foo"},
+				},
+				Samples: []prompb.Sample{
+					{Value: 1, Timestamp: 1000},
+					{Value: 2, Timestamp: 2000},
+				},
+				Exemplars: []prompb.Exemplar{
+					{
+						Labels:    []prompb.Label{{Name: "trace_id", Value: "abc"}},
+						Value:     1,
+						Timestamp: 1000,
+					},
+				},
+			},
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "bar"},
+				},
+				Histograms: []prompb.Histogram{
+					{
+						Count:          1,
+						Sum:            2,
+						PositiveSpans:  []prompb.BucketSpan{{Offset: 1, Length: 1}},
+						PositiveDeltas: []int64{1},
+						Timestamp:      1000,
+					},
+				},
+			},
+		},
+		Metadata: []prompb.MetricMetadata{
+			{
+				Type:             prompb.MetricTypeGauge,
+				MetricFamilyName: "foo",
+				Help:             "foo help",
+			},
+		},
+	}
+	data := snappy.Encode(nil, wrSrc.MarshalProtobufV2(nil))
+
+	var result []string
+	var metadata []string
+	ws, err := Parse(bytes.NewReader(data), false, true, func(tss []prompb.TimeSeries, mms []prompb.MetricMetadata) error {
+		for _, ts := range tss {
+			var labels []string
+			for _, label := range ts.Labels {
+				labels = append(labels, fmt.Sprintf("%s=%q", label.Name, label.Value))
+			}
+			for _, sample := range ts.Samples {
+				result = append(result, fmt.Sprintf("{%s} %g %d", strings.Join(labels, ","), sample.Value, sample.Timestamp))
+			}
+		}
+		for _, mm := range mms {
+			metadata = append(metadata, fmt.Sprintf("%s %s %q", mm.MetricFamilyName, mm.Type, mm.Help))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resultExpected := []string{
+		`{__name__="foo"} 1 1000`,
+		`{__name__="foo"} 2 2000`,
+		`{__name__="bar_count"} 1 1000`,
+		`{__name__="bar_sum"} 2 1000`,
+		`{__name__="bar_bucket",vmrange="1e+00...2e+00"} 1 1000`,
+	}
+	if !reflect.DeepEqual(result, resultExpected) {
+		t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", strings.Join(result, "\n"), strings.Join(resultExpected, "\n"))
+	}
+	metadataExpected := []string{
+		`foo gauge "foo help"`,
+	}
+	if !reflect.DeepEqual(metadata, metadataExpected) {
+		t.Fatalf("unexpected metadata\ngot\n%s\nwant\n%s", strings.Join(metadata, "\n"), strings.Join(metadataExpected, "\n"))
+	}
+
+	wsExpected := WriteStats{
+		Samples:    2,
+		Histograms: 1,
+		Exemplars:  1,
+	}
+	if ws != wsExpected {
+		t.Fatalf("unexpected write stats; got %+v; want %+v", ws, wsExpected)
+	}
+
+	h := http.Header{}
+	ws.SetResponseHeaders(h)
+	for name, valueExpected := range map[string]string{
+		"X-Prometheus-Remote-Write-Samples-Written":    "2",
+		"X-Prometheus-Remote-Write-Histograms-Written": "1",
+		"X-Prometheus-Remote-Write-Exemplars-Written":  "1",
+	} {
+		if value := h.Get(name); value != valueExpected {
+			t.Fatalf("unexpected %s header value; got %q; want %q", name, value, valueExpected)
+		}
+	}
+}
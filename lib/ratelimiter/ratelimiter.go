@@ -76,3 +76,75 @@ func (rl *RateLimiter) Register(count int) {
 	}
 	rl.budget -= int64(count)
 }
+
+// TryRegister registers count resources if the per-second rate limit isn't exceeded yet.
+//
+// It returns zero if the resources have been registered. Otherwise it returns the duration
+// to wait until the rate limit allows registering new resources. The resources aren't registered in this case.
+//
+// Unlike Register, TryRegister never blocks.
+func (rl *RateLimiter) TryRegister(count int) time.Duration {
+	if rl == nil {
+		return 0
+	}
+
+	limit := rl.perSecondLimit
+	if limit <= 0 {
+		return 0
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if d := rl.refillBudgetLocked(limit); d > 0 {
+		rl.limitReached.Inc()
+		return d
+	}
+	rl.budget -= int64(count)
+	return 0
+}
+
+// RegisterNonBlocking registers count resources without blocking.
+//
+// The budget may become negative after the call. In this case subsequent TryRegister calls
+// fail until the budget is restored at the given per-second rate.
+func (rl *RateLimiter) RegisterNonBlocking(count int) {
+	if rl == nil {
+		return
+	}
+
+	limit := rl.perSecondLimit
+	if limit <= 0 {
+		return
+	}
+
+	rl.mu.Lock()
+	rl.refillBudgetLocked(limit)
+	rl.budget -= int64(count)
+	rl.mu.Unlock()
+}
+
+// refillBudgetLocked increases the budget by limit for every second passed since the deadline.
+//
+// It returns the duration until the budget becomes positive. Zero is returned if the budget is already positive.
+func (rl *RateLimiter) refillBudgetLocked(limit int64) time.Duration {
+	if rl.budget > 0 {
+		return 0
+	}
+	ct := time.Now()
+	if !ct.Before(rl.deadline) {
+		n := int64(ct.Sub(rl.deadline)/time.Second) + 1
+		if n > (limit-rl.budget)/limit {
+			// Do not accumulate the budget over the limit during idle periods.
+			rl.budget = limit
+		} else {
+			rl.budget += n * limit
+		}
+		rl.deadline = ct.Add(time.Second)
+		if rl.budget > 0 {
+			return 0
+		}
+	}
+	// The budget is restored by limit every second after the deadline.
+	return rl.deadline.Sub(ct) + time.Duration(-rl.budget/limit)*time.Second
+}
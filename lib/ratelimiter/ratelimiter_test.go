@@ -0,0 +1,50 @@
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+func TestRateLimiterTryRegister(t *testing.T) {
+	limitReached := metrics.NewCounter(`test_rate_limiter_try_register_limit_reached_total`)
+	rl := New(10, limitReached, nil)
+
+	// The whole per-second budget must be available at the start
+	for i := 0; i < 10; i++ {
+		if d := rl.TryRegister(1); d != 0 {
+			t.Fatalf("unexpected non-zero duration at iteration #%d: %s", i, d)
+		}
+	}
+
+	// The budget is exhausted
+	d := rl.TryRegister(1)
+	if d <= 0 || d > time.Second {
+		t.Fatalf("unexpected duration; got %s; want (0..1s]", d)
+	}
+	if n := limitReached.Get(); n != 1 {
+		t.Fatalf("unexpected limitReached; got %d; want 1", n)
+	}
+
+	// Register resources exceeding the budget for the next 3 seconds
+	rl.RegisterNonBlocking(30)
+	d = rl.TryRegister(1)
+	if d <= 3*time.Second || d > 4*time.Second {
+		t.Fatalf("unexpected duration; got %s; want (3s..4s]", d)
+	}
+
+	// Zero limit disables rate limiting
+	rl = New(0, limitReached, nil)
+	rl.RegisterNonBlocking(100)
+	if d := rl.TryRegister(100); d != 0 {
+		t.Fatalf("unexpected non-zero duration for disabled rate limiter: %s", d)
+	}
+
+	// nil rate limiter must be usable
+	rl = nil
+	rl.RegisterNonBlocking(100)
+	if d := rl.TryRegister(100); d != 0 {
+		t.Fatalf("unexpected non-zero duration for nil rate limiter: %s", d)
+	}
+}
@@ -16,6 +16,14 @@ type blockStreamMerger struct {
 	// Blocks with smaller timestamps are removed because of retention.
 	retentionDeadline int64
 
+	// s is used for obtaining per-series retention if retention filters are set.
+	s *Storage
+
+	// prevMetricID and prevRetentionDeadline cache the retention deadline for the last seen metricID,
+	// since blocks for the same time series are merged sequentially.
+	prevMetricID          uint64
+	prevRetentionDeadline int64
+
 	// Whether the call to NextBlock must be no-op.
 	nextBlockNoop bool
 
@@ -35,15 +43,21 @@ func (bsm *blockStreamMerger) reset() {
 	bsm.bsrHeap = bsm.bsrHeap[:0]
 
 	bsm.retentionDeadline = 0
+	bsm.s = nil
+	bsm.prevMetricID = 0
+	bsm.prevRetentionDeadline = 0
 	bsm.nextBlockNoop = false
 	bsm.err = nil
 	bsm.useSparseCache = false
 }
 
 // Init initializes bsm with the given bsrs.
-func (bsm *blockStreamMerger) Init(bsrs []*blockStreamReader, retentionDeadline int64, useSparseCache bool) {
+//
+// s is used for applying retention filters to the merged blocks. It may be nil.
+func (bsm *blockStreamMerger) Init(bsrs []*blockStreamReader, s *Storage, retentionDeadline int64, useSparseCache bool) {
 	bsm.reset()
 	bsm.retentionDeadline = retentionDeadline
+	bsm.s = s
 	for _, bsr := range bsrs {
 		if bsr.NextBlock() {
 			bsm.bsrHeap = append(bsm.bsrHeap, bsr)
@@ -66,8 +80,21 @@ func (bsm *blockStreamMerger) Init(bsrs []*blockStreamReader, retentionDeadline
 	bsm.useSparseCache = useSparseCache
 }
 
-func (bsm *blockStreamMerger) getRetentionDeadline(_ *blockHeader) int64 {
-	return bsm.retentionDeadline
+func (bsm *blockStreamMerger) getRetentionDeadline(bh *blockHeader) int64 {
+	s := bsm.s
+	if s == nil || !s.hasRetentionFilters() {
+		return bsm.retentionDeadline
+	}
+	metricID := bh.TSID.MetricID
+	if metricID == bsm.prevMetricID {
+		// Fast path - the block belongs to the same time series as the previous block.
+		return bsm.prevRetentionDeadline
+	}
+	// bsm.retentionDeadline is calculated for s.retentionMsecs, which is the maximum retention.
+	retentionMsecs := s.getRetentionMsecsForMetricID(metricID)
+	bsm.prevMetricID = metricID
+	bsm.prevRetentionDeadline = bsm.retentionDeadline + s.retentionMsecs - retentionMsecs
+	return bsm.prevRetentionDeadline
 }
 
 // NextBlock stores the next block in bsm.Block.
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// DownsamplingPeriod defines the interval between samples for samples older than the given offset.
+type DownsamplingPeriod struct {
+	// Offset is the age of samples in milliseconds, starting from which the samples are downsampled to Interval.
+	Offset int64
+
+	// Interval is the interval in milliseconds between downsampled samples.
+	Interval int64
+}
+
+// String returns string representation of p in the form `offset:interval`.
+func (p *DownsamplingPeriod) String() string {
+	return fmt.Sprintf("%dms:%dms", p.Offset, p.Interval)
+}
+
+// ParseDownsamplingPeriods parses downsampling periods from a.
+//
+// Every item in a must have the form `offset:interval`, for example, `30d:1m`.
+// The returned periods are sorted by offset.
+func ParseDownsamplingPeriods(a []string) ([]DownsamplingPeriod, error) {
+	var periods []DownsamplingPeriod
+	for _, s := range a {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		n := strings.IndexByte(s, ':')
+		if n < 0 {
+			return nil, fmt.Errorf("missing ':' in downsampling period %q; it must have the form `offset:interval`", s)
+		}
+		offset, err := promutils.ParseDuration(s[:n])
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse offset in downsampling period %q: %w", s, err)
+		}
+		interval, err := promutils.ParseDuration(s[n+1:])
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse interval in downsampling period %q: %w", s, err)
+		}
+		if offset <= 0 {
+			return nil, fmt.Errorf("offset in downsampling period %q must be positive", s)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("interval in downsampling period %q must be positive", s)
+		}
+		periods = append(periods, DownsamplingPeriod{
+			Offset:   offset.Milliseconds(),
+			Interval: interval.Milliseconds(),
+		})
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Offset < periods[j].Offset
+	})
+	for i := 1; i < len(periods); i++ {
+		prev, p := &periods[i-1], &periods[i]
+		if p.Offset == prev.Offset {
+			return nil, fmt.Errorf("duplicate downsampling periods with the same offset: %s and %s", prev, p)
+		}
+		if p.Interval <= prev.Interval {
+			return nil, fmt.Errorf("downsampling interval must increase with offset; got %s after %s", p, prev)
+		}
+		if p.Interval%prev.Interval != 0 {
+			return nil, fmt.Errorf("downsampling interval must be a multiple of the interval for the smaller offset; got %s after %s", p, prev)
+		}
+	}
+	return periods, nil
+}
+
+// SetDownsamplingPeriods sets the downsampling periods, which are applied to samples during background merges and querying.
+//
+// Periods must be obtained via ParseDownsamplingPeriods. Downsampling is disabled if periods is empty.
+//
+// This function must be called before initializing the storage.
+func SetDownsamplingPeriods(periods []DownsamplingPeriod) {
+	downsamplingPeriods = append([]DownsamplingPeriod{}, periods...)
+}
+
+var downsamplingPeriods []DownsamplingPeriod
+
+func isDownsamplingEnabled() bool {
+	return len(downsamplingPeriods) > 0
+}
+
+// getDedupIntervalForTimestamp returns the interval in milliseconds, which must be used for de-duplication of samples
+// with the given timestamp at currentTimestamp.
+//
+// The returned interval takes into account both the dedup interval and the downsampling periods.
+func getDedupIntervalForTimestamp(timestamp, currentTimestamp, dedupInterval int64) int64 {
+	d := dedupInterval
+	for _, p := range downsamplingPeriods {
+		if timestamp < currentTimestamp-p.Offset && p.Interval > d {
+			d = p.Interval
+		}
+	}
+	return d
+}
+
+// getNextDownsamplingBoundary returns the smallest timestamp bigger than the given timestamp,
+// where the interval returned by getDedupIntervalForTimestamp may change.
+func getNextDownsamplingBoundary(timestamp, currentTimestamp int64) int64 {
+	boundary := int64(math.MaxInt64)
+	for _, p := range downsamplingPeriods {
+		b := currentTimestamp - p.Offset
+		if b > timestamp && b < boundary {
+			boundary = b
+		}
+	}
+	return boundary
+}
+
+// DownsampleSamples de-duplicates samples with dedupInterval and downsamples them according to the periods set via SetDownsamplingPeriods.
+//
+// Samples older than the offset of the downsampling period at currentTimestamp are de-duplicated with the interval of the period.
+// This guarantees consistent query results for samples, which weren't downsampled by background merges yet.
+func DownsampleSamples(srcTimestamps []int64, srcValues []float64, dedupInterval, currentTimestamp int64) ([]int64, []float64) {
+	return downsampleSamplesInternal(srcTimestamps, srcValues, dedupInterval, currentTimestamp, DeduplicateSamples)
+}
+
+func downsampleSamplesDuringMerge(srcTimestamps, srcValues []int64, dedupInterval, currentTimestamp int64) ([]int64, []int64) {
+	return downsampleSamplesInternal(srcTimestamps, srcValues, dedupInterval, currentTimestamp, deduplicateSamplesDuringMerge)
+}
+
+func downsampleSamplesInternal[T int64 | float64](srcTimestamps []int64, srcValues []T, dedupInterval, currentTimestamp int64,
+	dedup func(timestamps []int64, values []T, dedupInterval int64) ([]int64, []T)) ([]int64, []T) {
+	if !isDownsamplingEnabled() || len(srcTimestamps) == 0 || srcTimestamps[0] >= currentTimestamp-downsamplingPeriods[0].Offset {
+		// Fast path - the samples are too young for downsampling.
+		return dedup(srcTimestamps, srcValues, dedupInterval)
+	}
+
+	// Samples are sorted by timestamp, so split them into ranges with the same interval
+	// and de-duplicate every range individually.
+	dstTimestamps := srcTimestamps[:0]
+	dstValues := srcValues[:0]
+	for len(srcTimestamps) > 0 {
+		interval := getDedupIntervalForTimestamp(srcTimestamps[0], currentTimestamp, dedupInterval)
+		boundary := getNextDownsamplingBoundary(srcTimestamps[0], currentTimestamp)
+		n := sort.Search(len(srcTimestamps), func(i int) bool {
+			return srcTimestamps[i] >= boundary
+		})
+		timestamps, values := dedup(srcTimestamps[:n], srcValues[:n], interval)
+		// It is safe to append to dst* here, since they cannot overlap with the remaining src* items.
+		dstTimestamps = append(dstTimestamps, timestamps...)
+		dstValues = append(dstValues, values...)
+		srcTimestamps = srcTimestamps[n:]
+		srcValues = srcValues[n:]
+	}
+	return dstTimestamps, dstValues
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDownsamplingPeriodsSuccess(t *testing.T) {
+	f := func(a []string, periodsExpected []DownsamplingPeriod) {
+		t.Helper()
+		periods, err := ParseDownsamplingPeriods(a)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(periods, periodsExpected) {
+			t.Fatalf("unexpected periods\ngot\n%v\nwant\n%v", periods, periodsExpected)
+		}
+	}
+	f(nil, nil)
+	f([]string{""}, nil)
+	f([]string{"30d:1m"}, []DownsamplingPeriod{
+		{Offset: 30 * 24 * 3600 * 1000, Interval: 60 * 1000},
+	})
+	f([]string{"180d:5m", " 30d:1m"}, []DownsamplingPeriod{
+		{Offset: 30 * 24 * 3600 * 1000, Interval: 60 * 1000},
+		{Offset: 180 * 24 * 3600 * 1000, Interval: 5 * 60 * 1000},
+	})
+}
+
+func TestParseDownsamplingPeriodsFailure(t *testing.T) {
+	f := func(a []string) {
+		t.Helper()
+		_, err := ParseDownsamplingPeriods(a)
+		if err == nil {
+			t.Fatalf("expecting non-nil error for %q", a)
+		}
+	}
+	f([]string{"30d"})
+	f([]string{"foo:1m"})
+	f([]string{"30d:bar"})
+	f([]string{"0:1m"})
+	f([]string{"30d:0"})
+	f([]string{"30d:1m", "30d:5m"})
+	f([]string{"30d:5m", "180d:1m"})
+	f([]string{"30d:2m", "180d:5m"})
+}
+
+func TestDownsampleSamples(t *testing.T) {
+	periodsOrig := downsamplingPeriods
+	defer func() {
+		downsamplingPeriods = periodsOrig
+	}()
+
+	f := func(periods []DownsamplingPeriod, dedupInterval, currentTimestamp int64, timestamps []int64, timestampsExpected []int64) {
+		t.Helper()
+		SetDownsamplingPeriods(periods)
+
+		values := make([]float64, len(timestamps))
+		valuesInt := make([]int64, len(timestamps))
+		for i, ts := range timestamps {
+			values[i] = float64(ts)
+			valuesInt[i] = ts
+		}
+		timestampsCopy := append([]int64{}, timestamps...)
+		timestampsResult, valuesResult := DownsampleSamples(timestampsCopy, values, dedupInterval, currentTimestamp)
+		if !reflect.DeepEqual(timestampsResult, timestampsExpected) {
+			t.Fatalf("unexpected timestamps\ngot\n%v\nwant\n%v", timestampsResult, timestampsExpected)
+		}
+		for i, v := range valuesResult {
+			if v != float64(timestampsResult[i]) {
+				t.Fatalf("unexpected value at position %d; got %v; want %v", i, v, timestampsResult[i])
+			}
+		}
+
+		timestampsCopy = append(timestampsCopy[:0], timestamps...)
+		timestampsResult, valuesIntResult := downsampleSamplesDuringMerge(timestampsCopy, valuesInt, dedupInterval, currentTimestamp)
+		if !reflect.DeepEqual(timestampsResult, timestampsExpected) {
+			t.Fatalf("unexpected timestamps during merge\ngot\n%v\nwant\n%v", timestampsResult, timestampsExpected)
+		}
+		if !reflect.DeepEqual(valuesIntResult, timestampsResult) {
+			t.Fatalf("unexpected values during merge\ngot\n%v\nwant\n%v", valuesIntResult, timestampsResult)
+		}
+	}
+
+	timestamps := []int64{0, 3, 5, 8, 10, 13, 15, 18, 20, 23, 25, 28}
+
+	// downsampling is disabled
+	f(nil, 0, 30, timestamps, timestamps)
+	f(nil, 5, 30, timestamps, []int64{0, 5, 10, 15, 20, 25, 28})
+
+	// samples are too young for downsampling
+	f([]DownsamplingPeriod{{Offset: 100, Interval: 10}}, 0, 30, timestamps, timestamps)
+
+	// all the samples are downsampled
+	f([]DownsamplingPeriod{{Offset: 1, Interval: 10}}, 0, 30, timestamps, []int64{0, 10, 20, 28})
+
+	// samples older than 10 are downsampled to 5, while samples older than 20 are downsampled to 10
+	f([]DownsamplingPeriod{{Offset: 10, Interval: 5}, {Offset: 20, Interval: 10}}, 0, 30, timestamps, []int64{0, 8, 10, 15, 18, 20, 23, 25, 28})
+
+	// dedup interval is applied to young samples
+	f([]DownsamplingPeriod{{Offset: 20, Interval: 10}}, 5, 30, timestamps, []int64{0, 8, 10, 15, 20, 25, 28})
+}
+
+func TestIsFinalDedupNeededForParts(t *testing.T) {
+	periodsOrig := downsamplingPeriods
+	defer func() {
+		downsamplingPeriods = periodsOrig
+	}()
+
+	f := func(periods []DownsamplingPeriod, dedupInterval, currentTimestamp int64, phs []partHeader, resultExpected bool) {
+		t.Helper()
+		SetDownsamplingPeriods(periods)
+
+		var pws []*partWrapper
+		for _, ph := range phs {
+			pws = append(pws, &partWrapper{
+				p: &part{
+					ph: ph,
+				},
+			})
+		}
+		result := isFinalDedupNeededForParts(pws, currentTimestamp, dedupInterval)
+		if result != resultExpected {
+			t.Fatalf("unexpected result; got %v; want %v", result, resultExpected)
+		}
+	}
+
+	// no parts
+	f(nil, 5, 100, nil, false)
+
+	// parts are de-duplicated with the dedup interval
+	f(nil, 5, 100, []partHeader{{MaxTimestamp: 10, MinDedupInterval: 5}, {MaxTimestamp: 90, MinDedupInterval: 5}}, false)
+	f(nil, 5, 100, []partHeader{{MaxTimestamp: 10, MinDedupInterval: 5}, {MaxTimestamp: 90, MinDedupInterval: 0}}, true)
+
+	// the old part must be downsampled even if the partition contains young parts
+	periods := []DownsamplingPeriod{{Offset: 50, Interval: 10}}
+	f(periods, 5, 100, []partHeader{{MaxTimestamp: 10, MinDedupInterval: 5}, {MaxTimestamp: 90, MinDedupInterval: 5}}, true)
+
+	// the old part is already downsampled
+	f(periods, 5, 100, []partHeader{{MaxTimestamp: 10, MinDedupInterval: 10}, {MaxTimestamp: 90, MinDedupInterval: 5}}, false)
+
+	// the part with young samples isn't downsampled until its MaxTimestamp becomes older than the offset
+	f(periods, 5, 100, []partHeader{{MaxTimestamp: 60, MinDedupInterval: 5}}, false)
+}
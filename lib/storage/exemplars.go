@@ -0,0 +1,421 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timeutil"
+)
+
+// Exemplar is an exemplar attached to a time series sample.
+//
+// See https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md#exemplars
+type Exemplar struct {
+	// Labels contains exemplar labels such as trace_id.
+	Labels []ExemplarLabel
+
+	// Value is exemplar value.
+	Value float64
+
+	// Timestamp is exemplar timestamp in milliseconds.
+	Timestamp int64
+}
+
+// ExemplarLabel is a label attached to Exemplar.
+type ExemplarLabel struct {
+	Name  string
+	Value string
+}
+
+// ExemplarRow is an exemplar for the time series with the given MetricNameRaw.
+type ExemplarRow struct {
+	// MetricNameRaw contains raw metric name, which must be the same as MetricRow.MetricNameRaw for the time series.
+	MetricNameRaw []byte
+
+	// Exemplar is the exemplar for the time series.
+	Exemplar Exemplar
+}
+
+// SeriesExemplars contains exemplars for a single time series.
+type SeriesExemplars struct {
+	// MetricName is marshaled MetricName for the time series.
+	//
+	// It can be unmarshaled with MetricName.Unmarshal.
+	MetricName []byte
+
+	// Exemplars contains exemplars for the time series sorted by timestamp.
+	Exemplars []Exemplar
+}
+
+// exemplarsFilename is the name of the file with exemplars inside metadata directory.
+const exemplarsFilename = "exemplars"
+
+var (
+	maxExemplarsPerSeries   = 10
+	exemplarsRetentionMsecs = (3 * 24 * time.Hour).Milliseconds()
+	maxSeriesWithExemplars  = 1_000_000
+)
+
+// SetMaxExemplarsPerSeries sets the maximum number of exemplars to store per each time series.
+//
+// Exemplars aren't stored if n <= 0.
+func SetMaxExemplarsPerSeries(n int) {
+	maxExemplarsPerSeries = n
+}
+
+// SetExemplarsRetention sets the retention for the stored exemplars.
+func SetExemplarsRetention(retention time.Duration) {
+	exemplarsRetentionMsecs = retention.Milliseconds()
+}
+
+// SetMaxSeriesWithExemplars sets the maximum number of time series with exemplars, which can be stored in the storage.
+func SetMaxSeriesWithExemplars(n int) {
+	maxSeriesWithExemplars = n
+}
+
+// storedExemplar is a compact representation of Exemplar.
+type storedExemplar struct {
+	// labels contains marshaled exemplar labels.
+	labels    string
+	value     float64
+	timestamp int64
+}
+
+// exemplarStorage holds the most recent exemplars per each metricID.
+//
+// Exemplars outside the retention are automatically removed.
+type exemplarStorage struct {
+	droppedExemplars atomic.Uint64
+
+	path string
+
+	mu sync.Mutex
+
+	// m maps metricID to exemplars for the given time series ordered by timestamp.
+	m map[uint64][]storedExemplar
+
+	// isDirty is set to true when m contains changes, which aren't saved to path yet.
+	isDirty bool
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func mustOpenExemplarStorage(path string) *exemplarStorage {
+	es := &exemplarStorage{
+		path:   path,
+		m:      make(map[uint64][]storedExemplar),
+		stopCh: make(chan struct{}),
+	}
+	es.mustLoad()
+	es.wg.Add(1)
+	go func() {
+		defer es.wg.Done()
+		es.periodicSaver()
+	}()
+	return es
+}
+
+func (es *exemplarStorage) MustClose() {
+	close(es.stopCh)
+	es.wg.Wait()
+	es.mustSave()
+}
+
+func (es *exemplarStorage) periodicSaver() {
+	d := timeutil.AddJitterToDuration(5 * time.Minute)
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-es.stopCh:
+			return
+		case <-ticker.C:
+			es.mustSave()
+		}
+	}
+}
+
+// Add adds exemplar e for the given metricID.
+func (es *exemplarStorage) Add(metricID uint64, e *Exemplar) {
+	maxPerSeries := maxExemplarsPerSeries
+	if maxPerSeries <= 0 {
+		return
+	}
+	minTimestamp := int64(fasttime.UnixTimestamp()*1000) - exemplarsRetentionMsecs
+	if e.Timestamp < minTimestamp {
+		es.droppedExemplars.Add(1)
+		return
+	}
+	labels := string(marshalExemplarLabels(nil, e.Labels))
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	ses, ok := es.m[metricID]
+	if !ok && len(es.m) >= maxSeriesWithExemplars {
+		es.droppedExemplars.Add(1)
+		return
+	}
+	if n := len(ses); n > 0 {
+		last := &ses[n-1]
+		if last.timestamp == e.Timestamp && last.labels == labels {
+			// Skip duplicate exemplar. This is usual case when the same exemplar is scraped multiple times.
+			return
+		}
+		if e.Timestamp < last.timestamp {
+			// Do not accept out of order exemplars in order to keep ses sorted by timestamp.
+			es.droppedExemplars.Add(1)
+			return
+		}
+	}
+	if len(ses) >= maxPerSeries {
+		// Drop the oldest exemplars.
+		n := copy(ses, ses[len(ses)-maxPerSeries+1:])
+		clear(ses[n:])
+		ses = ses[:n]
+	}
+	ses = append(ses, storedExemplar{
+		labels:    labels,
+		value:     e.Value,
+		timestamp: e.Timestamp,
+	})
+	es.m[metricID] = ses
+	es.isDirty = true
+}
+
+// AppendExemplars appends exemplars for the given metricID on the given tr to dst and returns the result.
+func (es *exemplarStorage) AppendExemplars(dst []Exemplar, metricID uint64, tr TimeRange) []Exemplar {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	for _, se := range es.m[metricID] {
+		if se.timestamp < tr.MinTimestamp || se.timestamp > tr.MaxTimestamp {
+			continue
+		}
+		labels, err := unmarshalExemplarLabels(nil, se.labels)
+		if err != nil {
+			logger.Panicf("BUG: cannot unmarshal exemplar labels: %s", err)
+		}
+		dst = append(dst, Exemplar{
+			Labels:    labels,
+			Value:     se.value,
+			Timestamp: se.timestamp,
+		})
+	}
+	return dst
+}
+
+// SeriesCount returns the number of time series with exemplars in es.
+func (es *exemplarStorage) SeriesCount() int {
+	es.mu.Lock()
+	n := len(es.m)
+	es.mu.Unlock()
+	return n
+}
+
+// removeStaleExemplarsLocked removes exemplars outside the retention.
+func (es *exemplarStorage) removeStaleExemplarsLocked() {
+	minTimestamp := int64(fasttime.UnixTimestamp()*1000) - exemplarsRetentionMsecs
+	for metricID, ses := range es.m {
+		n := sort.Search(len(ses), func(i int) bool {
+			return ses[i].timestamp >= minTimestamp
+		})
+		if n == 0 {
+			continue
+		}
+		es.isDirty = true
+		if n == len(ses) {
+			delete(es.m, metricID)
+			continue
+		}
+		es.m[metricID] = append(ses[:0:0], ses[n:]...)
+	}
+}
+
+func (es *exemplarStorage) mustSave() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	es.removeStaleExemplarsLocked()
+	if !es.isDirty {
+		return
+	}
+	var dst []byte
+	dst = encoding.MarshalVarUint64(dst, uint64(len(es.m)))
+	for metricID, ses := range es.m {
+		dst = encoding.MarshalUint64(dst, metricID)
+		dst = encoding.MarshalVarUint64(dst, uint64(len(ses)))
+		for _, se := range ses {
+			dst = encoding.MarshalBytes(dst, []byte(se.labels))
+			dst = encoding.MarshalUint64(dst, math.Float64bits(se.value))
+			dst = encoding.MarshalVarInt64(dst, se.timestamp)
+		}
+	}
+	fs.MustWriteAtomic(es.path, dst, true)
+	es.isDirty = false
+}
+
+func (es *exemplarStorage) mustLoad() {
+	if !fs.IsPathExist(es.path) {
+		return
+	}
+	src, err := os.ReadFile(es.path)
+	if err != nil {
+		logger.Panicf("FATAL: cannot read %s: %s", es.path, err)
+	}
+	m, err := unmarshalStoredExemplars(src)
+	if err != nil {
+		logger.Errorf("discarding %s, since it contains broken data: %s", es.path, err)
+		return
+	}
+	es.m = m
+	es.removeStaleExemplarsLocked()
+}
+
+func unmarshalStoredExemplars(src []byte) (map[uint64][]storedExemplar, error) {
+	n, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return nil, fmt.Errorf("cannot unmarshal the number of series")
+	}
+	src = src[nSize:]
+	if n > uint64(len(src)) {
+		return nil, fmt.Errorf("too big number of series: %d; it cannot exceed the remaining data size %d", n, len(src))
+	}
+	m := make(map[uint64][]storedExemplar, n)
+	for i := uint64(0); i < n; i++ {
+		if len(src) < 8 {
+			return nil, fmt.Errorf("cannot unmarshal metricID for series #%d from %d bytes; need at least 8 bytes", i, len(src))
+		}
+		metricID := encoding.UnmarshalUint64(src)
+		src = src[8:]
+		exemplarsCount, nSize := encoding.UnmarshalVarUint64(src)
+		if nSize <= 0 {
+			return nil, fmt.Errorf("cannot unmarshal the number of exemplars for series #%d", i)
+		}
+		src = src[nSize:]
+		if exemplarsCount > uint64(len(src)) {
+			return nil, fmt.Errorf("too big number of exemplars for series #%d: %d; it cannot exceed the remaining data size %d", i, exemplarsCount, len(src))
+		}
+		ses := make([]storedExemplar, 0, exemplarsCount)
+		for j := uint64(0); j < exemplarsCount; j++ {
+			labels, nSize := encoding.UnmarshalBytes(src)
+			if nSize <= 0 {
+				return nil, fmt.Errorf("cannot unmarshal labels for exemplar #%d at series #%d", j, i)
+			}
+			src = src[nSize:]
+			if len(src) < 8 {
+				return nil, fmt.Errorf("cannot unmarshal value for exemplar #%d at series #%d", j, i)
+			}
+			value := math.Float64frombits(encoding.UnmarshalUint64(src))
+			src = src[8:]
+			timestamp, nSize := encoding.UnmarshalVarInt64(src)
+			if nSize <= 0 {
+				return nil, fmt.Errorf("cannot unmarshal timestamp for exemplar #%d at series #%d", j, i)
+			}
+			src = src[nSize:]
+			ses = append(ses, storedExemplar{
+				labels:    string(labels),
+				value:     value,
+				timestamp: timestamp,
+			})
+		}
+		m[metricID] = ses
+	}
+	if len(src) > 0 {
+		return nil, fmt.Errorf("unexpected non-empty tail left; len(tail)=%d", len(src))
+	}
+	return m, nil
+}
+
+func marshalExemplarLabels(dst []byte, labels []ExemplarLabel) []byte {
+	for _, label := range labels {
+		dst = encoding.MarshalBytes(dst, []byte(label.Name))
+		dst = encoding.MarshalBytes(dst, []byte(label.Value))
+	}
+	return dst
+}
+
+func unmarshalExemplarLabels(dst []ExemplarLabel, src string) ([]ExemplarLabel, error) {
+	b := []byte(src)
+	for len(b) > 0 {
+		name, nSize := encoding.UnmarshalBytes(b)
+		if nSize <= 0 {
+			return dst, fmt.Errorf("cannot unmarshal label name")
+		}
+		b = b[nSize:]
+		value, nSize := encoding.UnmarshalBytes(b)
+		if nSize <= 0 {
+			return dst, fmt.Errorf("cannot unmarshal label value")
+		}
+		b = b[nSize:]
+		dst = append(dst, ExemplarLabel{
+			Name:  string(name),
+			Value: string(value),
+		})
+	}
+	return dst, nil
+}
+
+// AddExemplars adds ers to the storage.
+//
+// Exemplars are stored only for time series, which were already added to the storage via AddRows.
+func (s *Storage) AddExemplars(ers []ExemplarRow) {
+	var genTSID generationTSID
+	for i := range ers {
+		er := &ers[i]
+		if !s.getTSIDFromCache(&genTSID, er.MetricNameRaw) {
+			// The time series is missing in the storage - drop its exemplar.
+			s.exemplars.droppedExemplars.Add(1)
+			continue
+		}
+		s.exemplars.Add(genTSID.TSID.MetricID, &er.Exemplar)
+	}
+}
+
+// SearchExemplars returns exemplars on the given tr for time series matching the given tfss.
+func (s *Storage) SearchExemplars(qt *querytracer.Tracer, tfss []*TagFilters, tr TimeRange, maxMetrics int, deadline uint64) ([]SeriesExemplars, error) {
+	qt = qt.NewChild("search for exemplars: filters=%s, timeRange=%s", tfss, &tr)
+	defer qt.Done()
+
+	metricIDs, err := s.idb().searchMetricIDs(qt, tfss, tr, maxMetrics, deadline)
+	if err != nil {
+		return nil, err
+	}
+	idb := s.idb()
+	var result []SeriesExemplars
+	var metricName []byte
+	for i, metricID := range metricIDs {
+		if i&paceLimiterSlowIterationsMask == 0 {
+			if err := checkSearchDeadlineAndPace(deadline); err != nil {
+				return nil, err
+			}
+		}
+		exemplars := s.exemplars.AppendExemplars(nil, metricID, tr)
+		if len(exemplars) == 0 {
+			continue
+		}
+		var ok bool
+		metricName, ok = idb.searchMetricName(metricName[:0], metricID, false)
+		if !ok {
+			// Skip missing metricName for metricID.
+			continue
+		}
+		result = append(result, SeriesExemplars{
+			MetricName: append([]byte{}, metricName...),
+			Exemplars:  exemplars,
+		})
+	}
+	qt.Printf("found exemplars for %d out of %d series", len(result), len(metricIDs))
+	return result, nil
+}
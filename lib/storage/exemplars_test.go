@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+)
+
+func TestExemplarStorageAdd(t *testing.T) {
+	path := filepath.Join(t.Name(), exemplarsFilename)
+	defer fs.MustRemoveAll(t.Name())
+	fs.MustMkdirIfNotExist(t.Name())
+
+	maxPerSeriesOrig := maxExemplarsPerSeries
+	SetMaxExemplarsPerSeries(2)
+	defer SetMaxExemplarsPerSeries(maxPerSeriesOrig)
+
+	now := int64(fasttime.UnixTimestamp() * 1000)
+	trace := func(traceID string) []ExemplarLabel {
+		return []ExemplarLabel{{Name: "trace_id", Value: traceID}}
+	}
+
+	es := mustOpenExemplarStorage(path)
+	es.Add(1, &Exemplar{Labels: trace("a"), Value: 1, Timestamp: now - 3000})
+	// duplicate exemplar must be skipped
+	es.Add(1, &Exemplar{Labels: trace("a"), Value: 1, Timestamp: now - 3000})
+	es.Add(1, &Exemplar{Labels: trace("b"), Value: 2, Timestamp: now - 2000})
+	es.Add(1, &Exemplar{Labels: trace("c"), Value: 3, Timestamp: now - 1000})
+	// out of order exemplar must be dropped
+	es.Add(1, &Exemplar{Labels: trace("d"), Value: 4, Timestamp: now - 5000})
+	// exemplar outside the retention must be dropped
+	es.Add(2, &Exemplar{Labels: trace("e"), Value: 5, Timestamp: now - exemplarsRetentionMsecs - 3600*1000})
+	es.Add(3, &Exemplar{Value: 6, Timestamp: now})
+
+	if n := es.droppedExemplars.Load(); n != 2 {
+		t.Fatalf("unexpected number of dropped exemplars; got %d; want 2", n)
+	}
+
+	f := func(metricID uint64, tr TimeRange, resultExpected []Exemplar) {
+		t.Helper()
+		result := es.AppendExemplars(nil, metricID, tr)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected exemplars for metricID=%d\ngot\n%v\nwant\n%v", metricID, result, resultExpected)
+		}
+	}
+
+	trAll := TimeRange{
+		MinTimestamp: 0,
+		MaxTimestamp: now,
+	}
+	checkAll := func() {
+		t.Helper()
+		if n := es.SeriesCount(); n != 2 {
+			t.Fatalf("unexpected number of series with exemplars; got %d; want 2", n)
+		}
+		f(1, trAll, []Exemplar{
+			{Labels: trace("b"), Value: 2, Timestamp: now - 2000},
+			{Labels: trace("c"), Value: 3, Timestamp: now - 1000},
+		})
+		f(1, TimeRange{MinTimestamp: now - 1500, MaxTimestamp: now}, []Exemplar{
+			{Labels: trace("c"), Value: 3, Timestamp: now - 1000},
+		})
+		f(2, trAll, nil)
+		f(3, trAll, []Exemplar{
+			{Value: 6, Timestamp: now},
+		})
+	}
+	checkAll()
+
+	// Verify the exemplars survive the restart.
+	es.MustClose()
+	es = mustOpenExemplarStorage(path)
+	checkAll()
+	es.MustClose()
+}
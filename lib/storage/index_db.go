@@ -1422,6 +1422,10 @@ func (is *indexSearch) getTSDBStatus(qt *querytracer.Tracer, tfss []*TagFilters,
 	if bytes.HasPrefix(prevLabelValuePair, focusLabelEqualBytes) {
 		thSeriesCountByFocusLabelValue.push(prevLabelValuePair[len(focusLabelEqualBytes):], seriesCountByLabelValuePair)
 	}
+	seriesCountByRetention, err := is.getSeriesCountByRetention(qt, filter, date, totalSeries, maxMetrics)
+	if err != nil {
+		return nil, err
+	}
 	status := &TSDBStatus{
 		TotalSeries:                  totalSeries,
 		TotalLabelValuePairs:         totalLabelValuePairs,
@@ -1430,6 +1434,7 @@ func (is *indexSearch) getTSDBStatus(qt *querytracer.Tracer, tfss []*TagFilters,
 		SeriesCountByFocusLabelValue: thSeriesCountByFocusLabelValue.getSortedResult(),
 		SeriesCountByLabelValuePair:  thSeriesCountByLabelValuePair.getSortedResult(),
 		LabelValueCountByLabelName:   thLabelValueCountByLabelName.getSortedResult(),
+		SeriesCountByRetention:       seriesCountByRetention,
 	}
 	return status, nil
 }
@@ -1445,6 +1450,9 @@ type TSDBStatus struct {
 	SeriesCountByFocusLabelValue []TopHeapEntry
 	SeriesCountByLabelValuePair  []TopHeapEntry
 	LabelValueCountByLabelName   []TopHeapEntry
+
+	// SeriesCountByRetention contains the number of series per each effective retention.
+	SeriesCountByRetention []TopHeapEntry
 }
 
 func (status *TSDBStatus) hasEntries() bool {
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 
@@ -17,9 +18,13 @@ import (
 func mergeBlockStreams(ph *partHeader, bsw *blockStreamWriter, bsrs []*blockStreamReader, stopCh <-chan struct{}, s *Storage, retentionDeadline int64,
 	rowsMerged, rowsDeleted *atomic.Uint64, useSparseCache bool) error {
 	ph.Reset()
+	if s != nil && s.hasRetentionFilters() {
+		// The minimum expire timestamp is updated with every written block in mergeBlockStreamsInternal.
+		ph.MinExpireTimestamp = math.MaxInt64
+	}
 
 	bsm := bsmPool.Get().(*blockStreamMerger)
-	bsm.Init(bsrs, retentionDeadline, useSparseCache)
+	bsm.Init(bsrs, s, retentionDeadline, useSparseCache)
 	err := mergeBlockStreamsInternal(ph, bsw, bsm, stopCh, s, rowsMerged, rowsDeleted)
 	bsm.reset()
 	bsmPool.Put(bsm)
@@ -45,6 +50,10 @@ func mergeBlockStreamsInternal(ph *partHeader, bsw *blockStreamWriter, bsm *bloc
 	defer putBlock(pendingBlock)
 	tmpBlock := getBlock()
 	defer putBlock(tmpBlock)
+	writeBlock := func(b *Block) {
+		bsw.WriteExternalBlock(b, ph, rowsMerged)
+		updateMinExpireTimestamp(ph, b, s)
+	}
 	for bsm.NextBlock() {
 		select {
 		case <-stopCh:
@@ -77,14 +86,14 @@ func mergeBlockStreamsInternal(ph *partHeader, bsw *blockStreamWriter, bsm *bloc
 			if b.bh.TSID.Less(&pendingBlock.bh.TSID) {
 				logger.Panicf("BUG: the next TSID=%+v is smaller than the current TSID=%+v", &b.bh.TSID, &pendingBlock.bh.TSID)
 			}
-			bsw.WriteExternalBlock(pendingBlock, ph, rowsMerged)
+			writeBlock(pendingBlock)
 			pendingBlock.CopyFrom(b)
 			continue
 		}
 		if pendingBlock.tooBig() && pendingBlock.bh.MaxTimestamp <= b.bh.MinTimestamp {
 			// Fast path - pendingBlock is too big and it doesn't overlap with b.
 			// Write the pendingBlock and then deal with b.
-			bsw.WriteExternalBlock(pendingBlock, ph, rowsMerged)
+			writeBlock(pendingBlock)
 			pendingBlock.CopyFrom(b)
 			continue
 		}
@@ -120,17 +129,35 @@ func mergeBlockStreamsInternal(ph *partHeader, bsw *blockStreamWriter, bsm *bloc
 		tmpBlock.timestamps = tmpBlock.timestamps[:maxRowsPerBlock]
 		tmpBlock.values = tmpBlock.values[:maxRowsPerBlock]
 		tmpBlock.fixupTimestamps()
-		bsw.WriteExternalBlock(tmpBlock, ph, rowsMerged)
+		writeBlock(tmpBlock)
 	}
 	if err := bsm.Error(); err != nil {
 		return fmt.Errorf("cannot read block to be merged: %w", err)
 	}
 	if !pendingBlockIsEmpty {
-		bsw.WriteExternalBlock(pendingBlock, ph, rowsMerged)
+		writeBlock(pendingBlock)
 	}
 	return nil
 }
 
+// updateMinExpireTimestamp updates ph.MinExpireTimestamp with the expire timestamp for the block b written to the part.
+func updateMinExpireTimestamp(ph *partHeader, b *Block, s *Storage) {
+	if ph.MinExpireTimestamp == 0 {
+		// Retention filters aren't applied during the merge.
+		return
+	}
+	retentionMsecs := s.getRetentionMsecsForMetricID(b.bh.TSID.MetricID)
+	if retentionMsecs >= s.retentionMsecs {
+		// Samples outside the maximum retention are removed by dropping the whole partitions.
+		return
+	}
+	// The block is dropped during the merge when all its samples are outside the retention.
+	expireTimestamp := b.bh.MaxTimestamp + retentionMsecs
+	if expireTimestamp < ph.MinExpireTimestamp {
+		ph.MinExpireTimestamp = expireTimestamp
+	}
+}
+
 // mergeBlocks merges ib1 and ib2 to ob.
 func mergeBlocks(ob, ib1, ib2 *Block, retentionDeadline int64, rowsDeleted *atomic.Uint64) {
 	ib1.assertMergeable(ib2)
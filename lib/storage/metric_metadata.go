@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timeutil"
+)
+
+// MetricMetadata contains metadata for a single metric family.
+//
+// See https://prometheus.io/docs/prometheus/latest/querying/api/#querying-metric-metadata
+type MetricMetadata struct {
+	// MetricFamilyName is the name of the metric family the metadata refers to.
+	MetricFamilyName string
+
+	// Type is the metric type.
+	//
+	// It must contain MetricMetadata.MetricType enum value from Prometheus remote write protocol.
+	Type uint32
+
+	// Help is the metric description.
+	Help string
+
+	// Unit is the metric unit.
+	Unit string
+}
+
+// metricMetadataFilename is the name of the file with metric metadata inside metadata directory.
+//
+// The file is stored inside metadata directory, so it is automatically included into snapshots and backups.
+const metricMetadataFilename = "metric_metadata"
+
+var maxMetricMetadataEntries = 100_000
+
+// SetMaxMetricMetadataEntries sets the maximum number of unique metric metadata entries, which can be stored in the storage.
+func SetMaxMetricMetadataEntries(n int) {
+	maxMetricMetadataEntries = n
+}
+
+// metricMetadataStorage holds unique metric metadata entries.
+//
+// Entries, which weren't updated during the retention, are automatically removed.
+type metricMetadataStorage struct {
+	droppedEntries atomic.Uint64
+
+	path           string
+	retentionMsecs int64
+
+	mu sync.Mutex
+
+	// m maps metadata entry to the last timestamp in seconds when it was seen.
+	m map[MetricMetadata]uint64
+
+	// isDirty is set to true when m contains changes, which aren't saved to path yet.
+	isDirty bool
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func mustOpenMetricMetadataStorage(path string, retentionMsecs int64) *metricMetadataStorage {
+	mms := &metricMetadataStorage{
+		path:           path,
+		retentionMsecs: retentionMsecs,
+		m:              make(map[MetricMetadata]uint64),
+		stopCh:         make(chan struct{}),
+	}
+	mms.mustLoad()
+	mms.wg.Add(1)
+	go func() {
+		defer mms.wg.Done()
+		mms.periodicSaver()
+	}()
+	return mms
+}
+
+func (mms *metricMetadataStorage) MustClose() {
+	close(mms.stopCh)
+	mms.wg.Wait()
+	mms.mustSave()
+}
+
+func (mms *metricMetadataStorage) periodicSaver() {
+	d := timeutil.AddJitterToDuration(time.Minute)
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-mms.stopCh:
+			return
+		case <-ticker.C:
+			mms.mustSave()
+		}
+	}
+}
+
+// Add adds entries to mms.
+func (mms *metricMetadataStorage) Add(entries []MetricMetadata) {
+	currentTimestamp := fasttime.UnixTimestamp()
+	mms.mu.Lock()
+	for i := range entries {
+		e := &entries[i]
+		if e.MetricFamilyName == "" {
+			continue
+		}
+		if _, ok := mms.m[*e]; !ok {
+			if len(mms.m) >= maxMetricMetadataEntries {
+				mms.droppedEntries.Add(1)
+				continue
+			}
+			// Clone strings, since they may refer to the buffers owned by the caller.
+			e := MetricMetadata{
+				MetricFamilyName: strings.Clone(e.MetricFamilyName),
+				Type:             e.Type,
+				Help:             strings.Clone(e.Help),
+				Unit:             strings.Clone(e.Unit),
+			}
+			mms.m[e] = currentTimestamp
+			mms.isDirty = true
+			continue
+		}
+		if currentTimestamp-mms.m[*e] > 3600 {
+			// Persist the updated timestamp, so the entry isn't removed as stale after the restart.
+			mms.isDirty = true
+		}
+		mms.m[*e] = currentTimestamp
+	}
+	mms.mu.Unlock()
+}
+
+// Search returns metadata entries for the given metricFamilyName.
+//
+// All the entries are returned if metricFamilyName is empty.
+// limit limits the number of returned metric families, while limitPerMetric limits the number of returned entries per each metric family.
+// Non-positive limits mean no limit.
+//
+// The returned entries are sorted by MetricFamilyName.
+func (mms *metricMetadataStorage) Search(metricFamilyName string, limit, limitPerMetric int) []MetricMetadata {
+	var result []MetricMetadata
+	mms.mu.Lock()
+	for e := range mms.m {
+		if metricFamilyName != "" && e.MetricFamilyName != metricFamilyName {
+			continue
+		}
+		result = append(result, e)
+	}
+	mms.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := &result[i], &result[j]
+		if a.MetricFamilyName != b.MetricFamilyName {
+			return a.MetricFamilyName < b.MetricFamilyName
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Help != b.Help {
+			return a.Help < b.Help
+		}
+		return a.Unit < b.Unit
+	})
+
+	if limit <= 0 && limitPerMetric <= 0 {
+		return result
+	}
+	dst := result[:0]
+	metricFamilies := 0
+	entriesPerMetric := 0
+	prevMetricFamilyName := ""
+	for _, e := range result {
+		if len(dst) == 0 || e.MetricFamilyName != prevMetricFamilyName {
+			if limit > 0 && metricFamilies >= limit {
+				break
+			}
+			metricFamilies++
+			entriesPerMetric = 0
+			prevMetricFamilyName = e.MetricFamilyName
+		}
+		if limitPerMetric > 0 && entriesPerMetric >= limitPerMetric {
+			continue
+		}
+		entriesPerMetric++
+		dst = append(dst, e)
+	}
+	return dst
+}
+
+// EntriesCount returns the number of entries in mms.
+func (mms *metricMetadataStorage) EntriesCount() int {
+	mms.mu.Lock()
+	n := len(mms.m)
+	mms.mu.Unlock()
+	return n
+}
+
+// removeStaleEntriesLocked removes entries, which weren't seen during the retention.
+func (mms *metricMetadataStorage) removeStaleEntriesLocked() {
+	currentTimestamp := fasttime.UnixTimestamp()
+	retentionSecs := uint64(mms.retentionMsecs / 1000)
+	if currentTimestamp < retentionSecs {
+		return
+	}
+	minTimestamp := currentTimestamp - retentionSecs
+	for e, timestamp := range mms.m {
+		if timestamp < minTimestamp {
+			delete(mms.m, e)
+			mms.isDirty = true
+		}
+	}
+}
+
+func (mms *metricMetadataStorage) mustSave() {
+	mms.mu.Lock()
+	defer mms.mu.Unlock()
+
+	mms.removeStaleEntriesLocked()
+	if !mms.isDirty {
+		return
+	}
+	var dst []byte
+	dst = encoding.MarshalVarUint64(dst, uint64(len(mms.m)))
+	for e, timestamp := range mms.m {
+		dst = e.marshal(dst)
+		dst = encoding.MarshalVarUint64(dst, timestamp)
+	}
+	fs.MustWriteAtomic(mms.path, dst, true)
+	mms.isDirty = false
+}
+
+func (mms *metricMetadataStorage) mustLoad() {
+	if !fs.IsPathExist(mms.path) {
+		return
+	}
+	src, err := os.ReadFile(mms.path)
+	if err != nil {
+		logger.Panicf("FATAL: cannot read %s: %s", mms.path, err)
+	}
+	m, err := unmarshalMetricMetadataEntries(src)
+	if err != nil {
+		logger.Errorf("discarding %s, since it contains broken data: %s", mms.path, err)
+		return
+	}
+	mms.m = m
+	mms.removeStaleEntriesLocked()
+}
+
+func unmarshalMetricMetadataEntries(src []byte) (map[MetricMetadata]uint64, error) {
+	n, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return nil, fmt.Errorf("cannot unmarshal the number of entries")
+	}
+	src = src[nSize:]
+	if n > uint64(len(src)) {
+		return nil, fmt.Errorf("too big number of entries: %d; it cannot exceed the remaining data size %d", n, len(src))
+	}
+	m := make(map[MetricMetadata]uint64, n)
+	for i := uint64(0); i < n; i++ {
+		var e MetricMetadata
+		tail, err := e.unmarshal(src)
+		if err != nil {
+			return nil, fmt.Errorf("cannot unmarshal entry #%d: %w", i, err)
+		}
+		src = tail
+		timestamp, nSize := encoding.UnmarshalVarUint64(src)
+		if nSize <= 0 {
+			return nil, fmt.Errorf("cannot unmarshal timestamp for entry #%d", i)
+		}
+		src = src[nSize:]
+		m[e] = timestamp
+	}
+	if len(src) > 0 {
+		return nil, fmt.Errorf("unexpected non-empty tail left; len(tail)=%d", len(src))
+	}
+	return m, nil
+}
+
+func (mm *MetricMetadata) marshal(dst []byte) []byte {
+	dst = encoding.MarshalBytes(dst, []byte(mm.MetricFamilyName))
+	dst = encoding.MarshalVarUint64(dst, uint64(mm.Type))
+	dst = encoding.MarshalBytes(dst, []byte(mm.Help))
+	dst = encoding.MarshalBytes(dst, []byte(mm.Unit))
+	return dst
+}
+
+func (mm *MetricMetadata) unmarshal(src []byte) ([]byte, error) {
+	name, nSize := encoding.UnmarshalBytes(src)
+	if nSize <= 0 {
+		return src, fmt.Errorf("cannot unmarshal metric family name")
+	}
+	src = src[nSize:]
+	mm.MetricFamilyName = string(name)
+
+	mt, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return src, fmt.Errorf("cannot unmarshal metric type")
+	}
+	src = src[nSize:]
+	mm.Type = uint32(mt)
+
+	help, nSize := encoding.UnmarshalBytes(src)
+	if nSize <= 0 {
+		return src, fmt.Errorf("cannot unmarshal help")
+	}
+	src = src[nSize:]
+	mm.Help = string(help)
+
+	unit, nSize := encoding.UnmarshalBytes(src)
+	if nSize <= 0 {
+		return src, fmt.Errorf("cannot unmarshal unit")
+	}
+	src = src[nSize:]
+	mm.Unit = string(unit)
+
+	return src, nil
+}
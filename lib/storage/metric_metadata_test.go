@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+)
+
+func TestMetricMetadataStorageSearch(t *testing.T) {
+	path := filepath.Join(t.Name(), metricMetadataFilename)
+	defer fs.MustRemoveAll(t.Name())
+	fs.MustMkdirIfNotExist(t.Name())
+
+	mms := mustOpenMetricMetadataStorage(path, retention31Days.Milliseconds())
+	mms.Add([]MetricMetadata{
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help"},
+		{MetricFamilyName: "bar", Type: 2, Help: "bar help", Unit: "seconds"},
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help 2"},
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help"},
+		{MetricFamilyName: "", Type: 1, Help: "missing name"},
+	})
+
+	f := func(metricFamilyName string, limit, limitPerMetric int, resultExpected []MetricMetadata) {
+		t.Helper()
+		result := mms.Search(metricFamilyName, limit, limitPerMetric)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result\ngot\n%v\nwant\n%v", result, resultExpected)
+		}
+	}
+
+	f("", 0, 0, []MetricMetadata{
+		{MetricFamilyName: "bar", Type: 2, Help: "bar help", Unit: "seconds"},
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help"},
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help 2"},
+	})
+	f("foo", 0, 0, []MetricMetadata{
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help"},
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help 2"},
+	})
+	f("", 1, 0, []MetricMetadata{
+		{MetricFamilyName: "bar", Type: 2, Help: "bar help", Unit: "seconds"},
+	})
+	f("", 0, 1, []MetricMetadata{
+		{MetricFamilyName: "bar", Type: 2, Help: "bar help", Unit: "seconds"},
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help"},
+	})
+	f("missing", 0, 0, nil)
+
+	// Verify the entries survive the restart.
+	mms.MustClose()
+	mms = mustOpenMetricMetadataStorage(path, retention31Days.Milliseconds())
+	if n := mms.EntriesCount(); n != 3 {
+		t.Fatalf("unexpected number of entries after the restart; got %d; want 3", n)
+	}
+	f("foo", 0, 0, []MetricMetadata{
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help"},
+		{MetricFamilyName: "foo", Type: 1, Help: "foo help 2"},
+	})
+	mms.MustClose()
+}
@@ -31,6 +31,14 @@ type partHeader struct {
 
 	// MinDedupInterval is minimal dedup interval in milliseconds across all the blocks in the part.
 	MinDedupInterval int64
+
+	// MinExpireTimestamp is the minimum timestamp in milliseconds when some block in the part falls entirely
+	// outside the per-series retention set via retention filters, so it can be dropped during the merge.
+	//
+	// It is set to 0 if the part was created without retention filters.
+	// It is set to math.MaxInt64 if the part has no blocks for time series with per-series retention
+	// smaller than the maximum retention.
+	MinExpireTimestamp int64
 }
 
 // String returns string representation of ph.
@@ -45,6 +53,7 @@ func (ph *partHeader) Reset() {
 	ph.MinTimestamp = (1 << 63) - 1
 	ph.MaxTimestamp = -1 << 63
 	ph.MinDedupInterval = 0
+	ph.MinExpireTimestamp = 0
 }
 
 func (ph *partHeader) readMinDedupInterval(partPath string) error {
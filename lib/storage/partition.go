@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -1344,27 +1345,72 @@ func (pt *partition) runFinalDedup(stopCh <-chan struct{}) error {
 }
 
 func (pt *partition) isFinalDedupNeeded() bool {
-	dedupInterval := GetDedupInterval()
+	currentTimestamp := time.Now().UnixMilli()
 
 	pws := pt.GetParts(nil, false)
-	minDedupInterval := getMinDedupInterval(pws)
+	ok := isFinalDedupNeededForParts(pws, currentTimestamp, GetDedupInterval())
 	pt.PutParts(pws)
 
-	return dedupInterval > minDedupInterval
+	return ok
 }
 
-func getMinDedupInterval(pws []*partWrapper) int64 {
-	if len(pws) == 0 {
-		return 0
+func (pt *partition) runRetentionFiltersMerge(stopCh <-chan struct{}) error {
+	t := time.Now()
+	logger.Infof("start applying retention filters to partition (%s, %s)", pt.bigPartsPath, pt.smallPartsPath)
+	if err := pt.ForceMergeAllParts(stopCh); err != nil {
+		return fmt.Errorf("cannot apply retention filters to partition (%s, %s): %w", pt.bigPartsPath, pt.smallPartsPath, err)
+	}
+	logger.Infof("retention filters have been applied to partition (%s, %s) in %.3f seconds", pt.bigPartsPath, pt.smallPartsPath, time.Since(t).Seconds())
+	return nil
+}
+
+// isRetentionFiltersMergeNeeded returns true if pt contains blocks, which are outside per-series retentions
+// for more than retentionFiltersMergeInterval.
+//
+// The decision is based on part headers, so it is preserved across restarts,
+// and partitions without expired blocks aren't merged.
+func (pt *partition) isRetentionFiltersMergeNeeded(currentTimestamp int64) bool {
+	retentions := pt.s.getRetentionMsecsToEnforce()
+	if len(retentions) == 0 {
+		return false
+	}
+	minRetentionMsecs := slices.Min(retentions)
+	deadline := currentTimestamp - retentionFiltersMergeInterval.Milliseconds()
+
+	pws := pt.GetParts(nil, false)
+	defer pt.PutParts(pws)
+	for _, pw := range pws {
+		ph := &pw.p.ph
+		expireTimestamp := ph.MinExpireTimestamp
+		if expireTimestamp == 0 {
+			// The part was created without retention filters,
+			// so it may contain samples outside the smallest per-series retention.
+			expireTimestamp = ph.MinTimestamp + minRetentionMsecs
+		}
+		if expireTimestamp < deadline {
+			return true
+		}
 	}
-	dMin := pws[0].p.ph.MinDedupInterval
-	for _, pw := range pws[1:] {
-		d := pw.p.ph.MinDedupInterval
-		if d < dMin {
-			dMin = d
+	return false
+}
+
+// retentionFiltersMergeInterval is the interval after the expiration of blocks, which triggers forced merge applying retention filters to a partition.
+//
+// This reduces the number of forced merges for partitions with continuously expiring blocks.
+const retentionFiltersMergeInterval = 24 * time.Hour
+
+// isFinalDedupNeededForParts returns true if some of pws must be de-duplicated with bigger interval than they were de-duplicated with.
+//
+// The interval is determined individually per each part by its MaxTimestamp, so old parts are downsampled
+// without waiting until the whole partition becomes older than the downsampling offset.
+func isFinalDedupNeededForParts(pws []*partWrapper, currentTimestamp, dedupInterval int64) bool {
+	for _, pw := range pws {
+		ph := &pw.p.ph
+		if getDedupIntervalForTimestamp(ph.MaxTimestamp, currentTimestamp, dedupInterval) > ph.MinDedupInterval {
+			return true
 		}
 	}
-	return dMin
+	return false
 }
 
 // mergeParts merges pws to a single resulting part.
@@ -1577,7 +1623,9 @@ func (pt *partition) mergePartsInternal(dstPartPath string, bsw *blockStreamWrit
 		return nil, fmt.Errorf("cannot merge %d parts to %s: %w", len(bsrs), dstPartPath, err)
 	}
 	if dstPartPath != "" {
-		ph.MinDedupInterval = GetDedupInterval()
+		// All the samples in the part are older or equal to ph.MaxTimestamp,
+		// so they are de-duplicated with at least the interval for ph.MaxTimestamp.
+		ph.MinDedupInterval = getDedupIntervalForTimestamp(ph.MaxTimestamp, currentTimestamp, GetDedupInterval())
 		ph.MustWriteMetadata(dstPartPath)
 	}
 	return &ph, nil
@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metricsql"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/uint64set"
+)
+
+// RetentionFilter applies the given retention to time series matching the given series filter.
+type RetentionFilter struct {
+	// filter is the original series filter, such as `{env="dev"}`.
+	filter string
+
+	// tfss contains or-delimited tag filters for the filter.
+	tfss []*TagFilters
+
+	// tfsPtrs contains pointers to tag filters from tfss, which can be passed to matchTagFilters.
+	tfsPtrs [][]*tagFilter
+
+	// retentionMsecs is the retention in milliseconds for time series matching the filter.
+	retentionMsecs int64
+}
+
+// String returns string representation of rf in the form `filter:retention`.
+func (rf *RetentionFilter) String() string {
+	return fmt.Sprintf("%s:%s", rf.filter, formatRetentionMsecs(rf.retentionMsecs))
+}
+
+// RetentionMsecs returns the retention in milliseconds for time series matching rf.
+func (rf *RetentionFilter) RetentionMsecs() int64 {
+	return rf.retentionMsecs
+}
+
+// ParseRetentionFilters parses retention filters from a.
+//
+// Every item in a must have the form `filter:retention`, for example, `{env="dev"}:7d`.
+// The returned filters are sorted by retention, so the smallest retention is applied
+// to time series matching multiple filters.
+func ParseRetentionFilters(a []string) ([]*RetentionFilter, error) {
+	var rfs []*RetentionFilter
+	for _, s := range a {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		rf, err := parseRetentionFilter(s)
+		if err != nil {
+			return nil, err
+		}
+		rfs = append(rfs, rf)
+	}
+	sort.SliceStable(rfs, func(i, j int) bool {
+		return rfs[i].retentionMsecs < rfs[j].retentionMsecs
+	})
+	return rfs, nil
+}
+
+func parseRetentionFilter(s string) (*RetentionFilter, error) {
+	// The filter may contain colons, so search for the last colon.
+	n := strings.LastIndexByte(s, ':')
+	if n < 0 {
+		return nil, fmt.Errorf("missing ':' in retention filter %q; it must have the form `filter:retention`", s)
+	}
+	filter := strings.TrimSpace(s[:n])
+	retention, err := promutils.ParseDuration(strings.TrimSpace(s[n+1:]))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse retention in retention filter %q: %w", s, err)
+	}
+	if retention < 24*time.Hour {
+		return nil, fmt.Errorf("retention in retention filter %q cannot be smaller than a day; got %s", s, retention)
+	}
+	expr, err := metricsql.Parse(filter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse series filter in retention filter %q: %w", s, err)
+	}
+	me, ok := expr.(*metricsql.MetricExpr)
+	if !ok {
+		return nil, fmt.Errorf("unexpected series filter in retention filter %q; it must be a series selector such as `{env=\"dev\"}`", s)
+	}
+	rf := &RetentionFilter{
+		filter:         filter,
+		retentionMsecs: retention.Milliseconds(),
+	}
+	for _, lfs := range me.LabelFilterss {
+		tfs := NewTagFilters()
+		for _, lf := range lfs {
+			key := []byte(lf.Label)
+			if lf.Label == "__name__" {
+				key = nil
+			}
+			if err := tfs.Add(key, []byte(lf.Value), lf.IsNegative, lf.IsRegexp); err != nil {
+				return nil, fmt.Errorf("cannot parse label filter %s in retention filter %q: %w", lf.AppendString(nil), s, err)
+			}
+		}
+		if len(tfs.tfs) == 0 {
+			return nil, fmt.Errorf("series filter in retention filter %q matches all the time series; use -retentionPeriod instead", s)
+		}
+		tfsPtrs := make([]*tagFilter, len(tfs.tfs))
+		for i := range tfs.tfs {
+			tfsPtrs[i] = &tfs.tfs[i]
+		}
+		rf.tfss = append(rf.tfss, tfs)
+		rf.tfsPtrs = append(rf.tfsPtrs, tfsPtrs)
+	}
+	return rf, nil
+}
+
+// SetRetentionFilters sets retention filters, which are applied to time series during background merges.
+//
+// Filters must be obtained via ParseRetentionFilters. The retention for time series, which do not match any filter,
+// is set via the retention arg of MustOpenStorage. Filters may contain bigger retention than the retention passed to MustOpenStorage.
+//
+// This function must be called before initializing the storage.
+func SetRetentionFilters(rfs []*RetentionFilter) {
+	globalRetentionFilters = append([]*RetentionFilter{}, rfs...)
+}
+
+var globalRetentionFilters []*RetentionFilter
+
+// getMaxRetentionMsecs returns the maximum retention across the default retention and rfs.
+func getMaxRetentionMsecs(defaultRetentionMsecs int64, rfs []*RetentionFilter) int64 {
+	retentionMsecs := defaultRetentionMsecs
+	for _, rf := range rfs {
+		if rf.retentionMsecs > retentionMsecs {
+			retentionMsecs = rf.retentionMsecs
+		}
+	}
+	return retentionMsecs
+}
+
+func (s *Storage) hasRetentionFilters() bool {
+	return len(s.retentionFilters) > 0
+}
+
+// getRetentionMsecsForMetricID returns the retention in milliseconds for the time series with the given metricID.
+func (s *Storage) getRetentionMsecsForMetricID(metricID uint64) int64 {
+	if idx, ok := s.retentionFiltersCache.get(metricID); ok {
+		return s.getRetentionMsecsForFilterIdx(idx)
+	}
+
+	metricName, ok := s.idb().searchMetricName(nil, metricID, false)
+	if !ok {
+		// Do not cache missing metric name, since it may appear later.
+		// Keep the data for the maximum retention in this case.
+		return s.retentionMsecs
+	}
+	mn := GetMetricName()
+	defer PutMetricName(mn)
+	if err := mn.Unmarshal(metricName); err != nil {
+		logger.Panicf("FATAL: cannot unmarshal metricName %q: %s", metricName, err)
+	}
+	idx := s.getRetentionFilterIdx(mn)
+	s.retentionFiltersCache.set(metricID, idx)
+	return s.getRetentionMsecsForFilterIdx(idx)
+}
+
+func (s *Storage) getRetentionMsecsForFilterIdx(idx int) int64 {
+	if idx < 0 {
+		return s.defaultRetentionMsecs
+	}
+	return s.retentionFilters[idx].retentionMsecs
+}
+
+// getRetentionFilterIdx returns the index of the first retention filter matching mn.
+//
+// -1 is returned if mn doesn't match any retention filter.
+func (s *Storage) getRetentionFilterIdx(mn *MetricName) int {
+	var kb bytesutil.ByteBuffer
+	var tfsBuf []*tagFilter
+	for i, rf := range s.retentionFilters {
+		for _, tfs := range rf.tfsPtrs {
+			// matchTagFilters may re-order the filters, so pass a copy of them,
+			// since it may be called concurrently from multiple merges.
+			tfsBuf = append(tfsBuf[:0], tfs...)
+			ok, err := matchTagFilters(mn, tfsBuf, &kb)
+			if err != nil {
+				logger.Panicf("BUG: cannot match %s against retention filter %s: %s", mn, rf, err)
+			}
+			if ok {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// getRetentionMsecsToEnforce returns retentions in milliseconds smaller than s.retentionMsecs,
+// which must be enforced via background merges.
+//
+// Data outside s.retentionMsecs is removed by dropping the whole partitions.
+func (s *Storage) getRetentionMsecsToEnforce() []int64 {
+	if !s.hasRetentionFilters() {
+		return nil
+	}
+	var a []int64
+	if s.defaultRetentionMsecs < s.retentionMsecs {
+		a = append(a, s.defaultRetentionMsecs)
+	}
+	for _, rf := range s.retentionFilters {
+		if rf.retentionMsecs < s.retentionMsecs {
+			a = append(a, rf.retentionMsecs)
+		}
+	}
+	return a
+}
+
+// retentionFiltersCache holds metricID -> retention filter index entries.
+type retentionFiltersCache struct {
+	mu sync.Mutex
+
+	curr map[uint64]int
+	prev map[uint64]int
+}
+
+// maxRetentionFiltersCacheEntries is the maximum number of entries per generation in retentionFiltersCache.
+const maxRetentionFiltersCacheEntries = 1 << 20
+
+func newRetentionFiltersCache() *retentionFiltersCache {
+	return &retentionFiltersCache{
+		curr: make(map[uint64]int),
+		prev: make(map[uint64]int),
+	}
+}
+
+func (rfc *retentionFiltersCache) get(metricID uint64) (int, bool) {
+	rfc.mu.Lock()
+	defer rfc.mu.Unlock()
+
+	if idx, ok := rfc.curr[metricID]; ok {
+		return idx, true
+	}
+	idx, ok := rfc.prev[metricID]
+	if ok {
+		// Move the entry to the current generation, so it survives the next rotation.
+		rfc.setLocked(metricID, idx)
+	}
+	return idx, ok
+}
+
+func (rfc *retentionFiltersCache) set(metricID uint64, idx int) {
+	rfc.mu.Lock()
+	rfc.setLocked(metricID, idx)
+	rfc.mu.Unlock()
+}
+
+func (rfc *retentionFiltersCache) setLocked(metricID uint64, idx int) {
+	if len(rfc.curr) >= maxRetentionFiltersCacheEntries {
+		rfc.prev = rfc.curr
+		rfc.curr = make(map[uint64]int, len(rfc.prev))
+	}
+	rfc.curr[metricID] = idx
+}
+
+// getSeriesCountByRetention returns the number of time series per each effective retention for the given date.
+//
+// filter limits the set of time series to count if it isn't nil. totalSeries is the total number of matching time series.
+func (is *indexSearch) getSeriesCountByRetention(qt *querytracer.Tracer, filter *uint64set.Set, date, totalSeries uint64, maxMetrics int) ([]TopHeapEntry, error) {
+	s := is.db.s
+	if totalSeries == 0 {
+		return nil, nil
+	}
+	if !s.hasRetentionFilters() {
+		return []TopHeapEntry{{
+			Name:  formatRetentionMsecs(s.defaultRetentionMsecs),
+			Count: totalSeries,
+		}}, nil
+	}
+
+	dmis := s.getDeletedMetricIDs()
+	countByRetention := make(map[int64]uint64)
+	var seen uint64set.Set
+	for _, rf := range s.retentionFilters {
+		metricIDs, err := is.searchMetricIDsWithFiltersOnDate(qt, rf.tfss, date, maxMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("cannot search time series for retention filter %s: %w", rf, err)
+		}
+		if metricIDs == nil {
+			continue
+		}
+		if filter != nil {
+			metricIDs.Intersect(filter)
+		}
+		metricIDs.Subtract(dmis)
+		metricIDs.Subtract(&seen)
+		countByRetention[rf.retentionMsecs] += uint64(metricIDs.Len())
+		seen.Union(metricIDs)
+	}
+	// The totalSeries is an estimation, so make sure the number of time series with the default retention isn't negative.
+	if n := uint64(seen.Len()); n < totalSeries {
+		countByRetention[s.defaultRetentionMsecs] += totalSeries - n
+	}
+
+	a := make([]TopHeapEntry, 0, len(countByRetention))
+	for retentionMsecs, n := range countByRetention {
+		if n == 0 {
+			continue
+		}
+		a = append(a, TopHeapEntry{
+			Name:  formatRetentionMsecs(retentionMsecs),
+			Count: n,
+		})
+	}
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].Count != a[j].Count {
+			return a[i].Count > a[j].Count
+		}
+		return a[i].Name < a[j].Name
+	})
+	return a, nil
+}
+
+// formatRetentionMsecs returns human-readable representation for the given retention in milliseconds.
+func formatRetentionMsecs(retentionMsecs int64) string {
+	const msecsPerHour = 3600 * 1000
+	const msecsPerDay = 24 * msecsPerHour
+	if retentionMsecs%msecsPerDay == 0 {
+		return fmt.Sprintf("%dd", retentionMsecs/msecsPerDay)
+	}
+	if retentionMsecs%msecsPerHour == 0 {
+		return fmt.Sprintf("%dh", retentionMsecs/msecsPerHour)
+	}
+	return time.Duration(retentionMsecs * 1e6).String()
+}
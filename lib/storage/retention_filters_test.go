@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRetentionFiltersSuccess(t *testing.T) {
+	f := func(a []string, resultExpected []string) {
+		t.Helper()
+		rfs, err := ParseRetentionFilters(a)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var result []string
+		for _, rf := range rfs {
+			result = append(result, rf.String())
+		}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected retention filters\ngot\n%q\nwant\n%q", result, resultExpected)
+		}
+	}
+	f(nil, nil)
+	f([]string{""}, nil)
+	f([]string{`{env="dev"}:7d`}, []string{`{env="dev"}:7d`})
+	f([]string{`foo{bar=~"a:b"}:48h`}, []string{`foo{bar=~"a:b"}:2d`})
+
+	// filters are sorted by retention
+	f([]string{`{team="kpi"}:5y`, `{env="dev"}:7d`, `{env="staging"}:30d`}, []string{`{env="dev"}:7d`, `{env="staging"}:30d`, `{team="kpi"}:1825d`})
+
+	// or filters
+	f([]string{`{env="dev" or team="juniors"}:3d`}, []string{`{env="dev" or team="juniors"}:3d`})
+}
+
+func TestParseRetentionFiltersFailure(t *testing.T) {
+	f := func(a []string) {
+		t.Helper()
+		_, err := ParseRetentionFilters(a)
+		if err == nil {
+			t.Fatalf("expecting non-nil error for %q", a)
+		}
+	}
+
+	// missing retention
+	f([]string{`{env="dev"}`})
+
+	// invalid retention
+	f([]string{`{env="dev"}:foo`})
+
+	// too small retention
+	f([]string{`{env="dev"}:1h`})
+
+	// invalid filter
+	f([]string{`{env="dev":7d`})
+
+	// not a series selector
+	f([]string{`sum(foo):7d`})
+
+	// the filter matches all the series
+	f([]string{`{__name__=~".*"}:7d`})
+}
+
+func TestStorageGetRetentionFilterIdx(t *testing.T) {
+	rfs, err := ParseRetentionFilters([]string{`{env="dev"}:3d`, `foo{env=~"dev|prod"}:7d`, `{team="kpi" or instance!=""}:30d`})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s := &Storage{
+		retentionFilters: rfs,
+	}
+
+	f := func(mn *MetricName, idxExpected int) {
+		t.Helper()
+		idx := s.getRetentionFilterIdx(mn)
+		if idx != idxExpected {
+			t.Fatalf("unexpected retention filter index for %s; got %d; want %d", mn, idx, idxExpected)
+		}
+	}
+	f(newTestMetricName("foo"), -1)
+	f(newTestMetricName("foo", "env", "staging"), -1)
+	f(newTestMetricName("foo", "env", "dev"), 0)
+	f(newTestMetricName("bar", "env", "dev", "team", "kpi"), 0)
+	f(newTestMetricName("foo", "env", "prod"), 1)
+	f(newTestMetricName("bar", "env", "prod"), -1)
+	f(newTestMetricName("bar", "team", "kpi"), 2)
+	f(newTestMetricName("bar", "instance", "host:123"), 2)
+}
+
+func newTestMetricName(metricGroup string, tags ...string) *MetricName {
+	mn := &MetricName{
+		MetricGroup: []byte(metricGroup),
+	}
+	for i := 0; i+1 < len(tags); i += 2 {
+		mn.AddTag(tags[i], tags[i+1])
+	}
+	mn.sortTags()
+	return mn
+}
+
+func TestStorageRetentionFilters(t *testing.T) {
+	defer testRemoveAll(t)
+
+	rfs, err := ParseRetentionFilters([]string{`{env="dev"}:2d`, `{team="kpi"}:60d`})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	SetRetentionFilters(rfs)
+	defer SetRetentionFilters(nil)
+
+	s := MustOpenStorage(t.Name(), 30*24*time.Hour, 0, 0)
+	defer s.MustClose()
+
+	if s.retentionMsecs != 60*24*3600*1000 {
+		t.Fatalf("unexpected retentionMsecs; got %d; want %d", s.retentionMsecs, 60*24*3600*1000)
+	}
+
+	now := time.Now().UnixMilli()
+	mns := []*MetricName{
+		newTestMetricName("metric", "env", "dev"),
+		newTestMetricName("metric", "env", "prod"),
+		newTestMetricName("metric", "team", "kpi"),
+	}
+	// Add samples for distinct days in distinct batches, so they are stored in distinct blocks.
+	// Blocks with samples outside the retention are dropped during the merge.
+	for i := 0; i < 10; i++ {
+		var mrs []MetricRow
+		for _, mn := range mns {
+			mrs = append(mrs, MetricRow{
+				MetricNameRaw: mn.marshalRaw(nil),
+				Timestamp:     now - int64(i)*24*3600*1000,
+				Value:         float64(i),
+			})
+		}
+		s.AddRows(mrs, defaultPrecisionBits)
+		s.DebugFlush()
+	}
+	if err := s.ForceMergePartitions(""); err != nil {
+		t.Fatalf("cannot force merge partitions: %s", err)
+	}
+
+	tr := TimeRange{
+		MinTimestamp: now - 30*24*3600*1000,
+		MaxTimestamp: now,
+	}
+	rowsCountExpected := map[string]int{
+		// samples outside 2 days are removed for env="dev"
+		`metric{env="dev"}`: 2,
+		// samples are kept for the default retention
+		`metric{env="prod"}`: 10,
+		// samples are kept for the bigger retention
+		`metric{team="kpi"}`: 10,
+	}
+	rowsCount := testCountRowsPerSeries(t, s, tr)
+	if !reflect.DeepEqual(rowsCount, rowsCountExpected) {
+		t.Fatalf("unexpected rows count per series\ngot\n%v\nwant\n%v", rowsCount, rowsCountExpected)
+	}
+
+	// Verify the effective retention in TSDB status.
+	status, err := s.GetTSDBStatus(nil, nil, 0, "", 10, 1e6, noDeadline)
+	if err != nil {
+		t.Fatalf("cannot obtain TSDB status: %s", err)
+	}
+	seriesCountByRetentionExpected := []TopHeapEntry{
+		{Name: "2d", Count: 1},
+		{Name: "30d", Count: 1},
+		{Name: "60d", Count: 1},
+	}
+	if !reflect.DeepEqual(status.SeriesCountByRetention, seriesCountByRetentionExpected) {
+		t.Fatalf("unexpected SeriesCountByRetention\ngot\n%v\nwant\n%v", status.SeriesCountByRetention, seriesCountByRetentionExpected)
+	}
+}
+
+func TestStorageRetentionFiltersMergeNeeded(t *testing.T) {
+	defer testRemoveAll(t)
+
+	rfs, err := ParseRetentionFilters([]string{`{env="dev"}:2d`})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	SetRetentionFilters(rfs)
+	defer SetRetentionFilters(nil)
+
+	const msecsPerDay = 24 * 3600 * 1000
+	now := time.Now().UnixMilli()
+
+	s := MustOpenStorage(t.Name(), 30*24*time.Hour, 0, 0)
+	mns := []*MetricName{
+		newTestMetricName("metric", "env", "dev"),
+		newTestMetricName("metric", "env", "prod"),
+	}
+	for i := 0; i < 10; i++ {
+		var mrs []MetricRow
+		for _, mn := range mns {
+			mrs = append(mrs, MetricRow{
+				MetricNameRaw: mn.marshalRaw(nil),
+				Timestamp:     now - int64(i)*msecsPerDay,
+				Value:         float64(i),
+			})
+		}
+		s.AddRows(mrs, defaultPrecisionBits)
+		s.DebugFlush()
+	}
+	if err := s.ForceMergePartitions(""); err != nil {
+		t.Fatalf("cannot force merge partitions: %s", err)
+	}
+
+	f := func(s *Storage, currentTimestamp int64, resultExpected bool) {
+		t.Helper()
+		ptws := s.tb.GetPartitions(nil)
+		defer s.tb.PutPartitions(ptws)
+		result := false
+		for _, ptw := range ptws {
+			if ptw.pt.isRetentionFiltersMergeNeeded(currentTimestamp) {
+				result = true
+			}
+		}
+		if result != resultExpected {
+			t.Fatalf("unexpected isRetentionFiltersMergeNeeded result at %d; got %v; want %v", currentTimestamp, result, resultExpected)
+		}
+	}
+
+	// Samples for env="dev" outside the retention are dropped by the merge above,
+	// while the remaining samples expire in 2 days. The merge is needed a day after the expiration.
+	f(s, now, false)
+	f(s, now+msecsPerDay, false)
+	f(s, now+3*msecsPerDay+1, true)
+	s.MustClose()
+
+	// The state must be preserved after the restart.
+	s = MustOpenStorage(t.Name(), 30*24*time.Hour, 0, 0)
+	f(s, now, false)
+	f(s, now+msecsPerDay, false)
+	f(s, now+3*msecsPerDay+1, true)
+	s.MustClose()
+}
+
+func testCountRowsPerSeries(t *testing.T, s *Storage, tr TimeRange) map[string]int {
+	t.Helper()
+	tfs := NewTagFilters()
+	if err := tfs.Add(nil, []byte(".+"), false, true); err != nil {
+		t.Fatalf("unexpected error in TagFilters.Add: %s", err)
+	}
+	var search Search
+	search.Init(nil, s, []*TagFilters{tfs}, tr, 1e5, noDeadline)
+	defer search.MustClose()
+
+	rowsCount := make(map[string]int)
+	var mn MetricName
+	for search.NextMetricBlock() {
+		var b Block
+		search.MetricBlockRef.BlockRef.MustReadBlock(&b)
+		if err := mn.Unmarshal(search.MetricBlockRef.MetricName); err != nil {
+			t.Fatalf("cannot unmarshal metric name: %s", err)
+		}
+		rb := newTestRawBlock(&b, tr)
+		rowsCount[mn.String()] += len(rb.Timestamps)
+	}
+	if err := search.Error(); err != nil {
+		t.Fatalf("search error: %s", err)
+	}
+	return rowsCount
+}
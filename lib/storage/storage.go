@@ -64,10 +64,21 @@ type Storage struct {
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/1401
 	nextRotationTimestamp atomic.Int64
 
-	path           string
-	cachePath      string
+	path      string
+	cachePath string
+
+	// retentionMsecs is the maximum retention across the default retention and retentionFilters.
 	retentionMsecs int64
 
+	// defaultRetentionMsecs is the retention for time series, which do not match retentionFilters.
+	defaultRetentionMsecs int64
+
+	// retentionFilters contains retention filters set via SetRetentionFilters.
+	retentionFilters []*RetentionFilter
+
+	// retentionFiltersCache contains metricID -> retention filter index entries for retentionFilters.
+	retentionFiltersCache *retentionFiltersCache
+
 	// lock file for exclusive access to the storage on the given path.
 	flockF *os.File
 
@@ -163,6 +174,12 @@ type Storage struct {
 
 	// isReadOnly is set to true when the storage is in read-only mode.
 	isReadOnly atomic.Bool
+
+	// metricMetadata contains metric metadata such as HELP, TYPE and UNIT.
+	metricMetadata *metricMetadataStorage
+
+	// exemplars contains the most recent exemplars per each time series.
+	exemplars *exemplarStorage
 }
 
 // MustOpenStorage opens storage on the given path with the given retentionMsecs.
@@ -175,10 +192,13 @@ func MustOpenStorage(path string, retention time.Duration, maxHourlySeries, maxD
 		retention = retentionMax
 	}
 	s := &Storage{
-		path:           path,
-		cachePath:      filepath.Join(path, cacheDirname),
-		retentionMsecs: retention.Milliseconds(),
-		stopCh:         make(chan struct{}),
+		path:                  path,
+		cachePath:             filepath.Join(path, cacheDirname),
+		retentionMsecs:        getMaxRetentionMsecs(retention.Milliseconds(), globalRetentionFilters),
+		defaultRetentionMsecs: retention.Milliseconds(),
+		retentionFilters:      globalRetentionFilters,
+		retentionFiltersCache: newRetentionFiltersCache(),
+		stopCh:                make(chan struct{}),
 	}
 	fs.MustMkdirIfNotExist(path)
 
@@ -239,6 +259,8 @@ func MustOpenStorage(path string, retention time.Duration, maxHourlySeries, maxD
 	isEmptyDB := !fs.IsPathExist(filepath.Join(path, indexdbDirname))
 	fs.MustMkdirIfNotExist(metadataDir)
 	s.minTimestampForCompositeIndex = mustGetMinTimestampForCompositeIndex(metadataDir, isEmptyDB)
+	s.metricMetadata = mustOpenMetricMetadataStorage(filepath.Join(metadataDir, metricMetadataFilename), s.retentionMsecs)
+	s.exemplars = mustOpenExemplarStorage(filepath.Join(metadataDir, exemplarsFilename))
 
 	// Load indexdb
 	idbPath := filepath.Join(path, indexdbDirname)
@@ -375,6 +397,9 @@ func (s *Storage) CreateSnapshot() (string, error) {
 
 	fs.MustSyncPath(dstDataDir)
 
+	// Save metric metadata and exemplars before copying metadata directory, so the snapshot contains the most recent metadata.
+	s.metricMetadata.mustSave()
+	s.exemplars.mustSave()
 	srcMetadataDir := filepath.Join(srcDir, metadataDirname)
 	dstMetadataDir := filepath.Join(dstDir, metadataDirname)
 	fs.MustCopyDirectory(srcMetadataDir, dstMetadataDir)
@@ -548,6 +573,12 @@ type Metrics struct {
 
 	NextRetentionSeconds uint64
 
+	MetricMetadataEntries        uint64
+	MetricMetadataDroppedEntries uint64
+
+	SeriesWithExemplars uint64
+	ExemplarsDropped    uint64
+
 	IndexDBMetrics IndexDBMetrics
 	TableMetrics   TableMetrics
 }
@@ -648,6 +679,12 @@ func (s *Storage) UpdateMetrics(m *Metrics) {
 	}
 	m.NextRetentionSeconds = uint64(d)
 
+	m.MetricMetadataEntries += uint64(s.metricMetadata.EntriesCount())
+	m.MetricMetadataDroppedEntries += s.metricMetadata.droppedEntries.Load()
+
+	m.SeriesWithExemplars += uint64(s.exemplars.SeriesCount())
+	m.ExemplarsDropped += s.exemplars.droppedExemplars.Load()
+
 	s.idb().UpdateMetrics(&m.IndexDBMetrics)
 	s.tb.UpdateMetrics(&m.TableMetrics)
 }
@@ -874,6 +911,8 @@ func (s *Storage) MustClose() {
 
 	s.tb.MustClose()
 	s.idb().MustClose()
+	s.metricMetadata.MustClose()
+	s.exemplars.MustClose()
 
 	// Save caches.
 	s.mustSaveCache(s.tsidCache, "metricName_tsid")
@@ -1610,6 +1649,22 @@ func (mr *MetricRow) UnmarshalX(src []byte) ([]byte, error) {
 	return tail, nil
 }
 
+// AddMetricMetadata adds mms to the storage.
+//
+// mms may refer to buffers owned by the caller, since they are copied if needed.
+func (s *Storage) AddMetricMetadata(mms []MetricMetadata) {
+	s.metricMetadata.Add(mms)
+}
+
+// SearchMetricMetadata returns metric metadata for the given metricFamilyName.
+//
+// Metadata for all the metric families is returned if metricFamilyName is empty.
+// limit limits the number of returned metric families, while limitPerMetric limits the number of entries per each metric family.
+// Non-positive limits mean no limits.
+func (s *Storage) SearchMetricMetadata(metricFamilyName string, limit, limitPerMetric int) []MetricMetadata {
+	return s.metricMetadata.Search(metricFamilyName, limit, limitPerMetric)
+}
+
 // ForceMergePartitions force-merges partitions in s with names starting from the given partitionNamePrefix.
 //
 // Partitions are merged sequentially in order to reduce load on the system.
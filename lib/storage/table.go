@@ -35,9 +35,10 @@ type table struct {
 
 	stopCh chan struct{}
 
-	retentionWatcherWG  sync.WaitGroup
-	finalDedupWatcherWG sync.WaitGroup
-	forceMergeWG        sync.WaitGroup
+	retentionWatcherWG        sync.WaitGroup
+	finalDedupWatcherWG       sync.WaitGroup
+	retentionFiltersWatcherWG sync.WaitGroup
+	forceMergeWG              sync.WaitGroup
 }
 
 // partitionWrapper provides refcounting mechanism for the partition.
@@ -123,6 +124,7 @@ func mustOpenTable(path string, s *Storage) *table {
 	}
 	tb.startRetentionWatcher()
 	tb.startFinalDedupWatcher()
+	tb.startRetentionFiltersWatcher()
 	return tb
 }
 
@@ -177,6 +179,7 @@ func (tb *table) MustClose() {
 	close(tb.stopCh)
 	tb.retentionWatcherWG.Wait()
 	tb.finalDedupWatcherWG.Wait()
+	tb.retentionFiltersWatcherWG.Wait()
 	tb.forceMergeWG.Wait()
 
 	tb.ptwsLock.Lock()
@@ -443,8 +446,8 @@ func (tb *table) startFinalDedupWatcher() {
 }
 
 func (tb *table) finalDedupWatcher() {
-	if !isDedupEnabled() {
-		// Deduplication is disabled.
+	if !isDedupEnabled() && !isDownsamplingEnabled() {
+		// Deduplication and downsampling are disabled.
 		return
 	}
 	f := func() {
@@ -494,6 +497,50 @@ func (tb *table) finalDedupWatcher() {
 	}
 }
 
+func (tb *table) startRetentionFiltersWatcher() {
+	tb.retentionFiltersWatcherWG.Add(1)
+	go func() {
+		tb.retentionFiltersWatcher()
+		tb.retentionFiltersWatcherWG.Done()
+	}()
+}
+
+// retentionFiltersWatcher periodically force-merges partitions with samples outside per-series retentions.
+//
+// Background merges aren't performed for old partitions, so the samples outside per-series retentions
+// would stay there until the whole partition is dropped according to the maximum retention.
+func (tb *table) retentionFiltersWatcher() {
+	if len(tb.s.getRetentionMsecsToEnforce()) == 0 {
+		// There is no need in applying per-series retentions via merges.
+		return
+	}
+	f := func() {
+		ptws := tb.GetPartitions(nil)
+		defer tb.PutPartitions(ptws)
+		currentTimestamp := timestampFromTime(time.Now())
+		for _, ptw := range ptws {
+			if !ptw.pt.isRetentionFiltersMergeNeeded(currentTimestamp) {
+				continue
+			}
+			if err := ptw.pt.runRetentionFiltersMerge(tb.stopCh); err != nil {
+				logger.Errorf("cannot apply retention filters to partition %s: %s", ptw.pt.name, err)
+			}
+		}
+	}
+
+	d := timeutil.AddJitterToDuration(time.Hour)
+	t := time.NewTicker(d)
+	defer t.Stop()
+	for {
+		select {
+		case <-tb.stopCh:
+			return
+		case <-t.C:
+			f()
+		}
+	}
+}
+
 // GetPartitions appends tb's partitions snapshot to dst and returns the result.
 //
 // The returned partitions must be passed to PutPartitions
@@ -0,0 +1,208 @@
+package streamaggr
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ddsketchBucketLabel is the label name for ddsketch buckets emitted by quantiles_sketch output.
+const ddsketchBucketLabel = "vmrange"
+
+// ddsketchRelativeAccuracy is the relative accuracy for quantiles returned by ddsketch.
+const ddsketchRelativeAccuracy = 0.01
+
+// ddsketchMinIndexableValue is the minimum absolute value, which can be stored in ddsketch buckets.
+//
+// Values with smaller absolute values are counted in the zero bucket.
+const ddsketchMinIndexableValue = 1e-9
+
+var (
+	ddsketchGamma    = (1 + ddsketchRelativeAccuracy) / (1 - ddsketchRelativeAccuracy)
+	ddsketchLogGamma = math.Log(ddsketchGamma)
+)
+
+// ddsketch is a mergeable sketch for quantiles' estimation with relative accuracy guarantees.
+//
+// See https://arxiv.org/abs/1908.10693
+//
+// Every bucket in the sketch can be represented as VictoriaMetrics histogram bucket with `vmrange` label,
+// so the sketch can be merged either by another ddsketch or by histogram_quantile() function at VictoriaMetrics.
+type ddsketch struct {
+	positive  map[int]float64
+	negative  map[int]float64
+	zeroCount float64
+}
+
+func newDDSketch() *ddsketch {
+	return &ddsketch{
+		positive: make(map[int]float64),
+		negative: make(map[int]float64),
+	}
+}
+
+// add adds v to sk.
+func (sk *ddsketch) add(v float64) {
+	if math.IsInf(v, 0) {
+		// Infinite values cannot be mapped to buckets.
+		return
+	}
+	switch {
+	case v >= ddsketchMinIndexableValue:
+		sk.positive[ddsketchIndex(v)]++
+	case v <= -ddsketchMinIndexableValue:
+		sk.negative[ddsketchIndex(-v)]++
+	default:
+		sk.zeroCount++
+	}
+}
+
+// count returns the number of values added to sk.
+func (sk *ddsketch) count() float64 {
+	n := sk.zeroCount
+	for _, c := range sk.positive {
+		n += c
+	}
+	for _, c := range sk.negative {
+		n += c
+	}
+	return n
+}
+
+// quantiles appends quantiles for the given phis to dst and returns the result.
+//
+// NaN is returned for all the phis if sk is empty.
+func (sk *ddsketch) quantiles(dst, phis []float64) []float64 {
+	total := sk.count()
+	if total <= 0 {
+		for range phis {
+			dst = append(dst, math.NaN())
+		}
+		return dst
+	}
+
+	negativeIdxs := sortedBucketIdxs(sk.negative)
+	positiveIdxs := sortedBucketIdxs(sk.positive)
+	for _, phi := range phis {
+		dst = append(dst, sk.quantile(phi, total, negativeIdxs, positiveIdxs))
+	}
+	return dst
+}
+
+func (sk *ddsketch) quantile(phi, total float64, negativeIdxs, positiveIdxs []int) float64 {
+	rank := phi * (total - 1)
+	n := float64(0)
+	// Negative values are visited from the biggest absolute values to the smallest ones.
+	for i := len(negativeIdxs) - 1; i >= 0; i-- {
+		idx := negativeIdxs[i]
+		n += sk.negative[idx]
+		if n > rank {
+			return -ddsketchValue(idx)
+		}
+	}
+	n += sk.zeroCount
+	if n > rank {
+		return 0
+	}
+	for _, idx := range positiveIdxs {
+		n += sk.positive[idx]
+		if n > rank {
+			return ddsketchValue(idx)
+		}
+	}
+	// This may happen because of float rounding errors.
+	if len(positiveIdxs) > 0 {
+		return ddsketchValue(positiveIdxs[len(positiveIdxs)-1])
+	}
+	if sk.zeroCount > 0 {
+		return 0
+	}
+	return -ddsketchValue(negativeIdxs[0])
+}
+
+// visitBuckets calls f for every non-empty bucket in sk in ascending order of bucket ranges.
+//
+// vmrange passed to f contains VictoriaMetrics histogram bucket range for the given bucket.
+func (sk *ddsketch) visitBuckets(f func(vmrange string, count float64)) {
+	negativeIdxs := sortedBucketIdxs(sk.negative)
+	for i := len(negativeIdxs) - 1; i >= 0; i-- {
+		idx := negativeIdxs[i]
+		lower, upper := ddsketchBucketBounds(idx)
+		f(formatVMRange(-upper, -lower), sk.negative[idx])
+	}
+	if sk.zeroCount > 0 {
+		f("0...0", sk.zeroCount)
+	}
+	for _, idx := range sortedBucketIdxs(sk.positive) {
+		lower, upper := ddsketchBucketBounds(idx)
+		f(formatVMRange(lower, upper), sk.positive[idx])
+	}
+}
+
+// addBucket adds count to the bucket with the given vmrange.
+//
+// vmrange must be obtained from visitBuckets.
+func (sk *ddsketch) addBucket(vmrange string, count float64) error {
+	n := strings.Index(vmrange, "...")
+	if n < 0 {
+		return fmt.Errorf("missing `...` in vmrange=%q", vmrange)
+	}
+	lower, err := strconv.ParseFloat(vmrange[:n], 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse lower bound at vmrange=%q: %w", vmrange, err)
+	}
+	upper, err := strconv.ParseFloat(vmrange[n+len("..."):], 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse upper bound at vmrange=%q: %w", vmrange, err)
+	}
+	if math.IsNaN(count) || count < 0 {
+		return fmt.Errorf("unexpected count=%v for vmrange=%q; it must be non-negative", count, vmrange)
+	}
+	switch {
+	case lower == 0 && upper == 0:
+		sk.zeroCount += count
+	case lower > 0 && upper > lower:
+		sk.positive[ddsketchIndexFromUpperBound(upper)] += count
+	case upper < 0 && upper > lower:
+		sk.negative[ddsketchIndexFromUpperBound(-lower)] += count
+	default:
+		return fmt.Errorf("unexpected vmrange=%q", vmrange)
+	}
+	return nil
+}
+
+func ddsketchIndex(v float64) int {
+	return int(math.Ceil(math.Log(v) / ddsketchLogGamma))
+}
+
+// ddsketchIndexFromUpperBound returns bucket index for the given upper bound of the bucket.
+//
+// The upper bound may be rounded when formatting vmrange, so the index is rounded to the nearest integer.
+func ddsketchIndexFromUpperBound(upper float64) int {
+	return int(math.Round(math.Log(upper) / ddsketchLogGamma))
+}
+
+func ddsketchBucketBounds(idx int) (float64, float64) {
+	upper := math.Pow(ddsketchGamma, float64(idx))
+	return upper / ddsketchGamma, upper
+}
+
+// ddsketchValue returns the value with the minimum relative error for all the values in the bucket with the given idx.
+func ddsketchValue(idx int) float64 {
+	return 2 * math.Pow(ddsketchGamma, float64(idx)) / (ddsketchGamma + 1)
+}
+
+func formatVMRange(lower, upper float64) string {
+	return fmt.Sprintf("%.3e...%.3e", lower, upper)
+}
+
+func sortedBucketIdxs(m map[int]float64) []int {
+	idxs := make([]int, 0, len(m))
+	for idx := range m {
+		idxs = append(idxs, idx)
+	}
+	slices.Sort(idxs)
+	return idxs
+}
@@ -0,0 +1,153 @@
+package streamaggr
+
+import (
+	"math"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/cespare/xxhash/v2"
+)
+
+// distinctSketchAggrState calculates output=count_series_sketch and output=unique_samples_sketch
+// e.g. the estimated number of unique series or unique sample values with hyperloglog.
+//
+// If emitSketches is set, then hyperloglog registers are emitted instead of the estimated count.
+// If mergeSketches is set, then input samples are treated as hyperloglog registers emitted by another *_sketch output.
+type distinctSketchAggrState struct {
+	m sync.Map
+
+	// suffix is the output suffix
+	suffix string
+
+	// countSeries is set for count_series_sketch output, which counts unique input series.
+	// Otherwise unique sample values are counted.
+	countSeries bool
+
+	emitSketches  bool
+	mergeSketches bool
+}
+
+type distinctSketchStateValue struct {
+	mu      sync.Mutex
+	hll     *hyperloglog
+	deleted bool
+}
+
+func newCountSeriesSketchAggrState(emitSketches, mergeSketches bool) *distinctSketchAggrState {
+	return &distinctSketchAggrState{
+		suffix:        "count_series_sketch",
+		countSeries:   true,
+		emitSketches:  emitSketches,
+		mergeSketches: mergeSketches,
+	}
+}
+
+func newUniqueSamplesSketchAggrState(emitSketches, mergeSketches bool) *distinctSketchAggrState {
+	return &distinctSketchAggrState{
+		suffix:        "unique_samples_sketch",
+		emitSketches:  emitSketches,
+		mergeSketches: mergeSketches,
+	}
+}
+
+func (as *distinctSketchAggrState) pushSamples(samples []pushSample) {
+	var labels []prompbmarshal.Label
+	for i := range samples {
+		s := &samples[i]
+		inputKey, outputKey := getInputOutputKey(s.key)
+
+		var groupIdx string
+		var h uint64
+		if as.mergeSketches {
+			labels, groupIdx = getSketchLabelValue(labels[:0], inputKey, hllRegistersLabel)
+			if groupIdx == "" {
+				// Skip samples without hyperloglog registers.
+				continue
+			}
+		} else if as.countSeries {
+			h = xxhash.Sum64(bytesutil.ToUnsafeBytes(inputKey))
+		} else {
+			h = hashFloat64(s.value)
+		}
+
+	again:
+		v, ok := as.m.Load(outputKey)
+		if !ok {
+			// The entry is missing in the map. Try creating it.
+			v = &distinctSketchStateValue{
+				hll: newHyperLogLog(),
+			}
+			outputKey = bytesutil.InternString(outputKey)
+			vNew, loaded := as.m.LoadOrStore(outputKey, v)
+			if loaded {
+				// Use the entry created by a concurrent goroutine.
+				v = vNew
+			}
+		}
+		sv := v.(*distinctSketchStateValue)
+		sv.mu.Lock()
+		deleted := sv.deleted
+		if !deleted {
+			if as.mergeSketches {
+				// Invalid registers are silently skipped, since they cannot be merged.
+				_ = sv.hll.addGroup(groupIdx, s.value)
+			} else {
+				sv.hll.add(h)
+			}
+		}
+		sv.mu.Unlock()
+		if deleted {
+			// The entry has been deleted by the concurrent call to flushState
+			// Try obtaining and updating the entry again.
+			goto again
+		}
+	}
+}
+
+func (as *distinctSketchAggrState) flushState(ctx *flushCtx) {
+	m := &as.m
+	m.Range(func(k, v any) bool {
+		// Atomically delete the entry from the map, so new entry is created for the next flush.
+		m.Delete(k)
+
+		sv := v.(*distinctSketchStateValue)
+		sv.mu.Lock()
+		// Mark the entry as deleted, so it won't be updated anymore by concurrent pushSample() calls.
+		sv.deleted = true
+		sv.mu.Unlock()
+
+		key := k.(string)
+		if as.emitSketches {
+			sv.hll.visitGroups(func(groupIdx int, value float64) {
+				ctx.appendSeriesWithExtraLabel(key, as.suffix, value, hllRegistersLabel, hllGroupLabelValues[groupIdx])
+			})
+			return true
+		}
+		ctx.appendSeries(key, as.suffix, sv.hll.estimate())
+		return true
+	})
+}
+
+// getSketchLabelValue returns the value for the label with the given labelName from the compressed inputKey.
+//
+// It uses dst as a temporary buffer for decompressed labels and returns it for the reuse.
+func getSketchLabelValue(dst []prompbmarshal.Label, inputKey, labelName string) ([]prompbmarshal.Label, string) {
+	dst = decompressLabels(dst, inputKey)
+	for _, label := range dst {
+		if label.Name == labelName {
+			return dst, label.Value
+		}
+	}
+	return dst, ""
+}
+
+func hashFloat64(v float64) uint64 {
+	// Hash the bits of v instead of using them as is, since hyperloglog requires uniformly distributed hashes.
+	var b [8]byte
+	n := math.Float64bits(v)
+	for i := range b {
+		b[i] = byte(n >> (8 * i))
+	}
+	return xxhash.Sum64(b[:])
+}
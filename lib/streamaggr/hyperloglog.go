@@ -0,0 +1,164 @@
+package streamaggr
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+)
+
+const (
+	// hllRegistersLabel is the label name for hyperloglog registers emitted by count_series_sketch and unique_samples_sketch outputs.
+	hllRegistersLabel = "hll_registers"
+
+	// hllPrecision is the number of bits from the hash used for register index.
+	//
+	// It gives the standard error of 1.04/sqrt(2^hllPrecision) ~ 1.6% for the estimated cardinality.
+	hllPrecision = 12
+
+	hllRegistersCount = 1 << hllPrecision
+
+	// hllRegisterBits is the number of bits needed for storing the maximum register value, which equals to 64-hllPrecision+1.
+	hllRegisterBits = 6
+
+	// hllRegistersPerGroup is the number of registers packed into a single float64 value when emitting the sketch.
+	//
+	// 8*6=48 bits fit the 53-bit mantissa of float64, so the packed value is represented exactly.
+	hllRegistersPerGroup = 8
+
+	hllGroupsCount = hllRegistersCount / hllRegistersPerGroup
+
+	// hllSparseMaxLen is the maximum number of unique hashes to store in the sparse representation.
+	//
+	// The exact count is returned while the number of unique hashes doesn't exceed this value.
+	hllSparseMaxLen = 256
+)
+
+// hllGroupLabelValues contains pre-formatted values for `hll_registers` label.
+var hllGroupLabelValues = func() []string {
+	a := make([]string, hllGroupsCount)
+	for i := range a {
+		a[i] = strconv.Itoa(i)
+	}
+	return a
+}()
+
+// hyperloglog is a mergeable sketch for cardinality estimation.
+//
+// See http://algo.inria.fr/flajolet/Publications/FlFuGaMe07.pdf
+//
+// The sketch starts with the exact sparse representation and switches to the dense representation
+// when the number of unique hashes exceeds hllSparseMaxLen.
+type hyperloglog struct {
+	sparse    map[uint64]struct{}
+	registers []uint8
+}
+
+func newHyperLogLog() *hyperloglog {
+	return &hyperloglog{
+		sparse: make(map[uint64]struct{}),
+	}
+}
+
+// add adds the hash h to hll.
+func (hll *hyperloglog) add(h uint64) {
+	if hll.registers != nil {
+		hllUpdateRegister(hll.registers, h)
+		return
+	}
+	hll.sparse[h] = struct{}{}
+	if len(hll.sparse) > hllSparseMaxLen {
+		hll.convertToDense()
+	}
+}
+
+func (hll *hyperloglog) convertToDense() {
+	registers := make([]uint8, hllRegistersCount)
+	for h := range hll.sparse {
+		hllUpdateRegister(registers, h)
+	}
+	hll.registers = registers
+	hll.sparse = nil
+}
+
+// estimate returns the estimated number of unique hashes added to hll.
+func (hll *hyperloglog) estimate() float64 {
+	if hll.registers == nil {
+		return float64(len(hll.sparse))
+	}
+
+	m := float64(hllRegistersCount)
+	sum := float64(0)
+	zeros := 0
+	for _, r := range hll.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		// Use linear counting for small cardinalities.
+		e = m * math.Log(m/float64(zeros))
+	}
+	return math.Round(e)
+}
+
+// visitGroups calls f for every group of registers with non-zero values.
+//
+// groupIdx is in the range [0 ... hllGroupsCount), while value contains hllRegistersPerGroup registers packed into float64.
+func (hll *hyperloglog) visitGroups(f func(groupIdx int, value float64)) {
+	registers := hll.registers
+	if registers == nil {
+		registers = make([]uint8, hllRegistersCount)
+		for h := range hll.sparse {
+			hllUpdateRegister(registers, h)
+		}
+	}
+	for groupIdx := 0; groupIdx < hllGroupsCount; groupIdx++ {
+		group := registers[groupIdx*hllRegistersPerGroup : (groupIdx+1)*hllRegistersPerGroup]
+		packed := uint64(0)
+		for i, r := range group {
+			packed |= uint64(r) << (i * hllRegisterBits)
+		}
+		if packed != 0 {
+			f(groupIdx, float64(packed))
+		}
+	}
+}
+
+// addGroup merges the group of registers obtained from visitGroups into hll.
+func (hll *hyperloglog) addGroup(groupIdxStr string, value float64) error {
+	groupIdx, err := strconv.Atoi(groupIdxStr)
+	if err != nil {
+		return fmt.Errorf("cannot parse group index %q: %w", groupIdxStr, err)
+	}
+	if groupIdx < 0 || groupIdx >= hllGroupsCount {
+		return fmt.Errorf("group index must be in the range [0..%d); got %d", hllGroupsCount, groupIdx)
+	}
+	if value < 0 || value >= (1<<(hllRegistersPerGroup*hllRegisterBits)) || value != math.Trunc(value) {
+		return fmt.Errorf("unexpected value for registers group %d: %v", groupIdx, value)
+	}
+	if hll.registers == nil {
+		hll.convertToDense()
+	}
+	packed := uint64(value)
+	group := hll.registers[groupIdx*hllRegistersPerGroup : (groupIdx+1)*hllRegistersPerGroup]
+	for i := range group {
+		r := uint8((packed >> (i * hllRegisterBits)) & (1<<hllRegisterBits - 1))
+		if r > group[i] {
+			group[i] = r
+		}
+	}
+	return nil
+}
+
+func hllUpdateRegister(registers []uint8, h uint64) {
+	idx := h >> (64 - hllPrecision)
+	w := h<<hllPrecision | (1 << (hllPrecision - 1))
+	r := uint8(bits.LeadingZeros64(w) + 1)
+	if r > registers[idx] {
+		registers[idx] = r
+	}
+}
@@ -0,0 +1,122 @@
+package streamaggr
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+// quantilesSketchAggrState calculates output=quantiles_sketch, e.g. the given quantiles over the input samples with ddsketch.
+//
+// If emitSketches is set, then ddsketch buckets are emitted instead of quantiles.
+// If mergeSketches is set, then input samples are treated as ddsketch buckets emitted by another quantiles_sketch output.
+type quantilesSketchAggrState struct {
+	m sync.Map
+
+	phis []float64
+
+	emitSketches  bool
+	mergeSketches bool
+}
+
+type quantilesSketchStateValue struct {
+	mu      sync.Mutex
+	sk      *ddsketch
+	deleted bool
+}
+
+func newQuantilesSketchAggrState(phis []float64, emitSketches, mergeSketches bool) *quantilesSketchAggrState {
+	return &quantilesSketchAggrState{
+		phis:          phis,
+		emitSketches:  emitSketches,
+		mergeSketches: mergeSketches,
+	}
+}
+
+func (as *quantilesSketchAggrState) pushSamples(samples []pushSample) {
+	var labels []prompbmarshal.Label
+	for i := range samples {
+		s := &samples[i]
+		inputKey, outputKey := getInputOutputKey(s.key)
+
+		var vmrange string
+		if as.mergeSketches {
+			labels, vmrange = getSketchLabelValue(labels[:0], inputKey, ddsketchBucketLabel)
+			if vmrange == "" {
+				// Skip samples without ddsketch buckets.
+				continue
+			}
+		}
+
+	again:
+		v, ok := as.m.Load(outputKey)
+		if !ok {
+			// The entry is missing in the map. Try creating it.
+			v = &quantilesSketchStateValue{
+				sk: newDDSketch(),
+			}
+			outputKey = bytesutil.InternString(outputKey)
+			vNew, loaded := as.m.LoadOrStore(outputKey, v)
+			if loaded {
+				// Use the entry created by a concurrent goroutine.
+				v = vNew
+			}
+		}
+		sv := v.(*quantilesSketchStateValue)
+		sv.mu.Lock()
+		deleted := sv.deleted
+		if !deleted {
+			if as.mergeSketches {
+				// Invalid buckets are silently skipped, since they cannot be merged.
+				_ = sv.sk.addBucket(vmrange, s.value)
+			} else {
+				sv.sk.add(s.value)
+			}
+		}
+		sv.mu.Unlock()
+		if deleted {
+			// The entry has been deleted by the concurrent call to flushState
+			// Try obtaining and updating the entry again.
+			goto again
+		}
+	}
+}
+
+func (as *quantilesSketchAggrState) flushState(ctx *flushCtx) {
+	m := &as.m
+	phis := as.phis
+	var quantiles []float64
+	var b []byte
+	m.Range(func(k, v any) bool {
+		// Atomically delete the entry from the map, so new entry is created for the next flush.
+		m.Delete(k)
+
+		sv := v.(*quantilesSketchStateValue)
+		sv.mu.Lock()
+		// Mark the entry as deleted, so it won't be updated anymore by concurrent pushSample() calls.
+		sv.deleted = true
+		sv.mu.Unlock()
+
+		key := k.(string)
+		if as.emitSketches {
+			sv.sk.visitBuckets(func(vmrange string, count float64) {
+				vmrange = bytesutil.InternString(vmrange)
+				ctx.appendSeriesWithExtraLabel(key, "quantiles_sketch", count, ddsketchBucketLabel, vmrange)
+			})
+			return true
+		}
+		if sv.sk.count() <= 0 {
+			// Nothing to flush, since all the merged buckets were invalid.
+			return true
+		}
+		quantiles = sv.sk.quantiles(quantiles[:0], phis)
+		for i, quantile := range quantiles {
+			b = strconv.AppendFloat(b[:0], phis[i], 'g', -1, 64)
+			phiStr := bytesutil.InternBytes(b)
+			ctx.appendSeriesWithExtraLabel(key, "quantiles_sketch", quantile, "quantile", phiStr)
+		}
+		return true
+	})
+}
@@ -0,0 +1,146 @@
+package streamaggr
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestDDSketchQuantiles(t *testing.T) {
+	f := func(values []float64) {
+		t.Helper()
+
+		sk := newDDSketch()
+		for _, v := range values {
+			sk.add(v)
+		}
+
+		// Merge the sketch via its buckets
+		skMerged := newDDSketch()
+		sk.visitBuckets(func(vmrange string, count float64) {
+			if err := skMerged.addBucket(vmrange, count); err != nil {
+				t.Fatalf("cannot add bucket: %s", err)
+			}
+		})
+
+		sorted := append([]float64{}, values...)
+		slices.Sort(sorted)
+		phis := []float64{0, 0.1, 0.5, 0.9, 0.99, 1}
+		quantiles := sk.quantiles(nil, phis)
+		quantilesMerged := skMerged.quantiles(nil, phis)
+		for i, phi := range phis {
+			expected := sorted[int(phi*float64(len(sorted)-1))]
+			if err := math.Abs(quantiles[i] - expected); err > math.Abs(expected)*ddsketchRelativeAccuracy {
+				t.Fatalf("too big error for phi=%v; got %v; want %v", phi, quantiles[i], expected)
+			}
+			if quantilesMerged[i] != quantiles[i] {
+				t.Fatalf("unexpected quantile for phi=%v after merging; got %v; want %v", phi, quantilesMerged[i], quantiles[i])
+			}
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+
+	// positive values
+	values := make([]float64, 10_000)
+	for i := range values {
+		values[i] = r.ExpFloat64() * 1000
+	}
+	f(values)
+
+	// mixed values
+	for i := range values {
+		values[i] = r.NormFloat64() * 100
+	}
+	values[0] = 0
+	f(values)
+
+	// a single value
+	f([]float64{-1.5})
+}
+
+func TestDDSketchAddBucketFailure(t *testing.T) {
+	f := func(vmrange string, count float64) {
+		t.Helper()
+
+		sk := newDDSketch()
+		if err := sk.addBucket(vmrange, count); err == nil {
+			t.Fatalf("expecting non-nil error for vmrange=%q, count=%v", vmrange, count)
+		}
+	}
+
+	f("", 1)
+	f("foo...bar", 1)
+	f("1...foo", 1)
+	f("2...1", 1)
+	f("-1...1", 1)
+	f("1...2", -1)
+	f("1...2", math.NaN())
+}
+
+func TestHyperLogLogEstimate(t *testing.T) {
+	f := func(n int) {
+		t.Helper()
+
+		// Split the hashes among multiple sketches and merge them via registers groups
+		hlls := []*hyperloglog{newHyperLogLog(), newHyperLogLog(), newHyperLogLog()}
+		for i := 0; i < n; i++ {
+			h := hashFloat64(float64(i))
+			hlls[i%len(hlls)].add(h)
+			// Duplicate hashes mustn't be counted
+			hlls[(i+1)%len(hlls)].add(h)
+		}
+		hllMerged := newHyperLogLog()
+		for _, hll := range hlls {
+			hll.visitGroups(func(groupIdx int, value float64) {
+				if err := hllMerged.addGroup(hllGroupLabelValues[groupIdx], value); err != nil {
+					t.Fatalf("cannot add registers group: %s", err)
+				}
+			})
+		}
+
+		estimate := hllMerged.estimate()
+		if err := math.Abs(estimate - float64(n)); err > 0.05*float64(n) {
+			t.Fatalf("too big error for n=%d; got %v", n, estimate)
+		}
+	}
+
+	f(1)
+	f(10)
+	f(300)
+	f(10_000)
+	f(1_000_000)
+}
+
+func TestHyperLogLogSparse(t *testing.T) {
+	hll := newHyperLogLog()
+	for i := 0; i < hllSparseMaxLen; i++ {
+		hll.add(uint64(i))
+		hll.add(uint64(i))
+	}
+	if n := hll.estimate(); n != hllSparseMaxLen {
+		t.Fatalf("unexpected estimate for the sparse sketch; got %v; want %v", n, hllSparseMaxLen)
+	}
+	if hll.registers != nil {
+		t.Fatalf("the sketch mustn't be converted to dense representation")
+	}
+}
+
+func TestHyperLogLogAddGroupFailure(t *testing.T) {
+	f := func(groupIdx string, value float64) {
+		t.Helper()
+
+		hll := newHyperLogLog()
+		if err := hll.addGroup(groupIdx, value); err == nil {
+			t.Fatalf("expecting non-nil error for groupIdx=%q, value=%v", groupIdx, value)
+		}
+	}
+
+	f("foo", 1)
+	f("-1", 1)
+	f("512", 1)
+	f("0", -1)
+	f("0", 1.5)
+	f("0", 1<<48)
+}
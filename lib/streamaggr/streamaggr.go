@@ -30,6 +30,7 @@ var supportedOutputs = []string{
 	"avg",
 	"count_samples",
 	"count_series",
+	"count_series_sketch",
 	"histogram_bucket",
 	"increase",
 	"increase_prometheus",
@@ -37,6 +38,7 @@ var supportedOutputs = []string{
 	"max",
 	"min",
 	"quantiles(phi1, ..., phiN)",
+	"quantiles_sketch(phi1, ..., phiN)",
 	"rate_avg",
 	"rate_sum",
 	"stddev",
@@ -45,6 +47,7 @@ var supportedOutputs = []string{
 	"total",
 	"total_prometheus",
 	"unique_samples",
+	"unique_samples_sketch",
 }
 
 var (
@@ -186,6 +189,7 @@ type Config struct {
 	// - avg - the average value across all the samples
 	// - count_samples - counts the input samples
 	// - count_series - counts the number of unique input series
+	// - count_series_sketch - estimates the number of unique input series with HyperLogLog sketch
 	// - histogram_bucket - creates VictoriaMetrics histogram for input samples
 	// - increase - calculates the increase over input series
 	// - increase_prometheus - calculates the increase over input series, ignoring the first sample in new time series
@@ -193,6 +197,7 @@ type Config struct {
 	// - max - the maximum sample value
 	// - min - the minimum sample value
 	// - quantiles(phi1, ..., phiN) - quantiles' estimation for phi in the range [0..1]
+	// - quantiles_sketch(phi1, ..., phiN) - quantiles' estimation for phi in the range [0..1] with DDSketch
 	// - rate_avg - calculates average of rate for input counters
 	// - rate_sum - calculates sum of rate for input counters
 	// - stddev - standard deviation across all the samples
@@ -201,6 +206,9 @@ type Config struct {
 	// - total - aggregates input counters
 	// - total_prometheus - aggregates input counters, ignoring the first sample in new time series
 	// - unique_samples - counts the number of unique sample values
+	// - unique_samples_sketch - estimates the number of unique sample values with HyperLogLog sketch
+	//
+	// Outputs with _sketch suffix can be merged across multiple aggregators. See EmitSketches and MergeSketches.
 	//
 	// The output time series will have the following names by default:
 	//
@@ -213,6 +221,19 @@ type Config struct {
 	// KeepMetricNames instructs to leave metric names as is for the output time series without adding any suffix.
 	KeepMetricNames *bool `yaml:"keep_metric_names,omitempty"`
 
+	// EmitSketches instructs emitting serialized sketches instead of estimations for outputs with _sketch suffix.
+	//
+	// quantiles_sketch emits DDSketch buckets with `vmrange` label, so they can be merged either by another aggregator
+	// with MergeSketches option or by histogram_quantile() function at VictoriaMetrics.
+	// count_series_sketch and unique_samples_sketch emit HyperLogLog registers with `hll_registers` label,
+	// so they can be merged by another aggregator with MergeSketches option.
+	EmitSketches bool `yaml:"emit_sketches,omitempty"`
+
+	// MergeSketches instructs treating input samples as serialized sketches emitted by aggregators with EmitSketches option.
+	//
+	// All the Outputs must have _sketch suffix if this option is set.
+	MergeSketches bool `yaml:"merge_sketches,omitempty"`
+
 	// IgnoreOldSamples instructs to ignore samples with old timestamps outside the current aggregation interval.
 	IgnoreOldSamples *bool `yaml:"ignore_old_samples,omitempty"`
 
@@ -525,6 +546,22 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 		by = addMissingUnderscoreName(by)
 	}
 
+	// check cfg.MergeSketches
+	outputWithout := without
+	if cfg.MergeSketches {
+		for _, output := range cfg.Outputs {
+			if !isSketchOutput(output) {
+				return nil, fmt.Errorf("`merge_sketches` can be applied only to outputs with _sketch suffix; got `outputs: %q`; "+
+					"see https://docs.victoriametrics.com/stream-aggregation/#sketches", cfg.Outputs)
+			}
+		}
+		if len(by) == 0 {
+			// Sketch labels must be excluded from the output labels, so sketches from distinct input series are merged.
+			outputWithout = sortAndRemoveDuplicates(append(append([]string{}, without...), sketchLabels...))
+			aggregateOnlyByTime = false
+		}
+	}
+
 	// check cfg.KeepMetricNames
 	keepMetricNames := opts.KeepMetricNames
 	if v := cfg.KeepMetricNames; v != nil {
@@ -539,7 +576,8 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 			return nil, fmt.Errorf("`outputs` list must contain only a single entry if `keep_metric_names` is set; got %q; "+
 				"see https://docs.victoriametrics.com/stream-aggregation/#output-metric-names", cfg.Outputs)
 		}
-		if cfg.Outputs[0] == "histogram_bucket" || strings.HasPrefix(cfg.Outputs[0], "quantiles(") && strings.Contains(cfg.Outputs[0], ",") {
+		if cfg.Outputs[0] == "histogram_bucket" || cfg.EmitSketches && isSketchOutput(cfg.Outputs[0]) ||
+			(strings.HasPrefix(cfg.Outputs[0], "quantiles(") || strings.HasPrefix(cfg.Outputs[0], "quantiles_sketch(")) && strings.Contains(cfg.Outputs[0], ",") {
 			return nil, fmt.Errorf("`keep_metric_names` cannot be applied to `outputs: %q`, since they can generate multiple time series; "+
 				"see https://docs.victoriametrics.com/stream-aggregation/#output-metric-names", cfg.Outputs)
 		}
@@ -572,7 +610,7 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 	aggrOutputs := make([]aggrOutput, len(cfg.Outputs))
 	outputsSeen := make(map[string]struct{}, len(cfg.Outputs))
 	for i, output := range cfg.Outputs {
-		as, err := newAggrState(output, outputsSeen, stalenessInterval, ignoreFirstSampleInterval)
+		as, err := newAggrState(output, outputsSeen, stalenessInterval, ignoreFirstSampleInterval, cfg.EmitSketches, cfg.MergeSketches)
 		if err != nil {
 			return nil, err
 		}
@@ -605,7 +643,7 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 		ignoreOldSamples: ignoreOldSamples,
 
 		by:                  by,
-		without:             without,
+		without:             outputWithout,
 		aggregateOnlyByTime: aggregateOnlyByTime,
 
 		interval:      interval,
@@ -660,7 +698,8 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 	return a, nil
 }
 
-func newAggrState(output string, outputsSeen map[string]struct{}, stalenessInterval, ignoreFirstSampleInterval time.Duration) (aggrState, error) {
+func newAggrState(output string, outputsSeen map[string]struct{}, stalenessInterval, ignoreFirstSampleInterval time.Duration,
+	emitSketches, mergeSketches bool) (aggrState, error) {
 	// check for duplicated output
 	if _, ok := outputsSeen[output]; ok {
 		return nil, fmt.Errorf("`outputs` list contains duplicate aggregation function: %s", output)
@@ -668,32 +707,19 @@ func newAggrState(output string, outputsSeen map[string]struct{}, stalenessInter
 	outputsSeen[output] = struct{}{}
 
 	if strings.HasPrefix(output, "quantiles(") {
-		if !strings.HasSuffix(output, ")") {
-			return nil, fmt.Errorf("missing closing brace for `quantiles()` output")
-		}
-		argsStr := output[len("quantiles(") : len(output)-1]
-		if len(argsStr) == 0 {
-			return nil, fmt.Errorf("`quantiles()` must contain at least one phi")
-		}
-		args := strings.Split(argsStr, ",")
-		phis := make([]float64, len(args))
-		for i, arg := range args {
-			arg = strings.TrimSpace(arg)
-			phi, err := strconv.ParseFloat(arg, 64)
-			if err != nil {
-				return nil, fmt.Errorf("cannot parse phi=%q for quantiles(%s): %w", arg, argsStr, err)
-			}
-			if phi < 0 || phi > 1 {
-				return nil, fmt.Errorf("phi inside quantiles(%s) must be in the range [0..1]; got %v", argsStr, phi)
-			}
-			phis[i] = phi
-		}
-		if _, ok := outputsSeen["quantiles"]; ok {
-			return nil, fmt.Errorf("`outputs` list contains duplicated `quantiles()` function, please combine multiple phi* like `quantiles(0.5, 0.9)`")
+		phis, err := parseQuantilesOutput(output, "quantiles", outputsSeen)
+		if err != nil {
+			return nil, err
 		}
-		outputsSeen["quantiles"] = struct{}{}
 		return newQuantilesAggrState(phis), nil
 	}
+	if strings.HasPrefix(output, "quantiles_sketch(") {
+		phis, err := parseQuantilesOutput(output, "quantiles_sketch", outputsSeen)
+		if err != nil {
+			return nil, err
+		}
+		return newQuantilesSketchAggrState(phis, emitSketches, mergeSketches), nil
+	}
 
 	switch output {
 	case "avg":
@@ -702,6 +728,8 @@ func newAggrState(output string, outputsSeen map[string]struct{}, stalenessInter
 		return newCountSamplesAggrState(), nil
 	case "count_series":
 		return newCountSeriesAggrState(), nil
+	case "count_series_sketch":
+		return newCountSeriesSketchAggrState(emitSketches, mergeSketches), nil
 	case "histogram_bucket":
 		return newHistogramBucketAggrState(stalenessInterval), nil
 	case "increase":
@@ -730,11 +758,52 @@ func newAggrState(output string, outputsSeen map[string]struct{}, stalenessInter
 		return newTotalAggrState(stalenessInterval, ignoreFirstSampleInterval, false, false), nil
 	case "unique_samples":
 		return newUniqueSamplesAggrState(), nil
+	case "unique_samples_sketch":
+		return newUniqueSamplesSketchAggrState(emitSketches, mergeSketches), nil
 	default:
 		return nil, fmt.Errorf("unsupported output=%q; supported values: %s; see https://docs.victoriametrics.com/stream-aggregation/", output, supportedOutputs)
 	}
 }
 
+// parseQuantilesOutput parses phis from the output in the form funcName(phi1, ..., phiN)
+func parseQuantilesOutput(output, funcName string, outputsSeen map[string]struct{}) ([]float64, error) {
+	if !strings.HasSuffix(output, ")") {
+		return nil, fmt.Errorf("missing closing brace for `%s()` output", funcName)
+	}
+	argsStr := output[len(funcName)+1 : len(output)-1]
+	if len(argsStr) == 0 {
+		return nil, fmt.Errorf("`%s()` must contain at least one phi", funcName)
+	}
+	args := strings.Split(argsStr, ",")
+	phis := make([]float64, len(args))
+	for i, arg := range args {
+		arg = strings.TrimSpace(arg)
+		phi, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse phi=%q for %s(%s): %w", arg, funcName, argsStr, err)
+		}
+		if phi < 0 || phi > 1 {
+			return nil, fmt.Errorf("phi inside %s(%s) must be in the range [0..1]; got %v", funcName, argsStr, phi)
+		}
+		phis[i] = phi
+	}
+	if _, ok := outputsSeen[funcName]; ok {
+		return nil, fmt.Errorf("`outputs` list contains duplicated `%s()` function, please combine multiple phi* like `%s(0.5, 0.9)`", funcName, funcName)
+	}
+	outputsSeen[funcName] = struct{}{}
+	return phis, nil
+}
+
+// isSketchOutput returns true if the given output is based on mergeable sketches.
+func isSketchOutput(output string) bool {
+	return strings.HasPrefix(output, "quantiles_sketch(") || output == "count_series_sketch" || output == "unique_samples_sketch"
+}
+
+// sketchLabels contains label names, which are used for emitting sketches.
+//
+// These labels are excluded from the output labels when merging sketches.
+var sketchLabels = []string{ddsketchBucketLabel, hllRegistersLabel}
+
 func (a *aggregator) runFlusher(pushFunc PushFunc, alignFlushToInterval, skipIncompleteFlush bool, ignoreFirstIntervals int) {
 	alignedSleep := func(d time.Duration) {
 		if !alignFlushToInterval {
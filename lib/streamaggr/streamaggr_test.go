@@ -193,6 +193,44 @@ func TestAggregatorsFailure(t *testing.T) {
 - interval: 1m
   outputs: ["quantiles(0.5)", "quantiles(0.9)"]
 `)
+
+	// Invalid quantiles_sketch()
+	f(`
+- interval: 1m
+  outputs: ["quantiles_sketch("]
+`)
+	f(`
+- interval: 1m
+  outputs: ["quantiles_sketch()"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["quantiles_sketch(1.5)"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["quantiles_sketch(0.5)", "quantiles_sketch(0.9)"]
+`)
+
+	// merge_sketches with non-sketch outputs
+	f(`
+- interval: 1m
+  merge_sketches: true
+  outputs: [count_series_sketch, sum_samples]
+`)
+
+	// keep_metric_names with emitted sketches
+	f(`
+- interval: 1m
+  emit_sketches: true
+  keep_metric_names: true
+  outputs: [count_series_sketch]
+`)
+	f(`
+- interval: 1m
+  keep_metric_names: true
+  outputs: ["quantiles_sketch(0.5, 0.9)"]
+`)
 }
 
 func TestAggregatorsEqual(t *testing.T) {
@@ -866,6 +904,106 @@ cpu_usage:1m_without_cpu_quantiles{quantile="0.5"} 13.3
 cpu_usage:1m_without_cpu_quantiles{quantile="1"} 90
 `, "1111111")
 
+	// quantiles_sketch output
+	f(`
+- interval: 1m
+  outputs: ["quantiles_sketch(0, 0.5, 1)"]
+`, `
+cpu_usage{cpu="1"} 12.5
+cpu_usage{cpu="1"} 13.3
+cpu_usage{cpu="1"} 13
+cpu_usage{cpu="1"} 12
+cpu_usage{cpu="1"} 14
+cpu_usage{cpu="1"} 25
+cpu_usage{cpu="2"} 0
+cpu_usage{cpu="2"} -90
+`, `cpu_usage:1m_quantiles_sketch{cpu="1",quantile="0"} 12.06167417903914
+cpu_usage:1m_quantiles_sketch{cpu="1",quantile="0.5"} 13.066290498147689
+cpu_usage:1m_quantiles_sketch{cpu="1",quantile="1"} 24.780498769903094
+cpu_usage:1m_quantiles_sketch{cpu="2",quantile="0"} -89.13032933635797
+cpu_usage:1m_quantiles_sketch{cpu="2",quantile="0.5"} -89.13032933635797
+cpu_usage:1m_quantiles_sketch{cpu="2",quantile="1"} 0
+`, "11111111")
+
+	// quantiles_sketch output with emitted sketches
+	f(`
+- interval: 1m
+  without: [cpu]
+  emit_sketches: true
+  outputs: ["quantiles_sketch(0.5)"]
+`, `
+cpu_usage{cpu="1"} 12.5
+cpu_usage{cpu="1"} 12.6
+cpu_usage{cpu="2"} 0
+cpu_usage{cpu="2"} -90
+`, `cpu_usage:1m_without_cpu_quantiles_sketch{vmrange="-9.003e+01...-8.825e+01"} 1
+cpu_usage:1m_without_cpu_quantiles_sketch{vmrange="0...0"} 1
+cpu_usage:1m_without_cpu_quantiles_sketch{vmrange="1.243e+01...1.268e+01"} 2
+`, "1111")
+
+	// quantiles_sketch output with merged sketches
+	f(`
+- interval: 1m
+  without: [instance]
+  merge_sketches: true
+  outputs: ["quantiles_sketch(0, 0.5, 1)"]
+`, `
+cpu_usage:1m_without_cpu_quantiles_sketch{instance="a",vmrange="-9.003e+01...-8.825e+01"} 1
+cpu_usage:1m_without_cpu_quantiles_sketch{instance="a",vmrange="0...0"} 1
+cpu_usage:1m_without_cpu_quantiles_sketch{instance="a",vmrange="1.243e+01...1.268e+01"} 2
+cpu_usage:1m_without_cpu_quantiles_sketch{instance="b",vmrange="1.243e+01...1.268e+01"} 3
+cpu_usage:1m_without_cpu_quantiles_sketch{instance="b",vmrange="foobar"} 3
+`, `cpu_usage:1m_without_cpu_quantiles_sketch:1m_without_instance_quantiles_sketch{quantile="0"} -89.13032933635797
+cpu_usage:1m_without_cpu_quantiles_sketch:1m_without_instance_quantiles_sketch{quantile="0.5"} 12.553937179918199
+cpu_usage:1m_without_cpu_quantiles_sketch:1m_without_instance_quantiles_sketch{quantile="1"} 12.553937179918199
+`, "11111")
+
+	// count_series_sketch and unique_samples_sketch outputs
+	f(`
+- interval: 1m
+  by: [host]
+  outputs: [count_series_sketch, unique_samples_sketch]
+`, `
+foo{host="a",cpu="1"} 1
+foo{host="a",cpu="2"} 1
+foo{host="a",cpu="3"} 2
+foo{host="b",cpu="1"} 3
+foo{host="b",cpu="1"} 4
+`, `foo:1m_by_host_count_series_sketch{host="a"} 3
+foo:1m_by_host_count_series_sketch{host="b"} 1
+foo:1m_by_host_unique_samples_sketch{host="a"} 2
+foo:1m_by_host_unique_samples_sketch{host="b"} 2
+`, "11111")
+
+	// unique_samples_sketch output with emitted sketches
+	f(`
+- interval: 1m
+  without: [cpu]
+  emit_sketches: true
+  outputs: [unique_samples_sketch]
+`, `
+foo{host="a",cpu="1"} 1
+foo{host="a",cpu="2"} 1
+foo{host="a",cpu="2"} 2
+`, `foo:1m_without_cpu_unique_samples_sketch{hll_registers="273",host="a"} 6
+foo:1m_without_cpu_unique_samples_sketch{hll_registers="297",host="a"} 128
+`, "111")
+
+	// count_series_sketch output with merged sketches
+	f(`
+- interval: 1m
+  without: [instance]
+  merge_sketches: true
+  outputs: [count_series_sketch]
+`, `
+foo:1m_without_cpu_count_series_sketch{instance="a",hll_registers="426"} 1073741824
+foo:1m_without_cpu_count_series_sketch{instance="a",hll_registers="461"} 16384
+foo:1m_without_cpu_count_series_sketch{instance="b",hll_registers="426"} 1073741824
+foo:1m_without_cpu_count_series_sketch{instance="b",hll_registers="10"} 3
+foo:1m_without_cpu_count_series_sketch{instance="b",hll_registers="100000"} 3
+`, `foo:1m_without_cpu_count_series_sketch:1m_without_instance_count_series_sketch 3
+`, "11111")
+
 	// append additional label
 	f(`
 - interval: 1m